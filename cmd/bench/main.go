@@ -0,0 +1,131 @@
+// Command bench runs the reconcile path benchmarks and reports regressions
+// against a baseline produced by a previous run.
+//
+//	go run ./cmd/bench -out bench_output.txt
+//	go run ./cmd/bench -baseline bench_output.txt -threshold 0.1
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var defaultPkgs = []string{
+	"./internal/queue",
+	"./core/worker/infomer",
+}
+
+func main() {
+	var (
+		pkgs      = flag.String("pkgs", strings.Join(defaultPkgs, ","), "comma separated packages to benchmark")
+		bench     = flag.String("bench", ".", "benchmark regexp passed to go test -bench")
+		count     = flag.Int("count", 5, "times to run each benchmark")
+		benchtime = flag.String("benchtime", "1s", "benchtime passed to go test")
+		out       = flag.String("out", "", "file to save raw output, used as baseline of next run")
+		baseline  = flag.String("baseline", "", "raw output of a previous run to compare with")
+		threshold = flag.Float64("threshold", 0.1, "max allowed ns/op increase ratio against baseline")
+	)
+	flag.Parse()
+
+	args := []string{"test", "-run", "^$", "-bench", *bench, "-benchmem",
+		"-count", strconv.Itoa(*count), "-benchtime", *benchtime}
+	args = append(args, strings.Split(*pkgs, ",")...)
+
+	var buf bytes.Buffer
+	cmd := exec.Command("go", args...)
+	cmd.Stdout = io.MultiWriter(os.Stdout, &buf)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "go test failed: %v\n", err)
+		os.Exit(1)
+	}
+	if *out != "" {
+		if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "write %s: %v\n", *out, err)
+			os.Exit(1)
+		}
+	}
+	if *baseline == "" {
+		return
+	}
+
+	raw, err := os.ReadFile(*baseline)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read baseline: %v\n", err)
+		os.Exit(1)
+	}
+	regressions := compare(parse(bytes.NewReader(raw)), parse(&buf), *threshold)
+	if len(regressions) > 0 {
+		fmt.Println("\nregressions:")
+		for _, r := range regressions {
+			fmt.Println("  " + r)
+		}
+		os.Exit(1)
+	}
+	fmt.Println("\nno regression")
+}
+
+// BenchmarkName-8   	     100	  12345 ns/op	  ...
+var benchLine = regexp.MustCompile(`^(Benchmark\S+?)(?:-\d+)?\s+\d+\s+([\d.]+) ns/op`)
+
+// parse returns mean ns/op of each benchmark.
+func parse(r io.Reader) map[string]float64 {
+	sum, cnt := map[string]float64{}, map[string]int{}
+	scanner := bufio.NewScanner(r)
+	pkg := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "pkg: ") {
+			pkg = strings.TrimPrefix(line, "pkg: ")
+			continue
+		}
+		m := benchLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		ns, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			continue
+		}
+		name := pkg + "." + m[1]
+		sum[name] += ns
+		cnt[name]++
+	}
+
+	ret := make(map[string]float64, len(sum))
+	for name, s := range sum {
+		ret[name] = s / float64(cnt[name])
+	}
+	return ret
+}
+
+func compare(base, cur map[string]float64, threshold float64) []string {
+	names := make([]string, 0, len(cur))
+	for name := range cur {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var regressions []string
+	for _, name := range names {
+		old, ok := base[name]
+		if !ok || old == 0 {
+			continue
+		}
+		delta := (cur[name] - old) / old
+		fmt.Printf("%-90s %14.0f -> %14.0f ns/op  %+6.1f%%\n", name, old, cur[name], delta*100)
+		if delta > threshold {
+			regressions = append(regressions, fmt.Sprintf("%s: %+.1f%%", name, delta*100))
+		}
+	}
+	return regressions
+}
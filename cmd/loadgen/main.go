@@ -0,0 +1,347 @@
+// Command loadgen creates synthetic tasks against a scheduler at a fixed rate,
+// waits for them to finish, then reports achieved throughput and latency
+// percentiles. tasks are run by test executors, workers of the target
+// deployment must register them by testexec.Register.
+//
+//	go run ./cmd/loadgen -addr http://scheduler:8080 -rate 50 -duration 1m -runtime 2s -failure-ratio 0.1
+//
+// mutual TLS is used if $MINITASKX_TLS_CERT, $MINITASKX_TLS_KEY and
+// $MINITASKX_TLS_CA are set, with https addresses.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/core/worker/executor/testexec"
+	"github.com/xyzbit/minitaskx/pkg/util/tlsutil"
+)
+
+// tasks listed per request while waiting for them to finish.
+const listPageSize = 500
+
+// client of all requests, mutual TLS if configured by env.
+var httpClient = http.DefaultClient
+
+type config struct {
+	addr, token  string
+	bizType      string
+	rate         float64
+	duration     time.Duration
+	concurrency  int
+	payloadSize  int
+	runtime      time.Duration
+	failureRatio float64
+	wait, poll   time.Duration
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.addr, "addr", "http://127.0.0.1:8080", "address of the scheduler")
+	flag.StringVar(&cfg.token, "token", os.Getenv("MINITASKX_TOKEN"), "bearer token, defaults to $MINITASKX_TOKEN")
+	flag.StringVar(&cfg.bizType, "biz-type", "loadgen", "biz type of created tasks")
+	flag.Float64Var(&cfg.rate, "rate", 10, "tasks created per second")
+	flag.DurationVar(&cfg.duration, "duration", time.Minute, "how long tasks are created")
+	flag.IntVar(&cfg.concurrency, "concurrency", 64, "max create requests in flight")
+	flag.IntVar(&cfg.payloadSize, "payload-size", 0, "bytes of padding added to payloads")
+	flag.DurationVar(&cfg.runtime, "runtime", time.Second, "how long each task runs")
+	flag.Float64Var(&cfg.failureRatio, "failure-ratio", 0, "share of tasks failing, in [0, 1]")
+	flag.DurationVar(&cfg.wait, "wait", 5*time.Minute, "max time waiting for tasks to finish after created")
+	flag.DurationVar(&cfg.poll, "poll", 2*time.Second, "interval of checking finished tasks")
+	flag.Parse()
+	if cfg.rate <= 0 || cfg.concurrency <= 0 || cfg.failureRatio < 0 || cfg.failureRatio > 1 {
+		fmt.Fprintln(os.Stderr, "rate and concurrency must be positive, failure-ratio in [0, 1]")
+		os.Exit(2)
+	}
+
+	if files, ok := tlsutil.FilesFromEnv(); ok {
+		r, err := tlsutil.NewReloader(files, 0)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		httpClient = r.HTTPClient(0)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, cfg); err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// payload is the spec of testexec, padded to the payload size.
+type payload struct {
+	testexec.Spec
+	Padding string `json:"padding,omitempty"`
+}
+
+func run(ctx context.Context, cfg config) error {
+	p, err := json.Marshal(payload{
+		Spec: testexec.Spec{
+			Duration:    cfg.runtime.String(),
+			Probability: cfg.failureRatio,
+			Message:     "failed by loadgen",
+		},
+		Padding: strings.Repeat("x", cfg.payloadSize),
+	})
+	if err != nil {
+		return err
+	}
+	// tasks of a run share the biz id, so that they are listed together.
+	runID := fmt.Sprintf("loadgen-%d", time.Now().UnixMilli())
+	fmt.Printf("run %s: %.1f tasks/s for %s, runtime %s, failure ratio %.2f, payload %d bytes\n",
+		runID, cfg.rate, cfg.duration, cfg.runtime, cfg.failureRatio, len(p))
+
+	g := generate(ctx, cfg, runID, string(p))
+	fmt.Printf("created %d tasks in %s, waiting for them to finish...\n", g.created, g.elapsed.Round(time.Millisecond))
+
+	tasks, err := waitFinished(ctx, cfg, runID, g.created)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	report(os.Stdout, cfg, g, tasks)
+	return nil
+}
+
+type generated struct {
+	created   int
+	errs      int
+	lastErr   error
+	elapsed   time.Duration
+	latencies []time.Duration
+}
+
+// generate creates tasks at cfg.rate until cfg.duration elapsed, the rate
+// achieved is lower if create requests are slower than concurrency allows.
+func generate(ctx context.Context, cfg config, runID, payload string) *generated {
+	ctx, cancel := context.WithTimeout(ctx, cfg.duration)
+	defer cancel()
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		g   = &generated{}
+		sem = make(chan struct{}, cfg.concurrency)
+	)
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.rate))
+	defer ticker.Stop()
+	start := time.Now()
+	for seq := 0; ; seq++ {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			g.elapsed = time.Since(start)
+			return g
+		case <-ticker.C:
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+
+		wg.Add(1)
+		go func(seq int) {
+			defer func() { <-sem; wg.Done() }()
+			begin := time.Now()
+			err := post(context.Background(), cfg.addr+"/v1/tasks/create", cfg.token, map[string]any{
+				"task_key": fmt.Sprintf("%s-%d", runID, seq),
+				"biz_id":   runID,
+				"biz_type": cfg.bizType,
+				"type":     testexec.TypeFlaky,
+				"payload":  payload,
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				g.errs++
+				g.lastErr = err
+				return
+			}
+			g.created++
+			g.latencies = append(g.latencies, time.Since(begin))
+		}(seq)
+	}
+}
+
+// waitFinished lists tasks of the run until want tasks finished or cfg.wait
+// elapsed, tasks listed last are returned.
+func waitFinished(ctx context.Context, cfg config, runID string, want int) ([]*model.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.wait)
+	defer cancel()
+	ticker := time.NewTicker(cfg.poll)
+	defer ticker.Stop()
+
+	var tasks []*model.Task
+	for {
+		listed, err := listTasks(ctx, cfg, runID)
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "list tasks failed: %v\n", err)
+		}
+		if err == nil {
+			tasks = listed
+		}
+		if finished(tasks) >= want {
+			return tasks, nil
+		}
+		select {
+		case <-ctx.Done():
+			return tasks, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func listTasks(ctx context.Context, cfg config, runID string) ([]*model.Task, error) {
+	var tasks []*model.Task
+	for offset := 0; ; offset += listPageSize {
+		q := url.Values{}
+		q.Set("biz_ids", runID)
+		q.Set("biz_type", cfg.bizType)
+		q.Set("limit", strconv.Itoa(listPageSize))
+		q.Set("offset", strconv.Itoa(offset))
+		var body struct {
+			Data []*model.Task `json:"data"`
+		}
+		if err := get(ctx, cfg.addr+"/v1/tasks/list?"+q.Encode(), cfg.token, &body); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, body.Data...)
+		if len(body.Data) < listPageSize {
+			return tasks, nil
+		}
+	}
+}
+
+func finished(tasks []*model.Task) int {
+	n := 0
+	for _, t := range tasks {
+		if t.Status.IsFinalStatus() {
+			n++
+		}
+	}
+	return n
+}
+
+func report(out io.Writer, cfg config, g *generated, tasks []*model.Task) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "\nCreated:\t%d (%.1f/s, target %.1f/s)\n", g.created, perSecond(g.created, g.elapsed), cfg.rate)
+	if g.errs > 0 {
+		fmt.Fprintf(w, "Create Errors:\t%d, last: %v\n", g.errs, g.lastErr)
+	}
+
+	var done []*model.Task
+	statuses := make(map[model.TaskStatus]int)
+	var first, last time.Time
+	for _, t := range tasks {
+		if !t.Status.IsFinalStatus() {
+			continue
+		}
+		done = append(done, t)
+		statuses[t.Status]++
+		if first.IsZero() || t.CreatedAt.Before(first) {
+			first = t.CreatedAt
+		}
+		if t.FinishedAt != nil && t.FinishedAt.After(last) {
+			last = *t.FinishedAt
+		}
+	}
+	fmt.Fprintf(w, "Finished:\t%d (%.1f/s), success %d, failed %d\n",
+		len(done), perSecond(len(done), last.Sub(first)), statuses[model.TaskStatusSuccess], statuses[model.TaskStatusFailed])
+	if unfinished := g.created - len(done); unfinished > 0 {
+		fmt.Fprintf(w, "Unfinished:\t%d after waiting %s\n", unfinished, cfg.wait)
+	}
+
+	latency := model.SummarizeLatency(done)
+	fmt.Fprintln(w, "\nLatency\tCount\tP50\tP90\tP99\tMax")
+	printPercentiles(w, "create request", percentiles(g.latencies))
+	printPercentiles(w, "queue wait", latency.QueueWait)
+	printPercentiles(w, "run duration", latency.RunDuration)
+	printPercentiles(w, "end to end", latency.EndToEnd)
+}
+
+func printPercentiles(w io.Writer, name string, p model.Percentiles) {
+	fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", name, p.Count,
+		p.P50.Round(time.Millisecond), p.P90.Round(time.Millisecond), p.P99.Round(time.Millisecond), p.Max.Round(time.Millisecond))
+}
+
+// percentiles of ds, the same as the latency summary of tasks.
+func percentiles(ds []time.Duration) model.Percentiles {
+	if len(ds) == 0 {
+		return model.Percentiles{}
+	}
+	ds = slices.Clone(ds)
+	slices.Sort(ds)
+	at := func(p float64) time.Duration { return ds[int(float64(len(ds)-1)*p)] }
+	return model.Percentiles{Count: len(ds), P50: at(0.5), P90: at(0.9), P99: at(0.99), Max: ds[len(ds)-1]}
+}
+
+func perSecond(n int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
+
+func post(ctx context.Context, url, token string, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return do(req, token, nil)
+}
+
+func get(ctx context.Context, url, token string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	return do(req, token, out)
+}
+
+// do sends req, non 200 responses are returned as error, otherwise the body
+// is decoded into out if not nil.
+func do(req *http.Request, token string, out any) error {
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
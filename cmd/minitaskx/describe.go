@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/scheduler"
+)
+
+func describe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("describe", flag.ExitOnError)
+	var (
+		addr   = fs.String("addr", "http://127.0.0.1:8080", "address of the scheduler")
+		token  = fs.String("token", os.Getenv("MINITASKX_TOKEN"), "bearer token, defaults to $MINITASKX_TOKEN")
+		output = fs.String("o", "", "output format, json prints the raw response")
+	)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: minitaskx describe [flags] <task_key>")
+	}
+
+	resp, err := get(ctx, *addr+"/v1/tasks/describe?task_key="+url.QueryEscape(fs.Arg(0)), *token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if *output == "json" {
+		_, err := io.Copy(os.Stdout, resp.Body)
+		return err
+	}
+	var body struct {
+		Data *scheduler.TaskDescription `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	}
+	printDescription(os.Stdout, body.Data, time.Now())
+	return nil
+}
+
+func printDescription(out io.Writer, d *scheduler.TaskDescription, now time.Time) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	t := d.Task
+	fmt.Fprintf(w, "Key:\t%s\n", t.TaskKey)
+	fmt.Fprintf(w, "Biz:\t%s/%s\n", t.BizType, t.BizID)
+	fmt.Fprintf(w, "Type:\t%s\n", t.Type)
+	fmt.Fprintf(w, "Status:\t%s (want %s)\n", t.Status, t.WantRunStatus)
+	fmt.Fprintf(w, "Worker:\t%s\n", orNone(t.WorkerID))
+	if p, from, ok := t.InheritedPriority(); ok {
+		fmt.Fprintf(w, "Priority:\t%d (inherited %d from %s)\n", t.Priority, p, from)
+	} else {
+		fmt.Fprintf(w, "Priority:\t%d\n", t.Priority)
+	}
+	fmt.Fprintf(w, "Retries:\t%d\n", t.Retries)
+	fmt.Fprintf(w, "Created:\t%s\n", formatTime(&t.CreatedAt))
+	fmt.Fprintf(w, "Started:\t%s\n", formatTime(t.StartedAt))
+	fmt.Fprintf(w, "Finished:\t%s\n", formatTime(t.FinishedAt))
+	fmt.Fprintf(w, "Last Error:\t%s\n", orNone(d.LastError))
+	if len(t.Labels) > 0 {
+		fmt.Fprintln(w, "Labels:")
+		for k, v := range t.Labels {
+			fmt.Fprintf(w, "  %s=%s\n", k, v)
+		}
+	}
+
+	fmt.Fprintln(w, "Conditions:\n  Type\tStatus\tSince\tReason")
+	for _, c := range d.Conditions {
+		fmt.Fprintf(w, "  %s\t%t\t%s\t%s\n", c.Type, c.Status, formatTime(c.Since), c.Reason)
+	}
+
+	if len(d.Attempts) > 0 {
+		fmt.Fprintln(w, "Attempts:\n  #\tWorker\tStatus\tStarted\tFinished\tError")
+		for _, a := range d.Attempts {
+			fmt.Fprintf(w, "  %d\t%s\t%s\t%s\t%s\t%s\n", a.Number, a.WorkerID, a.Status, formatTime(&a.StartedAt), formatTime(a.FinishedAt), a.Error)
+		}
+	}
+
+	if len(d.Audits) > 0 {
+		fmt.Fprintln(w, "Audits:\n  Time\tAction\tOperator\tChange\tReason")
+		for _, a := range d.Audits {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s -> %s\t%s\n", formatTime(&a.CreatedAt), a.Action, a.Operator, a.From, a.To, a.Reason)
+		}
+	}
+
+	if len(d.Events) == 0 {
+		fmt.Fprintln(w, "Events:\t<none>")
+		return
+	}
+	fmt.Fprintln(w, "Events:\n  Type\tReason\tAge\tFrom\tMessage")
+	for _, e := range d.Events {
+		age := now.Sub(e.LastAt).Truncate(time.Second).String()
+		if e.Count > 1 {
+			age += fmt.Sprintf(" (x%d over %s)", e.Count, e.LastAt.Sub(e.FirstAt).Truncate(time.Second))
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", e.Type, e.Reason, age, e.Source, e.Message)
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "<none>"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,127 @@
+// Command minitaskx is the command line client of minitaskx.
+//
+//	minitaskx logs -addr http://worker:8080 -tail 100 -f <task_key>
+//	minitaskx describe -addr http://scheduler:8080 <task_key>
+//
+// mutual TLS is used if $MINITASKX_TLS_CERT, $MINITASKX_TLS_KEY and
+// $MINITASKX_TLS_CA are set, with https addresses.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"github.com/xyzbit/minitaskx/core/components/tasklog"
+	"github.com/xyzbit/minitaskx/pkg/util/tlsutil"
+)
+
+// client of all requests, mutual TLS if configured by env.
+var httpClient = http.DefaultClient
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if files, ok := tlsutil.FilesFromEnv(); ok {
+		r, err := tlsutil.NewReloader(files, 0)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		httpClient = r.HTTPClient(0)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "logs":
+		err = logs(ctx, os.Args[2:])
+	case "describe":
+		err = describe(ctx, os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: minitaskx <command> [flags]\n\ncommands:\n  logs      print output of a task captured by worker\n  describe  show spec, status, attempts, events and audits of a task")
+	os.Exit(2)
+}
+
+func logs(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	var (
+		addr   = fs.String("addr", "http://127.0.0.1:8080", "address of the worker running the task")
+		token  = fs.String("token", os.Getenv("MINITASKX_TOKEN"), "bearer token, defaults to $MINITASKX_TOKEN")
+		tail   = fs.Int("tail", 0, "number of lines to show from the end, 0 shows all")
+		follow = fs.Bool("f", false, "follow new output")
+	)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: minitaskx logs [flags] <task_key>")
+	}
+
+	q := url.Values{}
+	q.Set("task_key", fs.Arg(0))
+	q.Set("tail", strconv.Itoa(*tail))
+	q.Set("follow", strconv.FormatBool(*follow))
+	resp, err := get(ctx, *addr+"/v1/tasks/logs?"+q.Encode(), *token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var line tasklog.Line
+		if err := dec.Decode(&line); err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		out := os.Stdout
+		if line.Stream == tasklog.StreamStderr {
+			out = os.Stderr
+		}
+		fmt.Fprintln(out, line.Text)
+	}
+}
+
+// get sends a GET request, non 200 responses are returned as error.
+func get(ctx context.Context, url, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	return resp, nil
+}
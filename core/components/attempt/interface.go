@@ -0,0 +1,71 @@
+package attempt
+
+import (
+	"context"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// Attempt is one run of a task by an executor, eg. the first run and each retry.
+// it is stored in the task_attempt table of the backend, eg.
+//
+//	CREATE TABLE task_attempt (
+//	  task_key VARCHAR(64) NOT NULL,
+//	  number INT NOT NULL,
+//	  worker_id VARCHAR(128) NOT NULL,
+//	  status VARCHAR(32) NOT NULL,
+//	  error TEXT,
+//	  started_at DATETIME(3) NOT NULL,
+//	  finished_at DATETIME(3) NULL,
+//	  PRIMARY KEY (task_key, number)
+//	);
+type Attempt struct {
+	TaskKey string `json:"task_key"`
+	// starts from 1.
+	Number   int              `json:"number"`
+	WorkerID string           `json:"worker_id"`
+	Status   model.TaskStatus `json:"status"`
+	// error of the failed attempt.
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+func (a *Attempt) Finished() bool {
+	return a.FinishedAt != nil
+}
+
+type Interface interface {
+	// Save insert or update the attempt identified by task key and number.
+	Save(ctx context.Context, a *Attempt) error
+	// List returns attempts of taskKey ordered by number.
+	List(ctx context.Context, taskKey string) ([]*Attempt, error)
+	// Latest returns the attempt with the largest number, nil if none.
+	Latest(ctx context.Context, taskKey string) (*Attempt, error)
+}
+
+// Summary is the composite status of a task and its latest attempt.
+type Summary struct {
+	Status model.TaskStatus `json:"status"`
+	// number of attempts, 0 if never run.
+	Attempts int      `json:"attempts"`
+	Latest   *Attempt `json:"latest,omitempty"`
+	// latest attempt failed but the task is not final, a new attempt is expected.
+	Retrying bool `json:"retrying"`
+	// task failed and will not be retried.
+	PermanentFailure bool `json:"permanent_failure"`
+}
+
+// Summarize combines status of task with its latest attempt, latest may be nil.
+func Summarize(task *model.Task, latest *Attempt) Summary {
+	s := Summary{Status: task.Status, Latest: latest}
+	if latest == nil {
+		s.PermanentFailure = task.Status == model.TaskStatusFailed
+		return s
+	}
+	s.Attempts = latest.Number
+	s.Retrying = !task.Status.IsFinalStatus() && latest.Finished() && latest.Status == model.TaskStatusFailed
+	s.PermanentFailure = task.Status == model.TaskStatusFailed
+	return s
+}
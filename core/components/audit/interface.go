@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"context"
+	"time"
+)
+
+type Action string
+
+const (
+	ActionOperate  Action = "operate"
+	ActionDelete   Action = "delete"
+	ActionAssign   Action = "assign"
+	ActionUpdate   Action = "update_spec"
+	ActionRetry    Action = "retry"
+	ActionEvict    Action = "evict"
+	ActionApprove  Action = "approve"
+	ActionReject   Action = "reject"
+	ActionRerun    Action = "rerun"
+	ActionSchedule Action = "schedule_change"
+	ActionAnnotate Action = "annotate"
+
+	// privileged operations of admin.
+	ActionForceFinish   Action = "force_finish"
+	ActionForceReassign Action = "force_reassign"
+	ActionForceRelease  Action = "force_release"
+)
+
+// Entry records who changed the want status of a task.
+type Entry struct {
+	TaskKey string `json:"task_key"`
+	// principal requested the change, eg. user:alice, service:billing, system:scheduler.
+	Operator string `json:"operator"`
+	Action   Action `json:"action"`
+	// want status before and after the change.
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Interface interface {
+	Record(ctx context.Context, entry Entry) error
+	List(ctx context.Context, taskKey string) ([]Entry, error)
+}
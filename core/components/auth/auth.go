@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+type Role int
+
+const (
+	RoleViewer Role = iota + 1
+	RoleOperator
+	RoleAdmin
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleOperator:
+		return "operator"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "unknown"
+	}
+}
+
+type Principal struct {
+	Name string `json:"name"`
+	Role Role   `json:"role"`
+	// biz types the principal can access, empty means all.
+	BizTypes []string `json:"biz_types,omitempty"`
+}
+
+// Has reports whether principal's role is not lower than role.
+func (p *Principal) Has(role Role) bool {
+	return p.Role >= role
+}
+
+func (p *Principal) CanAccess(bizType string) bool {
+	return len(p.BizTypes) == 0 || slices.Contains(p.BizTypes, bizType)
+}
+
+// Operator returns the operator identity recorded in audit trail.
+func (p *Principal) Operator() string {
+	return "user:" + p.Name
+}
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+// StaticTokens authenticate by a fixed token table, token <==> principal.
+type StaticTokens map[string]Principal
+
+func (s StaticTokens) Authenticate(_ context.Context, token string) (*Principal, error) {
+	p, ok := s[token]
+	if !ok || token == "" {
+		return nil, ErrUnauthenticated
+	}
+	return &p, nil
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns principal of request, ok is false if auth is not enabled.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// CheckBizType returns error if the principal in ctx can not access bizType.
+func CheckBizType(ctx context.Context, bizType string) error {
+	p, ok := FromContext(ctx)
+	if !ok || p.CanAccess(bizType) {
+		return nil
+	}
+	return &ForbiddenError{Principal: p.Name, BizType: bizType}
+}
+
+type ForbiddenError struct {
+	Principal string
+	BizType   string
+}
+
+func (e *ForbiddenError) Error() string {
+	return "principal " + e.Principal + " can not access biz type " + e.BizType
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GinMiddleware authenticate bearer token and put principal into request context.
+func GinMiddleware(authenticator Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		p, err := authenticator.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.Request = c.Request.WithContext(WithPrincipal(c.Request.Context(), p))
+		c.Next()
+	}
+}
+
+// GinRequireRole reject request whose principal's role is lower than role.
+// it passes if auth is not enabled.
+func GinRequireRole(role Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := FromContext(c.Request.Context())
+		if ok && !p.Has(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "need role " + role.String()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// OperatorOf returns the authenticated principal as operator if auth is enabled,
+// client supplied operator can not be spoofed.
+func OperatorOf(c *gin.Context, supplied string) string {
+	if p, ok := FromContext(c.Request.Context()); ok {
+		return p.Operator()
+	}
+	return supplied
+}
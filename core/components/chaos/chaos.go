@@ -0,0 +1,97 @@
+// Package chaos injects faults into the framework's dependencies, so that
+// executors and recovery paths of the framework can be validated in staging.
+// It must never be enabled in production.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+)
+
+// ErrInjected is returned by injected failures.
+var ErrInjected = errors.New("chaos: injected failure")
+
+// Config of fault injection, a zero rate disables the fault.
+type Config struct {
+	// seed of random source, fixed seed makes faults reproducible.
+	Seed uint64
+
+	// latency added to every recorder call, uniformly in [0, RecorderLatency).
+	RecorderLatency time.Duration
+	// probability of recorder calls returning ErrInjected.
+	RecorderErrorRate float64
+	// probability of dropping a watch event of runnable tasks.
+	DropWatchRate float64
+
+	// probability of loader List returning ErrInjected.
+	LoaderErrorRate float64
+
+	// probability of worker pausing PauseDuration before handling a change.
+	PauseRate     float64
+	PauseDuration time.Duration
+}
+
+type Injector struct {
+	cfg Config
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func New(cfg Config) *Injector {
+	return &Injector{
+		cfg:  cfg,
+		rand: rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)),
+	}
+}
+
+func (i *Injector) hit(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rand.Float64() < rate
+}
+
+func (i *Injector) delay(ctx context.Context, max time.Duration) {
+	if max <= 0 {
+		return
+	}
+	i.mu.Lock()
+	d := time.Duration(i.rand.Int64N(int64(max)))
+	i.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// recorderFault delays the call and decides whether it fails.
+func (i *Injector) recorderFault(ctx context.Context, op string) error {
+	i.delay(ctx, i.cfg.RecorderLatency)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if i.hit(i.cfg.RecorderErrorRate) {
+		log.Warn("[Chaos] inject recorder %s failure", op)
+		return ErrInjected
+	}
+	return nil
+}
+
+// Pause blocks PauseDuration with probability PauseRate, simulating a
+// worker stalled by GC, CPU throttling or a frozen VM.
+func (i *Injector) Pause() {
+	if i.cfg.PauseDuration <= 0 || !i.hit(i.cfg.PauseRate) {
+		return
+	}
+	log.Warn("[Chaos] pause worker %s", i.cfg.PauseDuration)
+	time.Sleep(i.cfg.PauseDuration)
+}
@@ -0,0 +1,53 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type fakeLoader struct{}
+
+func (fakeLoader) List(context.Context) ([]*model.Task, error) { return nil, nil }
+func (fakeLoader) ChangeResult() <-chan *model.Task            { return nil }
+
+func TestInjector(t *testing.T) {
+	t.Run("比例为 0 不注入", func(t *testing.T) {
+		l := New(Config{}).WrapLoader(fakeLoader{})
+		for i := 0; i < 100; i++ {
+			if _, err := l.List(context.Background()); err != nil {
+				t.Fatalf("不应该注入错误, 得到 %v", err)
+			}
+		}
+	})
+
+	t.Run("按比例注入且相同种子可复现", func(t *testing.T) {
+		run := func() []bool {
+			l := New(Config{Seed: 42, LoaderErrorRate: 0.5}).WrapLoader(fakeLoader{})
+			ret := make([]bool, 0, 1000)
+			for i := 0; i < 1000; i++ {
+				_, err := l.List(context.Background())
+				if err != nil && !errors.Is(err, ErrInjected) {
+					t.Fatalf("期望 ErrInjected, 得到 %v", err)
+				}
+				ret = append(ret, err != nil)
+			}
+			return ret
+		}
+		first, second := run(), run()
+		failed := 0
+		for i := range first {
+			if first[i] != second[i] {
+				t.Fatal("相同种子注入结果应该一致")
+			}
+			if first[i] {
+				failed++
+			}
+		}
+		if failed < 400 || failed > 600 {
+			t.Errorf("期望约 500 次失败, 得到 %d", failed)
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package chaos
+
+import (
+	"context"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// Loader loads real tasks from executors.
+type Loader interface {
+	List(ctx context.Context) ([]*model.Task, error)
+	ChangeResult() <-chan *model.Task
+}
+
+// WrapLoader makes List of loader fail with probability LoaderErrorRate.
+func (i *Injector) WrapLoader(l Loader) Loader {
+	return &loaderWrapper{Loader: l, i: i}
+}
+
+type loaderWrapper struct {
+	Loader
+	i *Injector
+}
+
+func (l *loaderWrapper) List(ctx context.Context) ([]*model.Task, error) {
+	if l.i.hit(l.i.cfg.LoaderErrorRate) {
+		log.Warn("[Chaos] inject loader List failure")
+		return nil, ErrInjected
+	}
+	return l.Loader.List(ctx)
+}
@@ -0,0 +1,68 @@
+package chaos
+
+import (
+	"context"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// WrapRepo injects latency and failures into reads and writes of the
+// worker's recorder, and drops watch events of runnable tasks.
+func (i *Injector) WrapRepo(repo taskrepo.Interface) taskrepo.Interface {
+	return &repoWrapper{Interface: repo, i: i}
+}
+
+type repoWrapper struct {
+	taskrepo.Interface
+	i *Injector
+}
+
+func (r *repoWrapper) UpdateTask(ctx context.Context, task *model.Task) error {
+	if err := r.i.recorderFault(ctx, "UpdateTask"); err != nil {
+		return err
+	}
+	return r.Interface.UpdateTask(ctx, task)
+}
+
+func (r *repoWrapper) BatchUpdateTasks(ctx context.Context, tasks []*model.Task) error {
+	if err := r.i.recorderFault(ctx, "BatchUpdateTasks"); err != nil {
+		return err
+	}
+	return r.Interface.BatchUpdateTasks(ctx, tasks)
+}
+
+func (r *repoWrapper) BatchGetTask(ctx context.Context, taskKeys []string) ([]*model.Task, error) {
+	if err := r.i.recorderFault(ctx, "BatchGetTask"); err != nil {
+		return nil, err
+	}
+	return r.Interface.BatchGetTask(ctx, taskKeys)
+}
+
+func (r *repoWrapper) ListRunnableTasks(ctx context.Context, workerID string) ([]string, error) {
+	if err := r.i.recorderFault(ctx, "ListRunnableTasks"); err != nil {
+		return nil, err
+	}
+	return r.Interface.ListRunnableTasks(ctx, workerID)
+}
+
+func (r *repoWrapper) WatchRunnableTasks(ctx context.Context, workerID string) (<-chan []string, error) {
+	ch, err := r.Interface.WatchRunnableTasks(ctx, workerID)
+	if err != nil || r.i.cfg.DropWatchRate <= 0 {
+		return ch, err
+	}
+
+	out := make(chan []string, cap(ch))
+	go func() {
+		defer close(out)
+		for keys := range ch {
+			if r.i.hit(r.i.cfg.DropWatchRate) {
+				log.Warn("[Chaos] drop watch event: %v", keys)
+				continue
+			}
+			out <- keys
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,75 @@
+package crash
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+)
+
+const reportTimeout = 10 * time.Second
+
+// Report is a panic recovered by a component of worker.
+type Report struct {
+	// goroutine the panic is recovered in, eg. infomer, worker.change.
+	Component string `json:"component"`
+	// task implicated, empty if none.
+	TaskKey string    `json:"task_key,omitempty"`
+	Panic   string    `json:"panic"`
+	Stack   string    `json:"stack"`
+	At      time.Time `json:"at"`
+}
+
+// Reporter sends crash reports to external systems, eg. Sentry.
+type Reporter interface {
+	Report(ctx context.Context, r Report) error
+}
+
+// Recover recovers a panic of the goroutine, logs and reports it to reporter
+// if not nil, then calls onPanic with the panic as error if not nil.
+// it must be deferred directly, eg.
+//
+//	defer crash.Recover(reporter, "infomer", task.TaskKey, nil)
+func Recover(reporter Reporter, component, taskKey string, onPanic func(err error)) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	r := Report{
+		Component: component,
+		TaskKey:   taskKey,
+		Panic:     fmt.Sprint(v),
+		Stack:     string(debug.Stack()),
+		At:        time.Now(),
+	}
+	log.Error("[Crash] %s panic, task: %q, panic: %s\n%s", component, taskKey, r.Panic, r.Stack)
+	if reporter != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+		defer cancel()
+		if err := reporter.Report(ctx, r); err != nil {
+			log.Error("[Crash] report %s panic failed: %v", component, err)
+		}
+	}
+	if onPanic != nil {
+		onPanic(fmt.Errorf("panic: %s", r.Panic))
+	}
+}
+
+// Go runs fn in a goroutine and runs it again after it panics, after delay,
+// for loops of long-lived components. fn returning normally is not rerun.
+func Go(reporter Reporter, component string, delay time.Duration, fn func()) {
+	go func() {
+		for panicked := true; panicked; {
+			panicked = func() (panicked bool) {
+				defer Recover(reporter, component, "", func(error) { panicked = true })
+				fn()
+				return false
+			}()
+			if panicked {
+				time.Sleep(delay)
+			}
+		}
+	}()
+}
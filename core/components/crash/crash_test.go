@@ -0,0 +1,75 @@
+package crash
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memReporter struct {
+	mu      sync.Mutex
+	reports []Report
+}
+
+func (r *memReporter) Report(_ context.Context, c Report) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, c)
+	return nil
+}
+
+func (r *memReporter) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.reports)
+}
+
+func TestRecover(t *testing.T) {
+	t.Run("上报 panic 并转为错误", func(t *testing.T) {
+		r := &memReporter{}
+		var got error
+		func() {
+			defer Recover(r, "worker.change", "t1", func(err error) { got = err })
+			panic("boom")
+		}()
+		if got == nil || got.Error() != "panic: boom" {
+			t.Errorf("错误不符: %v", got)
+		}
+		if r.len() != 1 || r.reports[0].TaskKey != "t1" || !strings.Contains(r.reports[0].Stack, "crash_test.go") {
+			t.Errorf("上报不符: %+v", r.reports)
+		}
+	})
+
+	t.Run("未 panic 不上报", func(t *testing.T) {
+		r := &memReporter{}
+		func() {
+			defer Recover(r, "worker", "", func(error) { t.Error("不应回调") })
+		}()
+		if r.len() != 0 {
+			t.Error("不应上报")
+		}
+	})
+
+	t.Run("循环 panic 后重新运行", func(t *testing.T) {
+		r := &memReporter{}
+		done := make(chan struct{})
+		runs := 0
+		Go(r, "infomer", time.Millisecond, func() {
+			runs++
+			if runs < 3 {
+				panic("again")
+			}
+			close(done)
+		})
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("应重新运行")
+		}
+		if r.len() != 2 {
+			t.Errorf("应上报 2 次, 得到 %d", r.len())
+		}
+	})
+}
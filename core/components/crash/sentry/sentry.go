@@ -0,0 +1,64 @@
+package sentry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/crash"
+	"github.com/xyzbit/minitaskx/core/components/notify"
+)
+
+// Reporter sends crash reports to Sentry as error events by the store API.
+type Reporter struct {
+	storeURL    string
+	auth        string
+	environment string
+}
+
+var _ crash.Reporter = (*Reporter)(nil)
+
+// New returns a Reporter of the project of dsn, eg. https://key@o1.ingest.sentry.io/2.
+func New(dsn, environment string) (*Reporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid sentry dsn")
+	}
+	projectID := strings.TrimPrefix(u.Path, "/")
+	if u.User == nil || u.User.Username() == "" || projectID == "" {
+		return nil, errors.Errorf("invalid sentry dsn: %s", dsn)
+	}
+	return &Reporter{
+		storeURL:    fmt.Sprintf("%s://%s/api/%s/store/", u.Scheme, u.Host, projectID),
+		auth:        "Sentry sentry_version=7, sentry_client=minitaskx/1.0, sentry_key=" + u.User.Username(),
+		environment: environment,
+	}, nil
+}
+
+func (r *Reporter) Report(ctx context.Context, c crash.Report) error {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	tags := map[string]string{"component": c.Component}
+	if c.TaskKey != "" {
+		tags["task_key"] = c.TaskKey
+	}
+	event := map[string]any{
+		"event_id":    hex.EncodeToString(id),
+		"timestamp":   c.At.UTC().Format("2006-01-02T15:04:05.000Z"),
+		"level":       "fatal",
+		"logger":      "minitaskx",
+		"platform":    "go",
+		"environment": r.environment,
+		"message":     fmt.Sprintf("%s panic: %s", c.Component, c.Panic),
+		"tags":        tags,
+		"extra":       map[string]string{"stack": c.Stack},
+	}
+	return notify.PostJSON(ctx, nil, r.storeURL, event, map[string]string{"X-Sentry-Auth": r.auth})
+}
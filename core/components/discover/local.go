@@ -0,0 +1,103 @@
+package discover
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Local discovers instances registered in the same process, eg. the
+// scheduler and worker of standalone mode.
+type Local struct {
+	mu          sync.Mutex
+	instances   []Instance
+	subscribers []func(services []Instance, err error)
+}
+
+var _ Interface = (*Local)(nil)
+
+func NewLocal() *Local {
+	return &Local{}
+}
+
+func (l *Local) GetAvailableInstances() ([]Instance, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.available(), nil
+}
+
+// UpdateInstance replaces the registered instance of the same ip and port.
+func (l *Local) UpdateInstance(i Instance) error {
+	l.mu.Lock()
+	idx := l.index(i)
+	if idx < 0 {
+		l.mu.Unlock()
+		return fmt.Errorf("instance %s:%d not registered", i.Ip, i.Port)
+	}
+	i.InstanceId = l.instances[idx].InstanceId
+	l.instances[idx] = i
+	l.notify()
+	return nil
+}
+
+// Subscribe calls callback with available instances now and once they change.
+func (l *Local) Subscribe(callback func(services []Instance, err error)) error {
+	l.mu.Lock()
+	l.subscribers = append(l.subscribers, callback)
+	services := l.available()
+	l.mu.Unlock()
+	callback(services, nil)
+	return nil
+}
+
+func (l *Local) Register(i Instance) (bool, error) {
+	l.mu.Lock()
+	if i.InstanceId == "" {
+		i.InstanceId = fmt.Sprintf("%s:%d", i.Ip, i.Port)
+	}
+	if idx := l.index(i); idx >= 0 {
+		l.instances[idx] = i
+	} else {
+		l.instances = append(l.instances, i)
+	}
+	l.notify()
+	return true, nil
+}
+
+func (l *Local) UnRegister(i Instance) (bool, error) {
+	l.mu.Lock()
+	idx := l.index(i)
+	if idx < 0 {
+		l.mu.Unlock()
+		return false, nil
+	}
+	l.instances = slices.Delete(l.instances, idx, idx+1)
+	l.notify()
+	return true, nil
+}
+
+func (l *Local) index(i Instance) int {
+	return slices.IndexFunc(l.instances, func(r Instance) bool { return r.Ip == i.Ip && r.Port == i.Port })
+}
+
+// available returns copies of healthy and enabled instances, l.mu is held.
+func (l *Local) available() []Instance {
+	ret := make([]Instance, 0, len(l.instances))
+	for _, i := range l.instances {
+		if i.Healthy && i.Enable {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// notify unlocks l.mu, then calls subscribers outside of the lock, since
+// they may call l back.
+func (l *Local) notify() {
+	services := l.available()
+	subscribers := slices.Clone(l.subscribers)
+	l.mu.Unlock()
+	for _, callback := range subscribers {
+		callback(services, nil)
+	}
+}
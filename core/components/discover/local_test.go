@@ -0,0 +1,44 @@
+package discover
+
+import "testing"
+
+func TestLocal(t *testing.T) {
+	l := NewLocal()
+	var notified [][]Instance
+	l.Subscribe(func(services []Instance, err error) { notified = append(notified, services) })
+
+	t.Run("注册后通知订阅者", func(t *testing.T) {
+		l.Register(Instance{Ip: "127.0.0.1", Port: 8080, Healthy: true, Enable: true, Metadata: map[string]string{"worker_id": "w1"}})
+		got, _ := l.GetAvailableInstances()
+		if len(got) != 1 || got[0].ID() != "w1" || got[0].InstanceId == "" {
+			t.Fatalf("期望 w1 可用, 得到 %+v", got)
+		}
+		if len(notified) != 2 || len(notified[1]) != 1 {
+			t.Fatalf("期望订阅时和注册后各通知一次, 得到 %v", notified)
+		}
+	})
+
+	t.Run("禁用的实例不可用", func(t *testing.T) {
+		if err := l.UpdateInstance(Instance{Ip: "127.0.0.1", Port: 8080, Healthy: true}); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := l.GetAvailableInstances(); len(got) != 0 {
+			t.Fatalf("期望没有可用实例, 得到 %+v", got)
+		}
+	})
+
+	t.Run("更新未注册的实例失败", func(t *testing.T) {
+		if err := l.UpdateInstance(Instance{Ip: "127.0.0.1", Port: 9090}); err == nil {
+			t.Fatal("期望返回错误")
+		}
+	})
+
+	t.Run("注销", func(t *testing.T) {
+		if ok, _ := l.UnRegister(Instance{Ip: "127.0.0.1", Port: 8080}); !ok {
+			t.Fatal("期望注销成功")
+		}
+		if ok, _ := l.UnRegister(Instance{Ip: "127.0.0.1", Port: 8080}); ok {
+			t.Fatal("重复注销应返回 false")
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package election
+
+import "time"
+
+// Local elects the only scheduler of the process as leader, eg. standalone mode.
+type Local struct {
+	leader LeaderElection
+}
+
+var _ Interface = (*Local)(nil)
+
+func NewLocal(id string) *Local {
+	return &Local{leader: LeaderElection{MasterID: id}}
+}
+
+func (l *Local) Leader() (*LeaderElection, error) {
+	leader := l.leader
+	leader.LastSeenActive = time.Now()
+	return &leader, nil
+}
+
+func (l *Local) AmILeader(leader *LeaderElection) bool {
+	return leader != nil && leader.MasterID == l.leader.MasterID
+}
+
+// AttemptElection returns at once, the scheduler is always the leader.
+func (l *Local) AttemptElection() {}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"context"
+	"time"
+)
+
+type Type string
+
+const (
+	TypeNormal  Type = "Normal"
+	TypeWarning Type = "Warning"
+)
+
+// Reason is a short, machine readable description of why the event happened.
+type Reason string
+
+const (
+	ReasonScheduled Reason = "Scheduled"
+	ReasonStarted   Reason = "Started"
+	ReasonBackOff   Reason = "BackOff"
+	ReasonPreempted Reason = "Preempted"
+	ReasonEvicted   Reason = "Evicted"
+	ReasonFinished  Reason = "Finished"
+
+	// status change of a task is left to the system, see infomer.WithAutoFinishGrace.
+	ReasonAutoFinished Reason = "AutoFinished"
+	// a scheduled want status change is applied or dropped.
+	ReasonScheduledChange Reason = "ScheduledChange"
+	// the executor of a task did not start in time, see worker.WithStartTimeout.
+	ReasonStartTimeout Reason = "StartTimeout"
+	// an executor rejected a change of a task permanently, see executor.Reject.
+	ReasonRejected Reason = "Rejected"
+	// a task waited without assignment longer than the starvation threshold.
+	ReasonStarving Reason = "Starving"
+	// a task inherited or gave back priority of a task depending on it, see model.GateTask.
+	ReasonPriorityInherited Reason = "PriorityInherited"
+	// retries of the biz type of a failed task used up its retry budget, see scheduler.WithRetryBudget.
+	ReasonRetryBudgetExceeded Reason = "RetryBudgetExceeded"
+)
+
+// Event is something happened to a task, like events of kubernetes objects.
+// repeated events of the same task, source, reason and message are
+// aggregated into one event by increasing Count.
+type Event struct {
+	TaskKey string `json:"task_key"`
+	Type    Type   `json:"type"`
+	Reason  Reason `json:"reason"`
+	Message string `json:"message"`
+	// component emitted the event, eg. scheduler, worker:w1.
+	Source  string    `json:"source"`
+	Count   int       `json:"count"`
+	FirstAt time.Time `json:"first_at"`
+	LastAt  time.Time `json:"last_at"`
+}
+
+type Interface interface {
+	// Record stores the event, FirstAt and LastAt default to now.
+	Record(ctx context.Context, e Event) error
+	// List returns unexpired events of taskKey ordered by LastAt.
+	List(ctx context.Context, taskKey string) ([]Event, error)
+}
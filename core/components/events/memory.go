@@ -0,0 +1,94 @@
+package events
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTTL = time.Hour
+	pruneEvery = 1000
+)
+
+// Memory keeps events within the process for ttl, only suitable for a single
+// scheduler or tests, events are lost on restart.
+type Memory struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	events  map[string][]*Event
+	records int
+}
+
+// NewMemory creates Memory keeping events for ttl after they last happened, ttl <= 0 means DefaultTTL.
+func NewMemory(ttl time.Duration) *Memory {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Memory{ttl: ttl, events: make(map[string][]*Event)}
+}
+
+func (m *Memory) Record(_ context.Context, e Event) error {
+	now := time.Now()
+	if e.LastAt.IsZero() {
+		e.LastAt = now
+	}
+	if e.FirstAt.IsZero() {
+		e.FirstAt = e.LastAt
+	}
+	if e.Count <= 0 {
+		e.Count = 1
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records++
+	if m.records%pruneEvery == 0 {
+		for key := range m.events {
+			m.prune(key, now)
+		}
+	}
+
+	for _, old := range m.prune(e.TaskKey, now) {
+		if old.Source == e.Source && old.Reason == e.Reason && old.Message == e.Message && old.Type == e.Type {
+			old.Count += e.Count
+			old.LastAt = e.LastAt
+			return nil
+		}
+	}
+	m.events[e.TaskKey] = append(m.events[e.TaskKey], &e)
+	return nil
+}
+
+func (m *Memory) List(_ context.Context, taskKey string) ([]Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := m.prune(taskKey, time.Now())
+	ret := make([]Event, 0, len(events))
+	for _, e := range events {
+		ret = append(ret, *e)
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].LastAt.Before(ret[j].LastAt) })
+	return ret, nil
+}
+
+// prune drops expired events of taskKey and returns the rest.
+func (m *Memory) prune(taskKey string, now time.Time) []*Event {
+	events := m.events[taskKey]
+	kept := events[:0]
+	for _, e := range events {
+		if now.Sub(e.LastAt) < m.ttl {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == 0 {
+		delete(m.events, taskKey)
+		return nil
+	}
+	m.events[taskKey] = kept
+	return kept
+}
@@ -0,0 +1,38 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("重复事件聚合计数", func(t *testing.T) {
+		m := NewMemory(time.Hour)
+		for i := 0; i < 3; i++ {
+			_ = m.Record(ctx, Event{TaskKey: "t1", Reason: ReasonBackOff, Message: "back-off", Source: "scheduler"})
+		}
+		_ = m.Record(ctx, Event{TaskKey: "t1", Reason: ReasonScheduled, Message: "assigned", Source: "scheduler"})
+
+		got, _ := m.List(ctx, "t1")
+		if len(got) != 2 || got[0].Reason != ReasonBackOff || got[0].Count != 3 {
+			t.Fatalf("期望 BackOff 聚合为 3 次, 得到 %+v", got)
+		}
+	})
+
+	t.Run("过期事件不返回", func(t *testing.T) {
+		m := NewMemory(time.Minute)
+		_ = m.Record(ctx, Event{TaskKey: "t1", Reason: ReasonStarted, LastAt: time.Now().Add(-2 * time.Minute)})
+		_ = m.Record(ctx, Event{TaskKey: "t1", Reason: ReasonFinished})
+
+		got, _ := m.List(ctx, "t1")
+		if len(got) != 1 || got[0].Reason != ReasonFinished {
+			t.Fatalf("期望只剩 Finished, 得到 %+v", got)
+		}
+		if got, _ := m.List(ctx, "t2"); len(got) != 0 {
+			t.Fatalf("期望无事件, 得到 %+v", got)
+		}
+	})
+}
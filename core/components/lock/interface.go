@@ -0,0 +1,16 @@
+package lock
+
+import "context"
+
+// Interface is a distributed lock without expiration, locks are held by owner
+// until unlocked, eg. a task holding its exclusion group until it finishes.
+// implementations may be backed by redis, etcd or a database row.
+type Interface interface {
+	// TryLock acquires key for owner without blocking,
+	// acquired is true if key is free or already held by owner.
+	TryLock(ctx context.Context, key, owner string) (acquired bool, err error)
+	// Unlock releases key if it's held by owner.
+	Unlock(ctx context.Context, key, owner string) error
+	// Owner returns the owner holding key, empty if key is free.
+	Owner(ctx context.Context, key string) (string, error)
+}
@@ -0,0 +1,42 @@
+package lock
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory is a lock within the process, only suitable for a single scheduler,
+// locks are lost on restart.
+type Memory struct {
+	mu     sync.Mutex
+	owners map[string]string
+}
+
+func NewMemory() *Memory {
+	return &Memory{owners: make(map[string]string)}
+}
+
+func (m *Memory) TryLock(_ context.Context, key, owner string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cur, ok := m.owners[key]; ok && cur != owner {
+		return false, nil
+	}
+	m.owners[key] = owner
+	return true, nil
+}
+
+func (m *Memory) Unlock(_ context.Context, key, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.owners[key] == owner {
+		delete(m.owners, key)
+	}
+	return nil
+}
+
+func (m *Memory) Owner(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.owners[key], nil
+}
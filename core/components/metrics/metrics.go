@@ -0,0 +1,103 @@
+package metrics
+
+import "sync"
+
+// Counter represents a single numerical value that only ever goes up.
+type Counter interface {
+	Add(v float64, labelValues ...string)
+}
+
+// Gauge represents a single numerical value that can arbitrarily go up and down.
+type Gauge interface {
+	Set(v float64, labelValues ...string)
+}
+
+// Histogram counts individual observations.
+type Histogram interface {
+	Observe(v float64, labelValues ...string)
+}
+
+// Provider generates metrics, eg. prometheus.
+// labelValues of metric methods must match labelNames in order.
+type Provider interface {
+	NewCounter(name, help string, labelNames ...string) Counter
+	NewGauge(name, help string, labelNames ...string) Gauge
+	NewHistogram(name, help string, labelNames ...string) Histogram
+}
+
+type noopMetric struct{}
+
+func (noopMetric) Add(float64, ...string)     {}
+func (noopMetric) Set(float64, ...string)     {}
+func (noopMetric) Observe(float64, ...string) {}
+
+type noopProvider struct{}
+
+func (noopProvider) NewCounter(string, string, ...string) Counter     { return noopMetric{} }
+func (noopProvider) NewGauge(string, string, ...string) Gauge         { return noopMetric{} }
+func (noopProvider) NewHistogram(string, string, ...string) Histogram { return noopMetric{} }
+
+var (
+	_globalMu       sync.RWMutex
+	_globalProvider Provider = noopProvider{}
+)
+
+// SetProvider sets the metrics provider for all subsequently created metrics.
+func SetProvider(p Provider) {
+	_globalMu.Lock()
+	defer _globalMu.Unlock()
+	_globalProvider = p
+}
+
+func Global() Provider {
+	_globalMu.RLock()
+	defer _globalMu.RUnlock()
+	return _globalProvider
+}
+
+// Labeled returns a provider adding label name of value to every metric of p,
+// eg. worker_id telling apart metrics of workers embedded in one process.
+func Labeled(p Provider, name, value string) Provider {
+	return labeledProvider{p: p, name: name, value: value}
+}
+
+type labeledProvider struct {
+	p           Provider
+	name, value string
+}
+
+func (l labeledProvider) NewCounter(name, help string, labelNames ...string) Counter {
+	return labeledMetric{c: l.p.NewCounter(name, help, l.labelNames(labelNames)...), value: l.value}
+}
+
+func (l labeledProvider) NewGauge(name, help string, labelNames ...string) Gauge {
+	return labeledMetric{g: l.p.NewGauge(name, help, l.labelNames(labelNames)...), value: l.value}
+}
+
+func (l labeledProvider) NewHistogram(name, help string, labelNames ...string) Histogram {
+	return labeledMetric{h: l.p.NewHistogram(name, help, l.labelNames(labelNames)...), value: l.value}
+}
+
+func (l labeledProvider) labelNames(names []string) []string {
+	return append([]string{l.name}, names...)
+}
+
+// labeledMetric prepends value to label values of the wrapped metric.
+type labeledMetric struct {
+	c     Counter
+	g     Gauge
+	h     Histogram
+	value string
+}
+
+func (m labeledMetric) Add(v float64, labelValues ...string) {
+	m.c.Add(v, append([]string{m.value}, labelValues...)...)
+}
+
+func (m labeledMetric) Set(v float64, labelValues ...string) {
+	m.g.Set(v, append([]string{m.value}, labelValues...)...)
+}
+
+func (m labeledMetric) Observe(v float64, labelValues ...string) {
+	m.h.Observe(v, append([]string{m.value}, labelValues...)...)
+}
@@ -0,0 +1,72 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/xyzbit/minitaskx/core/components/notify"
+)
+
+type Config struct {
+	// smtp server address, eg. smtp.example.com:587
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Notifier send message by SMTP.
+type Notifier struct {
+	cfg  Config
+	auth smtp.Auth
+}
+
+var _ notify.Interface = (*Notifier)(nil)
+
+func New(cfg Config) *Notifier {
+	n := &Notifier{cfg: cfg}
+	if cfg.Username != "" {
+		host, _, _ := net.SplitHostPort(cfg.Addr)
+		n.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	return n
+}
+
+func (n *Notifier) Notify(ctx context.Context, msg notify.Message) error {
+	if len(n.cfg.To) == 0 {
+		return fmt.Errorf("email receivers is empty")
+	}
+
+	body := msg.Text
+	if msg.Link != "" {
+		body += "\r\n\r\n" + msg.Link
+	}
+	content := strings.Join([]string{
+		"From: " + n.cfg.From,
+		"To: " + strings.Join(n.cfg.To, ","),
+		// encode the subject so that non-ASCII titles are readable and line
+		// breaks in titles can not inject headers.
+		"Subject: " + mime.QEncoding.Encode("utf-8", fmt.Sprintf("[%s] %s", msg.Level, msg.Title)),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	// net/smtp has no context support, run it async so ctx cancel is respected.
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- smtp.SendMail(n.cfg.Addr, n.auth, n.cfg.From, n.cfg.To, []byte(content))
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-errCh:
+		return err
+	}
+}
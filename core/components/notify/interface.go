@@ -0,0 +1,47 @@
+package notify
+
+import "context"
+
+type Level string
+
+const (
+	LevelInfo     Level = "info"
+	LevelWarning  Level = "warning"
+	LevelCritical Level = "critical"
+)
+
+type Message struct {
+	Level   Level  `json:"level"`
+	Title   string `json:"title"`
+	Text    string `json:"text"`
+	TaskKey string `json:"task_key"`
+	BizID   string `json:"biz_id"`
+	BizType string `json:"biz_type"`
+	Status  string `json:"status"`
+	// error message of task.
+	Msg string `json:"msg"`
+	// deep link to the task detail page.
+	Link string `json:"link,omitempty"`
+}
+
+// Interface send notification or alert to external systems.
+type Interface interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+type multi []Interface
+
+// Multi notify all notifiers and return the first error.
+func Multi(notifiers ...Interface) Interface {
+	return multi(notifiers)
+}
+
+func (m multi) Notify(ctx context.Context, msg Message) error {
+	var firstErr error
+	for _, n := range m {
+		if err := n.Notify(ctx, msg); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,50 @@
+package pagerduty
+
+import (
+	"context"
+
+	"github.com/xyzbit/minitaskx/core/components/notify"
+)
+
+const eventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// Notifier trigger PagerDuty incident by Events API v2.
+type Notifier struct {
+	routingKey string
+}
+
+var _ notify.Interface = (*Notifier)(nil)
+
+func New(routingKey string) *Notifier {
+	return &Notifier{routingKey: routingKey}
+}
+
+func (n *Notifier) Notify(ctx context.Context, msg notify.Message) error {
+	severity := "warning"
+	switch msg.Level {
+	case notify.LevelCritical:
+		severity = "critical"
+	case notify.LevelInfo:
+		severity = "info"
+	}
+
+	event := map[string]any{
+		"routing_key":  n.routingKey,
+		"event_action": "trigger",
+		// same task only open one incident.
+		"dedup_key": msg.TaskKey + ":" + msg.Title,
+		"payload": map[string]any{
+			"summary":  msg.Title + ": " + msg.Text,
+			"source":   "minitaskx",
+			"severity": severity,
+			"custom_details": map[string]string{
+				"task_key": msg.TaskKey,
+				"biz_id":   msg.BizID,
+				"biz_type": msg.BizType,
+				"status":   msg.Status,
+				"msg":      msg.Msg,
+			},
+		},
+	}
+	return notify.PostJSON(ctx, nil, eventsURL, event, nil)
+}
@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+)
+
+// Rule config notifier and message templates of a biz type.
+// Templates are text/template executed with Message, eg. "task {{.TaskKey}} is {{.Status}}: {{.Msg}}".
+type Rule struct {
+	// empty means default rule for biz types without rule.
+	BizType  string
+	Notifier Interface
+	// empty means keep the original title/text.
+	TitleTemplate string
+	TextTemplate  string
+}
+
+type compiledRule struct {
+	notifier Interface
+	title    *template.Template
+	text     *template.Template
+}
+
+// Router route messages to notifiers by biz type and render templated messages.
+type Router struct {
+	link  *template.Template
+	rules map[string][]compiledRule
+}
+
+var _ Interface = (*Router)(nil)
+
+// NewRouter linkTemplate is used to render deep link of task, eg. "https://ops.example.com/tasks/{{.TaskKey}}".
+func NewRouter(linkTemplate string, rules ...Rule) (*Router, error) {
+	r := &Router{rules: make(map[string][]compiledRule)}
+	if linkTemplate != "" {
+		t, err := template.New("link").Parse(linkTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("parse link template: %w", err)
+		}
+		r.link = t
+	}
+
+	for _, rule := range rules {
+		if rule.Notifier == nil {
+			return nil, fmt.Errorf("notifier of biz type %q is nil", rule.BizType)
+		}
+		c := compiledRule{notifier: rule.Notifier}
+		if rule.TitleTemplate != "" {
+			t, err := template.New("title").Parse(rule.TitleTemplate)
+			if err != nil {
+				return nil, fmt.Errorf("parse title template of biz type %q: %w", rule.BizType, err)
+			}
+			c.title = t
+		}
+		if rule.TextTemplate != "" {
+			t, err := template.New("text").Parse(rule.TextTemplate)
+			if err != nil {
+				return nil, fmt.Errorf("parse text template of biz type %q: %w", rule.BizType, err)
+			}
+			c.text = t
+		}
+		r.rules[rule.BizType] = append(r.rules[rule.BizType], c)
+	}
+	return r, nil
+}
+
+func (r *Router) Notify(ctx context.Context, msg Message) error {
+	rules, ok := r.rules[msg.BizType]
+	if !ok {
+		rules = r.rules[""]
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	if r.link != nil && msg.Link == "" {
+		link, err := render(r.link, msg)
+		if err != nil {
+			return err
+		}
+		msg.Link = link
+	}
+
+	var firstErr error
+	for _, rule := range rules {
+		m := msg
+		if err := rule.render(&m); err != nil {
+			return err
+		}
+		if err := rule.notifier.Notify(ctx, m); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (c compiledRule) render(msg *Message) error {
+	if c.title != nil {
+		title, err := render(c.title, *msg)
+		if err != nil {
+			return err
+		}
+		msg.Title = title
+	}
+	if c.text != nil {
+		text, err := render(c.text, *msg)
+		if err != nil {
+			return err
+		}
+		msg.Text = text
+	}
+	return nil
+}
+
+func render(t *template.Template, msg Message) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, msg); err != nil {
+		return "", fmt.Errorf("render template %s: %w", t.Name(), err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type recorder struct {
+	err  error
+	sent []Message
+}
+
+func (r *recorder) Notify(_ context.Context, msg Message) error {
+	r.sent = append(r.sent, msg)
+	return r.err
+}
+
+func TestRouter(t *testing.T) {
+	ctx := context.Background()
+	report, fallback, failing := &recorder{}, &recorder{}, &recorder{err: errors.New("down")}
+	r, err := NewRouter("https://ops.example.com/tasks/{{.TaskKey}}",
+		Rule{BizType: "report", Notifier: report, TitleTemplate: "report {{.TaskKey}} {{.Status}}", TextTemplate: "{{.Msg}}"},
+		Rule{BizType: "report", Notifier: failing},
+		Rule{Notifier: fallback},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("按业务类型路由并渲染模板", func(t *testing.T) {
+		err := r.Notify(ctx, Message{Title: "t", Text: "x", TaskKey: "k1", BizType: "report", Status: "failed", Msg: "boom"})
+		if err == nil {
+			t.Error("期望返回失败通知的错误")
+		}
+		if len(report.sent) != 1 || len(failing.sent) != 1 || len(fallback.sent) != 0 {
+			t.Fatalf("期望只通知 report 的规则, 得到 %d %d %d", len(report.sent), len(failing.sent), len(fallback.sent))
+		}
+		if m := report.sent[0]; m.Title != "report k1 failed" || m.Text != "boom" || m.Link != "https://ops.example.com/tasks/k1" {
+			t.Errorf("渲染错误: %+v", m)
+		}
+		if m := failing.sent[0]; m.Title != "t" || m.Text != "x" {
+			t.Errorf("无模板的规则应保留原消息: %+v", m)
+		}
+	})
+
+	t.Run("无规则的业务类型使用默认规则", func(t *testing.T) {
+		if err := r.Notify(ctx, Message{Title: "t", TaskKey: "k2", BizType: "email", Link: "https://other"}); err != nil {
+			t.Fatal(err)
+		}
+		if len(fallback.sent) != 1 || fallback.sent[0].Link != "https://other" {
+			t.Errorf("期望默认规则收到原链接, 得到 %+v", fallback.sent)
+		}
+	})
+
+	t.Run("无默认规则时忽略", func(t *testing.T) {
+		r, err := NewRouter("", Rule{BizType: "report", Notifier: report})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Notify(ctx, Message{BizType: "email"}); err != nil {
+			t.Errorf("期望忽略, 得到 %v", err)
+		}
+	})
+
+	t.Run("模板错误", func(t *testing.T) {
+		if _, err := NewRouter("", Rule{Notifier: report, TitleTemplate: "{{.Missing"}); err == nil {
+			t.Error("期望解析模板失败")
+		}
+		if _, err := NewRouter("", Rule{BizType: "report"}); err == nil {
+			t.Error("期望缺少 notifier 报错")
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xyzbit/minitaskx/core/components/notify"
+)
+
+// Notifier send message by slack incoming webhook.
+type Notifier struct {
+	webhookURL string
+}
+
+var _ notify.Interface = (*Notifier)(nil)
+
+func New(webhookURL string) *Notifier {
+	return &Notifier{webhookURL: webhookURL}
+}
+
+func (n *Notifier) Notify(ctx context.Context, msg notify.Message) error {
+	text := fmt.Sprintf("*[%s] %s*\n%s", msg.Level, msg.Title, msg.Text)
+	if msg.Link != "" {
+		text += fmt.Sprintf("\n<%s|%s>", msg.Link, msg.TaskKey)
+	}
+	return notify.PostJSON(ctx, nil, n.webhookURL, map[string]string{"text": text}, nil)
+}
@@ -0,0 +1,23 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/xyzbit/minitaskx/core/components/notify"
+)
+
+// Notifier post notify.Message as json to the url.
+type Notifier struct {
+	url     string
+	headers map[string]string
+}
+
+var _ notify.Interface = (*Notifier)(nil)
+
+func New(url string, headers map[string]string) *Notifier {
+	return &Notifier{url: url, headers: headers}
+}
+
+func (n *Notifier) Notify(ctx context.Context, msg notify.Message) error {
+	return notify.PostJSON(ctx, nil, n.url, msg, n.headers)
+}
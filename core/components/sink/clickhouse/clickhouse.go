@@ -0,0 +1,114 @@
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/sink"
+)
+
+// Sink write records to ClickHouse through HTTP interface with JSONEachRow format.
+// Table should have the same columns as sink.Record's json tags, eg.
+//
+//	CREATE TABLE task_finished (
+//	  task_key String, biz_id String, biz_type String, type String,
+//	  status String, worker_id String, msg String,
+//	  created_at DateTime64(3), started_at DateTime64(3), finished_at DateTime64(3),
+//	  duration_ms Int64, queue_wait_ms Int64
+//	) ENGINE = MergeTree ORDER BY (biz_type, finished_at);
+type Sink struct {
+	endpoint string
+	table    string
+	user     string
+	password string
+	cli      *http.Client
+}
+
+var _ sink.Interface = (*Sink)(nil)
+
+// NewSink endpoint eg. http://127.0.0.1:8123, table eg. task_finished or
+// analytics.task_finished, it is quoted so that it can not inject queries.
+func NewSink(endpoint, table, user, password string) *Sink {
+	return &Sink{
+		endpoint: endpoint,
+		table:    quoteTable(table),
+		user:     user,
+		password: password,
+		cli:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *Sink) Write(ctx context.Context, records []sink.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	for _, r := range records {
+		row := map[string]any{
+			"task_key":      r.TaskKey,
+			"biz_id":        r.BizID,
+			"biz_type":      r.BizType,
+			"type":          r.Type,
+			"status":        r.Status,
+			"worker_id":     r.WorkerID,
+			"msg":           r.Msg,
+			"created_at":    formatTime(r.CreatedAt),
+			"started_at":    formatTime(r.StartedAt),
+			"finished_at":   formatTime(r.FinishedAt),
+			"duration_ms":   r.DurationMs,
+			"queue_wait_ms": r.QueueWaitMs,
+		}
+		b, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		body.Write(b)
+		body.WriteByte('\n')
+	}
+
+	query := url.Values{}
+	query.Set("query", fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", s.table))
+	query.Set("date_time_input_format", "best_effort")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/?"+query.Encode(), &body)
+	if err != nil {
+		return err
+	}
+	if s.user != "" {
+		req.SetBasicAuth(s.user, s.password)
+	}
+
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("clickhouse insert failed, status: %d, body: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
+
+// quoteTable quotes each part of a possibly database qualified table name
+// as an identifier.
+func quoteTable(table string) string {
+	parts := strings.Split(table, ".")
+	for i, p := range parts {
+		parts[i] = "`" + identEscaper.Replace(p) + "`"
+	}
+	return strings.Join(parts, ".")
+}
+
+var identEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.000")
+}
@@ -0,0 +1,37 @@
+package clickhouse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/sink"
+)
+
+func TestSinkQuotesTable(t *testing.T) {
+	queries := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.Query().Get("query")
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		table string
+		want  string
+	}{
+		{"task_finished", "INSERT INTO `task_finished` FORMAT JSONEachRow"},
+		{"analytics.task_finished", "INSERT INTO `analytics`.`task_finished` FORMAT JSONEachRow"},
+		{"t FORMAT CSV; DROP TABLE x --`", "INSERT INTO `t FORMAT CSV; DROP TABLE x --\\`` FORMAT JSONEachRow"},
+	}
+	for _, tt := range tests {
+		s := NewSink(srv.URL, tt.table, "", "")
+		if err := s.Write(context.Background(), []sink.Record{{TaskKey: "t1", FinishedAt: time.Now()}}); err != nil {
+			t.Fatal(err)
+		}
+		if got := <-queries; got != tt.want {
+			t.Errorf("表 %q 期望 %s, 得到 %s", tt.table, tt.want, got)
+		}
+	}
+}
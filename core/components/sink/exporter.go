@@ -0,0 +1,171 @@
+package sink
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/retry"
+)
+
+const (
+	defaultBatchSize     = 200
+	defaultFlushInterval = 5 * time.Second
+	defaultBufferSize    = 10000
+
+	// max running tasks whose start time is tracked, start times of more
+	// tasks are taken from the tasks reported.
+	maxStartedTasks = 100000
+	// start times of tasks not reported within it are forgotten, eg. tasks
+	// deleted or moved to other workers, finished tasks carrying StartedAt
+	// do not need them.
+	startedTTL = 24 * time.Hour
+)
+
+// Exporter observe task status changes and streams finished task records to sink in batches.
+// Records will be dropped when buffer is full, analytics must never block the reconcile path.
+type Exporter struct {
+	sink          Interface
+	batchSize     int
+	flushInterval time.Duration
+
+	mu sync.Mutex
+	// task key => when the task is first observed running, and last observed.
+	startedAt map[string]started
+
+	records chan Record
+}
+
+func NewExporter(sink Interface, batchSize int, flushInterval time.Duration) *Exporter {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+	return &Exporter{
+		sink:          sink,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		startedAt:     make(map[string]started),
+		records:       make(chan Record, defaultBufferSize),
+	}
+}
+
+// Observe receive real task status change.
+func (e *Exporter) Observe(task *model.Task) {
+	now := time.Now()
+	if task.Status == model.TaskStatusRunning {
+		e.mu.Lock()
+		s, ok := e.startedAt[task.TaskKey]
+		if !ok {
+			s.at = now
+		}
+		s.seen = now
+		if ok || len(e.startedAt) < maxStartedTasks {
+			e.startedAt[task.TaskKey] = s
+		}
+		e.mu.Unlock()
+		return
+	}
+	if !task.Status.IsFinalStatus() {
+		return
+	}
+
+	e.mu.Lock()
+	s, ok := e.startedAt[task.TaskKey]
+	delete(e.startedAt, task.TaskKey)
+	e.mu.Unlock()
+	startedAt := s.at
+	if task.StartedAt != nil {
+		startedAt = *task.StartedAt
+	} else if !ok {
+		startedAt = now
+	}
+	if task.FinishedAt != nil {
+		now = *task.FinishedAt
+	}
+
+	r := Record{
+		TaskKey:    task.TaskKey,
+		BizID:      task.BizID,
+		BizType:    task.BizType,
+		Type:       task.Type,
+		Status:     task.Status.String(),
+		WorkerID:   task.WorkerID,
+		Msg:        task.Msg,
+		CreatedAt:  task.CreatedAt,
+		StartedAt:  startedAt,
+		FinishedAt: now,
+		DurationMs: now.Sub(startedAt).Milliseconds(),
+	}
+	if !task.CreatedAt.IsZero() {
+		r.QueueWaitMs = startedAt.Sub(task.CreatedAt).Milliseconds()
+	}
+
+	select {
+	case e.records <- r:
+	default:
+		log.Warn("[Exporter] buffer is full, drop record of task %s", task.TaskKey)
+	}
+}
+
+type started struct {
+	at, seen time.Time
+}
+
+// expire forgets start times of tasks not observed since before.
+func (e *Exporter) expire(before time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for key, s := range e.startedAt {
+		if s.seen.Before(before) {
+			delete(e.startedAt, key)
+		}
+	}
+}
+
+// Run flush records until ctx done.
+func (e *Exporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, e.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := retry.Do(func() error {
+			return e.sink.Write(context.Background(), batch)
+		}); err != nil {
+			log.Error("[Exporter] write %d records failed: %v", len(batch), err)
+		}
+		batch = make([]Record, 0, e.batchSize)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// drain buffered records.
+			for {
+				select {
+				case r := <-e.records:
+					batch = append(batch, r)
+				default:
+					flush()
+					return
+				}
+			}
+		case r := <-e.records:
+			batch = append(batch, r)
+			if len(batch) >= e.batchSize {
+				flush()
+			}
+		case now := <-ticker.C:
+			flush()
+			e.expire(now.Add(-startedTTL))
+		}
+	}
+}
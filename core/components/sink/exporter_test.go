@@ -0,0 +1,35 @@
+package sink
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestExporterStartedAt(t *testing.T) {
+	t.Run("过期的开始时间被清理", func(t *testing.T) {
+		e := NewExporter(nil, 0, 0)
+		e.Observe(&model.Task{TaskKey: "gone", Status: model.TaskStatusRunning})
+		e.expire(time.Now().Add(time.Second))
+		if len(e.startedAt) != 0 {
+			t.Fatalf("期望清理过期任务, 得到 %v", e.startedAt)
+		}
+	})
+
+	t.Run("超过上限后不再记录", func(t *testing.T) {
+		e := NewExporter(nil, 0, 0)
+		for i := range maxStartedTasks {
+			e.startedAt[strconv.Itoa(i)] = started{}
+		}
+		e.Observe(&model.Task{TaskKey: "overflow", Status: model.TaskStatusRunning})
+		if _, ok := e.startedAt["overflow"]; ok || len(e.startedAt) != maxStartedTasks {
+			t.Fatalf("期望不超过 %d, 得到 %d", maxStartedTasks, len(e.startedAt))
+		}
+		e.Observe(&model.Task{TaskKey: "overflow", Status: model.TaskStatusSuccess})
+		if r := <-e.records; r.TaskKey != "overflow" {
+			t.Fatalf("期望仍然导出结束的任务, 得到 %+v", r)
+		}
+	})
+}
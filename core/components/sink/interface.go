@@ -0,0 +1,29 @@
+package sink
+
+import (
+	"context"
+	"time"
+)
+
+// Record is a finished task record used for analytics.
+type Record struct {
+	TaskKey    string    `json:"task_key"`
+	BizID      string    `json:"biz_id"`
+	BizType    string    `json:"biz_type"`
+	Type       string    `json:"type"`
+	Status     string    `json:"status"`
+	WorkerID   string    `json:"worker_id"`
+	Msg        string    `json:"msg"`
+	CreatedAt  time.Time `json:"created_at"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// run duration, from started to finished.
+	DurationMs int64 `json:"duration_ms"`
+	// queue wait time, from created to started.
+	QueueWaitMs int64 `json:"queue_wait_ms"`
+}
+
+// Interface is the OLAP sink of finished task records, eg. ClickHouse.
+type Interface interface {
+	Write(ctx context.Context, records []Record) error
+}
@@ -0,0 +1,181 @@
+package tasklog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const followPollInterval = 200 * time.Millisecond
+
+// ErrNotFound means no log of the task is captured.
+var ErrNotFound = errors.New("task log not found")
+
+// FileStore keeps log of each task in a local file of JSON lines.
+type FileStore struct {
+	dir string
+	// serialize appends of stdout and stderr to the same file.
+	mu sync.Mutex
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(taskKey string) string {
+	return filepath.Join(s.dir, filepath.Base(taskKey)+".log")
+}
+
+func (s *FileStore) Writer(taskKey string, stream Stream) (io.WriteCloser, error) {
+	f, err := os.OpenFile(s.path(taskKey), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &lineWriter{store: s, f: f, stream: stream}, nil
+}
+
+func (s *FileStore) Read(ctx context.Context, taskKey string, tail int, follow bool) (<-chan Line, error) {
+	f, err := os.Open(s.path(taskKey))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	r := &lineReader{f: f}
+	lines, err := r.next()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if tail > 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+
+	ch := make(chan Line, len(lines))
+	for _, l := range lines {
+		ch <- l
+	}
+	if !follow {
+		f.Close()
+		close(ch)
+		return ch, nil
+	}
+
+	go func() {
+		defer close(ch)
+		defer f.Close()
+
+		ticker := time.NewTicker(followPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			lines, err := r.next()
+			if err != nil {
+				return
+			}
+			for _, l := range lines {
+				select {
+				case ch <- l:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// lineReader reads complete lines appended to file,
+// a partial line is kept until the rest of it is written.
+type lineReader struct {
+	f       *os.File
+	pending []byte
+}
+
+func (r *lineReader) next() ([]Line, error) {
+	b, err := io.ReadAll(r.f)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	r.pending = append(r.pending, b...)
+
+	end := bytes.LastIndexByte(r.pending, '\n')
+	if end < 0 {
+		return nil, nil
+	}
+	var lines []Line
+	for _, raw := range bytes.Split(r.pending[:end], []byte{'\n'}) {
+		var l Line
+		if err := json.Unmarshal(raw, &l); err != nil {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	r.pending = append([]byte(nil), r.pending[end+1:]...)
+	return lines, nil
+}
+
+// lineWriter splits output into lines and appends them as JSON lines.
+type lineWriter struct {
+	store  *FileStore
+	f      *os.File
+	stream Stream
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	end := bytes.LastIndexByte(w.buf, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+	if err := w.flush(bytes.Split(w.buf[:end], []byte{'\n'})); err != nil {
+		return 0, err
+	}
+	w.buf = append(w.buf[:0], w.buf[end+1:]...)
+	return len(p), nil
+}
+
+func (w *lineWriter) Close() error {
+	var err error
+	if len(w.buf) > 0 {
+		err = w.flush([][]byte{w.buf})
+		w.buf = nil
+	}
+	if cerr := w.f.Close(); err == nil {
+		err = errors.WithStack(cerr)
+	}
+	return err
+}
+
+func (w *lineWriter) flush(texts [][]byte) error {
+	var out bytes.Buffer
+	now := time.Now()
+	enc := json.NewEncoder(&out)
+	for _, text := range texts {
+		if err := enc.Encode(Line{Time: now, Stream: w.stream, Text: string(bytes.TrimSuffix(text, []byte{'\r'}))}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	_, err := w.f.Write(out.Bytes())
+	return errors.WithStack(err)
+}
@@ -0,0 +1,67 @@
+package tasklog
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func collect(ch <-chan Line) []string {
+	var texts []string
+	for l := range ch {
+		texts = append(texts, fmt.Sprintf("%s:%s", l.Stream, l.Text))
+	}
+	return texts
+}
+
+func TestFileStore(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Read(context.Background(), "t1", 0, false); err != ErrNotFound {
+		t.Fatalf("Read() err = %v, want ErrNotFound", err)
+	}
+
+	stdout, _ := s.Writer("t1", StreamStdout)
+	stderr, _ := s.Writer("t1", StreamStderr)
+	fmt.Fprint(stdout, "a\nb")
+	fmt.Fprint(stderr, "oops\n")
+	fmt.Fprint(stdout, "c\n")
+
+	t.Run("读取全部", func(t *testing.T) {
+		ch, err := s.Read(context.Background(), "t1", 0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := fmt.Sprint(collect(ch))
+		if want := "[stdout:a stderr:oops stdout:bc]"; got != want {
+			t.Errorf("Read() = %v, want %v", got, want)
+		}
+	})
+	t.Run("读取最后 N 行", func(t *testing.T) {
+		ch, _ := s.Read(context.Background(), "t1", 1, false)
+		if got := fmt.Sprint(collect(ch)); got != "[stdout:bc]" {
+			t.Errorf("Read() = %v", got)
+		}
+	})
+	t.Run("跟随新输出", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		ch, _ := s.Read(ctx, "t1", 1, true)
+		<-ch
+
+		fmt.Fprint(stdout, "d")
+		stdout.Close()
+		stderr.Close()
+		select {
+		case l := <-ch:
+			if l.Text != "d" {
+				t.Errorf("followed line = %v, want d", l.Text)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("no followed line")
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package tasklog
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+type Stream string
+
+const (
+	StreamStdout Stream = "stdout"
+	StreamStderr Stream = "stderr"
+)
+
+// Line is one line of executor output.
+type Line struct {
+	Time   time.Time `json:"time"`
+	Stream Stream    `json:"stream"`
+	Text   string    `json:"text"`
+}
+
+// Interface stores stdout/stderr of executors per task, eg. local files or a remote store.
+type Interface interface {
+	// Writer returns writer appending output of stream to log of taskKey,
+	// output is split into lines, partial line is flushed on Close.
+	Writer(taskKey string, stream Stream) (io.WriteCloser, error)
+	// Read returns the last tail lines of taskKey, all lines if tail <= 0.
+	// if follow, new lines are streamed until ctx is done.
+	// channel is closed after all lines are sent.
+	Read(ctx context.Context, taskKey string, tail int, follow bool) (<-chan Line, error)
+}
@@ -0,0 +1,234 @@
+// Package archive serves archived tasks from batches in object storage,
+// so that old tasks purged from the repo are still readable.
+//
+// A batch is an object of JSON lines produced by taskrepo.ExportTasks,
+// objects ending with ".gz" are gzip compressed. Parquet is not supported.
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type options struct {
+	prefix          string
+	refreshInterval time.Duration
+}
+
+type Option func(o *options)
+
+// WithPrefix only reads batches under prefix of store.
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithRefreshInterval sets the min interval of scanning new batches on a miss, default 5m.
+// scanning runs in the background, a task archived since the last scan is
+// found once it finishes.
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.refreshInterval = interval
+	}
+}
+
+// Reader reads archived tasks, it indexes task keys of batches in memory,
+// tasks themselves are read from the store on demand.
+type Reader struct {
+	store Store
+	opts  *options
+	now   func() time.Time
+
+	mu sync.Mutex
+	// task key => object key of the batch.
+	index       map[string]string
+	scanned     map[string]bool
+	refreshedAt time.Time
+	refreshing  bool
+}
+
+func NewReader(store Store, opts ...Option) *Reader {
+	o := &options{refreshInterval: 5 * time.Minute}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Reader{
+		store:   store,
+		opts:    o,
+		now:     time.Now,
+		index:   make(map[string]string),
+		scanned: make(map[string]bool),
+	}
+}
+
+// Refresh indexes batches added since the last refresh.
+func (r *Reader) Refresh(ctx context.Context) error {
+	objects, err := r.store.List(ctx, r.opts.prefix)
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		r.mu.Lock()
+		scanned := r.scanned[object]
+		r.mu.Unlock()
+		if scanned {
+			continue
+		}
+
+		var keys []string
+		if err := r.scan(ctx, object, func(task *model.Task) bool {
+			keys = append(keys, task.TaskKey)
+			return true
+		}); err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		for _, key := range keys {
+			r.index[key] = object
+		}
+		r.scanned[object] = true
+		r.mu.Unlock()
+	}
+
+	r.mu.Lock()
+	r.refreshedAt = r.now()
+	r.mu.Unlock()
+	return nil
+}
+
+// GetTask returns archived task of taskKey, taskrepo.ErrTaskNotFound if not
+// archived or not indexed yet. a miss never waits for scanning batches, it
+// starts a refresh in the background if the index is stale.
+func (r *Reader) GetTask(ctx context.Context, taskKey string) (*model.Task, error) {
+	object, ok := r.lookup(taskKey)
+	if !ok {
+		r.refreshInBackground()
+		return nil, errors.Wrapf(taskrepo.ErrTaskNotFound, "task %s is not archived", taskKey)
+	}
+
+	var found *model.Task
+	err := r.scan(ctx, object, func(task *model.Task) bool {
+		if task.TaskKey == taskKey {
+			found = task
+		}
+		return found == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, errors.Wrapf(taskrepo.ErrTaskNotFound, "task %s is not in batch %s", taskKey, object)
+	}
+	return found, nil
+}
+
+// ListTask scans batches in order for tasks matched by filter.
+func (r *Reader) ListTask(ctx context.Context, filter *model.TaskFilter) ([]*model.Task, error) {
+	objects, err := r.store.List(ctx, r.opts.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ret     []*model.Task
+		skipped int
+	)
+	full := func() bool { return filter.Limit > 0 && len(ret) >= filter.Limit }
+	for _, object := range objects {
+		if full() {
+			break
+		}
+		if err := r.scan(ctx, object, func(task *model.Task) bool {
+			if !filter.Match(task) {
+				return true
+			}
+			if skipped < filter.Offset {
+				skipped++
+				return true
+			}
+			ret = append(ret, task)
+			return !full()
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func (r *Reader) lookup(taskKey string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	object, ok := r.index[taskKey]
+	return object, ok
+}
+
+// refreshInBackground starts a refresh if the index is stale and no refresh
+// is running. a failed refresh is retried after the refresh interval.
+func (r *Reader) refreshInBackground() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.refreshing || r.now().Sub(r.refreshedAt) < r.opts.refreshInterval {
+		return
+	}
+	r.refreshing = true
+	go func() {
+		err := r.Refresh(context.Background())
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.refreshing = false
+		if err != nil {
+			r.refreshedAt = r.now()
+			log.Error("[Archive] 刷新归档索引失败: %v", err)
+		}
+	}()
+}
+
+// scan decodes tasks of object until fn returns false.
+func (r *Reader) scan(ctx context.Context, object string, fn func(task *model.Task) bool) error {
+	rc, err := r.store.Open(ctx, object)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var in io.Reader = rc
+	if strings.HasSuffix(object, ".gz") {
+		gz, err := gzip.NewReader(rc)
+		if err != nil {
+			return errors.Wrapf(err, "batch %s", object)
+		}
+		defer gz.Close()
+		in = gz
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var task model.Task
+		if err := json.Unmarshal(scanner.Bytes(), &task); err != nil {
+			return errors.Wrapf(err, "batch %s", object)
+		}
+		task.Source = model.TaskSourceArchive
+		if !fn(&task) {
+			return nil
+		}
+	}
+	return errors.Wrapf(scanner.Err(), "batch %s", object)
+}
@@ -0,0 +1,102 @@
+package archive
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func writeBatch(t *testing.T, dir, name string, tasks ...*model.Task) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var enc *json.Encoder
+	if filepath.Ext(name) == ".gz" {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+		enc = json.NewEncoder(gz)
+	} else {
+		enc = json.NewEncoder(f)
+	}
+	for _, task := range tasks {
+		if err := enc.Encode(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeBatch(t, dir, "2024-01.jsonl",
+		&model.Task{TaskKey: "a", BizType: "x", Status: model.TaskStatusSuccess},
+		&model.Task{TaskKey: "b", BizType: "y", Status: model.TaskStatusFailed},
+	)
+	writeBatch(t, dir, "2024-02.jsonl.gz",
+		&model.Task{TaskKey: "c", BizType: "x", Status: model.TaskStatusSuccess},
+	)
+	r := NewReader(NewDirStore(dir), WithRefreshInterval(time.Millisecond))
+	if err := r.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("按 key 读取归档任务", func(t *testing.T) {
+		task, err := r.GetTask(ctx, "c")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.BizType != "x" || task.Source != model.TaskSourceArchive {
+			t.Errorf("期望来自归档的任务 c, 得到 %+v", task)
+		}
+	})
+
+	t.Run("未归档返回 ErrTaskNotFound", func(t *testing.T) {
+		if _, err := r.GetTask(ctx, "z"); !errors.Is(err, taskrepo.ErrTaskNotFound) {
+			t.Errorf("期望 ErrTaskNotFound, 得到 %v", err)
+		}
+	})
+
+	t.Run("新归档的批次在后台刷新后可读", func(t *testing.T) {
+		writeBatch(t, dir, "2024-03.jsonl", &model.Task{TaskKey: "d", BizType: "x", Status: model.TaskStatusSuccess})
+		time.Sleep(time.Millisecond)
+		if _, err := r.GetTask(ctx, "d"); !errors.Is(err, taskrepo.ErrTaskNotFound) {
+			t.Fatalf("期望未刷新时不等待扫描, 得到 %v", err)
+		}
+		deadline := time.Now().Add(time.Second)
+		for {
+			task, err := r.GetTask(ctx, "d")
+			if err == nil {
+				if task.TaskKey != "d" {
+					t.Fatalf("期望任务 d, 得到 %+v", task)
+				}
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("期望后台刷新后读到任务 d, 得到 %v", err)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+
+	t.Run("按条件分页列出", func(t *testing.T) {
+		tasks, err := r.ListTask(ctx, &model.TaskFilter{BizType: "x", Offset: 1, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tasks) != 2 || tasks[0].TaskKey != "c" || tasks[1].TaskKey != "d" {
+			t.Errorf("期望 c, d, 得到 %v", tasks)
+		}
+	})
+}
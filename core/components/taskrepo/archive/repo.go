@@ -0,0 +1,44 @@
+package archive
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// Wrap serves tasks not found in repo from archive, and archived tasks
+// listed with TaskFilter.Archived. archived tasks are read-only,
+// writes to them fail with taskrepo.ErrTaskNotFound of repo.
+func Wrap(repo taskrepo.Interface, archive *Reader) taskrepo.Interface {
+	return &archivedRepo{Interface: repo, archive: archive}
+}
+
+type archivedRepo struct {
+	taskrepo.Interface
+	archive *Reader
+}
+
+func (r *archivedRepo) GetTask(ctx context.Context, taskKey string) (*model.Task, error) {
+	task, err := r.Interface.GetTask(ctx, taskKey)
+	if !errors.Is(err, taskrepo.ErrTaskNotFound) {
+		return task, err
+	}
+	archived, aerr := r.archive.GetTask(ctx, taskKey)
+	if aerr != nil {
+		if errors.Is(aerr, taskrepo.ErrTaskNotFound) {
+			return nil, err
+		}
+		return nil, aerr
+	}
+	return archived, nil
+}
+
+func (r *archivedRepo) ListTask(ctx context.Context, filter *model.TaskFilter) ([]*model.Task, error) {
+	if filter.Archived {
+		return r.archive.ListTask(ctx, filter)
+	}
+	return r.Interface.ListTask(ctx, filter)
+}
@@ -0,0 +1,82 @@
+package taskrepo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+const (
+	DefaultBatchGetChunkSize   = 500
+	DefaultBatchGetParallelism = 4
+)
+
+type BatchGetFunc func(ctx context.Context, taskKeys []string) ([]*model.Task, error)
+
+// ChunkedBatchGetTask split large key sets into chunks of chunkSize,
+// query them with at most parallelism goroutines and merge the results.
+// It avoids sending a giant IN clause to the storage.
+func ChunkedBatchGetTask(
+	ctx context.Context,
+	fn BatchGetFunc,
+	taskKeys []string,
+	chunkSize, parallelism int,
+) ([]*model.Task, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultBatchGetChunkSize
+	}
+	if parallelism <= 0 {
+		parallelism = DefaultBatchGetParallelism
+	}
+	if len(taskKeys) <= chunkSize {
+		return fn(ctx, taskKeys)
+	}
+
+	chunks := make([][]string, 0, len(taskKeys)/chunkSize+1)
+	for start := 0; start < len(taskKeys); start += chunkSize {
+		end := min(start+chunkSize, len(taskKeys))
+		chunks = append(chunks, taskKeys[start:end])
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		results  = make([][]*model.Task, len(chunks))
+		sem      = make(chan struct{}, parallelism)
+	)
+	for idx, chunk := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int, chunk []string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			tasks, err := fn(ctx, chunk)
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[idx] = tasks
+		}(idx, chunk)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	ret := make([]*model.Task, 0, len(taskKeys))
+	for _, tasks := range results {
+		ret = append(ret, tasks...)
+	}
+	return ret, nil
+}
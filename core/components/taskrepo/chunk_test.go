@@ -0,0 +1,62 @@
+package taskrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestChunkedBatchGetTask(t *testing.T) {
+	keys := make([]string, 0, 1050)
+	for i := 0; i < 1050; i++ {
+		keys = append(keys, fmt.Sprintf("task-%d", i))
+	}
+
+	t.Run("合并所有分片结果", func(t *testing.T) {
+		var calls, maxChunk atomic.Int64
+		fn := func(ctx context.Context, taskKeys []string) ([]*model.Task, error) {
+			calls.Add(1)
+			if n := int64(len(taskKeys)); n > maxChunk.Load() {
+				maxChunk.Store(n)
+			}
+			tasks := make([]*model.Task, 0, len(taskKeys))
+			for _, key := range taskKeys {
+				tasks = append(tasks, &model.Task{TaskKey: key})
+			}
+			return tasks, nil
+		}
+
+		tasks, err := ChunkedBatchGetTask(context.Background(), fn, keys, 100, 3)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if len(tasks) != len(keys) {
+			t.Errorf("期望 %d 个任务, 得到 %d", len(keys), len(tasks))
+		}
+		if calls.Load() != 11 {
+			t.Errorf("期望调用 11 次, 得到 %d", calls.Load())
+		}
+		if maxChunk.Load() > 100 {
+			t.Errorf("分片大小超过限制: %d", maxChunk.Load())
+		}
+	})
+
+	t.Run("任意分片失败返回错误", func(t *testing.T) {
+		wantErr := errors.New("db error")
+		fn := func(ctx context.Context, taskKeys []string) ([]*model.Task, error) {
+			if taskKeys[0] == "task-500" {
+				return nil, wantErr
+			}
+			return nil, nil
+		}
+
+		_, err := ChunkedBatchGetTask(context.Background(), fn, keys, 100, 3)
+		if !errors.Is(err, wantErr) {
+			t.Errorf("期望错误 %v, 得到 %v", wantErr, err)
+		}
+	})
+}
@@ -0,0 +1,17 @@
+package taskrepo
+
+import "github.com/pkg/errors"
+
+// errors returned by all backends, callers should check them with errors.Is.
+// backends map errors of their driver to them, eg. gorm.ErrRecordNotFound
+// to ErrTaskNotFound and duplicate key (MySQL 1062) to ErrDuplicateTask.
+var (
+	// the task does not exist or is purged.
+	ErrTaskNotFound = errors.New("task not found")
+	// a task of the same task key already exists.
+	ErrDuplicateTask = errors.New("duplicate task")
+)
+
+// ErrFinalStatus is returned by repos wrapped by WithFinalGuard when an update
+// moves a task out of its final status other than by a rerun.
+var ErrFinalStatus = errors.New("task is in final status")
@@ -0,0 +1,111 @@
+package taskrepo
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type rerunKey struct{}
+
+// Rerun marks updates made with ctx as a rerun of finished tasks, which is
+// the only way moving a task out of its final status, eg. retry of failed tasks.
+func Rerun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rerunKey{}, true)
+}
+
+func isRerun(ctx context.Context) bool {
+	rerun, _ := ctx.Value(rerunKey{}).(bool)
+	return rerun
+}
+
+// WithFinalGuard wraps repo so that status updates of tasks in final status
+// fail with ErrFinalStatus unless made with a ctx of Rerun, like a trigger of
+// the database would do. the status is checked before the update, backends
+// enforcing it by triggers close the window between them.
+func WithFinalGuard(repo Interface) Interface {
+	return &finalGuardRepo{Interface: repo}
+}
+
+type finalGuardRepo struct {
+	Interface
+}
+
+func (r *finalGuardRepo) UpdateTask(ctx context.Context, task *model.Task) error {
+	if err := r.check(ctx, task); err != nil {
+		return err
+	}
+	return r.Interface.UpdateTask(ctx, task)
+}
+
+func (r *finalGuardRepo) BatchUpdateTasks(ctx context.Context, tasks []*model.Task) error {
+	if err := r.check(ctx, tasks...); err != nil {
+		return err
+	}
+	return r.Interface.BatchUpdateTasks(ctx, tasks)
+}
+
+func (r *finalGuardRepo) UpdateTaskStatusCAS(ctx context.Context, taskKey string, from, to model.TaskStatus) (bool, error) {
+	if from.IsFinalStatus() && from != to && !isRerun(ctx) {
+		return false, errors.Wrapf(ErrFinalStatus, "task %s %s -> %s", taskKey, from, to)
+	}
+	return r.Interface.UpdateTaskStatusCAS(ctx, taskKey, from, to)
+}
+
+func (r *finalGuardRepo) UpdateTaskCAS(ctx context.Context, from model.TaskStatus, task *model.Task) (bool, error) {
+	if from.IsFinalStatus() && from != task.Status && !isRerun(ctx) {
+		return false, errors.Wrapf(ErrFinalStatus, "task %s %s -> %s", task.TaskKey, from, task.Status)
+	}
+	return r.Interface.UpdateTaskCAS(ctx, from, task)
+}
+
+func (r *finalGuardRepo) BatchUpdateTasksCAS(ctx context.Context, from []model.TaskStatus, tasks []*model.Task) (bool, error) {
+	if !isRerun(ctx) {
+		for i, t := range tasks {
+			if from[i].IsFinalStatus() && t.Status != "" && from[i] != t.Status {
+				return false, errors.Wrapf(ErrFinalStatus, "task %s %s -> %s", t.TaskKey, from[i], t.Status)
+			}
+		}
+	}
+	return r.Interface.BatchUpdateTasksCAS(ctx, from, tasks)
+}
+
+func (r *finalGuardRepo) UpdateTaskGenerationCAS(ctx context.Context, from int64, task *model.Task) (bool, error) {
+	if err := r.check(ctx, task); err != nil {
+		return false, err
+	}
+	return r.Interface.UpdateTaskGenerationCAS(ctx, from, task)
+}
+
+// check rejects updates changing status of tasks in final status.
+func (r *finalGuardRepo) check(ctx context.Context, tasks ...*model.Task) error {
+	if isRerun(ctx) {
+		return nil
+	}
+	keys := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		if t.Status != "" {
+			keys = append(keys, t.TaskKey)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	current, err := r.Interface.BatchGetTask(ctx, keys)
+	if err != nil {
+		return err
+	}
+	statuses := make(map[string]model.TaskStatus, len(current))
+	for _, t := range current {
+		statuses[t.TaskKey] = t.Status
+	}
+	for _, t := range tasks {
+		from := statuses[t.TaskKey]
+		if t.Status != "" && from.IsFinalStatus() && t.Status != from {
+			return errors.Wrapf(ErrFinalStatus, "task %s %s -> %s", t.TaskKey, from, t.Status)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package taskrepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// statusRepo keeps status of tasks in memory.
+type statusRepo struct {
+	Interface
+	statuses map[string]model.TaskStatus
+}
+
+func (r *statusRepo) BatchGetTask(_ context.Context, keys []string) ([]*model.Task, error) {
+	var tasks []*model.Task
+	for _, key := range keys {
+		if status, ok := r.statuses[key]; ok {
+			tasks = append(tasks, &model.Task{TaskKey: key, Status: status})
+		}
+	}
+	return tasks, nil
+}
+
+func (r *statusRepo) UpdateTask(_ context.Context, task *model.Task) error {
+	if task.Status != "" {
+		r.statuses[task.TaskKey] = task.Status
+	}
+	return nil
+}
+
+func (r *statusRepo) BatchUpdateTasks(ctx context.Context, tasks []*model.Task) error {
+	for _, t := range tasks {
+		_ = r.UpdateTask(ctx, t)
+	}
+	return nil
+}
+
+func (r *statusRepo) UpdateTaskStatusCAS(_ context.Context, key string, from, to model.TaskStatus) (bool, error) {
+	if r.statuses[key] != from {
+		return false, nil
+	}
+	r.statuses[key] = to
+	return true, nil
+}
+
+func (r *statusRepo) UpdateTaskCAS(ctx context.Context, from model.TaskStatus, task *model.Task) (bool, error) {
+	return r.UpdateTaskStatusCAS(ctx, task.TaskKey, from, task.Status)
+}
+
+func TestWithFinalGuard(t *testing.T) {
+	ctx := context.Background()
+	inner := &statusRepo{statuses: map[string]model.TaskStatus{
+		"done": model.TaskStatusSuccess,
+		"run":  model.TaskStatusRunning,
+	}}
+	repo := WithFinalGuard(inner)
+
+	t.Run("终态任务不能变更状态", func(t *testing.T) {
+		err := repo.UpdateTask(ctx, &model.Task{TaskKey: "done", Status: model.TaskStatusFailed})
+		if !errors.Is(err, ErrFinalStatus) {
+			t.Errorf("期望 ErrFinalStatus, 得到 %v", err)
+		}
+		err = repo.BatchUpdateTasks(ctx, []*model.Task{
+			{TaskKey: "run", Status: model.TaskStatusSuccess},
+			{TaskKey: "done", Status: model.TaskStatusRunning},
+		})
+		if !errors.Is(err, ErrFinalStatus) || inner.statuses["run"] != model.TaskStatusRunning {
+			t.Errorf("批量更新应整体拒绝, 得到 %v", err)
+		}
+		if _, err := repo.UpdateTaskStatusCAS(ctx, "done", model.TaskStatusSuccess, model.TaskStatusWaitRunning); !errors.Is(err, ErrFinalStatus) {
+			t.Errorf("期望 ErrFinalStatus, 得到 %v", err)
+		}
+		if _, err := repo.UpdateTaskCAS(ctx, model.TaskStatusSuccess, &model.Task{TaskKey: "done", Status: model.TaskStatusWaitRunning}); !errors.Is(err, ErrFinalStatus) {
+			t.Errorf("期望 ErrFinalStatus, 得到 %v", err)
+		}
+	})
+
+	t.Run("非状态字段和非终态任务不受限", func(t *testing.T) {
+		if err := repo.UpdateTask(ctx, &model.Task{TaskKey: "done", Msg: "note"}); err != nil {
+			t.Error(err)
+		}
+		if err := repo.UpdateTask(ctx, &model.Task{TaskKey: "done", Status: model.TaskStatusSuccess}); err != nil {
+			t.Error(err)
+		}
+		if err := repo.UpdateTask(ctx, &model.Task{TaskKey: "run", Status: model.TaskStatusSuccess}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("重新运行可以离开终态", func(t *testing.T) {
+		applied, err := repo.UpdateTaskStatusCAS(Rerun(ctx), "done", model.TaskStatusSuccess, model.TaskStatusWaitScheduling)
+		if err != nil || !applied {
+			t.Errorf("重新运行应成功: %v", err)
+		}
+	})
+}
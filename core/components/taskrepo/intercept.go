@@ -0,0 +1,127 @@
+package taskrepo
+
+import (
+	"context"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// interceptor runs fn, the call of method to repo, write reports whether
+// the method modifies tasks.
+type interceptor func(ctx context.Context, method string, write bool, fn func(ctx context.Context) error) error
+
+// intercept wraps every call but WatchRunnableTasks of repo by i.
+func intercept(repo Interface, i interceptor) Interface {
+	return &interceptedRepo{Interface: repo, i: i}
+}
+
+type interceptedRepo struct {
+	Interface
+	i interceptor
+}
+
+func (r *interceptedRepo) CreateTask(ctx context.Context, task *model.Task) error {
+	return r.i(ctx, "CreateTask", true, func(ctx context.Context) error {
+		return r.Interface.CreateTask(ctx, task)
+	})
+}
+
+func (r *interceptedRepo) UpdateTask(ctx context.Context, task *model.Task) error {
+	return r.i(ctx, "UpdateTask", true, func(ctx context.Context) error {
+		return r.Interface.UpdateTask(ctx, task)
+	})
+}
+
+func (r *interceptedRepo) BatchUpdateTasks(ctx context.Context, tasks []*model.Task) error {
+	return r.i(ctx, "BatchUpdateTasks", true, func(ctx context.Context) error {
+		return r.Interface.BatchUpdateTasks(ctx, tasks)
+	})
+}
+
+func (r *interceptedRepo) UpdateTaskStatusCAS(ctx context.Context, taskKey string, from, to model.TaskStatus) (applied bool, err error) {
+	err = r.i(ctx, "UpdateTaskStatusCAS", true, func(ctx context.Context) error {
+		applied, err = r.Interface.UpdateTaskStatusCAS(ctx, taskKey, from, to)
+		return err
+	})
+	return applied && err == nil, err
+}
+
+func (r *interceptedRepo) UpdateTaskCAS(ctx context.Context, from model.TaskStatus, task *model.Task) (applied bool, err error) {
+	err = r.i(ctx, "UpdateTaskCAS", true, func(ctx context.Context) error {
+		applied, err = r.Interface.UpdateTaskCAS(ctx, from, task)
+		return err
+	})
+	return applied && err == nil, err
+}
+
+func (r *interceptedRepo) BatchUpdateTasksCAS(ctx context.Context, from []model.TaskStatus, tasks []*model.Task) (applied bool, err error) {
+	err = r.i(ctx, "BatchUpdateTasksCAS", true, func(ctx context.Context) error {
+		applied, err = r.Interface.BatchUpdateTasksCAS(ctx, from, tasks)
+		return err
+	})
+	return applied && err == nil, err
+}
+
+func (r *interceptedRepo) UpdateTaskGenerationCAS(ctx context.Context, from int64, task *model.Task) (applied bool, err error) {
+	err = r.i(ctx, "UpdateTaskGenerationCAS", true, func(ctx context.Context) error {
+		applied, err = r.Interface.UpdateTaskGenerationCAS(ctx, from, task)
+		return err
+	})
+	return applied && err == nil, err
+}
+
+func (r *interceptedRepo) DeleteTask(ctx context.Context, taskKey string) error {
+	return r.i(ctx, "DeleteTask", true, func(ctx context.Context) error {
+		return r.Interface.DeleteTask(ctx, taskKey)
+	})
+}
+
+func (r *interceptedRepo) PurgeTask(ctx context.Context, taskKey string) error {
+	return r.i(ctx, "PurgeTask", true, func(ctx context.Context) error {
+		return r.Interface.PurgeTask(ctx, taskKey)
+	})
+}
+
+func (r *interceptedRepo) GetTask(ctx context.Context, taskKey string) (task *model.Task, err error) {
+	err = r.i(ctx, "GetTask", false, func(ctx context.Context) error {
+		task, err = r.Interface.GetTask(ctx, taskKey)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (r *interceptedRepo) BatchGetTask(ctx context.Context, taskKeys []string) (tasks []*model.Task, err error) {
+	err = r.i(ctx, "BatchGetTask", false, func(ctx context.Context) error {
+		tasks, err = r.Interface.BatchGetTask(ctx, taskKeys)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *interceptedRepo) ListTask(ctx context.Context, filter *model.TaskFilter) (tasks []*model.Task, err error) {
+	err = r.i(ctx, "ListTask", false, func(ctx context.Context) error {
+		tasks, err = r.Interface.ListTask(ctx, filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *interceptedRepo) ListRunnableTasks(ctx context.Context, workerID string) (keys []string, err error) {
+	err = r.i(ctx, "ListRunnableTasks", false, func(ctx context.Context) error {
+		keys, err = r.Interface.ListRunnableTasks(ctx, workerID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
@@ -7,13 +7,35 @@ import (
 )
 
 type Interface interface {
-	// 事务创建任务记录和任务调度信息
+	// 事务创建任务记录和任务调度信息, 任务已存在时返回 ErrDuplicateTask.
 	CreateTask(ctx context.Context, task *model.Task) error
-	// 事务更新任务和任务调度信息
+	// 事务更新任务和任务调度信息, 任务不存在时返回 ErrTaskNotFound.
 	UpdateTask(ctx context.Context, task *model.Task) error
-	// 获取任务
+	// 批量更新任务, 在同一事务中按顺序更新, 任一失败则全部回滚.
+	BatchUpdateTasks(ctx context.Context, tasks []*model.Task) error
+	// update task status only when current status equals from.
+	// applied reports whether the update happened, so concurrent controllers
+	// can not race each other into illegal transitions.
+	UpdateTaskStatusCAS(ctx context.Context, taskKey string, from, to model.TaskStatus) (applied bool, err error)
+	// like UpdateTaskStatusCAS, and updates non-zero fields of task like
+	// UpdateTask in the same transaction, task.Status is the status to.
+	// nothing is updated if current status is not from.
+	UpdateTaskCAS(ctx context.Context, from model.TaskStatus, task *model.Task) (applied bool, err error)
+	// like BatchUpdateTasks, but only when current status of every tasks[i]
+	// equals from[i], nothing is updated otherwise.
+	BatchUpdateTasksCAS(ctx context.Context, from []model.TaskStatus, tasks []*model.Task) (applied bool, err error)
+	// like UpdateTask, but only when current spec generation equals from,
+	// so concurrent spec updates can not lose each other's changes.
+	UpdateTaskGenerationCAS(ctx context.Context, from int64, task *model.Task) (applied bool, err error)
+	// 软删除任务, 在同一事务中设置 deleted_at 并将期望状态置为 not_exist.
+	// 被删除的任务对用户不可见, 但仍会被 ListRunnableTasks 返回, 以便 worker 停止执行器.
+	// 任务不存在时返回 ErrTaskNotFound.
+	DeleteTask(ctx context.Context, taskKey string) error
+	// 物理删除任务(tombstone 回收)
+	PurgeTask(ctx context.Context, taskKey string) error
+	// 获取任务, 任务不存在时返回 ErrTaskNotFound.
 	GetTask(ctx context.Context, taskKey string) (*model.Task, error)
-	// 批量获取任务
+	// 批量获取任务, 不存在的任务被忽略.
 	BatchGetTask(ctx context.Context, taskKeys []string) ([]*model.Task, error)
 	// 查询任务列表
 	ListTask(ctx context.Context, filter *model.TaskFilter) ([]*model.Task, error)
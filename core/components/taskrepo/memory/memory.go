@@ -0,0 +1,310 @@
+// Package memory implements taskrepo.Interface in memory of one process, so
+// that standalone deployments, demos and tests run without any database.
+// tasks are lost once the process exits.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type Repo struct {
+	mu    sync.RWMutex
+	tasks map[string]*entry
+	// last id and revision given out, they only grow.
+	id, revision int64
+
+	opts *options
+}
+
+var _ taskrepo.Interface = (*Repo)(nil)
+
+type entry struct {
+	task     *model.Task
+	revision int64
+}
+
+type options struct {
+	watchInterval time.Duration
+}
+
+type Option func(o *options)
+
+// WithWatchInterval sets how often WatchRunnableTasks polls changed tasks, default 100ms.
+func WithWatchInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.watchInterval = interval
+	}
+}
+
+func New(opts ...Option) *Repo {
+	o := &options{watchInterval: 100 * time.Millisecond}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Repo{tasks: make(map[string]*entry), opts: o}
+}
+
+func (r *Repo) CreateTask(_ context.Context, task *model.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tasks[task.TaskKey]; ok {
+		return errors.Wrapf(taskrepo.ErrDuplicateTask, "task %s", task.TaskKey)
+	}
+
+	t := copyTask(task)
+	now := time.Now()
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = now
+	}
+	t.UpdatedAt = now
+	r.id++
+	t.ID = r.id
+	task.ID = t.ID
+	r.put(t)
+	return nil
+}
+
+// UpdateTask updates non-zero fields of task, like gorm Updates with a struct.
+func (r *Repo) UpdateTask(_ context.Context, task *model.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.update(task)
+}
+
+func (r *Repo) BatchUpdateTasks(_ context.Context, tasks []*model.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// all or nothing like a transaction.
+	for _, task := range tasks {
+		if _, ok := r.tasks[task.TaskKey]; !ok {
+			return errors.Wrapf(taskrepo.ErrTaskNotFound, "task %s", task.TaskKey)
+		}
+	}
+	for _, task := range tasks {
+		if err := r.update(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repo) BatchUpdateTasksCAS(_ context.Context, from []model.TaskStatus, tasks []*model.Task) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, task := range tasks {
+		e, ok := r.tasks[task.TaskKey]
+		if !ok {
+			return false, errors.Wrapf(taskrepo.ErrTaskNotFound, "task %s", task.TaskKey)
+		}
+		if e.task.Status != from[i] {
+			return false, nil
+		}
+	}
+	for _, task := range tasks {
+		if err := r.update(task); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (r *Repo) UpdateTaskGenerationCAS(_ context.Context, from int64, task *model.Task) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.tasks[task.TaskKey]
+	if !ok {
+		return false, errors.Wrapf(taskrepo.ErrTaskNotFound, "task %s", task.TaskKey)
+	}
+	if e.task.Generation != from {
+		return false, nil
+	}
+	return true, r.update(task)
+}
+
+func (r *Repo) UpdateTaskStatusCAS(ctx context.Context, taskKey string, from, to model.TaskStatus) (bool, error) {
+	return r.UpdateTaskCAS(ctx, from, &model.Task{TaskKey: taskKey, Status: to})
+}
+
+func (r *Repo) UpdateTaskCAS(_ context.Context, from model.TaskStatus, task *model.Task) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.tasks[task.TaskKey]
+	if !ok {
+		return false, errors.Wrapf(taskrepo.ErrTaskNotFound, "task %s", task.TaskKey)
+	}
+	if e.task.Status != from {
+		return false, nil
+	}
+	return true, r.update(task)
+}
+
+func (r *Repo) DeleteTask(_ context.Context, taskKey string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	return r.update(&model.Task{TaskKey: taskKey, DeletedAt: &now, WantRunStatus: model.TaskStatusNotExist})
+}
+
+func (r *Repo) PurgeTask(_ context.Context, taskKey string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tasks[taskKey]; !ok {
+		return errors.Wrapf(taskrepo.ErrTaskNotFound, "task %s", taskKey)
+	}
+	delete(r.tasks, taskKey)
+	return nil
+}
+
+// GetTask returns ErrTaskNotFound for soft deleted tasks, they are hidden from users.
+func (r *Repo) GetTask(_ context.Context, taskKey string) (*model.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.tasks[taskKey]
+	if !ok || e.task.IsDeleted() {
+		return nil, errors.Wrapf(taskrepo.ErrTaskNotFound, "task %s", taskKey)
+	}
+	return copyTask(e.task), nil
+}
+
+// BatchGetTask returns soft deleted tasks too, so that workers stop their executors.
+func (r *Repo) BatchGetTask(_ context.Context, taskKeys []string) ([]*model.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tasks := make([]*model.Task, 0, len(taskKeys))
+	for _, key := range taskKeys {
+		if e, ok := r.tasks[key]; ok {
+			tasks = append(tasks, copyTask(e.task))
+		}
+	}
+	sortByID(tasks)
+	return tasks, nil
+}
+
+func (r *Repo) ListTask(_ context.Context, filter *model.TaskFilter) ([]*model.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var tasks []*model.Task
+	for _, e := range r.tasks {
+		if filter.Match(e.task) {
+			tasks = append(tasks, copyTask(e.task))
+		}
+	}
+	sortByID(tasks)
+	return taskrepo.Paginate(tasks, filter.Offset, filter.Limit), nil
+}
+
+// ListRunnableTasks returns unfinished tasks assigned to workerID,
+// all unfinished tasks if workerID is empty. tasks parked by pause are
+// excluded until resumed.
+func (r *Repo) ListRunnableTasks(_ context.Context, workerID string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var tasks []*model.Task
+	for _, e := range r.tasks {
+		t := e.task
+		if t.Status.IsFinalStatus() || t.IsParked() || (workerID != "" && t.WorkerID != workerID) {
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	sortByID(tasks)
+	keys := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		keys = append(keys, t.TaskKey)
+	}
+	return keys, nil
+}
+
+// WatchRunnableTasks polls tasks changed since the last poll, tasks of
+// workerID are reported, all tasks if workerID is empty.
+func (r *Repo) WatchRunnableTasks(ctx context.Context, workerID string) (<-chan []string, error) {
+	r.mu.RLock()
+	last := r.revision
+	r.mu.RUnlock()
+
+	ch := make(chan []string, 100)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(r.opts.watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			var keys []string
+			keys, last = r.changedSince(workerID, last)
+			if len(keys) == 0 {
+				continue
+			}
+			select {
+			case ch <- keys:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (r *Repo) changedSince(workerID string, revision int64) ([]string, int64) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var keys []string
+	for key, e := range r.tasks {
+		if e.revision > revision && (workerID == "" || e.task.WorkerID == workerID) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, r.revision
+}
+
+// update merges task into the stored one, r.mu is held.
+func (r *Repo) update(update *model.Task) error {
+	e, ok := r.tasks[update.TaskKey]
+	if !ok {
+		return errors.Wrapf(taskrepo.ErrTaskNotFound, "task %s", update.TaskKey)
+	}
+	// merge the update as is, a copy would turn empty maps and slices, which
+	// clear fields, into nil ones which are skipped.
+	t := *e.task
+	taskrepo.MergeTask(&t, update)
+	t.UpdatedAt = time.Now()
+	r.put(copyTask(&t))
+	return nil
+}
+
+// copyTask deep copies task like a database row, so that callers never share
+// maps or pointers with stored tasks.
+func copyTask(task *model.Task) *model.Task {
+	data, err := json.Marshal(task)
+	if err != nil {
+		panic(err)
+	}
+	t := new(model.Task)
+	if err := json.Unmarshal(data, t); err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// put stores task and bumps its revision, so that watchers see the change.
+func (r *Repo) put(task *model.Task) {
+	r.revision++
+	r.tasks[task.TaskKey] = &entry{task: task, revision: r.revision}
+}
+
+func sortByID(tasks []*model.Task) {
+	slices.SortFunc(tasks, func(a, b *model.Task) int { return int(a.ID - b.ID) })
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// waitKeys waits until all keys are reported by ch.
+func waitKeys(t *testing.T, ch <-chan []string, keys ...string) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for len(keys) > 0 {
+		select {
+		case changed := <-ch:
+			keys = slices.DeleteFunc(keys, func(k string) bool { return slices.Contains(changed, k) })
+		case <-timeout:
+			t.Fatalf("期望监听到 %v 的变化", keys)
+		}
+	}
+}
+
+func TestRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := New(WithWatchInterval(10 * time.Millisecond))
+
+	t.Run("创建更新和 CAS", func(t *testing.T) {
+		task := &model.Task{TaskKey: "a", BizType: "biz", Status: model.TaskStatusWaitScheduling, Payload: "p"}
+		if err := repo.CreateTask(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.CreateTask(ctx, task); !errors.Is(err, taskrepo.ErrDuplicateTask) {
+			t.Fatalf("期望重复创建返回 ErrDuplicateTask, 得到 %v", err)
+		}
+		if err := repo.UpdateTask(ctx, &model.Task{TaskKey: "a", WorkerID: "w1"}); err != nil {
+			t.Fatal(err)
+		}
+		applied, err := repo.UpdateTaskStatusCAS(ctx, "a", model.TaskStatusWaitScheduling, model.TaskStatusWaitRunning)
+		if err != nil || !applied {
+			t.Fatalf("期望 CAS 成功, 得到 %v %v", applied, err)
+		}
+		applied, err = repo.UpdateTaskStatusCAS(ctx, "a", model.TaskStatusWaitScheduling, model.TaskStatusRunning)
+		if err != nil || applied {
+			t.Fatalf("期望状态不符时 CAS 不生效, 得到 %v %v", applied, err)
+		}
+		applied, err = repo.UpdateTaskCAS(ctx, model.TaskStatusRunning, &model.Task{TaskKey: "a", Status: model.TaskStatusWaitStop, WantRunStatus: model.TaskStatusStop})
+		if err != nil || applied {
+			t.Fatalf("期望状态不符时不更新, 得到 %v %v", applied, err)
+		}
+		applied, err = repo.BatchUpdateTasksCAS(ctx, []model.TaskStatus{model.TaskStatusRunning}, []*model.Task{{TaskKey: "a", WorkerID: "w2"}})
+		if err != nil || applied {
+			t.Fatalf("期望批量状态不符时不更新, 得到 %v %v", applied, err)
+		}
+		applied, err = repo.UpdateTaskGenerationCAS(ctx, 1, &model.Task{TaskKey: "a", WorkerID: "w2", Generation: 2})
+		if err != nil || applied {
+			t.Fatalf("期望 generation 不符时不更新, 得到 %v %v", applied, err)
+		}
+		got, err := repo.GetTask(ctx, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != model.TaskStatusWaitRunning || got.WantRunStatus != "" || got.WorkerID != "w1" || got.Payload != "p" {
+			t.Fatalf("期望合并更新, 得到 %+v", got)
+		}
+		keys, err := repo.ListRunnableTasks(ctx, "w1")
+		if err != nil || !slices.Equal(keys, []string{"a"}) {
+			t.Fatalf("期望 w1 可运行任务 [a], 得到 %v %v", keys, err)
+		}
+	})
+
+	t.Run("空的 map 和 slice 清空字段", func(t *testing.T) {
+		task := &model.Task{
+			TaskKey:          "clear",
+			Labels:           map[string]string{model.LabelScheduledChange: model.ScheduledChangePending},
+			ScheduledChanges: []model.ScheduledChange{{At: time.Now(), Status: model.TaskStatusPaused}},
+		}
+		if err := repo.CreateTask(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.UpdateTask(ctx, &model.Task{TaskKey: "clear", Labels: map[string]string{}, ScheduledChanges: []model.ScheduledChange{}}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := repo.GetTask(ctx, "clear")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Labels) != 0 || len(got.ScheduledChanges) != 0 {
+			t.Fatalf("期望清空最后一个标签和计划变更, 得到 %v %v", got.Labels, got.ScheduledChanges)
+		}
+	})
+
+	t.Run("删除清理后仍能监听到变化", func(t *testing.T) {
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ch, err := repo.WatchRunnableTasks(watchCtx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the tombstone holds the latest revision, then it's purged.
+		if err := repo.DeleteTask(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+		waitKeys(t, ch, "a")
+		if err := repo.PurgeTask(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.GetTask(ctx, "a"); !errors.Is(err, taskrepo.ErrTaskNotFound) {
+			t.Fatalf("期望清理后任务不存在, 得到 %v", err)
+		}
+
+		if err := repo.CreateTask(ctx, &model.Task{TaskKey: "b", Status: model.TaskStatusWaitScheduling}); err != nil {
+			t.Fatal(err)
+		}
+		waitKeys(t, ch, "b")
+	})
+}
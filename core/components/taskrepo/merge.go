@@ -0,0 +1,36 @@
+package taskrepo
+
+import (
+	"reflect"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// fields never changed by UpdateTask.
+var immutableFields = map[string]bool{"ID": true, "TaskKey": true, "CreatedAt": true}
+
+// MergeTask sets non-zero fields of update to task, for repos storing whole
+// tasks. non-nil empty maps and slices are not zero, they clear the field.
+func MergeTask(task, update *model.Task) {
+	dst := reflect.ValueOf(task).Elem()
+	src := reflect.ValueOf(update).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		name := src.Type().Field(i).Name
+		if immutableFields[name] || src.Field(i).IsZero() {
+			continue
+		}
+		dst.Field(i).Set(src.Field(i))
+	}
+}
+
+// Paginate applies offset and limit, limit 0 means no limit.
+func Paginate(tasks []*model.Task, offset, limit int) []*model.Task {
+	if offset >= len(tasks) {
+		return nil
+	}
+	tasks = tasks[offset:]
+	if limit > 0 && limit < len(tasks) {
+		tasks = tasks[:limit]
+	}
+	return tasks
+}
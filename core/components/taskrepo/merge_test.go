@@ -0,0 +1,62 @@
+package taskrepo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestMergeTask(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	task := &model.Task{
+		ID:            1,
+		TaskKey:       "a",
+		Payload:       "p",
+		Status:        model.TaskStatusWaitRunning,
+		WantRunStatus: model.TaskStatusRunning,
+		CreatedAt:     created,
+	}
+	MergeTask(task, &model.Task{
+		TaskKey:   "b",
+		Status:    model.TaskStatusRunning,
+		WorkerID:  "w1",
+		CreatedAt: created.Add(time.Hour),
+	})
+
+	t.Run("非零字段被更新", func(t *testing.T) {
+		if task.Status != model.TaskStatusRunning || task.WorkerID != "w1" {
+			t.Errorf("期望更新 status 和 worker, 得到 %+v", task)
+		}
+	})
+
+	t.Run("零值字段保持不变", func(t *testing.T) {
+		if task.Payload != "p" || task.WantRunStatus != model.TaskStatusRunning {
+			t.Errorf("期望保留 payload 和 want status, 得到 %+v", task)
+		}
+	})
+
+	t.Run("不可变字段不被更新", func(t *testing.T) {
+		if task.ID != 1 || task.TaskKey != "a" || !task.CreatedAt.Equal(created) {
+			t.Errorf("期望保留 id, key 和创建时间, 得到 %+v", task)
+		}
+	})
+
+	t.Run("空的 map 清空字段", func(t *testing.T) {
+		task := &model.Task{Labels: map[string]string{"a": "1"}}
+		MergeTask(task, &model.Task{Labels: map[string]string{}})
+		if len(task.Labels) != 0 {
+			t.Errorf("期望清空标签, 得到 %v", task.Labels)
+		}
+	})
+}
+
+func TestPaginate(t *testing.T) {
+	tasks := []*model.Task{{TaskKey: "a"}, {TaskKey: "b"}, {TaskKey: "c"}}
+	if got := Paginate(tasks, 1, 1); len(got) != 1 || got[0].TaskKey != "b" {
+		t.Errorf("期望 b, 得到 %v", got)
+	}
+	if got := Paginate(tasks, 3, 1); len(got) != 0 {
+		t.Errorf("期望空, 得到 %v", got)
+	}
+}
@@ -0,0 +1,188 @@
+// Package partition manages monthly RANGE partitions of MySQL task history tables,
+// so retention cleanup is a partition drop rather than a massive DELETE.
+//
+// The table must be created partitioned by the time column, eg.
+//
+//	CREATE TABLE task_history (
+//	  ...
+//	  created_at DATETIME NOT NULL,
+//	  PRIMARY KEY (id, created_at)
+//	) PARTITION BY RANGE (TO_DAYS(created_at)) (
+//	  PARTITION p202601 VALUES LESS THAN (TO_DAYS('2026-02-01'))
+//	);
+//
+// Note that MySQL requires the partition column in every unique key.
+package partition
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+)
+
+const partitionPrefix = "p"
+
+type Config struct {
+	// partitioned table name.
+	Table string
+	// column used by RANGE(TO_DAYS(column)).
+	Column string
+	// number of months kept, older partitions will be dropped. 0 means keep forever.
+	RetentionMonths int
+	// number of future months created in advance.
+	PremakeMonths int
+	// interval of checking partitions.
+	CheckInterval time.Duration
+}
+
+type Manager struct {
+	db  *sql.DB
+	cfg Config
+	now func() time.Time
+}
+
+func NewManager(db *sql.DB, cfg Config) *Manager {
+	if cfg.Column == "" {
+		cfg.Column = "created_at"
+	}
+	if cfg.PremakeMonths <= 0 {
+		cfg.PremakeMonths = 2
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = 12 * time.Hour
+	}
+	return &Manager{db: db, cfg: cfg, now: time.Now}
+}
+
+// Run maintain partitions periodically until ctx done.
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Maintain(ctx); err != nil {
+			log.Error("[Partition] maintain table %s failed: %v", m.cfg.Table, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Maintain create missing future partitions and drop expired ones.
+func (m *Manager) Maintain(ctx context.Context) error {
+	existing, err := m.listPartitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	toAdd, toDrop := plan(existing, m.now(), m.cfg.PremakeMonths, m.cfg.RetentionMonths)
+	if len(toAdd) > 0 {
+		if _, err := m.db.ExecContext(ctx, addPartitionSQL(m.cfg.Table, toAdd)); err != nil {
+			return fmt.Errorf("add partitions %v: %w", toAdd, err)
+		}
+		log.Info("[Partition] table %s add partitions: %v", m.cfg.Table, toAdd)
+	}
+	if len(toDrop) > 0 {
+		if _, err := m.db.ExecContext(ctx, dropPartitionSQL(m.cfg.Table, toDrop)); err != nil {
+			return fmt.Errorf("drop partitions %v: %w", toDrop, err)
+		}
+		log.Info("[Partition] table %s drop partitions: %v", m.cfg.Table, toDrop)
+	}
+	return nil
+}
+
+func (m *Manager) listPartitions(ctx context.Context) ([]string, error) {
+	rows, err := m.db.QueryContext(ctx,
+		"SELECT PARTITION_NAME FROM information_schema.PARTITIONS "+
+			"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND PARTITION_NAME IS NOT NULL",
+		m.cfg.Table,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// Name returns the partition name of the month t belongs to, eg. p202610.
+func Name(t time.Time) string {
+	return partitionPrefix + t.Format("200601")
+}
+
+func parseName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, partitionPrefix) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("200601", strings.TrimPrefix(name, partitionPrefix), time.UTC)
+	return t, err == nil
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// plan returns month starts of partitions need to add and partition names need to drop.
+func plan(existing []string, now time.Time, premake, retention int) (toAdd []time.Time, toDrop []string) {
+	exist := make(map[string]struct{}, len(existing))
+	var latest time.Time
+	for _, name := range existing {
+		exist[name] = struct{}{}
+		if t, ok := parseName(name); ok && t.After(latest) {
+			latest = t
+		}
+	}
+
+	// partitions must be added in ascending order and after the latest one.
+	current := monthStart(now)
+	for i := 0; i <= premake; i++ {
+		month := current.AddDate(0, i, 0)
+		if _, ok := exist[Name(month)]; ok || !month.After(latest) {
+			continue
+		}
+		toAdd = append(toAdd, month)
+	}
+
+	if retention > 0 {
+		expiredBefore := current.AddDate(0, -retention+1, 0)
+		for _, name := range existing {
+			if t, ok := parseName(name); ok && t.Before(expiredBefore) {
+				toDrop = append(toDrop, name)
+			}
+		}
+		sort.Strings(toDrop)
+	}
+	return toAdd, toDrop
+}
+
+func addPartitionSQL(table string, months []time.Time) string {
+	defs := make([]string, 0, len(months))
+	for _, month := range months {
+		defs = append(defs, fmt.Sprintf(
+			"PARTITION %s VALUES LESS THAN (TO_DAYS('%s'))",
+			Name(month), month.AddDate(0, 1, 0).Format("2006-01-02"),
+		))
+	}
+	return fmt.Sprintf("ALTER TABLE `%s` ADD PARTITION (%s)", table, strings.Join(defs, ", "))
+}
+
+func dropPartitionSQL(table string, names []string) string {
+	return fmt.Sprintf("ALTER TABLE `%s` DROP PARTITION %s", table, strings.Join(names, ", "))
+}
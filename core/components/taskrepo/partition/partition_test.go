@@ -0,0 +1,32 @@
+package partition
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	existing := []string{"p202606", "p202607", "p202608", "p202609", "p202610"}
+
+	toAdd, toDrop := plan(existing, now, 2, 3)
+
+	wantAdd := []time.Time{
+		time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if !reflect.DeepEqual(toAdd, wantAdd) {
+		t.Errorf("toAdd = %v, want %v", toAdd, wantAdd)
+	}
+	wantDrop := []string{"p202606", "p202607"}
+	if !reflect.DeepEqual(toDrop, wantDrop) {
+		t.Errorf("toDrop = %v, want %v", toDrop, wantDrop)
+	}
+
+	got := addPartitionSQL("task_history", wantAdd[:1])
+	want := "ALTER TABLE `task_history` ADD PARTITION (PARTITION p202611 VALUES LESS THAN (TO_DAYS('2026-12-01')))"
+	if got != want {
+		t.Errorf("addPartitionSQL() = %s, want %s", got, want)
+	}
+}
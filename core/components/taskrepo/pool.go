@@ -0,0 +1,89 @@
+package taskrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+)
+
+// PoolConfig tunes the connection pool of backends based on database/sql,
+// zero fields keep the default of database/sql.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// Apply sets the pool of db, for gorm pass the db returned by gorm.DB.DB().
+func (c PoolConfig) Apply(db *sql.DB) {
+	if c.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(c.MaxOpenConns)
+	}
+	if c.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(c.MaxIdleConns)
+	}
+	if c.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+	if c.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	}
+}
+
+// ReportPoolStats publishes stats of the pool of db named name every interval
+// until ctx is done, a pool waiting for connections means the database,
+// not reconciling, is the bottleneck.
+func ReportPoolStats(ctx context.Context, name string, db *sql.DB, interval time.Duration) {
+	p := metrics.Global()
+	conns := p.NewGauge("minitaskx_db_connections", "connections of db pool by state", "db", "state")
+	waits := p.NewGauge("minitaskx_db_wait_count", "total connections waited for", "db")
+	waitSeconds := p.NewGauge("minitaskx_db_wait_seconds", "total time blocked waiting for connections", "db")
+	closed := p.NewGauge("minitaskx_db_closed_connections", "total connections closed by pool limits", "db", "reason")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		st := db.Stats()
+		conns.Set(float64(st.MaxOpenConnections), name, "max_open")
+		conns.Set(float64(st.OpenConnections), name, "open")
+		conns.Set(float64(st.InUse), name, "in_use")
+		conns.Set(float64(st.Idle), name, "idle")
+		waits.Set(float64(st.WaitCount), name)
+		waitSeconds.Set(st.WaitDuration.Seconds(), name)
+		closed.Set(float64(st.MaxIdleClosed), name, "max_idle")
+		closed.Set(float64(st.MaxIdleTimeClosed), name, "max_idle_time")
+		closed.Set(float64(st.MaxLifetimeClosed), name, "max_lifetime")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WithMetrics wraps repo to observe latency of each call by method and result.
+func WithMetrics(repo Interface) Interface {
+	latency := metrics.Global().NewHistogram("minitaskx_repo_call_duration_seconds", "latency of task repo calls, result is ok, error or timeout", "method", "result")
+	return intercept(repo, func(ctx context.Context, method string, _ bool, fn func(ctx context.Context) error) error {
+		start := time.Now()
+		err := fn(ctx)
+		latency.Observe(time.Since(start).Seconds(), method, callResult(err))
+		return err
+	})
+}
+
+func callResult(err error) string {
+	switch {
+	case err == nil, errors.Is(err, ErrTaskNotFound):
+		return "ok"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return "timeout"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,25 @@
+package taskrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCallResult(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: nil, want: "ok"},
+		{err: fmt.Errorf("get: %w", ErrTaskNotFound), want: "ok"},
+		{err: context.DeadlineExceeded, want: "timeout"},
+		{err: errors.New("driver: bad connection"), want: "error"},
+	}
+	for _, tt := range tests {
+		if got := callResult(tt.err); got != tt.want {
+			t.Errorf("%v 期望 %s, 得到 %s", tt.err, tt.want, got)
+		}
+	}
+}
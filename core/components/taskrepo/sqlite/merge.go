@@ -0,0 +1,21 @@
+package sqlite
+
+// matchLabels reports whether labels have all pairs of selector.
+func matchLabels(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func nonEmpty(ss []string) []string {
+	ret := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if s != "" {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,12 @@
+package sqlite
+
+import "testing"
+
+func TestListHelpers(t *testing.T) {
+	t.Run("标签全部匹配", func(t *testing.T) {
+		labels := map[string]string{"env": "prod", "tier": "cold"}
+		if !matchLabels(labels, map[string]string{"env": "prod"}) || matchLabels(labels, map[string]string{"env": "dev"}) {
+			t.Errorf("标签匹配结果错误")
+		}
+	})
+}
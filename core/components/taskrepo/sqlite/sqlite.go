@@ -0,0 +1,547 @@
+// Package sqlite implements taskrepo.Interface on SQLite, so that scheduler
+// and worker can run in one binary without external infrastructure,
+// eg. edge agents and demos.
+//
+// The package only depends on database/sql, register a driver by importing it, eg.
+//
+//	import _ "modernc.org/sqlite" // driver name "sqlite"
+//	import _ "github.com/mattn/go-sqlite3" // driver name "sqlite3", needs cgo
+//
+//	db, err := sql.Open("sqlite", "file:minitaskx.db?_pragma=busy_timeout(5000)")
+//	repo, err := sqlite.New(db, sqlite.WithPoolStats("tasks", 15*time.Second))
+//
+// Specs of tasks are stored as JSON, columns are only kept for filtering.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+const (
+	table = "minitaskx_task"
+	// one row holding the last revision given out, so that revisions of
+	// purged rows are never given out again.
+	sequenceTable = "minitaskx_revision"
+)
+
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS ` + table + ` (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_key TEXT NOT NULL UNIQUE,
+		biz_id TEXT NOT NULL DEFAULT '',
+		biz_type TEXT NOT NULL DEFAULT '',
+		type TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT '',
+		worker_id TEXT NOT NULL DEFAULT '',
+		deleted INTEGER NOT NULL DEFAULT 0,
+		revision INTEGER NOT NULL DEFAULT 0,
+		data TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_` + table + `_biz ON ` + table + ` (biz_type, biz_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_` + table + `_worker ON ` + table + ` (worker_id, status)`,
+	`CREATE INDEX IF NOT EXISTS idx_` + table + `_revision ON ` + table + ` (revision)`,
+	`CREATE TABLE IF NOT EXISTS ` + sequenceTable + ` (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		revision INTEGER NOT NULL
+	)`,
+	// databases created before the sequence table start from revisions of tasks.
+	`INSERT OR IGNORE INTO ` + sequenceTable + ` (id, revision) SELECT 1, COALESCE(MAX(revision), 0) FROM ` + table,
+}
+
+type Repo struct {
+	db   *sql.DB
+	opts *options
+	// stops reporting stats of the pool.
+	cancel context.CancelFunc
+}
+
+var _ taskrepo.Interface = (*Repo)(nil)
+
+type options struct {
+	watchInterval time.Duration
+	now           func() time.Time
+
+	pool taskrepo.PoolConfig
+	// name and interval of reporting stats of the pool, 0 disables it.
+	poolStatsName     string
+	poolStatsInterval time.Duration
+}
+
+type Option func(o *options)
+
+// WithWatchInterval sets how often WatchRunnableTasks polls changed tasks, default 500ms.
+func WithWatchInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.watchInterval = interval
+	}
+}
+
+// WithPool tunes the pool of db, MaxOpenConns is ignored, see New.
+func WithPool(cfg taskrepo.PoolConfig) Option {
+	return func(o *options) {
+		o.pool = cfg
+	}
+}
+
+// WithPoolStats publishes stats of the pool of db as name every interval
+// until the repo is closed, see taskrepo.ReportPoolStats.
+func WithPoolStats(name string, interval time.Duration) Option {
+	return func(o *options) {
+		o.poolStatsName = name
+		o.poolStatsInterval = interval
+	}
+}
+
+// New creates tables if not exist. the pool of db is limited to one connection,
+// since SQLite serializes writes and every connection of ":memory:" is a new database.
+func New(db *sql.DB, opts ...Option) (*Repo, error) {
+	o := &options{watchInterval: 500 * time.Millisecond, now: time.Now}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.pool.Apply(db)
+	db.SetMaxOpenConns(1)
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, errors.Wrap(err, "create schema")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if o.poolStatsInterval > 0 {
+		go taskrepo.ReportPoolStats(ctx, o.poolStatsName, db, o.poolStatsInterval)
+	}
+	return &Repo{db: db, opts: o, cancel: cancel}, nil
+}
+
+// Close stops reporting stats of the pool, db is left open for its owner.
+func (r *Repo) Close() error {
+	r.cancel()
+	return nil
+}
+
+func (r *Repo) CreateTask(ctx context.Context, task *model.Task) error {
+	return r.tx(ctx, func(tx *sql.Tx) error {
+		if _, err := get(ctx, tx, task.TaskKey); err == nil {
+			return errors.Wrapf(taskrepo.ErrDuplicateTask, "task %s", task.TaskKey)
+		} else if !errors.Is(err, taskrepo.ErrTaskNotFound) {
+			return err
+		}
+
+		t := *task
+		now := r.opts.now()
+		if t.CreatedAt.IsZero() {
+			t.CreatedAt = now
+		}
+		t.UpdatedAt = now
+		data, err := json.Marshal(&t)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		revision, err := nextRevision(ctx, tx)
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO `+table+` (task_key, biz_id, biz_type, type, status, worker_id, deleted, revision, data)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			t.TaskKey, t.BizID, t.BizType, t.Type, string(t.Status), t.WorkerID, t.IsDeleted(), revision, string(data),
+		)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if id, err := res.LastInsertId(); err == nil {
+			task.ID = id
+		}
+		return nil
+	})
+}
+
+// UpdateTask updates non-zero fields of task, like gorm Updates with a struct.
+func (r *Repo) UpdateTask(ctx context.Context, task *model.Task) error {
+	return r.tx(ctx, func(tx *sql.Tx) error {
+		return r.update(ctx, tx, task)
+	})
+}
+
+func (r *Repo) BatchUpdateTasks(ctx context.Context, tasks []*model.Task) error {
+	return r.tx(ctx, func(tx *sql.Tx) error {
+		for _, task := range tasks {
+			if err := r.update(ctx, tx, task); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Repo) BatchUpdateTasksCAS(ctx context.Context, from []model.TaskStatus, updates []*model.Task) (applied bool, err error) {
+	err = r.tx(ctx, func(tx *sql.Tx) error {
+		tasks := make([]*model.Task, 0, len(updates))
+		for i, update := range updates {
+			task, err := get(ctx, tx, update.TaskKey)
+			if err != nil {
+				return err
+			}
+			if task.Status != from[i] {
+				return nil
+			}
+			tasks = append(tasks, task)
+		}
+		for i, task := range tasks {
+			taskrepo.MergeTask(task, updates[i])
+			if err := r.put(ctx, tx, task); err != nil {
+				return err
+			}
+		}
+		applied = true
+		return nil
+	})
+	return applied && err == nil, err
+}
+
+func (r *Repo) UpdateTaskGenerationCAS(ctx context.Context, from int64, update *model.Task) (applied bool, err error) {
+	err = r.tx(ctx, func(tx *sql.Tx) error {
+		task, err := get(ctx, tx, update.TaskKey)
+		if err != nil {
+			return err
+		}
+		if task.Generation != from {
+			return nil
+		}
+		taskrepo.MergeTask(task, update)
+		applied = true
+		return r.put(ctx, tx, task)
+	})
+	return applied && err == nil, err
+}
+
+func (r *Repo) UpdateTaskStatusCAS(ctx context.Context, taskKey string, from, to model.TaskStatus) (applied bool, err error) {
+	err = r.tx(ctx, func(tx *sql.Tx) error {
+		task, err := get(ctx, tx, taskKey)
+		if err != nil {
+			return err
+		}
+		if task.Status != from {
+			return nil
+		}
+		task.Status = to
+		applied = true
+		return r.put(ctx, tx, task)
+	})
+	return applied && err == nil, err
+}
+
+func (r *Repo) UpdateTaskCAS(ctx context.Context, from model.TaskStatus, update *model.Task) (applied bool, err error) {
+	err = r.tx(ctx, func(tx *sql.Tx) error {
+		task, err := get(ctx, tx, update.TaskKey)
+		if err != nil {
+			return err
+		}
+		if task.Status != from {
+			return nil
+		}
+		taskrepo.MergeTask(task, update)
+		applied = true
+		return r.put(ctx, tx, task)
+	})
+	return applied && err == nil, err
+}
+
+func (r *Repo) DeleteTask(ctx context.Context, taskKey string) error {
+	return r.tx(ctx, func(tx *sql.Tx) error {
+		task, err := get(ctx, tx, taskKey)
+		if err != nil {
+			return err
+		}
+		now := r.opts.now()
+		task.DeletedAt = &now
+		task.WantRunStatus = model.TaskStatusNotExist
+		return r.put(ctx, tx, task)
+	})
+}
+
+func (r *Repo) PurgeTask(ctx context.Context, taskKey string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE task_key = ?`, taskKey)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.Wrapf(taskrepo.ErrTaskNotFound, "task %s", taskKey)
+	}
+	return nil
+}
+
+// GetTask returns ErrTaskNotFound for soft deleted tasks, they are hidden from users.
+func (r *Repo) GetTask(ctx context.Context, taskKey string) (*model.Task, error) {
+	task, err := get(ctx, r.db, taskKey)
+	if err != nil {
+		return nil, err
+	}
+	if task.IsDeleted() {
+		return nil, errors.Wrapf(taskrepo.ErrTaskNotFound, "task %s is deleted", taskKey)
+	}
+	return task, nil
+}
+
+// BatchGetTask returns soft deleted tasks too, so that workers stop their executors.
+func (r *Repo) BatchGetTask(ctx context.Context, taskKeys []string) ([]*model.Task, error) {
+	if len(taskKeys) == 0 {
+		return nil, nil
+	}
+	args := make([]any, 0, len(taskKeys))
+	for _, key := range taskKeys {
+		args = append(args, key)
+	}
+	return r.query(ctx, `SELECT id, data FROM `+table+` WHERE task_key IN (`+placeholders(len(args))+`) ORDER BY id`, args...)
+}
+
+func (r *Repo) ListTask(ctx context.Context, filter *model.TaskFilter) ([]*model.Task, error) {
+	where := []string{"deleted = ?"}
+	args := []any{filter.OnlyDeleted}
+	if bizIDs := nonEmpty(filter.BizIDs); len(bizIDs) > 0 {
+		where = append(where, "biz_id IN ("+placeholders(len(bizIDs))+")")
+		for _, id := range bizIDs {
+			args = append(args, id)
+		}
+	}
+	if filter.BizType != "" {
+		where = append(where, "biz_type = ?")
+		args = append(args, filter.BizType)
+	}
+	if filter.Type != "" {
+		where = append(where, "type = ?")
+		args = append(args, filter.Type)
+	}
+	if len(filter.Statuses) > 0 {
+		where = append(where, "status IN ("+placeholders(len(filter.Statuses))+")")
+		for _, st := range filter.Statuses {
+			args = append(args, string(st))
+		}
+	}
+
+	tasks, err := r.query(ctx, `SELECT id, data FROM `+table+` WHERE `+strings.Join(where, " AND ")+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	// labels are stored in data, match them after loading.
+	ret := make([]*model.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if matchLabels(task.Labels, filter.Labels) {
+			ret = append(ret, task)
+		}
+	}
+	return taskrepo.Paginate(ret, filter.Offset, filter.Limit), nil
+}
+
+// ListRunnableTasks returns unfinished tasks assigned to workerID,
+// all unfinished tasks if workerID is empty. tasks parked by pause are
+// excluded until resumed.
+func (r *Repo) ListRunnableTasks(ctx context.Context, workerID string) ([]string, error) {
+	q := `SELECT task_key FROM ` + table + ` WHERE status NOT IN (?, ?, ?)
+		AND COALESCE(json_extract(data, '$.next_run_at'), '') != ?`
+	args := []any{
+		string(model.TaskStatusSuccess), string(model.TaskStatusFailed), string(model.TaskStatusStop),
+		model.ParkedRunAt.Format(time.RFC3339Nano),
+	}
+	if workerID != "" {
+		q += ` AND worker_id = ?`
+		args = append(args, workerID)
+	}
+	rows, err := r.db.QueryContext(ctx, q+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, errors.WithStack(rows.Err())
+}
+
+// WatchRunnableTasks polls tasks changed since the last poll, tasks of
+// workerID are reported, all tasks if workerID is empty.
+func (r *Repo) WatchRunnableTasks(ctx context.Context, workerID string) (<-chan []string, error) {
+	var last int64
+	if err := r.db.QueryRowContext(ctx, `SELECT revision FROM `+sequenceTable+` WHERE id = 1`).Scan(&last); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ch := make(chan []string, 100)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(r.opts.watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			keys, revision, err := r.changedSince(ctx, workerID, last)
+			if err != nil {
+				if ctx.Err() == nil {
+					continue
+				}
+				return
+			}
+			last = revision
+			if len(keys) == 0 {
+				continue
+			}
+			select {
+			case ch <- keys:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (r *Repo) changedSince(ctx context.Context, workerID string, revision int64) ([]string, int64, error) {
+	q := `SELECT task_key, revision FROM ` + table + ` WHERE revision > ?`
+	args := []any{revision}
+	if workerID != "" {
+		q += ` AND worker_id = ?`
+		args = append(args, workerID)
+	}
+	rows, err := r.db.QueryContext(ctx, q+` ORDER BY revision`, args...)
+	if err != nil {
+		return nil, revision, errors.WithStack(err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key, &revision); err != nil {
+			return nil, revision, errors.WithStack(err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, revision, errors.WithStack(rows.Err())
+}
+
+func (r *Repo) update(ctx context.Context, tx *sql.Tx, update *model.Task) error {
+	task, err := get(ctx, tx, update.TaskKey)
+	if err != nil {
+		return err
+	}
+	taskrepo.MergeTask(task, update)
+	return r.put(ctx, tx, task)
+}
+
+// put writes task and bumps its revision, so that watchers see the change.
+func (r *Repo) put(ctx context.Context, tx *sql.Tx, task *model.Task) error {
+	task.UpdatedAt = r.opts.now()
+	data, err := json.Marshal(task)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	revision, err := nextRevision(ctx, tx)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE `+table+` SET biz_id = ?, biz_type = ?, type = ?, status = ?, worker_id = ?, deleted = ?, revision = ?, data = ?
+		WHERE task_key = ?`,
+		task.BizID, task.BizType, task.Type, string(task.Status), task.WorkerID, task.IsDeleted(), revision, string(data),
+		task.TaskKey,
+	)
+	return errors.WithStack(err)
+}
+
+func (r *Repo) query(ctx context.Context, q string, args ...any) ([]*model.Task, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer rows.Close()
+
+	var tasks []*model.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, errors.WithStack(rows.Err())
+}
+
+func (r *Repo) tx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return errors.WithStack(tx.Commit())
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func get(ctx context.Context, q queryer, taskKey string) (*model.Task, error) {
+	row := q.QueryRowContext(ctx, `SELECT id, data FROM `+table+` WHERE task_key = ?`, taskKey)
+	task, err := scanTask(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Wrapf(taskrepo.ErrTaskNotFound, "task %s", taskKey)
+	}
+	return task, err
+}
+
+// nextRevision bumps the sequence in tx, revisions only grow even if rows
+// of the latest revisions are purged.
+func nextRevision(ctx context.Context, tx *sql.Tx) (int64, error) {
+	if _, err := tx.ExecContext(ctx, `UPDATE `+sequenceTable+` SET revision = revision + 1 WHERE id = 1`); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	var revision int64
+	err := tx.QueryRowContext(ctx, `SELECT revision FROM `+sequenceTable+` WHERE id = 1`).Scan(&revision)
+	return revision, errors.WithStack(err)
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(s scanner) (*model.Task, error) {
+	var (
+		id   int64
+		data string
+	)
+	if err := s.Scan(&id, &data); err != nil {
+		return nil, err
+	}
+	var task model.Task
+	if err := json.Unmarshal([]byte(data), &task); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	task.ID = id
+	return &task, nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
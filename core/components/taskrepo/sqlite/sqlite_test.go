@@ -0,0 +1,231 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func newTestRepo(t *testing.T) *Repo {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	repo, err := New(db, WithWatchInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+// waitKeys waits until all keys are reported by ch.
+func waitKeys(t *testing.T, ch <-chan []string, keys ...string) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for len(keys) > 0 {
+		select {
+		case changed := <-ch:
+			keys = slices.DeleteFunc(keys, func(k string) bool { return slices.Contains(changed, k) })
+		case <-timeout:
+			t.Fatalf("期望监听到 %v 的变化", keys)
+		}
+	}
+}
+
+func TestRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	t.Run("创建更新和 CAS", func(t *testing.T) {
+		task := &model.Task{TaskKey: "a", BizType: "biz", Status: model.TaskStatusWaitScheduling, Payload: "p"}
+		if err := repo.CreateTask(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.CreateTask(ctx, task); !errors.Is(err, taskrepo.ErrDuplicateTask) {
+			t.Fatalf("期望重复创建返回 ErrDuplicateTask, 得到 %v", err)
+		}
+		if err := repo.UpdateTask(ctx, &model.Task{TaskKey: "a", WorkerID: "w1"}); err != nil {
+			t.Fatal(err)
+		}
+		applied, err := repo.UpdateTaskStatusCAS(ctx, "a", model.TaskStatusWaitScheduling, model.TaskStatusWaitRunning)
+		if err != nil || !applied {
+			t.Fatalf("期望 CAS 成功, 得到 %v %v", applied, err)
+		}
+		applied, err = repo.UpdateTaskStatusCAS(ctx, "a", model.TaskStatusWaitScheduling, model.TaskStatusRunning)
+		if err != nil || applied {
+			t.Fatalf("期望状态不符时 CAS 不生效, 得到 %v %v", applied, err)
+		}
+		applied, err = repo.UpdateTaskCAS(ctx, model.TaskStatusRunning, &model.Task{TaskKey: "a", Status: model.TaskStatusWaitStop, WantRunStatus: model.TaskStatusStop})
+		if err != nil || applied {
+			t.Fatalf("期望状态不符时不更新, 得到 %v %v", applied, err)
+		}
+		applied, err = repo.BatchUpdateTasksCAS(ctx, []model.TaskStatus{model.TaskStatusRunning}, []*model.Task{{TaskKey: "a", WorkerID: "w2"}})
+		if err != nil || applied {
+			t.Fatalf("期望批量状态不符时不更新, 得到 %v %v", applied, err)
+		}
+		applied, err = repo.UpdateTaskGenerationCAS(ctx, 1, &model.Task{TaskKey: "a", WorkerID: "w2", Generation: 2})
+		if err != nil || applied {
+			t.Fatalf("期望 generation 不符时不更新, 得到 %v %v", applied, err)
+		}
+		got, err := repo.GetTask(ctx, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != model.TaskStatusWaitRunning || got.WantRunStatus != "" || got.WorkerID != "w1" || got.Payload != "p" {
+			t.Fatalf("期望合并更新, 得到 %+v", got)
+		}
+		keys, err := repo.ListRunnableTasks(ctx, "w1")
+		if err != nil || !slices.Equal(keys, []string{"a"}) {
+			t.Fatalf("期望 w1 可运行任务 [a], 得到 %v %v", keys, err)
+		}
+	})
+
+	t.Run("删除清理后版本号不回退", func(t *testing.T) {
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		ch, err := repo.WatchRunnableTasks(watchCtx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the tombstone holds the latest revision, then it's purged.
+		if err := repo.DeleteTask(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+		waitKeys(t, ch, "a")
+		if err := repo.PurgeTask(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.GetTask(ctx, "a"); !errors.Is(err, taskrepo.ErrTaskNotFound) {
+			t.Fatalf("期望清理后任务不存在, 得到 %v", err)
+		}
+
+		if err := repo.CreateTask(ctx, &model.Task{TaskKey: "b", Status: model.TaskStatusWaitScheduling}); err != nil {
+			t.Fatal(err)
+		}
+		waitKeys(t, ch, "b")
+	})
+	t.Run("挂起的任务不可运行", func(t *testing.T) {
+		parked := model.ParkedRunAt
+		later := time.Now().Add(time.Hour)
+		for _, task := range []*model.Task{
+			{TaskKey: "parked", WorkerID: "w2", Status: model.TaskStatusPaused, NextRunAt: &parked},
+			{TaskKey: "backoff", WorkerID: "w2", Status: model.TaskStatusWaitRunning, NextRunAt: &later},
+			{TaskKey: "done", WorkerID: "w2", Status: model.TaskStatusSuccess},
+		} {
+			if err := repo.CreateTask(ctx, task); err != nil {
+				t.Fatal(err)
+			}
+		}
+		keys, err := repo.ListRunnableTasks(ctx, "w2")
+		if err != nil || !slices.Equal(keys, []string{"backoff"}) {
+			t.Fatalf("期望 w2 可运行任务 [backoff], 得到 %v %v", keys, err)
+		}
+	})
+
+	t.Run("软删除的任务只在墓碑中列出", func(t *testing.T) {
+		if err := repo.DeleteTask(ctx, "done"); err != nil {
+			t.Fatal(err)
+		}
+		tasks, err := repo.ListTask(ctx, &model.TaskFilter{OnlyDeleted: true})
+		if err != nil || len(tasks) != 1 || tasks[0].TaskKey != "done" || !tasks[0].IsDeleted() {
+			t.Fatalf("期望墓碑 [done], 得到 %v %v", tasks, err)
+		}
+		tasks, err = repo.ListTask(ctx, &model.TaskFilter{})
+		if err != nil || slices.ContainsFunc(tasks, func(t *model.Task) bool { return t.TaskKey == "done" }) {
+			t.Fatalf("期望不列出已删除的任务, 得到 %v %v", tasks, err)
+		}
+	})
+
+	t.Run("空的 map 清空字段", func(t *testing.T) {
+		if err := repo.UpdateTask(ctx, &model.Task{TaskKey: "backoff", Labels: map[string]string{"a": "1"}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.UpdateTask(ctx, &model.Task{TaskKey: "backoff", Labels: map[string]string{}}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := repo.GetTask(ctx, "backoff")
+		if err != nil || len(got.Labels) != 0 {
+			t.Fatalf("期望清空标签, 得到 %v %v", got, err)
+		}
+	})
+}
+
+// gaugeRecorder records the last value of gauges by name and label values.
+type gaugeRecorder struct {
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func (r *gaugeRecorder) NewCounter(string, string, ...string) metrics.Counter { return nil }
+
+func (r *gaugeRecorder) NewHistogram(string, string, ...string) metrics.Histogram { return nil }
+
+func (r *gaugeRecorder) NewGauge(name, _ string, _ ...string) metrics.Gauge {
+	return recordedGauge{r: r, name: name}
+}
+
+func (r *gaugeRecorder) get(key string) (float64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.values[key]
+	return v, ok
+}
+
+type recordedGauge struct {
+	r    *gaugeRecorder
+	name string
+}
+
+func (g recordedGauge) Set(v float64, labelValues ...string) {
+	g.r.mu.Lock()
+	defer g.r.mu.Unlock()
+	g.r.values[strings.Join(append([]string{g.name}, labelValues...), "/")] = v
+}
+
+func TestPoolStats(t *testing.T) {
+	prev := metrics.Global()
+	defer metrics.SetProvider(prev)
+	recorder := &gaugeRecorder{values: make(map[string]float64)}
+	metrics.SetProvider(recorder)
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo, err := New(db, WithPool(taskrepo.PoolConfig{MaxOpenConns: 10, MaxIdleConns: 1}), WithPoolStats("tasks", 10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if v, ok := recorder.get("minitaskx_db_connections/tasks/max_open"); ok {
+			if v != 1 {
+				t.Fatalf("期望连接池上限为 1, 得到 %v", v)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("期望上报连接池指标")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := recorder.get("minitaskx_db_wait_count/tasks"); !ok {
+		t.Error("期望上报等待次数")
+	}
+}
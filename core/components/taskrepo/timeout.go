@@ -0,0 +1,42 @@
+package taskrepo
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts bounds each call of repo, 0 means only the deadline of ctx applies.
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+// WithTimeouts wraps repo so that calls fail fast with ctx.Err() once ctx is done,
+// and reads and writes are bounded by t. errors of backends caused by an expired
+// ctx are replaced by ctx.Err(), so that callers can check context.DeadlineExceeded.
+// WatchRunnableTasks is long-lived, only ctx applies to it.
+func WithTimeouts(repo Interface, t Timeouts) Interface {
+	return intercept(repo, func(ctx context.Context, _ string, write bool, fn func(ctx context.Context) error) error {
+		timeout := t.Read
+		if write {
+			timeout = t.Write
+		}
+		return callWithTimeout(ctx, timeout, fn)
+	})
+}
+
+func callWithTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := fn(ctx)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
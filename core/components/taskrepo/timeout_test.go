@@ -0,0 +1,42 @@
+package taskrepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// blockingRepo blocks reads until ctx is done, and fails with a driver error.
+type blockingRepo struct {
+	Interface
+	calls int
+}
+
+func (r *blockingRepo) GetTask(ctx context.Context, _ string) (*model.Task, error) {
+	r.calls++
+	<-ctx.Done()
+	return nil, errors.New("driver: bad connection")
+}
+
+func TestWithTimeouts(t *testing.T) {
+	t.Run("超时返回 ctx 错误", func(t *testing.T) {
+		repo := WithTimeouts(&blockingRepo{}, Timeouts{Read: 10 * time.Millisecond})
+		_, err := repo.GetTask(context.Background(), "a")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("期望 DeadlineExceeded, 得到 %v", err)
+		}
+	})
+
+	t.Run("ctx 已取消时不调用 repo", func(t *testing.T) {
+		inner := &blockingRepo{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := WithTimeouts(inner, Timeouts{}).GetTask(ctx, "a")
+		if !errors.Is(err, context.Canceled) || inner.calls != 0 {
+			t.Errorf("期望直接返回 Canceled, 得到 %v, 调用 %d 次", err, inner.calls)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package taskrepo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+const exportPageSize = 500
+
+// ExportTasks write tasks matched filter to w as JSON lines, for backup and migration.
+// filter's Offset and Limit are used as start position and max count, Limit <= 0 means all.
+func ExportTasks(ctx context.Context, repo Interface, filter *model.TaskFilter, w io.Writer) (n int, err error) {
+	f := model.TaskFilter{}
+	if filter != nil {
+		f = *filter
+	}
+	limit := f.Limit
+
+	enc := json.NewEncoder(w)
+	for {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+
+		f.Limit = exportPageSize
+		if limit > 0 {
+			f.Limit = min(exportPageSize, limit-n)
+		}
+		tasks, err := repo.ListTask(ctx, &f)
+		if err != nil {
+			return n, err
+		}
+		for _, task := range tasks {
+			if err := enc.Encode(task); err != nil {
+				return n, err
+			}
+			n++
+		}
+
+		if len(tasks) < f.Limit || (limit > 0 && n >= limit) {
+			return n, nil
+		}
+		f.Offset += len(tasks)
+	}
+}
+
+// ImportTasks read JSON lines produced by ExportTasks and create tasks by create,
+// keys, statuses and labels are preserved. create validates tasks before
+// writing them to repo, it stops the import on the first error.
+func ImportTasks(ctx context.Context, create func(ctx context.Context, task *model.Task) error, r io.Reader) (n int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+
+		var task model.Task
+		if err := json.Unmarshal(scanner.Bytes(), &task); err != nil {
+			return n, fmt.Errorf("line %d: %w", line, err)
+		}
+		if task.TaskKey == "" {
+			return n, fmt.Errorf("line %d: task key is empty", line)
+		}
+		if err := create(ctx, &task); err != nil {
+			return n, fmt.Errorf("line %d, task %s: %w", line, task.TaskKey, err)
+		}
+		n++
+	}
+	return n, scanner.Err()
+}
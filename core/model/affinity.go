@@ -0,0 +1,26 @@
+package model
+
+// Affinity places a task regarding tasks already assigned to workers.
+// a task matches a selector if it has all labels of the selector.
+type Affinity struct {
+	// runs on workers running a task of the selector, eg. {"dataset": "d1"},
+	// on any worker if no such task is assigned yet.
+	Tasks map[string]string `json:"tasks,omitempty"`
+	// never runs on workers running a task of the selector, eg. shards of a
+	// group carry {"shard-group": "g1"} as both labels and anti affinity.
+	AntiTasks map[string]string `json:"anti_tasks,omitempty"`
+}
+
+// MatchLabels reports whether labels have all labels of selector, empty
+// selector matches nothing.
+func MatchLabels(selector, labels map[string]string) bool {
+	if len(selector) == 0 {
+		return false
+	}
+	for k, v := range selector {
+		if l, ok := labels[k]; !ok || l != v {
+			return false
+		}
+	}
+	return true
+}
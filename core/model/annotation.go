@@ -0,0 +1,24 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReservedPrefix prefixes keys of annotations and labels set by the
+// framework, users can not set annotations of it.
+const ReservedPrefix = "minitaskx.io/"
+
+// ValidateAnnotations returns error if keys are empty or reserved.
+func ValidateAnnotations[V any](annotations map[string]V) error {
+	for k := range annotations {
+		if k == "" {
+			return errors.New("annotation key must be non-empty")
+		}
+		if strings.HasPrefix(k, ReservedPrefix) {
+			return errors.Errorf("annotation %s is reserved, prefix %s is used by the framework", k, ReservedPrefix)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package model
+
+// LabelExclusionGroup marks tasks never running concurrently cluster-wide,
+// eg. two migrations touching the same table.
+const LabelExclusionGroup = "minitaskx.io/exclusion-group"
+
+// ExclusionGroup returns the exclusion group of the task, empty if none.
+func (t *Task) ExclusionGroup() string {
+	return t.Labels[LabelExclusionGroup]
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ExecutorTypesKey is the worker metadata key of comma separated task types
+// the worker has registered executors for.
+const ExecutorTypesKey = "exec_types"
+
+// LabelExecutorVersion requires the task to run on workers reporting this executor version.
+const LabelExecutorVersion = "minitaskx.io/executor-version"
+
+// labels attached to runtime objects, eg. containers, to find tasks running in them.
+const (
+	LabelTaskKey  = "minitaskx.io/task-key"
+	LabelTaskType = "minitaskx.io/task-type"
+)
+
+// ParseExecutorTypes returns task types reported in worker metadata,
+// false if the worker does not report them.
+func ParseExecutorTypes(metadata map[string]string) (map[string]bool, bool) {
+	value, ok := metadata[ExecutorTypesKey]
+	if !ok {
+		return nil, false
+	}
+	types := make(map[string]bool)
+	for _, t := range strings.Split(value, ",") {
+		if t != "" {
+			types[t] = true
+		}
+	}
+	return types, true
+}
+
+// ExecutorVersionKeyPrefix is the prefix of worker metadata keys
+// reporting executor version of task types, eg. "exec_version_email": "v2".
+const ExecutorVersionKeyPrefix = "exec_version_"
+
+func ExecutorVersionKey(taskType string) string {
+	return ExecutorVersionKeyPrefix + taskType
+}
+
+// ParseExecutorVersions returns executor version of task types reported in worker metadata.
+func ParseExecutorVersions(metadata map[string]string) map[string]string {
+	result := make(map[string]string)
+	for key, value := range metadata {
+		if !strings.HasPrefix(key, ExecutorVersionKeyPrefix) {
+			continue
+		}
+		result[strings.TrimPrefix(key, ExecutorVersionKeyPrefix)] = value
+	}
+	return result
+}
+
+// PayloadSchemaKeyPrefix is the prefix of worker metadata keys reporting
+// the payload schema of task types, eg. "exec_schema_email": {"version": "2", ...}.
+const PayloadSchemaKeyPrefix = "exec_schema_"
+
+func PayloadSchemaKey(taskType string) string {
+	return PayloadSchemaKeyPrefix + taskType
+}
+
+// PayloadSchema describes payloads accepted by the executor of a task type,
+// Version is bumped on incompatible changes.
+type PayloadSchema struct {
+	Version string `json:"version"`
+	// JSON schema of payload, optional.
+	Schema json.RawMessage `json:"schema,omitempty"`
+}
+
+// ParsePayloadSchemas returns payload schema of task types reported in worker metadata,
+// malformed entries are ignored.
+func ParsePayloadSchemas(metadata map[string]string) map[string]PayloadSchema {
+	result := make(map[string]PayloadSchema)
+	for key, value := range metadata {
+		if !strings.HasPrefix(key, PayloadSchemaKeyPrefix) {
+			continue
+		}
+		var schema PayloadSchema
+		if err := json.Unmarshal([]byte(value), &schema); err != nil {
+			continue
+		}
+		result[strings.TrimPrefix(key, PayloadSchemaKeyPrefix)] = schema
+	}
+	return result
+}
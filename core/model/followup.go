@@ -0,0 +1,104 @@
+package model
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// labels of tasks declaring follow-ups and of follow-up tasks.
+const (
+	// LabelFollowUp is pending until follow-ups or compensations of the finished task are created, then done.
+	LabelFollowUp = "minitaskx.io/follow-up"
+	// LabelParent is the key of the task a follow-up task is created by.
+	LabelParent = "minitaskx.io/parent"
+)
+
+const (
+	FollowUpPending = "pending"
+	FollowUpDone    = "done"
+)
+
+// FollowUp is a task created once its parent finishes with status On,
+// follow-ups declaring their own follow-ups form a linear pipeline.
+type FollowUp struct {
+	// TaskStatusSuccess or TaskStatusFailed, failed tasks are followed up
+	// after retries are exhausted.
+	On TaskStatus `json:"on"`
+	// biz type of the follow-up task, default the parent's.
+	BizType string `json:"biz_type,omitempty"`
+	Type    string `json:"type"`
+	// text/template executed with the parent task, eg. {"input": {{printf "%q" .Msg}}}.
+	Payload   string            `json:"payload,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Retry     *RetryPolicy      `json:"retry,omitempty"`
+	FollowUps []FollowUp        `json:"follow_ups,omitempty"`
+	// undoes the follow-up task once a later step of the chain fails.
+	Compensation *Compensation `json:"compensation,omitempty"`
+}
+
+// ValidateFollowUps returns error if any follow-up can never be created.
+func ValidateFollowUps(followUps []FollowUp) error {
+	for i, f := range followUps {
+		if f.On != TaskStatusSuccess && f.On != TaskStatusFailed {
+			return errors.Errorf("invalid follow-up %d, on must be success or failed: %q", i, f.On)
+		}
+		if f.Type == "" {
+			return errors.Errorf("invalid follow-up %d, need type", i)
+		}
+		if _, err := template.New("payload").Parse(f.Payload); err != nil {
+			return errors.Wrapf(err, "invalid follow-up %d payload", i)
+		}
+		if err := ValidateCompensation(f.Compensation); err != nil {
+			return errors.WithMessagef(err, "invalid follow-up %d", i)
+		}
+		if err := ValidateFollowUps(f.FollowUps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewFollowUp returns the index-th follow-up task of t, its payload is rendered with t.
+func (t *Task) NewFollowUp(index int) (*Task, error) {
+	f := t.FollowUps[index]
+	payload, err := t.renderPayload(f.Payload)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "follow-up %d", index)
+	}
+
+	labels := make(map[string]string, len(f.Labels)+1)
+	for k, v := range f.Labels {
+		labels[k] = v
+	}
+	labels[LabelParent] = t.TaskKey
+	bizType := f.BizType
+	if bizType == "" {
+		bizType = t.BizType
+	}
+	return &Task{
+		BizID:     t.BizID,
+		BizType:   bizType,
+		Type:      f.Type,
+		Payload:   payload,
+		Labels:    labels,
+		Retry:     f.Retry,
+		FollowUps: f.FollowUps,
+
+		Compensation: f.Compensation,
+	}, nil
+}
+
+// renderPayload executes text/template text with t.
+func (t *Task) renderPayload(text string) (string, error) {
+	tmpl, err := template.New("payload").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid payload")
+	}
+	var payload strings.Builder
+	if err := tmpl.Execute(&payload, t); err != nil {
+		return "", errors.Wrap(err, "render payload")
+	}
+	return payload.String(), nil
+}
@@ -0,0 +1,7 @@
+package model
+
+// InGang reports whether the task belongs to a gang, tasks of a gang
+// are placed together or not at all.
+func (t *Task) InGang() bool {
+	return t.Gang != "" && t.GangSize > 1
+}
@@ -0,0 +1,37 @@
+package model
+
+type GateKind string
+
+const (
+	// GateHTTP is open while URL answers 2xx, the host of URL must be allowed on scheduler.
+	GateHTTP GateKind = "http"
+	// GateSQL is open while the predicate registered on scheduler returns true.
+	GateSQL GateKind = "sql"
+	// GateManual is open once Extra[ExtraGatePrefix+Name] is "true".
+	GateManual GateKind = "manual"
+	// GateTask is open once the task of TaskKey succeeded, the task inherits
+	// priority of tasks depending on it while they wait.
+	GateTask GateKind = "task"
+)
+
+// ExtraGatePrefix prefixes extra keys opening manual gates, eg. minitaskx.io/gate.release-approved.
+const ExtraGatePrefix = "minitaskx.io/gate."
+
+// Gate is an external condition which must be satisfied before the task is
+// scheduled for the first time, gates are not evaluated again once assigned.
+type Gate struct {
+	Name string   `json:"name"`
+	Kind GateKind `json:"kind"`
+	// url of GateHTTP.
+	URL string `json:"url,omitempty"`
+	// predicate name and its query args of GateSQL.
+	Predicate string   `json:"predicate,omitempty"`
+	Args      []string `json:"args,omitempty"`
+	// task GateTask depends on.
+	TaskKey string `json:"task_key,omitempty"`
+}
+
+// ManualGateOpened reports whether the manual gate name is opened through extra.
+func (t *Task) ManualGateOpened(name string) bool {
+	return t.Extra[ExtraGatePrefix+name] == "true"
+}
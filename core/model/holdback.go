@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// ParkedRunAt is NextRunAt of tasks paused before they started, they have no
+// executor and are not runnable until resumed.
+var ParkedRunAt = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// AnnotationHoldback records the time left of NextRunAt of a parked task,
+// NextRunAt is restored from it once the task is resumed.
+const AnnotationHoldback = ReservedPrefix + "holdback"
+
+// IsHeld reports whether the task is assigned but waits for NextRunAt, eg.
+// backoff of retries or the first run of its schedule.
+func (t *Task) IsHeld(now time.Time) bool {
+	return t.Status == TaskStatusWaitRunning && t.NextRunAt != nil && t.NextRunAt.After(now)
+}
+
+// IsParked reports whether NextRunAt of the task is parked by pause.
+func (t *Task) IsParked() bool {
+	return t.NextRunAt != nil && t.NextRunAt.Equal(ParkedRunAt)
+}
+
+// ParkedHoldback returns the time left of NextRunAt when the task was parked.
+func (t *Task) ParkedHoldback() time.Duration {
+	d, _ := time.ParseDuration(t.Annotations[AnnotationHoldback])
+	return max(d, 0)
+}
@@ -0,0 +1,35 @@
+package model
+
+import "strconv"
+
+// annotations of a task whose priority is boosted by a task of higher
+// priority depending on it by GateTask, so that the dependency is not
+// starved by tasks of priorities between them. set and reverted by scheduler.
+const (
+	// the priority inherited.
+	AnnotationInheritedPriority = "minitaskx.io/inherited-priority"
+	// key of the dependent task the priority is inherited from.
+	AnnotationInheritedFrom = "minitaskx.io/inherited-from"
+)
+
+// InheritedPriority returns the priority t inherited and the task it's
+// inherited from, false if t inherited none.
+func (t *Task) InheritedPriority() (int, string, bool) {
+	v, ok := t.Annotations[AnnotationInheritedPriority]
+	if !ok {
+		return 0, "", false
+	}
+	p, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, "", false
+	}
+	return p, t.Annotations[AnnotationInheritedFrom], true
+}
+
+// EffectivePriority is Priority boosted by the priority inherited.
+func (t *Task) EffectivePriority() int {
+	if p, _, ok := t.InheritedPriority(); ok && p > t.Priority {
+		return p
+	}
+	return t.Priority
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// QueueWait returns time from created to started.
+func (t *Task) QueueWait() (time.Duration, bool) {
+	if t.StartedAt == nil || t.CreatedAt.IsZero() {
+		return 0, false
+	}
+	return t.StartedAt.Sub(t.CreatedAt), true
+}
+
+// RunDuration returns time from started to finished.
+func (t *Task) RunDuration() (time.Duration, bool) {
+	if t.StartedAt == nil || t.FinishedAt == nil {
+		return 0, false
+	}
+	return t.FinishedAt.Sub(*t.StartedAt), true
+}
+
+// EndToEnd returns time from created to finished.
+func (t *Task) EndToEnd() (time.Duration, bool) {
+	if t.FinishedAt == nil || t.CreatedAt.IsZero() {
+		return 0, false
+	}
+	return t.FinishedAt.Sub(t.CreatedAt), true
+}
+
+type Percentiles struct {
+	Count int           `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+type LatencySummary struct {
+	QueueWait   Percentiles `json:"queue_wait"`
+	RunDuration Percentiles `json:"run_duration"`
+	EndToEnd    Percentiles `json:"end_to_end"`
+}
+
+// SummarizeLatency computes percentiles of tasks latencies, tasks without timestamps are ignored.
+func SummarizeLatency(tasks []*Task) LatencySummary {
+	var queueWait, run, e2e []time.Duration
+	for _, t := range tasks {
+		if d, ok := t.QueueWait(); ok {
+			queueWait = append(queueWait, d)
+		}
+		if d, ok := t.RunDuration(); ok {
+			run = append(run, d)
+		}
+		if d, ok := t.EndToEnd(); ok {
+			e2e = append(e2e, d)
+		}
+	}
+	return LatencySummary{
+		QueueWait:   percentiles(queueWait),
+		RunDuration: percentiles(run),
+		EndToEnd:    percentiles(e2e),
+	}
+}
+
+func percentiles(ds []time.Duration) Percentiles {
+	if len(ds) == 0 {
+		return Percentiles{}
+	}
+	slices.Sort(ds)
+	at := func(p float64) time.Duration {
+		idx := int(float64(len(ds)-1) * p)
+		return ds[idx]
+	}
+	return Percentiles{
+		Count: len(ds),
+		P50:   at(0.5),
+		P90:   at(0.9),
+		P99:   at(0.99),
+		Max:   ds[len(ds)-1],
+	}
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeLatency(t *testing.T) {
+	created := time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)
+	tasks := make([]*Task, 0, 10)
+	for i := 1; i <= 10; i++ {
+		started := created.Add(time.Duration(i) * time.Second)
+		finished := started.Add(time.Minute)
+		tasks = append(tasks, &Task{CreatedAt: created, StartedAt: &started, FinishedAt: &finished})
+	}
+	// 未开始的任务不参与统计
+	tasks = append(tasks, &Task{CreatedAt: created})
+
+	s := SummarizeLatency(tasks)
+	if s.QueueWait.Count != 10 {
+		t.Errorf("期望 10 个样本, 得到 %d", s.QueueWait.Count)
+	}
+	if s.QueueWait.P50 != 5*time.Second {
+		t.Errorf("queue wait p50 = %v, want 5s", s.QueueWait.P50)
+	}
+	if s.QueueWait.Max != 10*time.Second {
+		t.Errorf("queue wait max = %v, want 10s", s.QueueWait.Max)
+	}
+	if s.RunDuration.P99 != time.Minute {
+		t.Errorf("run duration p99 = %v, want 1m", s.RunDuration.P99)
+	}
+	if s.EndToEnd.P90 != time.Minute+9*time.Second {
+		t.Errorf("end to end p90 = %v, want 1m9s", s.EndToEnd.P90)
+	}
+}
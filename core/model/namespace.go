@@ -0,0 +1,37 @@
+package model
+
+import (
+	"strings"
+)
+
+// LabelNamespace is the namespace of the task, tasks without it are in the
+// namespace of their biz type.
+const LabelNamespace = "minitaskx.io/namespace"
+
+// NamespacesKey is the worker metadata key of comma separated namespaces the
+// worker is dedicated to, workers without it are shared by namespaces.
+const NamespacesKey = "namespaces"
+
+// Namespace returns the namespace of t, see LabelNamespace.
+func (t *Task) Namespace() string {
+	if ns := t.Labels[LabelNamespace]; ns != "" {
+		return ns
+	}
+	return t.BizType
+}
+
+// ParseNamespaces returns namespaces the worker is dedicated to,
+// false if the worker is shared.
+func ParseNamespaces(metadata map[string]string) (map[string]bool, bool) {
+	value := metadata[NamespacesKey]
+	if value == "" {
+		return nil, false
+	}
+	namespaces := make(map[string]bool)
+	for _, ns := range strings.Split(value, ",") {
+		if ns != "" {
+			namespaces[ns] = true
+		}
+	}
+	return namespaces, true
+}
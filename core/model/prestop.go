@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MaxPreStopGracePeriodSeconds caps grace periods of pre-stop hooks, so a
+// hook can not hold the stop of its task for long.
+const MaxPreStopGracePeriodSeconds = 600
+
+// PreStopHook is called by worker before the executor of a task is stopped,
+// so that external systems can be quiesced, like preStop hooks of containers.
+// if HTTPPost is not set, the executor of the task is called back, see
+// executor.PreStopper. the task is stopped even if the hook fails.
+type PreStopHook struct {
+	// succeeds if responds 2xx or 3xx, the body is the task in json.
+	// redirects are not followed, the host must be allowed by the scheduler.
+	HTTPPost string `json:"http_post,omitempty"`
+	// max time the hook runs before the executor is stopped anyway, default 30,
+	// at most MaxPreStopGracePeriodSeconds.
+	GracePeriodSeconds int `json:"grace_period_seconds,omitempty"`
+}
+
+func (h *PreStopHook) GracePeriod() time.Duration {
+	if h.GracePeriodSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(h.GracePeriodSeconds) * time.Second
+}
+
+// ValidatePreStop returns error if grace period of h is out of range, nil h is valid.
+func ValidatePreStop(h *PreStopHook) error {
+	if h == nil {
+		return nil
+	}
+	if h.GracePeriodSeconds < 0 || h.GracePeriodSeconds > MaxPreStopGracePeriodSeconds {
+		return errors.Errorf("invalid pre-stop hook, grace period seconds should be in [0, %d]", MaxPreStopGracePeriodSeconds)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"github.com/pkg/errors"
+)
+
+// LabelPreemption is "never" on tasks of priority classes never preempted.
+const LabelPreemption = "minitaskx.io/preemption"
+
+type PreemptionPolicy string
+
+const (
+	// tasks of the class are preempted lowest priority first, the default.
+	PreemptLowerPriority PreemptionPolicy = "lower_priority"
+	// tasks of the class are never preempted, eg. evicted by workers under pressure.
+	PreemptNever PreemptionPolicy = "never"
+)
+
+// PriorityClass names a priority and its policies, so that platform teams
+// manage priorities centrally, tasks reference it by name.
+type PriorityClass struct {
+	Name string `json:"name"`
+	// Priority of tasks of the class.
+	Value      int              `json:"value"`
+	Preemption PreemptionPolicy `json:"preemption,omitempty"`
+	// share of assignments while tasks of classes wait together, default 1.
+	// eg. weights 3 and 1 assign 3 tasks of the former per task of the latter.
+	Weight int `json:"weight,omitempty"`
+	// tasks without a class are of the default class, at most one is default.
+	Default bool `json:"default,omitempty"`
+}
+
+// ValidatePriorityClasses returns error if classes are ambiguous.
+func ValidatePriorityClasses(classes []PriorityClass) error {
+	names := make(map[string]bool, len(classes))
+	defaults := 0
+	for _, c := range classes {
+		if c.Name == "" || names[c.Name] {
+			return errors.Errorf("invalid priority class %q, name must be unique and non-empty", c.Name)
+		}
+		names[c.Name] = true
+		if c.Preemption != "" && c.Preemption != PreemptLowerPriority && c.Preemption != PreemptNever {
+			return errors.Errorf("invalid priority class %s, unknown preemption policy %q", c.Name, c.Preemption)
+		}
+		if c.Weight < 0 {
+			return errors.Errorf("invalid priority class %s, negative weight %d", c.Name, c.Weight)
+		}
+		if c.Default {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		return errors.New("at most one priority class is default")
+	}
+	return nil
+}
+
+// Preemptible reports whether t may be preempted, see LabelPreemption.
+func (t *Task) Preemptible() bool {
+	return t.Labels[LabelPreemption] != string(PreemptNever)
+}
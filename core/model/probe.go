@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// ProbeAction is what worker does when a probe keeps failing.
+type ProbeAction string
+
+const (
+	// ProbeActionFail exits the executor, the task turns to failed.
+	ProbeActionFail ProbeAction = "fail"
+	// ProbeActionRestart exits the executor and runs it again.
+	ProbeActionRestart ProbeAction = "restart"
+)
+
+// Probes are health checks of a running task run periodically by worker.
+// liveness probe starts after startup probe succeeds.
+type Probes struct {
+	Startup  *ProbeSpec `json:"startup,omitempty"`
+	Liveness *ProbeSpec `json:"liveness,omitempty"`
+	// default ProbeActionFail.
+	OnFailure ProbeAction `json:"on_failure,omitempty"`
+}
+
+// ProbeSpec probes by HTTPGet or Command of external processes, if neither is
+// set, the executor of the task is called back, see executor.Prober.
+type ProbeSpec struct {
+	// succeeds if responds 2xx or 3xx.
+	HTTPGet string `json:"http_get,omitempty"`
+	// succeeds if exits 0.
+	Command []string `json:"command,omitempty"`
+
+	InitialDelaySeconds int `json:"initial_delay_seconds,omitempty"`
+	// default 10.
+	PeriodSeconds int `json:"period_seconds,omitempty"`
+	// default 1.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// consecutive failures to trigger OnFailure, default 3.
+	FailureThreshold int `json:"failure_threshold,omitempty"`
+}
+
+func (p *ProbeSpec) InitialDelay() time.Duration {
+	return time.Duration(p.InitialDelaySeconds) * time.Second
+}
+
+func (p *ProbeSpec) Period() time.Duration {
+	if p.PeriodSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(p.PeriodSeconds) * time.Second
+}
+
+func (p *ProbeSpec) Timeout() time.Duration {
+	if p.TimeoutSeconds <= 0 {
+		return time.Second
+	}
+	return time.Duration(p.TimeoutSeconds) * time.Second
+}
+
+func (p *ProbeSpec) Threshold() int {
+	if p.FailureThreshold <= 0 {
+		return 3
+	}
+	return p.FailureThreshold
+}
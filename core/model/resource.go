@@ -12,6 +12,7 @@ import (
 
 const (
 	CpuUsageKey    = "rs_cpu_usage"
+	CpuCoresKey    = "rs_cpu_cores"
 	MemTotalKey    = "rs_mem_total"
 	MemUsedKey     = "rs_mem_used"
 	MemUsageKey    = "rs_mem_usage"
@@ -40,6 +41,7 @@ func GenerateResourceUsage() (map[string]string, error) {
 
 	return map[string]string{
 		CpuUsageKey:    strconv.FormatFloat(cpuPercent[0], 'f', 2, 64),
+		CpuCoresKey:    strconv.Itoa(runtime.NumCPU()),
 		MemTotalKey:    strconv.FormatFloat(float64(memInfo.Total)/(1024*1024*1024), 'f', 2, 64),
 		MemUsedKey:     strconv.FormatFloat(float64(memInfo.Used)/(1024*1024*1024), 'f', 2, 64),
 		MemUsageKey:    strconv.FormatFloat(memInfo.UsedPercent, 'f', 2, 64),
@@ -49,6 +51,36 @@ func GenerateResourceUsage() (map[string]string, error) {
 	}, nil
 }
 
+// ResourceRequest is resources a task is expected to use, workers report
+// their capacity by CpuCoresKey and MemTotalKey.
+type ResourceRequest struct {
+	// cores, eg. 0.5.
+	CPU float64 `json:"cpu,omitempty"`
+	// GiB, the unit of MemTotalKey.
+	Memory float64 `json:"memory,omitempty"`
+}
+
+// Validate returns error if requests are negative.
+func (r *ResourceRequest) Validate() error {
+	if r.CPU < 0 || r.Memory < 0 {
+		return fmt.Errorf("resource requests must be non-negative, got cpu %v, memory %v", r.CPU, r.Memory)
+	}
+	return nil
+}
+
+// ResourceUsage is resources a task used while running, sampled by executors
+// of tasks running out of the worker process. compare it with ResourceRequest
+// to right-size requests.
+type ResourceUsage struct {
+	// cores.
+	PeakCPU float64 `json:"peak_cpu"`
+	AvgCPU  float64 `json:"avg_cpu"`
+	// GiB.
+	PeakMemory float64 `json:"peak_memory"`
+	AvgMemory  float64 `json:"avg_memory"`
+	Samples    int     `json:"samples"`
+}
+
 // 生成污点标签
 func GenerateStain(ru map[string]string, disable bool) (map[string]string, error) {
 	u := ParseResourceUsage(ru)
@@ -65,6 +97,12 @@ func GenerateStain(ru map[string]string, disable bool) (map[string]string, error
 	return stain, nil
 }
 
+// IsCapacityStain reports whether the stain key marks worker has no capacity,
+// eg. resource pressure or going offline.
+func IsCapacityStain(key string) bool {
+	return key == stainPressureCPU || key == stainPressureMem || key == stainDisable
+}
+
 func ParseResourceUsage(metadata map[string]string) map[string]float64 {
 	result := make(map[string]float64)
 	for key, value := range metadata {
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// LabelRetry is pending while the task has retries left, then exhausted,
+// so that the retry controller lists only tasks it may retry.
+const LabelRetry = "minitaskx.io/retry"
+
+const (
+	RetryPending   = "pending"
+	RetryExhausted = "exhausted"
+)
+
+// RetryPolicy retries failed task up to MaxRetries times, the time before
+// the next attempt grows from InitialBackoffSeconds by Multiplier, capped by MaxBackoffSeconds.
+// zero backoff fields fall back to defaults of scheduler.
+type RetryPolicy struct {
+	MaxRetries            int     `json:"max_retries,omitempty"`
+	InitialBackoffSeconds int64   `json:"initial_backoff_seconds,omitempty"`
+	MaxBackoffSeconds     int64   `json:"max_backoff_seconds,omitempty"`
+	Multiplier            float64 `json:"multiplier,omitempty"`
+}
+
+// Backoff returns the delay before the retries-th retry (starts from 1).
+func (p RetryPolicy) Backoff(retries int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	delay := float64(p.InitialBackoffSeconds) * math.Pow(multiplier, float64(max(retries-1, 0)))
+	if p.MaxBackoffSeconds > 0 {
+		delay = math.Min(delay, float64(p.MaxBackoffSeconds))
+	}
+	return time.Duration(delay * float64(time.Second))
+}
+
+// CanRetry reports whether the failed task should be retried.
+func (t *Task) CanRetry() bool {
+	return t.Status == TaskStatusFailed && !t.IsDeleted() &&
+		t.Retry != nil && t.Retries < t.Retry.MaxRetries
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoffSeconds: 10, MaxBackoffSeconds: 60}
+	tests := []struct {
+		retries int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{4, 60 * time.Second},
+		{10, 60 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.Backoff(tt.retries); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.retries, got, tt.want)
+		}
+	}
+}
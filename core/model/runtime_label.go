@@ -0,0 +1,36 @@
+package model
+
+import "strings"
+
+// RuntimeLabelPrefix prefixes worker metadata keys of runtime labels, eg.
+// label_arch=amd64, tasks select workers by them, see Task.WorkerSelector.
+const RuntimeLabelPrefix = "label_"
+
+// runtime labels detected by workers.
+const (
+	// runtime.GOOS, eg. linux.
+	RuntimeLabelOS = "os"
+	// runtime.GOARCH, eg. arm64.
+	RuntimeLabelArch = "arch"
+	// logical cpus.
+	RuntimeLabelCPUs = "cpus"
+	// total memory in GiB, rounded down.
+	RuntimeLabelMemory = "memory_gib"
+	// "true" if the worker has gpus.
+	RuntimeLabelGPU = "gpu"
+	// number of gpus, only set if RuntimeLabelGPU is "true".
+	RuntimeLabelGPUs = "gpus"
+	// availability zone read from cloud metadata, eg. us-east-1a.
+	RuntimeLabelZone = "zone"
+)
+
+// ParseRuntimeLabels returns runtime labels of worker metadata without the prefix.
+func ParseRuntimeLabels(metadata map[string]string) map[string]string {
+	labels := make(map[string]string)
+	for key, value := range metadata {
+		if name, ok := strings.CutPrefix(key, RuntimeLabelPrefix); ok {
+			labels[name] = value
+		}
+	}
+	return labels
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// LabelCompensates is the key of the step a compensation task undoes.
+const LabelCompensates = "minitaskx.io/compensates"
+
+// Compensation undoes a succeeded step of a follow-up chain. once a step
+// fails after retries, compensations of succeeded steps before it run one
+// by one in reverse order, the failed step itself is not compensated.
+type Compensation struct {
+	// biz type of the compensation task, default the step's.
+	BizType string `json:"biz_type,omitempty"`
+	Type    string `json:"type"`
+	// text/template executed with the step, eg. {"order": "{{.BizID}}"}.
+	Payload string            `json:"payload,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Retry   *RetryPolicy      `json:"retry,omitempty"`
+}
+
+// ValidateCompensation returns error if c can never be created, nil c is valid.
+func ValidateCompensation(c *Compensation) error {
+	if c == nil {
+		return nil
+	}
+	if c.Type == "" {
+		return errors.New("invalid compensation, need type")
+	}
+	if _, err := template.New("payload").Parse(c.Payload); err != nil {
+		return errors.Wrap(err, "invalid compensation payload")
+	}
+	return nil
+}
+
+// NewCompensation returns the task undoing t, its payload is rendered with t.
+func (t *Task) NewCompensation() (*Task, error) {
+	c := t.Compensation
+	payload, err := t.renderPayload(c.Payload)
+	if err != nil {
+		return nil, errors.WithMessage(err, "compensation")
+	}
+
+	labels := make(map[string]string, len(c.Labels)+1)
+	for k, v := range c.Labels {
+		labels[k] = v
+	}
+	labels[LabelCompensates] = t.TaskKey
+	bizType := c.BizType
+	if bizType == "" {
+		bizType = t.BizType
+	}
+	return &Task{
+		BizID:   t.BizID,
+		BizType: bizType,
+		Type:    c.Type,
+		Payload: payload,
+		Labels:  labels,
+		Retry:   c.Retry,
+	}, nil
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xyzbit/minitaskx/pkg/util/cron"
+)
+
+// CatchUpPolicy decides how runs missed while the scheduler is down
+// or the task is held are handled.
+type CatchUpPolicy string
+
+const (
+	// CatchUpSkip drops missed runs, it's the default.
+	CatchUpSkip CatchUpPolicy = "skip"
+	// CatchUpOnce collapses missed runs into one run.
+	CatchUpOnce CatchUpPolicy = "once"
+	// CatchUpAll runs each missed run, at most MaxCatchUp of them.
+	CatchUpAll CatchUpPolicy = "all"
+)
+
+const (
+	defaultStartingDeadline = time.Minute
+	defaultMaxCatchUp       = 100
+)
+
+// CronSchedule runs a task at each match of Cron, evaluated in the IANA
+// Timezone so that runs follow DST transitions of the zone: wall clock
+// times skipped by DST never run, times repeated run once.
+type CronSchedule struct {
+	Cron string `json:"cron"`
+	// default UTC, never the local zone of scheduler.
+	Timezone string        `json:"timezone,omitempty"`
+	CatchUp  CatchUpPolicy `json:"catch_up,omitempty"`
+	// a run not started within it is missed, default 60s.
+	StartingDeadlineSeconds int64 `json:"starting_deadline_seconds,omitempty"`
+	// max missed runs replayed by CatchUpAll, default 100.
+	MaxCatchUp int `json:"max_catch_up,omitempty"`
+}
+
+func (s *CronSchedule) compile() (*cron.Schedule, *time.Location, error) {
+	schedule, err := cron.Parse(s.Cron)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc := time.UTC
+	if s.Timezone != "" {
+		if loc, err = time.LoadLocation(s.Timezone); err != nil {
+			return nil, nil, fmt.Errorf("invalid timezone %q: %v", s.Timezone, err)
+		}
+	}
+	switch s.CatchUp {
+	case "", CatchUpSkip, CatchUpOnce, CatchUpAll:
+	default:
+		return nil, nil, fmt.Errorf("invalid catch up policy %q", s.CatchUp)
+	}
+	return schedule, loc, nil
+}
+
+func (s *CronSchedule) Validate() error {
+	_, _, err := s.compile()
+	return err
+}
+
+// Next returns the first run strictly after t.
+func (s *CronSchedule) Next(t time.Time) (time.Time, error) {
+	schedule, loc, err := s.compile()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(t.In(loc)), nil
+}
+
+// Plan returns scheduled times of runs due at now, given last is the scheduled
+// time of the last run, and the time of the next run after now.
+// the latest run within starting deadline is always due, missed runs before
+// the deadline are handled by catch up policy.
+func (s *CronSchedule) Plan(last, now time.Time) (due []time.Time, next time.Time, err error) {
+	schedule, loc, err := s.compile()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	deadline := time.Duration(s.StartingDeadlineSeconds) * time.Second
+	if deadline <= 0 {
+		deadline = defaultStartingDeadline
+	}
+	maxCatchUp := s.MaxCatchUp
+	if maxCatchUp <= 0 {
+		maxCatchUp = defaultMaxCatchUp
+	}
+
+	var missed []time.Time
+	var onTime time.Time
+	for t := schedule.Next(last.In(loc)); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		if now.Sub(t) <= deadline {
+			onTime = t
+			continue
+		}
+		missed = append(missed, t)
+		// keep the latest ones.
+		if len(missed) > maxCatchUp {
+			missed = missed[1:]
+		}
+	}
+
+	switch s.CatchUp {
+	case CatchUpAll:
+		due = missed
+	case CatchUpOnce:
+		if len(missed) > 0 && onTime.IsZero() {
+			due = missed[len(missed)-1:]
+		}
+	}
+	if !onTime.IsZero() {
+		due = append(due, onTime)
+	}
+	return due, schedule.Next(now.In(loc)), nil
+}
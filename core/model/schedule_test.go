@@ -0,0 +1,82 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronSchedulePlan(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("缺少时区数据: %v", err)
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, 0, 0, loc)
+	}
+
+	t.Run("按任务时区计算并跳过夏令时不存在的时间", func(t *testing.T) {
+		s := &CronSchedule{Cron: "30 2 * * *", Timezone: "America/New_York"}
+		next, err := s.Next(at(9, 12, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !next.Equal(at(11, 2, 30)) {
+			t.Fatalf("期望 %s, 得到 %s", at(11, 2, 30), next)
+		}
+	})
+
+	// hourly runs from 00:00 to 05:00 of 03-01, now is 05:00:30.
+	last := at(1, 0, 0)
+	now := at(1, 5, 0).Add(30 * time.Second)
+	tests := []struct {
+		catchUp CatchUpPolicy
+		due     []time.Time
+	}{
+		{CatchUpSkip, []time.Time{at(1, 5, 0)}},
+		{CatchUpOnce, []time.Time{at(1, 5, 0)}},
+		{CatchUpAll, []time.Time{at(1, 1, 0), at(1, 2, 0), at(1, 3, 0), at(1, 4, 0), at(1, 5, 0)}},
+	}
+	for _, tt := range tests {
+		t.Run("补偿策略 "+string(tt.catchUp), func(t *testing.T) {
+			s := &CronSchedule{Cron: "@hourly", Timezone: "America/New_York", CatchUp: tt.catchUp}
+			due, next, err := s.Plan(last, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(due) != len(tt.due) {
+				t.Fatalf("期望 %v, 得到 %v", tt.due, due)
+			}
+			for i := range due {
+				if !due[i].Equal(tt.due[i]) {
+					t.Fatalf("期望 %v, 得到 %v", tt.due, due)
+				}
+			}
+			if !next.Equal(at(1, 6, 0)) {
+				t.Fatalf("下次运行期望 06:00, 得到 %s", next)
+			}
+		})
+	}
+
+	t.Run("没有准时的运行时只补偿一次", func(t *testing.T) {
+		s := &CronSchedule{Cron: "@hourly", CatchUp: CatchUpOnce}
+		due, _, err := s.Plan(last, at(1, 4, 30))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(due) != 1 || !due[0].Equal(at(1, 4, 0)) {
+			t.Fatalf("期望只补偿 04:00, 得到 %v", due)
+		}
+	})
+
+	t.Run("非法配置", func(t *testing.T) {
+		for _, s := range []*CronSchedule{
+			{Cron: "bad"},
+			{Cron: "@daily", Timezone: "Mars/Olympus"},
+			{Cron: "@daily", CatchUp: "sometimes"},
+		} {
+			if s.Validate() == nil {
+				t.Errorf("%+v 期望校验失败", s)
+			}
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LabelScheduledChange is pending while the task has scheduled changes,
+// so that the controller applying them lists only such tasks.
+const LabelScheduledChange = "minitaskx.io/scheduled-change"
+
+const ScheduledChangePending = "pending"
+
+// ScheduledChange changes want status of the task at At, eg. pause at 02:00
+// and resume at 04:00, instead of an external cron calling the api.
+type ScheduledChange struct {
+	At time.Time `json:"at"`
+	// TaskStatusPaused, TaskStatusRunning or TaskStatusStop.
+	Status TaskStatus `json:"status"`
+	// principal scheduled the change, recorded as the operator once applied.
+	Operator string `json:"operator,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// ValidateScheduledChanges returns error if any change can never be applied.
+func ValidateScheduledChanges(changes []ScheduledChange) error {
+	for i, c := range changes {
+		if c.At.IsZero() {
+			return errors.Errorf("invalid scheduled change %d, need at", i)
+		}
+		if c.Status != TaskStatusPaused && c.Status != TaskStatusRunning && c.Status != TaskStatusStop {
+			return errors.Errorf("invalid scheduled change %d, status must be paused, running or stop: %q", i, c.Status)
+		}
+	}
+	return nil
+}
+
+// DueChanges splits scheduled changes of t into changes due at now and the
+// pending rest, both sorted by At.
+func (t *Task) DueChanges(now time.Time) (due, pending []ScheduledChange) {
+	changes := slices.Clone(t.ScheduledChanges)
+	slices.SortStableFunc(changes, func(a, b ScheduledChange) int {
+		return a.At.Compare(b.At)
+	})
+	i := 0
+	for i < len(changes) && !changes[i].At.After(now) {
+		i++
+	}
+	return changes[:i], changes[i:]
+}
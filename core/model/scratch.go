@@ -0,0 +1,32 @@
+package model
+
+import "github.com/pkg/errors"
+
+// limits of the scratch of a task, it's stored with the task, so that it
+// must stay small, eg. cursors or offsets, not intermediate results.
+const (
+	MaxScratchKeys  = 64
+	MaxScratchBytes = 64 << 10
+)
+
+// ErrScratchTooLarge is returned when scratch exceeds MaxScratchKeys or MaxScratchBytes.
+var ErrScratchTooLarge = errors.New("scratch too large")
+
+// ValidateScratch returns error if keys are empty or scratch exceeds limits,
+// bytes are the total length of keys and values.
+func ValidateScratch(scratch map[string]string) error {
+	if len(scratch) > MaxScratchKeys {
+		return errors.Wrapf(ErrScratchTooLarge, "%d keys, max %d", len(scratch), MaxScratchKeys)
+	}
+	size := 0
+	for k, v := range scratch {
+		if k == "" {
+			return errors.New("scratch key must be non-empty")
+		}
+		size += len(k) + len(v)
+	}
+	if size > MaxScratchBytes {
+		return errors.Wrapf(ErrScratchTooLarge, "%d bytes, max %d", size, MaxScratchBytes)
+	}
+	return nil
+}
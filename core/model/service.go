@@ -0,0 +1,66 @@
+package model
+
+import "strconv"
+
+// TaskKind distinguishes run-to-completion jobs from long-running services.
+type TaskKind string
+
+const (
+	// TaskKindJob is the default kind, a task runs once until it finishes.
+	TaskKindJob TaskKind = ""
+	// TaskKindService keeps Replicas replica tasks running, replicas that
+	// exit are replaced by new ones. service itself is never run by workers.
+	TaskKindService TaskKind = "service"
+)
+
+// labels of replica tasks.
+const (
+	LabelService = "minitaskx.io/service"
+	LabelReplica = "minitaskx.io/replica"
+)
+
+func (t *Task) IsService() bool {
+	return t.Kind == TaskKindService
+}
+
+// NewReplica returns the index-th replica task of service, replicas run
+// with the spec of service. states of service, and specs of its own
+// lifecycle, eg. schedule, gates, approval, follow-ups and retry, are not
+// copied, the service controller replaces replicas that exit.
+func (t *Task) NewReplica(index int) *Task {
+	labels := make(map[string]string, len(t.Labels)+2)
+	for k, v := range t.Labels {
+		labels[k] = v
+	}
+	labels[LabelService] = t.TaskKey
+	labels[LabelReplica] = strconv.Itoa(index)
+
+	return &Task{
+		BizID:          t.BizID,
+		BizType:        t.BizType,
+		Type:           t.Type,
+		Payload:        t.Payload,
+		Labels:         labels,
+		Stains:         t.Stains,
+		Extra:          t.Extra,
+		SLA:            t.SLA,
+		Generation:     t.Generation,
+		Probes:         t.Probes,
+		Priority:       t.Priority,
+		PriorityClass:  t.PriorityClass,
+		Resources:      t.Resources,
+		PreStop:        t.PreStop,
+		LocalityKey:    t.LocalityKey,
+		Affinity:       t.Affinity,
+		WorkerSelector: t.WorkerSelector,
+	}
+}
+
+// ReplicaIndex returns index of replica task, false if it is not a replica.
+func (t *Task) ReplicaIndex() (int, bool) {
+	if t.Labels[LabelService] == "" {
+		return 0, false
+	}
+	index, err := strconv.Atoi(t.Labels[LabelReplica])
+	return index, err == nil
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// replicaSkipped are fields of service not copied to replicas, a field
+// added to Task must either be copied by NewReplica or listed here.
+var replicaSkipped = map[string]bool{
+	// states.
+	"ID": true, "TaskKey": true, "Status": true, "WantRunStatus": true, "WorkerID": true,
+	"Operator": true, "NextRunAt": true, "Msg": true, "CreatedAt": true, "UpdatedAt": true,
+	"AssignedAt": true, "StartedAt": true, "FinishedAt": true, "SLABreach": true,
+	"DeletedAt": true, "UnschedulableReason": true, "Retries": true, "LastHeartbeat": true,
+	"Source": true, "ApprovedBy": true, "ApprovedAt": true, "Epoch": true,
+	"Annotations": true, "Scratch": true, "Usage": true,
+	// lifecycle of the service itself.
+	"Kind": true, "Replicas": true, "Retry": true, "Gang": true, "GangSize": true,
+	"Schedule": true, "Gates": true, "ApprovalRequired": true, "FollowUps": true,
+	"Compensation": true, "ScheduledChanges": true,
+}
+
+func TestNewReplica(t *testing.T) {
+	service := &Task{}
+	fill(reflect.ValueOf(service).Elem())
+	replica := service.NewReplica(1)
+
+	t.Run("副本复制服务的全部 spec", func(t *testing.T) {
+		src, dst := reflect.ValueOf(service).Elem(), reflect.ValueOf(replica).Elem()
+		for i := 0; i < src.NumField(); i++ {
+			name := src.Type().Field(i).Name
+			if replicaSkipped[name] || name == "Labels" {
+				continue
+			}
+			if !reflect.DeepEqual(src.Field(i).Interface(), dst.Field(i).Interface()) {
+				t.Errorf("字段 %s 未复制到副本, 复制它或加入 replicaSkipped", name)
+			}
+		}
+	})
+
+	t.Run("副本标签指向服务", func(t *testing.T) {
+		if index, ok := replica.ReplicaIndex(); !ok || index != 1 || replica.Labels[LabelService] != service.TaskKey {
+			t.Errorf("副本标签错误: %v", replica.Labels)
+		}
+	})
+}
+
+// fill sets every field of v to a non-zero value.
+func fill(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString("x")
+		case reflect.Int, reflect.Int64:
+			f.SetInt(1)
+		case reflect.Bool:
+			f.SetBool(true)
+		case reflect.Map:
+			f.Set(reflect.MakeMap(f.Type()))
+			f.SetMapIndex(reflect.ValueOf("x"), reflect.ValueOf("x").Convert(f.Type().Elem()))
+		case reflect.Slice:
+			f.Set(reflect.MakeSlice(f.Type(), 1, 1))
+		case reflect.Pointer:
+			f.Set(reflect.New(f.Type().Elem()))
+		case reflect.Struct:
+			f.Set(reflect.ValueOf(time.Now()))
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// SLAPolicy declares the max queue time and max run time of a task, 0 means no limit.
+type SLAPolicy struct {
+	MaxQueueSeconds int64 `json:"max_queue_seconds,omitempty"`
+	MaxRunSeconds   int64 `json:"max_run_seconds,omitempty"`
+}
+
+// CheckSLA returns the breach reason of the task at now, empty means not breached.
+func (t *Task) CheckSLA(now time.Time) string {
+	if t.SLA == nil || t.Status.IsFinalStatus() {
+		return ""
+	}
+
+	if t.StartedAt == nil {
+		if max := t.SLA.MaxQueueSeconds; max > 0 && !t.CreatedAt.IsZero() {
+			if waited := now.Sub(t.CreatedAt); waited > time.Duration(max)*time.Second {
+				return fmt.Sprintf("queue time %s exceeds %ds", waited.Truncate(time.Second), max)
+			}
+		}
+		return ""
+	}
+
+	if max := t.SLA.MaxRunSeconds; max > 0 {
+		if ran := now.Sub(*t.StartedAt); ran > time.Duration(max)*time.Second {
+			return fmt.Sprintf("run time %s exceeds %ds", ran.Truncate(time.Second), max)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckSLA(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+	sla := &SLAPolicy{MaxQueueSeconds: 60, MaxRunSeconds: 600}
+	tests := []struct {
+		name   string
+		task   *Task
+		breach bool
+	}{
+		{
+			name: "未声明 SLA",
+			task: &Task{CreatedAt: *ago(time.Hour)},
+		},
+		{
+			name: "排队未超时",
+			task: &Task{SLA: sla, CreatedAt: *ago(30 * time.Second)},
+		},
+		{
+			name:   "排队超过截止时间",
+			task:   &Task{SLA: sla, CreatedAt: *ago(2 * time.Minute)},
+			breach: true,
+		},
+		{
+			name: "运行未超时",
+			task: &Task{SLA: sla, CreatedAt: *ago(time.Hour), StartedAt: ago(time.Minute)},
+		},
+		{
+			name:   "运行超过最长时间",
+			task:   &Task{SLA: sla, CreatedAt: *ago(time.Hour), StartedAt: ago(11 * time.Minute)},
+			breach: true,
+		},
+		{
+			name: "只限制排队时间",
+			task: &Task{SLA: &SLAPolicy{MaxQueueSeconds: 60}, CreatedAt: *ago(time.Hour), StartedAt: ago(time.Hour)},
+		},
+		{
+			name: "已结束的任务不检查",
+			task: &Task{SLA: sla, Status: TaskStatusSuccess, CreatedAt: *ago(time.Hour), StartedAt: ago(time.Hour)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.task.CheckSLA(now); (got != "") != tt.breach {
+				t.Errorf("CheckSLA() = %q, want breach %v", got, tt.breach)
+			}
+		})
+	}
+}
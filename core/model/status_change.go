@@ -17,6 +17,8 @@ const (
 	ChangeResume ChangeType = "resume"
 	ChangePause  ChangeType = "pause"
 	ChangeStop   ChangeType = "stop"
+	// apply a new spec(generation) to a running or paused executor.
+	ChangeUpdate ChangeType = "update"
 
 	ChangeExceptionUpdate ChangeType = "exception_update"
 	ChangeExceptionFinish ChangeType = "exception_finish"
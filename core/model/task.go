@@ -1,6 +1,15 @@
 package model
 
-import "time"
+import (
+	"slices"
+	"time"
+)
+
+// operators of system controllers.
+const (
+	OperatorScheduler = "system:scheduler"
+	OperatorWorker    = "system:worker"
+)
 
 type Task struct {
 	ID            int64             `json:"id,omitempty"`
@@ -15,12 +24,93 @@ type Task struct {
 	Status        TaskStatus        `json:"status,omitempty"`          // current real status
 	WantRunStatus TaskStatus        `json:"want_run_status,omitempty"` // want status
 	WorkerID      string            `json:"worker_id,omitempty"`
+	Operator      string            `json:"operator,omitempty"` // who requested the last want status change
 	NextRunAt     *time.Time        `json:"next_run_at,omitempty"`
 	Msg           string            `json:"msg,omitempty"`
 	CreatedAt     time.Time         `json:"created_at,omitempty"`
 	UpdatedAt     time.Time         `json:"updated_at,omitempty"`
+	// lifecycle timestamps: created -> assigned -> started -> finished.
+	AssignedAt *time.Time `json:"assigned_at,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// SLA of the task, SLABreach records the breach reason once alerted.
+	SLA       *SLAPolicy `json:"sla,omitempty"`
+	SLABreach string     `json:"sla_breach,omitempty"`
+	// soft delete mark, deleted task is hidden from user view
+	// but retained until no worker reports it.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// spec generation, increased when payload or labels of want task are changed.
+	// real task carries the generation its executor is running with.
+	Generation int64 `json:"generation,omitempty"`
+	// kind of task, services keep Replicas replica tasks running.
+	Kind     TaskKind `json:"kind,omitempty"`
+	Replicas int      `json:"replicas,omitempty"`
+	// why the task is unschedulable, Msg carries the detail.
+	// only meaningful when Status is TaskStatusUnschedulable.
+	UnschedulableReason UnschedulableReason `json:"unschedulable_reason,omitempty"`
+	// retry policy of failed task, Retries is the number of retries made.
+	Retry   *RetryPolicy `json:"retry,omitempty"`
+	Retries int          `json:"retries,omitempty"`
+	// health checks run by worker while the task is running.
+	Probes *Probes `json:"probes,omitempty"`
+	// last time the executor reported the task alive, set on real task only.
+	// nil means the executor does not support heartbeat.
+	LastHeartbeat *time.Time `json:"last_heartbeat,omitempty"`
+	// higher priority tasks are evicted later under worker pressure.
+	Priority int `json:"priority,omitempty"`
+	// name of the priority class setting Priority, see PriorityClass.
+	PriorityClass string `json:"priority_class,omitempty"`
+	// name of the gang the task belongs to, GangSize tasks of the gang
+	// are placed together once all of them are created.
+	Gang     string `json:"gang,omitempty"`
+	GangSize int    `json:"gang_size,omitempty"`
+	// recurring schedule of the task, the first run is held until its NextRunAt.
+	Schedule *CronSchedule `json:"schedule,omitempty"`
+	// where the task is read from, empty means the repo.
+	Source TaskSource `json:"source,omitempty"`
+	// external conditions holding the first scheduling of the task.
+	Gates []Gate `json:"gates,omitempty"`
+	// task waits in TaskStatusWaitApproval until approved, ApprovedBy is the approver.
+	ApprovalRequired bool       `json:"approval_required,omitempty"`
+	ApprovedBy       string     `json:"approved_by,omitempty"`
+	ApprovedAt       *time.Time `json:"approved_at,omitempty"`
+	// tasks created once the task finishes, see LabelFollowUp.
+	FollowUps []FollowUp `json:"follow_ups,omitempty"`
+	// undoes the task once a later step of its follow-up chain fails.
+	Compensation *Compensation `json:"compensation,omitempty"`
+	// want status changes applied at given times, see LabelScheduledChange.
+	ScheduledChanges []ScheduledChange `json:"scheduled_changes,omitempty"`
+	// lease epoch, increased each time the task is assigned to a worker.
+	// real task carries the epoch its executor is started with, executors
+	// of older epochs are fenced off by workers.
+	Epoch int64 `json:"epoch,omitempty"`
+	// notes of the task, not part of the spec, so changing them never
+	// reconciles the task. keys of ReservedPrefix are set by the framework.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// key-value area of executors, eg. cursors kept across pause, resume and
+	// retries, see executor.ScratchStore. it's removed with the task.
+	Scratch map[string]string `json:"scratch,omitempty"`
+	// resources the task is expected to use, see PlacementBinPack of scheduler.
+	Resources *ResourceRequest `json:"resources,omitempty"`
+	// called by worker before the executor is stopped.
+	PreStop *PreStopHook `json:"pre_stop,omitempty"`
+	// tasks of the same key, eg. customer id, are assigned to the same
+	// worker while it's a candidate, so that executors hit their caches.
+	LocalityKey string `json:"locality_key,omitempty"`
+	// where the task runs regarding other tasks.
+	Affinity *Affinity `json:"affinity,omitempty"`
+	// runtime labels the worker must have, eg. {"arch": "arm64", "gpu": "true"},
+	// see RuntimeLabelPrefix.
+	WorkerSelector map[string]string `json:"worker_selector,omitempty"`
+	// resources used by the run, reported by executors sampling them.
+	Usage *ResourceUsage `json:"usage,omitempty"`
 }
 
+type TaskSource string
+
+// TaskSourceArchive marks tasks served from archive, they are read-only.
+const TaskSourceArchive TaskSource = "archive"
+
 func (t *Task) Clone() *Task {
 	return &Task{
 		ID:        t.ID,
@@ -33,17 +123,93 @@ func (t *Task) Clone() *Task {
 		Stains:    t.Stains,
 		Extra:     t.Extra,
 		Status:    t.Status,
+		Operator:  t.Operator,
 		Msg:       t.Msg,
 		CreatedAt: t.CreatedAt,
 		UpdatedAt: t.UpdatedAt,
+		DeletedAt: t.DeletedAt,
+
+		AssignedAt: t.AssignedAt,
+		StartedAt:  t.StartedAt,
+		FinishedAt: t.FinishedAt,
+
+		SLA:       t.SLA,
+		SLABreach: t.SLABreach,
+
+		Generation: t.Generation,
+		Kind:       t.Kind,
+		Replicas:   t.Replicas,
+
+		UnschedulableReason: t.UnschedulableReason,
+		Retry:               t.Retry,
+		Retries:             t.Retries,
+		Probes:              t.Probes,
+		LastHeartbeat:       t.LastHeartbeat,
+		Priority:            t.Priority,
+		PriorityClass:       t.PriorityClass,
+		Gang:                t.Gang,
+		GangSize:            t.GangSize,
+		Schedule:            t.Schedule,
+		Source:              t.Source,
+		Gates:               t.Gates,
+		ApprovalRequired:    t.ApprovalRequired,
+		ApprovedBy:          t.ApprovedBy,
+		ApprovedAt:          t.ApprovedAt,
+		FollowUps:           t.FollowUps,
+		Compensation:        t.Compensation,
+		Epoch:               t.Epoch,
+		ScheduledChanges:    t.ScheduledChanges,
+		Annotations:         t.Annotations,
+		Scratch:             t.Scratch,
+		Resources:           t.Resources,
+		PreStop:             t.PreStop,
+		LocalityKey:         t.LocalityKey,
+		Affinity:            t.Affinity,
+		WorkerSelector:      t.WorkerSelector,
+		Usage:               t.Usage,
 	}
 }
 
+func (t *Task) IsDeleted() bool {
+	return t.DeletedAt != nil
+}
+
 type TaskFilter struct {
 	BizIDs  []string
 	BizType string
 	Type    string
+	// only list soft deleted tasks(tombstones).
+	OnlyDeleted bool
+	// all labels must match.
+	Labels map[string]string
+	// any of statuses matches, empty matches all.
+	Statuses []TaskStatus
+	// only list archived tasks.
+	Archived bool
 
 	Offset int
 	Limit  int
 }
+
+// Match reports whether t matches conditions of f, Offset and Limit are ignored.
+// it is used by backends filtering tasks in memory.
+func (f *TaskFilter) Match(t *Task) bool {
+	if f.OnlyDeleted != t.IsDeleted() {
+		return false
+	}
+	if ids := slices.DeleteFunc(slices.Clone(f.BizIDs), func(id string) bool { return id == "" }); len(ids) > 0 && !slices.Contains(ids, t.BizID) {
+		return false
+	}
+	if (f.BizType != "" && f.BizType != t.BizType) || (f.Type != "" && f.Type != t.Type) {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, t.Status) {
+		return false
+	}
+	for k, v := range f.Labels {
+		if t.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
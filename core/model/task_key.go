@@ -0,0 +1,25 @@
+package model
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// MaxTaskKeyLen is the length of the task_key column.
+const MaxTaskKeyLen = 64
+
+// task keys supplied by callers start with a letter or digit, and contain
+// letters, digits and . _ : - only, so that they are safe in urls and logs.
+var taskKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]*$`)
+
+// ValidateTaskKey returns error if key can not be used as a task key.
+func ValidateTaskKey(key string) error {
+	if len(key) > MaxTaskKeyLen {
+		return errors.Errorf("invalid task key %q, longer than %d", key, MaxTaskKeyLen)
+	}
+	if !taskKeyPattern.MatchString(key) {
+		return errors.Errorf("invalid task key %q, want letters, digits and . _ : - only", key)
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ type TaskStatus string
 const (
 	TaskStatusNotExist       TaskStatus = "not_exist" // It is a virtual state used to mark that the task does not exist for deletion processing.
 	TaskStatusWaitScheduling TaskStatus = "wait_scheduling"
+	TaskStatusUnschedulable  TaskStatus = "unschedulable" // no worker can run the task, see Task.UnschedulableReason.
 	TaskStatusWaitRunning    TaskStatus = "wait_running"
 	TaskStatusRunning        TaskStatus = "running"
 	TaskStatusWaitPaused     TaskStatus = "wait_paused"
@@ -17,6 +18,9 @@ const (
 	TaskStatusStop           TaskStatus = "stop"
 	TaskStatusSuccess        TaskStatus = "success"
 	TaskStatusFailed         TaskStatus = "failed"
+
+	// held until approved, see Task.ApprovalRequired.
+	TaskStatusWaitApproval TaskStatus = "waiting_approval"
 )
 
 func (ts TaskStatus) String() string {
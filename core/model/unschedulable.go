@@ -0,0 +1,20 @@
+package model
+
+// UnschedulableReason is the machine-readable reason of an unschedulable task.
+type UnschedulableReason string
+
+const (
+	// no available worker, or all workers are under resource pressure.
+	UnschedulableNoCapacity UnschedulableReason = "no_capacity"
+	// no worker registered executor of the task type or required version.
+	UnschedulableNoExecutor UnschedulableReason = "no_executor"
+	// workers have stains or labels not tolerated by the task.
+	UnschedulableNoMatchingLabels UnschedulableReason = "no_matching_labels"
+	// tasks of the biz type running on workers reached the quota.
+	UnschedulableQuotaExceeded UnschedulableReason = "quota_exceeded"
+	// members of a gang share an exclusion group, they can never run together.
+	UnschedulableExclusionConflict UnschedulableReason = "exclusion_conflict"
+
+	// cost of the biz type in the current month exceeded its budget.
+	UnschedulableBudgetExceeded UnschedulableReason = "budget_exceeded"
+)
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/audit"
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// ForceFinishTask set a task stuck in non-final status to the final status directly.
+// executor will not be notified, make sure it has exited.
+func (s *Scheduler) ForceFinishTask(ctx context.Context, taskKey string, status model.TaskStatus, reason, operator string) error {
+	if !status.IsFinalStatus() {
+		return errors.Errorf("%s is not a final status", status)
+	}
+	task, err := s.taskRepo.GetTask(ctx, taskKey)
+	if err != nil {
+		return err
+	}
+	if err := auth.CheckBizType(ctx, task.BizType); err != nil {
+		return err
+	}
+	if task.Status.IsFinalStatus() {
+		return errors.Errorf("任务[%s]已是终态 %s", taskKey, task.Status)
+	}
+
+	now := time.Now()
+	if err := s.taskRepo.UpdateTask(ctx, &model.Task{
+		TaskKey:       taskKey,
+		Status:        status,
+		WantRunStatus: status,
+		Operator:      operator,
+		FinishedAt:    &now,
+		Msg:           fmt.Sprintf("force finished by %s: %s", operator, reason),
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.audit(ctx, audit.Entry{
+		TaskKey:  taskKey,
+		Operator: operator,
+		Action:   audit.ActionForceFinish,
+		From:     task.Status.String(),
+		To:       status.String(),
+		Reason:   reason,
+	})
+	return nil
+}
+
+// ForceReassignTask move a task to the target worker. the lease epoch is
+// increased like assigning, so that the executor of the old worker is fenced
+// and exits, reports of it are dropped.
+func (s *Scheduler) ForceReassignTask(ctx context.Context, taskKey, workerID, reason, operator string) error {
+	task, err := s.taskRepo.GetTask(ctx, taskKey)
+	if err != nil {
+		return err
+	}
+	if err := auth.CheckBizType(ctx, task.BizType); err != nil {
+		return err
+	}
+	if task.Status.IsFinalStatus() {
+		return errors.Errorf("任务[%s]已是终态 %s", taskKey, task.Status)
+	}
+	if !slices.ContainsFunc(s.getAvailableWorkers(), func(w discover.Instance) bool {
+		return w.ID() == workerID
+	}) {
+		return errors.Errorf("worker[%s]不可用", workerID)
+	}
+	if s.cordons.cordoned(workerID) {
+		return errors.Errorf("worker[%s]已 cordon", workerID)
+	}
+
+	nextStatus := model.TaskStatusRunning
+	now := time.Now()
+	if err := s.taskRepo.UpdateTask(ctx, &model.Task{
+		TaskKey:       taskKey,
+		Status:        nextStatus.PreWaitStatus(),
+		WantRunStatus: nextStatus,
+		WorkerID:      workerID,
+		NextRunAt:     &now,
+		AssignedAt:    &now,
+		Operator:      operator,
+		Epoch:         task.Epoch + 1,
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.audit(ctx, audit.Entry{
+		TaskKey:  taskKey,
+		Operator: operator,
+		Action:   audit.ActionForceReassign,
+		From:     task.WorkerID,
+		To:       workerID,
+		Reason:   reason,
+	})
+	return nil
+}
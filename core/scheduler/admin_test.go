@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// adminRepo also applies status, worker and epoch updates.
+type adminRepo struct {
+	statusRepo
+}
+
+func (r *adminRepo) UpdateTask(ctx context.Context, update *model.Task) error {
+	for _, t := range r.tasks {
+		if t.TaskKey != update.TaskKey {
+			continue
+		}
+		if update.Status != "" {
+			t.Status = update.Status
+		}
+		if update.WorkerID != "" {
+			t.WorkerID = update.WorkerID
+		}
+		if update.Epoch != 0 {
+			t.Epoch = update.Epoch
+		}
+		if update.FinishedAt != nil {
+			t.FinishedAt = update.FinishedAt
+		}
+	}
+	return r.statusRepo.UpdateTask(ctx, update)
+}
+
+func TestAdminOperations(t *testing.T) {
+	ctx := context.Background()
+	stuck := &model.Task{TaskKey: "stuck", Status: model.TaskStatusWaitStop, WantRunStatus: model.TaskStatusStop, WorkerID: "w1", Epoch: 1}
+	running := &model.Task{TaskKey: "running", Status: model.TaskStatusRunning, WantRunStatus: model.TaskStatusRunning, WorkerID: "w1", Epoch: 3}
+	repo := &adminRepo{statusRepo{getRepo{listRepo{tasks: []*model.Task{stuck, running}}}}}
+	s := &Scheduler{taskRepo: repo, opts: newOptions()}
+	s.setAvailableWorkers([]discover.Instance{
+		{InstanceId: "w1", Enable: true, Healthy: true},
+		{InstanceId: "w2", Enable: true, Healthy: true},
+	})
+
+	t.Run("强制结束卡住的任务", func(t *testing.T) {
+		if err := s.ForceFinishTask(ctx, "stuck", model.TaskStatusRunning, "", "bob"); err == nil {
+			t.Error("非终态应被拒绝")
+		}
+		if err := s.ForceFinishTask(ctx, "stuck", model.TaskStatusStop, "executor gone", "bob"); err != nil {
+			t.Fatal(err)
+		}
+		if stuck.Status != model.TaskStatusStop || stuck.WantRunStatus != model.TaskStatusStop || stuck.FinishedAt == nil {
+			t.Fatalf("期望直接结束, 得到 %s/%s", stuck.Status, stuck.WantRunStatus)
+		}
+		if err := s.ForceFinishTask(ctx, "stuck", model.TaskStatusStop, "", "bob"); err == nil {
+			t.Error("已结束的任务应被拒绝")
+		}
+	})
+
+	t.Run("强制迁移递增 epoch 以隔离旧执行器", func(t *testing.T) {
+		if err := s.ForceReassignTask(ctx, "running", "w3", "", "bob"); err == nil {
+			t.Error("不可用的 worker 应被拒绝")
+		}
+		if err := s.ForceReassignTask(ctx, "running", "w2", "w1 hangs", "bob"); err != nil {
+			t.Fatal(err)
+		}
+		if running.WorkerID != "w2" || running.Epoch != 4 || running.Status != model.TaskStatusWaitRunning {
+			t.Fatalf("期望迁移到 w2 且 epoch 为 4, 得到 %s %d %s", running.WorkerID, running.Epoch, running.Status)
+		}
+	})
+}
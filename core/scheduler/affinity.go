@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// colocated is a task assigned to a worker, as seen by affinity.
+type colocated struct {
+	taskKey string
+	labels  map[string]string
+}
+
+// colocation tracks tasks assigned to workers for affinity. it's rebuilt
+// from runnable tasks every assign pass and updated by assignments within
+// the pass, so that tasks of a pass see each other.
+type colocation struct {
+	mu sync.Mutex
+	// worker id => labeled tasks assigned to it.
+	workers map[string][]colocated
+}
+
+func newColocation() *colocation {
+	return &colocation{workers: make(map[string][]colocated)}
+}
+
+// reset rebuilds tasks of workers from tasks assigned to available workers.
+func (c *colocation) reset(assigned []*model.Task) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.workers = make(map[string][]colocated)
+	for _, task := range assigned {
+		c.add(task.WorkerID, task)
+	}
+}
+
+// reserve adds task assigned to workerID.
+func (c *colocation) reserve(workerID string, task *model.Task) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(workerID, task)
+}
+
+func (c *colocation) add(workerID string, task *model.Task) {
+	if len(task.Labels) == 0 {
+		return
+	}
+	c.workers[workerID] = append(c.workers[workerID], colocated{taskKey: task.TaskKey, labels: task.Labels})
+}
+
+// runs reports whether workerID runs a task other than task matching selector.
+func (c *colocation) runs(workerID string, task *model.Task, selector map[string]string) bool {
+	for _, t := range c.workers[workerID] {
+		if t.taskKey != task.TaskKey && model.MatchLabels(selector, t.labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyRuns reports whether any worker runs a task other than task matching selector.
+func (c *colocation) anyRuns(task *model.Task, selector map[string]string) bool {
+	for workerID := range c.workers {
+		if c.runs(workerID, task, selector) {
+			return true
+		}
+	}
+	return false
+}
+
+// reason returns why affinity of task forbids worker, empty if it allows.
+func (c *colocation) reason(task *model.Task, worker discover.Instance) string {
+	if task.Affinity == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reasonLocked(task, worker.ID(), c.anyRuns(task, task.Affinity.Tasks))
+}
+
+func (c *colocation) reasonLocked(task *model.Task, workerID string, affinityRuns bool) string {
+	a := task.Affinity
+	if affinityRuns && !c.runs(workerID, task, a.Tasks) {
+		return fmt.Sprintf("任务亲和 %v, worker 未运行匹配的任务", a.Tasks)
+	}
+	if len(a.AntiTasks) > 0 && c.runs(workerID, task, a.AntiTasks) {
+		return fmt.Sprintf("任务反亲和 %v, worker 已运行匹配的任务", a.AntiTasks)
+	}
+	return ""
+}
+
+// route keeps workers allowed by affinity of task.
+func (c *colocation) route(task *model.Task, workers []discover.Instance) []discover.Instance {
+	if task.Affinity == nil {
+		return workers
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	affinityRuns := c.anyRuns(task, task.Affinity.Tasks)
+	ret := make([]discover.Instance, 0, len(workers))
+	for _, worker := range workers {
+		if c.reasonLocked(task, worker.ID(), affinityRuns) == "" {
+			ret = append(ret, worker)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestColocation(t *testing.T) {
+	workers := []discover.Instance{{InstanceId: "w1"}, {InstanceId: "w2"}, {InstanceId: "w3"}}
+	ids := func(ws []discover.Instance) []string {
+		ret := make([]string, 0, len(ws))
+		for _, w := range ws {
+			ret = append(ret, w.ID())
+		}
+		return ret
+	}
+	shard := func(key string) *model.Task {
+		return &model.Task{
+			TaskKey:  key,
+			Labels:   map[string]string{"shard-group": "g1"},
+			Affinity: &model.Affinity{AntiTasks: map[string]string{"shard-group": "g1"}},
+		}
+	}
+
+	t.Run("反亲和不与同组分片同机", func(t *testing.T) {
+		c := newColocation()
+		c.reset([]*model.Task{{TaskKey: "s1", WorkerID: "w1", Labels: map[string]string{"shard-group": "g1"}}})
+		got := ids(c.route(shard("s2"), workers))
+		if len(got) != 2 || got[0] != "w2" || got[1] != "w3" {
+			t.Fatalf("期望 [w2 w3], 得到 %v", got)
+		}
+		// assigned within the pass.
+		c.reserve("w2", shard("s2"))
+		if got := ids(c.route(shard("s3"), workers)); len(got) != 1 || got[0] != "w3" {
+			t.Fatalf("期望 [w3], 得到 %v", got)
+		}
+		if reason := c.reason(shard("s3"), workers[0]); reason == "" {
+			t.Fatal("期望返回反亲和原因")
+		}
+	})
+
+	t.Run("不与自身反亲和", func(t *testing.T) {
+		c := newColocation()
+		c.reset([]*model.Task{{TaskKey: "s1", WorkerID: "w1", Labels: map[string]string{"shard-group": "g1"}}})
+		if got := c.route(shard("s1"), workers); len(got) != 3 {
+			t.Fatalf("期望 3 个 worker, 得到 %v", ids(got))
+		}
+	})
+
+	t.Run("亲和运行在匹配任务所在 worker", func(t *testing.T) {
+		task := &model.Task{TaskKey: "t2", Affinity: &model.Affinity{Tasks: map[string]string{"dataset": "d1"}}}
+		c := newColocation()
+		if got := c.route(task, workers); len(got) != 3 {
+			t.Fatalf("没有匹配任务时期望不限制, 得到 %v", ids(got))
+		}
+		c.reset([]*model.Task{
+			{TaskKey: "t1", WorkerID: "w3", Labels: map[string]string{"dataset": "d1"}},
+			{TaskKey: "t0", WorkerID: "w1", Labels: map[string]string{"dataset": "d2"}},
+		})
+		if got := ids(c.route(task, workers)); len(got) != 1 || got[0] != "w3" {
+			t.Fatalf("期望 [w3], 得到 %v", got)
+		}
+		if got := c.route(task, workers[:2]); len(got) != 0 {
+			t.Fatalf("匹配任务所在 worker 不可用时期望无候选, 得到 %v", ids(got))
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/audit"
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// max tasks waiting approval checked in one pass.
+const approvalBatchSize = 500
+
+// ApproveTask lets a task waiting approval be scheduled, operator is recorded as the approver.
+func (s *Scheduler) ApproveTask(ctx context.Context, taskKey, reason, operator string) error {
+	task, err := s.waitingApproval(ctx, taskKey)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := s.taskRepo.UpdateTask(ctx, &model.Task{
+		TaskKey:    taskKey,
+		ApprovedBy: operator,
+		ApprovedAt: &now,
+		Operator:   operator,
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	applied, err := s.taskRepo.UpdateTaskStatusCAS(ctx, taskKey, model.TaskStatusWaitApproval, model.TaskStatusWaitScheduling)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !applied {
+		return errors.Errorf("任务[%s]状态已被并发修改, 请重试", taskKey)
+	}
+
+	s.audit(ctx, audit.Entry{
+		TaskKey:  taskKey,
+		Operator: operator,
+		Action:   audit.ActionApprove,
+		From:     task.Status.String(),
+		To:       model.TaskStatusWaitScheduling.String(),
+		Reason:   reason,
+	})
+	s.triggerReAssignEvent()
+	return nil
+}
+
+// RejectTask stops a task waiting approval, it will never run.
+func (s *Scheduler) RejectTask(ctx context.Context, taskKey, reason, operator string) error {
+	task, err := s.waitingApproval(ctx, taskKey)
+	if err != nil {
+		return err
+	}
+	return s.reject(ctx, task, reason, operator)
+}
+
+func (s *Scheduler) waitingApproval(ctx context.Context, taskKey string) (*model.Task, error) {
+	task, err := s.taskRepo.GetTask(ctx, taskKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.CheckBizType(ctx, task.BizType); err != nil {
+		return nil, err
+	}
+	if task.Status != model.TaskStatusWaitApproval {
+		return nil, errors.Errorf("任务[%s]当前状态为 %s, 不在等待审批", taskKey, task.Status)
+	}
+	return task, nil
+}
+
+func (s *Scheduler) reject(ctx context.Context, task *model.Task, reason, operator string) error {
+	applied, err := s.taskRepo.UpdateTaskStatusCAS(ctx, task.TaskKey, model.TaskStatusWaitApproval, model.TaskStatusStop)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !applied {
+		return errors.Errorf("任务[%s]状态已被并发修改, 请重试", task.TaskKey)
+	}
+	now := time.Now()
+	if err := s.taskRepo.UpdateTask(ctx, &model.Task{
+		TaskKey:       task.TaskKey,
+		WantRunStatus: model.TaskStatusStop,
+		Operator:      operator,
+		FinishedAt:    &now,
+		Msg:           fmt.Sprintf("rejected by %s: %s", operator, reason),
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.audit(ctx, audit.Entry{
+		TaskKey:  task.TaskKey,
+		Operator: operator,
+		Action:   audit.ActionReject,
+		From:     task.Status.String(),
+		To:       model.TaskStatusStop.String(),
+		Reason:   reason,
+	})
+	return nil
+}
+
+// runApprovalController rejects tasks waiting approval longer than approval timeout, only leader works.
+func (s *Scheduler) runApprovalController() {
+	if s.opts.approvalTimeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(min(s.opts.approvalTimeout, time.Minute))
+	defer ticker.Stop()
+	for range ticker.C {
+		amILeader, _, err := s.amILeader()
+		if err != nil {
+			log.Error("[Approval] 获取 leader 状态失败: %v", err)
+			continue
+		}
+		if !amILeader {
+			continue
+		}
+		if err := s.rejectExpiredApprovals(context.Background(), time.Now()); err != nil {
+			log.Error("[Approval] 拒绝超时审批任务失败: %v", err)
+		}
+	}
+}
+
+func (s *Scheduler) rejectExpiredApprovals(ctx context.Context, now time.Time) error {
+	tasks, err := s.taskRepo.ListTask(ctx, &model.TaskFilter{
+		Statuses: []model.TaskStatus{model.TaskStatusWaitApproval},
+		Limit:    approvalBatchSize,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, task := range tasks {
+		if now.Sub(task.CreatedAt) < s.opts.approvalTimeout {
+			continue
+		}
+		reason := fmt.Sprintf("not approved within %s", s.opts.approvalTimeout)
+		if err := s.reject(ctx, task, reason, model.OperatorScheduler); err != nil {
+			log.Error("[Approval] 任务[%s]超时拒绝失败: %v", task.TaskKey, err)
+			continue
+		}
+		log.Info("[Approval] 任务[%s]%s, 已拒绝", task.TaskKey, reason)
+	}
+	return nil
+}
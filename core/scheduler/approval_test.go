@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// statusRepo applies status CAS and updates of tasks in memory.
+type statusRepo struct {
+	getRepo
+}
+
+func (r *statusRepo) UpdateTaskStatusCAS(_ context.Context, taskKey string, from, to model.TaskStatus) (bool, error) {
+	for _, t := range r.tasks {
+		if t.TaskKey == taskKey && t.Status == from {
+			t.Status = to
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *statusRepo) UpdateTaskCAS(ctx context.Context, from model.TaskStatus, update *model.Task) (bool, error) {
+	applied, err := r.UpdateTaskStatusCAS(ctx, update.TaskKey, from, update.Status)
+	if !applied || err != nil {
+		return applied, err
+	}
+	return true, r.UpdateTask(ctx, update)
+}
+
+func (r *statusRepo) UpdateTask(_ context.Context, update *model.Task) error {
+	for _, t := range r.tasks {
+		if t.TaskKey == update.TaskKey {
+			if update.ApprovedBy != "" {
+				t.ApprovedBy = update.ApprovedBy
+			}
+			if update.Msg != "" {
+				t.Msg = update.Msg
+			}
+			if update.Labels != nil {
+				t.Labels = update.Labels
+			}
+			if update.Annotations != nil {
+				t.Annotations = update.Annotations
+			}
+			if update.WantRunStatus != "" {
+				t.WantRunStatus = update.WantRunStatus
+			}
+			if update.NextRunAt != nil {
+				t.NextRunAt = update.NextRunAt
+			}
+		}
+	}
+	return nil
+}
+
+func TestApproval(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := &statusRepo{getRepo{listRepo{tasks: []*model.Task{
+		{TaskKey: "a", Status: model.TaskStatusWaitApproval, CreatedAt: now},
+		{TaskKey: "b", Status: model.TaskStatusWaitApproval, CreatedAt: now.Add(-2 * time.Hour)},
+		{TaskKey: "c", Status: model.TaskStatusWaitApproval, CreatedAt: now},
+	}}}}
+	s := &Scheduler{taskRepo: repo, opts: newOptions(WithApprovalTimeout(time.Hour))}
+	s.assignEvent = make(chan struct{}, 1)
+
+	t.Run("审批通过后等待调度并记录审批人", func(t *testing.T) {
+		if err := s.ApproveTask(ctx, "a", "lgtm", "user:alice"); err != nil {
+			t.Fatal(err)
+		}
+		if task := repo.tasks[0]; task.Status != model.TaskStatusWaitScheduling || task.ApprovedBy != "user:alice" {
+			t.Fatalf("期望等待调度且审批人为 alice, 得到 %s %s", task.Status, task.ApprovedBy)
+		}
+		if err := s.ApproveTask(ctx, "a", "", "user:alice"); err == nil {
+			t.Fatal("期望重复审批报错")
+		}
+	})
+
+	t.Run("超时自动拒绝", func(t *testing.T) {
+		if err := s.rejectExpiredApprovals(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+		if repo.tasks[1].Status != model.TaskStatusStop || repo.tasks[2].Status != model.TaskStatusWaitApproval {
+			t.Fatalf("期望只拒绝超时任务, 得到 %s %s", repo.tasks[1].Status, repo.tasks[2].Status)
+		}
+	})
+}
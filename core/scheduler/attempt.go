@@ -0,0 +1,32 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/xyzbit/minitaskx/core/components/attempt"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// SummarizeTask returns the composite status of task and its latest attempt.
+// attempts are unknown if no attempt repo is configured.
+func (s *Scheduler) SummarizeTask(ctx context.Context, task *model.Task) (attempt.Summary, error) {
+	if s.opts.attemptRepo == nil {
+		return attempt.Summarize(task, nil), nil
+	}
+	latest, err := s.opts.attemptRepo.Latest(ctx, task.TaskKey)
+	if err != nil {
+		return attempt.Summary{}, err
+	}
+	return attempt.Summarize(task, latest), nil
+}
+
+// ListAttempts returns run attempts of the task.
+func (s *Scheduler) ListAttempts(ctx context.Context, taskKey string) ([]*attempt.Attempt, error) {
+	if _, err := s.GetTask(ctx, taskKey); err != nil {
+		return nil, err
+	}
+	if s.opts.attemptRepo == nil {
+		return nil, nil
+	}
+	return s.opts.attemptRepo.List(ctx, taskKey)
+}
@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/audit"
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/log"
+)
+
+// audit record the entry to audit trail, failure will not break the operation.
+func (s *Scheduler) audit(ctx context.Context, entry audit.Entry) {
+	log.Info("[Audit] task %s %s by %s: %s -> %s", entry.TaskKey, entry.Action, entry.Operator, entry.From, entry.To)
+	if s.opts.auditor == nil {
+		return
+	}
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	if err := s.opts.auditor.Record(ctx, entry); err != nil {
+		log.Error("[Audit] record task %s %s failed: %v", entry.TaskKey, entry.Action, err)
+	}
+}
+
+// ListAudits returns audit trail of the task.
+func (s *Scheduler) ListAudits(ctx context.Context, taskKey string) ([]audit.Entry, error) {
+	if s.opts.auditor == nil {
+		return nil, nil
+	}
+	task, err := s.taskRepo.GetTask(ctx, taskKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.CheckBizType(ctx, task.BizType); err != nil {
+		return nil, err
+	}
+	return s.opts.auditor.List(ctx, taskKey)
+}
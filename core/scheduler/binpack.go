@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// PlacementStrategy decides which of candidate workers runs a task.
+type PlacementStrategy string
+
+const (
+	// spreads tasks to the workers using least resources, the default.
+	PlacementSpread PlacementStrategy = "spread"
+	// packs tasks onto the fullest workers their resource requests fit,
+	// preferring workers whose tasks are estimated to finish around the
+	// time the task does, so that other workers are left idle and workers
+	// drain together. tasks without requests take no room.
+	PlacementBinPack PlacementStrategy = "binpack"
+)
+
+// weight of aligned finish times against utilization of packed workers.
+const drainAlignmentWeight = 0.25
+
+// workerLoad is resources requested by tasks assigned to a worker.
+type workerLoad struct {
+	cpu, memory float64
+	// estimated time all tasks of the worker finish.
+	drainAt time.Time
+}
+
+// packer places tasks by PlacementBinPack. loads are rebuilt from runnable
+// tasks every assign pass and updated by assignments within the pass.
+type packer struct {
+	mu sync.Mutex
+	// worker id => load.
+	loads map[string]*workerLoad
+	// run duration of tasks estimated from history.
+	estimate func(task *model.Task) time.Duration
+}
+
+func newPacker(estimate func(task *model.Task) time.Duration) *packer {
+	return &packer{loads: make(map[string]*workerLoad), estimate: estimate}
+}
+
+// reset rebuilds loads from tasks assigned to workers.
+func (p *packer) reset(assigned []*model.Task, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.loads = make(map[string]*workerLoad)
+	for _, task := range assigned {
+		start := now
+		if task.StartedAt != nil {
+			start = *task.StartedAt
+		} else if task.AssignedAt != nil {
+			start = *task.AssignedAt
+		}
+		p.add(task.WorkerID, task, start)
+	}
+}
+
+// reserve adds task assigned to workerID at now to its load.
+func (p *packer) reserve(workerID string, task *model.Task, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.add(workerID, task, now)
+}
+
+func (p *packer) add(workerID string, task *model.Task, start time.Time) {
+	load := p.loads[workerID]
+	if load == nil {
+		load = &workerLoad{}
+		p.loads[workerID] = load
+	}
+	if r := task.Resources; r != nil {
+		load.cpu += r.CPU
+		load.memory += r.Memory
+	}
+	if end := start.Add(p.estimate(task)); end.After(load.drainAt) {
+		load.drainAt = end
+	}
+}
+
+// pick returns the worker of workers task packs best, false if requests of
+// task fit no worker.
+func (p *packer) pick(task *model.Task, workers []discover.Instance, now time.Time) (discover.Instance, float64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	best, bestScore := -1, math.Inf(-1)
+	for i, worker := range workers {
+		score, fits := p.score(task, worker, now)
+		if fits && score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return discover.Instance{}, 0, false
+	}
+	return workers[best], bestScore, true
+}
+
+// score is the utilization of worker once task is placed, plus how close
+// the estimated finish of task is to the drain time of worker.
+func (p *packer) score(task *model.Task, worker discover.Instance, now time.Time) (float64, bool) {
+	load := p.loads[worker.ID()]
+	if load == nil {
+		load = &workerLoad{}
+	}
+	request := model.ResourceRequest{}
+	if task.Resources != nil {
+		request = *task.Resources
+	}
+	capacity := model.ParseResourceUsage(worker.Metadata)
+
+	var utilizations []float64
+	for _, dim := range []struct{ used, request, capacity float64 }{
+		{load.cpu, request.CPU, capacity[model.CpuCoresKey]},
+		{load.memory, request.Memory, capacity[model.MemTotalKey]},
+	} {
+		// capacity unknown, eg. reported by workers of older versions.
+		if dim.capacity <= 0 {
+			continue
+		}
+		if dim.used+dim.request > dim.capacity {
+			return 0, false
+		}
+		utilizations = append(utilizations, (dim.used+dim.request)/dim.capacity)
+	}
+	utilization := 0.0
+	for _, u := range utilizations {
+		utilization += u
+	}
+	if len(utilizations) > 0 {
+		utilization /= float64(len(utilizations))
+	}
+
+	taskLeft := p.estimate(task)
+	workerLeft := max(load.drainAt.Sub(now), 0)
+	alignment := 1.0
+	if longer := max(taskLeft, workerLeft); longer > 0 {
+		alignment = 1 - float64((taskLeft-workerLeft).Abs())/float64(longer)
+	}
+	return utilization + drainAlignmentWeight*alignment, true
+}
+
+// packWorker selects the worker of candidates by PlacementBinPack, it spreads
+// tasks whose requests fit no worker.
+func (s *Scheduler) packWorker(task *model.Task, candidates []discover.Instance) (discover.Instance, string) {
+	if worker, score, ok := s.packer.pick(task, candidates, time.Now()); ok {
+		return worker, fmt.Sprintf("装箱得分最高(%.2f), 共 %d 个候选 worker", score, len(candidates))
+	}
+	return priorityWorker(candidates), "资源请求无法装入任何 worker, 按资源使用分散"
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestPacker(t *testing.T) {
+	now := time.Now()
+	worker := func(id, cores, mem string) discover.Instance {
+		return discover.Instance{InstanceId: id, Metadata: map[string]string{model.CpuCoresKey: cores, model.MemTotalKey: mem}}
+	}
+	workers := []discover.Instance{worker("w1", "8", "16"), worker("w2", "8", "16"), worker("w3", "4", "8")}
+	noHistory := func(*model.Task) time.Duration { return 0 }
+	task := func(bizType string, cpu, mem float64) *model.Task {
+		return &model.Task{TaskKey: bizType, BizType: bizType, Resources: &model.ResourceRequest{CPU: cpu, Memory: mem}}
+	}
+
+	t.Run("装入已用资源最多且放得下的 worker", func(t *testing.T) {
+		p := newPacker(noHistory)
+		running := task("etl", 4, 8)
+		running.WorkerID = "w2"
+		p.reset([]*model.Task{running}, now)
+		got, _, ok := p.pick(task("etl", 2, 4), workers, now)
+		if !ok || got.ID() != "w2" {
+			t.Fatalf("期望装入 w2, 得到 %s", got.ID())
+		}
+		p.reserve("w2", task("etl", 4, 8), now)
+		if got, _, _ := p.pick(task("etl", 2, 4), workers, now); got.ID() != "w3" {
+			t.Fatalf("w2 已满, 期望装入较小的 w3, 得到 %s", got.ID())
+		}
+	})
+
+	t.Run("放不下时返回 false", func(t *testing.T) {
+		if _, _, ok := newPacker(noHistory).pick(task("etl", 16, 1), workers, now); ok {
+			t.Fatal("期望没有 worker 放得下")
+		}
+	})
+
+	t.Run("优先选择任务预计同时结束的 worker", func(t *testing.T) {
+		p := newPacker(func(task *model.Task) time.Duration {
+			return map[string]time.Duration{"short": time.Minute, "long": time.Hour}[task.BizType]
+		})
+		short, long := task("short", 1, 1), task("long", 1, 1)
+		short.WorkerID, long.WorkerID = "w1", "w2"
+		p.reset([]*model.Task{short, long}, now)
+		if got, _, _ := p.pick(task("long", 1, 1), workers[:2], now); got.ID() != "w2" {
+			t.Fatalf("期望与长任务放在一起, 得到 %s", got.ID())
+		}
+		if got, _, _ := p.pick(task("short", 1, 1), workers[:2], now); got.ID() != "w1" {
+			t.Fatalf("期望与短任务放在一起, 得到 %s", got.ID())
+		}
+	})
+}
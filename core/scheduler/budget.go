@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// BizTypeUsage is the executor runtime and cost of a biz type in the current month.
+type BizTypeUsage struct {
+	BizType        string  `json:"biz_type"`
+	RuntimeSeconds float64 `json:"runtime_seconds"`
+	// runtime seconds weighted by cost weights of task types.
+	Cost float64 `json:"cost"`
+	// monthly budget of cost, zero means unlimited.
+	Budget   float64 `json:"budget,omitempty"`
+	Exceeded bool    `json:"exceeded"`
+}
+
+// Usage is runtime accounted per biz type since the start of the month.
+type Usage struct {
+	Since     time.Time      `json:"since"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	BizTypes  []BizTypeUsage `json:"biz_types"`
+	// more tasks than dashboardScanLimit exist, usage is partial.
+	Truncated bool `json:"truncated"`
+}
+
+type usageMetrics struct {
+	runtime metrics.Gauge
+	cost    metrics.Gauge
+	budget  metrics.Gauge
+}
+
+func newUsageMetrics() *usageMetrics {
+	p := metrics.Global()
+	return &usageMetrics{
+		runtime: p.NewGauge("minitaskx_biz_runtime_seconds", "executor runtime of tasks in the current month", "biz_type"),
+		cost:    p.NewGauge("minitaskx_biz_cost", "executor runtime weighted by cost weights in the current month", "biz_type"),
+		budget:  p.NewGauge("minitaskx_biz_budget", "monthly budget of cost, zero means unlimited", "biz_type"),
+	}
+}
+
+// Usage returns usage of bizType, or of all biz types if empty. usage is
+// cached by the usage controller and recomputed if older than usage interval.
+func (s *Scheduler) Usage(ctx context.Context, bizType string) (*Usage, error) {
+	if err := auth.CheckBizType(ctx, bizType); err != nil {
+		return nil, err
+	}
+	usage := s.usage.Load()
+	if usage == nil || time.Since(usage.UpdatedAt) > s.opts.usageInterval {
+		var err error
+		if usage, err = s.refreshUsage(ctx, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	if bizType == "" {
+		return usage, nil
+	}
+
+	filtered := *usage
+	filtered.BizTypes = nil
+	for _, u := range usage.BizTypes {
+		if u.BizType == bizType {
+			filtered.BizTypes = append(filtered.BizTypes, u)
+		}
+	}
+	return &filtered, nil
+}
+
+// runUsageController accounts usage of biz types, only leader works.
+func (s *Scheduler) runUsageController() {
+	ticker := time.NewTicker(s.opts.usageInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		amILeader, _, err := s.amILeader()
+		if err != nil {
+			log.Error("[Budget] 获取 leader 状态失败: %v", err)
+			continue
+		}
+		if !amILeader {
+			continue
+		}
+		if _, err := s.refreshUsage(context.Background(), time.Now()); err != nil {
+			log.Error("[Budget] 统计业务类型用量失败: %v", err)
+		}
+	}
+}
+
+// refreshUsage accounts time tasks ran since the start of the month of now,
+// running tasks count the time elapsed so far.
+func (s *Scheduler) refreshUsage(ctx context.Context, now time.Time) (*Usage, error) {
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	byBizType := make(map[string]*BizTypeUsage)
+	truncated, err := s.scanTasks(ctx, &model.TaskFilter{}, func(task *model.Task) {
+		if task.StartedAt == nil {
+			return
+		}
+		end := now
+		switch {
+		case task.FinishedAt != nil:
+			end = *task.FinishedAt
+		case task.Status != model.TaskStatusRunning:
+			return
+		}
+		start := *task.StartedAt
+		if start.Before(since) {
+			start = since
+		}
+		if !end.After(start) {
+			return
+		}
+
+		u := byBizType[task.BizType]
+		if u == nil {
+			u = &BizTypeUsage{BizType: task.BizType}
+			byBizType[task.BizType] = u
+		}
+		seconds := end.Sub(start).Seconds()
+		u.RuntimeSeconds += seconds
+		u.Cost += seconds * s.costWeight(task.Type)
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	usage := &Usage{Since: since, UpdatedAt: now, Truncated: truncated}
+	for bizType, budget := range s.opts.budgets {
+		if byBizType[bizType] == nil {
+			byBizType[bizType] = &BizTypeUsage{BizType: bizType}
+		}
+		byBizType[bizType].Budget = budget
+	}
+	for _, u := range byBizType {
+		u.Exceeded = u.Budget > 0 && u.Cost >= u.Budget
+		usage.BizTypes = append(usage.BizTypes, *u)
+
+		s.usageMetrics.runtime.Set(u.RuntimeSeconds, u.BizType)
+		s.usageMetrics.cost.Set(u.Cost, u.BizType)
+		s.usageMetrics.budget.Set(u.Budget, u.BizType)
+	}
+	sort.Slice(usage.BizTypes, func(i, j int) bool { return usage.BizTypes[i].BizType < usage.BizTypes[j].BizType })
+
+	s.usage.Store(usage)
+	return usage, nil
+}
+
+func (s *Scheduler) costWeight(taskType string) float64 {
+	if weight, ok := s.opts.costWeights[taskType]; ok {
+		return weight
+	}
+	return 1
+}
+
+// overBudget returns why new tasks of the biz type are not scheduled, empty
+// if the budget is not exceeded. tasks already assigned once are not held.
+func (s *Scheduler) overBudget(task *model.Task) string {
+	usage := s.usage.Load()
+	if usage == nil || task.AssignedAt != nil {
+		return ""
+	}
+	for _, u := range usage.BizTypes {
+		if u.BizType == task.BizType && u.Exceeded {
+			return fmt.Sprintf("业务类型 %s 本月成本 %.0f 已超出预算 %.0f", u.BizType, u.Cost, u.Budget)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestUsage(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	at := func(t time.Time) *time.Time { return &t }
+	hour := func(d, h int) time.Time { return time.Date(2024, 5, d, h, 0, 0, 0, time.UTC) }
+	repo := &listRepo{tasks: []*model.Task{
+		{TaskKey: "ok", BizType: "a", Type: "gpu", Status: model.TaskStatusSuccess, StartedAt: at(hour(1, 0)), FinishedAt: at(hour(1, 1))},
+		// only the part ran in this month counts.
+		{TaskKey: "cross-month", BizType: "a", Status: model.TaskStatusFailed, StartedAt: at(time.Date(2024, 4, 30, 23, 0, 0, 0, time.UTC)), FinishedAt: at(hour(1, 1))},
+		{TaskKey: "last-month", BizType: "a", Status: model.TaskStatusSuccess, StartedAt: at(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)), FinishedAt: at(time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC))},
+		{TaskKey: "run", BizType: "b", Status: model.TaskStatusRunning, StartedAt: at(hour(10, 11))},
+		{TaskKey: "wait", BizType: "b", Status: model.TaskStatusWaitScheduling},
+	}}
+	s := &Scheduler{taskRepo: repo, usageMetrics: newUsageMetrics(), opts: newOptions(
+		WithCostWeight("gpu", 10),
+		WithBizTypeBudget("a", 36000),
+		WithBizTypeBudget("c", 100),
+	)}
+
+	usage, err := s.refreshUsage(context.Background(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("按业务类型统计", func(t *testing.T) {
+		want := []BizTypeUsage{
+			{BizType: "a", RuntimeSeconds: 7200, Cost: 36000 + 3600, Budget: 36000, Exceeded: true},
+			{BizType: "b", RuntimeSeconds: 3600, Cost: 3600},
+			{BizType: "c", Budget: 100},
+		}
+		if len(usage.BizTypes) != len(want) {
+			t.Fatalf("用量错误: %+v", usage.BizTypes)
+		}
+		for i := range want {
+			if usage.BizTypes[i] != want[i] {
+				t.Errorf("用量错误: got %+v, want %+v", usage.BizTypes[i], want[i])
+			}
+		}
+		if !usage.Since.Equal(hour(1, 0)) {
+			t.Errorf("统计起点错误: %v", usage.Since)
+		}
+	})
+
+	t.Run("超出预算的新任务不调度", func(t *testing.T) {
+		if s.overBudget(&model.Task{BizType: "a"}) == "" {
+			t.Error("超出预算的新任务应不调度")
+		}
+		if s.overBudget(&model.Task{BizType: "a", AssignedAt: at(now)}) != "" {
+			t.Error("已调度过的任务不受预算限制")
+		}
+		if s.overBudget(&model.Task{BizType: "c"}) != "" {
+			t.Error("未超出预算的任务应可调度")
+		}
+	})
+
+	t.Run("按业务类型过滤", func(t *testing.T) {
+		u, err := s.Usage(context.Background(), "b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(u.BizTypes) != 1 || u.BizTypes[0].BizType != "b" || len(usage.BizTypes) != 3 {
+			t.Errorf("过滤错误: %+v", u.BizTypes)
+		}
+	})
+}
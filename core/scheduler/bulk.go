@@ -0,0 +1,178 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/audit"
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+const (
+	// max tasks updated in one transaction by bulk operations.
+	bulkBatchSize = 200
+	// max reasons of skipped tasks kept in BulkResult.
+	maxBulkSkips = 100
+)
+
+// BulkResult summarizes a bulk operation.
+type BulkResult struct {
+	// tasks matching the filter when updated.
+	Matched int `json:"matched"`
+	Updated int `json:"updated"`
+	// tasks can not be changed, eg. finished or of forbidden biz types.
+	Skipped int `json:"skipped"`
+	// why tasks are skipped by task key, at most maxBulkSkips.
+	Skips map[string]string `json:"skips,omitempty"`
+}
+
+func (r *BulkResult) skip(taskKey string, err error) {
+	r.Skipped++
+	if len(r.Skips) >= maxBulkSkips {
+		return
+	}
+	if r.Skips == nil {
+		r.Skips = make(map[string]string)
+	}
+	r.Skips[taskKey] = err.Error()
+}
+
+// StopTasks stops all tasks matching filter, eg. all tasks of a biz type in
+// an incident. Offset and Limit of filter are ignored.
+func (s *Scheduler) StopTasks(ctx context.Context, filter *model.TaskFilter, reason, operator string) (*BulkResult, error) {
+	return s.operateTasks(ctx, filter, model.TaskStatusStop, reason, operator)
+}
+
+// PauseTasks pauses all tasks matching filter, services are skipped.
+// Offset and Limit of filter are ignored.
+func (s *Scheduler) PauseTasks(ctx context.Context, filter *model.TaskFilter, reason, operator string) (*BulkResult, error) {
+	return s.operateTasks(ctx, filter, model.TaskStatusPaused, reason, operator)
+}
+
+// operateTasks changes want status of tasks matching filter in batches, each
+// batch is updated in one transaction only if no status of its tasks changed
+// since read. a batch failed or not applied, eg. a task of it finished
+// meanwhile, is operated task by task on the latest tasks.
+func (s *Scheduler) operateTasks(ctx context.Context, filter *model.TaskFilter, nextStatus model.TaskStatus, reason, operator string) (*BulkResult, error) {
+	if filter == nil || (len(filter.BizIDs) == 0 && filter.BizType == "" && filter.Type == "" && len(filter.Labels) == 0 && len(filter.Statuses) == 0) {
+		return nil, errors.New("bulk operations need at least one of biz ids, biz type, type, labels or statuses")
+	}
+	if filter.OnlyDeleted || filter.Archived {
+		return nil, errors.New("bulk operations can not change deleted or archived tasks")
+	}
+	if err := auth.CheckBizType(ctx, filter.BizType); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.listTaskKeys(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := &BulkResult{}
+	for batch := range slices.Chunk(keys, bulkBatchSize) {
+		tasks, err := s.taskRepo.BatchGetTask(ctx, batch)
+		if err != nil {
+			return result, errors.WithStack(err)
+		}
+
+		var (
+			operated, updates []*model.Task
+			from              []model.TaskStatus
+		)
+		now := time.Now()
+		for _, task := range tasks {
+			// changed since listed.
+			if !filter.Match(task) {
+				continue
+			}
+			result.Matched++
+			if err := auth.CheckBizType(ctx, task.BizType); err != nil {
+				result.skip(task.TaskKey, err)
+				continue
+			}
+			waitStatus, err := operable(task, nextStatus)
+			if err != nil {
+				result.skip(task.TaskKey, err)
+				continue
+			}
+			update := &model.Task{
+				TaskKey:       task.TaskKey,
+				Status:        waitStatus,
+				WantRunStatus: nextStatus,
+				Operator:      operator,
+			}
+			holdback(task, nextStatus, update, now)
+			operated = append(operated, task)
+			updates = append(updates, update)
+			from = append(from, task.Status)
+		}
+		if len(updates) == 0 {
+			continue
+		}
+
+		applied, err := s.taskRepo.BatchUpdateTasksCAS(ctx, from, updates)
+		if err != nil || !applied {
+			log.Warn("[Bulk] 批量%s %d 个任务未生效, 逐个操作: %v", nextStatus, len(updates), err)
+			for _, task := range operated {
+				if err := s.operateLatest(ctx, filter, task.TaskKey, nextStatus, reason, operator); err != nil {
+					result.skip(task.TaskKey, err)
+					continue
+				}
+				result.Updated++
+			}
+			continue
+		}
+		result.Updated += len(updates)
+		for _, task := range operated {
+			s.audit(ctx, audit.Entry{
+				TaskKey:  task.TaskKey,
+				Operator: operator,
+				Action:   audit.ActionOperate,
+				From:     task.WantRunStatus.String(),
+				To:       nextStatus.String(),
+				Reason:   reason,
+			})
+		}
+	}
+	log.Info("[Bulk] %s 操作 %s 完成, 匹配 %d, 更新 %d, 跳过 %d", operator, nextStatus, result.Matched, result.Updated, result.Skipped)
+	return result, nil
+}
+
+// operateLatest operates the latest task of taskKey, which changed since
+// read by the batch, if it still matches filter.
+func (s *Scheduler) operateLatest(ctx context.Context, filter *model.TaskFilter, taskKey string, nextStatus model.TaskStatus, reason, operator string) error {
+	task, err := s.taskRepo.GetTask(ctx, taskKey)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !filter.Match(task) {
+		return errors.Errorf("任务[%s]已不再匹配过滤条件", taskKey)
+	}
+	return s.operateTask(ctx, task, nextStatus, reason, operator)
+}
+
+// listTaskKeys returns keys of all tasks matching filter, keys are listed
+// before any update since updated tasks may no longer match filter.
+func (s *Scheduler) listTaskKeys(ctx context.Context, filter *model.TaskFilter) ([]string, error) {
+	page := *filter
+	page.Offset, page.Limit = 0, bulkBatchSize
+	var keys []string
+	for {
+		tasks, err := s.taskRepo.ListTask(ctx, &page)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, task := range tasks {
+			keys = append(keys, task.TaskKey)
+		}
+		if len(tasks) < page.Limit {
+			return keys, nil
+		}
+		page.Offset += len(tasks)
+	}
+}
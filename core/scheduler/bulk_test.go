@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type bulkRepo struct {
+	statusRepo
+	batchErr error
+	batches  int
+	// statuses reported by workers right after tasks are read.
+	reported map[string]model.TaskStatus
+}
+
+func (r *bulkRepo) BatchGetTask(_ context.Context, keys []string) ([]*model.Task, error) {
+	tasks, _ := (&batchRepo{r.listRepo}).BatchGetTask(context.Background(), keys)
+	ret := make([]*model.Task, 0, len(tasks))
+	for _, t := range tasks {
+		c := *t
+		ret = append(ret, &c)
+		if status, ok := r.reported[t.TaskKey]; ok {
+			t.Status = status
+		}
+	}
+	return ret, nil
+}
+
+func (r *bulkRepo) BatchUpdateTasksCAS(_ context.Context, from []model.TaskStatus, updates []*model.Task) (bool, error) {
+	if r.batchErr != nil {
+		return false, r.batchErr
+	}
+	current := make(map[string]*model.Task, len(r.tasks))
+	for _, t := range r.tasks {
+		current[t.TaskKey] = t
+	}
+	for i, u := range updates {
+		if current[u.TaskKey].Status != from[i] {
+			return false, nil
+		}
+	}
+	r.batches++
+	for _, u := range updates {
+		t := current[u.TaskKey]
+		t.Status, t.WantRunStatus = u.Status, u.WantRunStatus
+	}
+	return true, nil
+}
+
+func TestBulkOperate(t *testing.T) {
+	ctx := context.Background()
+	newRepo := func() *bulkRepo {
+		repo := &bulkRepo{}
+		for i := 0; i < bulkBatchSize+10; i++ {
+			repo.tasks = append(repo.tasks, &model.Task{
+				TaskKey: "ingest-" + string(rune('a'+i%26)) + string(rune('a'+i/26)), BizType: "ingest",
+				Status: model.TaskStatusRunning, WantRunStatus: model.TaskStatusRunning,
+			})
+		}
+		repo.tasks = append(repo.tasks,
+			&model.Task{TaskKey: "done", BizType: "ingest", Status: model.TaskStatusSuccess},
+			&model.Task{TaskKey: "other", BizType: "report", Status: model.TaskStatusRunning},
+		)
+		return repo
+	}
+
+	t.Run("分批停止匹配的任务", func(t *testing.T) {
+		repo := newRepo()
+		s := &Scheduler{taskRepo: repo, opts: newOptions()}
+		result, err := s.StopTasks(ctx, &model.TaskFilter{BizType: "ingest"}, "incident", "user:alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Matched != bulkBatchSize+11 || result.Updated != bulkBatchSize+10 || result.Skipped != 1 || result.Skips["done"] == "" {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+		if repo.batches != 2 {
+			t.Errorf("want 2 batches, got %d", repo.batches)
+		}
+		for _, task := range repo.tasks {
+			stopped := task.WantRunStatus == model.TaskStatusStop && task.Status == model.TaskStatusWaitStop
+			if stopped != (task.BizType == "ingest" && task.TaskKey != "done") {
+				t.Errorf("task %s status %s want %s", task.TaskKey, task.Status, task.WantRunStatus)
+			}
+		}
+	})
+
+	t.Run("批量失败时逐个操作", func(t *testing.T) {
+		repo := newRepo()
+		repo.batchErr = errors.New("final status")
+		s := &Scheduler{taskRepo: repo, opts: newOptions()}
+		result, err := s.PauseTasks(ctx, &model.TaskFilter{BizType: "ingest", Statuses: []model.TaskStatus{model.TaskStatusRunning}}, "incident", "user:alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Matched != bulkBatchSize+10 || result.Updated != bulkBatchSize+10 || result.Skipped != 0 {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+		if repo.tasks[0].Status != model.TaskStatusWaitPaused {
+			t.Errorf("task should be paused one by one, got %s", repo.tasks[0].Status)
+		}
+	})
+
+	t.Run("读取后状态被修改时不覆盖", func(t *testing.T) {
+		repo := newRepo()
+		repo.reported = map[string]model.TaskStatus{repo.tasks[0].TaskKey: model.TaskStatusSuccess}
+		s := &Scheduler{taskRepo: repo, opts: newOptions()}
+		result, err := s.PauseTasks(ctx, &model.TaskFilter{BizType: "ingest", Statuses: []model.TaskStatus{model.TaskStatusRunning}}, "incident", "user:alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Updated != bulkBatchSize+9 || result.Skipped != 1 || result.Skips[repo.tasks[0].TaskKey] == "" {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+		if repo.tasks[0].Status != model.TaskStatusSuccess {
+			t.Errorf("status reported by worker should be kept, got %s", repo.tasks[0].Status)
+		}
+		if repo.tasks[1].Status != model.TaskStatusWaitPaused {
+			t.Errorf("other tasks should be paused one by one, got %s", repo.tasks[1].Status)
+		}
+	})
+
+	t.Run("空条件及无权限的业务类型", func(t *testing.T) {
+		repo := newRepo()
+		s := &Scheduler{taskRepo: repo, opts: newOptions()}
+		if _, err := s.StopTasks(ctx, &model.TaskFilter{}, "incident", "user:alice"); err == nil {
+			t.Error("empty filter should be rejected")
+		}
+
+		ctx := auth.WithPrincipal(ctx, &auth.Principal{Name: "bob", Role: auth.RoleAdmin, BizTypes: []string{"report"}})
+		var forbidden *auth.ForbiddenError
+		if _, err := s.StopTasks(ctx, &model.TaskFilter{BizType: "ingest"}, "incident", "user:bob"); !errors.As(err, &forbidden) {
+			t.Errorf("want forbidden, got %v", err)
+		}
+		if repo.tasks[0].Status != model.TaskStatusRunning {
+			t.Errorf("task should not be stopped, got %s", repo.tasks[0].Status)
+		}
+	})
+}
@@ -0,0 +1,296 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/notify"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// CanaryPolicy routes Percent of tasks of TaskType to workers reporting
+// executor Version, the rest go to workers running other versions.
+// canary is rolled back, no more tasks are routed to Version, once failure
+// rate of canary tasks exceeds MaxFailureRate after MinSamples tasks finished.
+type CanaryPolicy struct {
+	TaskType string `json:"task_type"`
+	Version  string `json:"version"`
+	// 0-100.
+	Percent float64 `json:"percent"`
+	// 0-1, 0 disables auto rollback.
+	MaxFailureRate float64 `json:"max_failure_rate"`
+	MinSamples     int     `json:"min_samples"`
+}
+
+// CanaryStatus is the progress of a canary policy.
+type CanaryStatus struct {
+	Policy CanaryPolicy `json:"policy"`
+	// finished tasks ran by canary and stable workers.
+	CanaryFinished int    `json:"canary_finished"`
+	CanaryFailed   int    `json:"canary_failed"`
+	StableFinished int    `json:"stable_finished"`
+	StableFailed   int    `json:"stable_failed"`
+	RolledBack     bool   `json:"rolled_back"`
+	RollbackReason string `json:"rollback_reason,omitempty"`
+}
+
+type canaryState struct {
+	status CanaryStatus
+	// assigned unfinished task keys, true if assigned to canary worker.
+	pending map[string]bool
+}
+
+// canaries tracks canary policies, stats are kept in memory of the leader
+// and restart from zero after leader changes.
+type canaries struct {
+	mu     sync.Mutex
+	states map[string]*canaryState
+}
+
+func newCanaries(policies []CanaryPolicy) *canaries {
+	c := &canaries{states: make(map[string]*canaryState)}
+	for _, p := range policies {
+		c.set(p)
+	}
+	return c
+}
+
+func (c *canaries) set(p CanaryPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states[p.TaskType] = &canaryState{
+		status:  CanaryStatus{Policy: p},
+		pending: make(map[string]bool),
+	}
+}
+
+func (c *canaries) remove(taskType string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.states, taskType)
+}
+
+func (c *canaries) list() []CanaryStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ret := make([]CanaryStatus, 0, len(c.states))
+	for _, st := range c.states {
+		ret = append(ret, st.status)
+	}
+	return ret
+}
+
+// route narrows candidate workers of task to canary or stable workers.
+func (c *canaries) route(task *model.Task, workers []discover.Instance) []discover.Instance {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, ok := c.states[task.Type]
+	if !ok {
+		return workers
+	}
+
+	var canary, stable []discover.Instance
+	for _, w := range workers {
+		if model.ParseExecutorVersions(w.Metadata)[task.Type] == st.status.Policy.Version {
+			canary = append(canary, w)
+		} else {
+			stable = append(stable, w)
+		}
+	}
+
+	toCanary := !st.status.RolledBack && random.Float64()*100 < st.status.Policy.Percent
+	switch {
+	case toCanary && len(canary) > 0:
+		return canary
+	case len(stable) > 0:
+		return stable
+	case st.status.RolledBack:
+		// never fall back to the rolled back version.
+		return nil
+	default:
+		return canary
+	}
+}
+
+// track records task assigned to worker running executor version.
+func (c *canaries) track(task *model.Task, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if st, ok := c.states[task.Type]; ok {
+		st.pending[task.TaskKey] = version == st.status.Policy.Version
+	}
+}
+
+func (c *canaries) pendingKeys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var keys []string
+	for _, st := range c.states {
+		for key := range st.pending {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// observe counts finished tasks of keys, returns statuses rolled back by them.
+func (c *canaries) observe(keys []string, tasks []*model.Task) []CanaryStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// purged tasks are no longer tracked.
+	found := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		found[task.TaskKey] = true
+	}
+	for _, key := range keys {
+		if found[key] {
+			continue
+		}
+		for _, st := range c.states {
+			delete(st.pending, key)
+		}
+	}
+
+	for _, task := range tasks {
+		st, ok := c.states[task.Type]
+		if !ok {
+			continue
+		}
+		toCanary, ok := st.pending[task.TaskKey]
+		if !ok || !task.Status.IsFinalStatus() {
+			continue
+		}
+		delete(st.pending, task.TaskKey)
+
+		failed := task.Status == model.TaskStatusFailed
+		if toCanary {
+			st.status.CanaryFinished++
+			if failed {
+				st.status.CanaryFailed++
+			}
+		} else {
+			st.status.StableFinished++
+			if failed {
+				st.status.StableFailed++
+			}
+		}
+	}
+
+	var rolledBack []CanaryStatus
+	for _, st := range c.states {
+		if reason := st.status.rollbackReason(); reason != "" {
+			st.status.RolledBack = true
+			st.status.RollbackReason = reason
+			rolledBack = append(rolledBack, st.status)
+		}
+	}
+	return rolledBack
+}
+
+// rollbackReason returns why the canary should be rolled back, empty if not.
+func (s CanaryStatus) rollbackReason() string {
+	p := s.Policy
+	if s.RolledBack || p.MaxFailureRate <= 0 || s.CanaryFinished == 0 || s.CanaryFinished < p.MinSamples {
+		return ""
+	}
+	rate := float64(s.CanaryFailed) / float64(s.CanaryFinished)
+	if rate <= p.MaxFailureRate {
+		return ""
+	}
+	return fmt.Sprintf("canary failure rate %.2f exceeds %.2f, %d/%d failed", rate, p.MaxFailureRate, s.CanaryFailed, s.CanaryFinished)
+}
+
+// SetCanary starts routing tasks of policy.TaskType by executor version,
+// stats of previous policy of the same task type are reset.
+func (s *Scheduler) SetCanary(policy CanaryPolicy) error {
+	if policy.TaskType == "" || policy.Version == "" {
+		return errors.New("invalid params, need task type and version")
+	}
+	if policy.Percent < 0 || policy.Percent > 100 {
+		return errors.Errorf("invalid canary percent %v", policy.Percent)
+	}
+	s.canaries.set(policy)
+	log.Info("任务类型[%s]开始灰度版本 %s, 比例 %.1f%%", policy.TaskType, policy.Version, policy.Percent)
+	return nil
+}
+
+// RemoveCanary stops routing tasks of taskType by executor version.
+func (s *Scheduler) RemoveCanary(taskType string) {
+	s.canaries.remove(taskType)
+}
+
+// Canaries returns status of all canary policies.
+func (s *Scheduler) Canaries() []CanaryStatus {
+	return s.canaries.list()
+}
+
+func (s *Scheduler) trackCanary(task *model.Task, workerID string) {
+	for _, worker := range s.getAvailableWorkers() {
+		if worker.ID() == workerID {
+			s.canaries.track(task, model.ParseExecutorVersions(worker.Metadata)[task.Type])
+			return
+		}
+	}
+}
+
+// runCanaryController collects results of canary tasks, only leader works.
+func (s *Scheduler) runCanaryController() {
+	if s.opts.canaryCheckInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.opts.canaryCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		amILeader, _, err := s.amILeader()
+		if err != nil {
+			log.Error("获取 leader 状态失败: %v", err)
+			continue
+		}
+		if !amILeader {
+			continue
+		}
+
+		if err := s.checkCanaries(context.Background()); err != nil {
+			log.Error("灰度检查失败: %v", err)
+		}
+	}
+}
+
+func (s *Scheduler) checkCanaries(ctx context.Context) error {
+	keys := s.canaries.pendingKeys()
+	if len(keys) == 0 {
+		return nil
+	}
+	tasks, err := taskrepo.ChunkedBatchGetTask(
+		ctx, s.taskRepo.BatchGetTask, keys,
+		taskrepo.DefaultBatchGetChunkSize, taskrepo.DefaultBatchGetParallelism,
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, st := range s.canaries.observe(keys, tasks) {
+		log.Warn("任务类型[%s]灰度版本 %s 已回滚: %s", st.Policy.TaskType, st.Policy.Version, st.RollbackReason)
+		if s.opts.alerter == nil {
+			continue
+		}
+		if err := s.opts.alerter.Notify(ctx, notify.Message{
+			Level: notify.LevelWarning,
+			Title: "Canary rolled back",
+			Text:  fmt.Sprintf("task type %s version %s: %s", st.Policy.TaskType, st.Policy.Version, st.RollbackReason),
+		}); err != nil {
+			log.Error("灰度回滚告警发送失败: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestCanaryRoute(t *testing.T) {
+	workers := []discover.Instance{
+		{InstanceId: "1", Metadata: map[string]string{model.ExecutorVersionKey("email"): "v1"}},
+		{InstanceId: "2", Metadata: map[string]string{model.ExecutorVersionKey("email"): "v2"}},
+	}
+	task := &model.Task{TaskKey: "t1", Type: "email"}
+
+	t.Run("无灰度策略不过滤", func(t *testing.T) {
+		c := newCanaries(nil)
+		if got := c.route(task, workers); len(got) != 2 {
+			t.Errorf("route() = %v, want all workers", got)
+		}
+	})
+	t.Run("100% 灰度路由到新版本", func(t *testing.T) {
+		c := newCanaries([]CanaryPolicy{{TaskType: "email", Version: "v2", Percent: 100}})
+		got := c.route(task, workers)
+		if len(got) != 1 || got[0].InstanceId != "2" {
+			t.Errorf("route() = %v, want worker 2", got)
+		}
+	})
+	t.Run("0% 灰度路由到稳定版本", func(t *testing.T) {
+		c := newCanaries([]CanaryPolicy{{TaskType: "email", Version: "v2", Percent: 0}})
+		got := c.route(task, workers)
+		if len(got) != 1 || got[0].InstanceId != "1" {
+			t.Errorf("route() = %v, want worker 1", got)
+		}
+	})
+	t.Run("回滚后不再路由到新版本", func(t *testing.T) {
+		c := newCanaries([]CanaryPolicy{{TaskType: "email", Version: "v2", Percent: 100}})
+		c.states["email"].status.RolledBack = true
+		if got := c.route(task, workers[1:]); len(got) != 0 {
+			t.Errorf("route() = %v, want none", got)
+		}
+	})
+}
+
+func TestCanaryObserve(t *testing.T) {
+	c := newCanaries([]CanaryPolicy{{TaskType: "email", Version: "v2", Percent: 50, MaxFailureRate: 0.5, MinSamples: 2}})
+	c.track(&model.Task{TaskKey: "a", Type: "email"}, "v2")
+	c.track(&model.Task{TaskKey: "b", Type: "email"}, "v2")
+	c.track(&model.Task{TaskKey: "c", Type: "email"}, "v1")
+	c.track(&model.Task{TaskKey: "d", Type: "email"}, "v2")
+
+	keys := []string{"a", "b", "c", "d"}
+	rolledBack := c.observe(keys, []*model.Task{
+		{TaskKey: "a", Type: "email", Status: model.TaskStatusFailed},
+		{TaskKey: "b", Type: "email", Status: model.TaskStatusRunning},
+		{TaskKey: "c", Type: "email", Status: model.TaskStatusSuccess},
+	})
+	if len(rolledBack) != 0 {
+		t.Fatalf("observe() rolled back before min samples: %v", rolledBack)
+	}
+	if got := len(c.pendingKeys()); got != 1 {
+		t.Fatalf("pending keys = %d, want 1", got)
+	}
+
+	rolledBack = c.observe([]string{"b"}, []*model.Task{
+		{TaskKey: "b", Type: "email", Status: model.TaskStatusFailed},
+	})
+	if len(rolledBack) != 1 || !rolledBack[0].RolledBack {
+		t.Fatalf("observe() = %v, want rolled back", rolledBack)
+	}
+	st := c.list()[0]
+	if st.CanaryFinished != 2 || st.CanaryFailed != 2 || st.StableFinished != 1 {
+		t.Errorf("status = %+v", st)
+	}
+}
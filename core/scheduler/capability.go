@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// Capability is a task type the fleet can run, aggregated from metadata
+// published by available workers.
+type Capability struct {
+	TaskType string `json:"task_type"`
+	// ids of available workers having registered the executor, sorted.
+	Workers []string `json:"workers"`
+	// executor version => number of workers reporting it.
+	ExecutorVersions map[string]int `json:"executor_versions,omitempty"`
+	// payload schema version => schema, workers may run different
+	// versions during a rollout.
+	Schemas map[string]json.RawMessage `json:"schemas,omitempty"`
+}
+
+// ListCapabilities returns task types registered by available workers, sorted by task type.
+// workers not reporting task types are skipped, since they accept any type.
+func (s *Scheduler) ListCapabilities() []Capability {
+	byType := make(map[string]*Capability)
+	for _, w := range s.getAvailableWorkers() {
+		types, ok := model.ParseExecutorTypes(w.Metadata)
+		if !ok {
+			continue
+		}
+		versions := model.ParseExecutorVersions(w.Metadata)
+		schemas := model.ParsePayloadSchemas(w.Metadata)
+		for taskType := range types {
+			c := byType[taskType]
+			if c == nil {
+				c = &Capability{TaskType: taskType}
+				byType[taskType] = c
+			}
+			c.Workers = append(c.Workers, w.ID())
+			if version, ok := versions[taskType]; ok {
+				if c.ExecutorVersions == nil {
+					c.ExecutorVersions = make(map[string]int)
+				}
+				c.ExecutorVersions[version]++
+			}
+			if schema, ok := schemas[taskType]; ok {
+				if c.Schemas == nil {
+					c.Schemas = make(map[string]json.RawMessage)
+				}
+				c.Schemas[schema.Version] = schema.Schema
+			}
+		}
+	}
+
+	ret := make([]Capability, 0, len(byType))
+	for _, c := range byType {
+		sort.Strings(c.Workers)
+		ret = append(ret, *c)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].TaskType < ret[j].TaskType })
+	return ret
+}
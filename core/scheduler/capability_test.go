@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestListCapabilities(t *testing.T) {
+	s := &Scheduler{}
+	s.setAvailableWorkers([]discover.Instance{
+		{InstanceId: "w2", Metadata: map[string]string{
+			model.ExecutorTypesKey:               "email,sms",
+			model.ExecutorVersionKey("email"):    "v2",
+			model.PayloadSchemaKey("email"):      `{"version": "2", "schema": {"type": "object"}}`,
+			model.PayloadSchemaKey("sms"):        `not json`,
+			model.ExecutorVersionKey("not-used"): "v1",
+		}},
+		{InstanceId: "w1", Metadata: map[string]string{
+			model.ExecutorTypesKey:            "email",
+			model.ExecutorVersionKey("email"): "v1",
+			model.PayloadSchemaKey("email"):   `{"version": "1"}`,
+		}},
+		// accepts any type, not reported.
+		{InstanceId: "w3"},
+	})
+
+	caps := s.ListCapabilities()
+	if len(caps) != 2 || caps[0].TaskType != "email" || caps[1].TaskType != "sms" {
+		t.Fatalf("任务类型错误: %+v", caps)
+	}
+
+	t.Run("聚合 worker 和版本", func(t *testing.T) {
+		email := caps[0]
+		if len(email.Workers) != 2 || email.Workers[0] != "w1" {
+			t.Errorf("worker 错误: %v", email.Workers)
+		}
+		if email.ExecutorVersions["v1"] != 1 || email.ExecutorVersions["v2"] != 1 {
+			t.Errorf("执行器版本错误: %v", email.ExecutorVersions)
+		}
+		if len(email.Schemas) != 2 || string(email.Schemas["2"]) != `{"type": "object"}` {
+			t.Errorf("schema 错误: %v", email.Schemas)
+		}
+	})
+
+	t.Run("忽略格式错误的 schema", func(t *testing.T) {
+		if caps[1].Schemas != nil || len(caps[1].Workers) != 1 {
+			t.Errorf("sms 能力错误: %+v", caps[1])
+		}
+	})
+}
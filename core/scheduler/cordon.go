@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/components/log"
+)
+
+// Cordon keeps new tasks off a worker, eg. during maintenance, tasks already
+// assigned to it keep running.
+type Cordon struct {
+	WorkerID   string    `json:"worker_id"`
+	Reason     string    `json:"reason,omitempty"`
+	Operator   string    `json:"operator"`
+	CordonedAt time.Time `json:"cordoned_at"`
+}
+
+// ErrNotLeader is returned by calls only the leader serves, eg. cordons,
+// retry them on the leader.
+var ErrNotLeader = errors.New("scheduler is not the leader")
+
+// cordons are kept in memory of the leader only, which is the only scheduler
+// assigning tasks, cordon workers on the new leader again after it changes.
+// the zero value has no cordon.
+type cordons struct {
+	mu      sync.RWMutex
+	workers map[string]Cordon
+}
+
+func (c *cordons) cordoned(workerID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.workers[workerID]
+	return ok
+}
+
+// route removes cordoned workers.
+func (c *cordons) route(workers []discover.Instance) []discover.Instance {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.workers) == 0 {
+		return workers
+	}
+	ret := make([]discover.Instance, 0, len(workers))
+	for _, worker := range workers {
+		if _, ok := c.workers[worker.ID()]; !ok {
+			ret = append(ret, worker)
+		}
+	}
+	return ret
+}
+
+func (c *cordons) list() []Cordon {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ret := make([]Cordon, 0, len(c.workers))
+	for _, cordon := range c.workers {
+		ret = append(ret, cordon)
+	}
+	slices.SortFunc(ret, func(a, b Cordon) int { return strings.Compare(a.WorkerID, b.WorkerID) })
+	return ret
+}
+
+// CordonWorker stops assigning new tasks to workerID, tasks assigned to it
+// keep running. the worker needs not be available, so that it's cordoned
+// before it registers again after maintenance. cordons are not persisted,
+// ErrNotLeader is returned if the scheduler is not the leader.
+func (s *Scheduler) CordonWorker(ctx context.Context, workerID, reason, operator string) error {
+	if workerID == "" {
+		return errors.New("invalid params, need worker id")
+	}
+	if err := s.checkLeader(); err != nil {
+		return err
+	}
+	s.cordons.mu.Lock()
+	defer s.cordons.mu.Unlock()
+	if _, ok := s.cordons.workers[workerID]; ok {
+		return nil
+	}
+	if s.cordons.workers == nil {
+		s.cordons.workers = make(map[string]Cordon)
+	}
+	s.cordons.workers[workerID] = Cordon{WorkerID: workerID, Reason: reason, Operator: operator, CordonedAt: time.Now()}
+	log.Info("worker[%s]已被 %s cordon: %s", workerID, operator, reason)
+	return nil
+}
+
+// UncordonWorker assigns new tasks to workerID again, ErrNotLeader is
+// returned if the scheduler is not the leader.
+func (s *Scheduler) UncordonWorker(ctx context.Context, workerID, operator string) error {
+	if workerID == "" {
+		return errors.New("invalid params, need worker id")
+	}
+	if err := s.checkLeader(); err != nil {
+		return err
+	}
+	s.cordons.mu.Lock()
+	_, ok := s.cordons.workers[workerID]
+	delete(s.cordons.workers, workerID)
+	s.cordons.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	log.Info("worker[%s]已被 %s uncordon", workerID, operator)
+	// tasks waiting for room may be assigned to the worker now.
+	s.triggerReAssignEvent()
+	return nil
+}
+
+// Cordons returns cordoned workers sorted by worker id, ErrNotLeader is
+// returned if the scheduler is not the leader, which knows no cordon.
+func (s *Scheduler) Cordons() ([]Cordon, error) {
+	if err := s.checkLeader(); err != nil {
+		return nil, err
+	}
+	return s.cordons.list(), nil
+}
+
+func (s *Scheduler) checkLeader() error {
+	amILeader, leader, err := s.amILeader()
+	if err != nil {
+		return err
+	}
+	if !amILeader {
+		if leader == nil {
+			return ErrNotLeader
+		}
+		return errors.Wrapf(ErrNotLeader, "leader is %s(%s)", leader.MasterID, leader.IP)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/components/election"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestCordonWorker(t *testing.T) {
+	ctx := context.Background()
+	newScheduler := func() *Scheduler {
+		s := &Scheduler{
+			opts:        newOptions(),
+			canaries:    newCanaries(nil),
+			taskRepo:    &getRepo{listRepo{tasks: []*model.Task{{TaskKey: "t1", Status: model.TaskStatusRunning, WorkerID: "w2"}}}},
+			assignEvent: make(chan struct{}, 1),
+			elector:     election.NewLocal("s1"),
+		}
+		s.setAvailableWorkers([]discover.Instance{
+			{InstanceId: "w1", Metadata: map[string]string{model.CpuUsageKey: "10", model.MemUsageKey: "10"}},
+			{InstanceId: "w2", Metadata: map[string]string{model.CpuUsageKey: "90", model.MemUsageKey: "90"}},
+		})
+		return s
+	}
+
+	t.Run("cordon 后不再分配新任务", func(t *testing.T) {
+		s := newScheduler()
+		if err := s.CordonWorker(ctx, "w1", "维护", "ops"); err != nil {
+			t.Fatal(err)
+		}
+		workerID, err := s.selectWorkerID(&model.Task{TaskKey: "t1"})
+		if err != nil || workerID != "w2" {
+			t.Fatalf("期望分配到 w2, 得到 %s, %v", workerID, err)
+		}
+		if p := s.PreviewPlacement(&model.Task{TaskKey: "t1"}); p.WorkerID != "w2" {
+			t.Fatalf("预览期望 w2, 得到 %+v", p)
+		}
+		if err := s.ForceReassignTask(ctx, "t1", "w1", "", "ops"); err == nil {
+			t.Fatal("期望不能强制分配到 cordon 的 worker")
+		}
+		if cordons, _ := s.Cordons(); len(cordons) != 1 || cordons[0].WorkerID != "w1" || cordons[0].Operator != "ops" {
+			t.Fatalf("期望 w1 已 cordon, 得到 %+v", cordons)
+		}
+	})
+
+	t.Run("全部 cordon 时无法调度", func(t *testing.T) {
+		s := newScheduler()
+		s.CordonWorker(ctx, "w1", "", "ops")
+		s.CordonWorker(ctx, "w2", "", "ops")
+		_, err := s.selectWorkerID(&model.Task{TaskKey: "t1"})
+		var ue *unschedulableError
+		if !errors.As(err, &ue) || ue.code != model.UnschedulableNoCapacity {
+			t.Fatalf("期望 no_capacity, 得到 %v", err)
+		}
+	})
+
+	t.Run("uncordon 后恢复分配", func(t *testing.T) {
+		s := newScheduler()
+		s.CordonWorker(ctx, "w1", "", "ops")
+		if err := s.UncordonWorker(ctx, "w1", "ops"); err != nil {
+			t.Fatal(err)
+		}
+		if len(s.assignEvent) != 1 {
+			t.Fatal("uncordon 后期望触发分配")
+		}
+		if workerID, _ := s.selectWorkerID(&model.Task{TaskKey: "t1"}); workerID != "w1" {
+			t.Fatalf("期望分配到 w1, 得到 %s", workerID)
+		}
+		if cordons, _ := s.Cordons(); len(cordons) != 0 {
+			t.Fatalf("期望没有 cordon, 得到 %+v", cordons)
+		}
+	})
+
+	t.Run("非 leader 拒绝 cordon", func(t *testing.T) {
+		s := newScheduler()
+		s.elector = followerElector{}
+		if err := s.CordonWorker(ctx, "w1", "维护", "ops"); !errors.Is(err, ErrNotLeader) {
+			t.Fatalf("期望 ErrNotLeader, 得到 %v", err)
+		}
+		if err := s.UncordonWorker(ctx, "w1", "ops"); !errors.Is(err, ErrNotLeader) {
+			t.Fatalf("期望 ErrNotLeader, 得到 %v", err)
+		}
+		if _, err := s.Cordons(); !errors.Is(err, ErrNotLeader) {
+			t.Fatalf("期望 ErrNotLeader, 得到 %v", err)
+		}
+		if s.cordons.cordoned("w1") {
+			t.Fatal("非 leader 不应保存 cordon")
+		}
+	})
+}
+
+// followerElector elects another scheduler.
+type followerElector struct{}
+
+func (followerElector) Leader() (*election.LeaderElection, error) {
+	return &election.LeaderElection{MasterID: "s0", IP: "10.0.0.1"}, nil
+}
+
+func (followerElector) AmILeader(*election.LeaderElection) bool { return false }
+
+func (followerElector) AttemptElection() {}
@@ -0,0 +1,196 @@
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+const (
+	dashboardPageSize = 500
+	// repo has no aggregate query, aggregates are computed by scanning at most this many tasks.
+	dashboardScanLimit = 50000
+)
+
+// DashboardFilter scopes the aggregates of Dashboard.
+type DashboardFilter struct {
+	BizType string
+	Type    string
+	// start of throughput, failures and slowest tasks, default one hour ago.
+	Since time.Time
+	// width of throughput buckets, default 5 minutes.
+	Bucket time.Duration
+	// max number of failures and slowest tasks, default 10.
+	Limit int
+}
+
+// Dashboard aggregates tasks for a monitoring UI.
+type Dashboard struct {
+	StatusCounts map[model.TaskStatus]int `json:"status_counts"`
+	Workers      []WorkerLoad             `json:"workers"`
+	Throughput   []ThroughputBucket       `json:"throughput"`
+	// failed tasks finished since Since, latest first.
+	RecentFailures []*model.Task `json:"recent_failures"`
+	// tasks ran longest since Since, running tasks count the time elapsed so far.
+	Slowest []SlowTask `json:"slowest"`
+	// more tasks than dashboardScanLimit matched, aggregates are partial.
+	Truncated bool `json:"truncated"`
+}
+
+// WorkerLoad is the load of an available worker.
+type WorkerLoad struct {
+	WorkerID string `json:"worker_id"`
+	// tasks assigned to the worker and not finished.
+	Tasks      int     `json:"tasks"`
+	Running    int     `json:"running"`
+	CPUUsage   float64 `json:"cpu_usage"`
+	MemUsage   float64 `json:"mem_usage"`
+	Goroutines float64 `json:"goroutines"`
+	// gets no new tasks, see Scheduler.CordonWorker.
+	Cordoned bool `json:"cordoned"`
+	// runtime labels published by the worker, see model.RuntimeLabelPrefix.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ThroughputBucket counts tasks created and finished in [Start, Start+Bucket).
+type ThroughputBucket struct {
+	Start     time.Time `json:"start"`
+	Created   int       `json:"created"`
+	Succeeded int       `json:"succeeded"`
+	Failed    int       `json:"failed"`
+	Stopped   int       `json:"stopped"`
+}
+
+type SlowTask struct {
+	Task     *model.Task   `json:"task"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Dashboard returns per-status counts, per-worker load, throughput, recent
+// failures and slowest tasks matched filter.
+func (s *Scheduler) Dashboard(ctx context.Context, filter DashboardFilter) (*Dashboard, error) {
+	if err := auth.CheckBizType(ctx, filter.BizType); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if filter.Since.IsZero() {
+		filter.Since = now.Add(-time.Hour)
+	}
+	if filter.Bucket <= 0 {
+		filter.Bucket = 5 * time.Minute
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+
+	d := &Dashboard{StatusCounts: make(map[model.TaskStatus]int)}
+	start := filter.Since.Truncate(filter.Bucket)
+	for t := start; t.Before(now); t = t.Add(filter.Bucket) {
+		d.Throughput = append(d.Throughput, ThroughputBucket{Start: t})
+	}
+	bucket := func(t time.Time) *ThroughputBucket {
+		if t.Before(start) || !t.Before(now) {
+			return nil
+		}
+		return &d.Throughput[int(t.Sub(start)/filter.Bucket)]
+	}
+
+	workers := s.getAvailableWorkers()
+	loads := make(map[string]*WorkerLoad, len(workers))
+	for _, w := range workers {
+		usage := model.ParseResourceUsage(w.Metadata)
+		d.Workers = append(d.Workers, WorkerLoad{
+			WorkerID:   w.ID(),
+			CPUUsage:   usage[model.CpuUsageKey],
+			MemUsage:   usage[model.MemUsageKey],
+			Goroutines: usage[model.GoGoroutineKey],
+			Cordoned:   s.cordons.cordoned(w.ID()),
+			Labels:     model.ParseRuntimeLabels(w.Metadata),
+		})
+	}
+	for i := range d.Workers {
+		loads[d.Workers[i].WorkerID] = &d.Workers[i]
+	}
+
+	f := &model.TaskFilter{BizType: filter.BizType, Type: filter.Type}
+	truncated, err := s.scanTasks(ctx, f, func(task *model.Task) {
+		d.StatusCounts[task.Status]++
+		if load := loads[task.WorkerID]; load != nil && !task.Status.IsFinalStatus() {
+			load.Tasks++
+			if task.Status == model.TaskStatusRunning {
+				load.Running++
+			}
+		}
+		if b := bucket(task.CreatedAt); b != nil {
+			b.Created++
+		}
+		if task.FinishedAt != nil {
+			if b := bucket(*task.FinishedAt); b != nil {
+				switch task.Status {
+				case model.TaskStatusSuccess:
+					b.Succeeded++
+				case model.TaskStatusFailed:
+					b.Failed++
+				case model.TaskStatusStop:
+					b.Stopped++
+				}
+			}
+		}
+		if task.Status == model.TaskStatusFailed && task.FinishedAt != nil && !task.FinishedAt.Before(filter.Since) {
+			d.RecentFailures = append(d.RecentFailures, task)
+		}
+		if dur, ok := elapsed(task, now); ok && (task.FinishedAt == nil || !task.FinishedAt.Before(filter.Since)) {
+			d.Slowest = append(d.Slowest, SlowTask{Task: task, Duration: dur})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.Truncated = truncated
+
+	sort.Slice(d.RecentFailures, func(i, j int) bool {
+		return d.RecentFailures[i].FinishedAt.After(*d.RecentFailures[j].FinishedAt)
+	})
+	d.RecentFailures = d.RecentFailures[:min(len(d.RecentFailures), filter.Limit)]
+	sort.Slice(d.Slowest, func(i, j int) bool { return d.Slowest[i].Duration > d.Slowest[j].Duration })
+	d.Slowest = d.Slowest[:min(len(d.Slowest), filter.Limit)]
+	return d, nil
+}
+
+// scanTasks calls fn with tasks matched f page by page, it stops after
+// dashboardScanLimit tasks and returns true if more tasks matched.
+func (s *Scheduler) scanTasks(ctx context.Context, f *model.TaskFilter, fn func(task *model.Task)) (truncated bool, err error) {
+	f.Offset, f.Limit = 0, dashboardPageSize
+	for scanned := 0; ; {
+		tasks, err := s.taskRepo.ListTask(ctx, f)
+		if err != nil {
+			return false, err
+		}
+		for _, task := range tasks {
+			fn(task)
+		}
+
+		scanned += len(tasks)
+		if len(tasks) < f.Limit {
+			return false, nil
+		}
+		if scanned >= dashboardScanLimit {
+			return true, nil
+		}
+		f.Offset += len(tasks)
+	}
+}
+
+// elapsed returns run duration of finished task or time since started of running task.
+func elapsed(task *model.Task, now time.Time) (time.Duration, bool) {
+	if d, ok := task.RunDuration(); ok {
+		return d, true
+	}
+	if task.StartedAt != nil && task.Status == model.TaskStatusRunning {
+		return now.Sub(*task.StartedAt), true
+	}
+	return 0, false
+}
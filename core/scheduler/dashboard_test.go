@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type listRepo struct {
+	taskrepo.Interface
+	tasks []*model.Task
+}
+
+func (r *listRepo) ListTask(_ context.Context, filter *model.TaskFilter) ([]*model.Task, error) {
+	var ret []*model.Task
+	for _, t := range r.tasks {
+		if filter.Match(t) {
+			ret = append(ret, t)
+		}
+	}
+	ret = ret[min(filter.Offset, len(ret)):]
+	return ret[:min(filter.Limit, len(ret))], nil
+}
+
+func TestDashboard(t *testing.T) {
+	now := time.Now()
+	at := func(ago time.Duration) *time.Time {
+		t := now.Add(-ago)
+		return &t
+	}
+	repo := &listRepo{tasks: []*model.Task{
+		{TaskKey: "ok", Status: model.TaskStatusSuccess, CreatedAt: *at(50 * time.Minute), StartedAt: at(40 * time.Minute), FinishedAt: at(30 * time.Minute)},
+		{TaskKey: "old-fail", Status: model.TaskStatusFailed, Msg: "boom", StartedAt: at(3 * time.Hour), FinishedAt: at(2 * time.Hour)},
+		{TaskKey: "fail", Status: model.TaskStatusFailed, Msg: "oom", StartedAt: at(20 * time.Minute), FinishedAt: at(19 * time.Minute)},
+		{TaskKey: "run", Status: model.TaskStatusRunning, WorkerID: "w1", StartedAt: at(45 * time.Minute)},
+		{TaskKey: "wait", Status: model.TaskStatusWaitRunning, WorkerID: "w1"},
+	}}
+	s := &Scheduler{taskRepo: repo}
+	s.setAvailableWorkers([]discover.Instance{{InstanceId: "w1", Metadata: map[string]string{model.CpuUsageKey: "0.5"}}, {InstanceId: "w2"}})
+
+	d, err := s.Dashboard(context.Background(), DashboardFilter{Bucket: 30 * time.Minute, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("按状态计数", func(t *testing.T) {
+		if d.StatusCounts[model.TaskStatusFailed] != 2 || d.StatusCounts[model.TaskStatusRunning] != 1 {
+			t.Errorf("状态计数错误: %v", d.StatusCounts)
+		}
+	})
+
+	t.Run("worker 负载", func(t *testing.T) {
+		if len(d.Workers) != 2 || d.Workers[0].Tasks != 2 || d.Workers[0].Running != 1 || d.Workers[0].CPUUsage != 0.5 {
+			t.Errorf("worker 负载错误: %+v", d.Workers)
+		}
+	})
+
+	t.Run("吞吐按时间分桶", func(t *testing.T) {
+		var created, succeeded, failed int
+		for _, b := range d.Throughput {
+			created += b.Created
+			succeeded += b.Succeeded
+			failed += b.Failed
+		}
+		if created != 1 || succeeded != 1 || failed != 1 {
+			t.Errorf("期望 created=1 succeeded=1 failed=1, 得到 %d %d %d", created, succeeded, failed)
+		}
+	})
+
+	t.Run("只返回时间范围内的失败", func(t *testing.T) {
+		if len(d.RecentFailures) != 1 || d.RecentFailures[0].TaskKey != "fail" {
+			t.Errorf("最近失败错误: %v", d.RecentFailures)
+		}
+	})
+
+	t.Run("最慢任务包含运行中任务", func(t *testing.T) {
+		if len(d.Slowest) != 2 || d.Slowest[0].Task.TaskKey != "run" || d.Slowest[1].Task.TaskKey != "ok" {
+			t.Errorf("最慢任务错误: %+v", d.Slowest)
+		}
+	})
+}
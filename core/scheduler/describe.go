@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/attempt"
+	"github.com/xyzbit/minitaskx/core/components/audit"
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// TaskDescription aggregates everything known about a task, like kubectl describe.
+type TaskDescription struct {
+	// spec, want and real status and the current worker.
+	Task       *model.Task        `json:"task"`
+	Summary    attempt.Summary    `json:"summary"`
+	Conditions []Condition        `json:"conditions"`
+	Attempts   []*attempt.Attempt `json:"attempts"`
+	Events     []events.Event     `json:"events"`
+	Audits     []audit.Entry      `json:"audits"`
+	// error of the latest failed attempt, or message of the failed task.
+	LastError string `json:"last_error,omitempty"`
+}
+
+type ConditionType string
+
+const (
+	// task is assigned to a worker.
+	ConditionScheduled ConditionType = "Scheduled"
+	// executor of task is running.
+	ConditionRunning ConditionType = "Running"
+	// real status has reached want status.
+	ConditionSynced ConditionType = "Synced"
+	// task has not breached its SLA.
+	ConditionSLAMet ConditionType = "SLAMet"
+	// gates holding the first scheduling are open, only present for tasks having gates.
+	ConditionGatesOpen ConditionType = "GatesOpen"
+	// retry of the failed task waits for the retry budget of its biz type,
+	// only present for tasks held by the budget.
+	ConditionRetryBudgetExceeded ConditionType = "RetryBudgetExceeded"
+)
+
+// Condition is an aspect of task state derived from its fields.
+type Condition struct {
+	Type   ConditionType `json:"type"`
+	Status bool          `json:"status"`
+	Reason string        `json:"reason,omitempty"`
+	// when the condition became true, if known.
+	Since *time.Time `json:"since,omitempty"`
+}
+
+// DescribeTask returns the aggregated view of the task, attempts, events
+// and audits are empty if their repos are not configured.
+func (s *Scheduler) DescribeTask(ctx context.Context, taskKey string) (*TaskDescription, error) {
+	task, err := s.GetTask(ctx, taskKey)
+	if err != nil {
+		return nil, err
+	}
+	d := &TaskDescription{Task: task, Conditions: taskConditions(task)}
+	if len(task.Gates) > 0 {
+		d.Conditions = append(d.Conditions, s.gatesCondition(task))
+	}
+	if reason, ok := s.retryBudget.reason(task.TaskKey); ok && task.Status == model.TaskStatusFailed {
+		d.Conditions = append(d.Conditions, Condition{Type: ConditionRetryBudgetExceeded, Status: true, Reason: reason})
+	}
+	if s.opts.attemptRepo != nil {
+		if d.Attempts, err = s.opts.attemptRepo.List(ctx, taskKey); err != nil {
+			return nil, err
+		}
+	}
+	if s.opts.eventRecorder != nil {
+		if d.Events, err = s.opts.eventRecorder.List(ctx, taskKey); err != nil {
+			return nil, err
+		}
+	}
+	if s.opts.auditor != nil {
+		if d.Audits, err = s.opts.auditor.List(ctx, taskKey); err != nil {
+			return nil, err
+		}
+	}
+
+	var latest *attempt.Attempt
+	if len(d.Attempts) > 0 {
+		latest = d.Attempts[len(d.Attempts)-1]
+	}
+	d.Summary = attempt.Summarize(task, latest)
+	d.LastError = lastError(task, d.Attempts)
+	return d, nil
+}
+
+func taskConditions(task *model.Task) []Condition {
+	scheduled := Condition{Type: ConditionScheduled, Status: task.WorkerID != "", Since: task.AssignedAt}
+	if task.Status == model.TaskStatusUnschedulable {
+		scheduled.Reason = string(task.UnschedulableReason)
+	}
+
+	running := Condition{Type: ConditionRunning, Status: task.Status == model.TaskStatusRunning}
+	if running.Status {
+		running.Since = task.StartedAt
+	}
+
+	synced := Condition{Type: ConditionSynced, Status: task.Status == task.WantRunStatus}
+	if !synced.Status {
+		synced.Reason = "want " + task.WantRunStatus.String() + ", got " + task.Status.String()
+	}
+
+	slaMet := Condition{Type: ConditionSLAMet, Status: task.SLABreach == "", Reason: task.SLABreach}
+	return []Condition{scheduled, running, synced, slaMet}
+}
+
+func (s *Scheduler) gatesCondition(task *model.Task) Condition {
+	c := Condition{Type: ConditionGatesOpen, Status: task.AssignedAt != nil}
+	if c.Status {
+		return c
+	}
+	reason, ok := s.gates.peek(task.TaskKey)
+	if !ok {
+		reason = "gates are not evaluated by this scheduler"
+	}
+	c.Status, c.Reason = reason == "", reason
+	return c
+}
+
+func lastError(task *model.Task, attempts []*attempt.Attempt) string {
+	for i := len(attempts) - 1; i >= 0; i-- {
+		if attempts[i].Error != "" {
+			return attempts[i].Error
+		}
+	}
+	if task.Status == model.TaskStatusFailed || task.Status == model.TaskStatusUnschedulable {
+		return task.Msg
+	}
+	return ""
+}
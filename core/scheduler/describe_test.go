@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type getRepo struct {
+	listRepo
+}
+
+func (r *getRepo) GetTask(_ context.Context, taskKey string) (*model.Task, error) {
+	for _, t := range r.tasks {
+		if t.TaskKey == taskKey {
+			return t, nil
+		}
+	}
+	return nil, taskrepo.ErrTaskNotFound
+}
+
+func TestDescribeTask(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	recorder := events.NewMemory(time.Hour)
+	s := &Scheduler{
+		taskRepo: &getRepo{listRepo{tasks: []*model.Task{{
+			TaskKey:       "t1",
+			Status:        model.TaskStatusFailed,
+			WantRunStatus: model.TaskStatusRunning,
+			WorkerID:      "w1",
+			AssignedAt:    &now,
+			Msg:           "exit code 1",
+			SLABreach:     "run timeout",
+		}}}},
+		opts: newOptions(WithEventRecorder(recorder)),
+	}
+	_ = recorder.Record(ctx, events.Event{TaskKey: "t1", Reason: events.ReasonScheduled})
+
+	d, err := s.DescribeTask(ctx, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("聚合事件与最后错误", func(t *testing.T) {
+		if len(d.Events) != 1 || d.LastError != "exit code 1" || !d.Summary.PermanentFailure {
+			t.Errorf("聚合结果错误: %+v", d)
+		}
+	})
+
+	t.Run("推导状况", func(t *testing.T) {
+		want := map[ConditionType]bool{ConditionScheduled: true, ConditionRunning: false, ConditionSynced: false, ConditionSLAMet: false}
+		for _, c := range d.Conditions {
+			if c.Status != want[c.Type] {
+				t.Errorf("%s 期望 %t, 得到 %t", c.Type, want[c.Type], c.Status)
+			}
+		}
+	})
+}
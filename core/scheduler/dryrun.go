@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// Placement is the result of previewing where a task would be assigned.
+type Placement struct {
+	// empty if the task is unschedulable.
+	WorkerID   string            `json:"worker_id"`
+	Reason     string            `json:"reason"`
+	Candidates []WorkerPlacement `json:"candidates"`
+}
+
+// WorkerPlacement is the filter and score result of one worker.
+type WorkerPlacement struct {
+	WorkerID string  `json:"worker_id"`
+	Filtered bool    `json:"filtered"`
+	Reason   string  `json:"reason,omitempty"`
+	Score    float64 `json:"score,omitempty"`
+}
+
+// PreviewPlacement runs filter and priority of scheduling for task against
+// current available workers, without persisting anything or updating the
+// local resource estimate of workers. canary routing is random, the preview
+// is one sample of it.
+func (s *Scheduler) PreviewPlacement(task *model.Task) *Placement {
+	s.rwmu.RLock()
+	defer s.rwmu.RUnlock()
+
+	workers := s.getAvailableWorkers()
+	p := &Placement{Candidates: make([]WorkerPlacement, 0, len(workers))}
+	if len(workers) == 0 {
+		p.Reason = "没有可用的 worker 服务"
+		return p
+	}
+
+	candidateWorkers, filtered, unschedulable := s.filterCandidates(task, workers)
+	p.Candidates = append(p.Candidates, filtered...)
+	if unschedulable != nil {
+		p.Reason = unschedulable.reason
+		return p
+	}
+
+	scores := scoreWorkers(candidateWorkers)
+	for _, score := range scores {
+		p.Candidates = append(p.Candidates, WorkerPlacement{
+			WorkerID: candidateWorkers[score.index].ID(),
+			Score:    score.score,
+		})
+	}
+	best := scores[0]
+	p.WorkerID = candidateWorkers[best.index].ID()
+	switch {
+	case len(candidateWorkers) == 1:
+		p.Reason = "唯一可用的 worker"
+	case task.LocalityKey != "":
+		p.WorkerID = localityWorker(task.LocalityKey, candidateWorkers).ID()
+		p.Reason = fmt.Sprintf("局部性 key %s 一致性哈希到该 worker", task.LocalityKey)
+	case s.opts.placement == PlacementBinPack:
+		worker, reason := s.packWorker(task, candidateWorkers)
+		p.WorkerID, p.Reason = worker.ID(), reason
+	default:
+		p.Reason = fmt.Sprintf("资源得分最低(%.2f), 共 %d 个候选 worker", best.score, len(candidateWorkers))
+	}
+	return p
+}
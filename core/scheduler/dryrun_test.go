@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestPreviewPlacement(t *testing.T) {
+	tests := []struct {
+		name       string
+		workers    []discover.Instance
+		task       *model.Task
+		policies   []CanaryPolicy
+		wantWorker string
+		wantFilter int
+	}{
+		{
+			name:    "没有可用 worker",
+			workers: []discover.Instance{},
+			task:    &model.Task{},
+		},
+		{
+			name: "污点过滤后无候选",
+			workers: []discover.Instance{
+				{InstanceId: "1", Metadata: map[string]string{"stain_gpu": "true"}},
+			},
+			task:       &model.Task{},
+			wantFilter: 1,
+		},
+		{
+			name: "过滤污点后选择资源得分最低的 worker",
+			workers: []discover.Instance{
+				{InstanceId: "1", Metadata: map[string]string{"stain_gpu": "true"}},
+				{InstanceId: "2", Metadata: map[string]string{model.CpuUsageKey: "32", model.MemUsageKey: "90"}},
+				{InstanceId: "3", Metadata: map[string]string{model.CpuUsageKey: "16", model.MemUsageKey: "80"}},
+			},
+			task:       &model.Task{},
+			wantWorker: "3",
+			wantFilter: 1,
+		},
+		{
+			name: "按灰度策略分流",
+			workers: []discover.Instance{
+				{InstanceId: "1", Metadata: map[string]string{model.ExecutorVersionKey("email"): "v1"}},
+				{InstanceId: "2", Metadata: map[string]string{model.ExecutorVersionKey("email"): "v2"}},
+			},
+			task:       &model.Task{TaskKey: "t1", Type: "email"},
+			policies:   []CanaryPolicy{{TaskType: "email", Version: "v2", Percent: 100}},
+			wantWorker: "2",
+			wantFilter: 1,
+		},
+		{
+			name: "按标签中的命名空间选择专属 worker 池",
+			workers: []discover.Instance{
+				{InstanceId: "1", Metadata: map[string]string{model.CpuUsageKey: "10", model.MemUsageKey: "10", model.NamespacesKey: "ads"}},
+				{InstanceId: "2", Metadata: map[string]string{model.CpuUsageKey: "90", model.MemUsageKey: "90", model.NamespacesKey: "billing"}},
+			},
+			task:       &model.Task{Labels: map[string]string{model.LabelNamespace: "billing"}},
+			wantWorker: "2",
+			wantFilter: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scheduler{opts: newOptions(), canaries: newCanaries(tt.policies), colocation: newColocation()}
+			s.setAvailableWorkers(tt.workers)
+
+			p := s.PreviewPlacement(tt.task)
+			if p.WorkerID != tt.wantWorker {
+				t.Errorf("PreviewPlacement() worker = %v, want %v", p.WorkerID, tt.wantWorker)
+			}
+			// preview runs the same filters as assigning.
+			if _, _, err := s.filterCandidates(tt.task, tt.workers); len(tt.workers) > 0 && (err == nil) != (p.WorkerID != "") {
+				t.Errorf("PreviewPlacement() = %v, filters of assigning err = %v", p.WorkerID, err)
+			}
+			filtered := 0
+			for _, c := range p.Candidates {
+				if c.Filtered {
+					filtered++
+				}
+			}
+			if filtered != tt.wantFilter {
+				t.Errorf("PreviewPlacement() filtered = %v, want %v", filtered, tt.wantFilter)
+			}
+			// preview must not change the resource estimate of workers.
+			for i, w := range s.getAvailableWorkers() {
+				if w.Metadata[model.CpuUsageKey] != tt.workers[i].Metadata[model.CpuUsageKey] {
+					t.Errorf("PreviewPlacement() changed worker %s metadata", w.ID())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package scheduler
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// estimateWindow is the number of latest succeeded runs estimates are computed from.
+const estimateWindow = 200
+
+// DurationEstimate is run duration statistics of the latest succeeded tasks
+// of a task type and biz type, empty biz type is all biz types of the type.
+type DurationEstimate struct {
+	Type    string        `json:"type"`
+	BizType string        `json:"biz_type"`
+	Samples int           `json:"samples"`
+	P50     time.Duration `json:"p50"`
+	P95     time.Duration `json:"p95"`
+}
+
+// Estimates are run durations estimated from history, they are used by
+// PlacementBinPack and SLA defaults, see WithEstimatedSLA.
+type Estimates struct {
+	UpdatedAt time.Time          `json:"updated_at"`
+	Items     []DurationEstimate `json:"items"`
+	// more tasks than dashboardScanLimit succeeded, estimates are partial.
+	Truncated bool `json:"truncated"`
+}
+
+type estimateKey struct{ taskType, bizType string }
+
+// lookup returns the estimate of task type and biz type, or of the task type
+// if its biz type has no history.
+func (e *Estimates) lookup(taskType, bizType string) (DurationEstimate, bool) {
+	var byType DurationEstimate
+	found := false
+	for _, item := range e.Items {
+		if item.Type != taskType {
+			continue
+		}
+		if item.BizType == bizType {
+			return item, true
+		}
+		if item.BizType == "" {
+			byType, found = item, true
+		}
+	}
+	return byType, found
+}
+
+// Estimates returns estimates of taskType and bizType, empty means all. estimates
+// are cached by the estimate controller and recomputed if older than estimate interval.
+func (s *Scheduler) Estimates(ctx context.Context, taskType, bizType string) (*Estimates, error) {
+	if err := auth.CheckBizType(ctx, bizType); err != nil {
+		return nil, err
+	}
+	estimates := s.estimates.Load()
+	if estimates == nil || time.Since(estimates.UpdatedAt) > s.opts.estimateInterval {
+		var err error
+		if estimates, err = s.refreshEstimates(ctx, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	if taskType == "" && bizType == "" {
+		return estimates, nil
+	}
+
+	filtered := *estimates
+	filtered.Items = nil
+	for _, item := range estimates.Items {
+		if (taskType == "" || item.Type == taskType) && (bizType == "" || item.BizType == bizType) {
+			filtered.Items = append(filtered.Items, item)
+		}
+	}
+	return &filtered, nil
+}
+
+// runEstimateController estimates run durations periodically, only leader works.
+func (s *Scheduler) runEstimateController() {
+	ticker := time.NewTicker(s.opts.estimateInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		amILeader, _, err := s.amILeader()
+		if err != nil {
+			log.Error("[Estimate] 获取 leader 状态失败: %v", err)
+			continue
+		}
+		if !amILeader {
+			continue
+		}
+		if _, err := s.refreshEstimates(context.Background(), time.Now()); err != nil {
+			log.Error("[Estimate] 统计任务运行时长失败: %v", err)
+		}
+	}
+}
+
+// refreshEstimates computes estimates from the latest estimateWindow
+// succeeded runs of each task type and biz type.
+func (s *Scheduler) refreshEstimates(ctx context.Context, now time.Time) (*Estimates, error) {
+	runs := make(map[estimateKey][]*model.Task)
+	truncated, err := s.scanTasks(ctx, &model.TaskFilter{Statuses: []model.TaskStatus{model.TaskStatusSuccess}}, func(task *model.Task) {
+		if _, ok := task.RunDuration(); !ok {
+			return
+		}
+		for _, key := range []estimateKey{{task.Type, task.BizType}, {task.Type, ""}} {
+			runs[key] = append(runs[key], task)
+		}
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	estimates := &Estimates{UpdatedAt: now, Truncated: truncated}
+	for key, tasks := range runs {
+		// latest runs first.
+		slices.SortFunc(tasks, func(a, b *model.Task) int { return b.FinishedAt.Compare(*a.FinishedAt) })
+		tasks = tasks[:min(len(tasks), estimateWindow)]
+		ds := make([]time.Duration, 0, len(tasks))
+		for _, task := range tasks {
+			d, _ := task.RunDuration()
+			ds = append(ds, d)
+		}
+		slices.Sort(ds)
+		at := func(p float64) time.Duration { return ds[int(float64(len(ds)-1)*p)] }
+		estimates.Items = append(estimates.Items, DurationEstimate{
+			Type:    key.taskType,
+			BizType: key.bizType,
+			Samples: len(ds),
+			P50:     at(0.5),
+			P95:     at(0.95),
+		})
+	}
+	slices.SortFunc(estimates.Items, func(a, b DurationEstimate) int {
+		return cmp.Or(cmp.Compare(a.Type, b.Type), cmp.Compare(a.BizType, b.BizType))
+	})
+
+	s.estimates.Store(estimates)
+	return estimates, nil
+}
+
+// estimatedDuration returns the median run duration of tasks like task, zero
+// if there is no history.
+func (s *Scheduler) estimatedDuration(task *model.Task) time.Duration {
+	estimates := s.estimates.Load()
+	if estimates == nil {
+		return 0
+	}
+	e, _ := estimates.lookup(task.Type, task.BizType)
+	return e.P50
+}
+
+// estimatedSLA returns the SLA of task not declaring one, max run time is
+// a multiple of the p95 run duration of tasks like it, nil if disabled or
+// history is too short.
+func (s *Scheduler) estimatedSLA(task *model.Task) *model.SLAPolicy {
+	estimates := s.estimates.Load()
+	if s.opts.estimatedSLAFactor <= 0 || estimates == nil {
+		return nil
+	}
+	e, ok := estimates.lookup(task.Type, task.BizType)
+	if !ok || e.Samples < s.opts.estimatedSLAMinSamples || e.P95 <= 0 {
+		return nil
+	}
+	maxRun := time.Duration(float64(e.P95) * s.opts.estimatedSLAFactor)
+	return &model.SLAPolicy{MaxRunSeconds: max(int64(maxRun.Seconds()), 1)}
+}
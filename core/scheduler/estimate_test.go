@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestEstimates(t *testing.T) {
+	now := time.Now()
+	run := func(key, taskType, bizType string, d time.Duration, status model.TaskStatus) *model.Task {
+		started, finished := now.Add(-time.Hour), now.Add(-time.Hour+d)
+		return &model.Task{TaskKey: key, Type: taskType, BizType: bizType, Status: status, StartedAt: &started, FinishedAt: &finished}
+	}
+	var tasks []*model.Task
+	for i := 1; i <= 20; i++ {
+		tasks = append(tasks, run(fmt.Sprintf("a%d", i), "etl", "a", time.Duration(i)*time.Minute, model.TaskStatusSuccess))
+	}
+	tasks = append(tasks,
+		run("b1", "etl", "b", time.Hour, model.TaskStatusSuccess),
+		run("b2", "etl", "b", 10*time.Hour, model.TaskStatusFailed),
+	)
+	s := &Scheduler{taskRepo: &listRepo{tasks: tasks}, opts: newOptions(WithEstimatedSLA(2, 10))}
+	if _, err := s.refreshEstimates(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("按任务类型和业务类型统计成功的运行", func(t *testing.T) {
+		got, err := s.Estimates(context.Background(), "etl", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []DurationEstimate{
+			{Type: "etl", BizType: "", Samples: 21, P50: 11 * time.Minute, P95: 20 * time.Minute},
+			{Type: "etl", BizType: "a", Samples: 20, P50: 10 * time.Minute, P95: 19 * time.Minute},
+			{Type: "etl", BizType: "b", Samples: 1, P50: time.Hour, P95: time.Hour},
+		}
+		if len(got.Items) != len(want) {
+			t.Fatalf("期望 %d 项, 得到 %+v", len(want), got.Items)
+		}
+		for i := range want {
+			if got.Items[i] != want[i] {
+				t.Errorf("得到 %+v, 期望 %+v", got.Items[i], want[i])
+			}
+		}
+	})
+
+	t.Run("没有历史的业务类型按任务类型估计", func(t *testing.T) {
+		if d := s.estimatedDuration(&model.Task{Type: "etl", BizType: "c"}); d != 11*time.Minute {
+			t.Fatalf("期望 11m, 得到 %s", d)
+		}
+		if d := s.estimatedDuration(&model.Task{Type: "other"}); d != 0 {
+			t.Fatalf("期望没有估计, 得到 %s", d)
+		}
+	})
+
+	t.Run("样本足够时提供默认 SLA", func(t *testing.T) {
+		sla := s.estimatedSLA(&model.Task{Type: "etl", BizType: "a"})
+		if sla == nil || sla.MaxRunSeconds != int64((38*time.Minute).Seconds()) {
+			t.Fatalf("期望最长运行 38m, 得到 %+v", sla)
+		}
+		if sla := s.estimatedSLA(&model.Task{Type: "etl", BizType: "b"}); sla != nil {
+			t.Fatalf("样本不足时不应提供 SLA, 得到 %+v", sla)
+		}
+	})
+}
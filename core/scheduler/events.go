@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/components/log"
+)
+
+const eventSource = "scheduler"
+
+// event emits e of the task, failure will not break the operation.
+func (s *Scheduler) event(ctx context.Context, e events.Event) {
+	if s.opts.eventRecorder == nil {
+		return
+	}
+	e.Source = eventSource
+	if e.Type == "" {
+		e.Type = events.TypeNormal
+	}
+	if err := s.opts.eventRecorder.Record(ctx, e); err != nil {
+		log.Error("[Event] record %s of task %s failed: %v", e.Reason, e.TaskKey, err)
+	}
+}
+
+// ListEvents returns unexpired events of the task.
+func (s *Scheduler) ListEvents(ctx context.Context, taskKey string) ([]events.Event, error) {
+	if s.opts.eventRecorder == nil {
+		return nil, nil
+	}
+	task, err := s.taskRepo.GetTask(ctx, taskKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.CheckBizType(ctx, task.BizType); err != nil {
+		return nil, err
+	}
+	return s.opts.eventRecorder.List(ctx, taskKey)
+}
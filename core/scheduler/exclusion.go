@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func exclusionLockKey(group string) string {
+	return "minitaskx/exclusion/" + group
+}
+
+// acquireExclusion reports whether task may be assigned regarding its exclusion group.
+// the group is held by a task from assignment until it's no longer runnable,
+// a lock left by a finished task is taken over lazily.
+func (s *Scheduler) acquireExclusion(ctx context.Context, task *model.Task, stats *runnableStats) bool {
+	group := task.ExclusionGroup()
+	if group == "" {
+		return true
+	}
+	// the lock may be lost, eg. lock.Memory after leader changes.
+	if holder, ok := stats.exclusions[group]; ok && holder != task.TaskKey {
+		log.Debug("任务[%s]等待互斥组[%s], 被任务[%s]占用", task.TaskKey, group, holder)
+		return false
+	}
+
+	key := exclusionLockKey(group)
+	owner, err := s.opts.locker.Owner(ctx, key)
+	if err != nil {
+		log.Error("获取互斥组[%s]持有者失败: %v", group, err)
+		return false
+	}
+	if owner != "" && owner != task.TaskKey {
+		if stats.runnable[owner] {
+			log.Debug("任务[%s]等待互斥组[%s], 被任务[%s]占用", task.TaskKey, group, owner)
+			return false
+		}
+		if err := s.opts.locker.Unlock(ctx, key, owner); err != nil {
+			log.Error("释放互斥组[%s]失败: %v", group, err)
+			return false
+		}
+	}
+
+	acquired, err := s.opts.locker.TryLock(ctx, key, task.TaskKey)
+	if err != nil {
+		log.Error("获取互斥组[%s]失败: %v", group, err)
+		return false
+	}
+	if acquired {
+		// other tasks of the group in this pass see it held.
+		stats.exclusions[group] = task.TaskKey
+	}
+	return acquired
+}
+
+// releaseExclusion releases the exclusion group held by task which is not assigned.
+func (s *Scheduler) releaseExclusion(ctx context.Context, task *model.Task) {
+	group := task.ExclusionGroup()
+	if group == "" {
+		return
+	}
+	if err := s.opts.locker.Unlock(ctx, exclusionLockKey(group), task.TaskKey); err != nil {
+		log.Error("释放互斥组[%s]失败: %v", group, err)
+	}
+}
@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/lock"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestAcquireExclusion(t *testing.T) {
+	ctx := context.Background()
+	s := &Scheduler{opts: &options{locker: lock.NewMemory()}}
+	task := func(key string) *model.Task {
+		return &model.Task{TaskKey: key, Labels: map[string]string{model.LabelExclusionGroup: "users-table"}}
+	}
+	newStats := func(runnable ...string) *runnableStats {
+		stats := &runnableStats{exclusions: make(map[string]string), runnable: make(map[string]bool)}
+		for _, key := range runnable {
+			stats.runnable[key] = true
+		}
+		return stats
+	}
+
+	t.Run("无互斥组不受限制", func(t *testing.T) {
+		if !s.acquireExclusion(ctx, &model.Task{TaskKey: "free"}, newStats()) {
+			t.Fatal("期望允许分配")
+		}
+	})
+
+	t.Run("同组任务不能同时分配", func(t *testing.T) {
+		stats := newStats("m1", "m2")
+		if !s.acquireExclusion(ctx, task("m1"), stats) {
+			t.Fatal("m1 应获得互斥组")
+		}
+		if s.acquireExclusion(ctx, task("m2"), stats) {
+			t.Fatal("m2 不应获得互斥组")
+		}
+	})
+
+	t.Run("持有者仍可运行时继续等待", func(t *testing.T) {
+		if s.acquireExclusion(ctx, task("m2"), newStats("m1", "m2")) {
+			t.Fatal("m1 未结束, m2 不应获得互斥组")
+		}
+	})
+
+	t.Run("持有者结束后接管锁", func(t *testing.T) {
+		if !s.acquireExclusion(ctx, task("m2"), newStats("m2")) {
+			t.Fatal("m1 已结束, m2 应获得互斥组")
+		}
+	})
+
+	t.Run("未分配时释放", func(t *testing.T) {
+		s.releaseExclusion(ctx, task("m2"))
+		if !s.acquireExclusion(ctx, task("m3"), newStats("m2", "m3")) {
+			t.Fatal("m2 已释放, m3 应获得互斥组")
+		}
+	})
+}
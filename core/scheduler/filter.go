@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// placementFilter is one step narrowing candidate workers of a task.
+type placementFilter struct {
+	// route returns workers the task may be placed on.
+	route func(task *model.Task, workers []discover.Instance) []discover.Instance
+	// reason explains why worker is filtered out by route.
+	reason func(task *model.Task, worker discover.Instance) string
+	// unschedulable explains why no worker is left, workers are the input of route.
+	unschedulable func(task *model.Task, workers []discover.Instance) *unschedulableError
+}
+
+// placementFilters are steps of filtering workers in order, shared by
+// assigning and previewing.
+func (s *Scheduler) placementFilters() []placementFilter {
+	return []placementFilter{
+		// cordon 的 worker 不再分配新任务
+		{
+			route:  func(_ *model.Task, workers []discover.Instance) []discover.Instance { return s.cordons.route(workers) },
+			reason: func(*model.Task, discover.Instance) string { return "worker 已 cordon" },
+			unschedulable: func(*model.Task, []discover.Instance) *unschedulableError {
+				return &unschedulableError{code: model.UnschedulableNoCapacity, reason: "可用的 worker 均已 cordon"}
+			},
+		},
+		// 执行器、污点、亲和性
+		{route: filterWorker, reason: filterReason, unschedulable: classifyUnschedulable},
+		// 命名空间专属 worker 池
+		{
+			route:  s.routePool,
+			reason: s.poolReason,
+			unschedulable: func(task *model.Task, _ []discover.Instance) *unschedulableError {
+				return &unschedulableError{
+					code:   model.UnschedulableNoMatchingLabels,
+					reason: fmt.Sprintf("命名空间 %s 的 worker 池没有可用的 worker", task.Namespace()),
+				}
+			},
+		},
+		// canary 按执行器版本分流
+		{
+			route: s.canaries.route,
+			reason: func(task *model.Task, _ discover.Instance) string {
+				return fmt.Sprintf("任务类型 %s 灰度分流到其他执行器版本", task.Type)
+			},
+			unschedulable: func(task *model.Task, _ []discover.Instance) *unschedulableError {
+				return &unschedulableError{
+					code:   model.UnschedulableNoExecutor,
+					reason: fmt.Sprintf("任务类型 %s 灰度已回滚, 没有运行稳定版本的 worker", task.Type),
+				}
+			},
+		},
+		// 任务间亲和与反亲和
+		{
+			route:  s.colocation.route,
+			reason: s.colocation.reason,
+			unschedulable: func(*model.Task, []discover.Instance) *unschedulableError {
+				return &unschedulableError{code: model.UnschedulableNoMatchingLabels, reason: "没有满足任务亲和性的 worker"}
+			},
+		},
+	}
+}
+
+// filterCandidates runs placement filters over workers, it returns workers
+// left and workers filtered out with reasons, or why no worker is left.
+func (s *Scheduler) filterCandidates(task *model.Task, workers []discover.Instance) ([]discover.Instance, []WorkerPlacement, *unschedulableError) {
+	var filtered []WorkerPlacement
+	for _, f := range s.placementFilters() {
+		routed := f.route(task, workers)
+		kept := make(map[string]bool, len(routed))
+		for _, w := range routed {
+			kept[w.ID()] = true
+		}
+		for _, w := range workers {
+			if !kept[w.ID()] {
+				filtered = append(filtered, WorkerPlacement{WorkerID: w.ID(), Filtered: true, Reason: f.reason(task, w)})
+			}
+		}
+		if len(routed) == 0 {
+			return nil, filtered, f.unschedulable(task, workers)
+		}
+		workers = routed
+	}
+	return workers, filtered, nil
+}
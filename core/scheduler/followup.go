@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// max finished tasks followed up in one pass.
+const followUpBatchSize = 500
+
+// runFollowUpController creates follow-ups of finished tasks, only leader works.
+func (s *Scheduler) runFollowUpController() {
+	ticker := time.NewTicker(s.opts.followUpCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		amILeader, _, err := s.amILeader()
+		if err != nil {
+			log.Error("[FollowUp] 获取 leader 状态失败: %v", err)
+			continue
+		}
+		if !amILeader {
+			continue
+		}
+		if err := s.createFollowUps(context.Background()); err != nil {
+			log.Error("[FollowUp] 创建后续任务失败: %v", err)
+		}
+	}
+}
+
+func (s *Scheduler) createFollowUps(ctx context.Context) error {
+	tasks, err := s.taskRepo.ListTask(ctx, &model.TaskFilter{
+		Labels:   map[string]string{model.LabelFollowUp: model.FollowUpPending},
+		Statuses: []model.TaskStatus{model.TaskStatusSuccess, model.TaskStatusFailed},
+		Limit:    followUpBatchSize,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, task := range tasks {
+		// failed tasks are followed up once retries are exhausted.
+		if task.IsDeleted() || task.CanRetry() {
+			continue
+		}
+		if err := s.followUp(ctx, task); err != nil {
+			log.Error("[FollowUp] 任务[%s]创建后续任务失败: %v", task.TaskKey, err)
+		}
+	}
+	return nil
+}
+
+// needFollowUp reports whether the follow-up controller handles task once it
+// finishes, steps of chains and compensations are handled to drive sagas.
+func needFollowUp(task *model.Task) bool {
+	return len(task.FollowUps) > 0 || task.Labels[model.LabelParent] != "" || task.Labels[model.LabelCompensates] != ""
+}
+
+// followUp creates follow-ups and compensations of task then marks it done,
+// tasks created before a failure are kept since their keys are derived from task.
+func (s *Scheduler) followUp(ctx context.Context, task *model.Task) error {
+	for i, f := range task.FollowUps {
+		if f.On != task.Status {
+			continue
+		}
+		child, err := task.NewFollowUp(i)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		child.NextRunAt = &now
+		child.TaskKey = followUpKey(task.TaskKey, i)
+		err = s.insertTask(ctx, child)
+		if errors.Is(err, taskrepo.ErrDuplicateTask) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		log.Info("[FollowUp] 任务[%s]%s, 创建后续任务[%s]", task.TaskKey, task.Status, child.TaskKey)
+	}
+	if err := s.continueSaga(ctx, task); err != nil {
+		return err
+	}
+
+	labels := withLabel(task, model.LabelFollowUp, model.FollowUpDone)
+	return errors.WithStack(s.taskRepo.UpdateTask(ctx, &model.Task{TaskKey: task.TaskKey, Labels: labels}))
+}
+
+// followUpKey derives the key of the index-th follow-up of parent, so that
+// a follow-up is never created twice.
+func followUpKey(parent string, index int) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(parent+"/follow-up/"+strconv.Itoa(index))).String()
+}
@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// createRepo creates tasks in memory.
+type createRepo struct {
+	statusRepo
+}
+
+func (r *createRepo) CreateTask(_ context.Context, task *model.Task) error {
+	for _, t := range r.tasks {
+		if t.TaskKey == task.TaskKey {
+			return taskrepo.ErrDuplicateTask
+		}
+	}
+	r.tasks = append(r.tasks, task)
+	return nil
+}
+
+func TestFollowUps(t *testing.T) {
+	followUps := []model.FollowUp{
+		{On: model.TaskStatusSuccess, Type: "report", Payload: `{"from": "{{.TaskKey}}", "result": {{printf "%q" .Msg}}}`,
+			FollowUps: []model.FollowUp{{On: model.TaskStatusSuccess, Type: "notify"}}},
+		{On: model.TaskStatusFailed, Type: "cleanup", BizType: "ops"},
+	}
+
+	t.Run("校验后续任务", func(t *testing.T) {
+		if err := model.ValidateFollowUps([]model.FollowUp{{On: model.TaskStatusStop, Type: "x"}}); err == nil {
+			t.Error("on 只能为 success 或 failed")
+		}
+		if err := model.ValidateFollowUps([]model.FollowUp{{On: model.TaskStatusSuccess, Type: "x", Payload: "{{"}}); err == nil {
+			t.Error("非法模板应校验失败")
+		}
+	})
+
+	repo := &createRepo{}
+	s := &Scheduler{taskRepo: repo, opts: newOptions()}
+	parent := &model.Task{BizID: "b1", BizType: "etl", Type: "extract", FollowUps: followUps}
+	if err := s.createTask(context.Background(), parent); err != nil {
+		t.Fatal(err)
+	}
+	if parent.Labels[model.LabelFollowUp] != model.FollowUpPending {
+		t.Fatalf("声明后续任务的任务应标记 pending: %v", parent.Labels)
+	}
+
+	t.Run("成功后创建成功分支", func(t *testing.T) {
+		parent.Status, parent.Msg = model.TaskStatusSuccess, `rows=3 "ok"`
+		for range 2 {
+			if err := s.createFollowUps(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(repo.tasks) != 2 {
+			t.Fatalf("应只创建一个后续任务: %d", len(repo.tasks))
+		}
+		child := repo.tasks[1]
+		if child.Type != "report" || child.BizType != "etl" || child.BizID != "b1" || child.Labels[model.LabelParent] != parent.TaskKey {
+			t.Errorf("后续任务错误: %+v", child)
+		}
+		if want := `{"from": "` + parent.TaskKey + `", "result": "rows=3 \"ok\""}`; child.Payload != want {
+			t.Errorf("payload 渲染错误: %s", child.Payload)
+		}
+		if child.Labels[model.LabelFollowUp] != model.FollowUpPending || len(child.FollowUps) != 1 {
+			t.Errorf("后续任务应继续链式创建: %+v", child)
+		}
+		if parent.Labels[model.LabelFollowUp] != model.FollowUpDone {
+			t.Errorf("父任务应标记 done: %v", parent.Labels)
+		}
+	})
+
+	t.Run("重试中的失败任务不创建", func(t *testing.T) {
+		task := &model.Task{TaskKey: "f", Status: model.TaskStatusFailed, Retry: &model.RetryPolicy{MaxRetries: 1},
+			Labels: map[string]string{model.LabelFollowUp: model.FollowUpPending}, FollowUps: followUps}
+		repo.tasks = append(repo.tasks, task)
+		if err := s.createFollowUps(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.tasks) != 3 {
+			t.Fatalf("重试中的任务不应创建后续任务: %d", len(repo.tasks))
+		}
+
+		task.Retries = 1
+		if err := s.createFollowUps(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.tasks) != 4 || repo.tasks[3].Type != "cleanup" || repo.tasks[3].BizType != "ops" {
+			t.Errorf("重试耗尽后应创建失败分支: %+v", repo.tasks[len(repo.tasks)-1])
+		}
+	})
+}
+
+func TestSaga(t *testing.T) {
+	step := func(key, parent string, status model.TaskStatus, c *model.Compensation) *model.Task {
+		labels := map[string]string{model.LabelFollowUp: model.FollowUpDone}
+		if parent != "" {
+			labels[model.LabelParent] = parent
+		}
+		return &model.Task{TaskKey: key, BizID: "order-1", Status: status, Labels: labels, Compensation: c}
+	}
+	failed := step("s4", "s3", model.TaskStatusFailed, nil)
+	failed.Labels[model.LabelFollowUp] = model.FollowUpPending
+	repo := &createRepo{statusRepo{getRepo{listRepo{tasks: []*model.Task{
+		step("s1", "", model.TaskStatusSuccess, &model.Compensation{Type: "refund", Payload: "{{.BizID}}"}),
+		step("s2", "s1", model.TaskStatusSuccess, nil),
+		step("s3", "s2", model.TaskStatusSuccess, &model.Compensation{Type: "release"}),
+		failed,
+	}}}}}
+	s := &Scheduler{taskRepo: repo, opts: newOptions()}
+
+	// finish runs the follow-up controller and succeeds the compensation created.
+	finish := func() *model.Task {
+		n := len(repo.tasks)
+		if err := s.createFollowUps(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.tasks) == n {
+			return nil
+		}
+		c := repo.tasks[len(repo.tasks)-1]
+		c.Status = model.TaskStatusSuccess
+		return c
+	}
+
+	t.Run("按逆序补偿成功的步骤", func(t *testing.T) {
+		if c := finish(); c == nil || c.Type != "release" || c.Labels[model.LabelCompensates] != "s3" {
+			t.Fatalf("应先补偿 s3: %+v", c)
+		}
+		if c := finish(); c == nil || c.Type != "refund" || c.Payload != "order-1" {
+			t.Fatalf("应跳过无补偿的 s2 并补偿 s1: %+v", c)
+		}
+		if c := finish(); c != nil {
+			t.Errorf("补偿已完成, 不应再创建任务: %+v", c)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// groupGangs separates gangs to be placed as a whole from tasks placed one by one.
+// members of a gang having members on available workers are placed one by one
+// to repair the gang, members of a gang not fully created are held.
+func groupGangs(tasks []*model.Task, assignedGangs map[string]int) (singles []*model.Task, gangs [][]*model.Task) {
+	members := make(map[string][]*model.Task)
+	for _, task := range tasks {
+		if !task.InGang() || assignedGangs[task.Gang] > 0 {
+			singles = append(singles, task)
+			continue
+		}
+		members[task.Gang] = append(members[task.Gang], task)
+	}
+
+	for gang, ms := range members {
+		if len(ms) < ms[0].GangSize {
+			log.Debug("任务组[%s]已创建 %d/%d 个任务, 等待全部创建后分配", gang, len(ms), ms[0].GangSize)
+			continue
+		}
+		slices.SortFunc(ms, func(a, b *model.Task) int { return strings.Compare(a.TaskKey, b.TaskKey) })
+		gangs = append(gangs, ms)
+	}
+	slices.SortFunc(gangs, func(a, b []*model.Task) int { return strings.Compare(a[0].Gang, b[0].Gang) })
+	return singles, gangs
+}
+
+// assignGang places all members of a gang, or marks all of them unschedulable
+// if any member can't be placed, so that no member starts alone. exclusion
+// groups of all members are held before any member is assigned.
+func (s *Scheduler) assignGang(ctx context.Context, members []*model.Task, quota *quotaTracker, stats *runnableStats) error {
+	gang := members[0].Gang
+	for _, m := range members {
+		if reason := s.overBudget(m); reason != "" {
+			return s.markGangUnschedulable(ctx, members, &unschedulableError{
+				code:   model.UnschedulableBudgetExceeded,
+				reason: fmt.Sprintf("任务组[%s]任务[%s]: %s", gang, m.TaskKey, reason),
+			})
+		}
+	}
+	if a, b, ok := sharedExclusion(members); ok {
+		return s.markGangUnschedulable(ctx, members, &unschedulableError{
+			code:   model.UnschedulableExclusionConflict,
+			reason: fmt.Sprintf("任务组[%s]任务[%s]和[%s]属于同一互斥组 %s, 无法同时运行", gang, a.TaskKey, b.TaskKey, a.ExclusionGroup()),
+		})
+	}
+
+	taken := 0
+	releaseQuota := func() {
+		for _, m := range members[:taken] {
+			quota.release(m.BizType)
+		}
+	}
+	for _, m := range members {
+		if !quota.take(m.BizType) {
+			releaseQuota()
+			return s.markGangUnschedulable(ctx, members, &unschedulableError{
+				code:   model.UnschedulableQuotaExceeded,
+				reason: fmt.Sprintf("任务组[%s]任务[%s]: 业务类型 %s 运行中任务数已达配额 %d", gang, m.TaskKey, m.BizType, s.opts.quotas[m.BizType]),
+			})
+		}
+		taken++
+	}
+
+	acquired := 0
+	releaseExclusions := func() {
+		for _, m := range members[:acquired] {
+			s.releaseGangExclusion(ctx, m, stats)
+		}
+	}
+	for _, m := range members {
+		if !s.acquireExclusion(ctx, m, stats) {
+			releaseExclusions()
+			releaseQuota()
+			log.Debug("任务组[%s]任务[%s]等待互斥组, 整组暂不分配", gang, m.TaskKey)
+			return nil
+		}
+		acquired++
+	}
+
+	workerIDs := make([]string, 0, len(members))
+	for _, m := range members {
+		workerID, err := s.selectWorkerID(m)
+		var unschedulable *unschedulableError
+		if errors.As(err, &unschedulable) {
+			releaseExclusions()
+			releaseQuota()
+			return s.markGangUnschedulable(ctx, members, &unschedulableError{
+				code:   unschedulable.code,
+				reason: fmt.Sprintf("任务组[%s]任务[%s]: %s", gang, m.TaskKey, unschedulable.reason),
+			})
+		}
+		if err != nil {
+			releaseExclusions()
+			releaseQuota()
+			return err
+		}
+		workerIDs = append(workerIDs, workerID)
+	}
+
+	// members failed to commit are placed one by one in following passes.
+	for i, m := range members {
+		if err := s.commitAssignment(ctx, m, workerIDs[i]); err != nil {
+			quota.release(m.BizType)
+			s.releaseGangExclusion(ctx, m, stats)
+			log.Error("任务组[%s]任务[%s]分配失败, err: %v", gang, m.TaskKey, err)
+		}
+	}
+	log.Info("任务组[%s] %d 个任务已分配", gang, len(members))
+	return nil
+}
+
+// sharedExclusion returns two members in the same exclusion group.
+func sharedExclusion(members []*model.Task) (*model.Task, *model.Task, bool) {
+	holders := make(map[string]*model.Task)
+	for _, m := range members {
+		group := m.ExclusionGroup()
+		if group == "" {
+			continue
+		}
+		if holder, ok := holders[group]; ok {
+			return holder, m, true
+		}
+		holders[group] = m
+	}
+	return nil, nil, false
+}
+
+// releaseGangExclusion releases the exclusion group held by a member not
+// assigned, other tasks of the pass may take it.
+func (s *Scheduler) releaseGangExclusion(ctx context.Context, m *model.Task, stats *runnableStats) {
+	s.releaseExclusion(ctx, m)
+	if group := m.ExclusionGroup(); group != "" && stats.exclusions[group] == m.TaskKey {
+		delete(stats.exclusions, group)
+	}
+}
+
+func (s *Scheduler) markGangUnschedulable(ctx context.Context, members []*model.Task, e *unschedulableError) error {
+	for _, m := range members {
+		if err := s.markUnschedulable(ctx, m, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
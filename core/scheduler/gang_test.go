@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestGroupGangs(t *testing.T) {
+	member := func(key, gang string, size int) *model.Task {
+		return &model.Task{TaskKey: key, Gang: gang, GangSize: size}
+	}
+	tasks := []*model.Task{
+		{TaskKey: "single"},
+		member("a-2", "a", 2),
+		member("a-1", "a", 2),
+		member("b-1", "b", 3),
+		member("c-2", "c", 2),
+	}
+
+	singles, gangs := groupGangs(tasks, map[string]int{"c": 1})
+
+	t.Run("已有成员运行的任务组逐个修复", func(t *testing.T) {
+		if len(singles) != 2 || singles[0].TaskKey != "single" || singles[1].TaskKey != "c-2" {
+			t.Fatalf("singles 错误: %v", singles)
+		}
+	})
+
+	t.Run("任务组完整才整体分配", func(t *testing.T) {
+		if len(gangs) != 1 || len(gangs[0]) != 2 {
+			t.Fatalf("期望只有任务组 a, 得到 %v", gangs)
+		}
+		if gangs[0][0].TaskKey != "a-1" || gangs[0][1].TaskKey != "a-2" {
+			t.Fatalf("成员应按 key 排序, 得到 %s, %s", gangs[0][0].TaskKey, gangs[0][1].TaskKey)
+		}
+	})
+}
+
+func TestAssignGangExclusion(t *testing.T) {
+	ctx := context.Background()
+	member := func(key, gang, group string) *model.Task {
+		task := &model.Task{TaskKey: key, Gang: gang, GangSize: 2, Status: model.TaskStatusWaitScheduling}
+		if group != "" {
+			task.Labels = map[string]string{model.LabelExclusionGroup: group}
+		}
+		return task
+	}
+	running := &model.Task{TaskKey: "holder", Labels: map[string]string{model.LabelExclusionGroup: "users-table"}}
+	a := []*model.Task{member("a-1", "a", ""), member("a-2", "a", "users-table")}
+	b := []*model.Task{member("b-1", "b", "orders-table"), member("b-2", "b", "orders-table")}
+	repo := &adminRepo{statusRepo{getRepo{listRepo{tasks: append(append([]*model.Task{running}, a...), b...)}}}}
+	s := &Scheduler{taskRepo: repo, opts: newOptions(), canaries: newCanaries(nil), colocation: newColocation()}
+	s.setAvailableWorkers([]discover.Instance{{InstanceId: "w1", Enable: true, Healthy: true}})
+	newStats := func() *runnableStats {
+		return &runnableStats{
+			bizTypes:   make(map[string]int),
+			exclusions: make(map[string]string),
+			runnable:   map[string]bool{"holder": true, "a-1": true, "a-2": true, "b-1": true, "b-2": true},
+		}
+	}
+	if !s.acquireExclusion(ctx, running, newStats()) {
+		t.Fatal("holder 应获得互斥组")
+	}
+
+	t.Run("成员的互斥组被占用时整组等待", func(t *testing.T) {
+		stats := newStats()
+		if err := s.assignGang(ctx, a, newQuotaTracker(nil, stats.bizTypes), stats); err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range a {
+			if m.WorkerID != "" {
+				t.Fatalf("期望整组不分配, %s 分配到 %s", m.TaskKey, m.WorkerID)
+			}
+		}
+		if stats.bizTypes[""] != 0 {
+			t.Fatalf("期望归还配额, 得到 %v", stats.bizTypes)
+		}
+	})
+
+	t.Run("互斥组释放后整组分配并持有", func(t *testing.T) {
+		stats := newStats()
+		delete(stats.runnable, "holder")
+		if err := s.assignGang(ctx, a, newQuotaTracker(nil, stats.bizTypes), stats); err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range a {
+			if m.WorkerID != "w1" {
+				t.Fatalf("期望整组分配, %s 分配到 %q", m.TaskKey, m.WorkerID)
+			}
+		}
+		if stats.exclusions["users-table"] != "a-2" {
+			t.Fatalf("期望 a-2 持有互斥组, 得到 %v", stats.exclusions)
+		}
+	})
+
+	t.Run("成员属于同一互斥组时无法调度", func(t *testing.T) {
+		stats := newStats()
+		if err := s.assignGang(ctx, b, newQuotaTracker(nil, stats.bizTypes), stats); err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range b {
+			if m.Status != model.TaskStatusUnschedulable || m.WorkerID != "" {
+				t.Fatalf("期望 %s 无法调度, 得到 %s %q", m.TaskKey, m.Status, m.WorkerID)
+			}
+		}
+		if owner, _ := s.opts.locker.Owner(ctx, exclusionLockKey("orders-table")); owner != "" {
+			t.Fatalf("期望不持有互斥组, 被 %s 持有", owner)
+		}
+	})
+}
@@ -0,0 +1,303 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+const gateTimeout = 5 * time.Second
+
+// gateClient calls http gates, redirects are not followed so that a gate
+// can not lead the scheduler to hosts out of the allowlist.
+var gateClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// checkGateURL returns an error unless rawURL is a http(s) url of hosts.
+func checkGateURL(rawURL string, hosts map[string]bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if !hosts[strings.ToLower(u.Hostname())] && !hosts[strings.ToLower(u.Host)] {
+		return errors.Errorf("host %q is not allowed", u.Host)
+	}
+	return nil
+}
+
+// gates caches results of gates of pending tasks, tasks are registered
+// by the assignment loop and evaluated by the gate controller.
+type gates struct {
+	mu sync.Mutex
+	// task key => why the task is held, empty if all gates are open.
+	held map[string]string
+	// task key => keys of tasks it depends on and boosted, see inheritPriority.
+	boosted map[string][]string
+}
+
+func newGates() *gates {
+	return &gates{held: make(map[string]string), boosted: make(map[string][]string)}
+}
+
+// reason returns why the task is held by its gates, empty if it is not.
+// gates of a task seen the first time are closed until evaluated.
+func (g *gates) reason(task *model.Task) string {
+	if len(task.Gates) == 0 || task.AssignedAt != nil {
+		return ""
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	reason, ok := g.held[task.TaskKey]
+	if !ok {
+		reason = "gates are not evaluated yet"
+		g.held[task.TaskKey] = reason
+	}
+	return reason
+}
+
+// peek returns the cached result without registering the task.
+func (g *gates) peek(taskKey string) (reason string, ok bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	reason, ok = g.held[taskKey]
+	return reason, ok
+}
+
+func (g *gates) keys() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	keys := make([]string, 0, len(g.held))
+	for key := range g.held {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// set records the result, returns true if the task turns open.
+func (g *gates) set(taskKey, reason string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	old, ok := g.held[taskKey]
+	if !ok {
+		return false
+	}
+	g.held[taskKey] = reason
+	return old != "" && reason == ""
+}
+
+func (g *gates) forget(taskKey string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.held, taskKey)
+}
+
+// boost records that taskKey boosted the priority of dep.
+func (g *gates) boost(taskKey, dep string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !slices.Contains(g.boosted[taskKey], dep) {
+		g.boosted[taskKey] = append(g.boosted[taskKey], dep)
+	}
+}
+
+// unboost returns and forgets tasks taskKey boosted.
+func (g *gates) unboost(taskKey string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	deps := g.boosted[taskKey]
+	delete(g.boosted, taskKey)
+	return deps
+}
+
+// validatePreStop checks the pre-stop hook of a task, workers post to hosts
+// allowed for http gates only.
+func validatePreStop(h *model.PreStopHook, hosts map[string]bool) error {
+	if err := model.ValidatePreStop(h); err != nil {
+		return err
+	}
+	if h == nil || h.HTTPPost == "" {
+		return nil
+	}
+	if err := checkGateURL(h.HTTPPost, hosts); err != nil {
+		return errors.Errorf("invalid pre-stop hook: %v", err)
+	}
+	return nil
+}
+
+// validateGates checks gates of a task, hosts are hosts http gates may call.
+func validateGates(gates []model.Gate, hosts map[string]bool) error {
+	names := make(map[string]bool, len(gates))
+	for _, gate := range gates {
+		if gate.Name == "" || names[gate.Name] {
+			return errors.Errorf("invalid gate, need unique name: %q", gate.Name)
+		}
+		names[gate.Name] = true
+		switch gate.Kind {
+		case model.GateManual:
+		case model.GateHTTP:
+			if gate.URL == "" {
+				return errors.Errorf("invalid gate %s, need url", gate.Name)
+			}
+			if err := checkGateURL(gate.URL, hosts); err != nil {
+				return errors.Errorf("invalid gate %s: %v", gate.Name, err)
+			}
+		case model.GateSQL:
+			if gate.Predicate == "" {
+				return errors.Errorf("invalid gate %s, need predicate", gate.Name)
+			}
+		case model.GateTask:
+			if gate.TaskKey == "" {
+				return errors.Errorf("invalid gate %s, need task key", gate.Name)
+			}
+		default:
+			return errors.Errorf("invalid gate %s, unknown kind %q", gate.Name, gate.Kind)
+		}
+	}
+	return nil
+}
+
+// runGateController evaluates gates of pending tasks, only leader works.
+func (s *Scheduler) runGateController() {
+	ticker := time.NewTicker(s.opts.gateCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		amILeader, _, err := s.amILeader()
+		if err != nil {
+			log.Error("[Gate] 获取 leader 状态失败: %v", err)
+			continue
+		}
+		if !amILeader {
+			continue
+		}
+		if err := s.checkGates(context.Background()); err != nil {
+			log.Error("[Gate] 检查任务门控失败: %v", err)
+		}
+	}
+}
+
+func (s *Scheduler) checkGates(ctx context.Context) error {
+	keys := s.gates.keys()
+	if len(keys) == 0 {
+		return nil
+	}
+	tasks, err := s.taskRepo.BatchGetTask(ctx, keys)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	found := make(map[string]bool, len(tasks))
+	opened := false
+	for _, task := range tasks {
+		found[task.TaskKey] = true
+		if task.IsDeleted() || task.Status.IsFinalStatus() || task.AssignedAt != nil {
+			s.gates.forget(task.TaskKey)
+			s.revertInheritance(ctx, task.TaskKey)
+			continue
+		}
+		reason := s.evaluateGates(ctx, task)
+		if reason != "" {
+			log.Debug("[Gate] 任务[%s]等待门控: %s", task.TaskKey, reason)
+			s.inheritPriority(ctx, task)
+		} else {
+			s.revertInheritance(ctx, task.TaskKey)
+		}
+		if s.gates.set(task.TaskKey, reason) {
+			log.Info("[Gate] 任务[%s]门控已满足", task.TaskKey)
+			opened = true
+		}
+	}
+	for _, key := range keys {
+		if !found[key] {
+			s.gates.forget(key)
+			s.revertInheritance(ctx, key)
+		}
+	}
+	if opened {
+		s.triggerReAssignEvent()
+	}
+	return nil
+}
+
+// evaluateGates returns why the first closed gate of task is closed, empty if all are open.
+func (s *Scheduler) evaluateGates(ctx context.Context, task *model.Task) string {
+	for _, gate := range task.Gates {
+		if err := s.evaluateGate(ctx, task, gate); err != nil {
+			return fmt.Sprintf("gate %s: %v", gate.Name, err)
+		}
+	}
+	return ""
+}
+
+// evaluateGate returns nil if gate is open.
+func (s *Scheduler) evaluateGate(ctx context.Context, task *model.Task, gate model.Gate) error {
+	ctx, cancel := context.WithTimeout(ctx, gateTimeout)
+	defer cancel()
+
+	switch gate.Kind {
+	case model.GateManual:
+		if !task.ManualGateOpened(gate.Name) {
+			return errors.New("waiting for manual approval")
+		}
+		return nil
+	case model.GateHTTP:
+		// the allowlist may shrink after the task is created.
+		if err := checkGateURL(gate.URL, s.opts.gateHosts); err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, gate.URL, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := gateClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return errors.Errorf("not ready: %s", resp.Status)
+		}
+		return nil
+	case model.GateSQL:
+		query, ok := s.opts.gatePredicates[gate.Predicate]
+		if !ok || s.opts.gateDB == nil {
+			return errors.Errorf("predicate %q is not registered", gate.Predicate)
+		}
+		args := make([]any, 0, len(gate.Args))
+		for _, arg := range gate.Args {
+			args = append(args, arg)
+		}
+		var ready bool
+		if err := s.opts.gateDB.QueryRowContext(ctx, query, args...).Scan(&ready); err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if !ready {
+			return errors.New("predicate is false")
+		}
+		return nil
+	case model.GateTask:
+		dep, err := s.taskRepo.GetTask(ctx, gate.TaskKey)
+		if err != nil {
+			return err
+		}
+		if dep.Status != model.TaskStatusSuccess {
+			return errors.Errorf("task %s is %s", gate.TaskKey, dep.Status)
+		}
+		return nil
+	}
+	return errors.Errorf("unknown gate kind %q", gate.Kind)
+}
@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type batchRepo struct {
+	listRepo
+}
+
+func (r *batchRepo) BatchGetTask(_ context.Context, keys []string) ([]*model.Task, error) {
+	var ret []*model.Task
+	for _, t := range r.tasks {
+		for _, key := range keys {
+			if t.TaskKey == key {
+				ret = append(ret, t)
+			}
+		}
+	}
+	return ret, nil
+}
+
+func TestGates(t *testing.T) {
+	ready := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	task := &model.Task{
+		TaskKey: "t1",
+		Status:  model.TaskStatusWaitScheduling,
+		Gates: []model.Gate{
+			{Name: "upstream", Kind: model.GateHTTP, URL: srv.URL},
+			{Name: "approved", Kind: model.GateManual},
+		},
+	}
+	s := &Scheduler{taskRepo: &batchRepo{listRepo{tasks: []*model.Task{task}}}, gates: newGates(), opts: newOptions(WithGateHosts(host))}
+	s.assignEvent = make(chan struct{}, 1)
+	ctx := context.Background()
+
+	t.Run("未评估前阻塞", func(t *testing.T) {
+		if s.gates.reason(task) == "" {
+			t.Fatal("期望未评估的门控阻塞调度")
+		}
+		if s.gates.reason(&model.Task{TaskKey: "free"}) != "" {
+			t.Fatal("无门控的任务不应阻塞")
+		}
+	})
+
+	t.Run("所有门控满足后放行", func(t *testing.T) {
+		if err := s.checkGates(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if reason := s.gates.reason(task); reason != "gate upstream: not ready: 503 Service Unavailable" {
+			t.Fatalf("期望 http 门控未就绪, 得到 %q", reason)
+		}
+
+		ready = true
+		if err := s.checkGates(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if reason := s.gates.reason(task); reason != "gate approved: waiting for manual approval" {
+			t.Fatalf("期望等待人工审批, 得到 %q", reason)
+		}
+
+		task.Extra = map[string]string{model.ExtraGatePrefix + "approved": "true"}
+		if err := s.checkGates(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if reason := s.gates.reason(task); reason != "" {
+			t.Fatalf("期望放行, 得到 %q", reason)
+		}
+		if len(s.assignEvent) != 1 {
+			t.Fatal("期望门控打开后触发重新分配")
+		}
+	})
+
+	t.Run("校验门控定义", func(t *testing.T) {
+		if err := validateGates([]model.Gate{{Name: "a", Kind: model.GateHTTP}}, s.opts.gateHosts); err == nil {
+			t.Error("期望缺少 url 报错")
+		}
+		if err := validateGates([]model.Gate{{Name: "a", Kind: model.GateManual}, {Name: "a", Kind: model.GateManual}}, s.opts.gateHosts); err == nil {
+			t.Error("期望重名报错")
+		}
+		for _, u := range []string{"http://169.254.169.254/latest/meta-data", "file:///etc/passwd", "http://" + host + ".evil.com/"} {
+			if err := validateGates([]model.Gate{{Name: "a", Kind: model.GateHTTP, URL: u}}, s.opts.gateHosts); err == nil {
+				t.Errorf("期望不在白名单的 url %s 报错", u)
+			}
+		}
+		if err := validateGates([]model.Gate{{Name: "a", Kind: model.GateHTTP, URL: srv.URL + "/ready"}}, s.opts.gateHosts); err != nil {
+			t.Errorf("期望白名单内的 url 通过, 得到 %v", err)
+		}
+	})
+
+	t.Run("校验 pre-stop 钩子", func(t *testing.T) {
+		for _, u := range []string{"http://169.254.169.254/latest/meta-data", "file:///etc/passwd"} {
+			if err := validatePreStop(&model.PreStopHook{HTTPPost: u}, s.opts.gateHosts); err == nil {
+				t.Errorf("期望不在白名单的 url %s 报错", u)
+			}
+		}
+		if err := validatePreStop(&model.PreStopHook{GracePeriodSeconds: model.MaxPreStopGracePeriodSeconds + 1}, s.opts.gateHosts); err == nil {
+			t.Error("期望宽限期过长报错")
+		}
+		if err := validatePreStop(&model.PreStopHook{HTTPPost: srv.URL + "/drain", GracePeriodSeconds: 60}, s.opts.gateHosts); err != nil {
+			t.Errorf("期望白名单内的 url 通过, 得到 %v", err)
+		}
+		if err := validatePreStop(nil, s.opts.gateHosts); err != nil {
+			t.Errorf("期望没有钩子时通过, 得到 %v", err)
+		}
+	})
+
+	t.Run("不跟随重定向", func(t *testing.T) {
+		gate := model.Gate{Name: "upstream", Kind: model.GateHTTP, URL: srv.URL + "/redirect"}
+		if err := s.evaluateGate(ctx, task, gate); err == nil {
+			t.Error("期望重定向视为未就绪")
+		}
+	})
+
+	t.Run("白名单外的 host 不请求", func(t *testing.T) {
+		s := &Scheduler{opts: newOptions()}
+		if err := s.evaluateGate(ctx, task, model.Gate{Name: "upstream", Kind: model.GateHTTP, URL: srv.URL}); err == nil {
+			t.Error("期望未配置白名单时拒绝请求")
+		}
+	})
+}
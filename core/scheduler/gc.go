@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+const tombstoneGCBatch = 500
+
+// runTombstoneGC purge soft deleted tasks periodically, only leader works.
+func (s *Scheduler) runTombstoneGC() {
+	ticker := time.NewTicker(s.opts.tombstoneGCInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		amILeader, _, err := s.amILeader()
+		if err != nil {
+			log.Error("获取 leader 状态失败: %v", err)
+			continue
+		}
+		if !amILeader {
+			continue
+		}
+
+		if err := s.purgeTombstones(context.Background()); err != nil {
+			log.Error("回收已删除任务失败: %v", err)
+		}
+	}
+}
+
+func (s *Scheduler) purgeTombstones(ctx context.Context) error {
+	tombstones, err := s.taskRepo.ListTask(ctx, &model.TaskFilter{
+		OnlyDeleted: true,
+		Limit:       tombstoneGCBatch,
+	})
+	if err != nil {
+		return err
+	}
+
+	workers := s.getAvailableWorkers()
+	for _, task := range tombstones {
+		if !canPurge(task, workers) {
+			continue
+		}
+		// replicas of a deleted service are stopped by the service controller,
+		// the tombstone is kept until then so that they are not orphaned.
+		if task.IsService() {
+			live, err := s.liveReplicas(ctx, task)
+			if err != nil || len(live) > 0 {
+				continue
+			}
+		}
+		if err := s.taskRepo.PurgeTask(ctx, task.TaskKey); err != nil {
+			log.Error("任务[%s]回收失败: %v", task.TaskKey, err)
+			continue
+		}
+		log.Info("任务[%s]已回收", task.TaskKey)
+	}
+	return nil
+}
+
+// canPurge reports whether no worker reports the task anymore.
+// task never assigned, parked before started, executor exited, or the worker is gone.
+func canPurge(task *model.Task, workers []discover.Instance) bool {
+	if !task.IsDeleted() {
+		return false
+	}
+	if task.WorkerID == "" || task.Status.IsFinalStatus() || task.IsParked() {
+		return true
+	}
+	return !slices.ContainsFunc(workers, func(w discover.Instance) bool {
+		return w.ID() == task.WorkerID
+	})
+}
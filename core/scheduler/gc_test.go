@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo/memory"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestPurgeTombstones(t *testing.T) {
+	deletedAt := time.Now()
+	parked := model.ParkedRunAt
+	workers := []discover.Instance{{InstanceId: "w1"}}
+	tests := []struct {
+		name string
+		task *model.Task
+		want bool
+	}{
+		{
+			name: "未删除的任务不回收",
+			task: &model.Task{TaskKey: "alive", WorkerID: "w2", Status: model.TaskStatusSuccess},
+		},
+		{
+			name: "从未分配",
+			task: &model.Task{TaskKey: "unassigned", Status: model.TaskStatusWaitScheduling, DeletedAt: &deletedAt},
+			want: true,
+		},
+		{
+			name: "执行器已退出",
+			task: &model.Task{TaskKey: "exited", WorkerID: "w1", Status: model.TaskStatusSuccess, DeletedAt: &deletedAt},
+			want: true,
+		},
+		{
+			name: "worker 已下线",
+			task: &model.Task{TaskKey: "gone", WorkerID: "w2", Status: model.TaskStatusRunning, DeletedAt: &deletedAt},
+			want: true,
+		},
+		{
+			name: "启动前被挂起",
+			task: &model.Task{TaskKey: "parked", WorkerID: "w1", Status: model.TaskStatusWaitRunning, NextRunAt: &parked, DeletedAt: &deletedAt},
+			want: true,
+		},
+		{
+			name: "worker 仍在运行执行器",
+			task: &model.Task{TaskKey: "running", WorkerID: "w1", Status: model.TaskStatusRunning, DeletedAt: &deletedAt},
+		},
+	}
+
+	ctx := context.Background()
+	repo := memory.New()
+	for _, tt := range tests {
+		if err := repo.CreateTask(ctx, tt.task); err != nil {
+			t.Fatal(err)
+		}
+		if tt.task.IsDeleted() {
+			if err := repo.DeleteTask(ctx, tt.task.TaskKey); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	s := &Scheduler{taskRepo: repo, opts: newOptions()}
+	s.setAvailableWorkers(workers)
+	if err := s.purgeTombstones(ctx); err != nil {
+		t.Fatal(err)
+	}
+	kept := make(map[string]bool)
+	for _, filter := range []*model.TaskFilter{{}, {OnlyDeleted: true}} {
+		tasks, err := repo.ListTask(ctx, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, task := range tasks {
+			kept[task.TaskKey] = true
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canPurge(tt.task, workers); got != tt.want {
+				t.Errorf("canPurge() = %v, want %v", got, tt.want)
+			}
+			if purged := !kept[tt.task.TaskKey]; purged != tt.want {
+				t.Errorf("purgeTombstones() purged = %v, want %v", purged, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/graphql"
+)
+
+// fields of task computed by resolvers rather than stored in task.
+const (
+	gqlFieldAttempts = "attempts"
+	gqlFieldAudits   = "audits"
+)
+
+// taskFields are json names of task fields selectable by graphql queries.
+var taskFields = func() map[string]bool {
+	fields := map[string]bool{gqlFieldAttempts: true, gqlFieldAudits: true}
+	t := reflect.TypeOf(model.Task{})
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// QueryGraphQL runs a graphql query over tasks, eg.
+//
+//	query($biz: String) {
+//	  tasks(biz_type: $biz, status: ["failed"], limit: 10) {
+//	    task_key status
+//	    attempts { worker_id status }
+//	    audits { action operator }
+//	  }
+//	  task(key: "k1") { status msg }
+//	}
+//
+// arguments of tasks are biz_ids, biz_type, type, status, labels, limit, offset and archived.
+func (s *Scheduler) QueryGraphQL(ctx context.Context, query string, variables map[string]any) (map[string]any, error) {
+	fields, err := graphql.Parse(query, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]any, len(fields))
+	for _, f := range fields {
+		switch f.Name {
+		case "task":
+			key, _ := f.Args["key"].(string)
+			if key == "" {
+				return nil, errors.New("task requires argument key")
+			}
+			task, err := s.GetTask(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			if data[f.Key()], err = s.resolveTask(ctx, task, f.Fields); err != nil {
+				return nil, err
+			}
+		case "tasks":
+			filter, err := gqlTaskFilter(f.Args)
+			if err != nil {
+				return nil, err
+			}
+			if err := auth.CheckBizType(ctx, filter.BizType); err != nil {
+				return nil, err
+			}
+			tasks, err := s.ListTask(ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+			list := make([]any, 0, len(tasks))
+			for _, task := range tasks {
+				v, err := s.resolveTask(ctx, task, f.Fields)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			data[f.Key()] = list
+		default:
+			return nil, errors.Errorf("unknown query field %q", f.Name)
+		}
+	}
+	return data, nil
+}
+
+func (s *Scheduler) resolveTask(ctx context.Context, task *model.Task, fields []*graphql.Field) (map[string]any, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("task requires a selection of fields")
+	}
+	m, err := toJSONMap(task)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]any, len(fields))
+	for _, f := range fields {
+		if !taskFields[f.Name] {
+			return nil, errors.Errorf("unknown task field %q", f.Name)
+		}
+		var v any
+		switch f.Name {
+		case gqlFieldAttempts:
+			if s.opts.attemptRepo != nil {
+				if v, err = s.opts.attemptRepo.List(ctx, task.TaskKey); err != nil {
+					return nil, err
+				}
+			}
+		case gqlFieldAudits:
+			if s.opts.auditor != nil {
+				if v, err = s.opts.auditor.List(ctx, task.TaskKey); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			v = m[f.Name]
+		}
+		if ret[f.Key()], err = project(v, f.Fields); err != nil {
+			return nil, errors.Wrapf(err, "field %s", f.Name)
+		}
+	}
+	return ret, nil
+}
+
+// project keeps selected fields of v, v is returned as is without selection.
+func project(v any, fields []*graphql.Field) (any, error) {
+	if len(fields) == 0 || v == nil {
+		return v, nil
+	}
+	if _, ok := v.(map[string]any); !ok {
+		var err error
+		if v, err = toJSONValue(v); err != nil {
+			return nil, err
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		ret := make(map[string]any, len(fields))
+		for _, f := range fields {
+			sub, err := project(v[f.Name], f.Fields)
+			if err != nil {
+				return nil, err
+			}
+			ret[f.Key()] = sub
+		}
+		return ret, nil
+	case nil:
+		return nil, nil
+	case []any:
+		ret := make([]any, 0, len(v))
+		for _, item := range v {
+			sub, err := project(item, fields)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, sub)
+		}
+		return ret, nil
+	default:
+		return nil, fmt.Errorf("scalar has no fields")
+	}
+}
+
+func gqlTaskFilter(args map[string]any) (*model.TaskFilter, error) {
+	filter := &model.TaskFilter{Limit: 20}
+	for name, v := range args {
+		var ok bool
+		switch name {
+		case "biz_ids":
+			filter.BizIDs, ok = gqlStrings(v)
+		case "biz_type":
+			filter.BizType, ok = v.(string)
+		case "type":
+			filter.Type, ok = v.(string)
+		case "status":
+			var statuses []string
+			statuses, ok = gqlStrings(v)
+			for _, st := range statuses {
+				filter.Statuses = append(filter.Statuses, model.TaskStatus(st))
+			}
+		case "labels":
+			var obj map[string]any
+			obj, ok = v.(map[string]any)
+			filter.Labels = make(map[string]string, len(obj))
+			for k, lv := range obj {
+				filter.Labels[k] = fmt.Sprint(lv)
+			}
+		case "limit":
+			filter.Limit, ok = gqlInt(v)
+		case "offset":
+			filter.Offset, ok = gqlInt(v)
+		case "archived":
+			filter.Archived, ok = v.(bool)
+		default:
+			return nil, errors.Errorf("unknown argument %q of tasks", name)
+		}
+		if !ok {
+			return nil, errors.Errorf("invalid argument %s: %v", name, v)
+		}
+	}
+	return filter, nil
+}
+
+// gqlInt accepts int literals and integral numbers of JSON variables.
+func gqlInt(v any) (int, bool) {
+	switch v := v.(type) {
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), v == float64(int(v))
+	}
+	return 0, false
+}
+
+// gqlStrings accepts a list of strings or a single string, as graphql input coercion does.
+func gqlStrings(v any) ([]string, bool) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, true
+	case []any:
+		ret := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			ret = append(ret, s)
+		}
+		return ret, true
+	}
+	return nil, false
+}
+
+func toJSONMap(v any) (map[string]any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return m, nil
+}
+
+func toJSONValue(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var ret any
+	if err := json.Unmarshal(b, &ret); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return ret, nil
+}
@@ -0,0 +1,39 @@
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/graphql"
+)
+
+func TestResolveTask(t *testing.T) {
+	s := &Scheduler{opts: newOptions()}
+
+	t.Run("只返回选择的字段", func(t *testing.T) {
+		fields, err := graphql.Parse(`{ tasks { key: task_key retry { max_retries } probes { liveness { period_seconds } } } }`, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.resolveTask(context.Background(), &model.Task{
+			TaskKey: "a",
+			Status:  model.TaskStatusRunning,
+			Retry:   &model.RetryPolicy{MaxRetries: 3},
+		}, fields[0].Fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]any{"key": "a", "retry": map[string]any{"max_retries": float64(3)}, "probes": nil}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("期望 %v, 得到 %v", want, got)
+		}
+	})
+
+	t.Run("未知字段报错", func(t *testing.T) {
+		if _, err := s.resolveTask(context.Background(), &model.Task{}, []*graphql.Field{{Name: "nope"}}); err == nil {
+			t.Errorf("期望未知字段报错")
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// holdback parks NextRunAt of a held task being paused, so that workers no
+// longer list it as runnable, and restores NextRunAt of a parked task being
+// resumed with the time left when it was parked.
+func holdback(task *model.Task, nextStatus model.TaskStatus, update *model.Task, now time.Time) {
+	switch {
+	case nextStatus == model.TaskStatusPaused && task.IsHeld(now):
+		parked := model.ParkedRunAt
+		left := task.NextRunAt.Sub(now).String()
+		update.NextRunAt = &parked
+		update.Annotations = mergePatch(task.Annotations, map[string]*string{model.AnnotationHoldback: &left})
+	case nextStatus != model.TaskStatusPaused && task.IsParked():
+		next := now
+		if nextStatus == model.TaskStatusRunning {
+			next = now.Add(task.ParkedHoldback())
+		}
+		update.NextRunAt = &next
+		update.Annotations = mergePatch(task.Annotations, map[string]*string{model.AnnotationHoldback: nil})
+	}
+}
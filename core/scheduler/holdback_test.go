@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo/memory"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestHoldback(t *testing.T) {
+	ctx := context.Background()
+	nextRunAt := time.Now().Add(time.Hour)
+	repo := &statusRepo{getRepo{listRepo{tasks: []*model.Task{
+		{TaskKey: "a", Status: model.TaskStatusWaitRunning, WantRunStatus: model.TaskStatusRunning, NextRunAt: &nextRunAt},
+		{TaskKey: "b", Status: model.TaskStatusWaitRunning, WantRunStatus: model.TaskStatusRunning, NextRunAt: &nextRunAt},
+	}}}}
+	s := &Scheduler{taskRepo: repo, opts: newOptions()}
+	a, b := repo.tasks[0], repo.tasks[1]
+
+	t.Run("暂停等待运行的任务时挂起 NextRunAt", func(t *testing.T) {
+		if err := s.OperateTask(ctx, "", "a", model.TaskStatusPaused, "bob"); err != nil {
+			t.Fatal(err)
+		}
+		if a.Status != model.TaskStatusPaused || !a.IsParked() {
+			t.Fatalf("期望直接暂停并挂起, 得到 %s, %v", a.Status, a.NextRunAt)
+		}
+		if left := a.ParkedHoldback(); left <= 59*time.Minute || left > time.Hour {
+			t.Fatalf("期望记录剩余约 1h, 得到 %s", left)
+		}
+	})
+
+	t.Run("恢复时按剩余时间还原 NextRunAt", func(t *testing.T) {
+		if err := s.OperateTask(ctx, "", "a", model.TaskStatusRunning, "bob"); err != nil {
+			t.Fatal(err)
+		}
+		if a.Status != model.TaskStatusWaitRunning || a.IsParked() || !a.IsHeld(time.Now().Add(59*time.Minute)) {
+			t.Fatalf("期望等待运行约 1h 后, 得到 %s, %v", a.Status, a.NextRunAt)
+		}
+		if _, ok := a.Annotations[model.AnnotationHoldback]; ok {
+			t.Fatal("恢复后应移除剩余时间注解")
+		}
+	})
+
+	t.Run("停止挂起的任务直接结束", func(t *testing.T) {
+		if err := s.OperateTask(ctx, "", "b", model.TaskStatusPaused, "bob"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.OperateTask(ctx, "", "b", model.TaskStatusStop, "bob"); err != nil {
+			t.Fatal(err)
+		}
+		if b.Status != model.TaskStatusStop || b.IsParked() {
+			t.Fatalf("期望直接停止并解除挂起, 得到 %s, %v", b.Status, b.NextRunAt)
+		}
+	})
+
+	t.Run("批量暂停时同样挂起", func(t *testing.T) {
+		repo := memory.New()
+		s := &Scheduler{taskRepo: repo, opts: newOptions()}
+		if err := repo.CreateTask(ctx, &model.Task{TaskKey: "c", BizType: "held", Status: model.TaskStatusWaitRunning, WantRunStatus: model.TaskStatusRunning, NextRunAt: &nextRunAt}); err != nil {
+			t.Fatal(err)
+		}
+		result, err := s.PauseTasks(ctx, &model.TaskFilter{BizType: "held"}, "", "bob")
+		if err != nil || result.Updated != 1 {
+			t.Fatalf("期望暂停 1 个任务, 得到 %+v %v", result, err)
+		}
+		c, err := repo.GetTask(ctx, "c")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Status != model.TaskStatusPaused || !c.IsParked() || c.ParkedHoldback() <= 59*time.Minute {
+			t.Fatalf("期望直接暂停并挂起, 得到 %s, %v, %v", c.Status, c.NextRunAt, c.Annotations)
+		}
+		if keys, err := repo.ListRunnableTasks(ctx, ""); err != nil || len(keys) != 0 {
+			t.Fatalf("挂起的任务不应可运行, 得到 %v %v", keys, err)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// inheritPriority boosts tasks task depends on by GateTask to the effective
+// priority of task while it's held, so that a chain of dependencies is not
+// starved by tasks of priorities between them. tasks boosted inherit in turn
+// on later checks if they are held too, see revertInheritance.
+func (s *Scheduler) inheritPriority(ctx context.Context, task *model.Task) {
+	want := task.EffectivePriority()
+	for _, gate := range task.Gates {
+		if gate.Kind != model.GateTask {
+			continue
+		}
+		dep, err := s.taskRepo.GetTask(ctx, gate.TaskKey)
+		if err != nil || dep.IsDeleted() || dep.Status.IsFinalStatus() {
+			continue
+		}
+		inherited, from, ok := dep.InheritedPriority()
+		switch {
+		case want > dep.EffectivePriority():
+		case ok && from == task.TaskKey && want != inherited:
+			// the priority of task changed since it's inherited.
+			if want <= dep.Priority {
+				s.dropInheritance(ctx, dep)
+				continue
+			}
+		default:
+			continue
+		}
+
+		p := strconv.Itoa(want)
+		err = s.patchAnnotations(ctx, dep, map[string]*string{
+			model.AnnotationInheritedPriority: &p,
+			model.AnnotationInheritedFrom:     &task.TaskKey,
+		})
+		if err != nil {
+			log.Error("[Gate] 任务[%s]继承任务[%s]的优先级失败: %v", dep.TaskKey, task.TaskKey, err)
+			continue
+		}
+		s.gates.boost(task.TaskKey, dep.TaskKey)
+		log.Info("[Gate] 任务[%s]继承任务[%s]的优先级 %d", dep.TaskKey, task.TaskKey, want)
+		s.event(ctx, events.Event{
+			TaskKey: dep.TaskKey,
+			Reason:  events.ReasonPriorityInherited,
+			Message: fmt.Sprintf("priority %d inherited from task %s depending on it", want, task.TaskKey),
+		})
+	}
+}
+
+// revertInheritance reverts priorities inherited from taskKey once it's no
+// longer held, tasks boosted by others since are left as is.
+func (s *Scheduler) revertInheritance(ctx context.Context, taskKey string) {
+	for _, key := range s.gates.unboost(taskKey) {
+		dep, err := s.taskRepo.GetTask(ctx, key)
+		if err != nil {
+			continue
+		}
+		if _, from, ok := dep.InheritedPriority(); ok && from == taskKey {
+			s.dropInheritance(ctx, dep)
+		}
+	}
+}
+
+func (s *Scheduler) dropInheritance(ctx context.Context, dep *model.Task) {
+	err := s.patchAnnotations(ctx, dep, map[string]*string{
+		model.AnnotationInheritedPriority: nil,
+		model.AnnotationInheritedFrom:     nil,
+	})
+	if err != nil {
+		log.Error("[Gate] 任务[%s]恢复优先级失败: %v", dep.TaskKey, err)
+		return
+	}
+	log.Info("[Gate] 任务[%s]恢复优先级 %d", dep.TaskKey, dep.Priority)
+	s.event(ctx, events.Event{
+		TaskKey: dep.TaskKey,
+		Reason:  events.ReasonPriorityInherited,
+		Message: fmt.Sprintf("priority %d restored", dep.Priority),
+	})
+}
@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// inheritRepo gets, batch gets and updates tasks in memory.
+type inheritRepo struct {
+	statusRepo
+}
+
+func (r *inheritRepo) BatchGetTask(ctx context.Context, keys []string) ([]*model.Task, error) {
+	return (&batchRepo{r.listRepo}).BatchGetTask(ctx, keys)
+}
+
+func TestInheritPriority(t *testing.T) {
+	extract := &model.Task{TaskKey: "extract", Status: model.TaskStatusWaitScheduling, Priority: 1}
+	load := &model.Task{
+		TaskKey:  "load",
+		Status:   model.TaskStatusWaitScheduling,
+		Priority: 10,
+		Gates:    []model.Gate{{Name: "extracted", Kind: model.GateTask, TaskKey: "extract"}},
+	}
+	report := &model.Task{
+		TaskKey:  "report",
+		Status:   model.TaskStatusWaitScheduling,
+		Priority: 100,
+		Gates:    []model.Gate{{Name: "loaded", Kind: model.GateTask, TaskKey: "load"}},
+	}
+	repo := &inheritRepo{statusRepo{getRepo{listRepo{tasks: []*model.Task{extract, load, report}}}}}
+	s := &Scheduler{taskRepo: repo, gates: newGates(), opts: newOptions()}
+	s.assignEvent = make(chan struct{}, 1)
+	ctx := context.Background()
+
+	t.Run("校验任务门控", func(t *testing.T) {
+		if err := validateGates([]model.Gate{{Name: "x", Kind: model.GateTask}}, nil); err == nil {
+			t.Error("task gate without task key should be rejected")
+		}
+	})
+
+	t.Run("依赖链逐级继承优先级", func(t *testing.T) {
+		s.gates.reason(load)
+		s.gates.reason(report)
+		if err := s.checkGates(ctx); err != nil {
+			t.Fatal(err)
+		}
+		// load inherits first, extract inherits from load on the next check.
+		if err := s.checkGates(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if p := load.EffectivePriority(); p != 100 {
+			t.Errorf("load should inherit priority 100 of report, got %d", p)
+		}
+		if p, from, _ := extract.InheritedPriority(); p != 100 || from != "load" {
+			t.Errorf("extract should inherit priority 100 from load, got %d from %q", p, from)
+		}
+		if extract.Priority != 1 || load.Priority != 10 {
+			t.Error("priorities of tasks should be kept")
+		}
+	})
+
+	t.Run("依赖完成后恢复优先级", func(t *testing.T) {
+		extract.Status = model.TaskStatusSuccess
+		if err := s.checkGates(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if reason := s.gates.reason(load); reason != "" {
+			t.Fatalf("load should be released once extract succeeded, got %q", reason)
+		}
+		if _, _, ok := load.InheritedPriority(); !ok {
+			t.Error("load should keep the priority inherited while report waits for it")
+		}
+
+		<-s.assignEvent
+		load.AssignedAt = &load.CreatedAt
+		load.Status = model.TaskStatusSuccess
+		if err := s.checkGates(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if s.gates.reason(report) != "" {
+			t.Fatal("report should be released once load succeeded")
+		}
+		if _, _, ok := load.InheritedPriority(); ok {
+			t.Error("priority inherited by load should be reverted once report is released")
+		}
+	})
+
+	t.Run("按继承的优先级排序", func(t *testing.T) {
+		classes := []model.PriorityClass{
+			{Name: "batch", Value: 0, Default: true},
+			{Name: "critical", Value: 1000},
+		}
+		s := &Scheduler{opts: newOptions(WithPriorityClasses(classes...))}
+		boosted := &model.Task{TaskKey: "boosted", Annotations: map[string]string{
+			model.AnnotationInheritedPriority: "1000",
+			model.AnnotationInheritedFrom:     "x",
+		}}
+		if c := s.effectiveClass(boosted); c.Name != "critical" {
+			t.Errorf("boosted task should be queued in class critical, got %q", c.Name)
+		}
+		tasks := s.orderByPriorityClass([]*model.Task{{TaskKey: "plain"}, boosted})
+		if tasks[0].TaskKey != "boosted" {
+			t.Errorf("boosted task should be ordered first, got %s", tasks[0].TaskKey)
+		}
+	})
+}
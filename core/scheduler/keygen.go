@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// KeyGenerator generates keys of tasks created without a key supplied by
+// callers, keys must be unique among schedulers and at most model.MaxTaskKeyLen long.
+type KeyGenerator interface {
+	NewKey(task *model.Task) (string, error)
+}
+
+// KeyGeneratorFunc adapts a function to KeyGenerator.
+type KeyGeneratorFunc func(task *model.Task) (string, error)
+
+func (f KeyGeneratorFunc) NewKey(task *model.Task) (string, error) {
+	return f(task)
+}
+
+// UUIDKeys generates random UUIDv4 keys, the default.
+func UUIDKeys() KeyGenerator {
+	return KeyGeneratorFunc(func(*model.Task) (string, error) {
+		return uuid.New().String(), nil
+	})
+}
+
+// UUIDv7Keys generates UUIDv7 keys, which sort by creation time so that
+// inserts append to the primary index of MySQL instead of splitting pages.
+func UUIDv7Keys() KeyGenerator {
+	return KeyGeneratorFunc(func(*model.Task) (string, error) {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		return id.String(), nil
+	})
+}
+
+// PrefixKeys prefixes keys of gen with prefix of the task, eg. the biz type,
+// so that ops tooling can tell where a task comes from by its key.
+func PrefixKeys(prefix func(task *model.Task) string, gen KeyGenerator) KeyGenerator {
+	return KeyGeneratorFunc(func(task *model.Task) (string, error) {
+		key, err := gen.NewKey(task)
+		if err != nil {
+			return "", err
+		}
+		return prefix(task) + key, nil
+	})
+}
+
+// BizTypePrefix is a prefix of PrefixKeys, eg. billing-.
+func BizTypePrefix(task *model.Task) string {
+	if task.BizType == "" {
+		return ""
+	}
+	return task.BizType + "-"
+}
+
+const (
+	snowflakeNodeBits = 10
+	snowflakeSeqBits  = 12
+	snowflakeMaxNode  = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSeq   = 1<<snowflakeSeqBits - 1
+)
+
+// snowflakeEpoch is the start of timestamps of snowflake keys.
+var snowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type snowflake struct {
+	mu   sync.Mutex
+	node int64
+	// milliseconds since snowflakeEpoch and sequence within it of the last key.
+	last int64
+	seq  int64
+	now  func() time.Time
+}
+
+// SnowflakeKeys generates decimal snowflake ids, 41 bits of milliseconds,
+// 10 bits of node and 12 bits of sequence. keys are unique if each scheduler
+// has its own node in [0, 1023], and sort by time if of the same length.
+func SnowflakeKeys(node int64) (KeyGenerator, error) {
+	if node < 0 || node > snowflakeMaxNode {
+		return nil, errors.Errorf("snowflake node must be in [0, %d]: %d", snowflakeMaxNode, node)
+	}
+	return &snowflake{node: node, now: time.Now}, nil
+}
+
+func (s *snowflake) NewKey(*model.Task) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms := s.now().Sub(snowflakeEpoch).Milliseconds()
+	if ms < s.last {
+		// clock moved backwards, keep keys increasing.
+		ms = s.last
+	}
+	if ms == s.last {
+		s.seq++
+		if s.seq > snowflakeMaxSeq {
+			// sequence of the millisecond is exhausted, borrow the next one.
+			ms++
+			s.seq = 0
+		}
+	} else {
+		s.seq = 0
+	}
+	s.last = ms
+	id := ms<<(snowflakeNodeBits+snowflakeSeqBits) | s.node<<snowflakeSeqBits | s.seq
+	return strconv.FormatInt(id, 10), nil
+}
@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestKeyGenerators(t *testing.T) {
+	task := &model.Task{BizType: "billing"}
+	newKeys := func(t *testing.T, g KeyGenerator, n int) []string {
+		keys := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			key, err := g.NewKey(task)
+			if err != nil {
+				t.Fatalf("NewKey() error = %v", err)
+			}
+			if err := model.ValidateTaskKey(key); err != nil {
+				t.Fatalf("invalid key %q: %v", key, err)
+			}
+			keys = append(keys, key)
+		}
+		return keys
+	}
+	assertSorted := func(t *testing.T, keys []string) {
+		for i := 1; i < len(keys); i++ {
+			if keys[i-1] >= keys[i] {
+				t.Fatalf("keys not sorted: %q >= %q", keys[i-1], keys[i])
+			}
+		}
+	}
+
+	t.Run("UUIDv7 按时间排序", func(t *testing.T) {
+		assertSorted(t, newKeys(t, UUIDv7Keys(), 1000))
+	})
+
+	t.Run("snowflake 按时间排序且序列耗尽时借用下一毫秒", func(t *testing.T) {
+		g, err := SnowflakeKeys(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now()
+		g.(*snowflake).now = func() time.Time { return now }
+		assertSorted(t, newKeys(t, g, 3*(snowflakeMaxSeq+1)))
+
+		// clock moved backwards.
+		g.(*snowflake).now = func() time.Time { return now.Add(-time.Second) }
+		last, _ := g.NewKey(task)
+		g.(*snowflake).now = func() time.Time { return now }
+		next, _ := g.NewKey(task)
+		if len(last) != len(next) || last >= next {
+			t.Errorf("keys not sorted after clock moved backwards: %q, %q", last, next)
+		}
+	})
+
+	t.Run("snowflake 并发唯一", func(t *testing.T) {
+		g, _ := SnowflakeKeys(snowflakeMaxNode)
+		var (
+			mu   sync.Mutex
+			seen = make(map[string]bool)
+			wg   sync.WaitGroup
+		)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, key := range newKeys(t, g, 1000) {
+					mu.Lock()
+					if seen[key] {
+						t.Errorf("duplicate key %q", key)
+					}
+					seen[key] = true
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("snowflake 节点越界", func(t *testing.T) {
+		for _, node := range []int64{-1, snowflakeMaxNode + 1} {
+			if _, err := SnowflakeKeys(node); err == nil {
+				t.Errorf("SnowflakeKeys(%d) want error", node)
+			}
+		}
+	})
+
+	t.Run("前缀加序列", func(t *testing.T) {
+		sf, _ := SnowflakeKeys(1)
+		keys := newKeys(t, PrefixKeys(BizTypePrefix, sf), 100)
+		assertSorted(t, keys)
+		for _, key := range keys {
+			if key[:len("billing-")] != "billing-" {
+				t.Fatalf("key %q without biz type prefix", key)
+			}
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"hash/fnv"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+)
+
+// localityWorker returns the worker of candidates that tasks of key stick to,
+// by rendezvous hashing: each worker is weighted by the hash of key and its
+// id, the heaviest is picked. a key moves only if its worker is no longer a
+// candidate, and keys of other workers never move when one joins or leaves.
+func localityWorker(key string, candidates []discover.Instance) discover.Instance {
+	best, bestWeight := 0, uint64(0)
+	for i, worker := range candidates {
+		if w := localityWeight(key, worker.ID()); i == 0 || w > bestWeight {
+			best, bestWeight = i, w
+		}
+	}
+	return candidates[best]
+}
+
+func localityWeight(key, workerID string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(workerID))
+	// fnv mixes trailing bytes poorly, finalize as splitmix64 does.
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+)
+
+func TestLocalityWorker(t *testing.T) {
+	workers := func(n int) []discover.Instance {
+		ws := make([]discover.Instance, n)
+		for i := range ws {
+			ws[i] = discover.Instance{InstanceId: fmt.Sprintf("w%d", i)}
+		}
+		return ws
+	}
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("customer-%d", i)
+	}
+
+	t.Run("同一 key 与候选顺序无关", func(t *testing.T) {
+		ws := workers(5)
+		reversed := []discover.Instance{ws[4], ws[3], ws[2], ws[1], ws[0]}
+		for _, key := range keys[:100] {
+			if a, b := localityWorker(key, ws).ID(), localityWorker(key, reversed).ID(); a != b {
+				t.Fatalf("key %s 期望同一 worker, 得到 %s 和 %s", key, a, b)
+			}
+		}
+	})
+
+	t.Run("key 均匀分布", func(t *testing.T) {
+		counts := make(map[string]int)
+		for _, key := range keys {
+			counts[localityWorker(key, workers(4)).ID()]++
+		}
+		for id, n := range counts {
+			if n < 150 || n > 350 {
+				t.Fatalf("worker %s 分到 %d 个 key, 分布不均: %v", id, n, counts)
+			}
+		}
+		if len(counts) != 4 {
+			t.Fatalf("期望 4 个 worker 均分到 key, 得到 %v", counts)
+		}
+	})
+
+	t.Run("worker 下线只迁移其 key", func(t *testing.T) {
+		ws := workers(5)
+		for _, key := range keys {
+			before := localityWorker(key, ws).ID()
+			after := localityWorker(key, ws[:4]).ID()
+			if before != "w4" && before != after {
+				t.Fatalf("key %s 不应从 %s 迁移到 %s", key, before, after)
+			}
+		}
+	})
+}
@@ -0,0 +1,465 @@
+package scheduler
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xyzbit/minitaskx/core/components/attempt"
+	"github.com/xyzbit/minitaskx/core/components/audit"
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// apiOperation describes a http api in the openapi document.
+type apiOperation struct {
+	method  string
+	path    string
+	summary string
+	role    auth.Role
+	// struct whose form tags are query parameters.
+	query any
+	// json request body.
+	body any
+	// json response body.
+	response any
+	// content type of request or response body which is not json.
+	bodyType     string
+	responseType string
+}
+
+type taskKeyQuery struct {
+	TaskKey string `form:"task_key" binding:"required"`
+}
+
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// apiOperations must be kept in sync with RegisterRoutes.
+var apiOperations = []apiOperation{
+	{
+		method: http.MethodGet, path: "/v1/tasks/list", summary: "List tasks", role: auth.RoleViewer,
+		query: listTaskRequest{},
+		response: struct {
+			Data    []*model.Task        `json:"data"`
+			Latency model.LatencySummary `json:"latency"`
+		}{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/tasks/get", summary: "Get a task", role: auth.RoleViewer,
+		query: taskKeyQuery{},
+		response: struct {
+			Data    *model.Task     `json:"data"`
+			Summary attempt.Summary `json:"summary"`
+		}{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/tasks/describe", summary: "Describe a task with its attempts, events and audits", role: auth.RoleViewer,
+		query: taskKeyQuery{},
+		response: struct {
+			Data *TaskDescription `json:"data"`
+		}{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/tasks/export", summary: "Export tasks as JSON lines", role: auth.RoleViewer,
+		query: exportTasksRequest{}, responseType: "application/x-ndjson",
+	},
+	{
+		method: http.MethodGet, path: "/v1/tasks/audits", summary: "List audit entries of a task", role: auth.RoleViewer,
+		query: taskKeyQuery{},
+		response: struct {
+			Data []audit.Entry `json:"data"`
+		}{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/tasks/attempts", summary: "List run attempts of a task", role: auth.RoleViewer,
+		query: taskKeyQuery{},
+		response: struct {
+			Data []*attempt.Attempt `json:"data"`
+		}{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/tasks/events", summary: "List events of a task", role: auth.RoleViewer,
+		query: taskKeyQuery{},
+		response: struct {
+			Data []events.Event `json:"data"`
+		}{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/tasks/watch", summary: "Watch changes of tasks as server-sent events", role: auth.RoleViewer,
+		query: watchTasksRequest{}, responseType: "text/event-stream",
+	},
+	{
+		method: http.MethodGet, path: "/v1/tasks/dashboard", summary: "Aggregate tasks for dashboards", role: auth.RoleViewer,
+		query: dashboardRequest{},
+		response: struct {
+			Data *Dashboard `json:"data"`
+		}{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/tasks/usage", summary: "Get runtime and cost of biz types in the current month", role: auth.RoleViewer,
+		query: usageRequest{},
+		response: struct {
+			Data *Usage `json:"data"`
+		}{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/tasks/estimates", summary: "Get run durations of task types and biz types estimated from history", role: auth.RoleViewer,
+		query: estimatesRequest{},
+		response: struct {
+			Data *Estimates `json:"data"`
+		}{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/tasks/capabilities", summary: "List task types available workers can run", role: auth.RoleViewer,
+		response: struct {
+			Data []Capability `json:"data"`
+		}{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/tasks/priority-classes", summary: "List priority classes", role: auth.RoleViewer,
+		response: struct {
+			Data []model.PriorityClass `json:"data"`
+		}{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/tasks/graphql", summary: "Query tasks with GraphQL", role: auth.RoleViewer,
+		body: graphQLRequest{},
+		response: struct {
+			Data   map[string]any `json:"data"`
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors,omitempty"`
+		}{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/tasks/preview-placement", summary: "Preview placement of a task", role: auth.RoleViewer,
+		body: previewPlacementRequest{}, response: Placement{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/tasks/create", summary: "Create a task", role: auth.RoleOperator,
+		body: createTaskRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/tasks/operate", summary: "Change want status of a task", role: auth.RoleOperator,
+		body: operateTaskRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/tasks/rerun", summary: "Run a finished task again", role: auth.RoleOperator,
+		body: rerunTaskRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/tasks/schedule-changes", summary: "Schedule want status changes of a task", role: auth.RoleOperator,
+		body: scheduleChangesRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/tasks/update-spec", summary: "Update payload and labels of a task", role: auth.RoleOperator,
+		body: updateTaskSpecRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPatch, path: "/v1/tasks/metadata", summary: "Merge labels and extra of a task", role: auth.RoleOperator,
+		body: patchTaskMetadataRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPatch, path: "/v1/tasks/annotations", summary: "Merge annotations of a task without reconciling it", role: auth.RoleOperator,
+		body: patchTaskAnnotationsRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/tasks/delete", summary: "Delete a task", role: auth.RoleAdmin,
+		body: deleteTaskRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/tasks/import", summary: "Import tasks exported as JSON lines", role: auth.RoleAdmin,
+		bodyType: "application/x-ndjson",
+		response: struct {
+			Message  string `json:"message"`
+			Imported int    `json:"imported"`
+		}{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/tasks/bulk-stop", summary: "Stop all tasks matching a filter", role: auth.RoleAdmin,
+		body: bulkOperateRequest{},
+		response: struct {
+			Data *BulkResult `json:"data"`
+		}{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/tasks/bulk-pause", summary: "Pause all tasks matching a filter", role: auth.RoleAdmin,
+		body: bulkOperateRequest{},
+		response: struct {
+			Data *BulkResult `json:"data"`
+		}{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/tasks/approve", summary: "Approve a task waiting approval", role: auth.RoleAdmin,
+		body: approveTaskRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/tasks/reject", summary: "Reject a task waiting approval", role: auth.RoleAdmin,
+		body: approveTaskRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/admin/force-finish", summary: "Force a task to a final status", role: auth.RoleAdmin,
+		body: forceFinishRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/admin/force-reassign", summary: "Force a task to another worker", role: auth.RoleAdmin,
+		body: forceReassignRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/admin/canaries", summary: "List canary policies", role: auth.RoleAdmin,
+		response: struct {
+			Canaries []CanaryPolicy `json:"canaries"`
+		}{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/admin/canary", summary: "Set canary policy of a task type", role: auth.RoleAdmin,
+		body: CanaryPolicy{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/admin/canary/remove", summary: "Remove canary policy of a task type", role: auth.RoleAdmin,
+		body: removeCanaryRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/admin/windows", summary: "List scheduling windows", role: auth.RoleAdmin,
+		response: struct {
+			Windows []Window `json:"windows"`
+		}{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/admin/window", summary: "Set a scheduling window", role: auth.RoleAdmin,
+		body: Window{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/admin/window/remove", summary: "Remove a scheduling window", role: auth.RoleAdmin,
+		body: removeWindowRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodGet, path: "/v1/admin/cordons", summary: "List cordoned workers, served by the leader only (503 otherwise)", role: auth.RoleAdmin,
+		response: struct {
+			Cordons []Cordon `json:"cordons"`
+		}{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/admin/cordon", summary: "Stop assigning new tasks to a worker until the leader changes, served by the leader only (503 otherwise)", role: auth.RoleAdmin,
+		body: cordonRequest{}, response: messageResponse{},
+	},
+	{
+		method: http.MethodPost, path: "/v1/admin/uncordon", summary: "Assign new tasks to a cordoned worker again, served by the leader only (503 otherwise)", role: auth.RoleAdmin,
+		body: cordonRequest{}, response: messageResponse{},
+	},
+}
+
+// OpenAPI serves the OpenAPI v3 document of http apis.
+func (s *HttpServer) OpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, openAPIDocument())
+}
+
+func openAPIDocument() map[string]any {
+	g := &schemaGen{names: make(map[reflect.Type]string), schemas: make(map[string]any)}
+	errSchema := g.schema(reflect.TypeOf(errorResponse{}))
+
+	paths := make(map[string]any)
+	for _, op := range apiOperations {
+		operation := map[string]any{
+			"summary":         op.summary,
+			"operationId":     operationID(op.path),
+			"tags":            []string{strings.Split(op.path, "/")[2]},
+			"x-required-role": op.role.String(),
+			"security":        []any{map[string]any{"bearerAuth": []string{}}},
+		}
+		if op.query != nil {
+			operation["parameters"] = g.parameters(reflect.TypeOf(op.query))
+		}
+		switch {
+		case op.body != nil:
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(op.body))}},
+			}
+		case op.bodyType != "":
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{op.bodyType: map[string]any{"schema": map[string]any{"type": "string"}}},
+			}
+		}
+
+		ok := map[string]any{"description": "success"}
+		switch {
+		case op.response != nil:
+			ok["content"] = map[string]any{"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(op.response))}}
+		case op.responseType != "":
+			ok["content"] = map[string]any{op.responseType: map[string]any{"schema": map[string]any{"type": "string"}}}
+		}
+		operation["responses"] = map[string]any{
+			"200": ok,
+			"default": map[string]any{
+				"description": "error",
+				"content":     map[string]any{"application/json": map[string]any{"schema": errSchema}},
+			},
+		}
+
+		item, _ := paths[op.path].(map[string]any)
+		if item == nil {
+			item = make(map[string]any)
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "minitaskx scheduler",
+			"version": "v1",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// operationID derives id from path, eg. /v1/admin/canary/remove -> admin_canary_remove.
+func operationID(p string) string {
+	return strings.NewReplacer("/", "_", "-", "_").Replace(strings.TrimPrefix(p, "/v1/"))
+}
+
+// schemaGen generates json schemas of go types as encoding/json marshals them,
+// named struct types are registered as components and referenced.
+type schemaGen struct {
+	names   map[reflect.Type]string
+	schemas map[string]any
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *schemaGen) schema(t reflect.Type) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.ref(t)
+	}
+	// interfaces hold any value.
+	return map[string]any{}
+}
+
+func (g *schemaGen) ref(t reflect.Type) map[string]any {
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := g.schemas[name]; taken {
+			name = path.Base(t.PkgPath()) + "." + name
+		}
+		g.names[t] = name
+		// reserve the name before generating, struct may refer to itself.
+		g.schemas[name] = nil
+		g.schemas[name] = g.object(t)
+	}
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func (g *schemaGen) object(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	var required []string
+	g.fields(t, "json", func(name string, f reflect.StructField) {
+		props[name] = g.schema(f.Type)
+		if isRequired(f) {
+			required = append(required, name)
+		}
+	})
+	obj := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+	return obj
+}
+
+func (g *schemaGen) parameters(t reflect.Type) []any {
+	var params []any
+	g.fields(t, "form", func(name string, f reflect.StructField) {
+		params = append(params, map[string]any{
+			"name":     name,
+			"in":       "query",
+			"required": isRequired(f),
+			"schema":   g.schema(f.Type),
+		})
+	})
+	return params
+}
+
+// fields walks exported fields of struct t named by tag key, embedded
+// structs without tag are flattened as encoding/json does.
+func (g *schemaGen) fields(t reflect.Type, key string, f func(name string, field reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get(key)
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, key, f)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		f(name, field)
+	}
+}
+
+func isRequired(f reflect.StructField) bool {
+	for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	t.Run("覆盖所有注册的路由", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		(&HttpServer{}).RegisterRoutes(r, nil)
+
+		paths := openAPIDocument()["paths"].(map[string]any)
+		for _, route := range r.Routes() {
+			if route.Path == "/openapi.json" {
+				continue
+			}
+			item, _ := paths[route.Path].(map[string]any)
+			if item[strings.ToLower(route.Method)] == nil {
+				t.Errorf("路由 %s %s 未在文档中描述", route.Method, route.Path)
+			}
+		}
+		if len(apiOperations) != len(r.Routes())-1 {
+			t.Errorf("文档描述了 %d 个接口, 注册了 %d 个", len(apiOperations), len(r.Routes())-1)
+		}
+	})
+
+	t.Run("生成组件及引用", func(t *testing.T) {
+		b, err := json.Marshal(openAPIDocument())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc struct {
+			Components struct {
+				Schemas map[string]struct {
+					Properties map[string]map[string]any `json:"properties"`
+				} `json:"schemas"`
+			} `json:"components"`
+		}
+		if err := json.Unmarshal(b, &doc); err != nil {
+			t.Fatal(err)
+		}
+		task, ok := doc.Components.Schemas["Task"]
+		if !ok {
+			t.Fatal("缺少 Task 组件")
+		}
+		if task.Properties["task_key"]["type"] != "string" {
+			t.Errorf("task_key 期望 string, 得到 %v", task.Properties["task_key"])
+		}
+		if task.Properties["created_at"]["format"] != "date-time" {
+			t.Errorf("created_at 期望 date-time, 得到 %v", task.Properties["created_at"])
+		}
+		for _, ref := range strings.Split(string(b), `"$ref":"#/components/schemas/`)[1:] {
+			name := ref[:strings.IndexByte(ref, '"')]
+			if _, ok := doc.Components.Schemas[name]; !ok {
+				t.Errorf("引用了不存在的组件 %s", name)
+			}
+		}
+	})
+}
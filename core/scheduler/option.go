@@ -0,0 +1,446 @@
+package scheduler
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/attempt"
+	"github.com/xyzbit/minitaskx/core/components/audit"
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/components/lock"
+	"github.com/xyzbit/minitaskx/core/components/notify"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo/archive"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type options struct {
+	// interval of purging soft deleted tasks.
+	tombstoneGCInterval time.Duration
+
+	// alerter of SLA breach, SLA is not evaluated if nil.
+	alerter          notify.Interface
+	slaCheckInterval time.Duration
+
+	// audit trail of want status changes, optional.
+	auditor audit.Interface
+
+	// run attempts recorded by workers, optional.
+	attemptRepo attempt.Interface
+
+	// events of tasks emitted by scheduler and workers, optional.
+	eventRecorder events.Interface
+
+	// interval of reconciling replicas of services.
+	serviceSyncInterval time.Duration
+
+	// canary rollout of executor versions and interval of checking their failure rate.
+	canaryPolicies      []CanaryPolicy
+	canaryCheckInterval time.Duration
+
+	// max tasks assigned to workers per biz type, tasks over quota are unschedulable.
+	quotas map[string]int
+	// monthly cost budget per biz type, new tasks over budget are unschedulable.
+	budgets map[string]float64
+	// task type => cost of one runtime second, default 1.
+	costWeights map[string]float64
+	// interval of accounting usage of biz types.
+	usageInterval time.Duration
+	// interval of retrying unschedulable tasks, they are also retried once workers change.
+	unschedulableRetryInterval time.Duration
+
+	// interval of creating follow-ups of finished tasks.
+	followUpCheckInterval time.Duration
+
+	// interval of applying due scheduled changes of tasks.
+	scheduledChangeCheckInterval time.Duration
+
+	// interval of retrying failed tasks and default backoff of retry policies.
+	retryCheckInterval  time.Duration
+	retryInitialBackoff time.Duration
+	retryMaxBackoff     time.Duration
+	// max retries per minute per biz type, "" applies to biz types not listed.
+	retryBudgets map[string]int
+
+	// fallback polling interval of watch apis.
+	watchPollInterval time.Duration
+
+	// only log placement decisions of pending tasks, nothing is persisted.
+	dryRun bool
+
+	// lock of exclusion groups shared by schedulers.
+	locker lock.Interface
+
+	// calendars and blackouts gating dispatch.
+	windows []Window
+
+	// bound of each repo call, zero means only deadlines of ctx apply.
+	repoTimeouts taskrepo.Timeouts
+
+	// interval of evaluating gates of pending tasks, and db running predicates of sql gates.
+	gateCheckInterval time.Duration
+	gateDB            *sql.DB
+	gatePredicates    map[string]string
+	// hosts http gates may call.
+	gateHosts map[string]bool
+
+	// tasks waiting approval longer than it are rejected, zero never rejects.
+	approvalTimeout time.Duration
+
+	// serves tasks purged from repo, nil disables it.
+	archive *archive.Reader
+
+	// generates keys of tasks created without a key.
+	keyGenerator KeyGenerator
+
+	// namespaces whose tasks only run on workers dedicated to them.
+	isolatedNamespaces map[string]bool
+
+	// named priorities referenced by tasks.
+	priorityClasses []model.PriorityClass
+
+	// wait of unassigned tasks alerted as starvation, zero disables alerts.
+	starvationThreshold time.Duration
+
+	// strategy selecting workers of tasks.
+	placement PlacementStrategy
+
+	// interval of estimating run durations from history.
+	estimateInterval time.Duration
+	// max run time of tasks without SLA is factor times their p95 run
+	// duration estimated from at least min samples, zero factor disables it.
+	estimatedSLAFactor     float64
+	estimatedSLAMinSamples int
+}
+
+type Option func(o *options)
+
+func WithTombstoneGCInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.tombstoneGCInterval = interval
+	}
+}
+
+func WithSLAAlerter(alerter notify.Interface, checkInterval time.Duration) Option {
+	return func(o *options) {
+		o.alerter = alerter
+		o.slaCheckInterval = checkInterval
+	}
+}
+
+func WithServiceSyncInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.serviceSyncInterval = interval
+	}
+}
+
+// WithCanary routes tasks by executor version reported by workers,
+// policies can also be changed at runtime by Scheduler.SetCanary.
+func WithCanary(checkInterval time.Duration, policies ...CanaryPolicy) Option {
+	return func(o *options) {
+		o.canaryCheckInterval = checkInterval
+		o.canaryPolicies = append(o.canaryPolicies, policies...)
+	}
+}
+
+// WithBizTypeQuota limits number of tasks of bizType running on workers at the same time.
+func WithBizTypeQuota(bizType string, maxRunning int) Option {
+	return func(o *options) {
+		if o.quotas == nil {
+			o.quotas = make(map[string]int)
+		}
+		o.quotas[bizType] = maxRunning
+	}
+}
+
+// WithBizTypeBudget stops scheduling new tasks of bizType once its cost in the
+// current month reaches budget, tasks already scheduled once keep running.
+// cost is runtime seconds of executors weighted by WithCostWeight.
+func WithBizTypeBudget(bizType string, budget float64) Option {
+	return func(o *options) {
+		if o.budgets == nil {
+			o.budgets = make(map[string]float64)
+		}
+		o.budgets[bizType] = budget
+	}
+}
+
+// WithCostWeight sets cost of one runtime second of tasks of taskType, default 1.
+func WithCostWeight(taskType string, weight float64) Option {
+	return func(o *options) {
+		if o.costWeights == nil {
+			o.costWeights = make(map[string]float64)
+		}
+		o.costWeights[taskType] = weight
+	}
+}
+
+// WithUsageInterval sets interval of accounting usage of biz types, budgets
+// are enforced with usage at most this old.
+func WithUsageInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.usageInterval = interval
+	}
+}
+
+func WithUnschedulableRetryInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.unschedulableRetryInterval = interval
+	}
+}
+
+// WithRetryBackoff sets default backoff of retry policies not declaring it.
+func WithRetryBackoff(initial, max time.Duration) Option {
+	return func(o *options) {
+		o.retryInitialBackoff = initial
+		o.retryMaxBackoff = max
+	}
+}
+
+// WithRetryBudget limits retries of failed tasks of bizType to perMinute in
+// any minute, so that a systemic failure downstream is not amplified by
+// mass retries. tasks over budget stay failed until the budget frees up.
+// bizType "" applies to biz types without their own budget.
+func WithRetryBudget(bizType string, perMinute int) Option {
+	return func(o *options) {
+		if o.retryBudgets == nil {
+			o.retryBudgets = make(map[string]int)
+		}
+		o.retryBudgets[bizType] = perMinute
+	}
+}
+
+func WithRetryCheckInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.retryCheckInterval = interval
+	}
+}
+
+// WithFollowUpCheckInterval sets interval of creating follow-ups of finished tasks.
+func WithFollowUpCheckInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.followUpCheckInterval = interval
+	}
+}
+
+// WithScheduledChangeCheckInterval sets interval of applying due scheduled
+// changes of tasks, changes are applied at most this late.
+func WithScheduledChangeCheckInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.scheduledChangeCheckInterval = interval
+	}
+}
+
+// WithWatchPollInterval sets how often watch apis reload tasks besides
+// changes reported by repo watch.
+func WithWatchPollInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.watchPollInterval = interval
+	}
+}
+
+// WithDryRun makes the scheduler only log which worker pending tasks would be assigned to.
+func WithDryRun() Option {
+	return func(o *options) {
+		o.dryRun = true
+	}
+}
+
+// WithAttemptRepo adds the latest attempt to the view of GetTask,
+// it should be the same repo passed to workers.
+func WithAttemptRepo(repo attempt.Interface) Option {
+	return func(o *options) {
+		o.attemptRepo = repo
+	}
+}
+
+func WithAuditor(auditor audit.Interface) Option {
+	return func(o *options) {
+		o.auditor = auditor
+	}
+}
+
+// WithEventRecorder emits events of scheduling to recorder and serves events
+// of tasks through ListEvents, it should be the same recorder passed to workers.
+func WithEventRecorder(recorder events.Interface) Option {
+	return func(o *options) {
+		o.eventRecorder = recorder
+	}
+}
+
+// WithGateCheckInterval sets interval of evaluating gates of pending tasks.
+func WithGateCheckInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.gateCheckInterval = interval
+	}
+}
+
+// WithGatePredicates registers named queries for sql gates, a query returns a
+// single boolean row and gets args of the gate as parameters, eg.
+//
+//	"partition-ready": "SELECT COUNT(*) > 0 FROM partitions WHERE day = ?"
+//
+// tasks can only reference registered predicates, they never carry sql.
+func WithGatePredicates(db *sql.DB, predicates map[string]string) Option {
+	return func(o *options) {
+		o.gateDB = db
+		o.gatePredicates = predicates
+	}
+}
+
+// WithGateHosts allows http gates and http pre-stop hooks to call hosts, a
+// host is a hostname matching any port or a host:port, eg. "ci.internal" or
+// "10.0.0.1:8080". tasks calling other hosts are rejected, so they can not
+// make the scheduler or workers request arbitrary addresses.
+func WithGateHosts(hosts ...string) Option {
+	return func(o *options) {
+		if o.gateHosts == nil {
+			o.gateHosts = make(map[string]bool, len(hosts))
+		}
+		for _, host := range hosts {
+			o.gateHosts[strings.ToLower(host)] = true
+		}
+	}
+}
+
+// WithApprovalTimeout rejects tasks not approved within timeout after created.
+func WithApprovalTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.approvalTimeout = timeout
+	}
+}
+
+// WithLocker backs exclusion groups by a distributed lock, so that they
+// survive leader changes. default lock.Memory only protects within the leader.
+func WithLocker(l lock.Interface) Option {
+	return func(o *options) {
+		o.locker = l
+	}
+}
+
+// WithWindows gates dispatch of tasks by calendar and blackout windows,
+// windows can also be changed by Scheduler.SetWindow at runtime.
+func WithWindows(windows ...Window) Option {
+	return func(o *options) {
+		o.windows = append(o.windows, windows...)
+	}
+}
+
+// WithRepoTimeouts bounds each read and write of task repo.
+func WithRepoTimeouts(t taskrepo.Timeouts) Option {
+	return func(o *options) {
+		o.repoTimeouts = t
+	}
+}
+
+// WithArchive serves tasks purged from repo from archive, GetTask falls back
+// to it and ListTask lists it with TaskFilter.Archived. batches are indexed
+// once Run starts and rescanned in the background on misses.
+func WithArchive(r *archive.Reader) Option {
+	return func(o *options) {
+		o.archive = r
+	}
+}
+
+// WithKeyGenerator generates keys of tasks created without a key supplied
+// by callers by g, eg. UUIDv7Keys or SnowflakeKeys. default UUIDKeys.
+func WithKeyGenerator(g KeyGenerator) Option {
+	return func(o *options) {
+		o.keyGenerator = g
+	}
+}
+
+// WithIsolatedNamespaces isolates tasks of namespaces onto workers dedicated
+// to them, see worker.WithNamespaces, so that noisy tenants never share
+// workers with others. tasks are unschedulable while their pool is empty.
+func WithIsolatedNamespaces(namespaces ...string) Option {
+	return func(o *options) {
+		if o.isolatedNamespaces == nil {
+			o.isolatedNamespaces = make(map[string]bool)
+		}
+		for _, ns := range namespaces {
+			o.isolatedNamespaces[ns] = true
+		}
+	}
+}
+
+// WithPriorityClasses sets priority classes referenced by tasks, priority of
+// tasks is set by their class and tasks waiting for assignment are ordered
+// by weights of their classes.
+func WithPriorityClasses(classes ...model.PriorityClass) Option {
+	return func(o *options) {
+		o.priorityClasses = append(o.priorityClasses, classes...)
+	}
+}
+
+// WithStarvationThreshold sets how long a task waits without assignment
+// before it is reported as starving by an event and metrics, default 10m,
+// zero disables the reports but wait time is still exported.
+func WithStarvationThreshold(threshold time.Duration) Option {
+	return func(o *options) {
+		o.starvationThreshold = threshold
+	}
+}
+
+// WithPlacementStrategy sets how workers of tasks are selected, default
+// PlacementSpread. run durations of PlacementBinPack are estimated from
+// history, see WithEstimateInterval.
+func WithPlacementStrategy(strategy PlacementStrategy) Option {
+	return func(o *options) {
+		o.placement = strategy
+	}
+}
+
+// WithEstimateInterval sets interval of estimating run durations of task
+// types and biz types from their latest succeeded runs, default 5m.
+func WithEstimateInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.estimateInterval = interval
+	}
+}
+
+// WithEstimatedSLA alerts tasks not declaring SLA once they run longer than
+// factor times the p95 run duration of tasks of the same type and biz type,
+// estimates of less than minSamples runs are not trusted. SLA is only
+// evaluated with WithSLAAlerter.
+func WithEstimatedSLA(factor float64, minSamples int) Option {
+	return func(o *options) {
+		o.estimatedSLAFactor = factor
+		o.estimatedSLAMinSamples = minSamples
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	// set default
+	o := options{
+		tombstoneGCInterval: 5 * time.Minute,
+		slaCheckInterval:    30 * time.Second,
+		serviceSyncInterval: 10 * time.Second,
+		canaryCheckInterval: 30 * time.Second,
+
+		unschedulableRetryInterval: 30 * time.Second,
+		watchPollInterval:          time.Second,
+
+		retryCheckInterval:  5 * time.Second,
+		retryInitialBackoff: 10 * time.Second,
+		retryMaxBackoff:     10 * time.Minute,
+
+		gateCheckInterval: 10 * time.Second,
+		usageInterval:     5 * time.Minute,
+		estimateInterval:  5 * time.Minute,
+
+		followUpCheckInterval:        5 * time.Second,
+		scheduledChangeCheckInterval: 10 * time.Second,
+		starvationThreshold:          10 * time.Minute,
+
+		locker:       lock.NewMemory(),
+		keyGenerator: UUIDKeys(),
+		placement:    PlacementSpread,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &o
+}
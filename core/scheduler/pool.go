@@ -0,0 +1,34 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// poolReason returns why the worker can not run the task by namespace pools,
+// empty if it can. workers dedicated to namespaces only run tasks of them,
+// tasks of isolated namespaces only run on workers dedicated to them.
+func (s *Scheduler) poolReason(task *model.Task, worker discover.Instance) string {
+	ns := task.Namespace()
+	dedicated, ok := model.ParseNamespaces(worker.Metadata)
+	switch {
+	case ok && !dedicated[ns]:
+		return fmt.Sprintf("worker 专属于命名空间 %s, 任务命名空间为 %q", worker.Metadata[model.NamespacesKey], ns)
+	case !ok && s.opts.isolatedNamespaces[ns]:
+		return fmt.Sprintf("命名空间 %s 已隔离, 只能运行在其专属 worker 上", ns)
+	}
+	return ""
+}
+
+// routePool keeps workers of the namespace pool of task.
+func (s *Scheduler) routePool(task *model.Task, workers []discover.Instance) []discover.Instance {
+	ret := make([]discover.Instance, 0, len(workers))
+	for _, worker := range workers {
+		if s.poolReason(task, worker) == "" {
+			ret = append(ret, worker)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestNamespacePools(t *testing.T) {
+	workers := []discover.Instance{
+		{InstanceId: "shared"},
+		{InstanceId: "ingest", Metadata: map[string]string{model.NamespacesKey: "ingest"}},
+		{InstanceId: "batch", Metadata: map[string]string{model.NamespacesKey: "report,billing"}},
+	}
+	tests := []struct {
+		name     string
+		isolated []string
+		task     *model.Task
+		want     []string
+	}{
+		{
+			name: "未隔离的命名空间运行在共享及专属 worker 上",
+			task: &model.Task{BizType: "ingest"},
+			want: []string{"shared", "ingest"},
+		},
+		{
+			name:     "隔离的命名空间只运行在专属 worker 上",
+			isolated: []string{"ingest"},
+			task:     &model.Task{BizType: "ingest"},
+			want:     []string{"ingest"},
+		},
+		{
+			name:     "标签指定的命名空间优先于业务类型",
+			isolated: []string{"billing"},
+			task:     &model.Task{BizType: "ingest", Labels: map[string]string{model.LabelNamespace: "billing"}},
+			want:     []string{"batch"},
+		},
+		{
+			name: "专属 worker 不运行其他命名空间的任务",
+			task: &model.Task{BizType: "email"},
+			want: []string{"shared"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scheduler{opts: newOptions(WithIsolatedNamespaces(tt.isolated...))}
+			var got []string
+			for _, w := range s.routePool(tt.task, workers) {
+				got = append(got, w.ID())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("routePool() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("routePool() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	t.Run("专属 worker 池为空时不可调度", func(t *testing.T) {
+		s := &Scheduler{opts: newOptions(WithIsolatedNamespaces("ingest"))}
+		s.setAvailableWorkers(workers[:1])
+		_, err := s.selectWorkerID(&model.Task{BizType: "ingest"})
+		var e *unschedulableError
+		if !errors.As(err, &e) || e.code != model.UnschedulableNoMatchingLabels {
+			t.Fatalf("want unschedulable, got %v", err)
+		}
+	})
+}
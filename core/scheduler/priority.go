@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// ListPriorityClasses returns priority classes configured, sorted by value descending.
+func (s *Scheduler) ListPriorityClasses() []model.PriorityClass {
+	classes := slices.Clone(s.opts.priorityClasses)
+	slices.SortStableFunc(classes, func(a, b model.PriorityClass) int {
+		return cmp.Compare(b.Value, a.Value)
+	})
+	return classes
+}
+
+// priorityClass returns the class of name, or the default class if name is empty.
+func (s *Scheduler) priorityClass(name string) (model.PriorityClass, bool) {
+	for _, c := range s.opts.priorityClasses {
+		if c.Name == name || (name == "" && c.Default) {
+			return c, true
+		}
+	}
+	return model.PriorityClass{}, false
+}
+
+// resolvePriorityClass sets priority and preemption of task by its class,
+// tasks without a class are of the default class if any.
+func (s *Scheduler) resolvePriorityClass(task *model.Task) error {
+	c, ok := s.priorityClass(task.PriorityClass)
+	if !ok {
+		if task.PriorityClass != "" {
+			return errors.Errorf("priority class %q not found", task.PriorityClass)
+		}
+		return nil
+	}
+	task.PriorityClass = c.Name
+	task.Priority = c.Value
+	if c.Preemption == model.PreemptNever {
+		task.Labels = withLabel(task, model.LabelPreemption, string(model.PreemptNever))
+	}
+	return nil
+}
+
+// orderByPriorityClass orders tasks waiting for assignment by weighted round
+// robin of their classes, higher value classes first in each round, so that
+// tasks of low classes are not starved. tasks of a class are ordered by
+// effective priority, then creation time, tasks inheriting priority of a
+// higher class are queued in that class.
+func (s *Scheduler) orderByPriorityClass(tasks []*model.Task) []*model.Task {
+	if len(s.opts.priorityClasses) == 0 {
+		return tasks
+	}
+
+	queues := make(map[string][]*model.Task)
+	for _, task := range tasks {
+		// tasks created before classes are configured are of the default class.
+		c := s.effectiveClass(task)
+		queues[c.Name] = append(queues[c.Name], task)
+	}
+	names := make([]string, 0, len(queues))
+	for name, queue := range queues {
+		names = append(names, name)
+		slices.SortStableFunc(queue, func(a, b *model.Task) int {
+			if pa, pb := a.EffectivePriority(), b.EffectivePriority(); pa != pb {
+				return cmp.Compare(pb, pa)
+			}
+			return a.CreatedAt.Compare(b.CreatedAt)
+		})
+	}
+	value := func(name string) int {
+		c, _ := s.priorityClass(name)
+		return c.Value
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if c := cmp.Compare(value(b), value(a)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+
+	ret := make([]*model.Task, 0, len(tasks))
+	for len(ret) < len(tasks) {
+		for _, name := range names {
+			c, _ := s.priorityClass(name)
+			n := min(max(c.Weight, 1), len(queues[name]))
+			ret = append(ret, queues[name][:n]...)
+			queues[name] = queues[name][n:]
+		}
+	}
+	return ret
+}
+
+// effectiveClass returns the class of task, or the highest class its
+// effective priority reaches if that's higher, see model.GateTask.
+func (s *Scheduler) effectiveClass(task *model.Task) model.PriorityClass {
+	c, _ := s.priorityClass(task.PriorityClass)
+	p := task.EffectivePriority()
+	for _, other := range s.opts.priorityClasses {
+		if other.Value > c.Value && other.Value <= p {
+			c = other
+		}
+	}
+	return c
+}
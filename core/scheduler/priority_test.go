@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestPriorityClasses(t *testing.T) {
+	classes := []model.PriorityClass{
+		{Name: "batch", Value: 0, Default: true},
+		{Name: "critical", Value: 1000, Preemption: model.PreemptNever, Weight: 2},
+	}
+	s := &Scheduler{opts: newOptions(WithPriorityClasses(classes...))}
+
+	t.Run("校验优先级类别", func(t *testing.T) {
+		if err := model.ValidatePriorityClasses(classes); err != nil {
+			t.Fatal(err)
+		}
+		if err := model.ValidatePriorityClasses(append(classes, model.PriorityClass{Name: "batch"})); err == nil {
+			t.Error("duplicate names should be rejected")
+		}
+		if err := model.ValidatePriorityClasses(append(classes, model.PriorityClass{Name: "x", Default: true})); err == nil {
+			t.Error("more than one default class should be rejected")
+		}
+	})
+
+	t.Run("按类别设置优先级和抢占策略", func(t *testing.T) {
+		task := &model.Task{PriorityClass: "critical", Priority: 1}
+		if err := s.resolvePriorityClass(task); err != nil {
+			t.Fatal(err)
+		}
+		if task.Priority != 1000 || task.Preemptible() {
+			t.Errorf("critical task should be of priority 1000 and not preemptible: %d %v", task.Priority, task.Labels)
+		}
+
+		task = &model.Task{}
+		if err := s.resolvePriorityClass(task); err != nil {
+			t.Fatal(err)
+		}
+		if task.PriorityClass != "batch" || !task.Preemptible() {
+			t.Errorf("task without class should be of the default class: %+v", task)
+		}
+
+		if err := s.resolvePriorityClass(&model.Task{PriorityClass: "unknown"}); err == nil {
+			t.Error("unknown class should be rejected")
+		}
+	})
+
+	t.Run("按权重轮流分配各类别的任务", func(t *testing.T) {
+		now := time.Now()
+		var tasks []*model.Task
+		for i, class := range []string{"batch", "batch", "batch", "critical", "critical", "critical", ""} {
+			tasks = append(tasks, &model.Task{TaskKey: class + string(rune('0'+i)), PriorityClass: class, CreatedAt: now.Add(time.Duration(i) * time.Second)})
+		}
+		var got []string
+		for _, task := range s.orderByPriorityClass(tasks) {
+			got = append(got, task.TaskKey)
+		}
+		want := []string{"critical3", "critical4", "batch0", "critical5", "batch1", "batch2", "6"}
+		if len(got) != len(want) {
+			t.Fatalf("orderByPriorityClass() = %v, want %v", got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("orderByPriorityClass() = %v, want %v", got, want)
+			}
+		}
+	})
+}
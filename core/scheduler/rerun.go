@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/audit"
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// RerunTask schedules a finished task again, it is the only api moving a task
+// out of its final status. the task may be assigned to another worker.
+func (s *Scheduler) RerunTask(ctx context.Context, bizID, taskKey, reason, operator string) error {
+	task, err := s.findTask(ctx, bizID, taskKey)
+	if err != nil {
+		return err
+	}
+	if err := auth.CheckBizType(ctx, task.BizType); err != nil {
+		return err
+	}
+	if !task.Status.IsFinalStatus() {
+		return errors.Errorf("任务[%s]当前状态为 %s, 未结束不能重新运行", taskKey, task.Status)
+	}
+	if task.IsDeleted() || task.IsService() {
+		return errors.Errorf("任务[%s]已删除或是服务, 不能重新运行", taskKey)
+	}
+
+	now := time.Now()
+	applied, err := s.taskRepo.UpdateTaskCAS(taskrepo.Rerun(ctx), task.Status, &model.Task{
+		TaskKey:       taskKey,
+		Status:        model.TaskStatusWaitScheduling,
+		WantRunStatus: model.TaskStatusRunning,
+		NextRunAt:     &now,
+		Operator:      operator,
+		Msg:           fmt.Sprintf("rerun by %s: %s", operator, reason),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !applied {
+		return errors.Errorf("任务[%s]状态已被并发修改, 请重试", taskKey)
+	}
+
+	s.audit(ctx, audit.Entry{
+		TaskKey:  taskKey,
+		Operator: operator,
+		Action:   audit.ActionRerun,
+		From:     task.Status.String(),
+		To:       model.TaskStatusWaitScheduling.String(),
+		Reason:   reason,
+	})
+	s.triggerReAssignEvent()
+	return nil
+}
@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo/memory"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestRerunTask(t *testing.T) {
+	ctx := context.Background()
+	newRepo := func() taskrepo.Interface {
+		repo := taskrepo.WithFinalGuard(memory.New())
+		if err := repo.CreateTask(ctx, &model.Task{TaskKey: "a", Status: model.TaskStatusFailed, WantRunStatus: model.TaskStatusRunning}); err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	}
+
+	t.Run("状态和下次运行时间一起写入", func(t *testing.T) {
+		repo := newRepo()
+		s := &Scheduler{taskRepo: repo, opts: newOptions(), assignEvent: make(chan struct{}, 1)}
+		if err := s.RerunTask(ctx, "", "a", "fixed", "bob"); err != nil {
+			t.Fatal(err)
+		}
+		task, _ := repo.GetTask(ctx, "a")
+		if task.Status != model.TaskStatusWaitScheduling || task.NextRunAt == nil || task.Operator != "bob" {
+			t.Fatalf("期望 wait_scheduling 且设置下次运行时间, 得到 %s %v %s", task.Status, task.NextRunAt, task.Operator)
+		}
+	})
+
+	t.Run("状态已被并发修改时不写入", func(t *testing.T) {
+		repo := newRepo()
+		stale, _ := repo.GetTask(ctx, "a")
+		stale.Status = model.TaskStatusSuccess
+		s := &Scheduler{taskRepo: &staleRepo{Interface: repo, stale: map[string]*model.Task{"a": stale}}, opts: newOptions(), assignEvent: make(chan struct{}, 1)}
+		if err := s.RerunTask(ctx, "", "a", "fixed", "bob"); err == nil {
+			t.Fatal("期望 CAS 失败")
+		}
+		task, _ := repo.GetTask(ctx, "a")
+		if task.Status != model.TaskStatusFailed || task.NextRunAt != nil || task.Operator != "" {
+			t.Fatalf("CAS 失败后任务不应变化, 得到 %s %v %s", task.Status, task.NextRunAt, task.Operator)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/audit"
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// max failed tasks retried in one pass.
+const retryBatchSize = 500
+
+// runRetryController retries failed tasks with backoff, only leader works.
+func (s *Scheduler) runRetryController() {
+	ticker := time.NewTicker(s.opts.retryCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		amILeader, _, err := s.amILeader()
+		if err != nil {
+			log.Error("获取 leader 状态失败: %v", err)
+			continue
+		}
+		if !amILeader {
+			continue
+		}
+
+		if err := s.retryFailedTasks(context.Background()); err != nil {
+			log.Error("失败任务重试失败: %v", err)
+		}
+	}
+}
+
+func (s *Scheduler) retryFailedTasks(ctx context.Context) error {
+	tasks, err := s.taskRepo.ListTask(ctx, &model.TaskFilter{
+		Labels:   map[string]string{model.LabelRetry: model.RetryPending},
+		Statuses: []model.TaskStatus{model.TaskStatusFailed},
+		Limit:    retryBatchSize,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	now := time.Now()
+	held := make(map[string]string)
+	for _, task := range tasks {
+		if !task.CanRetry() {
+			// keep exhausted tasks out of later passes.
+			if err := s.exhaustRetries(ctx, task); err != nil {
+				log.Error("任务[%s]标记重试耗尽失败: %v", task.TaskKey, err)
+			}
+			continue
+		}
+		if reason, ok := s.takeRetryBudget(task, now); !ok {
+			held[task.TaskKey] = reason
+			continue
+		}
+		if err := s.retryTask(ctx, task); err != nil {
+			log.Error("任务[%s]重试失败: %v", task.TaskKey, err)
+		}
+	}
+	s.holdRetries(ctx, held)
+	return nil
+}
+
+// retryTask hands the failed task back to its worker after backoff,
+// the task is not runnable until NextRunAt so it holds no worker slot meanwhile.
+func (s *Scheduler) retryTask(ctx context.Context, task *model.Task) error {
+	retries := task.Retries + 1
+	backoff := s.retryPolicy(task).Backoff(retries)
+	nextRunAt := time.Now().Add(backoff)
+
+	// retry is a rerun of the failed task made by the system, NextRunAt is
+	// advanced in the same CAS so the task is not runnable before backoff.
+	applied, err := s.taskRepo.UpdateTaskCAS(taskrepo.Rerun(ctx), model.TaskStatusFailed, &model.Task{
+		TaskKey:       task.TaskKey,
+		Status:        model.TaskStatusRunning.PreWaitStatus(),
+		Retries:       retries,
+		NextRunAt:     &nextRunAt,
+		WantRunStatus: model.TaskStatusRunning,
+		Operator:      model.OperatorScheduler,
+		Msg:           fmt.Sprintf("retry %d/%d after %s: %s", retries, task.Retry.MaxRetries, backoff, task.Msg),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !applied {
+		return errors.Errorf("任务[%s]状态已被并发修改", task.TaskKey)
+	}
+
+	log.Info("任务[%s]第 %d 次重试, %s 后运行", task.TaskKey, retries, backoff)
+	s.audit(ctx, audit.Entry{
+		TaskKey:  task.TaskKey,
+		Operator: model.OperatorScheduler,
+		Action:   audit.ActionRetry,
+		From:     strconv.Itoa(task.Retries),
+		To:       strconv.Itoa(retries),
+		Reason:   task.Msg,
+	})
+	s.event(ctx, events.Event{
+		TaskKey: task.TaskKey,
+		Type:    events.TypeWarning,
+		Reason:  events.ReasonBackOff,
+		Message: fmt.Sprintf("back-off %s restarting failed task, retry %d/%d", backoff, retries, task.Retry.MaxRetries),
+	})
+	return nil
+}
+
+// exhaustRetries marks the failed task not retried anymore.
+func (s *Scheduler) exhaustRetries(ctx context.Context, task *model.Task) error {
+	labels := withLabel(task, model.LabelRetry, model.RetryExhausted)
+	return errors.WithStack(s.taskRepo.UpdateTask(ctx, &model.Task{TaskKey: task.TaskKey, Labels: labels}))
+}
+
+// retryPolicy fills backoff of task policy with defaults of scheduler.
+func (s *Scheduler) retryPolicy(task *model.Task) model.RetryPolicy {
+	p := *task.Retry
+	if p.InitialBackoffSeconds <= 0 {
+		p.InitialBackoffSeconds = int64(s.opts.retryInitialBackoff / time.Second)
+	}
+	if p.MaxBackoffSeconds <= 0 {
+		p.MaxBackoffSeconds = int64(s.opts.retryMaxBackoff / time.Second)
+	}
+	return p
+}
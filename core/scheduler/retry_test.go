@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestRetryFailedTasks(t *testing.T) {
+	ctx := context.Background()
+	pending := map[string]string{model.LabelRetry: model.RetryPending}
+
+	t.Run("创建时标记可重试", func(t *testing.T) {
+		repo := &createRepo{}
+		s := &Scheduler{taskRepo: repo, opts: newOptions()}
+		if err := s.createTask(ctx, &model.Task{TaskKey: "a", Retry: &model.RetryPolicy{MaxRetries: 1}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.createTask(ctx, &model.Task{TaskKey: "b"}); err != nil {
+			t.Fatal(err)
+		}
+		if repo.tasks[0].Labels[model.LabelRetry] != model.RetryPending || repo.tasks[1].Labels[model.LabelRetry] != "" {
+			t.Fatalf("期望仅有重试策略的任务被标记, 得到 %v %v", repo.tasks[0].Labels, repo.tasks[1].Labels)
+		}
+	})
+
+	t.Run("重试耗尽的任务不阻塞后续任务", func(t *testing.T) {
+		var tasks []*model.Task
+		for i := 0; i < retryBatchSize; i++ {
+			tasks = append(tasks, &model.Task{
+				TaskKey: fmt.Sprintf("exhausted-%d", i),
+				Status:  model.TaskStatusFailed,
+				Retry:   &model.RetryPolicy{MaxRetries: 1},
+				Retries: 1,
+				Labels:  pending,
+			})
+		}
+		// failed without a policy, never listed.
+		tasks = append(tasks, &model.Task{TaskKey: "no-policy", Status: model.TaskStatusFailed})
+		fresh := &model.Task{TaskKey: "fresh", Status: model.TaskStatusFailed, Retry: &model.RetryPolicy{MaxRetries: 1}, Labels: pending}
+		tasks = append(tasks, fresh)
+		repo := &statusRepo{getRepo{listRepo{tasks: tasks}}}
+		s := &Scheduler{taskRepo: repo, opts: newOptions()}
+
+		if err := s.retryFailedTasks(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if tasks[0].Labels[model.LabelRetry] != model.RetryExhausted {
+			t.Fatalf("期望标记重试耗尽, 得到 %v", tasks[0].Labels)
+		}
+		if err := s.retryFailedTasks(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if fresh.Status != model.TaskStatusWaitRunning || fresh.WantRunStatus != model.TaskStatusRunning {
+			t.Fatalf("期望重试新失败的任务, 得到 %s/%s", fresh.Status, fresh.WantRunStatus)
+		}
+	})
+
+	t.Run("CAS 失败时不写入重试", func(t *testing.T) {
+		task := &model.Task{TaskKey: "a", Status: model.TaskStatusWaitRunning, Retry: &model.RetryPolicy{MaxRetries: 3}}
+		repo := &statusRepo{getRepo{listRepo{tasks: []*model.Task{task}}}}
+		s := &Scheduler{taskRepo: repo, opts: newOptions()}
+
+		stale := task.Clone()
+		stale.Status = model.TaskStatusFailed
+		if err := s.retryTask(ctx, stale); err == nil {
+			t.Fatal("期望状态已变化时重试失败")
+		}
+		if task.WantRunStatus != "" || task.NextRunAt != nil {
+			t.Fatalf("CAS 失败后任务不应变化, 得到 %+v", task)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// window retries are counted in against budgets.
+const retryBudgetWindow = time.Minute
+
+// retryBudget counts retries of biz types in the last window, it's kept in
+// memory of the leader running the retry controller. the zero value is usable.
+type retryBudget struct {
+	mu sync.Mutex
+	// biz type => times of retries in the last window, oldest first.
+	retried map[string][]time.Time
+	// task key => why the failed task is not retried, tasks of the last pass.
+	held map[string]string
+}
+
+// take counts a retry of bizType at now if fewer than limit retries are
+// counted in the window before, otherwise it returns why not.
+func (b *retryBudget) take(bizType string, limit int, now time.Time) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	times := b.retried[bizType]
+	i := 0
+	for i < len(times) && !times[i].After(now.Add(-retryBudgetWindow)) {
+		i++
+	}
+	times = times[i:]
+	if len(times) >= limit {
+		b.retried[bizType] = times
+		return fmt.Sprintf("%d retries of biz type %s in the last %s, budget %d", len(times), bizType, retryBudgetWindow, limit), false
+	}
+	if b.retried == nil {
+		b.retried = make(map[string][]time.Time)
+	}
+	b.retried[bizType] = append(times, now)
+	return "", true
+}
+
+// hold replaces tasks held by the budget, it returns tasks newly held.
+func (b *retryBudget) hold(held map[string]string) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var added []string
+	for key := range held {
+		if _, ok := b.held[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	b.held = held
+	return added
+}
+
+// reason returns why the task is held by the budget, false if it's not.
+func (b *retryBudget) reason(taskKey string) (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	reason, ok := b.held[taskKey]
+	return reason, ok
+}
+
+// retryBudgetOf returns retries allowed per window of bizType, false if unlimited.
+func (s *Scheduler) retryBudgetOf(bizType string) (int, bool) {
+	if limit, ok := s.opts.retryBudgets[bizType]; ok {
+		return limit, true
+	}
+	limit, ok := s.opts.retryBudgets[""]
+	return limit, ok
+}
+
+// takeRetryBudget counts a retry of task against the budget of its biz type,
+// it returns why the task can't be retried now.
+func (s *Scheduler) takeRetryBudget(task *model.Task, now time.Time) (string, bool) {
+	limit, ok := s.retryBudgetOf(task.BizType)
+	if !ok {
+		return "", true
+	}
+	return s.retryBudget.take(task.BizType, limit, now)
+}
+
+// holdRetries records tasks held by budgets in a pass of the retry
+// controller, tasks newly held get an event.
+func (s *Scheduler) holdRetries(ctx context.Context, held map[string]string) {
+	for _, key := range s.retryBudget.hold(held) {
+		log.Warn("任务[%s]重试超出预算: %s", key, held[key])
+		s.event(ctx, events.Event{
+			TaskKey: key,
+			Type:    events.TypeWarning,
+			Reason:  events.ReasonRetryBudgetExceeded,
+			Message: "waiting for retry budget: " + held[key],
+		})
+	}
+}
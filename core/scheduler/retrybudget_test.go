@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestRetryBudget(t *testing.T) {
+	failed := func(key, bizType string) *model.Task {
+		return &model.Task{
+			TaskKey: key,
+			BizType: bizType,
+			Status:  model.TaskStatusFailed,
+			Retry:   &model.RetryPolicy{MaxRetries: 3},
+			Labels:  map[string]string{model.LabelRetry: model.RetryPending},
+		}
+	}
+	repo := &statusRepo{getRepo{listRepo{tasks: []*model.Task{
+		failed("a1", "a"), failed("a2", "a"), failed("a3", "a"), failed("b1", "b"),
+	}}}}
+	s := &Scheduler{taskRepo: repo, opts: newOptions(WithRetryBudget("a", 2))}
+	ctx := context.Background()
+
+	t.Run("超出预算的任务等待重试", func(t *testing.T) {
+		if err := s.retryFailedTasks(ctx); err != nil {
+			t.Fatal(err)
+		}
+		var retried []string
+		for _, task := range repo.tasks {
+			if task.Status != model.TaskStatusFailed {
+				retried = append(retried, task.TaskKey)
+			}
+		}
+		if len(retried) != 3 || repo.tasks[2].Status != model.TaskStatusFailed {
+			t.Fatalf("期望重试 a1 a2 b1, 得到 %v", retried)
+		}
+
+		d, err := s.DescribeTask(ctx, "a3")
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := d.Conditions[len(d.Conditions)-1]
+		if c.Type != ConditionRetryBudgetExceeded || !c.Status || c.Reason == "" {
+			t.Fatalf("期望 a3 有 RetryBudgetExceeded 条件, 得到 %+v", c)
+		}
+	})
+
+	t.Run("窗口滑过后释放预算", func(t *testing.T) {
+		now := time.Now()
+		for range 2 {
+			if _, ok := s.retryBudget.take("a", 2, now.Add(retryBudgetWindow)); !ok {
+				t.Fatal("期望一分钟后预算释放")
+			}
+		}
+		if _, ok := s.retryBudget.take("a", 2, now.Add(retryBudgetWindow)); ok {
+			t.Fatal("期望预算再次用完")
+		}
+	})
+
+	t.Run("默认预算用于未配置的业务类型", func(t *testing.T) {
+		s := &Scheduler{opts: newOptions(WithRetryBudget("", 1), WithRetryBudget("a", 5))}
+		if limit, ok := s.retryBudgetOf("b"); !ok || limit != 1 {
+			t.Errorf("期望 b 使用默认预算 1, 得到 %d %v", limit, ok)
+		}
+		if limit, _ := s.retryBudgetOf("a"); limit != 5 {
+			t.Errorf("期望 a 使用自身预算 5, 得到 %d", limit)
+		}
+		if _, ok := (&Scheduler{opts: newOptions()}).retryBudgetOf("a"); ok {
+			t.Error("未配置预算时不限制重试")
+		}
+	})
+}
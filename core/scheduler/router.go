@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+)
+
+// RegisterRoutes register all http apis to r.
+// if authenticator is nil, apis are open to anyone can reach the port.
+func (s *HttpServer) RegisterRoutes(r gin.IRouter, authenticator auth.Authenticator) {
+	var middlewares []gin.HandlerFunc
+	if authenticator != nil {
+		middlewares = append(middlewares, auth.GinMiddleware(authenticator))
+	}
+
+	g := r.Group("/v1/tasks", middlewares...)
+	g.GET("/list", auth.GinRequireRole(auth.RoleViewer), s.ListTask)
+	g.GET("/get", auth.GinRequireRole(auth.RoleViewer), s.GetTask)
+	g.GET("/describe", auth.GinRequireRole(auth.RoleViewer), s.DescribeTask)
+	g.GET("/export", auth.GinRequireRole(auth.RoleViewer), s.ExportTasks)
+	g.GET("/audits", auth.GinRequireRole(auth.RoleViewer), s.ListAudits)
+	g.GET("/attempts", auth.GinRequireRole(auth.RoleViewer), s.ListAttempts)
+	g.GET("/events", auth.GinRequireRole(auth.RoleViewer), s.ListEvents)
+	g.GET("/watch", auth.GinRequireRole(auth.RoleViewer), s.WatchTasks)
+	g.GET("/dashboard", auth.GinRequireRole(auth.RoleViewer), s.Dashboard)
+	g.GET("/usage", auth.GinRequireRole(auth.RoleViewer), s.Usage)
+	g.GET("/estimates", auth.GinRequireRole(auth.RoleViewer), s.Estimates)
+	g.GET("/capabilities", auth.GinRequireRole(auth.RoleViewer), s.ListCapabilities)
+	g.GET("/priority-classes", auth.GinRequireRole(auth.RoleViewer), s.ListPriorityClasses)
+	g.POST("/graphql", auth.GinRequireRole(auth.RoleViewer), s.GraphQL)
+	g.POST("/preview-placement", auth.GinRequireRole(auth.RoleViewer), s.PreviewPlacement)
+	g.POST("/create", auth.GinRequireRole(auth.RoleOperator), s.CreateTask)
+	g.POST("/operate", auth.GinRequireRole(auth.RoleOperator), s.OperateTask)
+	g.POST("/rerun", auth.GinRequireRole(auth.RoleOperator), s.RerunTask)
+	g.POST("/schedule-changes", auth.GinRequireRole(auth.RoleOperator), s.ScheduleChanges)
+	g.POST("/update-spec", auth.GinRequireRole(auth.RoleOperator), s.UpdateTaskSpec)
+	g.PATCH("/metadata", auth.GinRequireRole(auth.RoleOperator), s.PatchTaskMetadata)
+	g.PATCH("/annotations", auth.GinRequireRole(auth.RoleOperator), s.PatchTaskAnnotations)
+	g.POST("/delete", auth.GinRequireRole(auth.RoleAdmin), s.DeleteTask)
+	g.POST("/import", auth.GinRequireRole(auth.RoleAdmin), s.ImportTasks)
+	g.POST("/bulk-stop", auth.GinRequireRole(auth.RoleAdmin), s.StopTasks)
+	g.POST("/bulk-pause", auth.GinRequireRole(auth.RoleAdmin), s.PauseTasks)
+	g.POST("/approve", auth.GinRequireRole(auth.RoleAdmin), s.ApproveTask)
+	g.POST("/reject", auth.GinRequireRole(auth.RoleAdmin), s.RejectTask)
+
+	admin := r.Group("/v1/admin", append(middlewares, auth.GinRequireRole(auth.RoleAdmin))...)
+	admin.POST("/force-finish", s.ForceFinishTask)
+	admin.POST("/force-reassign", s.ForceReassignTask)
+	admin.GET("/canaries", s.ListCanaries)
+	admin.POST("/canary", s.SetCanary)
+	admin.POST("/canary/remove", s.RemoveCanary)
+	admin.GET("/windows", s.ListWindows)
+	admin.POST("/window", s.SetWindow)
+	admin.POST("/window/remove", s.RemoveWindow)
+	admin.GET("/cordons", s.ListCordons)
+	admin.POST("/cordon", s.CordonWorker)
+	admin.POST("/uncordon", s.UncordonWorker)
+
+	// the document only describes apis, no need to authenticate.
+	r.GET("/openapi.json", s.OpenAPI)
+}
+
+func errorStatus(err error) int {
+	var forbidden *auth.ForbiddenError
+	switch {
+	case errors.As(err, &forbidden):
+		return http.StatusForbidden
+	case errors.Is(err, taskrepo.ErrTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, taskrepo.ErrDuplicateTask), errors.Is(err, taskrepo.ErrFinalStatus):
+		return http.StatusConflict
+	case errors.Is(err, ErrNotLeader):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,26 @@
+package scheduler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: errors.Wrap(taskrepo.ErrTaskNotFound, "get task"), want: http.StatusNotFound},
+		{err: errors.WithStack(taskrepo.ErrDuplicateTask), want: http.StatusConflict},
+		{err: errors.New("db down"), want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := errorStatus(tt.err); got != tt.want {
+			t.Errorf("%v 期望 %d, 得到 %d", tt.err, tt.want, got)
+		}
+	}
+}
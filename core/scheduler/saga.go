@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// continueSaga compensates steps before a failed step, and the step before
+// a compensated step once its compensation succeeds.
+func (s *Scheduler) continueSaga(ctx context.Context, task *model.Task) error {
+	switch {
+	case task.Labels[model.LabelCompensates] != "":
+		if task.Status != model.TaskStatusSuccess {
+			log.Error("[Saga] 补偿任务[%s]失败, 步骤[%s]之前的步骤不再补偿", task.TaskKey, task.Labels[model.LabelCompensates])
+			return nil
+		}
+		step, err := s.taskRepo.GetTask(ctx, task.Labels[model.LabelCompensates])
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return s.compensate(ctx, step.Labels[model.LabelParent])
+	case task.Status == model.TaskStatusFailed && task.Labels[model.LabelParent] != "":
+		log.Info("[Saga] 步骤[%s]失败, 开始补偿之前的步骤", task.TaskKey)
+		return s.compensate(ctx, task.Labels[model.LabelParent])
+	}
+	return nil
+}
+
+// compensate creates the compensation of the nearest succeeded step from
+// stepKey back to the head of the chain, steps without compensation are skipped.
+func (s *Scheduler) compensate(ctx context.Context, stepKey string) error {
+	for stepKey != "" {
+		step, err := s.taskRepo.GetTask(ctx, stepKey)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if step.Compensation == nil || step.Status != model.TaskStatusSuccess {
+			stepKey = step.Labels[model.LabelParent]
+			continue
+		}
+
+		c, err := step.NewCompensation()
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		c.NextRunAt = &now
+		c.TaskKey = compensationKey(step.TaskKey)
+		err = s.insertTask(ctx, c)
+		if errors.Is(err, taskrepo.ErrDuplicateTask) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		log.Info("[Saga] 创建步骤[%s]的补偿任务[%s]", step.TaskKey, c.TaskKey)
+		return nil
+	}
+	return nil
+}
+
+// compensationKey derives the key of the compensation of step.
+func compensationKey(step string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(step+"/compensation")).String()
+}
@@ -0,0 +1,159 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/audit"
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// max tasks of scheduled changes checked in one pass.
+const scheduledChangeBatchSize = 500
+
+// ScheduleChanges replaces pending scheduled changes of the task with changes,
+// empty changes cancel them. changes of past times are applied soon.
+func (s *Scheduler) ScheduleChanges(ctx context.Context, bizID, taskKey string, changes []model.ScheduledChange, operator string) error {
+	if err := model.ValidateScheduledChanges(changes); err != nil {
+		return err
+	}
+	task, err := s.findTask(ctx, bizID, taskKey)
+	if err != nil {
+		return err
+	}
+	if err := auth.CheckBizType(ctx, task.BizType); err != nil {
+		return err
+	}
+	if task.IsDeleted() || task.Status.IsFinalStatus() {
+		return errors.Errorf("任务[%s]已删除或已结束, 不能计划状态变更", task.TaskKey)
+	}
+
+	for i := range changes {
+		if changes[i].Operator == "" {
+			changes[i].Operator = operator
+		}
+	}
+	if err := s.setScheduledChanges(ctx, task, changes); err != nil {
+		return err
+	}
+
+	to := make([]string, 0, len(changes))
+	for _, c := range changes {
+		to = append(to, fmt.Sprintf("%s@%s", c.Status, c.At.Format(time.RFC3339)))
+	}
+	s.audit(ctx, audit.Entry{
+		TaskKey:  task.TaskKey,
+		Operator: operator,
+		Action:   audit.ActionSchedule,
+		To:       fmt.Sprint(to),
+	})
+	return nil
+}
+
+// runScheduledChangeController applies due scheduled changes, only leader works.
+func (s *Scheduler) runScheduledChangeController() {
+	ticker := time.NewTicker(s.opts.scheduledChangeCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		amILeader, _, err := s.amILeader()
+		if err != nil {
+			log.Error("[ScheduledChange] 获取 leader 状态失败: %v", err)
+			continue
+		}
+		if !amILeader {
+			continue
+		}
+		if err := s.applyScheduledChanges(context.Background(), time.Now()); err != nil {
+			log.Error("[ScheduledChange] 应用计划状态变更失败: %v", err)
+		}
+	}
+}
+
+// applyScheduledChanges applies due changes of all pending tasks, they are
+// listed page by page before any is applied, since applying the last change
+// of a task removes it from the pending ones and shifts later pages.
+func (s *Scheduler) applyScheduledChanges(ctx context.Context, now time.Time) error {
+	page := &model.TaskFilter{
+		Labels: map[string]string{model.LabelScheduledChange: model.ScheduledChangePending},
+		Limit:  scheduledChangeBatchSize,
+	}
+	var tasks []*model.Task
+	for {
+		list, err := s.taskRepo.ListTask(ctx, page)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		tasks = append(tasks, list...)
+		if len(list) < page.Limit {
+			break
+		}
+		page.Offset += len(list)
+	}
+	for _, task := range tasks {
+		if err := s.applyDueChanges(ctx, task, now); err != nil {
+			log.Error("[ScheduledChange] 任务[%s]应用计划状态变更失败: %v", task.TaskKey, err)
+		}
+	}
+	return nil
+}
+
+// applyDueChanges applies the last change due, earlier changes due are
+// superseded by it, eg. the controller was down at both pause and resume.
+// changes can never be applied are dropped.
+func (s *Scheduler) applyDueChanges(ctx context.Context, task *model.Task, now time.Time) error {
+	due, pending := task.DueChanges(now)
+	if task.IsDeleted() || task.Status.IsFinalStatus() {
+		// nothing to change any more.
+		return s.setScheduledChanges(ctx, task, nil)
+	}
+	if len(due) == 0 {
+		if len(pending) == 0 {
+			return s.setScheduledChanges(ctx, task, nil)
+		}
+		return nil
+	}
+
+	c := due[len(due)-1]
+	operator := c.Operator
+	if operator == "" {
+		operator = model.OperatorScheduler
+	}
+	msg := fmt.Sprintf("%s scheduled at %s by %s", c.Status, c.At.Format(time.RFC3339), operator)
+	if c.Reason != "" {
+		msg += ": " + c.Reason
+	}
+	switch _, err := operable(task, c.Status); {
+	case task.WantRunStatus == c.Status:
+		log.Info("[ScheduledChange] 任务[%s]期望状态已是 %s, 忽略计划变更", task.TaskKey, c.Status)
+	case err != nil:
+		log.Warn("[ScheduledChange] 任务[%s]无法应用计划变更 %s: %v", task.TaskKey, msg, err)
+		s.event(ctx, events.Event{TaskKey: task.TaskKey, Type: events.TypeWarning, Reason: events.ReasonScheduledChange, Message: "dropped " + msg + ", " + err.Error()})
+	default:
+		// failures, eg. the status changed concurrently, are retried next pass.
+		if err := s.operateTask(ctx, task, c.Status, msg, operator); err != nil {
+			return err
+		}
+		log.Info("[ScheduledChange] 任务[%s]已应用计划变更 %s", task.TaskKey, msg)
+		s.event(ctx, events.Event{TaskKey: task.TaskKey, Reason: events.ReasonScheduledChange, Message: "applied " + msg})
+	}
+	return s.setScheduledChanges(ctx, task, pending)
+}
+
+// setScheduledChanges saves changes of the task, the task is labeled
+// pending until no change is left.
+func (s *Scheduler) setScheduledChanges(ctx context.Context, task *model.Task, changes []model.ScheduledChange) error {
+	pending := ""
+	if len(changes) > 0 {
+		pending = model.ScheduledChangePending
+	}
+	labels := withLabel(task, model.LabelScheduledChange, pending)
+	// non-nil so that repos clear changes left.
+	changes = append([]model.ScheduledChange{}, changes...)
+	return errors.WithStack(s.taskRepo.UpdateTask(ctx, &model.Task{TaskKey: task.TaskKey, ScheduledChanges: changes, Labels: labels}))
+}
@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo/memory"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type scheduledRepo struct {
+	statusRepo
+}
+
+func (r *scheduledRepo) UpdateTask(ctx context.Context, update *model.Task) error {
+	for _, t := range r.tasks {
+		if t.TaskKey != update.TaskKey {
+			continue
+		}
+		if update.WantRunStatus != "" {
+			t.WantRunStatus = update.WantRunStatus
+		}
+		if update.ScheduledChanges != nil {
+			t.ScheduledChanges = update.ScheduledChanges
+		}
+	}
+	return r.statusRepo.UpdateTask(ctx, update)
+}
+
+func TestScheduledChanges(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 15, 1, 0, 0, 0, time.UTC)
+	pauseAt, resumeAt := now.Add(time.Hour), now.Add(3*time.Hour)
+	task := &model.Task{TaskKey: "t1", BizID: "b1", Status: model.TaskStatusRunning, WantRunStatus: model.TaskStatusRunning}
+	repo := &scheduledRepo{}
+	repo.tasks = []*model.Task{task}
+	s := &Scheduler{taskRepo: repo, opts: newOptions()}
+
+	t.Run("非法的计划变更", func(t *testing.T) {
+		if err := s.ScheduleChanges(ctx, "b1", "", []model.ScheduledChange{{At: pauseAt, Status: model.TaskStatusSuccess}}, "user:alice"); err == nil {
+			t.Error("status must be paused, running or stop")
+		}
+		if err := s.ScheduleChanges(ctx, "b1", "", []model.ScheduledChange{{Status: model.TaskStatusPaused}}, "user:alice"); err == nil {
+			t.Error("at is required")
+		}
+	})
+
+	t.Run("到期前不变更", func(t *testing.T) {
+		err := s.ScheduleChanges(ctx, "b1", "", []model.ScheduledChange{
+			{At: resumeAt, Status: model.TaskStatusRunning},
+			{At: pauseAt, Status: model.TaskStatusPaused, Reason: "maintenance"},
+		}, "user:alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.Labels[model.LabelScheduledChange] != model.ScheduledChangePending || task.ScheduledChanges[0].Operator != "user:alice" {
+			t.Fatalf("changes should be pending: %v %+v", task.Labels, task.ScheduledChanges)
+		}
+		if err := s.applyScheduledChanges(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+		if task.WantRunStatus != model.TaskStatusRunning || len(task.ScheduledChanges) != 2 {
+			t.Fatalf("changes should not be applied before due: %s %+v", task.WantRunStatus, task.ScheduledChanges)
+		}
+	})
+
+	t.Run("到期后按时间顺序应用", func(t *testing.T) {
+		if err := s.applyScheduledChanges(ctx, pauseAt); err != nil {
+			t.Fatal(err)
+		}
+		if task.WantRunStatus != model.TaskStatusPaused || task.Status != model.TaskStatusWaitPaused || len(task.ScheduledChanges) != 1 {
+			t.Fatalf("pause should be applied: %s %s %+v", task.Status, task.WantRunStatus, task.ScheduledChanges)
+		}
+
+		task.Status = model.TaskStatusPaused
+		if err := s.applyScheduledChanges(ctx, resumeAt.Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		if task.WantRunStatus != model.TaskStatusRunning || task.Status != model.TaskStatusWaitRunning {
+			t.Fatalf("resume should be applied: %s %s", task.Status, task.WantRunStatus)
+		}
+		if len(task.ScheduledChanges) != 0 || task.Labels[model.LabelScheduledChange] != "" {
+			t.Fatalf("no change should be left: %v %+v", task.Labels, task.ScheduledChanges)
+		}
+	})
+
+	t.Run("错过的变更只应用最后一个", func(t *testing.T) {
+		task.Status = model.TaskStatusRunning
+		err := s.ScheduleChanges(ctx, "b1", "", []model.ScheduledChange{
+			{At: pauseAt, Status: model.TaskStatusPaused},
+			{At: resumeAt, Status: model.TaskStatusRunning},
+		}, "user:alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.applyScheduledChanges(ctx, resumeAt); err != nil {
+			t.Fatal(err)
+		}
+		if task.Status != model.TaskStatusRunning || len(task.ScheduledChanges) != 0 {
+			t.Fatalf("superseded pause should be skipped: %s %+v", task.Status, task.ScheduledChanges)
+		}
+	})
+
+	t.Run("待变更的任务超过一页时应用所有到期变更", func(t *testing.T) {
+		repo := memory.New()
+		s := &Scheduler{taskRepo: repo, opts: newOptions()}
+		pending := map[string]string{model.LabelScheduledChange: model.ScheduledChangePending}
+		for i := 0; i <= scheduledChangeBatchSize; i++ {
+			if err := repo.CreateTask(ctx, &model.Task{
+				TaskKey: fmt.Sprintf("future-%d", i), Status: model.TaskStatusRunning, WantRunStatus: model.TaskStatusRunning,
+				Labels: pending, ScheduledChanges: []model.ScheduledChange{{At: resumeAt, Status: model.TaskStatusPaused}},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := repo.CreateTask(ctx, &model.Task{
+			TaskKey: "due", Status: model.TaskStatusRunning, WantRunStatus: model.TaskStatusRunning,
+			Labels: pending, ScheduledChanges: []model.ScheduledChange{{At: pauseAt, Status: model.TaskStatusPaused}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.applyScheduledChanges(ctx, pauseAt); err != nil {
+			t.Fatal(err)
+		}
+		due, err := repo.GetTask(ctx, "due")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if due.WantRunStatus != model.TaskStatusPaused || due.Labels[model.LabelScheduledChange] != "" {
+			t.Fatalf("期望应用最后一页任务的到期变更, 得到 %s %v", due.WantRunStatus, due.Labels)
+		}
+	})
+}
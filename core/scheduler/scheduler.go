@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"io"
 	"slices"
 	"sort"
 	"strconv"
@@ -10,12 +11,15 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"github.com/xyzbit/minitaskx/core/components/audit"
+	"github.com/xyzbit/minitaskx/core/components/auth"
 	"github.com/xyzbit/minitaskx/core/components/discover"
 	"github.com/xyzbit/minitaskx/core/components/election"
+	"github.com/xyzbit/minitaskx/core/components/events"
 	"github.com/xyzbit/minitaskx/core/components/log"
 	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo/archive"
 	"github.com/xyzbit/minitaskx/core/model"
 	"golang.org/x/exp/rand"
 )
@@ -32,19 +36,65 @@ type Scheduler struct {
 	elector  election.Interface
 	taskRepo taskrepo.Interface
 
+	canaries *canaries
+	windows  *windows
+	gates    *gates
+	// task key => next time of retrying an unschedulable task.
+	requeueAt sync.Map
+	// usage of biz types accounted last time.
+	usage        atomic.Pointer[Usage]
+	usageMetrics *usageMetrics
+	// run durations estimated last time.
+	estimates atomic.Pointer[Estimates]
+	// wait of unassigned tasks.
+	starvation *starvation
+	// loads of workers placed by PlacementBinPack.
+	packer *packer
+	// tasks assigned to workers, for affinity.
+	colocation *colocation
+	// workers getting no new tasks.
+	cordons cordons
+	// retries of biz types in the last minute.
+	retryBudget retryBudget
+
 	logger log.Logger
+	opts   *options
 }
 
 func NewScheduler(
 	elector election.Interface,
 	discover discover.Interface,
 	taskRepo taskrepo.Interface,
+	opts ...Option,
 ) (*Scheduler, error) {
-	return &Scheduler{
+	o := newOptions(opts...)
+	windows, err := newWindows(o.windows)
+	if err != nil {
+		return nil, err
+	}
+	if err := model.ValidatePriorityClasses(o.priorityClasses); err != nil {
+		return nil, err
+	}
+	taskRepo = taskrepo.WithFinalGuard(taskRepo)
+	if o.archive != nil {
+		taskRepo = archive.Wrap(taskRepo, o.archive)
+	}
+	taskRepo = taskrepo.WithMetrics(taskrepo.WithTimeouts(taskRepo, o.repoTimeouts))
+	s := &Scheduler{
 		elector:  elector,
 		discover: discover,
 		taskRepo: taskRepo,
-	}, nil
+		canaries: newCanaries(o.canaryPolicies),
+		windows:  windows,
+		gates:    newGates(),
+		opts:     o,
+
+		usageMetrics: newUsageMetrics(),
+		starvation:   newStarvation(o.starvationThreshold),
+		colocation:   newColocation(),
+	}
+	s.packer = newPacker(s.estimatedDuration)
+	return s, nil
 }
 
 func (s *Scheduler) HttpServer() *HttpServer {
@@ -62,10 +112,32 @@ func (s *Scheduler) Run() error {
 	go s.elector.AttemptElection()
 	go s.monitorAssignEvent()
 	go s.autoTriggerReAssignEvent()
+	go s.runTombstoneGC()
+	go s.runSLAController()
+	go s.runServiceController()
+	go s.runCanaryController()
+	go s.runRetryController()
+	go s.runGateController()
+	go s.runApprovalController()
+	go s.runUsageController()
+	go s.runEstimateController()
+	go s.runFollowUpController()
+	go s.runScheduledChangeController()
+	if s.opts.archive != nil {
+		go s.refreshArchive()
+	}
 
 	return s.watchWorkers()
 }
 
+// refreshArchive indexes archived batches at startup, so that reads of
+// archived tasks do not miss until the first refresh on demand.
+func (s *Scheduler) refreshArchive() {
+	if err := s.opts.archive.Refresh(context.Background()); err != nil {
+		log.Error("[Archive] 刷新归档索引失败: %v", err)
+	}
+}
+
 func (s *Scheduler) CreateTask(ctx context.Context, task *model.Task) error {
 	// _, exist := executor.GetFactory(task.Type)
 	// if !exist {
@@ -82,55 +154,399 @@ func (s *Scheduler) ListTask(ctx context.Context, filter *model.TaskFilter) ([]*
 	return tasks, nil
 }
 
-func (s *Scheduler) OperateTask(ctx context.Context, bizID, taskKey string, nextStatus model.TaskStatus) error {
-	var task *model.Task
+// GetTask returns the task of taskKey.
+func (s *Scheduler) GetTask(ctx context.Context, taskKey string) (*model.Task, error) {
+	task, err := s.taskRepo.GetTask(ctx, taskKey)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := auth.CheckBizType(ctx, task.BizType); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// OperateTask change want status of the task, operator is the principal requested the change.
+func (s *Scheduler) OperateTask(ctx context.Context, bizID, taskKey string, nextStatus model.TaskStatus, operator string) error {
+	task, err := s.findTask(ctx, bizID, taskKey)
+	if err != nil {
+		return err
+	}
+	if err := auth.CheckBizType(ctx, task.BizType); err != nil {
+		return err
+	}
+	return s.operateTask(ctx, task, nextStatus, "", operator)
+}
 
-	if taskKey != "" {
-		t, err := s.taskRepo.GetTask(ctx, taskKey)
-		if err != nil {
+// operateTask change want status of the found task.
+func (s *Scheduler) operateTask(ctx context.Context, task *model.Task, nextStatus model.TaskStatus, reason, operator string) error {
+	waitStatus, err := operable(task, nextStatus)
+	if err != nil {
+		return err
+	}
+	update := &model.Task{
+		TaskKey:       task.TaskKey,
+		Status:        waitStatus,
+		WantRunStatus: nextStatus,
+		Operator:      operator,
+	}
+	holdback(task, nextStatus, update, time.Now())
+
+	// status and want status are written together, so that no task is left
+	// in a wait status with the old want status.
+	applied, err := s.taskRepo.UpdateTaskCAS(ctx, task.Status, update)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !applied {
+		return errors.Errorf("任务[%s]状态已被并发修改, 请重试", task.TaskKey)
+	}
+
+	s.audit(ctx, audit.Entry{
+		TaskKey:  task.TaskKey,
+		Operator: operator,
+		Action:   audit.ActionOperate,
+		From:     task.WantRunStatus.String(),
+		To:       nextStatus.String(),
+		Reason:   reason,
+	})
+	return nil
+}
+
+// operable returns the wait status of changing want status of task to nextStatus.
+func operable(task *model.Task, nextStatus model.TaskStatus) (model.TaskStatus, error) {
+	// held and parked tasks have no executor to confirm the change.
+	if nextStatus == model.TaskStatusPaused && task.IsHeld(time.Now()) {
+		return model.TaskStatusPaused, nil
+	}
+	if nextStatus == model.TaskStatusStop && task.Status == model.TaskStatusPaused && task.IsParked() {
+		return model.TaskStatusStop, nil
+	}
+	if err := task.Status.CanTransition(nextStatus); err != nil {
+		return "", err
+	}
+	if task.IsService() && nextStatus == model.TaskStatusPaused {
+		return "", errors.Errorf("服务[%s]不支持暂停, 请调整副本数或停止", task.TaskKey)
+	}
+
+	waitStatus := nextStatus.PreWaitStatus()
+	if waitStatus == "" {
+		return "", errors.Errorf("任务[%s]当前状态为 %s, 不允许进行 %s 操作", task.TaskKey, task.Status, nextStatus)
+	}
+	return waitStatus, nil
+}
+
+// ExportTasks write tasks as JSON lines to w.
+func (s *Scheduler) ExportTasks(ctx context.Context, filter *model.TaskFilter, w io.Writer) (int, error) {
+	return taskrepo.ExportTasks(ctx, s.taskRepo, filter, w)
+}
+
+// ImportTasks create tasks from JSON lines exported by ExportTasks, tasks are
+// validated as created by CreateTask, but keep their statuses.
+func (s *Scheduler) ImportTasks(ctx context.Context, r io.Reader) (int, error) {
+	return taskrepo.ImportTasks(ctx, func(ctx context.Context, task *model.Task) error {
+		if err := auth.CheckBizType(ctx, task.BizType); err != nil {
 			return err
 		}
-		task = t
-	} else if bizID != "" {
-		tasks, err := s.taskRepo.ListTask(ctx, &model.TaskFilter{
-			BizIDs: []string{bizID},
-			Limit:  1,
-		})
-		if err != nil {
+		if err := model.ValidateTaskKey(task.TaskKey); err != nil {
 			return err
 		}
-		if len(tasks) == 0 {
-			return errors.New("task not found")
+		if err := s.validateTask(task); err != nil {
+			return err
 		}
-		task = tasks[0]
-	} else {
-		return errors.New("invalid params, need bizID or taskKey")
+		return errors.WithStack(s.taskRepo.CreateTask(ctx, task))
+	}, r)
+}
+
+// DeleteTask soft delete the task, worker will observe it and exit the executor,
+// the tombstone is purged after no worker reports the task.
+func (s *Scheduler) DeleteTask(ctx context.Context, bizID, taskKey string, operator string) error {
+	task, err := s.findTask(ctx, bizID, taskKey)
+	if err != nil {
+		return err
 	}
-	if err := task.Status.CanTransition(nextStatus); err != nil {
+	if err := auth.CheckBizType(ctx, task.BizType); err != nil {
 		return err
 	}
+	if err := s.taskRepo.DeleteTask(ctx, task.TaskKey); err != nil {
+		return errors.WithStack(err)
+	}
 
-	waitStatus := nextStatus.PreWaitStatus()
-	if waitStatus == "" {
-		return errors.Errorf("任务[%s]当前状态为 %s, 不允许进行 %s 操作", task.TaskKey, task.Status, nextStatus)
+	s.audit(ctx, audit.Entry{
+		TaskKey:  task.TaskKey,
+		Operator: operator,
+		Action:   audit.ActionDelete,
+		From:     task.WantRunStatus.String(),
+		To:       model.TaskStatusNotExist.String(),
+	})
+	return nil
+}
+
+// UpdateTaskSpec replace payload and labels of the task and bumps its generation,
+// worker applies the new spec to the live executor in place, or restarts it
+// if the executor does not support hot reconfiguration.
+// nil labels keep the old labels.
+func (s *Scheduler) UpdateTaskSpec(ctx context.Context, bizID, taskKey, payload string, labels map[string]string, operator string) error {
+	task, err := s.findTask(ctx, bizID, taskKey)
+	if err != nil {
+		return err
+	}
+	if err := auth.CheckBizType(ctx, task.BizType); err != nil {
+		return err
+	}
+	if task.Status.IsFinalStatus() {
+		return errors.Errorf("任务[%s]已是终态 %s, 无法更新", task.TaskKey, task.Status)
 	}
 
-	err := s.taskRepo.UpdateTask(
-		ctx, &model.Task{
-			TaskKey:       task.TaskKey,
-			Status:        waitStatus,
-			WantRunStatus: nextStatus,
-		},
-	)
+	generation := task.Generation + 1
+	applied, err := s.taskRepo.UpdateTaskGenerationCAS(ctx, task.Generation, &model.Task{
+		TaskKey:    task.TaskKey,
+		Payload:    payload,
+		Labels:     labels,
+		Generation: generation,
+		Operator:   operator,
+	})
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	if !applied {
+		return errors.Errorf("任务[%s]规格已被并发修改, 请重试", task.TaskKey)
+	}
+
+	s.audit(ctx, audit.Entry{
+		TaskKey:  task.TaskKey,
+		Operator: operator,
+		Action:   audit.ActionUpdate,
+		From:     strconv.FormatInt(task.Generation, 10),
+		To:       strconv.FormatInt(generation, 10),
+	})
 	return nil
 }
 
+// PatchTaskMetadata merges labels and extra into the task, nil values remove keys.
+// spec generation is increased so that workers pass the change to executors
+// supporting hot reconfiguration, other executors are not restarted for it.
+func (s *Scheduler) PatchTaskMetadata(ctx context.Context, bizID, taskKey string, labels, extra map[string]*string, operator string) error {
+	task, err := s.findTask(ctx, bizID, taskKey)
+	if err != nil {
+		return err
+	}
+	if err := auth.CheckBizType(ctx, task.BizType); err != nil {
+		return err
+	}
+	if task.Status.IsFinalStatus() {
+		return errors.Errorf("任务[%s]已是终态 %s, 无法更新", task.TaskKey, task.Status)
+	}
+
+	generation := task.Generation + 1
+	applied, err := s.taskRepo.UpdateTaskGenerationCAS(ctx, task.Generation, &model.Task{
+		TaskKey:    task.TaskKey,
+		Labels:     mergePatch(task.Labels, labels),
+		Extra:      mergePatch(task.Extra, extra),
+		Generation: generation,
+		Operator:   operator,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !applied {
+		return errors.Errorf("任务[%s]规格已被并发修改, 请重试", task.TaskKey)
+	}
+
+	s.audit(ctx, audit.Entry{
+		TaskKey:  task.TaskKey,
+		Operator: operator,
+		Action:   audit.ActionUpdate,
+		From:     strconv.FormatInt(task.Generation, 10),
+		To:       strconv.FormatInt(generation, 10),
+		Reason:   "patch metadata",
+	})
+	return nil
+}
+
+// PatchTaskAnnotations merges annotations into the task, nil values remove keys.
+// annotations are not part of the spec, so the generation is kept and the
+// running executor is left untouched. finished tasks can be annotated too.
+func (s *Scheduler) PatchTaskAnnotations(ctx context.Context, bizID, taskKey string, annotations map[string]*string, operator string) error {
+	if err := model.ValidateAnnotations(annotations); err != nil {
+		return err
+	}
+	task, err := s.findTask(ctx, bizID, taskKey)
+	if err != nil {
+		return err
+	}
+	if err := auth.CheckBizType(ctx, task.BizType); err != nil {
+		return err
+	}
+	if err := s.patchAnnotations(ctx, task, annotations); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(annotations))
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s.audit(ctx, audit.Entry{
+		TaskKey:  task.TaskKey,
+		Operator: operator,
+		Action:   audit.ActionAnnotate,
+		To:       fmt.Sprint(keys),
+	})
+	return nil
+}
+
+// patchAnnotations merges annotations into the task without validation,
+// so that the framework sets annotations of ReservedPrefix by it.
+func (s *Scheduler) patchAnnotations(ctx context.Context, task *model.Task, annotations map[string]*string) error {
+	return errors.WithStack(s.taskRepo.UpdateTask(ctx, &model.Task{
+		TaskKey:     task.TaskKey,
+		Annotations: mergePatch(task.Annotations, annotations),
+	}))
+}
+
+// withLabel returns a copy of labels of task with k set to v, k is removed
+// if v is empty. labels of task may be shared with the caller, so they are
+// left untouched.
+func withLabel(task *model.Task, k, v string) map[string]string {
+	labels := make(map[string]string, len(task.Labels)+1)
+	for key, value := range task.Labels {
+		labels[key] = value
+	}
+	if v == "" {
+		delete(labels, k)
+	} else {
+		labels[k] = v
+	}
+	return labels
+}
+
+// mergePatch applies patch to a copy of m, nil if patch is empty so that m is untouched.
+// the result is an empty but non-nil map once all keys are removed.
+func mergePatch(m map[string]string, patch map[string]*string) map[string]string {
+	if len(patch) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(m)+len(patch))
+	for k, v := range m {
+		merged[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(merged, k)
+		} else {
+			merged[k] = *v
+		}
+	}
+	return merged
+}
+
+func (s *Scheduler) findTask(ctx context.Context, bizID, taskKey string) (*model.Task, error) {
+	if taskKey != "" {
+		return s.taskRepo.GetTask(ctx, taskKey)
+	}
+	if bizID == "" {
+		return nil, errors.New("invalid params, need bizID or taskKey")
+	}
+
+	tasks, err := s.taskRepo.ListTask(ctx, &model.TaskFilter{
+		BizIDs: []string{bizID},
+		Limit:  1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, errors.Wrapf(taskrepo.ErrTaskNotFound, "biz id %s", bizID)
+	}
+	return tasks[0], nil
+}
+
+// createTask creates task of a new key, or of the key supplied by the caller.
+// creating the same task of a supplied key again is a no-op, so that callers
+// can retry safely, a different task of the key fails with ErrDuplicateTask.
 func (s *Scheduler) createTask(ctx context.Context, task *model.Task) error {
-	task.TaskKey = uuid.New().String()
+	if task.TaskKey == "" {
+		key, err := s.opts.keyGenerator.NewKey(task)
+		if err != nil {
+			return errors.WithMessage(err, "generate task key")
+		}
+		task.TaskKey = key
+		return s.insertTask(ctx, task)
+	}
+	if err := model.ValidateTaskKey(task.TaskKey); err != nil {
+		return err
+	}
+
+	err := s.insertTask(ctx, task)
+	if !errors.Is(err, taskrepo.ErrDuplicateTask) {
+		return err
+	}
+	existing, gerr := s.taskRepo.GetTask(ctx, task.TaskKey)
+	if gerr != nil {
+		return errors.WithStack(gerr)
+	}
+	if existing.BizID != task.BizID || existing.BizType != task.BizType || existing.Type != task.Type || existing.Payload != task.Payload {
+		return errors.Wrapf(err, "任务[%s]已被其他任务使用", task.TaskKey)
+	}
+	log.Info("任务[%s]已创建, 忽略重复创建", task.TaskKey)
+	return nil
+}
+
+// validateTask checks a task to be created and resolves its priority class.
+func (s *Scheduler) validateTask(task *model.Task) error {
+	if err := validateGates(task.Gates, s.opts.gateHosts); err != nil {
+		return err
+	}
+	if err := model.ValidateFollowUps(task.FollowUps); err != nil {
+		return err
+	}
+	if err := model.ValidateCompensation(task.Compensation); err != nil {
+		return err
+	}
+	if err := model.ValidateScheduledChanges(task.ScheduledChanges); err != nil {
+		return err
+	}
+	if err := model.ValidateAnnotations(task.Annotations); err != nil {
+		return err
+	}
+	if err := validatePreStop(task.PreStop, s.opts.gateHosts); err != nil {
+		return err
+	}
+	return s.resolvePriorityClass(task)
+}
+
+// insertTask creates task of the given key.
+func (s *Scheduler) insertTask(ctx context.Context, task *model.Task) error {
+	if err := s.validateTask(task); err != nil {
+		return err
+	}
+	if len(task.ScheduledChanges) > 0 {
+		task.Labels = withLabel(task, model.LabelScheduledChange, model.ScheduledChangePending)
+	}
+	if task.Retry != nil && task.Retry.MaxRetries > 0 {
+		task.Labels = withLabel(task, model.LabelRetry, model.RetryPending)
+	}
+	if needFollowUp(task) && task.Labels[model.LabelFollowUp] == "" {
+		task.Labels = withLabel(task, model.LabelFollowUp, model.FollowUpPending)
+	}
 	task.Status = model.TaskStatusWaitScheduling
+	if task.ApprovalRequired {
+		task.Status = model.TaskStatusWaitApproval
+	}
+	if task.IsService() {
+		// service is never scheduled, service controller maintains its replicas.
+		task.Status = model.TaskStatusRunning
+		task.WantRunStatus = model.TaskStatusRunning
+	}
+	if task.Schedule != nil {
+		// hold the task until its first scheduled run.
+		next, err := task.Schedule.Next(time.Now())
+		if err != nil {
+			return errors.Wrap(err, "invalid schedule")
+		}
+		task.NextRunAt = &next
+	}
 
 	err := s.taskRepo.CreateTask(ctx, task)
 	if err != nil {
@@ -142,28 +558,65 @@ func (s *Scheduler) createTask(ctx context.Context, task *model.Task) error {
 func (s *Scheduler) assignTask(ctx context.Context, task *model.Task) error {
 	if task.Status == model.TaskStatusWaitScheduling {
 		log.Info("任务[%s]首次分配工作者", task.TaskKey)
-	} else {
+	} else if task.Status != model.TaskStatusUnschedulable {
 		log.Info("任务[%s]需要重新分配, 工作者替换", task.TaskKey)
 	}
 	workerID, err := s.selectWorkerID(task)
+	var unschedulable *unschedulableError
+	if errors.As(err, &unschedulable) {
+		s.releaseExclusion(ctx, task)
+		return s.markUnschedulable(ctx, task, unschedulable)
+	}
 	if err != nil {
 		return err
 	}
+	return s.commitAssignment(ctx, task, workerID)
+}
 
+// commitAssignment writes task assigned to workerID.
+func (s *Scheduler) commitAssignment(ctx context.Context, task *model.Task, workerID string) error {
 	nextStatus := model.TaskStatusRunning
 	now := time.Now()
-	err = s.taskRepo.UpdateTask(
+	// keep backoff of retried task.
+	nextRunAt := now
+	if task.NextRunAt != nil && task.NextRunAt.After(now) {
+		nextRunAt = *task.NextRunAt
+	}
+	err := s.taskRepo.UpdateTask(
 		ctx, &model.Task{
 			TaskKey:       task.TaskKey,
 			Status:        nextStatus.PreWaitStatus(),
-			NextRunAt:     &now,
+			NextRunAt:     &nextRunAt,
+			AssignedAt:    &now,
 			WorkerID:      workerID,
 			WantRunStatus: nextStatus,
+			Operator:      model.OperatorScheduler,
+			Epoch:         task.Epoch + 1,
 		},
 	)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	s.requeueAt.Delete(task.TaskKey)
+	s.trackCanary(task, workerID)
+	if s.opts.placement == PlacementBinPack {
+		s.packer.reserve(workerID, task, now)
+	}
+	s.colocation.reserve(workerID, task)
+
+	s.audit(ctx, audit.Entry{
+		TaskKey:  task.TaskKey,
+		Operator: model.OperatorScheduler,
+		Action:   audit.ActionAssign,
+		From:     task.WantRunStatus.String(),
+		To:       nextStatus.String(),
+		Reason:   "assign to worker " + workerID,
+	})
+	s.event(ctx, events.Event{
+		TaskKey: task.TaskKey,
+		Reason:  events.ReasonScheduled,
+		Message: "assigned to worker " + workerID,
+	})
 	return nil
 }
 
@@ -180,42 +633,140 @@ func (s *Scheduler) monitorAssignEvent() {
 		}
 
 		ctx := context.Background()
-		tasks, err := s.loadNeedAssignTasks(ctx)
+		tasks, stats, err := s.loadNeedAssignTasks(ctx)
 		if err != nil {
 			log.Error("获取任务列表失败: %+v", err)
 			continue
 		}
+		if s.opts.placement == PlacementBinPack {
+			s.packer.reset(stats.assigned, time.Now())
+		}
+		s.colocation.reset(stats.assigned)
+		if s.opts.dryRun {
+			for _, task := range tasks {
+				p := s.PreviewPlacement(task)
+				log.Info("[dry-run] 任务[%s]将分配到 worker[%s]: %s", task.TaskKey, p.WorkerID, p.Reason)
+			}
+			continue
+		}
 
+		quota := newQuotaTracker(s.opts.quotas, stats.bizTypes)
+		now := time.Now()
+		held := s.windows.gate(now)
+		s.observeStarvation(ctx, tasks, held, now)
+		tasks, gangs := groupGangs(s.orderByPriorityClass(tasks), stats.gangs)
 		for _, task := range tasks {
+			if !s.shouldAttempt(task, now) {
+				continue
+			}
+			if reason := held(task); reason != "" {
+				log.Debug("任务[%s]暂不分配: %s", task.TaskKey, reason)
+				continue
+			}
+			if reason := s.gates.reason(task); reason != "" {
+				log.Debug("任务[%s]暂不分配: %s", task.TaskKey, reason)
+				continue
+			}
+			if reason := s.overBudget(task); reason != "" {
+				if err := s.markUnschedulable(ctx, task, &unschedulableError{
+					code:   model.UnschedulableBudgetExceeded,
+					reason: reason,
+				}); err != nil {
+					log.Error("任务[%s]标记无法调度失败, err: %v", task.TaskKey, err)
+				}
+				continue
+			}
+			if !quota.take(task.BizType) {
+				if err := s.markUnschedulable(ctx, task, &unschedulableError{
+					code:   model.UnschedulableQuotaExceeded,
+					reason: fmt.Sprintf("业务类型 %s 运行中任务数已达配额 %d", task.BizType, s.opts.quotas[task.BizType]),
+				}); err != nil {
+					log.Error("任务[%s]标记无法调度失败, err: %v", task.TaskKey, err)
+				}
+				continue
+			}
+			if !s.acquireExclusion(ctx, task, stats) {
+				quota.release(task.BizType)
+				continue
+			}
 			if err := s.assignTask(ctx, task); err != nil {
+				quota.release(task.BizType)
+				s.releaseExclusion(ctx, task)
 				log.Error("任务[%s]分配失败, err: %v", task.TaskKey, err)
 			}
 		}
+		for _, members := range gangs {
+			if !slices.ContainsFunc(members, func(t *model.Task) bool { return s.shouldAttempt(t, now) }) {
+				continue
+			}
+			if slices.ContainsFunc(members, func(t *model.Task) bool { return held(t) != "" || s.gates.reason(t) != "" }) {
+				continue
+			}
+			if err := s.assignGang(ctx, members, quota, stats); err != nil {
+				log.Error("任务组[%s]分配失败, err: %v", members[0].Gang, err)
+			}
+		}
 	}
 }
 
-func (s *Scheduler) loadNeedAssignTasks(ctx context.Context) ([]*model.Task, error) {
+// runnableStats counts tasks assigned to available workers.
+type runnableStats struct {
+	bizTypes map[string]int
+	gangs    map[string]int
+	// exclusion group <==> task key assigned.
+	exclusions map[string]string
+	// keys of all runnable tasks.
+	runnable map[string]bool
+	// tasks assigned to available workers.
+	assigned []*model.Task
+}
+
+// loadNeedAssignTasks returns tasks not assigned to available workers,
+// and number of tasks assigned to available workers per biz type and gang.
+func (s *Scheduler) loadNeedAssignTasks(ctx context.Context) ([]*model.Task, *runnableStats, error) {
 	newAvailableWorkers := s.getAvailableWorkers()
 	allRunnableTaskKeys, err := s.taskRepo.ListRunnableTasks(ctx, "")
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, nil, errors.WithStack(err)
 	}
-	tasks, err := s.taskRepo.BatchGetTask(ctx, allRunnableTaskKeys)
+	tasks, err := taskrepo.ChunkedBatchGetTask(
+		ctx, s.taskRepo.BatchGetTask, allRunnableTaskKeys,
+		taskrepo.DefaultBatchGetChunkSize, taskrepo.DefaultBatchGetParallelism,
+	)
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, nil, errors.WithStack(err)
 	}
 
 	ret := make([]*model.Task, 0, len(tasks))
+	stats := &runnableStats{
+		bizTypes:   make(map[string]int),
+		gangs:      make(map[string]int),
+		exclusions: make(map[string]string),
+		runnable:   make(map[string]bool, len(tasks)),
+	}
 	for _, run := range tasks {
+		stats.runnable[run.TaskKey] = true
+		if run.IsService() || run.Status == model.TaskStatusWaitApproval {
+			continue
+		}
 		found := slices.ContainsFunc(newAvailableWorkers, func(newWorker discover.Instance) bool {
 			return newWorker.ID() == run.WorkerID
 		})
 		if !found {
 			ret = append(ret, run)
+			continue
+		}
+		stats.assigned = append(stats.assigned, run)
+		stats.bizTypes[run.BizType]++
+		if run.InGang() {
+			stats.gangs[run.Gang]++
+		}
+		if group := run.ExclusionGroup(); group != "" {
+			stats.exclusions[group] = run.TaskKey
 		}
 	}
 
-	return ret, nil
+	return ret, stats, nil
 }
 
 func (s *Scheduler) amILeader() (bool, *election.LeaderElection, error) {
@@ -243,6 +794,8 @@ func (s *Scheduler) watchWorkers() error {
 				s.setAvailableWorkers(newAvailableWorkers)
 				s.rwmu.Unlock()
 
+				// new workers may run unschedulable tasks.
+				s.requeueAt.Clear()
 				s.triggerReAssignEvent()
 			} else {
 				s.setAvailableWorkers(newAvailableWorkers)
@@ -262,20 +815,27 @@ func (s *Scheduler) selectWorkerID(task *model.Task) (string, error) {
 
 	availableWorkers := s.getAvailableWorkers()
 	if len(availableWorkers) == 0 {
-		return "", errors.New("没有可用的 worker 服务")
+		return "", &unschedulableError{code: model.UnschedulableNoCapacity, reason: "没有可用的 worker 服务"}
 	}
 
-	// filte 排除掉不部署的机器（污点、亲和性）
-	candidateWorkers := filterWorker(task, availableWorkers)
-	if len(candidateWorkers) == 0 {
-		return "", errors.New("没有可用的 worker")
+	// cordon、执行器、污点、命名空间、灰度和任务亲和性依次过滤
+	candidateWorkers, _, unschedulable := s.filterCandidates(task, availableWorkers)
+	if unschedulable != nil {
+		return "", unschedulable
 	}
 	if len(candidateWorkers) == 1 {
 		return candidateWorkers[0].ID(), nil
 	}
 
-	// priority 根据资源使用情况打分
-	selectedWorker := priorityWorker(candidateWorkers)
+	// priority 根据资源使用情况打分, 或按资源请求装箱, 有局部性 key 的任务粘在同一 worker
+	var selectedWorker discover.Instance
+	if task.LocalityKey != "" {
+		selectedWorker = localityWorker(task.LocalityKey, candidateWorkers)
+	} else if s.opts.placement == PlacementBinPack {
+		selectedWorker, _ = s.packWorker(task, candidateWorkers)
+	} else {
+		selectedWorker = priorityWorker(candidateWorkers)
+	}
 
 	s.updateLocalResourceEstimate(selectedWorker)
 
@@ -358,30 +918,81 @@ func filterWorker(task *model.Task, workers []discover.Instance) []discover.Inst
 	candidateWorkers := make([]discover.Instance, 0, len(workers))
 
 	for _, worker := range workers {
-		nodeStains := model.Parsestain(worker.Metadata)
-		if len(nodeStains) == 0 {
+		if filterReason(task, worker) == "" {
 			candidateWorkers = append(candidateWorkers, worker)
-			continue
-		}
-		if len(nodeStains) > len(task.Stains) {
-			continue
 		}
+	}
+	return candidateWorkers
+}
 
-		matched := true
-		for k, node := range nodeStains {
-			if task.Stains[k] != node {
-				matched = false
-				break
-			}
+// filterReason returns why the worker can not run the task, empty if it can.
+func filterReason(task *model.Task, worker discover.Instance) string {
+	if reason := executorReason(task, worker); reason != "" {
+		return reason
+	}
+	if reason := selectorReason(task, worker); reason != "" {
+		return reason
+	}
+
+	nodeStains := model.Parsestain(worker.Metadata)
+	if len(nodeStains) == 0 {
+		return ""
+	}
+	if len(nodeStains) > len(task.Stains) {
+		return fmt.Sprintf("任务未容忍 worker 污点 %v", nodeStains)
+	}
+
+	for k, node := range nodeStains {
+		if task.Stains[k] != node {
+			return fmt.Sprintf("任务未容忍 worker 污点 %s=%s", k, node)
 		}
-		if matched {
-			candidateWorkers = append(candidateWorkers, worker)
+	}
+	return ""
+}
+
+// selectorReason returns why runtime labels of the worker do not match the
+// worker selector of the task, empty if they match.
+func selectorReason(task *model.Task, worker discover.Instance) string {
+	if len(task.WorkerSelector) == 0 {
+		return ""
+	}
+	labels := model.ParseRuntimeLabels(worker.Metadata)
+	for k, v := range task.WorkerSelector {
+		if labels[k] != v {
+			return fmt.Sprintf("worker 运行时标签 %s=%q, 要求 %s", k, labels[k], v)
 		}
 	}
-	return candidateWorkers
+	return ""
+}
+
+// executorReason returns why the worker has no executor for the task, empty if it has.
+// workers not reporting task types are assumed to support all of them.
+func executorReason(task *model.Task, worker discover.Instance) string {
+	types, ok := model.ParseExecutorTypes(worker.Metadata)
+	if !ok {
+		return ""
+	}
+	if !types[task.Type] {
+		return fmt.Sprintf("worker 未注册任务类型 %s", task.Type)
+	}
+	want := task.Labels[model.LabelExecutorVersion]
+	if want == "" {
+		return ""
+	}
+	if version := model.ParseExecutorVersions(worker.Metadata)[task.Type]; version != want {
+		return fmt.Sprintf("worker 任务类型 %s 版本为 %q, 要求 %s", task.Type, version, want)
+	}
+	return ""
 }
 
 func priorityWorker(workers []discover.Instance) discover.Instance {
+	scores := scoreWorkers(workers)
+	log.Info("worker scores: %v", scores)
+	return workers[scores[0].index]
+}
+
+// scoreWorkers scores workers by resource usage, sorted by score ascending (lower is better).
+func scoreWorkers(workers []discover.Instance) []workerScore {
 	scores := make([]workerScore, 0, len(workers))
 	for i, worker := range workers {
 		resourceUsage := model.ParseResourceUsage(worker.Metadata)
@@ -411,7 +1022,5 @@ func priorityWorker(workers []discover.Instance) discover.Instance {
 	sort.SliceStable(scores, func(i, j int) bool {
 		return scores[i].score < scores[j].score
 	})
-
-	log.Info("worker scores: %v", scores)
-	return workers[scores[0].index]
+	return scores
 }
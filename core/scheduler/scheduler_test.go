@@ -1,9 +1,15 @@
 package scheduler
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 
+	"github.com/xyzbit/minitaskx/core/components/auth"
 	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo/memory"
 	"github.com/xyzbit/minitaskx/core/model"
 )
 
@@ -68,3 +74,283 @@ func TestSelectWorkerByResources(t *testing.T) {
 		})
 	}
 }
+
+func TestExecutorReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		task     *model.Task
+		metadata map[string]string
+		wantOK   bool
+	}{
+		{
+			name:     "worker 未上报任务类型, 默认支持",
+			task:     &model.Task{Type: "email"},
+			metadata: map[string]string{},
+			wantOK:   true,
+		},
+		{
+			name:     "worker 未注册任务类型",
+			task:     &model.Task{Type: "email"},
+			metadata: map[string]string{model.ExecutorTypesKey: "sms,push"},
+		},
+		{
+			name:     "worker 已注册任务类型",
+			task:     &model.Task{Type: "email"},
+			metadata: map[string]string{model.ExecutorTypesKey: "sms,email"},
+			wantOK:   true,
+		},
+		{
+			name: "执行器版本不匹配",
+			task: &model.Task{Type: "email", Labels: map[string]string{model.LabelExecutorVersion: "v2"}},
+			metadata: map[string]string{
+				model.ExecutorTypesKey:            "email",
+				model.ExecutorVersionKey("email"): "v1",
+			},
+		},
+		{
+			name: "执行器版本匹配",
+			task: &model.Task{Type: "email", Labels: map[string]string{model.LabelExecutorVersion: "v2"}},
+			metadata: map[string]string{
+				model.ExecutorTypesKey:            "email",
+				model.ExecutorVersionKey("email"): "v2",
+			},
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := executorReason(tt.task, discover.Instance{Metadata: tt.metadata})
+			if (reason == "") != tt.wantOK {
+				t.Errorf("executorReason() = %q, wantOK %v", reason, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	v := func(s string) *string { return &s }
+	got := mergePatch(map[string]string{"a": "1", "b": "2"}, map[string]*string{"a": nil, "c": v("3")})
+	if len(got) != 2 || got["b"] != "2" || got["c"] != "3" {
+		t.Errorf("合并结果错误: %v", got)
+	}
+	if got := mergePatch(map[string]string{"a": "1"}, map[string]*string{"a": nil}); got == nil || len(got) != 0 {
+		t.Errorf("期望空但非 nil 的 map, 得到 %v", got)
+	}
+	if got := mergePatch(map[string]string{"a": "1"}, nil); got != nil {
+		t.Errorf("期望 nil, 得到 %v", got)
+	}
+}
+
+func TestWithLabel(t *testing.T) {
+	task := &model.Task{Labels: map[string]string{"a": "1"}}
+	if got := withLabel(task, "b", "2"); len(got) != 2 || got["b"] != "2" || len(task.Labels) != 1 {
+		t.Errorf("期望复制后设置标签, 得到 %v, 原标签 %v", got, task.Labels)
+	}
+	if got := withLabel(task, "a", ""); got == nil || len(got) != 0 || task.Labels["a"] != "1" {
+		t.Errorf("期望复制后删除标签, 得到 %v, 原标签 %v", got, task.Labels)
+	}
+}
+
+func TestCreateTaskWithKey(t *testing.T) {
+	ctx := context.Background()
+	repo := &createRepo{}
+	s := &Scheduler{taskRepo: repo, opts: newOptions()}
+	newTask := func(key, payload string) *model.Task {
+		return &model.Task{TaskKey: key, BizID: "order-1", BizType: "billing", Type: "invoice", Payload: payload}
+	}
+
+	t.Run("使用调用方的任务键", func(t *testing.T) {
+		if err := s.CreateTask(ctx, newTask("billing:order-1", "{}")); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.tasks) != 1 || repo.tasks[0].TaskKey != "billing:order-1" {
+			t.Errorf("任务键错误: %+v", repo.tasks)
+		}
+	})
+
+	t.Run("重复创建相同任务幂等", func(t *testing.T) {
+		if err := s.CreateTask(ctx, newTask("billing:order-1", "{}")); err != nil {
+			t.Errorf("重复创建应成功: %v", err)
+		}
+		if len(repo.tasks) != 1 {
+			t.Errorf("不应创建新任务: %d", len(repo.tasks))
+		}
+	})
+
+	t.Run("任务键被其他任务占用", func(t *testing.T) {
+		err := s.CreateTask(ctx, newTask("billing:order-1", `{"amount": 1}`))
+		if !errors.Is(err, taskrepo.ErrDuplicateTask) {
+			t.Errorf("期望 ErrDuplicateTask, 得到 %v", err)
+		}
+	})
+
+	t.Run("非法任务键", func(t *testing.T) {
+		for _, key := range []string{"-a", "a b", "a/b", strings.Repeat("a", model.MaxTaskKeyLen+1)} {
+			if err := s.CreateTask(ctx, newTask(key, "{}")); err == nil {
+				t.Errorf("任务键 %q 应非法", key)
+			}
+		}
+	})
+}
+
+func TestPatchTaskAnnotations(t *testing.T) {
+	ctx := context.Background()
+	v := func(s string) *string { return &s }
+	repo := &statusRepo{getRepo{listRepo{tasks: []*model.Task{
+		{TaskKey: "a", Status: model.TaskStatusRunning, Generation: 3, Annotations: map[string]string{"owner": "alice"}},
+	}}}}
+	s := &Scheduler{taskRepo: repo, opts: newOptions()}
+
+	t.Run("合并注解且不增加 generation", func(t *testing.T) {
+		if err := s.PatchTaskAnnotations(ctx, "", "a", map[string]*string{"owner": nil, "ticket": v("OPS-1")}, "bob"); err != nil {
+			t.Fatal(err)
+		}
+		task := repo.tasks[0]
+		if len(task.Annotations) != 1 || task.Annotations["ticket"] != "OPS-1" || task.Generation != 3 {
+			t.Fatalf("期望只剩 ticket 且 generation 不变, 得到 %v, generation %d", task.Annotations, task.Generation)
+		}
+	})
+
+	t.Run("保留前缀不能由用户设置", func(t *testing.T) {
+		if err := s.PatchTaskAnnotations(ctx, "", "a", map[string]*string{model.ReservedPrefix + "x": v("1")}, "bob"); err == nil {
+			t.Fatal("期望返回错误")
+		}
+		if err := s.insertTask(ctx, &model.Task{TaskKey: "b", Annotations: map[string]string{model.ReservedPrefix + "x": "1"}}); err == nil {
+			t.Fatal("创建时期望返回错误")
+		}
+	})
+}
+
+func TestOperateTask(t *testing.T) {
+	ctx := context.Background()
+	task := &model.Task{TaskKey: "a", Status: model.TaskStatusRunning, WantRunStatus: model.TaskStatusRunning, WorkerID: "w1"}
+	repo := &statusRepo{getRepo{listRepo{tasks: []*model.Task{task}}}}
+	s := &Scheduler{taskRepo: repo, opts: newOptions()}
+
+	t.Run("状态和期望状态一起写入", func(t *testing.T) {
+		if err := s.operateTask(ctx, task.Clone(), model.TaskStatusPaused, "", "bob"); err != nil {
+			t.Fatal(err)
+		}
+		if task.Status != model.TaskStatusWaitPaused || task.WantRunStatus != model.TaskStatusPaused {
+			t.Fatalf("期望 wait_paused/paused, 得到 %s/%s", task.Status, task.WantRunStatus)
+		}
+	})
+
+	t.Run("状态已被并发修改时不写入期望状态", func(t *testing.T) {
+		stale := task.Clone()
+		stale.Status = model.TaskStatusRunning
+		if err := s.operateTask(ctx, stale, model.TaskStatusStop, "", "bob"); err == nil {
+			t.Fatal("期望 CAS 失败")
+		}
+		if task.Status != model.TaskStatusWaitPaused || task.WantRunStatus != model.TaskStatusPaused {
+			t.Fatalf("CAS 失败后任务不应变化, 得到 %s/%s", task.Status, task.WantRunStatus)
+		}
+	})
+}
+
+// staleRepo returns tasks read before other schedulers updated them.
+type staleRepo struct {
+	taskrepo.Interface
+	stale map[string]*model.Task
+}
+
+func (r *staleRepo) GetTask(ctx context.Context, taskKey string) (*model.Task, error) {
+	if task, ok := r.stale[taskKey]; ok {
+		return task.Clone(), nil
+	}
+	return r.Interface.GetTask(ctx, taskKey)
+}
+
+func TestUpdateTaskSpec(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.New()
+	if err := repo.CreateTask(ctx, &model.Task{TaskKey: "a", Status: model.TaskStatusRunning, Payload: "v1", Generation: 1}); err != nil {
+		t.Fatal(err)
+	}
+	s := &Scheduler{taskRepo: repo, opts: newOptions()}
+	v := func(s string) *string { return &s }
+
+	t.Run("更新规格并增加 generation", func(t *testing.T) {
+		if err := s.UpdateTaskSpec(ctx, "", "a", "v2", nil, "bob"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.PatchTaskMetadata(ctx, "", "a", map[string]*string{"team": v("infra")}, nil, "bob"); err != nil {
+			t.Fatal(err)
+		}
+		task, _ := repo.GetTask(ctx, "a")
+		if task.Payload != "v2" || task.Labels["team"] != "infra" || task.Generation != 3 {
+			t.Fatalf("期望 v2/infra/3, 得到 %s/%v/%d", task.Payload, task.Labels, task.Generation)
+		}
+	})
+
+	t.Run("generation 已被并发修改时不写入", func(t *testing.T) {
+		stale, _ := repo.GetTask(ctx, "a")
+		if err := s.UpdateTaskSpec(ctx, "", "a", "v3", nil, "alice"); err != nil {
+			t.Fatal(err)
+		}
+		s := &Scheduler{taskRepo: &staleRepo{Interface: repo, stale: map[string]*model.Task{"a": stale}}, opts: newOptions()}
+		if err := s.UpdateTaskSpec(ctx, "", "a", "v4", nil, "bob"); err == nil {
+			t.Fatal("期望 CAS 失败")
+		}
+		if err := s.PatchTaskMetadata(ctx, "", "a", map[string]*string{"team": nil}, nil, "bob"); err == nil {
+			t.Fatal("期望 CAS 失败")
+		}
+		task, _ := repo.GetTask(ctx, "a")
+		if task.Payload != "v3" || task.Labels["team"] != "infra" || task.Generation != 4 {
+			t.Fatalf("CAS 失败后任务不应变化, 得到 %s/%v/%d", task.Payload, task.Labels, task.Generation)
+		}
+	})
+}
+
+func TestExportImportTasks(t *testing.T) {
+	ctx := context.Background()
+	src := &Scheduler{taskRepo: memory.New(), opts: newOptions()}
+	for _, task := range []*model.Task{
+		{TaskKey: "a", BizID: "1", BizType: "report", Type: "noop", Payload: `{"n":1}`, Status: model.TaskStatusSuccess, Labels: map[string]string{"team": "x"}},
+		{TaskKey: "b", BizID: "2", BizType: "email", Type: "noop", Status: model.TaskStatusRunning, WantRunStatus: model.TaskStatusRunning},
+	} {
+		if err := src.taskRepo.CreateTask(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf strings.Builder
+	if n, err := src.ExportTasks(ctx, nil, &buf); err != nil || n != 2 {
+		t.Fatalf("导出失败: %d %v", n, err)
+	}
+
+	t.Run("导出后导入保留任务", func(t *testing.T) {
+		dst := &Scheduler{taskRepo: memory.New(), opts: newOptions()}
+		if n, err := dst.ImportTasks(ctx, strings.NewReader(buf.String())); err != nil || n != 2 {
+			t.Fatalf("导入失败: %d %v", n, err)
+		}
+		for _, key := range []string{"a", "b"} {
+			want, _ := src.taskRepo.GetTask(ctx, key)
+			got, err := dst.taskRepo.GetTask(ctx, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.BizType != want.BizType || got.Payload != want.Payload || got.Status != want.Status ||
+				got.WantRunStatus != want.WantRunStatus || got.Labels["team"] != want.Labels["team"] {
+				t.Errorf("任务 %s 导入后不一致: %+v, want %+v", key, got, want)
+			}
+		}
+	})
+
+	t.Run("无权访问的业务类型不能导入", func(t *testing.T) {
+		dst := &Scheduler{taskRepo: memory.New(), opts: newOptions()}
+		ctx := auth.WithPrincipal(ctx, &auth.Principal{Name: "bob", Role: auth.RoleAdmin, BizTypes: []string{"report"}})
+		n, err := dst.ImportTasks(ctx, strings.NewReader(buf.String()))
+		var forbidden *auth.ForbiddenError
+		if !errors.As(err, &forbidden) || n != 1 {
+			t.Fatalf("期望导入 1 个后拒绝, 得到 %d %v", n, err)
+		}
+	})
+
+	t.Run("与创建一样校验任务", func(t *testing.T) {
+		dst := &Scheduler{taskRepo: memory.New(), opts: newOptions()}
+		line := `{"task_key":"c","biz_type":"report","gates":[{"name":"g","kind":"http","url":"http://169.254.169.254/"}]}`
+		if n, err := dst.ImportTasks(ctx, strings.NewReader(line)); err == nil || n != 0 {
+			t.Fatalf("期望非法门控被拒绝, 得到 %d %v", n, err)
+		}
+	})
+}
@@ -1,12 +1,16 @@
 package scheduler
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/samber/lo"
+	"github.com/xyzbit/minitaskx/core/components/auth"
 	"github.com/xyzbit/minitaskx/core/components/log"
 	"github.com/xyzbit/minitaskx/core/model"
 )
@@ -15,13 +19,56 @@ type HttpServer struct {
 	scheduler *Scheduler
 }
 
+type createTaskRequest struct {
+	// optional, key supplied by caller makes creating idempotent.
+	TaskKey string           `json:"task_key"`
+	BizID   string           `json:"biz_id"`
+	BizType string           `json:"biz_type"`
+	Type    string           `json:"type"`
+	Payload string           `json:"payload"`
+	SLA     *model.SLAPolicy `json:"sla"`
+	// "service" keeps Replicas replica tasks running.
+	Kind     model.TaskKind `json:"kind"`
+	Replicas int            `json:"replicas"`
+	// retry failed task with backoff.
+	Retry *model.RetryPolicy `json:"retry"`
+	// health checks run by worker.
+	Probes *model.Probes `json:"probes"`
+	// higher priority tasks are evicted later under worker pressure.
+	Priority int `json:"priority"`
+	// name of the priority class, overrides priority.
+	PriorityClass string `json:"priority_class"`
+	// gang_size tasks of gang are placed together or not at all.
+	Gang     string `json:"gang"`
+	GangSize int    `json:"gang_size"`
+	// run at cron schedule in timezone.
+	Schedule *model.CronSchedule `json:"schedule"`
+	// external conditions must be satisfied before the task is scheduled.
+	Gates []model.Gate `json:"gates"`
+	// task is not scheduled until approved.
+	ApprovalRequired bool `json:"approval_required"`
+	// tasks created once the task succeeds or fails.
+	FollowUps []model.FollowUp `json:"follow_ups"`
+	// undoes the task once a later step of its follow-ups fails.
+	Compensation *model.Compensation `json:"compensation"`
+	// want status changes applied at given times, eg. pause at 02:00.
+	ScheduledChanges []model.ScheduledChange `json:"scheduled_changes"`
+	// notes of the task, changing them later never reconciles the task.
+	Annotations map[string]string `json:"annotations"`
+	// resources the task is expected to use, used by bin packing placement.
+	Resources *model.ResourceRequest `json:"resources"`
+	// called by worker before the executor is stopped.
+	PreStop *model.PreStopHook `json:"pre_stop"`
+	// tasks of the same key are assigned to the same worker, eg. customer id.
+	LocalityKey string `json:"locality_key"`
+	// runs with or apart from tasks of labels.
+	Affinity *model.Affinity `json:"affinity"`
+	// runtime labels the worker must have, eg. {"gpu": "true"}.
+	WorkerSelector map[string]string `json:"worker_selector"`
+}
+
 func (s *HttpServer) CreateTask(c *gin.Context) {
-	var req struct {
-		BizID   string `json:"biz_id"`
-		BizType string `json:"biz_type"`
-		Type    string `json:"type"`
-		Payload string `json:"payload"`
-	}
+	var req createTaskRequest
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -31,59 +78,169 @@ func (s *HttpServer) CreateTask(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid params"})
 		return
 	}
+	if err := auth.CheckBizType(c.Request.Context(), req.BizType); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if req.TaskKey != "" {
+		if err := model.ValidateTaskKey(req.TaskKey); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if _, ok := s.scheduler.priorityClass(req.PriorityClass); req.PriorityClass != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "priority class " + req.PriorityClass + " not found"})
+		return
+	}
+	if req.Resources != nil {
+		if err := req.Resources.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Kind == model.TaskKindService && req.Replicas <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "replicas of service must be positive"})
+		return
+	}
 
 	now := time.Now()
 	if err := s.scheduler.CreateTask(c.Request.Context(), &model.Task{
+		TaskKey:   req.TaskKey,
 		BizID:     req.BizID,
 		BizType:   req.BizType,
 		Type:      req.Type,
 		Payload:   req.Payload,
+		SLA:       req.SLA,
+		Kind:      req.Kind,
+		Replicas:  req.Replicas,
+		Retry:     req.Retry,
+		Probes:    req.Probes,
+		Priority:  req.Priority,
+		Gang:      req.Gang,
+		GangSize:  req.GangSize,
+		Schedule:  req.Schedule,
+		Gates:     req.Gates,
+		FollowUps: req.FollowUps,
 		NextRunAt: &now,
+
+		ApprovalRequired: req.ApprovalRequired,
+		Compensation:     req.Compensation,
+		ScheduledChanges: req.ScheduledChanges,
+		PriorityClass:    req.PriorityClass,
+		Annotations:      req.Annotations,
+		Resources:        req.Resources,
+		PreStop:          req.PreStop,
+		LocalityKey:      req.LocalityKey,
+		Affinity:         req.Affinity,
+		WorkerSelector:   req.WorkerSelector,
 	}); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "任务分配成功"})
 }
 
+type listTaskRequest struct {
+	BizIDs  string `json:"biz_ids" form:"biz_ids"` // a,b,c
+	BizType string `json:"biz_type" form:"biz_type"`
+	Type    string `json:"type" form:"type"`
+	Limit   int    `json:"limit" form:"limit"`   // default 20
+	Offset  int    `json:"offset" form:"offset"` // default 0
+	// only list archived tasks.
+	Archived bool `json:"archived" form:"archived"`
+}
+
 // ListTask 查询任务列表
 func (s *HttpServer) ListTask(c *gin.Context) {
-	var req struct {
-		BizIDs  string `json:"biz_ids" form:"biz_ids"` // a,b,c
-		BizType string `json:"biz_type" form:"biz_type"`
-		Type    string `json:"type" form:"type"`
-		Limit   int    `json:"limit" form:"limit"`   // default 20
-		Offset  int    `json:"offset" form:"offset"` // default 0
-	}
+	var req listTaskRequest
 	if err := c.BindQuery(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if err := auth.CheckBizType(c.Request.Context(), req.BizType); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
 	if req.Limit == 0 {
 		req.Limit = 20
 	}
 	tasks, err := s.scheduler.ListTask(c.Request.Context(), &model.TaskFilter{
-		BizIDs:  strings.Split(req.BizIDs, ","),
-		BizType: req.BizType,
-		Type:    req.Type,
-		Limit:   req.Limit,
-		Offset:  req.Offset,
+		BizIDs:   strings.Split(req.BizIDs, ","),
+		BizType:  req.BizType,
+		Type:     req.Type,
+		Limit:    req.Limit,
+		Offset:   req.Offset,
+		Archived: req.Archived,
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": tasks})
+	c.JSON(http.StatusOK, gin.H{"data": tasks, "latency": model.SummarizeLatency(tasks)})
 }
 
-func (s *HttpServer) OperateTask(c *gin.Context) {
-	var req struct {
-		BizID   string `json:"biz_id"`
-		TaskKey string `json:"task_key"`
-		Status  string `json:"status"`
+// GetTask 查询单个任务
+func (s *HttpServer) GetTask(c *gin.Context) {
+	taskKey := c.Query("task_key")
+	if taskKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_key is required"})
+		return
 	}
+	task, err := s.scheduler.GetTask(c.Request.Context(), taskKey)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	summary, err := s.scheduler.SummarizeTask(c.Request.Context(), task)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": task, "summary": summary})
+}
+
+// DescribeTask 查询任务的聚合视图: 规格, 状态, 运行尝试, 事件及审计记录
+func (s *HttpServer) DescribeTask(c *gin.Context) {
+	taskKey := c.Query("task_key")
+	if taskKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_key is required"})
+		return
+	}
+	d, err := s.scheduler.DescribeTask(c.Request.Context(), taskKey)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": d})
+}
+
+// ListAttempts 查询任务的运行尝试记录
+func (s *HttpServer) ListAttempts(c *gin.Context) {
+	taskKey := c.Query("task_key")
+	if taskKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_key is required"})
+		return
+	}
+	attempts, err := s.scheduler.ListAttempts(c.Request.Context(), taskKey)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": attempts})
+}
+
+type operateTaskRequest struct {
+	BizID    string `json:"biz_id"`
+	TaskKey  string `json:"task_key"`
+	Status   string `json:"status"`
+	Operator string `json:"operator"`
+}
+
+func (s *HttpServer) OperateTask(c *gin.Context) {
+	var req operateTaskRequest
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -99,9 +256,716 @@ func (s *HttpServer) OperateTask(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
 		return
 	}
-	if err := s.scheduler.OperateTask(c.Request.Context(), req.BizID, req.TaskKey, ts); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	operator := auth.OperatorOf(c, req.Operator)
+	if operator == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "operator is required"})
+		return
+	}
+	if err := s.scheduler.OperateTask(c.Request.Context(), req.BizID, req.TaskKey, ts, operator); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "任务操作成功"})
 }
+
+type rerunTaskRequest struct {
+	BizID    string `json:"biz_id"`
+	TaskKey  string `json:"task_key"`
+	Reason   string `json:"reason"`
+	Operator string `json:"operator"`
+}
+
+// RerunTask 重新运行已结束的任务
+func (s *HttpServer) RerunTask(c *gin.Context) {
+	var req rerunTaskRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	operator := auth.OperatorOf(c, req.Operator)
+	if operator == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "operator is required"})
+		return
+	}
+
+	if err := s.scheduler.RerunTask(c.Request.Context(), req.BizID, req.TaskKey, req.Reason, operator); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "任务重新运行成功"})
+}
+
+type scheduleChangesRequest struct {
+	BizID   string `json:"biz_id"`
+	TaskKey string `json:"task_key"`
+	// replace pending changes, empty cancels them.
+	Changes  []model.ScheduledChange `json:"changes"`
+	Operator string                  `json:"operator"`
+}
+
+// ScheduleChanges 计划任务未来的期望状态变更, 如 02:00 暂停, 04:00 恢复
+func (s *HttpServer) ScheduleChanges(c *gin.Context) {
+	var req scheduleChangesRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	operator := auth.OperatorOf(c, req.Operator)
+	if operator == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "operator is required"})
+		return
+	}
+	if err := model.ValidateScheduledChanges(req.Changes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.scheduler.ScheduleChanges(c.Request.Context(), req.BizID, req.TaskKey, req.Changes, operator); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "计划状态变更成功"})
+}
+
+type bulkOperateRequest struct {
+	BizIDs  []string          `json:"biz_ids"`
+	BizType string            `json:"biz_type"`
+	Type    string            `json:"type"`
+	Labels  map[string]string `json:"labels"`
+	// any of statuses matches.
+	Statuses []string `json:"statuses"`
+	Reason   string   `json:"reason"`
+	Operator string   `json:"operator"`
+}
+
+// StopTasks 批量停止匹配条件的任务
+func (s *HttpServer) StopTasks(c *gin.Context) {
+	s.operateTasks(c, s.scheduler.StopTasks)
+}
+
+// PauseTasks 批量暂停匹配条件的任务
+func (s *HttpServer) PauseTasks(c *gin.Context) {
+	s.operateTasks(c, s.scheduler.PauseTasks)
+}
+
+func (s *HttpServer) operateTasks(c *gin.Context, operate func(ctx context.Context, filter *model.TaskFilter, reason, operator string) (*BulkResult, error)) {
+	var req bulkOperateRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	operator := auth.OperatorOf(c, req.Operator)
+	if operator == "" || req.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "operator and reason are required"})
+		return
+	}
+
+	filter := &model.TaskFilter{BizIDs: req.BizIDs, BizType: req.BizType, Type: req.Type, Labels: req.Labels}
+	for _, status := range req.Statuses {
+		filter.Statuses = append(filter.Statuses, model.TaskStatus(status))
+	}
+	result, err := operate(c.Request.Context(), filter, req.Reason, operator)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error(), "data": result})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+type deleteTaskRequest struct {
+	BizID    string `json:"biz_id"`
+	TaskKey  string `json:"task_key"`
+	Operator string `json:"operator"`
+}
+
+func (s *HttpServer) DeleteTask(c *gin.Context) {
+	var req deleteTaskRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	operator := auth.OperatorOf(c, req.Operator)
+	if operator == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "operator is required"})
+		return
+	}
+
+	if err := s.scheduler.DeleteTask(c.Request.Context(), req.BizID, req.TaskKey, operator); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "任务删除成功"})
+}
+
+type exportTasksRequest struct {
+	BizIDs  string `json:"biz_ids" form:"biz_ids"` // a,b,c
+	BizType string `json:"biz_type" form:"biz_type"`
+	Type    string `json:"type" form:"type"`
+	Limit   int    `json:"limit" form:"limit"` // default all
+}
+
+// ExportTasks 导出任务, 返回 JSON lines
+func (s *HttpServer) ExportTasks(c *gin.Context) {
+	var req exportTasksRequest
+	if err := c.BindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := auth.CheckBizType(c.Request.Context(), req.BizType); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := &model.TaskFilter{
+		BizType: req.BizType,
+		Type:    req.Type,
+		Limit:   req.Limit,
+	}
+	if req.BizIDs != "" {
+		filter.BizIDs = strings.Split(req.BizIDs, ",")
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	n, err := s.scheduler.ExportTasks(c.Request.Context(), filter, c.Writer)
+	if err != nil {
+		// header has been written, can only log the error.
+		log.Error("export tasks failed after %d tasks: %v", n, err)
+	}
+}
+
+// ImportTasks 导入任务, 请求体为 ExportTasks 导出的 JSON lines
+func (s *HttpServer) ImportTasks(c *gin.Context) {
+	n, err := s.scheduler.ImportTasks(c.Request.Context(), c.Request.Body)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error(), "imported": n})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "任务导入成功", "imported": n})
+}
+
+// ListAudits 查询任务的操作审计记录
+func (s *HttpServer) ListAudits(c *gin.Context) {
+	taskKey := c.Query("task_key")
+	if taskKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_key is required"})
+		return
+	}
+	entries, err := s.scheduler.ListAudits(c.Request.Context(), taskKey)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// ListEvents 查询任务的事件
+func (s *HttpServer) ListEvents(c *gin.Context) {
+	taskKey := c.Query("task_key")
+	if taskKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_key is required"})
+		return
+	}
+	events, err := s.scheduler.ListEvents(c.Request.Context(), taskKey)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": events})
+}
+
+type approveTaskRequest struct {
+	TaskKey  string `json:"task_key"`
+	Reason   string `json:"reason"`
+	Operator string `json:"operator"`
+}
+
+// ApproveTask 审批通过等待审批的任务
+func (s *HttpServer) ApproveTask(c *gin.Context) {
+	s.approve(c, s.scheduler.ApproveTask)
+}
+
+// RejectTask 拒绝等待审批的任务, 任务将被停止
+func (s *HttpServer) RejectTask(c *gin.Context) {
+	s.approve(c, s.scheduler.RejectTask)
+}
+
+func (s *HttpServer) approve(c *gin.Context, decide func(ctx context.Context, taskKey, reason, operator string) error) {
+	var req approveTaskRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	operator := auth.OperatorOf(c, req.Operator)
+	if req.TaskKey == "" || operator == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_key and operator are required"})
+		return
+	}
+	if err := decide(c.Request.Context(), req.TaskKey, req.Reason, operator); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+type forceFinishRequest struct {
+	TaskKey  string `json:"task_key"`
+	Status   string `json:"status"`
+	Reason   string `json:"reason"`
+	Operator string `json:"operator"`
+}
+
+// ForceFinishTask 强制结束卡在非终态的任务
+func (s *HttpServer) ForceFinishTask(c *gin.Context) {
+	var req forceFinishRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	operator := auth.OperatorOf(c, req.Operator)
+	if req.TaskKey == "" || operator == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_key and operator are required"})
+		return
+	}
+
+	if err := s.scheduler.ForceFinishTask(c.Request.Context(), req.TaskKey, model.TaskStatus(req.Status), req.Reason, operator); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "任务已强制结束"})
+}
+
+type forceReassignRequest struct {
+	TaskKey  string `json:"task_key"`
+	WorkerID string `json:"worker_id"`
+	Reason   string `json:"reason"`
+	Operator string `json:"operator"`
+}
+
+// ForceReassignTask 强制将任务重新分配到指定 worker
+func (s *HttpServer) ForceReassignTask(c *gin.Context) {
+	var req forceReassignRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	operator := auth.OperatorOf(c, req.Operator)
+	if req.TaskKey == "" || req.WorkerID == "" || operator == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_key, worker_id and operator are required"})
+		return
+	}
+
+	if err := s.scheduler.ForceReassignTask(c.Request.Context(), req.TaskKey, req.WorkerID, req.Reason, operator); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "任务已强制重新分配"})
+}
+
+// previewPlacementRequest is the spec of a task deciding its placement.
+type previewPlacementRequest struct {
+	// optional, canary and locality of an existing task.
+	TaskKey        string            `json:"task_key"`
+	BizType        string            `json:"biz_type"`
+	Type           string            `json:"type"`
+	Stains         map[string]string `json:"stains"`
+	WorkerSelector map[string]string `json:"worker_selector"`
+	// eg. namespace and executor version.
+	Labels      map[string]string      `json:"labels"`
+	LocalityKey string                 `json:"locality_key"`
+	Affinity    *model.Affinity        `json:"affinity"`
+	Resources   *model.ResourceRequest `json:"resources"`
+}
+
+// PreviewPlacement 预览任务会被分配到哪个 worker, 不会持久化任何数据
+func (s *HttpServer) PreviewPlacement(c *gin.Context) {
+	var req previewPlacementRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := auth.CheckBizType(c.Request.Context(), req.BizType); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, s.scheduler.PreviewPlacement(&model.Task{
+		TaskKey:        req.TaskKey,
+		BizType:        req.BizType,
+		Type:           req.Type,
+		Stains:         req.Stains,
+		WorkerSelector: req.WorkerSelector,
+		Labels:         req.Labels,
+		LocalityKey:    req.LocalityKey,
+		Affinity:       req.Affinity,
+		Resources:      req.Resources,
+	}))
+}
+
+type updateTaskSpecRequest struct {
+	BizID    string            `json:"biz_id"`
+	TaskKey  string            `json:"task_key"`
+	Payload  string            `json:"payload"`
+	Labels   map[string]string `json:"labels"`
+	Operator string            `json:"operator"`
+}
+
+// UpdateTaskSpec 更新运行中任务的 payload/labels
+func (s *HttpServer) UpdateTaskSpec(c *gin.Context) {
+	var req updateTaskSpecRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	operator := auth.OperatorOf(c, req.Operator)
+	if (req.BizID == "" && req.TaskKey == "") || req.Payload == "" || operator == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "biz_id or task_key, payload and operator are required"})
+		return
+	}
+
+	if err := s.scheduler.UpdateTaskSpec(c.Request.Context(), req.BizID, req.TaskKey, req.Payload, req.Labels, operator); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "任务更新成功"})
+}
+
+type patchTaskMetadataRequest struct {
+	BizID   string `json:"biz_id"`
+	TaskKey string `json:"task_key"`
+	// null removes the key.
+	Labels   map[string]*string `json:"labels"`
+	Extra    map[string]*string `json:"extra"`
+	Operator string             `json:"operator"`
+}
+
+// PatchTaskMetadata 合并更新任务的 labels/extra, 值为 null 时删除
+func (s *HttpServer) PatchTaskMetadata(c *gin.Context) {
+	var req patchTaskMetadataRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	operator := auth.OperatorOf(c, req.Operator)
+	if (req.BizID == "" && req.TaskKey == "") || (len(req.Labels) == 0 && len(req.Extra) == 0) || operator == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "biz_id or task_key, labels or extra and operator are required"})
+		return
+	}
+
+	if err := s.scheduler.PatchTaskMetadata(c.Request.Context(), req.BizID, req.TaskKey, req.Labels, req.Extra, operator); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "任务更新成功"})
+}
+
+type patchTaskAnnotationsRequest struct {
+	BizID   string `json:"biz_id"`
+	TaskKey string `json:"task_key"`
+	// null removes the key.
+	Annotations map[string]*string `json:"annotations"`
+	Operator    string             `json:"operator"`
+}
+
+// PatchTaskAnnotations 合并更新任务的 annotations, 值为 null 时删除, 不会触发任务调和
+func (s *HttpServer) PatchTaskAnnotations(c *gin.Context) {
+	var req patchTaskAnnotationsRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	operator := auth.OperatorOf(c, req.Operator)
+	if (req.BizID == "" && req.TaskKey == "") || len(req.Annotations) == 0 || operator == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "biz_id or task_key, annotations and operator are required"})
+		return
+	}
+	if err := model.ValidateAnnotations(req.Annotations); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.scheduler.PatchTaskAnnotations(c.Request.Context(), req.BizID, req.TaskKey, req.Annotations, operator); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "任务更新成功"})
+}
+
+func (s *HttpServer) ListCanaries(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"canaries": s.scheduler.Canaries()})
+}
+
+func (s *HttpServer) SetCanary(c *gin.Context) {
+	var req CanaryPolicy
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.scheduler.SetCanary(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+type removeCanaryRequest struct {
+	TaskType string `json:"task_type"`
+}
+
+func (s *HttpServer) RemoveCanary(c *gin.Context) {
+	var req removeCanaryRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.scheduler.RemoveCanary(req.TaskType)
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+func (s *HttpServer) ListWindows(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"windows": s.scheduler.Windows()})
+}
+
+func (s *HttpServer) SetWindow(c *gin.Context) {
+	var req Window
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.scheduler.SetWindow(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+type removeWindowRequest struct {
+	Name string `json:"name"`
+}
+
+func (s *HttpServer) RemoveWindow(c *gin.Context) {
+	var req removeWindowRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.scheduler.RemoveWindow(req.Name)
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+// ListCordons 列出 cordon 的 worker, cordon 只保存在 leader 内存中, 非 leader 返回 503
+func (s *HttpServer) ListCordons(c *gin.Context) {
+	cordons, err := s.scheduler.Cordons()
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"cordons": cordons})
+}
+
+type cordonRequest struct {
+	WorkerID string `json:"worker_id"`
+	Reason   string `json:"reason"`
+	Operator string `json:"operator"`
+}
+
+// CordonWorker 停止向 worker 分配新任务, 已分配的任务继续运行.
+// cordon 不持久化, 只能在 leader 上操作, 非 leader 返回 503
+func (s *HttpServer) CordonWorker(c *gin.Context) {
+	var req cordonRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	operator := auth.OperatorOf(c, req.Operator)
+	if err := s.scheduler.CordonWorker(c.Request.Context(), req.WorkerID, req.Reason, operator); err != nil {
+		c.JSON(cordonErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+// UncordonWorker 恢复向 worker 分配新任务
+func (s *HttpServer) UncordonWorker(c *gin.Context) {
+	var req cordonRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	operator := auth.OperatorOf(c, req.Operator)
+	if err := s.scheduler.UncordonWorker(c.Request.Context(), req.WorkerID, operator); err != nil {
+		c.JSON(cordonErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+func cordonErrorStatus(err error) int {
+	if errors.Is(err, ErrNotLeader) {
+		return errorStatus(err)
+	}
+	return http.StatusBadRequest
+}
+
+type watchTasksRequest struct {
+	TaskKey string `form:"task_key"`
+	BizIDs  string `form:"biz_ids"` // a,b,c
+	BizType string `form:"biz_type"`
+	Type    string `form:"type"`
+	Limit   int    `form:"limit"` // default 100
+}
+
+// WatchTasks 以 SSE 推送任务状态变化, 指定 task_key 时只推送该任务
+func (s *HttpServer) WatchTasks(c *gin.Context) {
+	var req watchTasksRequest
+	if err := c.BindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var (
+		events <-chan TaskEvent
+		err    error
+	)
+	ctx := c.Request.Context()
+	if req.TaskKey != "" {
+		events, err = s.scheduler.WatchTask(ctx, req.TaskKey)
+	} else {
+		filter := &model.TaskFilter{BizType: req.BizType, Type: req.Type, Limit: req.Limit}
+		if req.BizIDs != "" {
+			filter.BizIDs = strings.Split(req.BizIDs, ",")
+		}
+		if filter.Limit == 0 {
+			filter.Limit = 100
+		}
+		events, err = s.scheduler.WatchTasks(ctx, filter)
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		event, ok := <-events
+		if !ok {
+			return false
+		}
+		c.SSEvent("task", event)
+		return true
+	})
+}
+
+// maxGraphQLBodyBytes caps the body of graphql requests.
+const maxGraphQLBodyBytes = 1 << 20
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+// GraphQL 以 GraphQL 查询任务, 一次请求获取任务的指定字段、运行记录和审计记录
+func (s *HttpServer) GraphQL(c *gin.Context) {
+	var req graphQLRequest
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxGraphQLBodyBytes)
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{"message": err.Error()}}})
+		return
+	}
+	data, err := s.scheduler.QueryGraphQL(c.Request.Context(), req.Query, req.Variables)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"errors": []gin.H{{"message": err.Error()}}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+type dashboardRequest struct {
+	BizType string `form:"biz_type"`
+	Type    string `form:"type"`
+	Window  string `form:"window"` // eg. 1h, default 1h
+	Bucket  string `form:"bucket"` // eg. 5m, default 5m
+	Limit   int    `form:"limit"`  // default 10
+}
+
+// Dashboard 查询任务聚合统计, 供监控页面使用
+func (s *HttpServer) Dashboard(c *gin.Context) {
+	var req dashboardRequest
+	if err := c.BindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter := DashboardFilter{BizType: req.BizType, Type: req.Type, Limit: req.Limit}
+	if req.Window != "" {
+		window, err := time.ParseDuration(req.Window)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.Since = time.Now().Add(-window)
+	}
+	if req.Bucket != "" {
+		bucket, err := time.ParseDuration(req.Bucket)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filter.Bucket = bucket
+	}
+
+	d, err := s.scheduler.Dashboard(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": d})
+}
+
+// ListCapabilities 查询可用 worker 注册的任务类型及 payload schema
+func (s *HttpServer) ListCapabilities(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": s.scheduler.ListCapabilities()})
+}
+
+// ListPriorityClasses 查询优先级类别
+func (s *HttpServer) ListPriorityClasses(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": s.scheduler.ListPriorityClasses()})
+}
+
+type estimatesRequest struct {
+	Type    string `form:"type"`
+	BizType string `form:"biz_type"`
+}
+
+// Estimates 查询按任务类型和业务类型统计的历史运行时长 p50/p95
+func (s *HttpServer) Estimates(c *gin.Context) {
+	var req estimatesRequest
+	if err := c.BindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	estimates, err := s.scheduler.Estimates(c.Request.Context(), req.Type, req.BizType)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": estimates})
+}
+
+type usageRequest struct {
+	BizType string `form:"biz_type"`
+}
+
+// Usage 查询业务类型本月的运行时长、成本和预算
+func (s *HttpServer) Usage(c *gin.Context) {
+	var req usageRequest
+	if err := c.BindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	usage, err := s.scheduler.Usage(c.Request.Context(), req.BizType)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": usage})
+}
@@ -0,0 +1,183 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// runServiceController keep replicas of services running, only leader works.
+func (s *Scheduler) runServiceController() {
+	ticker := time.NewTicker(s.opts.serviceSyncInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		amILeader, _, err := s.amILeader()
+		if err != nil {
+			log.Error("获取 leader 状态失败: %v", err)
+			continue
+		}
+		if !amILeader {
+			continue
+		}
+
+		if err := s.syncServices(context.Background()); err != nil {
+			log.Error("服务副本同步失败: %v", err)
+		}
+	}
+}
+
+func (s *Scheduler) syncServices(ctx context.Context) error {
+	keys, err := s.taskRepo.ListRunnableTasks(ctx, "")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	tasks, err := taskrepo.ChunkedBatchGetTask(
+		ctx, s.taskRepo.BatchGetTask, keys,
+		taskrepo.DefaultBatchGetChunkSize, taskrepo.DefaultBatchGetParallelism,
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, task := range tasks {
+		if !task.IsService() {
+			continue
+		}
+		if err := s.syncService(ctx, task); err != nil {
+			log.Error("服务[%s]副本同步失败: %v", task.TaskKey, err)
+		}
+	}
+	return nil
+}
+
+// replicas listed per page.
+const replicaPageSize = 500
+
+// unfinished statuses of replicas, finished replicas pile up as a service churns.
+var liveReplicaStatuses = []model.TaskStatus{
+	model.TaskStatusWaitApproval,
+	model.TaskStatusWaitScheduling,
+	model.TaskStatusUnschedulable,
+	model.TaskStatusWaitRunning,
+	model.TaskStatusRunning,
+	model.TaskStatusWaitPaused,
+	model.TaskStatusPaused,
+	model.TaskStatusWaitStop,
+}
+
+// liveReplicas returns unfinished replicas of service by index, all pages are listed.
+func (s *Scheduler) liveReplicas(ctx context.Context, service *model.Task) (map[int][]*model.Task, error) {
+	live := make(map[int][]*model.Task)
+	filter := &model.TaskFilter{
+		Labels:   map[string]string{model.LabelService: service.TaskKey},
+		Statuses: liveReplicaStatuses,
+		Limit:    replicaPageSize,
+	}
+	for {
+		replicas, err := s.taskRepo.ListTask(ctx, filter)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, r := range replicas {
+			// repo may ignore label filter.
+			if r.Labels[model.LabelService] != service.TaskKey {
+				continue
+			}
+			index, ok := r.ReplicaIndex()
+			if !ok || r.Status.IsFinalStatus() || r.IsDeleted() {
+				continue
+			}
+			live[index] = append(live[index], r)
+		}
+		if len(replicas) < filter.Limit {
+			return live, nil
+		}
+		filter.Offset += len(replicas)
+	}
+}
+
+// syncService converges live replicas of service to its want status and replicas.
+func (s *Scheduler) syncService(ctx context.Context, service *model.Task) error {
+	live, err := s.liveReplicas(ctx, service)
+	if err != nil {
+		return err
+	}
+
+	// service is deleted or stopped, stop all replicas then finish the service.
+	if service.IsDeleted() || service.WantRunStatus == model.TaskStatusStop {
+		for _, rs := range live {
+			for _, r := range rs {
+				s.stopReplica(ctx, r, "服务已停止")
+			}
+		}
+		if len(live) == 0 && !service.IsDeleted() {
+			return s.taskRepo.UpdateTask(ctx, &model.Task{
+				TaskKey: service.TaskKey,
+				Status:  model.TaskStatusStop,
+				Msg:     "all replicas stopped",
+			})
+		}
+		return nil
+	}
+
+	for index, rs := range live {
+		for i, r := range rs {
+			// scale down, or duplicated replica of the same index.
+			if index >= service.Replicas || i > 0 {
+				s.stopReplica(ctx, r, "副本数缩减")
+				continue
+			}
+			if r.Generation < service.Generation {
+				if err := s.taskRepo.UpdateTask(ctx, &model.Task{
+					TaskKey:    r.TaskKey,
+					Payload:    service.Payload,
+					Generation: service.Generation,
+					Operator:   model.OperatorScheduler,
+				}); err != nil {
+					log.Error("服务[%s]副本[%s]更新 spec 失败: %v", service.TaskKey, r.TaskKey, err)
+				}
+			}
+		}
+	}
+	// replace exited replicas.
+	for index := 0; index < service.Replicas; index++ {
+		if len(live[index]) > 0 {
+			continue
+		}
+		replica := service.NewReplica(index)
+		now := time.Now()
+		replica.NextRunAt = &now
+		if err := s.createTask(ctx, replica); err != nil {
+			log.Error("服务[%s]创建副本[%d]失败: %v", service.TaskKey, index, err)
+			continue
+		}
+		log.Info("服务[%s]创建副本[%d]: %s", service.TaskKey, index, replica.TaskKey)
+	}
+	return nil
+}
+
+func (s *Scheduler) stopReplica(ctx context.Context, replica *model.Task, reason string) {
+	if replica.WantRunStatus == model.TaskStatusStop {
+		return
+	}
+	status := model.TaskStatusStop.PreWaitStatus()
+	if replica.WorkerID == "" {
+		// not assigned yet, no executor to stop.
+		status = model.TaskStatusStop
+	}
+	if err := s.taskRepo.UpdateTask(ctx, &model.Task{
+		TaskKey:       replica.TaskKey,
+		Status:        status,
+		WantRunStatus: model.TaskStatusStop,
+		Operator:      model.OperatorScheduler,
+		Msg:           reason,
+	}); err != nil {
+		log.Error("停止副本[%s]失败: %v", replica.TaskKey, err)
+	}
+}
@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// serviceRepo also applies status, payload and generation updates of replicas.
+type serviceRepo struct {
+	createRepo
+	purged []string
+}
+
+func (r *serviceRepo) UpdateTask(ctx context.Context, update *model.Task) error {
+	for _, t := range r.tasks {
+		if t.TaskKey != update.TaskKey {
+			continue
+		}
+		if update.Status != "" {
+			t.Status = update.Status
+		}
+		if update.Payload != "" {
+			t.Payload = update.Payload
+		}
+		if update.Generation != 0 {
+			t.Generation = update.Generation
+		}
+	}
+	return r.statusRepo.UpdateTask(ctx, update)
+}
+
+func (r *serviceRepo) PurgeTask(_ context.Context, taskKey string) error {
+	r.purged = append(r.purged, taskKey)
+	return nil
+}
+
+func TestServiceController(t *testing.T) {
+	ctx := context.Background()
+	service := &model.Task{
+		TaskKey:       "svc",
+		Kind:          model.TaskKindService,
+		Type:          "web",
+		Payload:       "v1",
+		Replicas:      2,
+		Generation:    1,
+		Status:        model.TaskStatusRunning,
+		WantRunStatus: model.TaskStatusRunning,
+	}
+	repo := &serviceRepo{createRepo: createRepo{statusRepo{getRepo{listRepo{tasks: []*model.Task{service}}}}}}
+	s := &Scheduler{taskRepo: repo, opts: newOptions()}
+	s.setAvailableWorkers(nil)
+
+	live := func() map[int][]*model.Task {
+		t.Helper()
+		live, err := s.liveReplicas(ctx, service)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return live
+	}
+	sync := func() {
+		t.Helper()
+		if err := s.syncService(ctx, service); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("扩容到期望副本数", func(t *testing.T) {
+		sync()
+		sync()
+		if got := live(); len(got) != 2 || len(got[0]) != 1 || len(got[1]) != 1 {
+			t.Fatalf("期望 2 个副本各一个, 得到 %v", got)
+		}
+	})
+
+	t.Run("替换退出的副本", func(t *testing.T) {
+		exited := live()[0][0]
+		exited.Status = model.TaskStatusFailed
+		sync()
+		got := live()
+		if len(got[0]) != 1 || got[0][0].TaskKey == exited.TaskKey {
+			t.Fatalf("期望副本 0 被替换, 得到 %v", got[0])
+		}
+	})
+
+	t.Run("大量已结束副本不影响存活副本", func(t *testing.T) {
+		for i := 0; i < replicaPageSize; i++ {
+			r := service.NewReplica(0)
+			r.TaskKey = fmt.Sprintf("finished-%d", i)
+			r.Status = model.TaskStatusStop
+			repo.tasks = append([]*model.Task{r}, repo.tasks...)
+		}
+		n := len(repo.tasks)
+		sync()
+		if len(repo.tasks) != n {
+			t.Fatalf("期望不创建新副本, 任务数 %d -> %d", n, len(repo.tasks))
+		}
+	})
+
+	t.Run("按代次滚动更新副本", func(t *testing.T) {
+		service.Payload, service.Generation = "v2", 2
+		sync()
+		for index, rs := range live() {
+			if rs[0].Generation != 2 || rs[0].Payload != "v2" {
+				t.Errorf("期望副本 %d 更新到代次 2, 得到 %d %s", index, rs[0].Generation, rs[0].Payload)
+			}
+		}
+	})
+
+	t.Run("缩容停止多余副本", func(t *testing.T) {
+		service.Replicas = 1
+		scaled := live()[1][0]
+		sync()
+		got := live()
+		if len(got[0]) != 1 || len(got[1]) != 0 || scaled.WantRunStatus != model.TaskStatusStop {
+			t.Fatalf("期望副本 1 被停止, 得到 %v", got)
+		}
+	})
+
+	t.Run("删除服务后回收前停止所有副本", func(t *testing.T) {
+		now := time.Now()
+		service.DeletedAt = &now
+		if err := s.purgeTombstones(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.purged) != 0 {
+			t.Fatalf("存活副本停止前不应回收服务, 回收了 %v", repo.purged)
+		}
+
+		replica := live()[0][0]
+		sync()
+		if replica.WantRunStatus != model.TaskStatusStop || len(live()) != 0 {
+			t.Fatalf("期望副本 %s 被停止", replica.TaskKey)
+		}
+		if err := s.purgeTombstones(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.purged) != 1 || repo.purged[0] != "svc" {
+			t.Fatalf("期望副本停止后回收服务, 回收了 %v", repo.purged)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/notify"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// runSLAController evaluate SLA of runnable tasks periodically, only leader works.
+func (s *Scheduler) runSLAController() {
+	if s.opts.alerter == nil {
+		return
+	}
+
+	ticker := time.NewTicker(s.opts.slaCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		amILeader, _, err := s.amILeader()
+		if err != nil {
+			log.Error("获取 leader 状态失败: %v", err)
+			continue
+		}
+		if !amILeader {
+			continue
+		}
+
+		if err := s.checkSLA(context.Background()); err != nil {
+			log.Error("SLA 检查失败: %v", err)
+		}
+	}
+}
+
+func (s *Scheduler) checkSLA(ctx context.Context) error {
+	keys, err := s.taskRepo.ListRunnableTasks(ctx, "")
+	if err != nil {
+		return err
+	}
+	tasks, err := taskrepo.ChunkedBatchGetTask(
+		ctx, s.taskRepo.BatchGetTask, keys,
+		taskrepo.DefaultBatchGetChunkSize, taskrepo.DefaultBatchGetParallelism,
+	)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, task := range tasks {
+		// only alert once.
+		if task.SLABreach != "" {
+			continue
+		}
+		if task.SLA == nil {
+			task.SLA = s.estimatedSLA(task)
+		}
+		reason := task.CheckSLA(now)
+		if reason == "" {
+			continue
+		}
+
+		log.Warn("任务[%s] SLA 违约: %s", task.TaskKey, reason)
+		if err := s.opts.alerter.Notify(ctx, notify.Message{
+			Level:   notify.LevelWarning,
+			Title:   "SLA breached",
+			Text:    fmt.Sprintf("task %s(%s) %s", task.TaskKey, task.BizType, reason),
+			TaskKey: task.TaskKey,
+			BizID:   task.BizID,
+			BizType: task.BizType,
+			Status:  task.Status.String(),
+			Msg:     task.Msg,
+		}); err != nil {
+			log.Error("任务[%s] SLA 告警发送失败: %v", task.TaskKey, err)
+			continue
+		}
+
+		if err := s.taskRepo.UpdateTask(ctx, &model.Task{
+			TaskKey:   task.TaskKey,
+			SLABreach: reason,
+		}); err != nil {
+			log.Error("任务[%s] 标记 SLA 违约失败: %v", task.TaskKey, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/notify"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo/memory"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type slaAlerter struct {
+	err  error
+	sent []notify.Message
+}
+
+func (a *slaAlerter) Notify(_ context.Context, msg notify.Message) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.sent = append(a.sent, msg)
+	return nil
+}
+
+func TestCheckSLA(t *testing.T) {
+	ctx := context.Background()
+	hourAgo := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name     string
+		task     *model.Task
+		notifyOK bool
+		alerts   int
+		breach   bool
+	}{
+		{
+			name:     "排队超过截止时间",
+			task:     &model.Task{TaskKey: "queued", Status: model.TaskStatusWaitScheduling, CreatedAt: hourAgo, SLA: &model.SLAPolicy{MaxQueueSeconds: 60}},
+			notifyOK: true,
+			alerts:   1,
+			breach:   true,
+		},
+		{
+			name:     "运行超过最长时间",
+			task:     &model.Task{TaskKey: "slow", Status: model.TaskStatusRunning, CreatedAt: hourAgo, StartedAt: &hourAgo, SLA: &model.SLAPolicy{MaxRunSeconds: 60}},
+			notifyOK: true,
+			alerts:   1,
+			breach:   true,
+		},
+		{
+			name:     "未违约不告警",
+			task:     &model.Task{TaskKey: "ok", Status: model.TaskStatusRunning, CreatedAt: hourAgo, StartedAt: &hourAgo, SLA: &model.SLAPolicy{MaxRunSeconds: 7200}},
+			notifyOK: true,
+		},
+		{
+			name:     "已告警的任务不再告警",
+			task:     &model.Task{TaskKey: "alerted", Status: model.TaskStatusRunning, CreatedAt: hourAgo, StartedAt: &hourAgo, SLA: &model.SLAPolicy{MaxRunSeconds: 60}, SLABreach: "run time exceeds"},
+			notifyOK: true,
+			breach:   true,
+		},
+		{
+			name:   "告警发送失败时不标记, 下次重试",
+			task:   &model.Task{TaskKey: "retry", Status: model.TaskStatusRunning, CreatedAt: hourAgo, StartedAt: &hourAgo, SLA: &model.SLAPolicy{MaxRunSeconds: 60}},
+			alerts: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.New()
+			if err := repo.CreateTask(ctx, tt.task); err != nil {
+				t.Fatal(err)
+			}
+			alerter := &slaAlerter{}
+			if !tt.notifyOK {
+				alerter.err = errors.New("unavailable")
+			}
+			s := &Scheduler{taskRepo: repo, opts: newOptions(WithSLAAlerter(alerter, time.Minute))}
+
+			// checking again must not alert the same breach twice.
+			for range 2 {
+				if err := s.checkSLA(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(alerter.sent) != tt.alerts {
+				t.Errorf("期望告警 %d 次, 得到 %d", tt.alerts, len(alerter.sent))
+			}
+			task, err := repo.GetTask(ctx, tt.task.TaskKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (task.SLABreach != "") != tt.breach {
+				t.Errorf("期望违约标记 %v, 得到 %q", tt.breach, task.SLABreach)
+			}
+			if !tt.notifyOK {
+				alerter.err = nil
+				if err := s.checkSLA(ctx); err != nil {
+					t.Fatal(err)
+				}
+				if len(alerter.sent) != 1 {
+					t.Errorf("期望恢复后告警 1 次, 得到 %d", len(alerter.sent))
+				}
+			}
+		})
+	}
+}
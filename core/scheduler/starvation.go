@@ -0,0 +1,142 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// causes of starvation, label of starvation metrics.
+const (
+	// workers are full, or quota or budget of the biz type is exceeded,
+	// the task is assigned once there is room.
+	starvedByCapacity = "capacity"
+	// no worker has labels or stains the task tolerates.
+	starvedByLabels = "labels"
+	// no worker registered the executor of the task type.
+	starvedByExecutor = "executor"
+	// not classified yet, eg. the worker of the task is lost just now.
+	starvedPending = "pending"
+)
+
+var starvationCauses = []string{starvedByCapacity, starvedByLabels, starvedByExecutor, starvedPending}
+
+// starvationCause classifies why task waits for assignment.
+func starvationCause(task *model.Task) string {
+	if task.Status != model.TaskStatusUnschedulable {
+		return starvedPending
+	}
+	switch task.UnschedulableReason {
+	case model.UnschedulableNoMatchingLabels:
+		return starvedByLabels
+	case model.UnschedulableNoExecutor:
+		return starvedByExecutor
+	default:
+		return starvedByCapacity
+	}
+}
+
+// starvation tracks how long unassigned tasks wait, it's only used by the
+// assign loop of leader.
+type starvation struct {
+	threshold time.Duration
+	// task key => time the task is first seen waiting by this scheduler.
+	since map[string]time.Time
+	// task keys reported as starving.
+	reported map[string]bool
+
+	starving metrics.Gauge
+	maxWait  metrics.Gauge
+	total    metrics.Counter
+}
+
+func newStarvation(threshold time.Duration) *starvation {
+	p := metrics.Global()
+	return &starvation{
+		threshold: threshold,
+		since:     make(map[string]time.Time),
+		reported:  make(map[string]bool),
+		starving:  p.NewGauge("minitaskx_starving_tasks", "unassigned tasks waiting longer than the starvation threshold", "cause"),
+		maxWait:   p.NewGauge("minitaskx_unassigned_max_wait_seconds", "longest wait of unassigned tasks", "cause"),
+		total:     p.NewCounter("minitaskx_task_starvations_total", "tasks reported as starving", "biz_type", "cause"),
+	}
+}
+
+// starved is a task just exceeding the starvation threshold.
+type starved struct {
+	task  *model.Task
+	cause string
+	wait  time.Duration
+}
+
+// observe updates wait of tasks waiting for assignment at now, and returns
+// tasks exceeding the threshold since the last observe.
+func (st *starvation) observe(tasks []*model.Task, now time.Time) []starved {
+	var ret []starved
+	seen := make(map[string]bool, len(tasks))
+	starving := make(map[string]int, len(starvationCauses))
+	maxWait := make(map[string]time.Duration, len(starvationCauses))
+	for _, task := range tasks {
+		seen[task.TaskKey] = true
+		since, ok := st.since[task.TaskKey]
+		if !ok {
+			since = now
+			// never assigned tasks wait since created, which survives changes of leader.
+			if task.AssignedAt == nil && !task.CreatedAt.IsZero() {
+				since = task.CreatedAt
+			}
+			st.since[task.TaskKey] = since
+		}
+
+		wait := now.Sub(since)
+		cause := starvationCause(task)
+		maxWait[cause] = max(maxWait[cause], wait)
+		if st.threshold <= 0 || wait < st.threshold {
+			continue
+		}
+		starving[cause]++
+		if !st.reported[task.TaskKey] {
+			st.reported[task.TaskKey] = true
+			st.total.Add(1, task.BizType, cause)
+			ret = append(ret, starved{task: task, cause: cause, wait: wait})
+		}
+	}
+	// tasks assigned or finished.
+	for key := range st.since {
+		if !seen[key] {
+			delete(st.since, key)
+			delete(st.reported, key)
+		}
+	}
+
+	for _, cause := range starvationCauses {
+		st.starving.Set(float64(starving[cause]), cause)
+		st.maxWait.Set(maxWait[cause].Seconds(), cause)
+	}
+	return ret
+}
+
+// observeStarvation reports tasks waiting for assignment too long, tasks held
+// by windows or gates wait on purpose and are skipped.
+func (s *Scheduler) observeStarvation(ctx context.Context, tasks []*model.Task, held func(*model.Task) string, now time.Time) {
+	waiting := make([]*model.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if held(task) == "" && s.gates.reason(task) == "" {
+			waiting = append(waiting, task)
+		}
+	}
+	for _, st := range s.starvation.observe(waiting, now) {
+		log.Warn("任务[%s]已等待分配 %s (%s): %s", st.task.TaskKey, st.wait.Round(time.Second), st.cause, st.task.Msg)
+		s.event(ctx, events.Event{
+			TaskKey: st.task.TaskKey,
+			Type:    events.TypeWarning,
+			Reason:  events.ReasonStarving,
+			Message: fmt.Sprintf("waited %s without assignment, starved by %s: %s", st.wait.Round(time.Second), st.cause, st.task.Msg),
+		})
+	}
+}
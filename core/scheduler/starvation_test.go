@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestStarvation(t *testing.T) {
+	now := time.Now()
+	st := newStarvation(10 * time.Minute)
+	pending := &model.Task{TaskKey: "a", Status: model.TaskStatusWaitScheduling, CreatedAt: now.Add(-time.Hour)}
+	mismatched := &model.Task{
+		TaskKey: "b", Status: model.TaskStatusUnschedulable, UnschedulableReason: model.UnschedulableNoMatchingLabels,
+		CreatedAt: now.Add(-time.Hour), AssignedAt: &now,
+	}
+	full := &model.Task{
+		TaskKey: "c", Status: model.TaskStatusUnschedulable, UnschedulableReason: model.UnschedulableQuotaExceeded,
+		CreatedAt: now.Add(-time.Minute),
+	}
+
+	t.Run("未分配过的任务从创建时开始计算", func(t *testing.T) {
+		got := st.observe([]*model.Task{pending, mismatched, full}, now)
+		if len(got) != 1 || got[0].task != pending || got[0].cause != starvedPending || got[0].wait != time.Hour {
+			t.Fatalf("期望只有 a 饥饿 1h, 得到 %+v", got)
+		}
+	})
+
+	t.Run("重新分配的任务从首次观察时开始计算并区分原因", func(t *testing.T) {
+		got := st.observe([]*model.Task{pending, mismatched, full}, now.Add(10*time.Minute))
+		if len(got) != 2 {
+			t.Fatalf("期望 b c 饥饿, 得到 %+v", got)
+		}
+		causes := map[string]string{}
+		for _, s := range got {
+			causes[s.task.TaskKey] = s.cause
+		}
+		if causes["b"] != starvedByLabels || causes["c"] != starvedByCapacity {
+			t.Fatalf("期望 b 因标签, c 因容量, 得到 %v", causes)
+		}
+	})
+
+	t.Run("已报告的任务不重复报告, 分配后重新计算", func(t *testing.T) {
+		if got := st.observe([]*model.Task{pending}, now.Add(20*time.Minute)); len(got) != 0 {
+			t.Fatalf("期望不重复报告, 得到 %+v", got)
+		}
+		if got := st.observe([]*model.Task{mismatched}, now.Add(21*time.Minute)); len(got) != 0 {
+			t.Fatalf("期望 b 重新计算等待时间, 得到 %+v", got)
+		}
+	})
+
+	t.Run("阈值为 0 不报告", func(t *testing.T) {
+		if got := newStarvation(0).observe([]*model.Task{pending}, now); len(got) != 0 {
+			t.Fatalf("期望不报告, 得到 %+v", got)
+		}
+	})
+}
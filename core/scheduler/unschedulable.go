@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// unschedulableError means no available worker can run the task.
+type unschedulableError struct {
+	code   model.UnschedulableReason
+	reason string
+}
+
+func (e *unschedulableError) Error() string {
+	return "没有可运行该任务的 worker: " + e.reason
+}
+
+// classifyUnschedulable explains why all workers are filtered for task.
+func classifyUnschedulable(task *model.Task, workers []discover.Instance) *unschedulableError {
+	var reasons []string
+	hasExecutor, onlyCapacity := false, true
+	for _, worker := range workers {
+		reason := filterReason(task, worker)
+		if reason != "" && !slices.Contains(reasons, reason) {
+			reasons = append(reasons, reason)
+		}
+		if executorReason(task, worker) != "" {
+			continue
+		}
+		hasExecutor = true
+		if selectorReason(task, worker) != "" {
+			onlyCapacity = false
+		}
+		for k, v := range model.Parsestain(worker.Metadata) {
+			if task.Stains[k] != v && !model.IsCapacityStain(k) {
+				onlyCapacity = false
+			}
+		}
+	}
+
+	e := &unschedulableError{reason: strings.Join(reasons, "; ")}
+	switch {
+	case !hasExecutor:
+		e.code = model.UnschedulableNoExecutor
+	case onlyCapacity:
+		e.code = model.UnschedulableNoCapacity
+	default:
+		e.code = model.UnschedulableNoMatchingLabels
+	}
+	return e
+}
+
+// markUnschedulable keeps task out of workers, it is retried after
+// unschedulableRetryInterval or once available workers change.
+func (s *Scheduler) markUnschedulable(ctx context.Context, task *model.Task, e *unschedulableError) error {
+	s.requeueAt.Store(task.TaskKey, time.Now().Add(s.opts.unschedulableRetryInterval))
+	if task.Status == model.TaskStatusUnschedulable && task.UnschedulableReason == e.code && task.Msg == e.reason {
+		return nil
+	}
+	log.Warn("任务[%s]无法调度(%s): %s", task.TaskKey, e.code, e.reason)
+	return errors.WithStack(s.taskRepo.UpdateTask(ctx, &model.Task{
+		TaskKey:             task.TaskKey,
+		Status:              model.TaskStatusUnschedulable,
+		UnschedulableReason: e.code,
+		Msg:                 e.reason,
+	}))
+}
+
+// shouldAttempt reports whether to try assigning task at now,
+// unschedulable tasks are not retried on every assign event.
+func (s *Scheduler) shouldAttempt(task *model.Task, now time.Time) bool {
+	if task.Status != model.TaskStatusUnschedulable {
+		return true
+	}
+	at, ok := s.requeueAt.Load(task.TaskKey)
+	return !ok || !now.Before(at.(time.Time))
+}
+
+// quotaTracker counts tasks assigned per biz type within one assign pass.
+type quotaTracker struct {
+	quotas   map[string]int
+	assigned map[string]int
+}
+
+func newQuotaTracker(quotas, assigned map[string]int) *quotaTracker {
+	return &quotaTracker{quotas: quotas, assigned: assigned}
+}
+
+// take reserves a slot of bizType, false if the quota is exceeded.
+func (q *quotaTracker) take(bizType string) bool {
+	quota, ok := q.quotas[bizType]
+	if ok && q.assigned[bizType] >= quota {
+		return false
+	}
+	q.assigned[bizType]++
+	return true
+}
+
+func (q *quotaTracker) release(bizType string) {
+	q.assigned[bizType]--
+}
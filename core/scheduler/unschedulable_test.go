@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestClassifyUnschedulable(t *testing.T) {
+	tests := []struct {
+		name     string
+		workers  []discover.Instance
+		selector map[string]string
+		want     model.UnschedulableReason
+	}{
+		{
+			name: "没有 worker 注册任务类型",
+			workers: []discover.Instance{
+				{InstanceId: "1", Metadata: map[string]string{model.ExecutorTypesKey: "sms"}},
+			},
+			want: model.UnschedulableNoExecutor,
+		},
+		{
+			name: "worker 均处于资源压力",
+			workers: []discover.Instance{
+				{InstanceId: "1", Metadata: map[string]string{"stain_pressure_mem": "high"}},
+				{InstanceId: "2", Metadata: map[string]string{model.ExecutorTypesKey: "sms"}},
+			},
+			want: model.UnschedulableNoCapacity,
+		},
+		{
+			name: "任务未容忍 worker 污点",
+			workers: []discover.Instance{
+				{InstanceId: "1", Metadata: map[string]string{"stain_gpu": "true"}},
+				{InstanceId: "2", Metadata: map[string]string{"stain_pressure_mem": "high"}},
+			},
+			want: model.UnschedulableNoMatchingLabels,
+		},
+		{
+			name: "worker 运行时标签不匹配",
+			workers: []discover.Instance{
+				{InstanceId: "1", Metadata: map[string]string{model.RuntimeLabelPrefix + model.RuntimeLabelGPU: "false"}},
+				{InstanceId: "2", Metadata: map[string]string{"stain_pressure_mem": "high"}},
+			},
+			selector: map[string]string{model.RuntimeLabelGPU: "true"},
+			want:     model.UnschedulableNoMatchingLabels,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := classifyUnschedulable(&model.Task{Type: "email", WorkerSelector: tt.selector}, tt.workers)
+			if e.code != tt.want {
+				t.Errorf("classifyUnschedulable() = %v(%s), want %v", e.code, e.reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotaTracker(t *testing.T) {
+	q := newQuotaTracker(map[string]int{"billing": 2}, map[string]int{"billing": 1})
+	if !q.take("billing") {
+		t.Fatal("take() = false, want true under quota")
+	}
+	if q.take("billing") {
+		t.Fatal("take() = true, want false over quota")
+	}
+	q.release("billing")
+	if !q.take("billing") {
+		t.Fatal("take() = false after release")
+	}
+	if !q.take("report") {
+		t.Fatal("take() = false for biz type without quota")
+	}
+}
@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+const watchBuffer = 100
+
+// TaskEvent is a status transition of a task observed by watch.
+// the first event of each task has an empty From.
+type TaskEvent struct {
+	TaskKey string           `json:"task_key"`
+	From    model.TaskStatus `json:"from,omitempty"`
+	To      model.TaskStatus `json:"to"`
+	Task    *model.Task      `json:"task"`
+}
+
+// WatchTask streams status transitions of taskKey until ctx is done.
+func (s *Scheduler) WatchTask(ctx context.Context, taskKey string) (<-chan TaskEvent, error) {
+	if _, err := s.GetTask(ctx, taskKey); err != nil {
+		return nil, err
+	}
+
+	load := func(ctx context.Context) ([]*model.Task, error) {
+		task, err := s.taskRepo.GetTask(ctx, taskKey)
+		if err != nil {
+			return nil, err
+		}
+		return []*model.Task{task}, nil
+	}
+	match := func(keys []string) bool { return slices.Contains(keys, taskKey) }
+	return s.watch(ctx, load, match)
+}
+
+// WatchTasks streams status transitions of tasks matched by filter until ctx is done,
+// at most filter.Limit tasks are watched.
+func (s *Scheduler) WatchTasks(ctx context.Context, filter *model.TaskFilter) (<-chan TaskEvent, error) {
+	if err := auth.CheckBizType(ctx, filter.BizType); err != nil {
+		return nil, err
+	}
+
+	load := func(ctx context.Context) ([]*model.Task, error) {
+		return s.taskRepo.ListTask(ctx, filter)
+	}
+	match := func([]string) bool { return true }
+	return s.watch(ctx, load, match)
+}
+
+// watch reloads tasks when repo reports changed keys matched by match,
+// or every watchPollInterval, since repo watch may not report finished tasks.
+func (s *Scheduler) watch(
+	ctx context.Context,
+	load func(ctx context.Context) ([]*model.Task, error),
+	match func(keys []string) bool,
+) (<-chan TaskEvent, error) {
+	changed, err := s.taskRepo.WatchRunnableTasks(ctx, "")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ch := make(chan TaskEvent, watchBuffer)
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(s.opts.watchPollInterval)
+		defer ticker.Stop()
+
+		last := make(map[string]model.TaskStatus)
+		for {
+			tasks, err := load(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Error("watch 加载任务失败: %v", err)
+			}
+			for _, task := range tasks {
+				from, ok := last[task.TaskKey]
+				if ok && from == task.Status {
+					continue
+				}
+				last[task.TaskKey] = task.Status
+				select {
+				case ch <- TaskEvent{TaskKey: task.TaskKey, From: from, To: task.Status, Task: task}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if !waitWatchTrigger(ctx, ticker.C, changed, match) {
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// waitWatchTrigger blocks until the next reload, false if ctx is done.
+func waitWatchTrigger(ctx context.Context, tick <-chan time.Time, changed <-chan []string, match func(keys []string) bool) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-tick:
+			return true
+		case keys, ok := <-changed:
+			if !ok {
+				// repo watch closed, keep polling.
+				changed = nil
+				continue
+			}
+			if match(keys) {
+				return true
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package scheduler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/cron"
+)
+
+type WindowKind string
+
+const (
+	// WindowBlackout holds matched tasks while it's open, eg. maintenance.
+	WindowBlackout WindowKind = "blackout"
+	// WindowCalendar only dispatches matched tasks while it's open, eg. off-peak hours.
+	WindowCalendar WindowKind = "calendar"
+)
+
+// Window is a time window gating dispatch of matched runnable tasks,
+// held tasks queue up and are dispatched once the gate opens.
+// running tasks are not affected.
+type Window struct {
+	Name string     `json:"name"`
+	Kind WindowKind `json:"kind"`
+	// matched tasks, empty matches all.
+	TaskTypes []string `json:"task_types,omitempty"`
+	BizTypes  []string `json:"biz_types,omitempty"`
+	// recurring window opened at each match of Cron for DurationSeconds,
+	// evaluated in IANA Timezone, default UTC.
+	Cron            string `json:"cron,omitempty"`
+	DurationSeconds int64  `json:"duration_seconds,omitempty"`
+	Timezone        string `json:"timezone,omitempty"`
+	// one-off window within [Start, End).
+	Start *time.Time `json:"start,omitempty"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+func (w Window) matches(task *model.Task) bool {
+	return (len(w.TaskTypes) == 0 || slices.Contains(w.TaskTypes, task.Type)) &&
+		(len(w.BizTypes) == 0 || slices.Contains(w.BizTypes, task.BizType))
+}
+
+type compiledWindow struct {
+	Window
+	schedule *cron.Schedule
+	loc      *time.Location
+}
+
+func compileWindow(w Window) (*compiledWindow, error) {
+	if w.Name == "" {
+		return nil, errors.New("invalid params, need window name")
+	}
+	if w.Kind != WindowBlackout && w.Kind != WindowCalendar {
+		return nil, errors.Errorf("invalid window kind %q", w.Kind)
+	}
+
+	c := &compiledWindow{Window: w, loc: time.UTC}
+	switch {
+	case w.Cron != "":
+		if w.DurationSeconds <= 0 {
+			return nil, errors.New("invalid params, need duration of cron window")
+		}
+		s, err := cron.Parse(w.Cron)
+		if err != nil {
+			return nil, err
+		}
+		c.schedule = s
+		if w.Timezone != "" {
+			if c.loc, err = time.LoadLocation(w.Timezone); err != nil {
+				return nil, errors.Errorf("invalid timezone %q: %v", w.Timezone, err)
+			}
+		}
+	case w.Start != nil && w.End != nil:
+		if !w.End.After(*w.Start) {
+			return nil, errors.New("invalid params, window end must be after start")
+		}
+	default:
+		return nil, errors.New("invalid params, need cron or start and end of window")
+	}
+	return c, nil
+}
+
+// isOpen reports whether the window is open at now.
+func (c *compiledWindow) isOpen(now time.Time) bool {
+	if c.schedule == nil {
+		return !now.Before(*c.Start) && now.Before(*c.End)
+	}
+	// opened by a match within the last duration.
+	d := time.Duration(c.DurationSeconds) * time.Second
+	next := c.schedule.Next(now.In(c.loc).Add(-d))
+	return !next.IsZero() && !next.After(now)
+}
+
+type windows struct {
+	mu     sync.RWMutex
+	states map[string]*compiledWindow
+}
+
+func newWindows(ws []Window) (*windows, error) {
+	c := &windows{states: make(map[string]*compiledWindow)}
+	for _, w := range ws {
+		if err := c.set(w); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *windows) set(w Window) error {
+	compiled, err := compileWindow(w)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states[w.Name] = compiled
+	return nil
+}
+
+func (c *windows) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.states, name)
+}
+
+func (c *windows) list() []Window {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ret := make([]Window, 0, len(c.states))
+	for _, w := range c.states {
+		ret = append(ret, w.Window)
+	}
+	slices.SortFunc(ret, func(a, b Window) int { return strings.Compare(a.Name, b.Name) })
+	return ret
+}
+
+// gate evaluates windows at now once, the returned func reports why task
+// is held by windows, empty if it can be dispatched.
+func (c *windows) gate(now time.Time) func(task *model.Task) string {
+	c.mu.RLock()
+	type state struct {
+		*compiledWindow
+		open bool
+	}
+	states := make([]state, 0, len(c.states))
+	for _, w := range c.states {
+		states = append(states, state{compiledWindow: w, open: w.isOpen(now)})
+	}
+	c.mu.RUnlock()
+
+	return func(task *model.Task) string {
+		inCalendar, calendarOpen := false, false
+		for _, st := range states {
+			if !st.matches(task) {
+				continue
+			}
+			switch st.Kind {
+			case WindowBlackout:
+				if st.open {
+					return fmt.Sprintf("blackout window %s is open", st.Name)
+				}
+			case WindowCalendar:
+				inCalendar = true
+				calendarOpen = calendarOpen || st.open
+			}
+		}
+		if inCalendar && !calendarOpen {
+			return "no calendar window is open"
+		}
+		return ""
+	}
+}
+
+// SetWindow adds or replaces the window of the same name.
+func (s *Scheduler) SetWindow(w Window) error {
+	if err := s.windows.set(w); err != nil {
+		return err
+	}
+	log.Info("调度窗口[%s](%s)已设置", w.Name, w.Kind)
+	return nil
+}
+
+// RemoveWindow removes the window of name.
+func (s *Scheduler) RemoveWindow(name string) {
+	s.windows.remove(name)
+}
+
+// Windows returns all windows sorted by name.
+func (s *Scheduler) Windows() []Window {
+	return s.windows.list()
+}
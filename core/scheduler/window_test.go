@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestWindows(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	ws, err := newWindows([]Window{
+		{Name: "maintenance", Kind: WindowBlackout, BizTypes: []string{"billing"}, Start: &start, End: &end},
+		// 22:00-06:00 in Shanghai, 14:00-22:00 UTC.
+		{Name: "off-peak", Kind: WindowCalendar, TaskTypes: []string{"report"}, Cron: "0 22 * * *", DurationSeconds: 8 * 3600, Timezone: "Asia/Shanghai"},
+	})
+	if err != nil {
+		t.Skipf("缺少时区数据: %v", err)
+	}
+
+	billing := &model.Task{BizType: "billing", Type: "etl"}
+	report := &model.Task{BizType: "ads", Type: "report"}
+	other := &model.Task{BizType: "ads", Type: "etl"}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		task *model.Task
+		held bool
+	}{
+		{"维护窗口内阻止", start.Add(time.Hour), billing, true},
+		{"维护窗口结束后放行", end, billing, false},
+		{"日历窗口外阻止", start.Add(10 * time.Hour), report, true},
+		{"日历窗口内放行", start.Add(15 * time.Hour), report, false},
+		{"跨天的日历窗口内放行", start.Add(21 * time.Hour), report, false},
+		{"不匹配的任务不受影响", start.Add(time.Hour), other, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := ws.gate(tt.at)(tt.task)
+			if (reason != "") != tt.held {
+				t.Fatalf("期望 held=%v, 得到 %q", tt.held, reason)
+			}
+		})
+	}
+
+	t.Run("校验窗口配置", func(t *testing.T) {
+		for _, w := range []Window{
+			{Kind: WindowBlackout, Start: &start, End: &end},
+			{Name: "a", Kind: "unknown", Start: &start, End: &end},
+			{Name: "a", Kind: WindowBlackout, Cron: "0 * * * *"},
+			{Name: "a", Kind: WindowBlackout, Start: &end, End: &start},
+			{Name: "a", Kind: WindowBlackout},
+		} {
+			if err := ws.set(w); err == nil {
+				t.Errorf("%+v 期望校验失败", w)
+			}
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xyzbit/minitaskx/core/components/audit"
+)
+
+// ForceReleaseChange release the change-queue key of taskKey held by an executor
+// operation that never reported its result.
+func (w *Worker) ForceReleaseChange(ctx context.Context, taskKey, reason, operator string) error {
+	if !w.infomer.ForceRelease(taskKey) {
+		return fmt.Errorf("task[%s] has no in-flight change", taskKey)
+	}
+	if w.opts.auditor == nil {
+		return nil
+	}
+	if err := w.opts.auditor.Record(ctx, audit.Entry{
+		TaskKey:  taskKey,
+		Operator: operator,
+		Action:   audit.ActionForceRelease,
+		To:       w.id,
+		Reason:   reason,
+	}); err != nil {
+		w.opts.logger.Error("[Worker] record audit of task[%s] failed: %v", taskKey, err)
+	}
+	return nil
+}
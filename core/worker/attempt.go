@@ -0,0 +1,89 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/attempt"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+const attemptBuffer = 1000
+
+// attemptRecorder records run attempts from real task status changes,
+// changes are saved in order by a single goroutine to not block reconcile.
+type attemptRecorder struct {
+	repo     attempt.Interface
+	workerID func() string
+	events   chan *model.Task
+	logger   log.Logger
+}
+
+func newAttemptRecorder(repo attempt.Interface, workerID func() string, logger log.Logger) *attemptRecorder {
+	return &attemptRecorder{
+		repo:     repo,
+		workerID: workerID,
+		events:   make(chan *model.Task, attemptBuffer),
+		logger:   logger,
+	}
+}
+
+func (r *attemptRecorder) Observe(task *model.Task) {
+	if task.Status != model.TaskStatusRunning && !task.Status.IsFinalStatus() {
+		return
+	}
+	select {
+	case r.events <- task.Clone():
+	default:
+		r.logger.Error("[Worker] attempt buffer is full, drop %s %s", task.TaskKey, task.Status)
+	}
+}
+
+func (r *attemptRecorder) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case task := <-r.events:
+			if err := r.record(ctx, task); err != nil {
+				r.logger.Error("[Worker] record attempt of %s failed: %v", task.TaskKey, err)
+			}
+		}
+	}
+}
+
+// record starts a new attempt when task runs after the previous attempt finished
+// (resumed tasks continue the open attempt), and finishes it when task is final.
+func (r *attemptRecorder) record(ctx context.Context, task *model.Task) error {
+	latest, err := r.repo.Latest(ctx, task.TaskKey)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	a := latest
+	if a == nil || a.Finished() {
+		if task.Status != model.TaskStatusRunning && a != nil {
+			// final status of a finished attempt is reported again.
+			return nil
+		}
+		number := 1
+		if a != nil {
+			number = a.Number + 1
+		}
+		a = &attempt.Attempt{TaskKey: task.TaskKey, Number: number, StartedAt: now}
+	} else if task.Status == model.TaskStatusRunning {
+		return nil
+	}
+
+	a.WorkerID = r.workerID()
+	a.Status = task.Status
+	if task.Status.IsFinalStatus() {
+		a.FinishedAt = &now
+		if task.Status == model.TaskStatusFailed {
+			a.Error = task.Msg
+		}
+	}
+	return r.repo.Save(ctx, a)
+}
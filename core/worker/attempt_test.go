@@ -0,0 +1,85 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/attempt"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type memAttempts struct {
+	mu       sync.Mutex
+	attempts []*attempt.Attempt
+}
+
+func (m *memAttempts) Save(_ context.Context, a *attempt.Attempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, old := range m.attempts {
+		if old.TaskKey == a.TaskKey && old.Number == a.Number {
+			m.attempts[i] = a
+			return nil
+		}
+	}
+	m.attempts = append(m.attempts, a)
+	return nil
+}
+
+func (m *memAttempts) List(_ context.Context, taskKey string) ([]*attempt.Attempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ret []*attempt.Attempt
+	for _, a := range m.attempts {
+		if a.TaskKey == taskKey {
+			cp := *a
+			ret = append(ret, &cp)
+		}
+	}
+	return ret, nil
+}
+
+func (m *memAttempts) Latest(ctx context.Context, taskKey string) (*attempt.Attempt, error) {
+	as, _ := m.List(ctx, taskKey)
+	if len(as) == 0 {
+		return nil, nil
+	}
+	return as[len(as)-1], nil
+}
+
+func TestAttemptRecorder(t *testing.T) {
+	repo := &memAttempts{}
+	r := newAttemptRecorder(repo, func() string { return "w1" }, log.Global())
+	ctx := context.Background()
+
+	for _, task := range []*model.Task{
+		{TaskKey: "t1", Status: model.TaskStatusRunning},
+		{TaskKey: "t1", Status: model.TaskStatusRunning}, // resumed
+		{TaskKey: "t1", Status: model.TaskStatusFailed, Msg: "boom"},
+		{TaskKey: "t1", Status: model.TaskStatusFailed, Msg: "boom"}, // reported again
+		{TaskKey: "t1", Status: model.TaskStatusRunning},
+		{TaskKey: "t1", Status: model.TaskStatusSuccess},
+	} {
+		if err := r.record(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	as, _ := repo.List(ctx, "t1")
+	if len(as) != 2 {
+		t.Fatalf("attempts = %d, want 2", len(as))
+	}
+	if as[0].Number != 1 || as[0].Status != model.TaskStatusFailed || as[0].Error != "boom" || !as[0].Finished() {
+		t.Errorf("attempt 1 = %+v", as[0])
+	}
+	if as[1].Number != 2 || as[1].Status != model.TaskStatusSuccess || as[1].WorkerID != "w1" {
+		t.Errorf("attempt 2 = %+v", as[1])
+	}
+
+	s := attempt.Summarize(&model.Task{Status: model.TaskStatusWaitRunning}, as[0])
+	if !s.Retrying || s.PermanentFailure || s.Attempts != 1 {
+		t.Errorf("Summarize() = %+v, want retrying", s)
+	}
+}
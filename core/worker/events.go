@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+const eventBuffer = 1000
+
+// eventEmitter emits events of tasks observed by worker, events are
+// recorded by a single goroutine to not block reconcile.
+type eventEmitter struct {
+	recorder events.Interface
+	workerID func() string
+	events   chan events.Event
+	logger   log.Logger
+}
+
+func newEventEmitter(recorder events.Interface, workerID func() string, logger log.Logger) *eventEmitter {
+	return &eventEmitter{
+		recorder: recorder,
+		workerID: workerID,
+		events:   make(chan events.Event, eventBuffer),
+		logger:   logger,
+	}
+}
+
+// Observe emits Started and Finished from real status changes.
+func (e *eventEmitter) Observe(task *model.Task) {
+	switch {
+	case task.Status == model.TaskStatusRunning:
+		e.Emit(events.Event{TaskKey: task.TaskKey, Reason: events.ReasonStarted, Message: "started on worker " + e.workerID()})
+	case task.Status.IsFinalStatus():
+		event := events.Event{TaskKey: task.TaskKey, Reason: events.ReasonFinished, Message: fmt.Sprintf("finished with status %s", task.Status)}
+		if task.Status == model.TaskStatusFailed {
+			event.Type = events.TypeWarning
+			if task.Msg != "" {
+				event.Message += ": " + task.Msg
+			}
+		}
+		e.Emit(event)
+	}
+}
+
+// Emit queues the event, it is dropped if the buffer is full.
+func (e *eventEmitter) Emit(event events.Event) {
+	if event.Type == "" {
+		event.Type = events.TypeNormal
+	}
+	event.Source = "worker:" + e.workerID()
+	select {
+	case e.events <- event:
+	default:
+		e.logger.Error("[Worker] event buffer is full, drop %s of %s", event.Reason, event.TaskKey)
+	}
+}
+
+func (e *eventEmitter) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-e.events:
+			if err := e.recorder.Record(ctx, event); err != nil {
+				e.logger.Error("[Worker] record event %s of %s failed: %v", event.Reason, event.TaskKey, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/audit"
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// EvictionPolicy pauses or stops the lowest priority running tasks of worker
+// while its resource usage exceeds thresholds. evicted tasks are not resumed
+// automatically, operators resume them once the node recovers.
+type EvictionPolicy struct {
+	// usage percent thresholds, 0 disables the check.
+	MemoryPercent float64
+	CPUPercent    float64
+	// model.TaskStatusPaused or model.TaskStatusStop, default paused.
+	Action model.TaskStatus
+	// max tasks evicted per check, default 1, so that usage can settle in between.
+	MaxPerCheck int
+	// default 10s.
+	Interval time.Duration
+}
+
+func (p EvictionPolicy) withDefaults() EvictionPolicy {
+	if p.Action == "" {
+		p.Action = model.TaskStatusPaused
+	}
+	if p.MaxPerCheck <= 0 {
+		p.MaxPerCheck = 1
+	}
+	if p.Interval <= 0 {
+		p.Interval = 10 * time.Second
+	}
+	return p
+}
+
+// pressureReason returns why usage is under pressure, empty if not.
+func (p EvictionPolicy) pressureReason(usage map[string]float64) string {
+	if p.MemoryPercent > 0 && usage[model.MemUsageKey] > p.MemoryPercent {
+		return fmt.Sprintf("memory usage %.1f%% exceeds %.1f%%", usage[model.MemUsageKey], p.MemoryPercent)
+	}
+	if p.CPUPercent > 0 && usage[model.CpuUsageKey] > p.CPUPercent {
+		return fmt.Sprintf("cpu usage %.1f%% exceeds %.1f%%", usage[model.CpuUsageKey], p.CPUPercent)
+	}
+	return ""
+}
+
+// evictionCandidates returns running tasks to evict in order,
+// lowest priority first, then the latest started which loses the least work.
+func (p EvictionPolicy) evictionCandidates(tasks []*model.Task) []*model.Task {
+	var candidates []*model.Task
+	for _, t := range tasks {
+		if t.Status != model.TaskStatusRunning {
+			continue
+		}
+		// services can't be paused.
+		if t.IsService() && p.Action == model.TaskStatusPaused {
+			continue
+		}
+		if !t.Preemptible() {
+			continue
+		}
+		candidates = append(candidates, t)
+	}
+	slices.SortStableFunc(candidates, func(a, b *model.Task) int {
+		if pa, pb := a.EffectivePriority(), b.EffectivePriority(); pa != pb {
+			return pa - pb
+		}
+		return startedAt(b).Compare(startedAt(a))
+	})
+	if len(candidates) > p.MaxPerCheck {
+		candidates = candidates[:p.MaxPerCheck]
+	}
+	return candidates
+}
+
+func startedAt(t *model.Task) time.Time {
+	if t.StartedAt == nil {
+		return time.Time{}
+	}
+	return *t.StartedAt
+}
+
+// runEvictor checks resource usage and evicts tasks under pressure until ctx is done.
+func (w *Worker) runEvictor(ctx context.Context) {
+	policy := w.opts.eviction.withDefaults()
+	evictions := w.metrics.NewCounter("minitaskx_task_evictions_total", "tasks evicted by worker under resource pressure", "action")
+
+	ticker := w.opts.clock.NewTicker(policy.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+
+		ru, err := model.GenerateResourceUsage()
+		if err != nil {
+			w.opts.logger.Error("[Worker] 获取资源使用情况失败: %v", err)
+			continue
+		}
+		reason := policy.pressureReason(model.ParseResourceUsage(ru))
+		if reason == "" {
+			continue
+		}
+		for _, task := range w.evictionCandidates(ctx, policy) {
+			if err := w.evict(ctx, task, policy.Action, reason); err != nil {
+				w.opts.logger.Error("[Worker] 驱逐任务[%s]失败: %v", task.TaskKey, err)
+				continue
+			}
+			evictions.Add(1, string(policy.Action))
+		}
+	}
+}
+
+// evictionCandidates loads want tasks of running executors, which carry priority.
+func (w *Worker) evictionCandidates(ctx context.Context, policy EvictionPolicy) []*model.Task {
+	var keys []string
+	for _, real := range w.infomer.ListRealTasks() {
+		if real.Status == model.TaskStatusRunning {
+			keys = append(keys, real.TaskKey)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	tasks, err := w.taskRepo.BatchGetTask(ctx, keys)
+	if err != nil {
+		w.opts.logger.Error("[Worker] 获取待驱逐任务失败: %v", err)
+		return nil
+	}
+	return policy.evictionCandidates(tasks)
+}
+
+// evict checkpoints the task if supported, then changes its want status to action
+// the same way as operating a task by user.
+func (w *Worker) evict(ctx context.Context, task *model.Task, action model.TaskStatus, reason string) error {
+	if supported, err := w.exeManager.Checkpoint(task); supported && err != nil {
+		w.opts.logger.Warn("[Worker] 任务[%s]驱逐前保存进度失败: %v", task.TaskKey, err)
+	}
+
+	applied, err := w.taskRepo.UpdateTaskStatusCAS(ctx, task.TaskKey, model.TaskStatusRunning, action.PreWaitStatus())
+	if err != nil {
+		return err
+	}
+	if !applied {
+		// operated concurrently, nothing to evict.
+		return nil
+	}
+	operator := "system:worker:" + w.id
+	if err := w.taskRepo.UpdateTask(ctx, &model.Task{
+		TaskKey:       task.TaskKey,
+		WantRunStatus: action,
+		Operator:      operator,
+		Msg:           "evicted: " + reason,
+	}); err != nil {
+		return err
+	}
+
+	w.opts.logger.Warn("[Worker] 任务[%s](priority %d)因 %s 被驱逐, 目标状态 %s", task.TaskKey, task.EffectivePriority(), reason, action)
+	if w.opts.auditor != nil {
+		if err := w.opts.auditor.Record(ctx, audit.Entry{
+			TaskKey:  task.TaskKey,
+			Operator: operator,
+			Action:   audit.ActionEvict,
+			From:     string(model.TaskStatusRunning),
+			To:       string(action),
+			Reason:   reason,
+		}); err != nil {
+			w.opts.logger.Error("[Worker] record audit of task[%s] failed: %v", task.TaskKey, err)
+		}
+	}
+	if w.events != nil {
+		w.events.Emit(events.Event{
+			TaskKey: task.TaskKey,
+			Type:    events.TypeWarning,
+			Reason:  events.ReasonEvicted,
+			Message: fmt.Sprintf("evicted to %s: %s", action, reason),
+		})
+	}
+	return nil
+}
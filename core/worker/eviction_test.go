@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestEvictionPolicy(t *testing.T) {
+	p := EvictionPolicy{MemoryPercent: 90, MaxPerCheck: 2}.withDefaults()
+
+	t.Run("超过阈值才驱逐", func(t *testing.T) {
+		if r := p.pressureReason(map[string]float64{model.MemUsageKey: 80, model.CpuUsageKey: 99}); r != "" {
+			t.Fatalf("未配置 cpu 阈值不应驱逐, 得到 %s", r)
+		}
+		if r := p.pressureReason(map[string]float64{model.MemUsageKey: 95}); r == "" {
+			t.Fatal("内存超过阈值应驱逐")
+		}
+	})
+
+	t.Run("优先驱逐低优先级和最晚启动的任务", func(t *testing.T) {
+		early := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		late := early.Add(time.Hour)
+		tasks := []*model.Task{
+			{TaskKey: "high", Status: model.TaskStatusRunning, Priority: 10},
+			{TaskKey: "low-early", Status: model.TaskStatusRunning, StartedAt: &early},
+			{TaskKey: "low-late", Status: model.TaskStatusRunning, StartedAt: &late},
+			{TaskKey: "paused", Status: model.TaskStatusPaused, Priority: -1},
+			{TaskKey: "service", Status: model.TaskStatusRunning, Kind: model.TaskKindService, Priority: -1},
+		}
+		got := p.evictionCandidates(tasks)
+		if len(got) != 2 || got[0].TaskKey != "low-late" || got[1].TaskKey != "low-early" {
+			keys := make([]string, 0, len(got))
+			for _, t := range got {
+				keys = append(keys, t.TaskKey)
+			}
+			t.Fatalf("期望 [low-late low-early], 得到 %v", keys)
+		}
+	})
+	t.Run("不可抢占的任务不被驱逐", func(t *testing.T) {
+		tasks := []*model.Task{
+			{TaskKey: "critical", Status: model.TaskStatusRunning, Labels: map[string]string{model.LabelPreemption: string(model.PreemptNever)}},
+			{TaskKey: "batch", Status: model.TaskStatusRunning, Priority: 10},
+		}
+		if got := p.evictionCandidates(tasks); len(got) != 1 || got[0].TaskKey != "batch" {
+			t.Fatalf("期望只驱逐 batch, 得到 %v", got)
+		}
+	})
+}
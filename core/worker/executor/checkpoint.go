@@ -0,0 +1,22 @@
+package executor
+
+import "github.com/xyzbit/minitaskx/core/model"
+
+// Checkpointer is implemented by executors able to persist progress of a task,
+// so that a task paused or stopped by worker can continue from it later.
+type Checkpointer interface {
+	Checkpoint(taskKey string) error
+}
+
+// Checkpoint persists progress of task, supported is false if the executor can't.
+func (ge *Manager) Checkpoint(task *model.Task) (supported bool, err error) {
+	exe, ok := ge.registry().executor(task.Type)
+	if !ok {
+		return false, nil
+	}
+	c, ok := exe.(Checkpointer)
+	if !ok {
+		return false, nil
+	}
+	return true, c.Checkpoint(task.TaskKey)
+}
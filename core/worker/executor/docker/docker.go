@@ -12,8 +12,11 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 
+	"github.com/xyzbit/minitaskx/core/components/crash"
 	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/tasklog"
 	"github.com/xyzbit/minitaskx/core/model"
 	"github.com/xyzbit/minitaskx/core/worker/executor"
 )
@@ -21,6 +24,9 @@ import (
 type taskCtrl struct {
 	containerID string
 	task        *model.Task
+	usage       executor.UsageTracker
+	// stops sampling usage of the container.
+	stopSampling context.CancelFunc
 }
 
 type Executor struct {
@@ -28,33 +34,58 @@ type Executor struct {
 	taskrw     sync.RWMutex
 	tasks      map[string]*taskCtrl
 	resultChan chan *model.Task
+
+	// capture stdout/stderr of containers, optional.
+	logSink tasklog.Interface
+	// reports panics of monitors, nil only logs them.
+	crash crash.Reporter
+}
+
+type Option func(e *Executor)
+
+// WithLogSink streams stdout/stderr of containers to sink.
+func WithLogSink(sink tasklog.Interface) Option {
+	return func(e *Executor) {
+		e.logSink = sink
+	}
 }
 
 // NewExecutor 注意设置 DOCKER_API_VERSION, 保障客户端版本兼容.
-func NewExecutor() executor.Interface {
+func NewExecutor(opts ...Option) executor.Interface {
 	cli, err := client.NewClientWithOpts(client.FromEnv)
 	if err != nil {
 		log.Error("创建Docker客户端失败: %v", err)
 		return nil
 	}
-	return &Executor{
+	e := &Executor{
 		cli:        cli,
 		tasks:      make(map[string]*taskCtrl),
 		resultChan: make(chan *model.Task, 10),
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 func (e *Executor) Run(task *model.Task) error {
 	if task.Payload == "" {
-		return fmt.Errorf("task payload is nil")
+		return executor.Reject("task payload is nil")
 	}
 	ctx := context.Background()
 
 	var config container.Config
 	if err := sonic.UnmarshalString(task.Payload, &config); err != nil {
-		return fmt.Errorf("解析容器配置失败: %v", err)
+		return executor.Reject("解析容器配置失败: %v", err)
 	}
 
+	// make the container discoverable by loader.ContainerLoader.
+	if config.Labels == nil {
+		config.Labels = make(map[string]string)
+	}
+	config.Labels[model.LabelTaskKey] = task.TaskKey
+	config.Labels[model.LabelTaskType] = task.Type
+
 	if err := e.pullImage(ctx, config.Image); err != nil {
 		return err
 	}
@@ -68,14 +99,21 @@ func (e *Executor) Run(task *model.Task) error {
 		return fmt.Errorf("启动容器失败: %v", err)
 	}
 
-	e.taskrw.Lock()
-	e.tasks[task.TaskKey] = &taskCtrl{
-		containerID: resp.ID,
-		task:        task,
+	sampleCtx, stopSampling := context.WithCancel(context.Background())
+	ctrl := &taskCtrl{
+		containerID:  resp.ID,
+		task:         task,
+		stopSampling: stopSampling,
 	}
+	e.taskrw.Lock()
+	e.tasks[task.TaskKey] = ctrl
 	e.taskrw.Unlock()
 
+	go e.sampleUsage(sampleCtx, ctrl)
 	go e.monitorContainer(task.TaskKey)
+	if e.logSink != nil {
+		go e.captureLogs(task.TaskKey, resp.ID, config.Tty)
+	}
 
 	task.Status = model.TaskStatusRunning
 	e.resultChan <- task
@@ -164,7 +202,9 @@ func (e *Executor) stopAndRemove(taskKey string, force bool, status model.TaskSt
 	delete(e.tasks, taskKey)
 	e.taskrw.Unlock()
 
+	ctrl.stopSampling()
 	ctrl.task.Status = status
+	ctrl.task.Usage = ctrl.usage.Usage()
 	e.resultChan <- ctrl.task
 	return nil
 }
@@ -214,11 +254,20 @@ func (e *Executor) ChangeResult() <-chan *model.Task {
 	return e.resultChan
 }
 
+func (e *Executor) SetCrashReporter(r crash.Reporter) {
+	e.crash = r
+}
+
 func (e *Executor) monitorContainer(taskKey string) {
 	ctrl, err := e.getTaskCtrl(taskKey)
 	if err != nil {
 		return
 	}
+	defer crash.Recover(e.crash, "executor.docker", taskKey, func(err error) {
+		ctrl.task.Status = model.TaskStatusFailed
+		ctrl.task.Msg = err.Error()
+		e.resultChan <- ctrl.task
+	})
 
 	statusCh, errCh := e.cli.ContainerWait(context.Background(), ctrl.containerID, container.WaitConditionNotRunning)
 	select {
@@ -240,9 +289,99 @@ func (e *Executor) monitorContainer(taskKey string) {
 	delete(e.tasks, taskKey)
 	e.taskrw.Unlock()
 
+	ctrl.stopSampling()
+	ctrl.task.Usage = ctrl.usage.Usage()
 	e.resultChan <- ctrl.task
 }
 
+// sampleUsage samples cpu and memory of the container from its stats stream
+// until ctx is done or the container is removed.
+func (e *Executor) sampleUsage(ctx context.Context, ctrl *taskCtrl) {
+	stats, err := e.cli.ContainerStats(ctx, ctrl.containerID, true)
+	if err != nil {
+		log.Warn("任务[%s]获取容器资源统计失败: %v", ctrl.task.TaskKey, err)
+		return
+	}
+	defer stats.Body.Close()
+
+	decoder := json.NewDecoder(stats.Body)
+	for {
+		var s container.StatsResponse
+		if err := decoder.Decode(&s); err != nil {
+			return
+		}
+		// paused or stopped containers report no cpu.
+		if s.PreCPUStats.SystemUsage == 0 || s.MemoryStats.Usage == 0 {
+			continue
+		}
+		ctrl.usage.Observe(cpuCores(&s), memoryGiB(&s))
+	}
+}
+
+// cpuCores is cpu used between the previous and the current stats, as docker stats computes it.
+func cpuCores(s *container.StatsResponse) float64 {
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	cpus := float64(s.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	return cpuDelta / systemDelta * cpus
+}
+
+// memoryGiB is memory used excluding page cache, as docker stats computes it.
+func memoryGiB(s *container.StatsResponse) float64 {
+	used := s.MemoryStats.Usage
+	cache := s.MemoryStats.Stats["inactive_file"] // cgroup v2
+	if cache == 0 {
+		cache = s.MemoryStats.Stats["total_inactive_file"] // cgroup v1
+	}
+	if cache < used {
+		used -= cache
+	}
+	return float64(used) / (1 << 30)
+}
+
+// captureLogs streams output of container to log sink until the container is removed.
+func (e *Executor) captureLogs(taskKey, containerID string, tty bool) {
+	stdout, err := e.logSink.Writer(taskKey, tasklog.StreamStdout)
+	if err != nil {
+		log.Error("任务[%s]创建日志写入失败: %v", taskKey, err)
+		return
+	}
+	defer stdout.Close()
+	stderr, err := e.logSink.Writer(taskKey, tasklog.StreamStderr)
+	if err != nil {
+		log.Error("任务[%s]创建日志写入失败: %v", taskKey, err)
+		return
+	}
+	defer stderr.Close()
+
+	rc, err := e.cli.ContainerLogs(context.Background(), containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+	})
+	if err != nil {
+		log.Error("任务[%s]获取容器日志失败: %v", taskKey, err)
+		return
+	}
+	defer rc.Close()
+
+	// output of tty container is not multiplexed.
+	if tty {
+		_, err = io.Copy(stdout, rc)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, rc)
+	}
+	if err != nil {
+		log.Warn("任务[%s]容器日志中断: %v", taskKey, err)
+	}
+}
+
 func (e *Executor) getTaskCtrl(taskKey string) (*taskCtrl, error) {
 	e.taskrw.RLock()
 	ctrl, exists := e.tasks[taskKey]
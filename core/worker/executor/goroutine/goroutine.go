@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/xyzbit/minitaskx/core/components/crash"
 	"github.com/xyzbit/minitaskx/core/components/log"
 	"github.com/xyzbit/minitaskx/core/model"
 	"github.com/xyzbit/minitaskx/core/worker/executor"
@@ -28,6 +29,8 @@ type Executor struct {
 
 	resultChan  chan *model.Task // send external notifications when execution status changes
 	bizLogicNew func() BizLogic
+	// reports panics of biz logic, nil only logs them.
+	crash crash.Reporter
 }
 
 type BizLogic func(task *model.Task) (finished bool, err error)
@@ -41,10 +44,14 @@ func NewExecutor(new func() BizLogic) executor.Interface {
 	}
 }
 
+func (e *Executor) SetCrashReporter(r crash.Reporter) {
+	e.crash = r
+}
+
 func (e *Executor) Run(task *model.Task) error {
 	key := task.TaskKey
 	if ctrl := e.getTaskCtrl(key); ctrl != nil {
-		return errors.New("task already running")
+		return executor.ErrAlreadyRunning
 	}
 
 	e.setTask(key, task)
@@ -62,12 +69,10 @@ func (e *Executor) Run(task *model.Task) error {
 
 		finishCh := make(chan struct{}, 1)
 		go func() {
-			defer func() {
-				if r := recover(); r != nil {
-					err = fmt.Errorf("task %s panic: %v", key, r)
-				}
-				finishCh <- struct{}{}
-			}()
+			defer func() { finishCh <- struct{}{} }()
+			defer crash.Recover(e.crash, "executor.goroutine", key, func(e error) {
+				err = fmt.Errorf("task %s %v", key, e)
+			})
 
 			e.run(key)
 		}()
@@ -86,6 +91,19 @@ func (e *Executor) Run(task *model.Task) error {
 	return nil
 }
 
+// Update replace the spec of task, biz logic reads the new spec in its next round.
+func (e *Executor) Update(task *model.Task) error {
+	old := e.getTask(task.TaskKey)
+	if old == nil {
+		return errors.New("update need after run")
+	}
+	updated := task.Clone()
+	updated.Status = old.Status
+	e.setTask(task.TaskKey, updated)
+	e.resultChan <- updated.Clone()
+	return nil
+}
+
 func (e *Executor) Exit(taskKey string) error {
 	ch := e.getTaskCtrl(taskKey)
 	if ch == nil {
@@ -170,6 +188,7 @@ func (e *Executor) run(taskKey string) {
 				e.syncRunFinishResult(taskKey, err)
 				return
 			}
+			e.heartbeat(taskKey)
 		}
 	}
 }
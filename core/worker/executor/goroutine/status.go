@@ -1,6 +1,8 @@
 package goroutine
 
 import (
+	"time"
+
 	"github.com/xyzbit/minitaskx/core/model"
 )
 
@@ -36,6 +38,17 @@ func (e *Executor) syncStopResult(taskKey string) {
 	e.resultChan <- cloneTask
 }
 
+// heartbeat marks the task alive after each round of biz logic,
+// a round that never returns leaves the heartbeat stale.
+func (e *Executor) heartbeat(taskKey string) {
+	now := time.Now()
+	e.taskrw.Lock()
+	defer e.taskrw.Unlock()
+	if t, ok := e.tasks[taskKey]; ok {
+		t.LastHeartbeat = &now
+	}
+}
+
 func (e *Executor) getTask(taskKey string) *model.Task {
 	e.taskrw.RLock()
 	defer e.taskrw.RUnlock()
@@ -3,10 +3,14 @@ package executor
 import (
 	"context"
 
+	"github.com/xyzbit/minitaskx/core/components/crash"
 	"github.com/xyzbit/minitaskx/core/model"
 )
 
 // Interface is the interface of the executor.
+// async methods return nil once the call is accepted, ErrAlreadyRunning from
+// Run of a running task, errors made by Reject for calls that can never
+// succeed, and other errors for transient failures, see ResultOf.
 type Interface interface {
 	// (async) Run will create a executor's instance to run task and return standard results after completion.
 	// The executor running inside the worker program recommends processing ctx.Done for gracefully exit.
@@ -23,3 +27,25 @@ type Interface interface {
 	List(ctx context.Context) ([]*model.Task, error)
 	ChangeResult() <-chan *model.Task
 }
+
+// Updater is implemented by executors supporting hot reconfiguration.
+// executors not implementing it are restarted to apply a new spec.
+type Updater interface {
+	// (async) Update apply the new spec of task to the running or paused executor,
+	// the reported real task should carry the new generation.
+	Update(task *model.Task) error
+}
+
+// CrashReporterSetter is implemented by executors and loaders recovering
+// panics of their goroutines, the worker sets its reporter before it runs, eg.
+//
+//	defer crash.Recover(e.crash, "executor.docker", taskKey, onPanic)
+type CrashReporterSetter interface {
+	SetCrashReporter(r crash.Reporter)
+}
+
+// SchemaDescriber is implemented by executors publishing the schema of
+// payloads they accept, workers report it to the scheduler at startup.
+type SchemaDescriber interface {
+	PayloadSchema() model.PayloadSchema
+}
@@ -14,6 +14,7 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/xyzbit/minitaskx/core/components/crash"
 	"github.com/xyzbit/minitaskx/core/components/log"
 	"github.com/xyzbit/minitaskx/core/model"
 	"github.com/xyzbit/minitaskx/core/worker/executor"
@@ -31,6 +32,8 @@ type Executor struct {
 	taskrw     sync.RWMutex
 	tasks      map[string]*taskCtrl
 	resultChan chan *model.Task
+	// reports panics of monitors, nil only logs them.
+	crash crash.Reporter
 }
 
 func NewExecutor() executor.Interface {
@@ -63,12 +66,12 @@ func NewExecutor() executor.Interface {
 
 func (e *Executor) Run(task *model.Task) error {
 	if task.Payload == "" {
-		return fmt.Errorf("task payload is nil")
+		return executor.Reject("task payload is nil")
 	}
 
 	var config corev1.Container
 	if err := sonic.UnmarshalString(task.Payload, &config); err != nil {
-		return fmt.Errorf("解析容器配置失败: %v", err)
+		return executor.Reject("解析容器配置失败: %v", err)
 	}
 
 	job := &batchv1.Job{
@@ -143,11 +146,11 @@ func (e *Executor) stopAndRemove(taskKey string, force bool, status model.TaskSt
 }
 
 func (e *Executor) Pause(taskKey string) error {
-	return fmt.Errorf("Kaniko执行器不支持暂停操作")
+	return executor.Reject("Kaniko执行器不支持暂停操作")
 }
 
 func (e *Executor) Resume(taskKey string) error {
-	return fmt.Errorf("Kaniko执行器不支持恢复操作")
+	return executor.Reject("Kaniko执行器不支持恢复操作")
 }
 
 func (e *Executor) List(ctx context.Context) ([]*model.Task, error) {
@@ -165,11 +168,20 @@ func (e *Executor) ChangeResult() <-chan *model.Task {
 	return e.resultChan
 }
 
+func (e *Executor) SetCrashReporter(r crash.Reporter) {
+	e.crash = r
+}
+
 func (e *Executor) monitorJob(taskKey string) {
 	ctrl, err := e.getTaskCtrl(taskKey)
 	if err != nil {
 		return
 	}
+	defer crash.Recover(e.crash, "executor.k8sjob", taskKey, func(err error) {
+		ctrl.task.Status = model.TaskStatusFailed
+		ctrl.task.Msg = err.Error()
+		e.resultChan <- ctrl.task
+	})
 
 	watcher, err := e.cli.BatchV1().Jobs(e.namespace).Watch(context.Background(), metav1.ListOptions{
 		FieldSelector: fmt.Sprintf("metadata.name=%s", ctrl.jobName),
@@ -0,0 +1,24 @@
+package executor
+
+import (
+	"context"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// Loader reports real tasks of a task type, every executor is the loader of
+// its own tasks. a separate loader is useful when the executor only launches
+// tasks, eg. a subprocess executor listing processes by loader.ProcessLoader.
+type Loader interface {
+	List(ctx context.Context) ([]*model.Task, error)
+	ChangeResult() <-chan *model.Task
+}
+
+// RunLoaders starts registered loaders having a method Run(ctx) until ctx is done.
+func (ge *Manager) RunLoaders(ctx context.Context) {
+	for _, l := range ge.registry().loaders {
+		if r, ok := l.(interface{ Run(ctx context.Context) }); ok {
+			go r.Run(ctx)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package loader
+
+import "os"
+
+// processAlive reports whether pid exists.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package loader
+
+import "syscall"
+
+// processAlive reports whether pid exists, zombie processes not yet
+// reaped by the parent are considered alive.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
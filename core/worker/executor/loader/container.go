@@ -0,0 +1,78 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// ContainerLoader lists Docker containers of taskType labeled by
+// model.LabelTaskKey and model.LabelTaskType, including exited ones.
+// executors should remove containers once their final status is reported.
+type ContainerLoader struct {
+	*poller
+	cli      *client.Client
+	taskType string
+}
+
+func NewContainerLoader(cli *client.Client, taskType string, opts ...Option) *ContainerLoader {
+	l := &ContainerLoader{cli: cli, taskType: taskType}
+	l.poller = newPoller(l.List, newOptions(opts...).pollInterval)
+	return l
+}
+
+// Labels returns labels of the container running taskKey.
+func (l *ContainerLoader) Labels(taskKey string) map[string]string {
+	return map[string]string{
+		model.LabelTaskKey:  taskKey,
+		model.LabelTaskType: l.taskType,
+	}
+}
+
+func (l *ContainerLoader) List(ctx context.Context) ([]*model.Task, error) {
+	containers, err := l.cli.ContainerList(ctx, container.ListOptions{
+		All: true,
+		Filters: filters.NewArgs(
+			filters.Arg("label", model.LabelTaskKey),
+			filters.Arg("label", model.LabelTaskType+"="+l.taskType),
+		),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取容器列表失败: %v", err)
+	}
+
+	tasks := make([]*model.Task, 0, len(containers))
+	for _, c := range containers {
+		task := &model.Task{TaskKey: c.Labels[model.LabelTaskKey], Type: l.taskType}
+		switch c.State {
+		case "running", "restarting":
+			task.Status = model.TaskStatusRunning
+		case "paused":
+			task.Status = model.TaskStatusPaused
+		case "exited", "dead":
+			task.Status, task.Msg = exitedStatus(c.Status)
+		default:
+			// created or being removed.
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// exitedStatus parses exit code from docker status like "Exited (1) 2 minutes ago".
+func exitedStatus(status string) (model.TaskStatus, string) {
+	var code int
+	if _, err := fmt.Sscanf(status, "Exited (%d)", &code); err != nil {
+		return model.TaskStatusFailed, status
+	}
+	if code != 0 {
+		return model.TaskStatusFailed, fmt.Sprintf("容器退出码: %d", code)
+	}
+	return model.TaskStatusSuccess, ""
+}
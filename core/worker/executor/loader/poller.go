@@ -0,0 +1,109 @@
+// Package loader provides ready-made List/ChangeResult plumbing for executors
+// whose tasks run out of the worker process, eg. subprocesses and containers.
+// loaders are registered by executor.RegisterLoader, and started by worker.
+package loader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+const (
+	defaultPollInterval = 2 * time.Second
+	resultChBuffer      = 100
+)
+
+type options struct {
+	pollInterval time.Duration
+}
+
+type Option func(o *options)
+
+// WithPollInterval sets how often the runtime is listed to detect status changes.
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = interval
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	o := options{pollInterval: defaultPollInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &o
+}
+
+// poller turns a list function into ChangeResult by diffing statuses between polls.
+type poller struct {
+	list       func(ctx context.Context) ([]*model.Task, error)
+	interval   time.Duration
+	resultChan chan *model.Task
+
+	mu   sync.Mutex
+	last map[string]model.TaskStatus
+}
+
+func newPoller(list func(ctx context.Context) ([]*model.Task, error), interval time.Duration) *poller {
+	return &poller{
+		list:       list,
+		interval:   interval,
+		resultChan: make(chan *model.Task, resultChBuffer),
+		last:       make(map[string]model.TaskStatus),
+	}
+}
+
+func (p *poller) ChangeResult() <-chan *model.Task {
+	return p.resultChan
+}
+
+// Run polls the runtime until ctx is done.
+func (p *poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *poller) poll(ctx context.Context) {
+	tasks, err := p.list(ctx)
+	if err != nil {
+		log.Error("[Loader] list failed: %v", err)
+		return
+	}
+	for _, t := range p.changed(tasks) {
+		select {
+		case p.resultChan <- t:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// changed returns tasks whose status differs from the last poll.
+func (p *poller) changed(tasks []*model.Task) []*model.Task {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var ret []*model.Task
+	seen := make(map[string]model.TaskStatus, len(tasks))
+	for _, t := range tasks {
+		seen[t.TaskKey] = t.Status
+		if status, ok := p.last[t.TaskKey]; !ok || status != t.Status {
+			ret = append(ret, t)
+		}
+	}
+	p.last = seen
+	return ret
+}
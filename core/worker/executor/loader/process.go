@@ -0,0 +1,187 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/core/worker/executor"
+)
+
+const (
+	pidFileExt  = ".pid"
+	exitFileExt = ".exit"
+
+	// TaskKeyEnv tags subprocesses with their task key, see ProcessLoader.Tag.
+	TaskKeyEnv = "MINITASKX_TASK_KEY"
+)
+
+// ProcessLoader lists OS processes of taskType tracked by pid files in dir,
+// named <task key>.pid. a process is running while its pid is alive,
+// once exited its status is read from <task key>.exit holding the exit code.
+// pid files survive worker restarts, so running subprocesses are not lost.
+// cpu and memory of processes are sampled every poll, excluding children.
+type ProcessLoader struct {
+	*poller
+	dir      string
+	taskType string
+
+	mu sync.Mutex
+	// task key => usage sampled.
+	usages map[string]*processUsage
+}
+
+type processUsage struct {
+	tracker executor.UsageTracker
+	pid     int
+	// cpu seconds of the process at the last sample.
+	cpu float64
+	at  time.Time
+}
+
+func NewProcessLoader(dir, taskType string, opts ...Option) (*ProcessLoader, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建 pid 目录失败: %v", err)
+	}
+	l := &ProcessLoader{dir: dir, taskType: taskType, usages: make(map[string]*processUsage)}
+	l.poller = newPoller(l.List, newOptions(opts...).pollInterval)
+	return l, nil
+}
+
+// Tag appends TaskKeyEnv of taskKey to env of a subprocess,
+// so that the process can be identified by tools like ps.
+func (l *ProcessLoader) Tag(env []string, taskKey string) []string {
+	return append(env, TaskKeyEnv+"="+taskKey)
+}
+
+// Track records pid of the process running taskKey.
+func (l *ProcessLoader) Track(taskKey string, pid int) error {
+	_ = os.Remove(l.path(taskKey, exitFileExt))
+	return os.WriteFile(l.path(taskKey, pidFileExt), []byte(strconv.Itoa(pid)), 0o644)
+}
+
+// Exited records exit code of the process running taskKey, which is reported
+// as success if 0, otherwise failed.
+func (l *ProcessLoader) Exited(taskKey string, code int) error {
+	return os.WriteFile(l.path(taskKey, exitFileExt), []byte(strconv.Itoa(code)), 0o644)
+}
+
+// Untrack forgets taskKey, it's no longer listed.
+func (l *ProcessLoader) Untrack(taskKey string) error {
+	l.mu.Lock()
+	delete(l.usages, taskKey)
+	l.mu.Unlock()
+	for _, ext := range []string{pidFileExt, exitFileExt} {
+		if err := os.Remove(l.path(taskKey, ext)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *ProcessLoader) List(ctx context.Context) ([]*model.Task, error) {
+	files, err := filepath.Glob(filepath.Join(l.dir, "*"+pidFileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*model.Task, 0, len(files))
+	for _, file := range files {
+		taskKey := strings.TrimSuffix(filepath.Base(file), pidFileExt)
+		pid, err := readInt(file)
+		if err != nil {
+			// being written or corrupted, list it next time.
+			continue
+		}
+		tasks = append(tasks, l.status(taskKey, pid))
+	}
+	return tasks, nil
+}
+
+func (l *ProcessLoader) status(taskKey string, pid int) *model.Task {
+	task := &model.Task{TaskKey: taskKey, Type: l.taskType, Status: model.TaskStatusRunning}
+	if processAlive(pid) {
+		task.Usage = l.sample(taskKey, pid)
+		return task
+	}
+	task.Usage = l.usage(taskKey)
+
+	code, err := readInt(l.path(taskKey, exitFileExt))
+	switch {
+	case err != nil:
+		task.Status = model.TaskStatusFailed
+		task.Msg = fmt.Sprintf("进程 %d 已退出, 退出码未知", pid)
+	case code != 0:
+		task.Status = model.TaskStatusFailed
+		task.Msg = fmt.Sprintf("进程退出码: %d", code)
+	default:
+		task.Status = model.TaskStatusSuccess
+	}
+	return task
+}
+
+// sample observes cpu and memory of pid, and returns usage of taskKey so far.
+func (l *ProcessLoader) sample(taskKey string, pid int) *model.ResourceUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	u := l.usages[taskKey]
+	if u == nil || u.pid != pid {
+		// pid changed once the task is run again.
+		u = &processUsage{pid: pid}
+		l.usages[taskKey] = u
+	}
+
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return u.tracker.Usage()
+	}
+	times, err := p.Times()
+	if err != nil {
+		return u.tracker.Usage()
+	}
+	mem, err := p.MemoryInfo()
+	if err != nil {
+		return u.tracker.Usage()
+	}
+	now, cpu := time.Now(), times.User+times.System
+	// cpu of the first sample is unknown until the next one.
+	if !u.at.IsZero() {
+		cores := 0.0
+		if elapsed := now.Sub(u.at).Seconds(); elapsed > 0 && cpu > u.cpu {
+			cores = (cpu - u.cpu) / elapsed
+		}
+		u.tracker.Observe(cores, float64(mem.RSS)/(1<<30))
+	}
+	u.cpu, u.at = cpu, now
+	return u.tracker.Usage()
+}
+
+// usage returns usage sampled of taskKey, nil if none.
+func (l *ProcessLoader) usage(taskKey string) *model.ResourceUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if u := l.usages[taskKey]; u != nil {
+		return u.tracker.Usage()
+	}
+	return nil
+}
+
+func (l *ProcessLoader) path(taskKey, ext string) string {
+	return filepath.Join(l.dir, taskKey+ext)
+}
+
+func readInt(path string) (int, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
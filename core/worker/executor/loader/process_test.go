@@ -0,0 +1,104 @@
+package loader
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestProcessLoader(t *testing.T) {
+	l, err := NewProcessLoader(t.TempDir(), "shell")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a pid which has exited.
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("无法运行子进程: %v", err)
+	}
+	exitedPid := cmd.Process.Pid
+
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(l.Track("running", os.Getpid()))
+	must(l.Track("success", exitedPid))
+	must(l.Exited("success", 0))
+	must(l.Track("failed", exitedPid))
+	must(l.Exited("failed", 2))
+	must(l.Track("unknown", exitedPid))
+
+	list := func() map[string]model.TaskStatus {
+		tasks, err := l.List(context.Background())
+		must(err)
+		ret := make(map[string]model.TaskStatus)
+		for _, task := range tasks {
+			if task.Type != "shell" {
+				t.Errorf("任务类型错误: %s", task.Type)
+			}
+			ret[task.TaskKey] = task.Status
+		}
+		return ret
+	}
+
+	t.Run("根据 pid 和退出码得到状态", func(t *testing.T) {
+		want := map[string]model.TaskStatus{
+			"running": model.TaskStatusRunning,
+			"success": model.TaskStatusSuccess,
+			"failed":  model.TaskStatusFailed,
+			"unknown": model.TaskStatusFailed,
+		}
+		got := list()
+		if len(got) != len(want) {
+			t.Fatalf("期望 %v, 得到 %v", want, got)
+		}
+		for key, status := range want {
+			if got[key] != status {
+				t.Errorf("任务 %s 期望 %s, 得到 %s", key, status, got[key])
+			}
+		}
+	})
+
+	t.Run("采样运行中进程的资源", func(t *testing.T) {
+		usage := func() *model.ResourceUsage {
+			tasks, err := l.List(context.Background())
+			must(err)
+			for _, task := range tasks {
+				if task.TaskKey == "running" {
+					return task.Usage
+				}
+			}
+			return nil
+		}
+		usage()
+		u := usage()
+		if u == nil || u.Samples == 0 || u.PeakMemory <= 0 || u.AvgCPU < 0 {
+			t.Fatalf("期望采样到资源, 得到 %+v", u)
+		}
+	})
+
+	t.Run("Untrack 后不再列出", func(t *testing.T) {
+		must(l.Untrack("success"))
+		if _, ok := list()["success"]; ok {
+			t.Fatal("success 仍被列出")
+		}
+	})
+
+	t.Run("只上报状态变化", func(t *testing.T) {
+		l.poll(context.Background())
+		if n := len(l.ChangeResult()); n != 3 {
+			t.Fatalf("期望 3 个变化, 得到 %d", n)
+		}
+		l.poll(context.Background())
+		if n := len(l.ChangeResult()); n != 3 {
+			t.Fatalf("状态未变化不应上报, 得到 %d", n)
+		}
+	})
+}
@@ -3,7 +3,11 @@ package executor
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sync"
+	"time"
 
+	"github.com/xyzbit/minitaskx/core/components/crash"
 	"github.com/xyzbit/minitaskx/core/model"
 )
 
@@ -11,41 +15,71 @@ const (
 	resultChBuffer = 100
 )
 
-var executors = make(map[string]Interface)
-
-func RegisterExecutor(taskType string, ce Interface) {
-	executors[taskType] = ce
+type Manager struct {
+	// executors of the manager, nil means DefaultRegistry.
+	reg *Registry
+	// reports panics recovered by goroutines of the manager, nil only logs them.
+	crash crash.Reporter
+	// task key <==> generation applied to executor,
+	// stamped on real tasks reported by executors not carrying generation.
+	generations sync.Map
+	// task key <==> lease epoch the executor is started with, stamped like generation.
+	epochs sync.Map
+	// task key <==> payload applied to executor, changes of labels or
+	// extra alone don't restart executors not supporting Updater.
+	payloads sync.Map
+	// task keys being restarted to apply a new spec,
+	// final results of the old executor are dropped.
+	restarting sync.Map
 }
 
-func getExecutor(taskType string) (Interface, bool) {
-	e, ok := executors[taskType]
-	return e, ok
+// SetCrashReporter sets reporter of panics recovered by goroutines of the
+// manager and of its executors and loaders implementing CrashReporterSetter.
+// executors of a registry shared by workers report to the last reporter set.
+func (ge *Manager) SetCrashReporter(r crash.Reporter) {
+	ge.crash = r
+	reg := ge.registry()
+	for _, e := range reg.executors {
+		if s, ok := e.(CrashReporterSetter); ok {
+			s.SetCrashReporter(r)
+		}
+	}
+	for _, l := range reg.loaders {
+		if s, ok := l.(CrashReporterSetter); ok {
+			s.SetCrashReporter(r)
+		}
+	}
 }
 
-type Manager struct{}
-
 func (ge *Manager) List(ctx context.Context) ([]*model.Task, error) {
 	tasks := make([]*model.Task, 0)
-	for _, e := range executors {
-		ts, err := e.List(ctx)
+	for _, l := range ge.registry().allLoaders() {
+		ts, err := l.List(ctx)
 		if err != nil {
 			return nil, err
 		}
-		tasks = append(tasks, ts...)
+		for _, t := range ts {
+			tasks = append(tasks, ge.stamp(t))
+		}
 	}
 	return tasks, nil
 }
 
+// ChangeHandle applies change to the executor of the task, classify the
+// error by ResultOf.
 func (ge *Manager) ChangeHandle(change *model.Change) error {
-	exe, exist := getExecutor(change.TaskType)
+	exe, exist := ge.registry().executor(change.TaskType)
 	if !exist {
-		return fmt.Errorf("executor type(%s)  not found", change.TaskType)
+		return Reject("executor type(%s)  not found", change.TaskType)
 	}
 
 	var err error
 	switch change.ChangeType {
 	case model.ChangeCreate:
+		ge.applied(change.Task)
 		err = exe.Run(change.Task)
+	case model.ChangeUpdate:
+		err = ge.update(exe, change.Task)
 	case model.ChangeDelete:
 		err = exe.Exit(change.TaskKey)
 	case model.ChangePause:
@@ -53,21 +87,130 @@ func (ge *Manager) ChangeHandle(change *model.Change) error {
 	case model.ChangeResume:
 		err = exe.Resume(change.TaskKey)
 	case model.ChangeStop:
-		err = exe.Stop(change.TaskKey)
+		err = ge.stop(exe, change)
 	default:
-		err = fmt.Errorf("unknown change type: %s", change.ChangeType)
+		err = Reject("unknown change type: %s", change.ChangeType)
 	}
 	return err
 }
 
 func (ge *Manager) ChangeResult() <-chan *model.Task {
 	resultCh := make(chan *model.Task, resultChBuffer)
-	for _, e := range executors {
-		go func(e Interface) {
-			for event := range e.ChangeResult() {
-				resultCh <- event
+	for _, l := range ge.registry().allLoaders() {
+		go func(l Loader) {
+			for event := range l.ChangeResult() {
+				if stamped := ge.result(event); stamped != nil {
+					resultCh <- stamped
+				}
 			}
-		}(e)
+		}(l)
 	}
 	return resultCh
 }
+
+// result stamps event reported by executor, nil if it is dropped.
+func (ge *Manager) result(event *model.Task) *model.Task {
+	defer crash.Recover(ge.crash, "executor.result", event.TaskKey, nil)
+	if event.Status.IsFinalStatus() && ge.isStale(event) {
+		return nil
+	}
+	stamped := ge.stamp(event)
+	if event.Status.IsFinalStatus() {
+		ge.generations.Delete(event.TaskKey)
+		ge.payloads.Delete(event.TaskKey)
+		ge.epochs.Delete(event.TaskKey)
+	}
+	return stamped
+}
+
+// restartTimeout is the max time waiting for the old executor exiting.
+const restartTimeout = 30 * time.Second
+
+// update apply new spec by Updater, or restart the executor if not supported.
+// executors not supporting Updater can't observe labels or extra, so the
+// executor keeps running if payload is not changed.
+func (ge *Manager) update(exe Interface, task *model.Task) error {
+	if u, ok := exe.(Updater); ok {
+		if err := u.Update(task); err != nil {
+			return err
+		}
+		ge.applied(task)
+		return nil
+	}
+	if payload, ok := ge.payloads.Load(task.TaskKey); ok && payload.(string) == task.Payload {
+		ge.applied(task)
+		return nil
+	}
+	return ge.restart(exe, task)
+}
+
+// applied records the spec of task is applied to its executor.
+func (ge *Manager) applied(task *model.Task) {
+	ge.generations.Store(task.TaskKey, task.Generation)
+	ge.payloads.Store(task.TaskKey, task.Payload)
+	ge.epochs.Store(task.TaskKey, task.Epoch)
+}
+
+// restart exits the executor and runs task after it exited,
+// final result of the old executor is dropped.
+func (ge *Manager) restart(exe Interface, task *model.Task) error {
+	ge.restarting.Store(task.TaskKey, struct{}{})
+	defer ge.restarting.Delete(task.TaskKey)
+
+	if err := exe.Exit(task.TaskKey); err != nil {
+		return fmt.Errorf("restart exit: %v", err)
+	}
+	deadline := time.Now().Add(restartTimeout)
+	l := ge.registry().loaderOf(task.Type, exe)
+	for {
+		tasks, err := l.List(context.Background())
+		if err != nil {
+			return fmt.Errorf("restart wait exit: %v", err)
+		}
+		if !slices.ContainsFunc(tasks, func(t *model.Task) bool { return t.TaskKey == task.TaskKey }) {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("restart wait exit timeout")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	ge.applied(task)
+	return exe.Run(task)
+}
+
+// IsRestarting reports whether the executor of taskKey is being restarted.
+func (ge *Manager) IsRestarting(taskKey string) bool {
+	_, ok := ge.restarting.Load(taskKey)
+	return ok
+}
+
+// isStale reports whether the event is from an executor replaced by restart.
+func (ge *Manager) isStale(event *model.Task) bool {
+	if _, ok := ge.restarting.Load(event.TaskKey); ok {
+		return true
+	}
+	g, ok := ge.generations.Load(event.TaskKey)
+	return ok && event.Generation != 0 && event.Generation < g.(int64)
+}
+
+// stamp fills generation and epoch of real task reported by executors not carrying them.
+func (ge *Manager) stamp(t *model.Task) *model.Task {
+	if t == nil {
+		return t
+	}
+	g, gok := ge.generations.Load(t.TaskKey)
+	e, eok := ge.epochs.Load(t.TaskKey)
+	if (t.Generation != 0 || !gok) && (t.Epoch != 0 || !eok) {
+		return t
+	}
+	stamped := *t
+	if stamped.Generation == 0 && gok {
+		stamped.Generation = g.(int64)
+	}
+	if stamped.Epoch == 0 && eok {
+		stamped.Epoch = e.(int64)
+	}
+	return &stamped
+}
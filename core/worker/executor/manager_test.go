@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// recordExecutor records calls, tasks exit immediately.
+type recordExecutor struct {
+	runs, exits int
+}
+
+func (e *recordExecutor) Run(*model.Task) error                       { e.runs++; return nil }
+func (e *recordExecutor) Exit(string) error                           { e.exits++; return nil }
+func (e *recordExecutor) Stop(string) error                           { return nil }
+func (e *recordExecutor) Pause(string) error                          { return nil }
+func (e *recordExecutor) Resume(string) error                         { return nil }
+func (e *recordExecutor) List(context.Context) ([]*model.Task, error) { return nil, nil }
+func (e *recordExecutor) ChangeResult() <-chan *model.Task            { return nil }
+
+func TestManagerUpdate(t *testing.T) {
+	exe := &recordExecutor{}
+	ge := &Manager{}
+	task := &model.Task{TaskKey: "t1", Type: "record", Payload: "p1", Generation: 1}
+	ge.applied(task)
+
+	t.Run("只修改标签不重启", func(t *testing.T) {
+		patched := *task
+		patched.Labels = map[string]string{"k": "v"}
+		patched.Generation = 2
+		if err := ge.update(exe, &patched); err != nil {
+			t.Fatal(err)
+		}
+		if exe.exits != 0 || exe.runs != 0 {
+			t.Fatalf("期望不重启, 得到 exit %d run %d", exe.exits, exe.runs)
+		}
+		if g, _ := ge.generations.Load("t1"); g.(int64) != 2 {
+			t.Fatalf("期望 generation 2, 得到 %v", g)
+		}
+	})
+
+	t.Run("修改 payload 重启", func(t *testing.T) {
+		changed := *task
+		changed.Payload = "p2"
+		changed.Generation = 3
+		if err := ge.update(exe, &changed); err != nil {
+			t.Fatal(err)
+		}
+		if exe.exits != 1 || exe.runs != 1 {
+			t.Fatalf("期望重启一次, 得到 exit %d run %d", exe.exits, exe.runs)
+		}
+	})
+}
+
+func TestResultOf(t *testing.T) {
+	cases := map[string]struct {
+		err  error
+		want Ack
+	}{
+		"接受":   {nil, AckAccepted},
+		"已在运行": {fmt.Errorf("run: %w", ErrAlreadyRunning), AckAlreadyRunning},
+		"永久拒绝": {fmt.Errorf("run: %w", Reject("bad payload %q", "x")), AckRejected},
+		"临时失败": {errors.New("daemon unavailable"), AckFailed},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := ResultOf(c.err); got.Ack != c.want || (c.err == nil) != (got.Err == nil) {
+				t.Fatalf("期望 %s, 得到 %+v", c.want, got)
+			}
+		})
+	}
+
+	t.Run("未知执行器类型被拒绝", func(t *testing.T) {
+		err := (&Manager{}).ChangeHandle(&model.Change{TaskKey: "t1", TaskType: "no-such-type", ChangeType: model.ChangeCreate})
+		if got := ResultOf(err); got.Ack != AckRejected {
+			t.Fatalf("期望 rejected, 得到 %+v", got)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// PreStopper is implemented by executors supporting pre-stop callbacks,
+// used by pre-stop hooks not declaring HTTPPost.
+type PreStopper interface {
+	// PreStop prepares the executor of taskKey to be stopped, it should
+	// return before ctx is done.
+	PreStop(ctx context.Context, taskKey string) error
+}
+
+// preStopClient posts http pre-stop hooks. redirects are not followed, the
+// hook succeeds with a 3xx response, and the host allowed by the scheduler
+// can not lead workers elsewhere.
+var preStopClient = &http.Client{
+	Timeout:       model.MaxPreStopGracePeriodSeconds * time.Second,
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// stop runs the pre-stop hook of the task within its grace period, then
+// stops the executor whatever the hook returns.
+func (ge *Manager) stop(exe Interface, change *model.Change) error {
+	if task := change.Task; task != nil && task.PreStop != nil {
+		if err := ge.preStop(exe, task); err != nil {
+			log.Warn("[Executor] pre-stop hook of task[%s] failed, stop it anyway: %v", task.TaskKey, err)
+		}
+	}
+	return exe.Stop(change.TaskKey)
+}
+
+func (ge *Manager) preStop(exe Interface, task *model.Task) error {
+	ctx, cancel := context.WithTimeout(context.Background(), task.PreStop.GracePeriod())
+	defer cancel()
+
+	if url := task.PreStop.HTTPPost; url != "" {
+		body, err := json.Marshal(task)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := preStopClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("http pre-stop hook responds %d", resp.StatusCode)
+		}
+		return nil
+	}
+
+	p, ok := exe.(PreStopper)
+	if !ok {
+		return fmt.Errorf("executor type(%s) does not support pre-stop hook", task.Type)
+	}
+	return p.PreStop(ctx, task.TaskKey)
+}
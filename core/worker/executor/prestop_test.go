@@ -0,0 +1,99 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// preStopExecutor records pre-stop callbacks and stops, the callback blocks until ctx is done if block.
+type preStopExecutor struct {
+	recordExecutor
+	block          bool
+	preStops, stop int
+}
+
+func (e *preStopExecutor) Stop(string) error { e.stop++; return nil }
+
+func (e *preStopExecutor) PreStop(ctx context.Context, _ string) error {
+	e.preStops++
+	if e.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func TestPreStop(t *testing.T) {
+	ge := &Manager{}
+	change := func(hook *model.PreStopHook) *model.Change {
+		return &model.Change{TaskKey: "t1", ChangeType: model.ChangeStop, Task: &model.Task{TaskKey: "t1", Type: "prestop", PreStop: hook}}
+	}
+
+	t.Run("停止前回调执行器", func(t *testing.T) {
+		exe := &preStopExecutor{}
+		if err := ge.stop(exe, change(&model.PreStopHook{})); err != nil {
+			t.Fatal(err)
+		}
+		if exe.preStops != 1 || exe.stop != 1 {
+			t.Fatalf("期望回调并停止, 得到 pre-stop %d stop %d", exe.preStops, exe.stop)
+		}
+	})
+
+	t.Run("超过宽限期后仍然停止", func(t *testing.T) {
+		exe := &preStopExecutor{block: true}
+		start := time.Now()
+		if err := ge.stop(exe, change(&model.PreStopHook{GracePeriodSeconds: 1})); err != nil {
+			t.Fatal(err)
+		}
+		if exe.stop != 1 || time.Since(start) < time.Second {
+			t.Fatalf("期望等待宽限期后停止, 得到 stop %d, 耗时 %s", exe.stop, time.Since(start))
+		}
+	})
+
+	t.Run("调用 HTTP 钩子", func(t *testing.T) {
+		called := make(chan string, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called <- r.Method
+		}))
+		defer srv.Close()
+		exe := &preStopExecutor{}
+		if err := ge.stop(exe, change(&model.PreStopHook{HTTPPost: srv.URL})); err != nil {
+			t.Fatal(err)
+		}
+		if method := <-called; method != http.MethodPost || exe.preStops != 0 || exe.stop != 1 {
+			t.Fatalf("期望 POST 钩子后停止, 得到 %s, pre-stop %d stop %d", method, exe.preStops, exe.stop)
+		}
+	})
+
+	t.Run("HTTP 钩子不跟随重定向", func(t *testing.T) {
+		redirected := make(chan struct{}, 1)
+		target := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			redirected <- struct{}{}
+		}))
+		defer target.Close()
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+		}))
+		defer srv.Close()
+		if err := ge.preStop(&preStopExecutor{}, change(&model.PreStopHook{HTTPPost: srv.URL}).Task); err != nil {
+			t.Fatalf("期望 3xx 视为成功, 得到 %v", err)
+		}
+		select {
+		case <-redirected:
+			t.Fatal("期望不请求重定向的地址")
+		default:
+		}
+	})
+
+	t.Run("执行器不支持回调", func(t *testing.T) {
+		if err := ge.preStop(&recordExecutor{}, change(&model.PreStopHook{}).Task); err == nil || errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("期望不支持的错误, 得到 %v", err)
+		}
+	})
+}
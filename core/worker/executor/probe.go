@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// Prober is implemented by executors supporting probe callbacks,
+// used by probes declaring neither HTTPGet nor Command.
+type Prober interface {
+	// Probe returns nil if the executor of taskKey is healthy.
+	Probe(ctx context.Context, taskKey string) error
+}
+
+// Probe runs spec against the running task.
+func (ge *Manager) Probe(ctx context.Context, task *model.Task, spec *model.ProbeSpec) error {
+	ctx, cancel := context.WithTimeout(ctx, spec.Timeout())
+	defer cancel()
+
+	switch {
+	case spec.HTTPGet != "":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, spec.HTTPGet, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("http probe responds %d", resp.StatusCode)
+		}
+		return nil
+	case len(spec.Command) > 0:
+		if out, err := exec.CommandContext(ctx, spec.Command[0], spec.Command[1:]...).CombinedOutput(); err != nil {
+			return fmt.Errorf("command probe: %v, output: %s", err, out)
+		}
+		return nil
+	default:
+		exe, exist := ge.registry().executor(task.Type)
+		if !exist {
+			return fmt.Errorf("executor type(%s) not found", task.Type)
+		}
+		p, ok := exe.(Prober)
+		if !ok {
+			return fmt.Errorf("executor type(%s) does not support probe", task.Type)
+		}
+		return p.Probe(ctx, task.TaskKey)
+	}
+}
+
+// Restart exits the executor of task and runs it again.
+func (ge *Manager) Restart(task *model.Task) error {
+	exe, exist := ge.registry().executor(task.Type)
+	if !exist {
+		return fmt.Errorf("executor type(%s) not found", task.Type)
+	}
+	return ge.restart(exe, task)
+}
+
+// Fail exits the executor of task, the task turns to failed.
+func (ge *Manager) Fail(task *model.Task) error {
+	exe, exist := ge.registry().executor(task.Type)
+	if !exist {
+		return fmt.Errorf("executor type(%s) not found", task.Type)
+	}
+	return exe.Exit(task.TaskKey)
+}
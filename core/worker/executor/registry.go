@@ -0,0 +1,127 @@
+package executor
+
+import (
+	"slices"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// Registry holds executors, loaders and warm pools of task types. workers
+// embedded in one process may run different task types by their own
+// registries, see worker.WithExecutorRegistry. registries are not safe for
+// concurrent use, register before workers run.
+type Registry struct {
+	executors map[string]Interface
+	loaders   map[string]Loader
+	warmPools []maintainer
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		executors: make(map[string]Interface),
+		loaders:   make(map[string]Loader),
+	}
+}
+
+// registry used by package level functions and workers without their own.
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the registry of package level functions, eg. RegisterExecutor.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// NewManager returns the manager of executors registered in r, nil means DefaultRegistry.
+func NewManager(r *Registry) *Manager {
+	return &Manager{reg: r}
+}
+
+func (ge *Manager) registry() *Registry {
+	if ge.reg == nil {
+		return defaultRegistry
+	}
+	return ge.reg
+}
+
+func RegisterExecutor(taskType string, ce Interface) {
+	defaultRegistry.RegisterExecutor(taskType, ce)
+}
+
+// RegisterLoader lists real tasks of taskType by l instead of the executor of taskType,
+// so one worker can combine in-process, Docker and k8s runtimes.
+// if l has a method Run(ctx), it's started by worker.
+func RegisterLoader(taskType string, l Loader) {
+	defaultRegistry.RegisterLoader(taskType, l)
+}
+
+// RegisteredTypes returns sorted task types having a registered executor.
+func RegisteredTypes() []string {
+	return defaultRegistry.RegisteredTypes()
+}
+
+// RegisteredSchemas returns payload schema of registered executors implementing SchemaDescriber.
+func RegisteredSchemas() map[string]model.PayloadSchema {
+	return defaultRegistry.RegisteredSchemas()
+}
+
+func (r *Registry) RegisterExecutor(taskType string, ce Interface) {
+	r.executors[taskType] = ce
+}
+
+// RegisterLoader is like the package level RegisterLoader.
+func (r *Registry) RegisterLoader(taskType string, l Loader) {
+	r.loaders[taskType] = l
+}
+
+// RegisteredTypes returns sorted task types having a registered executor.
+func (r *Registry) RegisteredTypes() []string {
+	types := make([]string, 0, len(r.executors))
+	for taskType := range r.executors {
+		types = append(types, taskType)
+	}
+	slices.Sort(types)
+	return types
+}
+
+// RegisteredSchemas returns payload schema of registered executors implementing SchemaDescriber.
+func (r *Registry) RegisteredSchemas() map[string]model.PayloadSchema {
+	schemas := make(map[string]model.PayloadSchema)
+	for taskType, e := range r.executors {
+		if d, ok := e.(SchemaDescriber); ok {
+			schemas[taskType] = d.PayloadSchema()
+		}
+	}
+	return schemas
+}
+
+func (r *Registry) executor(taskType string) (Interface, bool) {
+	e, ok := r.executors[taskType]
+	return e, ok
+}
+
+// loaderOf returns the loader of taskType, exe itself if no loader registered.
+func (r *Registry) loaderOf(taskType string, exe Interface) Loader {
+	if l, ok := r.loaders[taskType]; ok {
+		return l
+	}
+	return exe
+}
+
+// allLoaders returns the loaders of all task types, each loader only once.
+func (r *Registry) allLoaders() []Loader {
+	ret := make([]Loader, 0, len(r.executors)+len(r.loaders))
+	add := func(l Loader) {
+		if !slices.Contains(ret, l) {
+			ret = append(ret, l)
+		}
+	}
+	for taskType, e := range r.executors {
+		if _, ok := r.loaders[taskType]; !ok {
+			add(e)
+		}
+	}
+	for _, l := range r.loaders {
+		add(l)
+	}
+	return ret
+}
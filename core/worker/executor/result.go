@@ -0,0 +1,64 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Ack is how an executor acknowledged an async call.
+type Ack string
+
+const (
+	// the call is accepted, its outcome is reported by ChangeResult.
+	AckAccepted Ack = "accepted"
+	// Run of a task the executor is already running, nothing is done.
+	AckAlreadyRunning Ack = "already_running"
+	// the call can never succeed, eg. invalid payload or unsupported operation,
+	// so it is not retried.
+	AckRejected Ack = "rejected"
+	// the call failed transiently, eg. the container runtime is unavailable,
+	// it is retried once the task is reconciled again.
+	AckFailed Ack = "failed"
+)
+
+// ErrAlreadyRunning is returned by Run of a task the executor is already running.
+var ErrAlreadyRunning = errors.New("task already running")
+
+// RejectedError is returned by executors rejecting a call permanently, see Reject.
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return "rejected: " + e.Reason
+}
+
+// Reject returns a RejectedError, async methods of executors return it for
+// calls that can never succeed, other errors are transient failures.
+func Reject(format string, args ...any) error {
+	return &RejectedError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Result is the acknowledgment of an async call of executor.
+type Result struct {
+	Ack Ack
+	// why the call is not accepted.
+	Err error
+}
+
+// ResultOf classifies err returned by an async call of executor, errors may
+// be wrapped.
+func ResultOf(err error) Result {
+	var rejected *RejectedError
+	switch {
+	case err == nil:
+		return Result{Ack: AckAccepted}
+	case errors.Is(err, ErrAlreadyRunning):
+		return Result{Ack: AckAlreadyRunning, Err: err}
+	case errors.As(err, &rejected):
+		return Result{Ack: AckRejected, Err: err}
+	default:
+		return Result{Ack: AckFailed, Err: err}
+	}
+}
@@ -0,0 +1,31 @@
+package executor
+
+import "context"
+
+// ScratchStore is a small key-value area persisted with a task, executors
+// keep cursors or offsets in it, so that a task paused, resumed, retried or
+// moved to another worker continues from them. values are limited by
+// model.MaxScratchKeys and model.MaxScratchBytes, and removed with the task.
+type ScratchStore interface {
+	// Get returns the scratch of task, empty if nothing is stored.
+	Get(ctx context.Context, taskKey string) (map[string]string, error)
+	// Put sets kvs to the scratch of task, other keys are kept.
+	Put(ctx context.Context, taskKey string, kvs map[string]string) error
+	// Delete removes keys from the scratch of task, all keys if none given.
+	Delete(ctx context.Context, taskKey string, keys ...string) error
+}
+
+// ScratchAware is implemented by executors using the scratch of tasks,
+// workers set the store before they run.
+type ScratchAware interface {
+	SetScratchStore(s ScratchStore)
+}
+
+// SetScratchStore sets s to registered executors implementing ScratchAware.
+func (ge *Manager) SetScratchStore(s ScratchStore) {
+	for _, e := range ge.registry().executors {
+		if a, ok := e.(ScratchAware); ok {
+			a.SetScratchStore(s)
+		}
+	}
+}
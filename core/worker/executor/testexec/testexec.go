@@ -0,0 +1,138 @@
+// Package testexec provides trivial executors registered under reserved task
+// types, so that load tests and CI suites exercise the full pipeline of
+// scheduler, worker and repo without custom executors.
+package testexec
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/core/worker/executor"
+	"github.com/xyzbit/minitaskx/core/worker/executor/goroutine"
+)
+
+// reserved task types, payloads are Spec in json, empty payload is zero Spec.
+const (
+	// finishes successfully at once.
+	TypeNoop = "minitaskx.noop"
+	// finishes successfully after Duration.
+	TypeSleep = "minitaskx.sleep"
+	// fails with Message after Duration.
+	TypeFail = "minitaskx.fail"
+	// fails with Probability after Duration, succeeds otherwise.
+	TypeFlaky = "minitaskx.flaky"
+)
+
+// time slept per round of biz logic, so that pause and stop are observed soon.
+const tick = 100 * time.Millisecond
+
+// Spec is the payload of tasks of reserved types.
+type Spec struct {
+	// eg. "10s", time paused is not counted.
+	Duration string `json:"duration,omitempty"`
+	// in [0, 1], used by TypeFlaky.
+	Probability float64 `json:"probability,omitempty"`
+	// error message of failed tasks.
+	Message string `json:"message,omitempty"`
+}
+
+// Register registers executors of all reserved task types.
+func Register() {
+	RegisterTo(executor.DefaultRegistry())
+}
+
+// RegisterTo registers executors of all reserved task types to r.
+func RegisterTo(r *executor.Registry) {
+	for _, taskType := range []string{TypeNoop, TypeSleep, TypeFail, TypeFlaky} {
+		r.RegisterExecutor(taskType, New(taskType))
+	}
+}
+
+// New returns the executor of the reserved task type, tasks of invalid
+// payloads are rejected.
+func New(taskType string) executor.Interface {
+	return &specExecutor{
+		Interface: goroutine.NewExecutor(func() goroutine.BizLogic { return bizLogic(taskType) }),
+	}
+}
+
+type specExecutor struct {
+	executor.Interface
+}
+
+func (e *specExecutor) Run(task *model.Task) error {
+	if _, err := parseSpec(task.Payload); err != nil {
+		return executor.Reject("invalid payload of %s: %v", task.Type, err)
+	}
+	return e.Interface.Run(task)
+}
+
+// Update applies the new spec, the time left of Duration is kept.
+func (e *specExecutor) Update(task *model.Task) error {
+	if _, err := parseSpec(task.Payload); err != nil {
+		return executor.Reject("invalid payload of %s: %v", task.Type, err)
+	}
+	return e.Interface.(executor.Updater).Update(task)
+}
+
+func parseSpec(payload string) (*Spec, error) {
+	spec := &Spec{}
+	if payload != "" {
+		if err := json.Unmarshal([]byte(payload), spec); err != nil {
+			return nil, err
+		}
+	}
+	if spec.Duration != "" {
+		if d, err := time.ParseDuration(spec.Duration); err != nil || d < 0 {
+			return nil, errors.New("duration must be a non-negative duration, eg. 10s")
+		}
+	}
+	if spec.Probability < 0 || spec.Probability > 1 {
+		return nil, errors.New("probability must be in [0, 1]")
+	}
+	return spec, nil
+}
+
+// bizLogic sleeps Duration in ticks, then finishes as taskType does.
+func bizLogic(taskType string) goroutine.BizLogic {
+	var (
+		remaining time.Duration
+		started   bool
+	)
+	return func(task *model.Task) (bool, error) {
+		spec, err := parseSpec(task.Payload)
+		if err != nil {
+			return true, err
+		}
+		if !started {
+			started = true
+			remaining, _ = time.ParseDuration(spec.Duration)
+		}
+		if taskType != TypeNoop && remaining > 0 {
+			slept := min(tick, remaining)
+			time.Sleep(slept)
+			remaining -= slept
+			return false, nil
+		}
+
+		switch taskType {
+		case TypeFail:
+			return true, failure(spec)
+		case TypeFlaky:
+			if rand.Float64() < spec.Probability {
+				return true, failure(spec)
+			}
+		}
+		return true, nil
+	}
+}
+
+func failure(spec *Spec) error {
+	if spec.Message != "" {
+		return errors.New(spec.Message)
+	}
+	return errors.New("failed by test executor")
+}
@@ -0,0 +1,55 @@
+package testexec
+
+import (
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/core/worker/executor"
+)
+
+func TestBizLogic(t *testing.T) {
+	// runs logic of taskType until finished, returns rounds run.
+	run := func(taskType, payload string) (int, error) {
+		fn := bizLogic(taskType)
+		task := &model.Task{TaskKey: "t1", Type: taskType, Payload: payload}
+		for rounds := 1; ; rounds++ {
+			if finished, err := fn(task); finished || err != nil {
+				return rounds, err
+			}
+		}
+	}
+
+	t.Run("noop 立即成功", func(t *testing.T) {
+		if rounds, err := run(TypeNoop, `{"duration":"1h"}`); rounds != 1 || err != nil {
+			t.Fatalf("期望一轮成功, 得到 %d 轮, %v", rounds, err)
+		}
+	})
+	t.Run("sleep 分多轮睡眠后成功", func(t *testing.T) {
+		if rounds, err := run(TypeSleep, `{"duration":"250ms"}`); rounds != 4 || err != nil {
+			t.Fatalf("期望 4 轮成功, 得到 %d 轮, %v", rounds, err)
+		}
+	})
+	t.Run("fail 失败并带上消息", func(t *testing.T) {
+		if _, err := run(TypeFail, `{"message":"boom"}`); err == nil || err.Error() != "boom" {
+			t.Fatalf("期望 boom, 得到 %v", err)
+		}
+	})
+	t.Run("flaky 按概率失败", func(t *testing.T) {
+		if _, err := run(TypeFlaky, `{"probability":1}`); err == nil {
+			t.Fatal("概率 1 应失败")
+		}
+		if _, err := run(TypeFlaky, `{"probability":0}`); err != nil {
+			t.Fatalf("概率 0 应成功, 得到 %v", err)
+		}
+	})
+}
+
+func TestRejectInvalidPayload(t *testing.T) {
+	exe := New(TypeSleep)
+	for _, payload := range []string{`{`, `{"duration":"soon"}`, `{"probability":2}`} {
+		err := exe.Run(&model.Task{TaskKey: "t1", Type: TypeSleep, Payload: payload})
+		if got := executor.ResultOf(err); got.Ack != executor.AckRejected {
+			t.Fatalf("payload %s 期望被拒绝, 得到 %+v", payload, got)
+		}
+	}
+}
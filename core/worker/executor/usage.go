@@ -0,0 +1,44 @@
+package executor
+
+import (
+	"sync"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// UsageTracker accumulates samples of resources used by a task, executors
+// of tasks running out of the worker process, eg. subprocesses and
+// containers, sample them while running and attach Usage to reported tasks.
+type UsageTracker struct {
+	mu              sync.Mutex
+	peakCPU, sumCPU float64
+	peakMem, sumMem float64
+	samples         int
+}
+
+// Observe adds a sample of cpu in cores and memory in GiB.
+func (u *UsageTracker) Observe(cpu, memory float64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.peakCPU = max(u.peakCPU, cpu)
+	u.peakMem = max(u.peakMem, memory)
+	u.sumCPU += cpu
+	u.sumMem += memory
+	u.samples++
+}
+
+// Usage returns usage of samples so far, nil if there is none.
+func (u *UsageTracker) Usage() *model.ResourceUsage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.samples == 0 {
+		return nil
+	}
+	return &model.ResourceUsage{
+		PeakCPU:    u.peakCPU,
+		AvgCPU:     u.sumCPU / float64(u.samples),
+		PeakMemory: u.peakMem,
+		AvgMemory:  u.sumMem / float64(u.samples),
+		Samples:    u.samples,
+	}
+}
@@ -0,0 +1,18 @@
+package executor
+
+import (
+	"testing"
+)
+
+func TestUsageTracker(t *testing.T) {
+	var u UsageTracker
+	if u.Usage() != nil {
+		t.Fatal("没有采样时期望 nil")
+	}
+	u.Observe(0.5, 1)
+	u.Observe(1.5, 3)
+	got := u.Usage()
+	if got.PeakCPU != 1.5 || got.AvgCPU != 1 || got.PeakMemory != 3 || got.AvgMemory != 2 || got.Samples != 2 {
+		t.Fatalf("期望峰值 1.5/3, 平均 1/2, 得到 %+v", got)
+	}
+}
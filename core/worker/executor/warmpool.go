@@ -0,0 +1,240 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+// Provisioner creates and destroys pre-provisioned instances of an executor,
+// eg. created but not started containers, or idle JVMs waiting for a job.
+type Provisioner[T any] interface {
+	Provision(ctx context.Context) (T, error)
+	Destroy(instance T) error
+}
+
+type warmPoolOptions struct {
+	// idle instances kept warm.
+	minIdle int
+	// max idle instances, released instances beyond it are destroyed.
+	maxIdle int
+	// idle instances older than it are destroyed, down to minIdle.
+	idleTimeout time.Duration
+	// how often the pool is refilled and reaped.
+	maintainInterval time.Duration
+	clock            clock.Clock
+}
+
+type WarmPoolOption func(o *warmPoolOptions)
+
+// WithPoolSize keeps minIdle instances warm and at most maxIdle idle instances.
+func WithPoolSize(minIdle, maxIdle int) WarmPoolOption {
+	return func(o *warmPoolOptions) {
+		o.minIdle = minIdle
+		o.maxIdle = maxIdle
+	}
+}
+
+// WithIdleTimeout reaps instances idle longer than timeout, down to min idle.
+func WithIdleTimeout(timeout time.Duration) WarmPoolOption {
+	return func(o *warmPoolOptions) {
+		o.idleTimeout = timeout
+	}
+}
+
+func WithMaintainInterval(interval time.Duration) WarmPoolOption {
+	return func(o *warmPoolOptions) {
+		o.maintainInterval = interval
+	}
+}
+
+// WithPoolClock replace the wall clock, mostly used by tests with clock.FakeClock.
+func WithPoolClock(c clock.Clock) WarmPoolOption {
+	return func(o *warmPoolOptions) {
+		o.clock = c
+	}
+}
+
+type warmInstance[T any] struct {
+	instance  T
+	idleSince time.Time
+}
+
+// WarmPool keeps pre-provisioned instances, Run of executors claims from it
+// to cut start latency. it's maintained by worker once registered by RegisterWarmPool.
+type WarmPool[T any] struct {
+	name        string
+	provisioner Provisioner[T]
+	opts        warmPoolOptions
+
+	mu sync.Mutex
+	// ordered by idleSince, claims take the newest.
+	idle   []warmInstance[T]
+	refill chan struct{}
+
+	idleGauge metrics.Gauge
+	claims    metrics.Counter
+}
+
+func NewWarmPool[T any](name string, p Provisioner[T], opts ...WarmPoolOption) *WarmPool[T] {
+	o := warmPoolOptions{
+		minIdle:          1,
+		idleTimeout:      10 * time.Minute,
+		maintainInterval: 5 * time.Second,
+		clock:            clock.RealClock{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.maxIdle < o.minIdle {
+		o.maxIdle = o.minIdle
+	}
+
+	m := metrics.Global()
+	return &WarmPool[T]{
+		name:        name,
+		provisioner: p,
+		opts:        o,
+		refill:      make(chan struct{}, 1),
+		idleGauge:   m.NewGauge("minitaskx_warm_pool_idle", "idle instances of warm pool", "pool"),
+		claims:      m.NewCounter("minitaskx_warm_pool_claims_total", "claims of warm pool, result is hit or miss", "pool", "result"),
+	}
+}
+
+// Claim takes a warm instance, or provisions one if the pool is empty.
+func (p *WarmPool[T]) Claim(ctx context.Context) (T, error) {
+	p.mu.Lock()
+	n := len(p.idle)
+	if n > 0 {
+		inst := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.idleGauge.Set(float64(n-1), p.name)
+		p.mu.Unlock()
+
+		p.claims.Add(1, p.name, "hit")
+		p.triggerRefill()
+		return inst.instance, nil
+	}
+	p.mu.Unlock()
+
+	p.claims.Add(1, p.name, "miss")
+	p.triggerRefill()
+	return p.provisioner.Provision(ctx)
+}
+
+// Release returns a reusable instance to the pool, it's destroyed if the pool is full.
+func (p *WarmPool[T]) Release(instance T) {
+	p.mu.Lock()
+	if len(p.idle) < p.opts.maxIdle {
+		p.idle = append(p.idle, warmInstance[T]{instance: instance, idleSince: p.opts.clock.Now()})
+		p.idleGauge.Set(float64(len(p.idle)), p.name)
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	p.destroy(instance)
+}
+
+// Idle returns the number of idle instances.
+func (p *WarmPool[T]) Idle() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle)
+}
+
+// Maintain refills and reaps the pool until ctx is done, then destroys idle instances.
+func (p *WarmPool[T]) Maintain(ctx context.Context) {
+	ticker := p.opts.clock.NewTicker(p.opts.maintainInterval)
+	defer ticker.Stop()
+
+	for {
+		p.reap()
+		p.fill(ctx)
+		select {
+		case <-ctx.Done():
+			p.drain()
+			return
+		case <-ticker.C():
+		case <-p.refill:
+		}
+	}
+}
+
+func (p *WarmPool[T]) triggerRefill() {
+	select {
+	case p.refill <- struct{}{}:
+	default:
+	}
+}
+
+// reap destroys instances idle longer than idle timeout, keeping min idle.
+func (p *WarmPool[T]) reap() {
+	now := p.opts.clock.Now()
+	p.mu.Lock()
+	var expired []T
+	for len(p.idle) > p.opts.minIdle && now.Sub(p.idle[0].idleSince) > p.opts.idleTimeout {
+		expired = append(expired, p.idle[0].instance)
+		p.idle = p.idle[1:]
+	}
+	p.idleGauge.Set(float64(len(p.idle)), p.name)
+	p.mu.Unlock()
+
+	for _, inst := range expired {
+		p.destroy(inst)
+	}
+}
+
+// fill provisions instances up to min idle.
+func (p *WarmPool[T]) fill(ctx context.Context) {
+	for p.Idle() < p.opts.minIdle && ctx.Err() == nil {
+		inst, err := p.provisioner.Provision(ctx)
+		if err != nil {
+			log.Error("[WarmPool] %s provision failed: %v", p.name, err)
+			return
+		}
+		p.Release(inst)
+	}
+}
+
+func (p *WarmPool[T]) drain() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.idleGauge.Set(0, p.name)
+	p.mu.Unlock()
+
+	for _, inst := range idle {
+		p.destroy(inst.instance)
+	}
+}
+
+func (p *WarmPool[T]) destroy(instance T) {
+	if err := p.provisioner.Destroy(instance); err != nil {
+		log.Error("[WarmPool] %s destroy failed: %v", p.name, err)
+	}
+}
+
+type maintainer interface {
+	Maintain(ctx context.Context)
+}
+
+// RegisterWarmPool lets workers of DefaultRegistry maintain pool while running.
+func RegisterWarmPool[T any](pool *WarmPool[T]) {
+	RegisterWarmPoolTo(defaultRegistry, pool)
+}
+
+// RegisterWarmPoolTo lets workers of r maintain pool while running.
+func RegisterWarmPoolTo[T any](r *Registry, pool *WarmPool[T]) {
+	r.warmPools = append(r.warmPools, pool)
+}
+
+// RunWarmPools maintains registered warm pools until ctx is done.
+func (ge *Manager) RunWarmPools(ctx context.Context) {
+	for _, p := range ge.registry().warmPools {
+		go p.Maintain(ctx)
+	}
+}
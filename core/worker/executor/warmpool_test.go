@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+type countProvisioner struct {
+	mu        sync.Mutex
+	next      int
+	destroyed []int
+}
+
+func (c *countProvisioner) Provision(context.Context) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.next++
+	return c.next, nil
+}
+
+func (c *countProvisioner) Destroy(instance int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.destroyed = append(c.destroyed, instance)
+	return nil
+}
+
+func TestWarmPool(t *testing.T) {
+	fc := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	prov := &countProvisioner{}
+	pool := NewWarmPool[int]("test", prov,
+		WithPoolSize(2, 3),
+		WithIdleTimeout(time.Minute),
+		WithPoolClock(fc),
+	)
+	ctx := context.Background()
+
+	t.Run("补足最小空闲实例", func(t *testing.T) {
+		pool.fill(ctx)
+		if pool.Idle() != 2 {
+			t.Fatalf("期望 2 个空闲实例, 得到 %d", pool.Idle())
+		}
+	})
+
+	t.Run("优先领取预热实例", func(t *testing.T) {
+		inst, err := pool.Claim(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inst != 2 || pool.Idle() != 1 {
+			t.Fatalf("期望领取 2 剩余 1, 得到 %d 剩余 %d", inst, pool.Idle())
+		}
+	})
+
+	t.Run("超出最大空闲数的实例被销毁", func(t *testing.T) {
+		for _, inst := range []int{10, 11, 12} {
+			pool.Release(inst)
+		}
+		if pool.Idle() != 3 || len(prov.destroyed) != 1 || prov.destroyed[0] != 12 {
+			t.Fatalf("空闲 %d, 销毁 %v", pool.Idle(), prov.destroyed)
+		}
+	})
+
+	t.Run("回收过期实例并保留最小空闲数", func(t *testing.T) {
+		fc.Step(2 * time.Minute)
+		pool.reap()
+		if pool.Idle() != 2 {
+			t.Fatalf("期望保留 2 个空闲实例, 得到 %d", pool.Idle())
+		}
+		if len(prov.destroyed) != 2 || prov.destroyed[1] != 1 {
+			t.Fatalf("期望回收最旧的实例 1, 销毁 %v", prov.destroyed)
+		}
+	})
+
+	t.Run("退出时销毁所有空闲实例", func(t *testing.T) {
+		pool.drain()
+		if pool.Idle() != 0 || len(prov.destroyed) != 4 {
+			t.Fatalf("空闲 %d, 销毁 %v", pool.Idle(), prov.destroyed)
+		}
+	})
+}
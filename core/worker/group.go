@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xyzbit/minitaskx/core/components/auth"
+)
+
+// Group runs several workers embedded in one process, eg. workers of
+// different labels and executor registries consolidating small workloads.
+// workers share repos and their connections passed to NewWorker, metrics of
+// them are created by the global metrics provider with label worker_id, and
+// served together by RegisterMetrics.
+type Group struct {
+	workers []*Worker
+}
+
+// NewGroup returns error if ids of workers are empty or duplicated, since
+// routes and health of workers are keyed by id.
+func NewGroup(workers ...*Worker) (*Group, error) {
+	ids := make(map[string]bool, len(workers))
+	for _, w := range workers {
+		if w.id == "" || ids[w.id] {
+			return nil, fmt.Errorf("worker id %q of group must be unique and non-empty", w.id)
+		}
+		ids[w.id] = true
+	}
+	return &Group{workers: workers}, nil
+}
+
+// Run runs all workers until ctx is done, errors of workers are joined.
+func (g *Group) Run(ctx context.Context) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, w := range g.workers {
+		wg.Add(1)
+		go func(w *Worker) {
+			defer wg.Done()
+			if err := w.Run(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("worker %s: %w", w.id, err))
+				mu.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// GroupHealth is healthy only if all workers are.
+type GroupHealth struct {
+	Healthy bool              `json:"healthy"`
+	Workers map[string]Health `json:"workers"`
+}
+
+func (g *Group) Health() GroupHealth {
+	h := GroupHealth{Healthy: true, Workers: make(map[string]Health, len(g.workers))}
+	for _, w := range g.workers {
+		wh := w.Health()
+		h.Workers[w.id] = wh
+		h.Healthy = h.Healthy && wh.Healthy
+	}
+	return h
+}
+
+// RegisterRoutes register apis of each worker under /workers/<id>,
+// and the combined health of workers.
+// if authenticator is nil, apis are open to anyone can reach the port.
+func (g *Group) RegisterRoutes(r gin.IRouter, authenticator auth.Authenticator) {
+	for _, w := range g.workers {
+		NewHttpServer(w).RegisterRoutes(r.Group("/workers/"+w.id), authenticator)
+	}
+
+	// probed by orchestrators, no need to authenticate.
+	r.GET("/healthz", g.health)
+}
+
+// RegisterMetrics serves metrics of all workers by h at /metrics, eg. the
+// handler of the provider set by metrics.SetProvider, which tells workers
+// apart by label worker_id. scraped like /healthz, no need to authenticate.
+func (g *Group) RegisterMetrics(r gin.IRouter, h http.Handler) {
+	r.GET("/metrics", gin.WrapH(h))
+}
+
+// health 查询所有 worker 的健康状态, 任一不健康时返回 503
+func (g *Group) health(c *gin.Context) {
+	h := g.Health()
+	status := http.StatusOK
+	if !h.Healthy {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, h)
+}
@@ -0,0 +1,125 @@
+package worker
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/core/worker/executor"
+	"github.com/xyzbit/minitaskx/core/worker/executor/testexec"
+)
+
+// labelRecorder records label values of metrics by metric name.
+type labelRecorder struct {
+	mu     sync.Mutex
+	values map[string][][]string
+}
+
+func (r *labelRecorder) NewCounter(name, _ string, _ ...string) metrics.Counter {
+	return recordedMetric{r: r, name: name}
+}
+
+func (r *labelRecorder) NewGauge(name, _ string, _ ...string) metrics.Gauge {
+	return recordedMetric{r: r, name: name}
+}
+
+func (r *labelRecorder) NewHistogram(name, _ string, _ ...string) metrics.Histogram {
+	return recordedMetric{r: r, name: name}
+}
+
+type recordedMetric struct {
+	r    *labelRecorder
+	name string
+}
+
+func (m recordedMetric) Add(_ float64, labelValues ...string)     { m.record(labelValues) }
+func (m recordedMetric) Set(_ float64, labelValues ...string)     { m.record(labelValues) }
+func (m recordedMetric) Observe(_ float64, labelValues ...string) { m.record(labelValues) }
+
+func (m recordedMetric) record(labelValues []string) {
+	m.r.mu.Lock()
+	defer m.r.mu.Unlock()
+	m.r.values[m.name] = append(m.r.values[m.name], labelValues)
+}
+
+func TestGroup(t *testing.T) {
+	sleeps := executor.NewRegistry()
+	sleeps.RegisterExecutor(testexec.TypeSleep, testexec.New(testexec.TypeSleep))
+	noops := executor.NewRegistry()
+	noops.RegisterExecutor(testexec.TypeNoop, testexec.New(testexec.TypeNoop))
+	w1 := NewWorker("w1", "127.0.0.1", 8080, nil, nil, WithExecutorRegistry(sleeps))
+	w2 := NewWorker("w2", "127.0.0.1", 8081, nil, nil, WithExecutorRegistry(noops))
+
+	t.Run("worker id 不能重复", func(t *testing.T) {
+		if _, err := NewGroup(w1, NewWorker("w1", "127.0.0.1", 8082, nil, nil)); err == nil {
+			t.Fatal("期望返回错误")
+		}
+	})
+
+	t.Run("各 worker 只上报自己的执行器", func(t *testing.T) {
+		if got := w1.generateWorkerDesc()[model.ExecutorTypesKey]; got != testexec.TypeSleep {
+			t.Fatalf("期望 %s, 得到 %s", testexec.TypeSleep, got)
+		}
+		if got := w2.generateWorkerDesc()[model.ExecutorTypesKey]; got != testexec.TypeNoop {
+			t.Fatalf("期望 %s, 得到 %s", testexec.TypeNoop, got)
+		}
+	})
+
+	t.Run("合并的健康检查", func(t *testing.T) {
+		g, err := NewGroup(w1, w2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := gin.New()
+		g.RegisterRoutes(r, nil)
+		for _, path := range []string{"/healthz", "/workers/w1/healthz"} {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s 期望 200, 得到 %d: %s", path, rec.Code, rec.Body)
+			}
+		}
+		if h := g.Health(); !h.Healthy || len(h.Workers) != 2 {
+			t.Fatalf("期望两个 worker 都健康, 得到 %+v", h)
+		}
+	})
+
+	t.Run("合并的指标按 worker_id 区分", func(t *testing.T) {
+		prev := metrics.Global()
+		defer metrics.SetProvider(prev)
+		recorder := &labelRecorder{values: make(map[string][][]string)}
+		metrics.SetProvider(recorder)
+		w1 := NewWorker("w1", "127.0.0.1", 8080, nil, nil, WithStartTimeout(time.Second))
+		w2 := NewWorker("w2", "127.0.0.1", 8081, nil, nil, WithStartTimeout(time.Second))
+		w1.startTimeouts.Add(1, testexec.TypeSleep)
+		w2.startTimeouts.Add(1, testexec.TypeSleep)
+		got := recorder.values["minitaskx_executor_start_timeouts_total"]
+		want := [][]string{{"w1", testexec.TypeSleep}, {"w2", testexec.TypeSleep}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("期望 %v, 得到 %v", want, got)
+		}
+
+		g, err := NewGroup(w1, w2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := gin.New()
+		g.RegisterMetrics(r, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			io.WriteString(w, "minitaskx_executor_start_timeouts_total{worker_id=\"w1\"} 1")
+		}))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "worker_id") {
+			t.Fatalf("期望返回指标, 得到 %d: %s", rec.Code, rec.Body)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package infomer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// autoFinished reports whether pair is filtered since the task finished on
+// either side and the system will change the other side, eg. the finish
+// reported by the executor is being recorded. pairs of only one side
+// finished are events, once they outlive the auto finish grace the finish
+// of the executor is reported again, or the executor of the task finished
+// by others exits as if the task were removed.
+func (i *Infomer) autoFinished(pair *TaskPair, now time.Time) bool {
+	return i.filterAutoFinished(pair, now, false)
+}
+
+// filterAutoFinished is autoFinished, dryRun decides the same way without
+// starting grace, emitting events or reporting again.
+func (i *Infomer) filterAutoFinished(pair *TaskPair, now time.Time, dryRun bool) bool {
+	want, real := pair.Want, pair.Real
+	wantFinished := want != nil && want.Status.IsFinalStatus()
+	realFinished := real != nil && real.Status.IsFinalStatus()
+	if real == nil || wantFinished == realFinished || (realFinished && want == nil) {
+		if real != nil && !dryRun {
+			i.autoFinishedAt.Delete(real.TaskKey)
+		}
+		return wantFinished || realFinished
+	}
+
+	msg := fmt.Sprintf("want status %s, real status %s", want.Status, real.Status)
+	since := now
+	if dryRun {
+		if v, ok := i.autoFinishedAt.Load(real.TaskKey); ok {
+			since = v.(time.Time)
+		}
+	} else {
+		v, _ := i.autoFinishedAt.LoadOrStore(real.TaskKey, now)
+		since = v.(time.Time)
+	}
+	if grace := i.opts.autoFinishGrace; grace <= 0 || now.Sub(since) < grace {
+		if !dryRun {
+			i.emit(events.Event{TaskKey: real.TaskKey, Type: events.TypeNormal, Reason: events.ReasonAutoFinished, Message: "left to the system, " + msg})
+		}
+		return true
+	}
+	if dryRun {
+		if realFinished {
+			return true
+		}
+		pair.Want = nil
+		return false
+	}
+
+	i.autoFinishedAt.Delete(real.TaskKey)
+	if realFinished {
+		i.logger.Warn("[Infomer] finish of task[%s] is not recorded in %s, report again, %s", real.TaskKey, i.opts.autoFinishGrace, msg)
+		i.emit(events.Event{TaskKey: real.TaskKey, Type: events.TypeWarning, Reason: events.ReasonAutoFinished, Message: "finish not recorded, report again, " + msg})
+		if reals := i.dedupFinishes(i.fenceReports([]*model.Task{real})); len(reals) > 0 && i.updateTask(i.changedTask(real)) {
+			i.ledger.record(real)
+		}
+		return true
+	}
+	i.logger.Warn("[Infomer] task[%s] finished %s ago, exit its executor, %s", real.TaskKey, i.opts.autoFinishGrace, msg)
+	i.emit(events.Event{TaskKey: real.TaskKey, Type: events.TypeWarning, Reason: events.ReasonAutoFinished, Message: "task finished, exit executor, " + msg})
+	pair.Want = nil
+	return false
+}
+
+func (i *Infomer) emit(e events.Event) {
+	if i.opts.emit != nil {
+		i.opts.emit(e)
+	}
+}
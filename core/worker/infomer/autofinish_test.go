@@ -0,0 +1,69 @@
+package infomer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+func TestAutoFinished(t *testing.T) {
+	c := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var emitted []events.Event
+	i := New(NewIndexer(&benchLoader{}, time.Minute, WithClock(c)), &benchRecorder{tasks: map[string]*model.Task{}}, log.Global(),
+		WithClock(c),
+		WithAutoFinishGrace(time.Minute),
+		WithEventEmitter(func(e events.Event) { emitted = append(emitted, e) }),
+	)
+	running := func(key string) *model.Task {
+		return &model.Task{TaskKey: key, Status: model.TaskStatusRunning, WantRunStatus: model.TaskStatusRunning}
+	}
+	finished := func(key string) *model.Task {
+		return &model.Task{TaskKey: key, Status: model.TaskStatusSuccess, WantRunStatus: model.TaskStatusRunning}
+	}
+
+	t.Run("双方都结束时过滤且不记录事件", func(t *testing.T) {
+		emitted = nil
+		if !i.autoFinished(&TaskPair{Want: finished("a"), Real: finished("a")}, c.Now()) {
+			t.Error("应过滤")
+		}
+		if i.autoFinished(&TaskPair{Want: running("a"), Real: running("a")}, c.Now()) {
+			t.Error("运行中不应过滤")
+		}
+		if len(emitted) != 0 {
+			t.Errorf("不应记录事件: %+v", emitted)
+		}
+	})
+
+	t.Run("执行器结束超时后重新上报", func(t *testing.T) {
+		emitted = nil
+		pair := &TaskPair{Want: running("b"), Real: finished("b")}
+		if !i.autoFinished(pair, c.Now()) || len(emitted) != 1 || emitted[0].Type != events.TypeNormal {
+			t.Fatalf("宽限期内应过滤并记录事件: %+v", emitted)
+		}
+		c.Step(time.Minute)
+		if !i.autoFinished(pair, c.Now()) || len(emitted) != 2 || emitted[1].Type != events.TypeWarning {
+			t.Fatalf("超时后应重新上报: %+v", emitted)
+		}
+		if !i.ledger.finished(pair.Real) {
+			t.Error("重新上报后应记录结束")
+		}
+	})
+
+	t.Run("任务结束超时后退出执行器", func(t *testing.T) {
+		pair := &TaskPair{Want: finished("c"), Real: running("c")}
+		if !i.autoFinished(pair, c.Now()) {
+			t.Fatal("宽限期内应过滤")
+		}
+		c.Step(time.Minute)
+		if i.autoFinished(pair, c.Now()) || pair.Want != nil {
+			t.Fatal("超时后应退出执行器")
+		}
+		if changes := DefaultDiffer.Diff([]TaskPair{*pair}); len(changes) != 1 || changes[0].ChangeType != model.ChangeDelete {
+			t.Errorf("应删除执行器: %+v", changes)
+		}
+	})
+}
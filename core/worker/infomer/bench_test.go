@@ -0,0 +1,122 @@
+package infomer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+type benchRecorder struct {
+	tasks map[string]*model.Task
+}
+
+func (r *benchRecorder) UpdateTask(context.Context, *model.Task) error { return nil }
+
+func (r *benchRecorder) BatchUpdateTasks(context.Context, []*model.Task) error { return nil }
+
+func (r *benchRecorder) BatchGetTask(_ context.Context, keys []string) ([]*model.Task, error) {
+	ret := make([]*model.Task, 0, len(keys))
+	for _, key := range keys {
+		if t, ok := r.tasks[key]; ok {
+			ret = append(ret, t)
+		}
+	}
+	return ret, nil
+}
+
+func (r *benchRecorder) ListRunnableTasks(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+func (r *benchRecorder) WatchRunnableTasks(context.Context, string) (<-chan []string, error) {
+	return nil, nil
+}
+
+type benchLoader struct {
+	tasks []*model.Task
+}
+
+func (l *benchLoader) List(context.Context) ([]*model.Task, error) { return l.tasks, nil }
+func (l *benchLoader) ChangeResult() <-chan *model.Task            { return nil }
+
+// newBenchInfomer builds n want tasks, every 4th of them has no real task
+// (create), every 4th real task is paused while want running (resume),
+// the rest are in sync.
+func newBenchInfomer(n int) (*Infomer, *benchLoader, []string) {
+	recorder := &benchRecorder{tasks: make(map[string]*model.Task, n)}
+	loader := &benchLoader{tasks: make([]*model.Task, 0, n)}
+	keys := make([]string, n)
+	for i := 0; i < n; i++ {
+		key := "task-" + strconv.Itoa(i)
+		keys[i] = key
+		recorder.tasks[key] = &model.Task{
+			TaskKey:       key,
+			Type:          "bench",
+			Status:        model.TaskStatusRunning,
+			WantRunStatus: model.TaskStatusRunning,
+		}
+		switch i % 4 {
+		case 0:
+		case 1:
+			loader.tasks = append(loader.tasks, &model.Task{TaskKey: key, Type: "bench", Status: model.TaskStatusPaused})
+		default:
+			loader.tasks = append(loader.tasks, &model.Task{TaskKey: key, Type: "bench", Status: model.TaskStatusRunning})
+		}
+	}
+
+	c := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	indexer := NewIndexer(loader, time.Minute, WithClock(c))
+	return New(indexer, recorder, log.Global(), WithClock(c)), loader, keys
+}
+
+func BenchmarkLoadTaskPairsAndDiff(b *testing.B) {
+	for _, n := range []int{10_000, 100_000} {
+		i, _, keys := newBenchInfomer(n)
+
+		b.Run(fmt.Sprintf("tasks=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for j := 0; j < b.N; j++ {
+				pairs, err := i.loadTaskPairs(context.Background(), keys, keys)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if changes := DefaultDiffer.Diff(pairs); len(changes) != n/2 {
+					b.Fatalf("expect %d changes, got %d", n/2, len(changes))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkIndexerRefreshCache(b *testing.B) {
+	for _, n := range []int{10_000, 100_000} {
+		i, loader, _ := newBenchInfomer(n)
+		// half of real tasks changed since cache initialized.
+		changed := make([]*model.Task, len(loader.tasks))
+		for j, t := range loader.tasks {
+			c := *t
+			if j%2 == 0 {
+				c.Status = model.TaskStatusStop
+			}
+			changed[j] = &c
+		}
+		loader.tasks = changed
+
+		b.Run(fmt.Sprintf("tasks=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			ch := make(chan *model.Task, len(changed))
+			for j := 0; j < b.N; j++ {
+				i.indexer.refreshCache(context.Background(), ch)
+				for len(ch) > 0 {
+					<-ch
+				}
+			}
+		})
+	}
+}
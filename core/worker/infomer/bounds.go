@@ -0,0 +1,64 @@
+package infomer
+
+import (
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/internal/queue"
+)
+
+// Overflow decides what happens to a change once a bound of the change queue is reached.
+type Overflow string
+
+const (
+	// OverflowBlock stops diffing until executors take waiting changes, default.
+	OverflowBlock Overflow = "block"
+	// OverflowDropOldest drops the oldest waiting change of the full bound,
+	// dropped changes are found again by the next resync.
+	OverflowDropOldest Overflow = "drop_oldest"
+	// OverflowReject rejects the new change and alerts by error log and
+	// minitaskx_change_queue_overflow_total, it is found again by the next resync.
+	OverflowReject Overflow = "reject"
+)
+
+// ChangeQueueBounds limits changes waiting for executors, so that a
+// misbehaving watch can't balloon memory of the worker.
+type ChangeQueueBounds struct {
+	// max changes waiting, 0 means unbounded.
+	MaxChanges int
+	// max changes waiting per task type, types absent are unbounded.
+	MaxPerTaskType map[string]int
+	Overflow       Overflow
+}
+
+func newChangeQueue(b *ChangeQueueBounds, logger log.Logger, p metrics.Provider) queue.TypedInterface[model.Change] {
+	if b == nil {
+		return queue.NewTyped[model.Change]()
+	}
+
+	overflows := p.NewCounter("minitaskx_change_queue_overflow_total", "changes dropped or rejected by bounds of change queue", "type", "policy")
+	policy := queue.OverflowBlock
+	switch b.Overflow {
+	case OverflowDropOldest:
+		policy = queue.OverflowDropOldest
+	case OverflowReject:
+		policy = queue.OverflowReject
+	}
+	bounds := &queue.Bounds[model.Change]{
+		MaxLen:      b.MaxChanges,
+		MaxPerGroup: b.MaxPerTaskType,
+		Overflow:    policy,
+		OnOverflow: func(c model.Change) {
+			overflows.Add(1, c.TaskType, string(b.Overflow))
+			if b.Overflow == OverflowReject {
+				logger.Error("[Infomer] change queue is full, reject change: %v", c)
+				return
+			}
+			logger.Warn("[Infomer] change queue is full, drop change: %v", c)
+		},
+	}
+	if len(b.MaxPerTaskType) > 0 {
+		bounds.Group = func(c model.Change) string { return c.TaskType }
+	}
+	return queue.NewTypedWithConfig(queue.TypedQueueConfig[model.Change]{Bounds: bounds})
+}
@@ -8,6 +8,9 @@ type ChangeConsumer interface {
 	// Pop get a change from queue.
 	// if has no change, fuction will be blocked.
 	WaitChange() (item model.Change, shutdown bool)
+	// GetBatch waits until at least one change is available, then returns up to max changes.
+	// changes in a batch belong to different tasks, each of them must be finished separately.
+	GetBatch(max int) (items []model.Change, shutdown bool)
 	JumpChange(item model.Change)
 }
 
@@ -19,6 +22,10 @@ func (cc *changeConsumer) WaitChange() (item model.Change, shutdown bool) {
 	return cc.i.changeQueue.Get()
 }
 
+func (cc *changeConsumer) GetBatch(max int) (items []model.Change, shutdown bool) {
+	return cc.i.changeQueue.GetBatch(max)
+}
+
 func (cc *changeConsumer) JumpChange(item model.Change) {
 	cc.i.changeQueue.Done(item)
 }
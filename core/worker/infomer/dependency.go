@@ -14,9 +14,18 @@ type realTaskLoader interface {
 
 type recorder interface {
 	UpdateTask(ctx context.Context, task *model.Task) error
+	// updates tasks in order in one transaction.
+	BatchUpdateTasks(ctx context.Context, tasks []*model.Task) error
 	BatchGetTask(ctx context.Context, taskKeys []string) ([]*model.Task, error)
 	// returns all runnable tasks of the current worker.
 	ListRunnableTasks(ctx context.Context, workerID string) (keys []string, err error)
 	// watch all runnable tasks change.
 	WatchRunnableTasks(ctx context.Context, workerID string) (keys <-chan []string, err error)
 }
+
+// ResyncSlotter staggers full resyncs of a worker fleet sharing one repo,
+// eg. by ranking ids of live workers in a table. worker of slot resyncs
+// at slot/total of each interval.
+type ResyncSlotter interface {
+	ResyncSlot(ctx context.Context, workerID string) (slot, total int, err error)
+}
@@ -0,0 +1,74 @@
+package infomer
+
+import (
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// TaskPair is the want and real task of the same task key, either of them may be nil.
+type TaskPair struct {
+	Want *model.Task
+	Real *model.Task
+}
+
+// Differ translates want/real pairs into changes applied by executors.
+// pairs of in-flight changes and finished tasks are filtered before Diff.
+type Differ interface {
+	Diff(pairs []TaskPair) []model.Change
+}
+
+// DifferFunc adapts a function to Differ.
+type DifferFunc func(pairs []TaskPair) []model.Change
+
+func (f DifferFunc) Diff(pairs []TaskPair) []model.Change {
+	return f(pairs)
+}
+
+// DefaultDiffer produces changes from the difference of want run status and real status.
+var DefaultDiffer Differ = DifferFunc(diff)
+
+func diff(taskPairs []TaskPair) []model.Change {
+	var changes []model.Change
+
+	for _, pair := range taskPairs {
+		var changeTask *model.Task
+		want, real := pair.Want, pair.Real
+		wantStatus, realStatus := model.TaskStatusNotExist, model.TaskStatusNotExist
+		if real != nil {
+			changeTask = real
+			realStatus = real.Status
+		}
+		if want != nil {
+			changeTask = want
+			wantStatus = want.WantRunStatus
+		}
+		log.Debug("[Infomer] diff, want status: %v, real: %v", wantStatus, realStatus)
+
+		if realStatus == wantStatus {
+			// spec of a live executor is changed.
+			if real != nil && want != nil && want.Generation > real.Generation && !realStatus.IsFinalStatus() {
+				changes = append(changes, model.Change{
+					TaskKey:    want.TaskKey,
+					TaskType:   want.Type,
+					ChangeType: model.ChangeUpdate,
+					Task:       want,
+				})
+			}
+			continue
+		}
+
+		changeType, err := model.GetChangeType(realStatus, wantStatus)
+		if err != nil {
+			log.Error("[diff] task key: %s, realStatus: %s, wantStatus: %s, err: %v", changeTask.TaskKey, realStatus, wantStatus, err)
+			continue
+		}
+		changes = append(changes, model.Change{
+			TaskKey:    changeTask.TaskKey,
+			TaskType:   changeTask.Type,
+			ChangeType: changeType,
+			Task:       changeTask,
+		})
+	}
+
+	return changes
+}
@@ -0,0 +1,63 @@
+package infomer
+
+import (
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestDefaultDiffer(t *testing.T) {
+	tests := []struct {
+		name string
+		pair TaskPair
+		want model.ChangeType
+	}{
+		{
+			name: "期望运行且无实际任务, 创建",
+			pair: TaskPair{Want: &model.Task{TaskKey: "a", WantRunStatus: model.TaskStatusRunning}},
+			want: model.ChangeCreate,
+		},
+		{
+			name: "无期望任务但仍在运行, 删除",
+			pair: TaskPair{Real: &model.Task{TaskKey: "a", Status: model.TaskStatusRunning}},
+			want: model.ChangeDelete,
+		},
+		{
+			name: "运行中期望暂停, 暂停",
+			pair: TaskPair{
+				Want: &model.Task{TaskKey: "a", WantRunStatus: model.TaskStatusPaused},
+				Real: &model.Task{TaskKey: "a", Status: model.TaskStatusRunning},
+			},
+			want: model.ChangePause,
+		},
+		{
+			name: "状态一致但 spec 代数落后, 更新",
+			pair: TaskPair{
+				Want: &model.Task{TaskKey: "a", WantRunStatus: model.TaskStatusRunning, Generation: 2},
+				Real: &model.Task{TaskKey: "a", Status: model.TaskStatusRunning, Generation: 1},
+			},
+			want: model.ChangeUpdate,
+		},
+		{
+			name: "状态一致, 无变更",
+			pair: TaskPair{
+				Want: &model.Task{TaskKey: "a", WantRunStatus: model.TaskStatusRunning},
+				Real: &model.Task{TaskKey: "a", Status: model.TaskStatusRunning},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := DefaultDiffer.Diff([]TaskPair{tt.pair})
+			if tt.want == "" {
+				if len(changes) != 0 {
+					t.Errorf("期望无变更, 得到 %v", changes)
+				}
+				return
+			}
+			if len(changes) != 1 || changes[0].ChangeType != tt.want {
+				t.Errorf("期望 %s, 得到 %v", tt.want, changes)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package infomer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/internal/queue"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+// timedQueue observes the time from a change is enqueued to it is marked done,
+// ie. waiting for and being applied by an executor, per change type and task type.
+type timedQueue struct {
+	queue.TypedInterface[model.Change]
+
+	clock clock.Clock
+	// task key <==> enqueued change, changes dropped by bounds are
+	// kept until the task is enqueued again.
+	enqueued sync.Map
+	duration metrics.Histogram
+}
+
+type enqueuedChange struct {
+	changeType model.ChangeType
+	taskType   string
+	at         time.Time
+}
+
+func newTimedQueue(q queue.TypedInterface[model.Change], c clock.Clock, p metrics.Provider) *timedQueue {
+	return &timedQueue{
+		TypedInterface: q,
+		clock:          c,
+		duration:       p.NewHistogram("minitaskx_change_duration_seconds", "time from change enqueued to done", "change", "type"),
+	}
+}
+
+func (q *timedQueue) Add(c model.Change) (exist bool) {
+	now := q.clock.Now()
+	if exist = q.TypedInterface.Add(c); !exist {
+		q.enqueued.Store(c.TaskKey, enqueuedChange{changeType: c.ChangeType, taskType: c.TaskType, at: now})
+	}
+	return exist
+}
+
+func (q *timedQueue) Done(c model.Change) {
+	q.TypedInterface.Done(c)
+	if v, ok := q.enqueued.LoadAndDelete(c.TaskKey); ok {
+		e := v.(enqueuedChange)
+		q.duration.Observe(q.clock.Since(e.at).Seconds(), string(e.changeType), e.taskType)
+	}
+}
@@ -0,0 +1,47 @@
+package infomer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/internal/queue"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+type observation struct {
+	v      float64
+	labels []string
+}
+
+type fakeHistogram struct{ observed []observation }
+
+func (h *fakeHistogram) Observe(v float64, labels ...string) {
+	h.observed = append(h.observed, observation{v: v, labels: labels})
+}
+
+func TestTimedQueue(t *testing.T) {
+	c := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	h := &fakeHistogram{}
+	q := newTimedQueue(queue.NewTyped[model.Change](), c, metrics.Global())
+	q.duration = h
+
+	q.Add(model.Change{TaskKey: "a", TaskType: "x", ChangeType: model.ChangeStop})
+	c.Step(3 * time.Second)
+	// repeated additions do not restart timing.
+	q.Add(model.Change{TaskKey: "a", TaskType: "x", ChangeType: model.ChangeStop})
+	item, _ := q.Get()
+	c.Step(2 * time.Second)
+	q.Done(item)
+	// only done once.
+	q.Done(item)
+
+	if len(h.observed) != 1 {
+		t.Fatalf("应记录一次: %+v", h.observed)
+	}
+	o := h.observed[0]
+	if o.v != 5 || o.labels[0] != string(model.ChangeStop) || o.labels[1] != "x" {
+		t.Errorf("记录错误: %+v", o)
+	}
+}
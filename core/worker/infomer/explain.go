@@ -0,0 +1,110 @@
+package infomer
+
+import (
+	"context"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// Decision is the result of reconciling one task.
+type Decision string
+
+const (
+	DecisionNotFound        Decision = "not_found"        // neither want nor real task exists.
+	DecisionNotAssigned     Decision = "not_assigned"     // want task is assigned to another worker.
+	DecisionInFlight        Decision = "in_flight"        // blocked by an in-flight change of the task.
+	DecisionAutoFinished    Decision = "auto_finished"    // filtered, task has finished by itself.
+	DecisionWaiting         Decision = "waiting"          // filtered, retried task in backoff or parked task.
+	DecisionInSync          Decision = "in_sync"          // want status equals to real status.
+	DecisionChange          Decision = "change"           // a change will be produced.
+	DecisionUnsupported     Decision = "unsupported"      // status transition is not supported.
+	DecisionExceptionChange Decision = "exception_change" // change is an exception, task will be paused.
+)
+
+// Explanation describes why infomer does or does not produce a change for a task.
+type Explanation struct {
+	TaskKey        string           `json:"task_key"`
+	WorkerID       string           `json:"worker_id"`
+	WantWorkerID   string           `json:"want_worker_id,omitempty"`
+	WantStatus     model.TaskStatus `json:"want_status"`
+	WantRunStatus  model.TaskStatus `json:"want_run_status"`
+	RealStatus     model.TaskStatus `json:"real_status"`
+	InFlightChange bool             `json:"in_flight_change"`
+	AutoFinished   bool             `json:"auto_finished"`
+	// executor of an older epoch, it exits as if the task were removed.
+	Fenced         bool             `json:"fenced,omitempty"`
+	StaleHeartbeat bool             `json:"stale_heartbeat,omitempty"`
+	ChangeType     model.ChangeType `json:"change_type,omitempty"`
+	Decision       Decision         `json:"decision"`
+	Reason         string           `json:"reason,omitempty"`
+}
+
+// Explain computes the diff decision of taskKey the same way as reconciling does,
+// without producing any change.
+func (i *Infomer) Explain(ctx context.Context, workerID, taskKey string) (*Explanation, error) {
+	e := &Explanation{
+		TaskKey:       taskKey,
+		WorkerID:      workerID,
+		WantStatus:    model.TaskStatusNotExist,
+		WantRunStatus: model.TaskStatusNotExist,
+		RealStatus:    model.TaskStatusNotExist,
+	}
+
+	wants, err := i.recorder.BatchGetTask(ctx, []string{taskKey})
+	if err != nil {
+		return nil, err
+	}
+	var want *model.Task
+	if len(wants) > 0 {
+		want = wants[0]
+		e.WantWorkerID = want.WorkerID
+		e.WantStatus = want.Status
+		e.WantRunStatus = want.WantRunStatus
+	}
+	var real *model.Task
+	if reals := i.indexer.ListTasks([]string{taskKey}); len(reals) > 0 {
+		real = reals[0]
+		e.RealStatus = real.Status
+		e.StaleHeartbeat = i.indexer.IsStale(taskKey)
+	}
+	e.InFlightChange = i.changeQueue.Exist(model.Change{TaskKey: taskKey})
+
+	switch {
+	case want == nil && real == nil:
+		e.Decision = DecisionNotFound
+		return e, nil
+	case want != nil && real == nil && want.WorkerID != workerID:
+		e.Decision = DecisionNotAssigned
+		return e, nil
+	case e.InFlightChange:
+		e.Decision = DecisionInFlight
+		return e, nil
+	}
+
+	pair := TaskPair{Want: want, Real: real}
+	e.Fenced = fenced(want, real)
+	if decision, reason := i.filterPair(&pair, i.opts.clock.Now(), true); decision != "" {
+		e.AutoFinished = decision == DecisionAutoFinished
+		e.Decision, e.Reason = decision, reason
+		return e, nil
+	}
+
+	changes := i.opts.differ.Diff([]TaskPair{pair})
+	if len(changes) == 0 {
+		e.Decision = DecisionInSync
+		// default differ drops unsupported transitions.
+		if e.RealStatus != e.WantRunStatus {
+			if _, err := model.GetChangeType(e.RealStatus, e.WantRunStatus); err != nil {
+				e.Decision = DecisionUnsupported
+				e.Reason = err.Error()
+			}
+		}
+		return e, nil
+	}
+	e.ChangeType = changes[0].ChangeType
+	e.Decision = DecisionChange
+	if changes[0].IsException() {
+		e.Decision = DecisionExceptionChange
+	}
+	return e, nil
+}
@@ -0,0 +1,65 @@
+package infomer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+func TestExplain(t *testing.T) {
+	c := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	later := c.Now().Add(time.Minute)
+	recorder := &benchRecorder{tasks: map[string]*model.Task{
+		"fenced":   {TaskKey: "fenced", Type: "x", WorkerID: "w1", Status: model.TaskStatusRunning, WantRunStatus: model.TaskStatusRunning, Epoch: 2},
+		"finished": {TaskKey: "finished", Type: "x", WorkerID: "w1", Status: model.TaskStatusRunning, WantRunStatus: model.TaskStatusRunning},
+		"backoff":  {TaskKey: "backoff", Type: "x", WorkerID: "w1", Status: model.TaskStatusWaitRunning, WantRunStatus: model.TaskStatusRunning, NextRunAt: &later},
+	}}
+	loader := &benchLoader{tasks: []*model.Task{
+		{TaskKey: "fenced", Type: "x", Status: model.TaskStatusRunning, Epoch: 1},
+		{TaskKey: "finished", Type: "x", Status: model.TaskStatusSuccess},
+	}}
+	var emitted []events.Event
+	i := New(NewIndexer(loader, time.Minute, WithClock(c)), recorder, log.Global(),
+		WithClock(c),
+		WithAutoFinishGrace(time.Minute),
+		WithEventEmitter(func(e events.Event) { emitted = append(emitted, e) }),
+	)
+	explain := func(key string) *Explanation {
+		t.Helper()
+		e, err := i.Explain(context.Background(), "w1", key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+
+	t.Run("与调和一致地退出旧 epoch 的执行器", func(t *testing.T) {
+		if e := explain("fenced"); !e.Fenced || e.Decision != DecisionChange || e.ChangeType != model.ChangeDelete {
+			t.Errorf("期望删除旧 epoch 的执行器, 得到 %+v", e)
+		}
+	})
+
+	t.Run("自动结束不产生副作用", func(t *testing.T) {
+		if e := explain("finished"); !e.AutoFinished || e.Decision != DecisionAutoFinished {
+			t.Errorf("期望自动结束, 得到 %+v", e)
+		}
+		if _, ok := i.autoFinishedAt.Load("finished"); ok || len(emitted) != 0 {
+			t.Errorf("解释不应开始宽限期或记录事件: %+v", emitted)
+		}
+	})
+
+	t.Run("退避中的任务等待", func(t *testing.T) {
+		if e := explain("backoff"); e.Decision != DecisionWaiting || e.Reason == "" {
+			t.Errorf("期望等待退避, 得到 %+v", e)
+		}
+		pairs, err := i.loadTaskPairsThreadSafe(context.Background(), triggerInfo{taskKeys: []string{"backoff"}})
+		if err != nil || len(pairs) != 0 {
+			t.Errorf("调和也应跳过, 得到 %+v %v", pairs, err)
+		}
+	})
+}
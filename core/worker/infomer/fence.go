@@ -0,0 +1,50 @@
+package infomer
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// fenced reports whether real is run by an executor of an older lease epoch
+// than want, ie. the task is reassigned while this worker was believed dead.
+// real tasks not carrying epoch are never fenced.
+func fenced(want, real *model.Task) bool {
+	return want != nil && real != nil && real.Epoch != 0 && real.Epoch < want.Epoch
+}
+
+// fenceReports drops reports of executors of older epochs than their want tasks,
+// so that a resurrected worker never overwrites status reported by the new owner.
+// changes of dropped reports are marked done.
+func (i *Infomer) fenceReports(reals []*model.Task) []*model.Task {
+	keys := make([]string, 0, len(reals))
+	for _, real := range reals {
+		if real.Epoch != 0 {
+			keys = append(keys, real.TaskKey)
+		}
+	}
+	if len(keys) == 0 {
+		return reals
+	}
+	wants, err := i.recorder.BatchGetTask(context.Background(), keys)
+	if err != nil {
+		// better report twice than lose the report of the owner.
+		i.logger.Error("[Infomer] fence reports, BatchGetTask failed: %v", err)
+		return reals
+	}
+	wantMap := lo.KeyBy(wants, func(t *model.Task) string { return t.TaskKey })
+
+	ret := reals[:0:0]
+	for _, real := range reals {
+		want := wantMap[real.TaskKey]
+		if !fenced(want, real) {
+			ret = append(ret, real)
+			continue
+		}
+		i.logger.Warn("[Infomer] drop report of task[%s] status %s, epoch %d is older than %d", real.TaskKey, real.Status, real.Epoch, want.Epoch)
+		i.changeQueue.Done(model.Change{TaskKey: real.TaskKey})
+	}
+	return ret
+}
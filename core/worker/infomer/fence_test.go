@@ -0,0 +1,74 @@
+package infomer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+func TestFence(t *testing.T) {
+	want := &model.Task{TaskKey: "t", Type: "x", Status: model.TaskStatusRunning, WantRunStatus: model.TaskStatusRunning, Epoch: 2}
+	stale := &model.Task{TaskKey: "t", Type: "x", Status: model.TaskStatusRunning, Epoch: 1}
+	recorder := &benchRecorder{tasks: map[string]*model.Task{"t": want}}
+	loader := &benchLoader{tasks: []*model.Task{stale}}
+	c := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	i := New(NewIndexer(loader, time.Minute, WithClock(c)), recorder, log.Global(), WithClock(c))
+
+	t.Run("旧 epoch 的执行器退出", func(t *testing.T) {
+		pairs, err := i.loadTaskPairsThreadSafe(context.Background(), triggerInfo{taskKeys: []string{"t"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		changes := DefaultDiffer.Diff(pairs)
+		if len(changes) != 1 || changes[0].ChangeType != model.ChangeDelete {
+			t.Errorf("应退出旧 epoch 的执行器: %+v", changes)
+		}
+	})
+
+	t.Run("丢弃旧 epoch 的状态上报", func(t *testing.T) {
+		current := &model.Task{TaskKey: "t", Status: model.TaskStatusSuccess, Epoch: 2}
+		unknown := &model.Task{TaskKey: "t", Status: model.TaskStatusSuccess}
+		got := i.fenceReports([]*model.Task{stale, current, unknown})
+		if len(got) != 2 || got[0] != current || got[1] != unknown {
+			t.Errorf("上报过滤错误: %+v", got)
+		}
+	})
+
+	t.Run("强制迁移后旧 worker 的执行器退出", func(t *testing.T) {
+		// force reassigned from this worker to w2, see scheduler.ForceReassignTask.
+		recorder.tasks["t"] = &model.Task{TaskKey: "t", Type: "x", WorkerID: "w2", Status: model.TaskStatusWaitRunning, WantRunStatus: model.TaskStatusRunning, Epoch: stale.Epoch + 1}
+		pairs, err := i.loadTaskPairsThreadSafe(context.Background(), triggerInfo{taskKeys: []string{"t"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		changes := DefaultDiffer.Diff(pairs)
+		if len(changes) != 1 || changes[0].ChangeType != model.ChangeDelete {
+			t.Errorf("旧 worker 应退出执行器: %+v", changes)
+		}
+		recorder.tasks["t"] = want
+	})
+
+	t.Run("相同 epoch 不隔离", func(t *testing.T) {
+		if fenced(want, &model.Task{Epoch: 2}) || fenced(nil, stale) {
+			t.Error("不应隔离")
+		}
+	})
+}
+
+func TestForceRelease(t *testing.T) {
+	i := New(NewIndexer(&benchLoader{}, time.Minute), &benchRecorder{}, log.Global())
+	if i.ForceRelease("t") {
+		t.Fatal("没有进行中的变更时应返回 false")
+	}
+	i.changeQueue.Add(model.Change{TaskKey: "t"})
+	if _, shutdown := i.changeQueue.Get(); shutdown {
+		t.Fatal("queue shut down")
+	}
+	if !i.ForceRelease("t") || i.changeQueue.Exist(model.Change{TaskKey: "t"}) {
+		t.Fatal("期望释放进行中的变更")
+	}
+}
@@ -0,0 +1,79 @@
+package infomer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// heartbeatChecker flags running tasks whose executor stops reporting heartbeat,
+// while loader still lists them as running, eg. a hung goroutine or container.
+type heartbeatChecker struct {
+	timeout time.Duration
+
+	mu    sync.Mutex
+	stale map[string]*model.Task
+
+	staleGauge metrics.Gauge
+}
+
+func newHeartbeatChecker(timeout time.Duration, p metrics.Provider) *heartbeatChecker {
+	return &heartbeatChecker{
+		timeout:    timeout,
+		stale:      make(map[string]*model.Task),
+		staleGauge: p.NewGauge("minitaskx_task_stale_heartbeat", "running tasks whose heartbeat is stale"),
+	}
+}
+
+// isStale reports whether heartbeat of real task is older than timeout at now.
+// tasks without heartbeat are never stale, their executor does not support it.
+func (h *heartbeatChecker) isStale(t *model.Task, now time.Time) bool {
+	return t.Status == model.TaskStatusRunning &&
+		t.LastHeartbeat != nil &&
+		now.Sub(*t.LastHeartbeat) > h.timeout
+}
+
+// check replaces stale tasks by scanning reals, only transitions are logged.
+func (h *heartbeatChecker) check(reals []*model.Task, now time.Time) {
+	stale := make(map[string]*model.Task)
+	for _, t := range reals {
+		if h.isStale(t, now) {
+			stale[t.TaskKey] = t
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for key, t := range stale {
+		if _, ok := h.stale[key]; !ok {
+			log.Warn("[Infomer] task[%s] heartbeat is stale, last at %s", key, t.LastHeartbeat.Format(time.RFC3339))
+		}
+	}
+	for key := range h.stale {
+		if _, ok := stale[key]; !ok {
+			log.Info("[Infomer] task[%s] heartbeat recovered or task exited", key)
+		}
+	}
+	h.stale = stale
+	h.staleGauge.Set(float64(len(stale)))
+}
+
+func (h *heartbeatChecker) list() []*model.Task {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ret := make([]*model.Task, 0, len(h.stale))
+	for _, t := range h.stale {
+		ret = append(ret, t)
+	}
+	return ret
+}
+
+func (h *heartbeatChecker) has(taskKey string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.stale[taskKey]
+	return ok
+}
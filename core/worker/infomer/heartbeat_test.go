@@ -0,0 +1,42 @@
+package infomer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestHeartbeatChecker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fresh := now.Add(-10 * time.Second)
+	old := now.Add(-time.Minute)
+	h := newHeartbeatChecker(30*time.Second, metrics.Global())
+
+	reals := []*model.Task{
+		{TaskKey: "fresh", Status: model.TaskStatusRunning, LastHeartbeat: &fresh},
+		{TaskKey: "hung", Status: model.TaskStatusRunning, LastHeartbeat: &old},
+		{TaskKey: "paused", Status: model.TaskStatusPaused, LastHeartbeat: &old},
+		{TaskKey: "unsupported", Status: model.TaskStatusRunning},
+	}
+
+	t.Run("只标记心跳超时的运行中任务", func(t *testing.T) {
+		h.check(reals, now)
+		stale := h.list()
+		if len(stale) != 1 || stale[0].TaskKey != "hung" {
+			t.Fatalf("期望只有 hung, 得到 %v", stale)
+		}
+		if !h.has("hung") || h.has("fresh") {
+			t.Fatal("has 结果不正确")
+		}
+	})
+
+	t.Run("心跳恢复后移除", func(t *testing.T) {
+		reals[1].LastHeartbeat = &fresh
+		h.check(reals, now)
+		if len(h.list()) != 0 {
+			t.Fatalf("期望没有超时任务, 得到 %v", h.list())
+		}
+	})
+}
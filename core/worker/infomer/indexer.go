@@ -2,28 +2,62 @@ package infomer
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
+	"github.com/xyzbit/minitaskx/core/components/crash"
 	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/metrics"
 	"github.com/xyzbit/minitaskx/core/model"
 	"github.com/xyzbit/minitaskx/internal/cache"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
 )
 
+// cache is stale if loader.List kept failing for this many resyncs by default.
+const defaultStaleResyncs = 3
+
 // Indexer will maintain cache of actual executor status
 type Indexer struct {
 	cache       *cache.ThreadSafeMap[*model.Task]
 	loader      realTaskLoader
 	afterChange func(task *model.Task)
 	resync      time.Duration
+	clock       clock.Clock
+	// nil if heartbeat timeout is not set.
+	heartbeat *heartbeatChecker
+	// replaces afterChange if set, handles changes queued together.
+	afterChanges    func(tasks []*model.Task)
+	afterChangeSize int
+	// called with keys of tasks recycled from cache.
+	afterRemove func(taskKey string)
+
+	// unix nano of the last successful loader.List, cache is stale after staleAfter.
+	listedAt   atomic.Int64
+	staleAfter time.Duration
+	staleness  metrics.Gauge
+
+	crash crash.Reporter
 }
 
 func NewIndexer(
 	loader realTaskLoader,
 	resync time.Duration,
+	opts ...Option,
 ) *Indexer {
+	o := newOptions(opts...)
 	i := &Indexer{
-		loader: loader,
-		resync: resync,
+		loader:     loader,
+		resync:     resync,
+		clock:      o.clock,
+		staleAfter: o.staleCacheThreshold,
+		staleness:  o.metrics.NewGauge("minitaskx_indexer_cache_staleness_seconds", "time since real tasks are listed successfully"),
+		crash:      o.crashReporter,
+	}
+	if i.staleAfter <= 0 {
+		i.staleAfter = defaultStaleResyncs * resync
+	}
+	if o.heartbeatTimeout > 0 {
+		i.heartbeat = newHeartbeatChecker(o.heartbeatTimeout, o.metrics)
 	}
 
 	if err := i.initCache(); err != nil {
@@ -36,18 +70,28 @@ func (i *Indexer) SetAfterChange(f func(task *model.Task)) {
 	i.afterChange = f
 }
 
+// SetAfterChanges is like SetAfterChange, but hands over up to size changes
+// queued together at once, so that they can be persisted in one round-trip.
+func (i *Indexer) SetAfterChanges(size int, f func(tasks []*model.Task)) {
+	i.afterChangeSize = size
+	i.afterChanges = f
+}
+
+// SetAfterRemove sets f called when a real task is recycled from cache,
+// so that states kept per real task can be released.
+func (i *Indexer) SetAfterRemove(f func(taskKey string)) {
+	i.afterRemove = f
+}
+
 func (i *Indexer) ListTasks(keys []string) []*model.Task {
-	list := i.cache.List()
 	if len(keys) == 0 {
-		return list
+		return i.cache.List()
 	}
 
 	ret := make([]*model.Task, 0, len(keys))
-	for _, item := range list {
-		for _, key := range keys {
-			if item.TaskKey == key {
-				ret = append(ret, item)
-			}
+	for _, key := range keys {
+		if item, exist := i.cache.Get(key); exist {
+			ret = append(ret, item)
 		}
 	}
 	return ret
@@ -63,24 +107,60 @@ func (i *Indexer) ListTaskKeys() []string {
 	return ret
 }
 
+// StaleTasks returns running tasks whose heartbeat is stale as of the last check.
+func (i *Indexer) StaleTasks() []*model.Task {
+	if i.heartbeat == nil {
+		return nil
+	}
+	return i.heartbeat.list()
+}
+
+// IsStale reports whether heartbeat of taskKey is stale as of the last check.
+func (i *Indexer) IsStale(taskKey string) bool {
+	return i.heartbeat != nil && i.heartbeat.has(taskKey)
+}
+
 // monitor real task status.
 func (i *Indexer) Monitor(ctx context.Context) {
 	ch := make(chan *model.Task, 100)
 
 	// force cache refresh periodically
 	go func() {
-		ticker := time.NewTicker(i.resync)
+		ticker := i.clock.NewTicker(i.resync)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-ticker.C:
-				i.refreshCache(ctx, ch)
+			case <-ticker.C():
+				func() {
+					defer crash.Recover(i.crash, "indexer.resync", "", nil)
+					i.refreshCache(ctx, ch)
+				}()
+				i.staleness.Set(i.CacheStaleness().Seconds())
 			}
 		}
 	}()
+	// flag running tasks without heartbeat
+	if i.heartbeat != nil {
+		go func() {
+			ticker := i.clock.NewTicker(i.heartbeat.timeout / 2)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C():
+					func() {
+						defer crash.Recover(i.crash, "indexer.heartbeat", "", nil)
+						i.checkHeartbeat(ctx)
+					}()
+				}
+			}
+		}()
+	}
 	// watch task's changes of real status
 	go func() {
 		resultChan := i.loader.ChangeResult()
@@ -90,8 +170,26 @@ func (i *Indexer) Monitor(ctx context.Context) {
 	}()
 
 	for change := range ch {
-		i.processTask(change)
+		if i.afterChanges == nil {
+			i.processTask(change)
+			continue
+		}
+		i.processTasks(drainChanges(ch, change, i.afterChangeSize))
+	}
+}
+
+// drainChanges returns first and changes already queued in ch, at most size.
+func drainChanges(ch <-chan *model.Task, first *model.Task, size int) []*model.Task {
+	batch := []*model.Task{first}
+	for len(batch) < size {
+		select {
+		case c := <-ch:
+			batch = append(batch, c)
+		default:
+			return batch
+		}
 	}
+	return batch
 }
 
 func (i *Indexer) initCache() error {
@@ -102,11 +200,14 @@ func (i *Indexer) initCache() error {
 		b := task.Status.IsFinalStatus() && afterSetDuration > time.Minute
 		if b {
 			log.Debug("[Infomer] recycle task: %s", task.TaskKey)
+			if i.afterRemove != nil {
+				i.afterRemove(task.TaskKey)
+			}
 		}
 		return b
 	}
 
-	c := cache.NewThreadSafeMap(recycleCondition)
+	c := cache.NewThreadSafeMapWithClock(recycleCondition, i.clock)
 
 	reals, err := i.loader.List(context.Background())
 	if err != nil {
@@ -117,15 +218,17 @@ func (i *Indexer) initCache() error {
 	}
 
 	i.cache = c
+	i.markListed()
 	return nil
 }
 
 func (i *Indexer) refreshCache(ctx context.Context, ch chan *model.Task) {
 	newTasks, err := i.loader.List(ctx)
 	if err != nil {
-		log.Error("[Infomer] List() failed: %v", err)
+		log.Error("[Infomer] List() failed, cache is stale for %s: %v", i.CacheStaleness(), err)
 		return
 	}
+	i.markListed()
 	for _, new := range newTasks {
 		old, exist := i.cache.Get(new.TaskKey)
 		if !exist || new.Status != old.Status {
@@ -139,6 +242,7 @@ func (i *Indexer) processTask(c *model.Task) {
 		log.Error("[Infomer] received nil task")
 		return
 	}
+	defer crash.Recover(i.crash, "indexer", c.TaskKey, nil)
 
 	i.cache.Set(c.TaskKey, c)
 
@@ -146,3 +250,31 @@ func (i *Indexer) processTask(c *model.Task) {
 		i.afterChange(c)
 	}
 }
+
+func (i *Indexer) processTasks(cs []*model.Task) {
+	defer crash.Recover(i.crash, "indexer", "", nil)
+	tasks := make([]*model.Task, 0, len(cs))
+	for _, c := range cs {
+		if c == nil {
+			log.Error("[Infomer] received nil task")
+			continue
+		}
+		i.cache.Set(c.TaskKey, c)
+		tasks = append(tasks, c)
+	}
+	if len(tasks) > 0 {
+		i.afterChanges(tasks)
+	}
+}
+
+// checkHeartbeat lists real tasks from loader rather than cache,
+// since cache is not refreshed on heartbeat only changes.
+func (i *Indexer) checkHeartbeat(ctx context.Context) {
+	reals, err := i.loader.List(ctx)
+	if err != nil {
+		log.Error("[Infomer] List() failed: %v", err)
+		return
+	}
+	i.markListed()
+	i.heartbeat.check(reals, i.clock.Now())
+}
@@ -0,0 +1,29 @@
+package infomer
+
+import (
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestDrainChanges(t *testing.T) {
+	ch := make(chan *model.Task, 10)
+	for _, key := range []string{"b", "c", "d"} {
+		ch <- &model.Task{TaskKey: key}
+	}
+	first := &model.Task{TaskKey: "a"}
+
+	t.Run("最多取 size 个", func(t *testing.T) {
+		batch := drainChanges(ch, first, 3)
+		if len(batch) != 3 || batch[0].TaskKey != "a" || batch[2].TaskKey != "c" {
+			t.Errorf("期望 a,b,c, 得到 %v", batch)
+		}
+	})
+
+	t.Run("不等待新的变更", func(t *testing.T) {
+		batch := drainChanges(ch, first, 10)
+		if len(batch) != 2 || batch[1].TaskKey != "d" {
+			t.Errorf("期望 a,d, 得到 %v", batch)
+		}
+	})
+}
@@ -10,7 +10,10 @@ import (
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
 
+	"github.com/xyzbit/minitaskx/core/components/crash"
 	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
 	"github.com/xyzbit/minitaskx/core/model"
 	"github.com/xyzbit/minitaskx/internal/queue"
 	"github.com/xyzbit/minitaskx/pkg/util/retry"
@@ -21,22 +24,48 @@ type Infomer struct {
 
 	indexer     *Indexer
 	recorder    recorder
+	cache       *recorderCache
 	changeQueue queue.TypedInterface[model.Change]
 
+	latency *latencyRecorder
+	usage   *usageRecorder
+	ledger  *finishLedger
+	// task key <==> when a pair of it was first filtered as auto finished.
+	autoFinishedAt sync.Map
+	// destructive changes suppressed by stale cache.
+	suppressed metrics.Counter
+	// watches resubscribed after closed.
+	resubscribes metrics.Counter
+
 	logger log.Logger
+	opts   *options
 }
 
 func New(
 	indexer *Indexer,
 	recorder recorder,
 	logger log.Logger,
+	opts ...Option,
 ) *Infomer {
-	return &Infomer{
-		indexer:     indexer,
-		recorder:    recorder,
-		changeQueue: queue.NewTyped[model.Change](),
-		logger:      logger,
+	o := newOptions(opts...)
+	i := &Infomer{
+		indexer:      indexer,
+		recorder:     recorder,
+		changeQueue:  newTimedQueue(newChangeQueue(o.changeQueueBounds, logger, o.metrics), o.clock, o.metrics),
+		latency:      newLatencyRecorder(o.clock, o.metrics),
+		usage:        newUsageRecorder(o.metrics),
+		ledger:       newFinishLedger(o.clock),
+		suppressed:   o.metrics.NewCounter("minitaskx_infomer_suppressed_changes_total", "destructive changes suppressed while cache of real tasks is stale", "change"),
+		resubscribes: o.metrics.NewCounter("minitaskx_infomer_watch_resubscribes_total", "watches of runnable tasks resubscribed after closed"),
+		logger:       logger,
+		opts:         o,
+	}
+	if o.recorderCacheTTL > 0 {
+		i.cache = newRecorderCache(recorder, o.recorderCacheTTL, o.clock, o.metrics)
+		i.recorder = i.cache
 	}
+	indexer.SetAfterRemove(i.latency.forget)
+	return i
 }
 
 func (i *Infomer) Run(ctx context.Context, workerID string, resync time.Duration) error {
@@ -72,6 +101,29 @@ func (i *Infomer) ChangeConsumer() ChangeConsumer {
 	return &changeConsumer{i: i}
 }
 
+// ForceRelease mark the in-flight change of taskKey as done, so that a change
+// stuck in executor will not block following changes of the task forever.
+// returns false if there is no in-flight change of taskKey.
+func (i *Infomer) ForceRelease(taskKey string) bool {
+	change := model.Change{TaskKey: taskKey}
+	if !i.changeQueue.Exist(change) {
+		return false
+	}
+	i.changeQueue.Done(change)
+	i.logger.Info("[Infomer] force release change of task[%s]", taskKey)
+	return true
+}
+
+// ListRealTasks returns real tasks reported by executors of this worker.
+func (i *Infomer) ListRealTasks() []*model.Task {
+	return i.indexer.ListTasks(nil)
+}
+
+// StaleTasks returns running tasks whose heartbeat is stale, see WithHeartbeatTimeout.
+func (i *Infomer) StaleTasks() []*model.Task {
+	return i.indexer.StaleTasks()
+}
+
 // graceful shutdown.
 // Stop sending new events and wait for old events to be consumed.
 func (i *Infomer) Shutdown(ctx context.Context) error {
@@ -103,55 +155,154 @@ func (i *Infomer) enqueueIfTaskChange(ctx context.Context, ch <-chan triggerInfo
 			if !ok {
 				return
 			}
-			// load want and real task status
-			taskPairs, err := i.loadTaskPairsThreadSafe(ctx, triggerInfo)
-			if err != nil {
-				i.logger.Error("[Infomer] loadTaskPairs failed: %v", err)
-				continue
-			}
-			if len(taskPairs) == 0 {
-				continue
-			}
+			i.enqueueChanges(ctx, triggerInfo)
+		}
+	}
+}
 
-			// diff to get change
-			changes := diff(taskPairs)
+func (i *Infomer) enqueueChanges(ctx context.Context, triggerInfo triggerInfo) {
+	defer crash.Recover(i.opts.crashReporter, "infomer", "", nil)
 
-			// handle exception change.
-			changes = i.handleException(changes)
+	// load want and real task status
+	taskPairs, err := i.loadTaskPairsThreadSafe(ctx, triggerInfo)
+	if err != nil {
+		i.logger.Error("[Infomer] loadTaskPairs failed: %v", err)
+		return
+	}
+	if len(taskPairs) == 0 {
+		return
+	}
 
-			// changeQueue can ensure that only one operation of a task is executed at the same time.
-			for _, change := range changes {
-				if exist := i.changeQueue.Add(change); !exist {
-					i.logger.Info("[Infomer] enqueue change: %v", change)
-				}
-			}
+	// diff to get change
+	changes := i.opts.differ.Diff(taskPairs)
+
+	changes = i.suppressDestructive(changes)
+
+	// handle exception change.
+	changes = i.handleException(changes)
+
+	// changeQueue can ensure that only one operation of a task is executed at the same time.
+	for _, change := range changes {
+		if exist := i.changeQueue.Add(change); !exist {
+			i.logger.Info("[Infomer] enqueue change: %v", change)
 		}
 	}
 }
 
 func (i *Infomer) monitorChangeResult(ctx context.Context) {
-	i.indexer.SetAfterChange(func(t *model.Task) {
-		i.logger.Info("[Infomer] monitor task %s status changed: %s", t.TaskKey, t.Status)
-
-		if err := retry.Do(func() error {
-			return i.recorder.UpdateTask(context.Background(), t)
-		}); err != nil {
-			i.logger.Error("[Infomer] UpdateTask(%s) failed: %v", t.TaskKey, err)
-		}
-
-		// mark change done, other operation of the task can enqueue.
-		i.changeQueue.Done(model.Change{TaskKey: t.TaskKey}) // only need task key to mask.
-	})
+	if i.opts.batchUpdateSize > 1 {
+		i.indexer.SetAfterChanges(i.opts.batchUpdateSize, func(reals []*model.Task) {
+			defer crash.Recover(i.opts.crashReporter, "infomer", "", func(error) {
+				for _, real := range reals {
+					i.changeQueue.Done(model.Change{TaskKey: real.TaskKey})
+				}
+			})
+			reals = i.dedupFinishes(i.fenceReports(reals))
+			if len(reals) == 0 {
+				return
+			}
+			ts := make([]*model.Task, 0, len(reals))
+			for _, real := range reals {
+				ts = append(ts, i.changedTask(real))
+			}
+			err := i.retryUpdate(func() error {
+				return i.recorder.BatchUpdateTasks(context.Background(), ts)
+			})
+			switch {
+			case errors.Is(err, taskrepo.ErrTaskNotFound), errors.Is(err, taskrepo.ErrFinalStatus):
+				// the batch is rolled back by a purged or finished task, update the rest one by one.
+				for j, t := range ts {
+					if i.updateTask(t) {
+						i.ledger.record(reals[j])
+					}
+				}
+			case err != nil:
+				i.logger.Error("[Infomer] BatchUpdateTasks(%d) failed: %v", len(ts), err)
+			default:
+				for _, real := range reals {
+					i.ledger.record(real)
+				}
+			}
+			for _, t := range ts {
+				i.changeDone(t)
+			}
+		})
+	} else {
+		i.indexer.SetAfterChange(func(real *model.Task) {
+			defer crash.Recover(i.opts.crashReporter, "infomer", real.TaskKey, func(err error) {
+				i.failTask(real.TaskKey, err)
+			})
+			if len(i.dedupFinishes(i.fenceReports([]*model.Task{real}))) == 0 {
+				return
+			}
+			t := i.changedTask(real)
+			if i.updateTask(t) {
+				i.ledger.record(real)
+			}
+			i.changeDone(t)
+		})
+	}
 	// monitor real task status
 	i.indexer.Monitor(ctx)
 }
 
-type taskPair struct {
-	want *model.Task
-	real *model.Task
+// updateTask reports whether t is applied or needs not, ie. the task is purged or finished.
+func (i *Infomer) updateTask(t *model.Task) bool {
+	err := i.retryUpdate(func() error {
+		return i.recorder.UpdateTask(context.Background(), t)
+	})
+	switch {
+	case errors.Is(err, taskrepo.ErrTaskNotFound):
+		i.logger.Warn("[Infomer] UpdateTask(%s) skipped, task is purged", t.TaskKey)
+	case errors.Is(err, taskrepo.ErrFinalStatus):
+		i.logger.Warn("[Infomer] UpdateTask(%s) to %s skipped: %v", t.TaskKey, t.Status, err)
+	case err != nil:
+		i.logger.Error("[Infomer] UpdateTask(%s) failed: %v", t.TaskKey, err)
+		return false
+	}
+	return true
+}
+
+// retryUpdate retries fn unless the task is purged or finished.
+func (i *Infomer) retryUpdate(fn func() error) error {
+	return retry.OnErrorWithClock(retry.DefaultBackoff, i.opts.clock, func(err error) bool {
+		return !errors.Is(err, taskrepo.ErrTaskNotFound) && !errors.Is(err, taskrepo.ErrFinalStatus)
+	}, fn)
 }
 
-func (i *Infomer) loadTaskPairsThreadSafe(ctx context.Context, info triggerInfo) ([]taskPair, error) {
+// changedTask converts changed real task to the update of want task.
+func (i *Infomer) changedTask(real *model.Task) *model.Task {
+	i.logger.Info("[Infomer] monitor task %s status changed: %s", real.TaskKey, real.Status)
+	t := i.latency.stamp(real)
+	i.usage.observe(t)
+	// spec of want task is owned by scheduler, never overwrite it by real task,
+	// which may carry the spec the executor started with.
+	t.Generation = 0
+	t.Epoch = 0
+	t.Payload = ""
+	t.Labels = nil
+	t.Extra = nil
+	t.Annotations = nil
+	t.Scratch = nil
+	return t
+}
+
+// failTask fails the task whose report panicked, the executor is left as is.
+func (i *Infomer) failTask(taskKey string, err error) {
+	i.updateTask(&model.Task{TaskKey: taskKey, Status: model.TaskStatusFailed, Msg: err.Error()})
+	i.changeQueue.Done(model.Change{TaskKey: taskKey})
+}
+
+func (i *Infomer) changeDone(t *model.Task) {
+	for _, observe := range i.opts.statusObservers {
+		observe(t)
+	}
+
+	// mark change done, other operation of the task can enqueue.
+	i.changeQueue.Done(model.Change{TaskKey: t.TaskKey}) // only need task key to mask.
+}
+
+func (i *Infomer) loadTaskPairsThreadSafe(ctx context.Context, info triggerInfo) ([]TaskPair, error) {
 	// 1. check processing task, Ensure serial execution of the same task.
 	processingKeys := make(map[string]struct{}, len(info.taskKeys))
 	for _, key := range info.taskKeys {
@@ -164,6 +315,14 @@ func (i *Infomer) loadTaskPairsThreadSafe(ctx context.Context, info triggerInfo)
 	wantTaskKeys, realTaskKeys := info.taskKeys, info.taskKeys
 	if info.resync {
 		realTaskKeys = i.indexer.ListTaskKeys()
+		if info.isDue != nil {
+			realTaskKeys = realTaskKeys[:0]
+			for _, real := range i.indexer.ListTasks(nil) {
+				if info.isDue(real) {
+					realTaskKeys = append(realTaskKeys, real.TaskKey)
+				}
+			}
+		}
 	}
 	if len(processingKeys) > 0 {
 		wtemp, rtemp := make([]string, 0, len(wantTaskKeys)), make([]string, 0, len(realTaskKeys))
@@ -179,6 +338,10 @@ func (i *Infomer) loadTaskPairsThreadSafe(ctx context.Context, info triggerInfo)
 		}
 		wantTaskKeys, realTaskKeys = wtemp, rtemp
 	}
+	// resync reads want tasks from repo, in case cache missed changes.
+	if info.resync && i.cache != nil {
+		i.cache.invalidate(wantTaskKeys...)
+	}
 	taskPairs, err := i.loadTaskPairs(ctx, wantTaskKeys, realTaskKeys)
 	if err != nil {
 		return nil, err
@@ -187,33 +350,52 @@ func (i *Infomer) loadTaskPairsThreadSafe(ctx context.Context, info triggerInfo)
 		return nil, nil
 	}
 
-	// 3. filter finished task.
-	// After the task is completed, the system will automatically modify the task state.
-	// This action occurs in parallel with 'diff' logic.
-	// So we filter out tasks that are completed.
-	ret := make([]taskPair, 0, len(taskPairs))
+	// 3. filter finished and waiting task.
+	now := i.opts.clock.Now()
+	ret := make([]TaskPair, 0, len(taskPairs))
 	for _, pair := range taskPairs {
-		if want := pair.want; want != nil {
-			if want.Status.IsFinalStatus() {
-				continue
-			}
-		}
-		if real := pair.real; real != nil {
-			if real.Status.IsFinalStatus() {
-				continue
-			}
+		if decision, _ := i.filterPair(&pair, now, false); decision != "" {
+			continue
 		}
 		ret = append(ret, pair)
 	}
 	return ret, nil
 }
 
-func (i *Infomer) loadTaskPairs(ctx context.Context, wantTaskKeys, realTaskKeys []string) ([]taskPair, error) {
+// filterPair filters a loaded pair before diffing, it returns the decision
+// of a pair not diffed, empty if the pair is diffed. reconciling and
+// explaining share it, dryRun filters without side effects for explaining.
+func (i *Infomer) filterPair(pair *TaskPair, now time.Time, dryRun bool) (Decision, string) {
+	// the executor of an older epoch exits as if the task were removed.
+	if fenced(pair.Want, pair.Real) {
+		if !dryRun {
+			i.logger.Warn("[Infomer] task[%s] is reassigned with epoch %d, exit executor of epoch %d", pair.Real.TaskKey, pair.Want.Epoch, pair.Real.Epoch)
+		}
+		pair.Want = nil
+	}
+	// After the task is completed, the system will automatically modify the task state.
+	// This action occurs in parallel with 'diff' logic.
+	// So we filter out tasks that are completed.
+	if i.filterAutoFinished(pair, now, dryRun) {
+		return DecisionAutoFinished, ""
+	}
+	// retried task waits for backoff before a new executor starts,
+	// parked task waits until resumed.
+	if want := pair.Want; want != nil && pair.Real == nil && want.NextRunAt != nil && want.NextRunAt.After(now) {
+		return DecisionWaiting, "next run at " + want.NextRunAt.Format(time.RFC3339)
+	}
+	return "", ""
+}
+
+func (i *Infomer) loadTaskPairs(ctx context.Context, wantTaskKeys, realTaskKeys []string) ([]TaskPair, error) {
 	if len(wantTaskKeys) == 0 && len(realTaskKeys) == 0 {
 		return nil, nil
 	}
 
-	wantTasks, err := i.recorder.BatchGetTask(ctx, wantTaskKeys) // 2.是不是延迟删除导致的，如果是要在diff判断状态
+	wantTasks, err := taskrepo.ChunkedBatchGetTask(
+		ctx, i.recorder.BatchGetTask, wantTaskKeys,
+		i.opts.batchGetChunkSize, i.opts.batchGetParallelism,
+	) // 2.是不是延迟删除导致的，如果是要在diff判断状态
 	if err != nil {
 		return nil, err
 	}
@@ -225,57 +407,20 @@ func (i *Infomer) loadTaskPairs(ctx context.Context, wantTaskKeys, realTaskKeys
 	realMap := lo.KeyBy(realTasks, func(t *model.Task) string { return t.TaskKey })
 	wantMap := lo.KeyBy(wantTasks, func(t *model.Task) string { return t.TaskKey })
 
-	taskPairs := make([]taskPair, 0, len(wantTasks))
+	taskPairs := make([]TaskPair, 0, len(wantTasks))
 	for _, want := range wantTasks {
-		taskPairs = append(taskPairs, taskPair{want: want, real: realMap[want.TaskKey]})
+		taskPairs = append(taskPairs, TaskPair{Want: want, Real: realMap[want.TaskKey]})
 	}
 	for _, real := range realTasks {
 		_, exists := wantMap[real.TaskKey]
 		if !exists {
-			taskPairs = append(taskPairs, taskPair{real: real})
+			taskPairs = append(taskPairs, TaskPair{Real: real})
 		}
 	}
 
 	return taskPairs, nil
 }
 
-func diff(taskPairs []taskPair) []model.Change {
-	var changes []model.Change
-
-	for _, pair := range taskPairs {
-		var changeTask *model.Task
-		want, real := pair.want, pair.real
-		wantStatus, realStatus := model.TaskStatusNotExist, model.TaskStatusNotExist
-		if real != nil {
-			changeTask = real
-			realStatus = real.Status
-		}
-		if want != nil {
-			changeTask = want
-			wantStatus = want.WantRunStatus
-		}
-		log.Debug("[Infomer] diff, want status: %v, real: %v", want.Status, real.Status)
-
-		if realStatus == wantStatus {
-			continue
-		}
-
-		changeType, err := model.GetChangeType(realStatus, wantStatus)
-		if err != nil {
-			log.Error("[diff] task key: %s, realStatus: %s, wantStatus: %s, err: %v", changeTask.TaskKey, realStatus, wantStatus, err)
-			continue
-		}
-		changes = append(changes, model.Change{
-			TaskKey:    changeTask.TaskKey,
-			TaskType:   changeTask.Type,
-			ChangeType: changeType,
-			Task:       changeTask,
-		})
-	}
-
-	return changes
-}
-
 func (i *Infomer) handleException(cs []model.Change) []model.Change {
 	normalChanges := make([]model.Change, 0, len(cs))
 	for _, c := range cs {
@@ -283,6 +428,10 @@ func (i *Infomer) handleException(cs []model.Change) []model.Change {
 			normalChanges = append(normalChanges, c)
 			continue
 		}
+		// the executor exits after its finish is applied, which is no exception.
+		if i.ledger.finishedAny(c.TaskKey) {
+			continue
+		}
 
 		if err := i.recorder.UpdateTask(context.Background(), &model.Task{
 			TaskKey: c.TaskKey,
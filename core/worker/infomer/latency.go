@@ -0,0 +1,74 @@
+package infomer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+type latencyRecorder struct {
+	clock     clock.Clock
+	startedAt sync.Map // task key <==> *time.Time
+
+	queueWait   metrics.Histogram
+	runDuration metrics.Histogram
+	endToEnd    metrics.Histogram
+}
+
+func newLatencyRecorder(c clock.Clock, p metrics.Provider) *latencyRecorder {
+	labels := []string{"biz_type", "type", "status"}
+	return &latencyRecorder{
+		clock:       c,
+		queueWait:   p.NewHistogram("minitaskx_task_queue_wait_seconds", "time from task created to started", labels...),
+		runDuration: p.NewHistogram("minitaskx_task_run_duration_seconds", "time from task started to finished", labels...),
+		endToEnd:    p.NewHistogram("minitaskx_task_end_to_end_seconds", "time from task created to finished", labels...),
+	}
+}
+
+// stamp returns a copy of real task with lifecycle timestamps filled,
+// and observes latencies when the task finished.
+func (r *latencyRecorder) stamp(t *model.Task) *model.Task {
+	now := r.clock.Now()
+	stamped := *t
+
+	switch {
+	case t.Status == model.TaskStatusRunning:
+		startedAt := t.StartedAt
+		if startedAt == nil {
+			startedAt = &now
+		}
+		actual, _ := r.startedAt.LoadOrStore(t.TaskKey, startedAt)
+		stamped.StartedAt = actual.(*time.Time)
+	case t.Status.IsFinalStatus():
+		if actual, ok := r.startedAt.LoadAndDelete(t.TaskKey); ok && stamped.StartedAt == nil {
+			stamped.StartedAt = actual.(*time.Time)
+		}
+		if stamped.FinishedAt == nil {
+			stamped.FinishedAt = &now
+		}
+		r.observe(&stamped)
+	}
+	return &stamped
+}
+
+// forget drops the start time of a task left the indexer, whose finish
+// may never be stamped, eg. the final status was not reported by this worker.
+func (r *latencyRecorder) forget(taskKey string) {
+	r.startedAt.Delete(taskKey)
+}
+
+func (r *latencyRecorder) observe(t *model.Task) {
+	labels := []string{t.BizType, t.Type, t.Status.String()}
+	if d, ok := t.QueueWait(); ok {
+		r.queueWait.Observe(d.Seconds(), labels...)
+	}
+	if d, ok := t.RunDuration(); ok {
+		r.runDuration.Observe(d.Seconds(), labels...)
+	}
+	if d, ok := t.EndToEnd(); ok {
+		r.endToEnd.Observe(d.Seconds(), labels...)
+	}
+}
@@ -0,0 +1,39 @@
+package infomer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+func TestLatencyForget(t *testing.T) {
+	c := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	loader := &benchLoader{tasks: []*model.Task{
+		{TaskKey: "done", Status: model.TaskStatusSuccess},
+		{TaskKey: "running", Status: model.TaskStatusRunning},
+	}}
+	i := New(NewIndexer(loader, time.Minute, WithClock(c)), &benchRecorder{}, log.Global(), WithClock(c))
+	// the worker saw both running, but never stamped the finish of done.
+	i.latency.stamp(&model.Task{TaskKey: "done", Status: model.TaskStatusRunning})
+	i.latency.stamp(&model.Task{TaskKey: "running", Status: model.TaskStatusRunning})
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := i.latency.startedAt.Load("done"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("期望任务离开 indexer 后释放开始时间")
+		}
+		if c.HasWaiters() {
+			c.Step(time.Minute)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, ok := i.latency.startedAt.Load("running"); !ok {
+		t.Error("运行中的任务应保留开始时间")
+	}
+}
@@ -0,0 +1,104 @@
+package infomer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+// finishes are remembered at least this long, a finish reported again
+// later is rare enough to be applied twice.
+const finishRetention = time.Hour
+
+// finishLedger records final statuses applied per attempt of tasks, an
+// attempt is a task key and the lease epoch it runs with. a task run again by
+// the same lease, eg. retried, reports non-final statuses before it finishes
+// again. so that a finish reported twice, eg. by a retried report or an
+// exception found by diff, never overwrites the final status or notifies
+// observers again.
+//
+// the ledger is kept in memory of the worker only, finishes are forgotten
+// when the worker restarts and are unknown to other workers, which take over
+// the task with a new epoch anyway. a finish reported again across a restart
+// of the worker is applied twice, the repo keeps the final status by
+// taskrepo.WithFinalGuard, while observers may be notified twice.
+type finishLedger struct {
+	mu    sync.Mutex
+	clock clock.Clock
+	// task key => epoch => when the attempt finished.
+	finishes map[string]map[int64]time.Time
+	prunedAt time.Time
+}
+
+func newFinishLedger(c clock.Clock) *finishLedger {
+	return &finishLedger{
+		clock:    c,
+		finishes: make(map[string]map[int64]time.Time),
+		prunedAt: c.Now(),
+	}
+}
+
+// finished reports whether the attempt of real has finished already.
+func (l *finishLedger) finished(real *model.Task) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.finishes[real.TaskKey][real.Epoch]
+	return ok
+}
+
+// finishedAny reports whether any attempt of task finished and not run again.
+func (l *finishLedger) finishedAny(taskKey string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.finishes[taskKey]) > 0
+}
+
+// record remembers final statuses applied, a non-final status starts a new
+// attempt of the task and forgets its finishes.
+func (l *finishLedger) record(t *model.Task) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	if t.Status.IsFinalStatus() {
+		epochs, ok := l.finishes[t.TaskKey]
+		if !ok {
+			epochs = make(map[int64]time.Time, 1)
+			l.finishes[t.TaskKey] = epochs
+		}
+		epochs[t.Epoch] = now
+	} else {
+		delete(l.finishes, t.TaskKey)
+	}
+
+	if now.Sub(l.prunedAt) < finishRetention {
+		return
+	}
+	for taskKey, epochs := range l.finishes {
+		for epoch, at := range epochs {
+			if now.Sub(at) >= finishRetention {
+				delete(epochs, epoch)
+			}
+		}
+		if len(epochs) == 0 {
+			delete(l.finishes, taskKey)
+		}
+	}
+	l.prunedAt = now
+}
+
+// dedupFinishes drops reports finishing attempts finished already,
+// changes of dropped reports are marked done.
+func (i *Infomer) dedupFinishes(reals []*model.Task) []*model.Task {
+	ret := reals[:0:0]
+	for _, real := range reals {
+		if !real.Status.IsFinalStatus() || !i.ledger.finished(real) {
+			ret = append(ret, real)
+			continue
+		}
+		i.logger.Warn("[Infomer] drop duplicate finish of task[%s] status %s, epoch %d", real.TaskKey, real.Status, real.Epoch)
+		i.changeQueue.Done(model.Change{TaskKey: real.TaskKey})
+	}
+	return ret
+}
@@ -0,0 +1,59 @@
+package infomer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+func TestFinishLedger(t *testing.T) {
+	c := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	i := New(NewIndexer(&benchLoader{}, time.Minute, WithClock(c)), &benchRecorder{}, log.Global(), WithClock(c))
+	success := &model.Task{TaskKey: "t", Status: model.TaskStatusSuccess, Epoch: 1}
+	failed := &model.Task{TaskKey: "t", Status: model.TaskStatusFailed, Epoch: 1}
+
+	t.Run("同一次运行只结束一次", func(t *testing.T) {
+		if got := i.dedupFinishes([]*model.Task{success}); len(got) != 1 {
+			t.Fatalf("首次结束不应丢弃: %+v", got)
+		}
+		i.ledger.record(success)
+		if got := i.dedupFinishes([]*model.Task{failed}); len(got) != 0 {
+			t.Errorf("重复结束应丢弃: %+v", got)
+		}
+	})
+
+	t.Run("已结束任务不按异常处理", func(t *testing.T) {
+		changes := []model.Change{
+			{TaskKey: "t", ChangeType: model.ChangeExceptionFinish},
+			{TaskKey: "other", ChangeType: model.ChangeCreate},
+		}
+		if got := i.handleException(changes); len(got) != 1 || got[0].TaskKey != "other" {
+			t.Errorf("异常变更过滤错误: %+v", got)
+		}
+	})
+
+	t.Run("重新运行后可再次结束", func(t *testing.T) {
+		i.ledger.record(&model.Task{TaskKey: "t", Status: model.TaskStatusRunning, Epoch: 1})
+		if got := i.dedupFinishes([]*model.Task{failed}); len(got) != 1 {
+			t.Errorf("新一次运行的结束不应丢弃: %+v", got)
+		}
+		i.ledger.record(failed)
+		if got := i.dedupFinishes([]*model.Task{{TaskKey: "t", Status: model.TaskStatusSuccess, Epoch: 2}}); len(got) != 1 {
+			t.Errorf("新 epoch 的结束不应丢弃: %+v", got)
+		}
+	})
+
+	t.Run("过期后清理", func(t *testing.T) {
+		c.Step(finishRetention)
+		i.ledger.record(&model.Task{TaskKey: "other", Status: model.TaskStatusSuccess})
+		if i.ledger.finished(failed) || i.ledger.finishedAny("t") {
+			t.Error("过期记录应被清理")
+		}
+		if _, ok := i.ledger.finishes["t"]; ok {
+			t.Error("没有记录的任务应被移除")
+		}
+	})
+}
@@ -0,0 +1,241 @@
+package infomer
+
+import (
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/crash"
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+type options struct {
+	// chunk size and parallelism of loading want tasks.
+	batchGetChunkSize   int
+	batchGetParallelism int
+
+	// called after real task status change is recorded.
+	statusObservers []func(task *model.Task)
+
+	// window of coalescing task keys emitted by watch, 0 disables it.
+	triggerDebounce time.Duration
+	// backoff of resubscribing closed watch.
+	watchBackoff    time.Duration
+	watchMaxBackoff time.Duration
+
+	// translates want/real pairs into changes.
+	differ Differ
+
+	// resync intervals of matched tasks, others use the interval passed to Run.
+	resyncRules []ResyncRule
+
+	// time source of resync, cache recycle, retry and latency.
+	clock clock.Clock
+
+	// running task is flagged stale when its heartbeat is older than it, 0 disables it.
+	heartbeatTimeout time.Duration
+
+	// spread resyncs of workers over the interval, see WithResyncSplay.
+	resyncSplay   time.Duration
+	resyncJitter  time.Duration
+	resyncSlotter ResyncSlotter
+
+	recorderCacheTTL time.Duration
+
+	batchUpdateSize int
+
+	// limits of changes waiting for executors, nil means unbounded.
+	changeQueueBounds *ChangeQueueBounds
+
+	// cache of real tasks is stale if loader.List kept failing longer than it.
+	staleCacheThreshold time.Duration
+
+	// pairs of one side finished are left to the system at most this long, 0 means forever.
+	autoFinishGrace time.Duration
+	// emits events of tasks, eg. pairs filtered as auto finished.
+	emit func(e events.Event)
+
+	// reports panics recovered by goroutines of infomer and indexer.
+	crashReporter crash.Reporter
+
+	// creates metrics of infomer and indexer.
+	metrics metrics.Provider
+}
+
+type Option func(o *options)
+
+func WithBatchGetChunk(size, parallelism int) Option {
+	return func(o *options) {
+		o.batchGetChunkSize = size
+		o.batchGetParallelism = parallelism
+	}
+}
+
+// WithStatusObserver add a observer of real task status change, eg. analytics exporter.
+// observer should not block.
+func WithStatusObserver(fn func(task *model.Task)) Option {
+	return func(o *options) {
+		o.statusObservers = append(o.statusObservers, fn)
+	}
+}
+
+// WithTriggerDebounce collapse bursts of watched task keys within window
+// into one trigger, so that the same tasks are not reloaded and re-diffed repeatedly.
+func WithTriggerDebounce(window time.Duration) Option {
+	return func(o *options) {
+		o.triggerDebounce = window
+	}
+}
+
+// WithWatchBackoff sets backoff of resubscribing the watch of runnable tasks
+// once it is closed, doubled from initial up to max.
+func WithWatchBackoff(initial, max time.Duration) Option {
+	return func(o *options) {
+		o.watchBackoff = initial
+		o.watchMaxBackoff = max
+	}
+}
+
+// WithDiffer replace DefaultDiffer, eg. treat payload changes as requiring
+// a restart of the executor.
+func WithDiffer(d Differ) Option {
+	return func(o *options) {
+		o.differ = d
+	}
+}
+
+// WithResyncRules sets resync interval per task type or label selector,
+// the first matched rule wins.
+func WithResyncRules(rules ...ResyncRule) Option {
+	return func(o *options) {
+		o.resyncRules = append(o.resyncRules, rules...)
+	}
+}
+
+// WithClock replace the wall clock, mostly used by tests with clock.FakeClock.
+// it should be passed to both New and NewIndexer.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithHeartbeatTimeout flags running tasks whose executor has not reported
+// heartbeat within timeout, it should be passed to NewIndexer.
+func WithHeartbeatTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.heartbeatTimeout = timeout
+	}
+}
+
+// WithResyncSplay delays the first resync by a random duration within splay,
+// so that workers started together do not resync at the same time.
+func WithResyncSplay(splay time.Duration) Option {
+	return func(o *options) {
+		o.resyncSplay = splay
+	}
+}
+
+// WithResyncJitter delays each resync by a random duration within jitter.
+func WithResyncJitter(jitter time.Duration) Option {
+	return func(o *options) {
+		o.resyncJitter = jitter
+	}
+}
+
+// WithResyncSlotter staggers resyncs by slots assigned by s instead of
+// a random splay, falls back to the splay if s fails.
+func WithResyncSlotter(s ResyncSlotter) Option {
+	return func(o *options) {
+		o.resyncSlotter = s
+	}
+}
+
+// WithRecorderCache caches want tasks read from recorder for at most ttl,
+// cached tasks are invalidated by watch events and updates of this worker.
+func WithRecorderCache(ttl time.Duration) Option {
+	return func(o *options) {
+		o.recorderCacheTTL = ttl
+	}
+}
+
+// WithBatchUpdate persists up to size status changes reported together
+// by executors with one BatchUpdateTasks call, 0 or 1 updates one by one.
+func WithBatchUpdate(size int) Option {
+	return func(o *options) {
+		o.batchUpdateSize = size
+	}
+}
+
+// WithChangeQueueBounds limits changes waiting for executors.
+func WithChangeQueueBounds(b ChangeQueueBounds) Option {
+	return func(o *options) {
+		o.changeQueueBounds = &b
+	}
+}
+
+// WithStaleCacheThreshold treats cache of real tasks as stale once loader.List
+// kept failing longer than threshold, stop and delete changes are suppressed
+// while stale. default 3 resync intervals, it should be passed to NewIndexer.
+func WithStaleCacheThreshold(threshold time.Duration) Option {
+	return func(o *options) {
+		o.staleCacheThreshold = threshold
+	}
+}
+
+// WithAutoFinishGrace bounds how long a task finished on one side is left
+// to the system: a finish of the executor not recorded within grace is
+// reported again, and the executor of a task finished by others is exited.
+// default 0 leaves them forever.
+func WithAutoFinishGrace(grace time.Duration) Option {
+	return func(o *options) {
+		o.autoFinishGrace = grace
+	}
+}
+
+// WithEventEmitter emits events of tasks found by reconciling, emit should not block.
+func WithEventEmitter(emit func(e events.Event)) Option {
+	return func(o *options) {
+		o.emit = emit
+	}
+}
+
+// WithCrashReporter reports panics recovered by goroutines of infomer and
+// indexer to r, panics are always logged. it should be passed to NewIndexer too.
+func WithCrashReporter(r crash.Reporter) Option {
+	return func(o *options) {
+		o.crashReporter = r
+	}
+}
+
+// WithMetricsProvider creates metrics of infomer and indexer by p instead of
+// the global provider, eg. labeled by worker id. it should be passed to
+// NewIndexer too.
+func WithMetricsProvider(p metrics.Provider) Option {
+	return func(o *options) {
+		o.metrics = p
+	}
+}
+
+func newOptions(opts ...Option) *options {
+	// set default
+	o := options{
+		batchGetChunkSize:   taskrepo.DefaultBatchGetChunkSize,
+		batchGetParallelism: taskrepo.DefaultBatchGetParallelism,
+		triggerDebounce:     defaultTriggerDebounce,
+		watchBackoff:        defaultWatchBackoff,
+		watchMaxBackoff:     defaultWatchMaxBackoff,
+		differ:              DefaultDiffer,
+		clock:               clock.RealClock{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.metrics == nil {
+		o.metrics = metrics.Global()
+	}
+
+	return &o
+}
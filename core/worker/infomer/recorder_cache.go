@@ -0,0 +1,161 @@
+package infomer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+// prune expired entries every pruneEvery stores.
+const pruneEvery = 1000
+
+type cacheEntry struct {
+	task     *model.Task
+	cachedAt time.Time
+}
+
+// recorderCache caches want tasks read by BatchGetTask, entries are invalidated
+// by watch events and writes of this worker, and expire after ttl in case
+// watch misses changes. full resyncs bypass it.
+type recorderCache struct {
+	recorder
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	// sequence of invalidation, loads started before a key is invalidated
+	// must not cache the key.
+	seq           uint64
+	invalidatedAt map[string]uint64
+	stores        int
+
+	requests metrics.Counter
+}
+
+func newRecorderCache(r recorder, ttl time.Duration, c clock.Clock, p metrics.Provider) *recorderCache {
+	return &recorderCache{
+		recorder:      r,
+		ttl:           ttl,
+		clock:         c,
+		entries:       make(map[string]cacheEntry),
+		invalidatedAt: make(map[string]uint64),
+		requests:      p.NewCounter("minitaskx_recorder_cache_requests_total", "want tasks read through recorder cache, result is hit or miss", "result"),
+	}
+}
+
+func (c *recorderCache) BatchGetTask(ctx context.Context, taskKeys []string) ([]*model.Task, error) {
+	now := c.clock.Now()
+	ret := make([]*model.Task, 0, len(taskKeys))
+	var missed []string
+
+	c.mu.Lock()
+	for _, key := range taskKeys {
+		e, ok := c.entries[key]
+		if ok && now.Sub(e.cachedAt) < c.ttl {
+			ret = append(ret, copyTask(e.task))
+			continue
+		}
+		missed = append(missed, key)
+	}
+	start := c.seq
+	c.mu.Unlock()
+
+	c.requests.Add(float64(len(ret)), "hit")
+	if len(missed) == 0 {
+		return ret, nil
+	}
+	c.requests.Add(float64(len(missed)), "miss")
+
+	loaded, err := c.recorder.BatchGetTask(ctx, missed)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, task := range loaded {
+		ret = append(ret, task)
+		if c.invalidatedAt[task.TaskKey] > start {
+			continue
+		}
+		// never replace a newer version cached by a concurrent load.
+		if e, ok := c.entries[task.TaskKey]; ok && e.task.UpdatedAt.After(task.UpdatedAt) {
+			continue
+		}
+		c.entries[task.TaskKey] = cacheEntry{task: copyTask(task), cachedAt: now}
+		c.stores++
+	}
+	if c.stores >= pruneEvery {
+		c.prune(now)
+	}
+	return ret, nil
+}
+
+func (c *recorderCache) UpdateTask(ctx context.Context, task *model.Task) error {
+	err := c.recorder.UpdateTask(ctx, task)
+	c.invalidate(task.TaskKey)
+	return err
+}
+
+func (c *recorderCache) BatchUpdateTasks(ctx context.Context, tasks []*model.Task) error {
+	err := c.recorder.BatchUpdateTasks(ctx, tasks)
+	keys := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		keys = append(keys, task.TaskKey)
+	}
+	c.invalidate(keys...)
+	return err
+}
+
+// WatchRunnableTasks invalidates changed keys before they trigger reconciling.
+func (c *recorderCache) WatchRunnableTasks(ctx context.Context, workerID string) (<-chan []string, error) {
+	in, err := c.recorder.WatchRunnableTasks(ctx, workerID)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan []string)
+	go func() {
+		defer close(out)
+		for keys := range in {
+			c.invalidate(keys...)
+			select {
+			case out <- keys:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (c *recorderCache) invalidate(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	for _, key := range keys {
+		delete(c.entries, key)
+		c.invalidatedAt[key] = c.seq
+	}
+}
+
+// prune drops expired entries and invalidation marks no load can observe.
+func (c *recorderCache) prune(now time.Time) {
+	c.stores = 0
+	for key, e := range c.entries {
+		if now.Sub(e.cachedAt) >= c.ttl {
+			delete(c.entries, key)
+		}
+	}
+	clear(c.invalidatedAt)
+}
+
+// copyTask copies all fields of task, Clone drops want side fields.
+func copyTask(task *model.Task) *model.Task {
+	cp := *task
+	return &cp
+}
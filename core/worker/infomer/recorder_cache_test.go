@@ -0,0 +1,119 @@
+package infomer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+type countingRecorder struct {
+	tasks   map[string]*model.Task
+	reads   int
+	changes chan []string
+	// called after rows are read, before they are returned.
+	onRead func()
+}
+
+func (r *countingRecorder) UpdateTask(_ context.Context, task *model.Task) error {
+	r.tasks[task.TaskKey] = copyTask(task)
+	return nil
+}
+
+func (r *countingRecorder) BatchUpdateTasks(ctx context.Context, tasks []*model.Task) error {
+	for _, task := range tasks {
+		_ = r.UpdateTask(ctx, task)
+	}
+	return nil
+}
+
+func (r *countingRecorder) BatchGetTask(_ context.Context, keys []string) ([]*model.Task, error) {
+	ret := make([]*model.Task, 0, len(keys))
+	for _, key := range keys {
+		r.reads++
+		if task, ok := r.tasks[key]; ok {
+			ret = append(ret, copyTask(task))
+		}
+	}
+	if r.onRead != nil {
+		r.onRead()
+	}
+	return ret, nil
+}
+
+func (r *countingRecorder) ListRunnableTasks(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+func (r *countingRecorder) WatchRunnableTasks(context.Context, string) (<-chan []string, error) {
+	return r.changes, nil
+}
+
+func TestRecorderCache(t *testing.T) {
+	ctx := context.Background()
+	fc := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r := &countingRecorder{
+		tasks:   map[string]*model.Task{"a": {TaskKey: "a", WantRunStatus: model.TaskStatusRunning}},
+		changes: make(chan []string),
+	}
+	c := newRecorderCache(r, time.Minute, fc, metrics.Global())
+
+	get := func() *model.Task {
+		t.Helper()
+		tasks, err := c.BatchGetTask(ctx, []string{"a"})
+		if err != nil || len(tasks) != 1 {
+			t.Fatalf("读取失败: %v %v", tasks, err)
+		}
+		return tasks[0]
+	}
+
+	t.Run("命中缓存不读 repo", func(t *testing.T) {
+		get()
+		get()
+		if r.reads != 1 {
+			t.Errorf("期望读 repo 1 次, 得到 %d", r.reads)
+		}
+	})
+
+	t.Run("watch 事件使缓存失效", func(t *testing.T) {
+		r.tasks["a"] = &model.Task{TaskKey: "a", WantRunStatus: model.TaskStatusPaused}
+		watched, _ := c.WatchRunnableTasks(ctx, "w1")
+		go func() { r.changes <- []string{"a"} }()
+		<-watched
+		if got := get(); got.WantRunStatus != model.TaskStatusPaused {
+			t.Errorf("期望读到 paused, 得到 %s", got.WantRunStatus)
+		}
+	})
+
+	t.Run("更新使缓存失效", func(t *testing.T) {
+		_ = c.UpdateTask(ctx, &model.Task{TaskKey: "a", WantRunStatus: model.TaskStatusStop})
+		if got := get(); got.WantRunStatus != model.TaskStatusStop {
+			t.Errorf("期望读到 stop, 得到 %s", got.WantRunStatus)
+		}
+	})
+
+	t.Run("过期后重新读取", func(t *testing.T) {
+		reads := r.reads
+		fc.Step(time.Minute)
+		get()
+		if r.reads != reads+1 {
+			t.Errorf("期望过期后读 repo")
+		}
+	})
+
+	t.Run("加载期间失效的任务不缓存", func(t *testing.T) {
+		fc.Step(time.Minute)
+		r.onRead = func() { c.invalidate("a") }
+		get()
+		r.onRead = nil
+
+		reads := r.reads
+		get()
+		if r.reads != reads+1 {
+			t.Errorf("期望加载期间失效的任务重新读 repo")
+		}
+	})
+}
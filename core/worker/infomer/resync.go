@@ -0,0 +1,130 @@
+package infomer
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// ResyncRule overrides the resync interval of tasks matching Type and Labels.
+type ResyncRule struct {
+	// empty matches any type.
+	Type string
+	// all labels must match, empty matches any task.
+	Labels   map[string]string
+	Interval time.Duration
+}
+
+func (r ResyncRule) match(t *model.Task) bool {
+	if r.Type != "" && r.Type != t.Type {
+		return false
+	}
+	for k, v := range r.Labels {
+		if t.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// resyncSchedule decides which tasks are due to resync on each tick.
+// group i is rules[i], the last group is tasks matching no rule.
+type resyncSchedule struct {
+	rules     []ResyncRule
+	intervals []time.Duration
+	last      []time.Time
+}
+
+func newResyncSchedule(defaultInterval time.Duration, rules []ResyncRule, now time.Time) *resyncSchedule {
+	s := &resyncSchedule{rules: rules}
+	for _, r := range rules {
+		interval := r.Interval
+		if interval <= 0 {
+			interval = defaultInterval
+		}
+		s.intervals = append(s.intervals, interval)
+	}
+	s.intervals = append(s.intervals, defaultInterval)
+	s.last = make([]time.Time, len(s.intervals))
+	for i := range s.last {
+		s.last[i] = now
+	}
+	return s
+}
+
+// tick returns the interval of ticker, which is the shortest interval.
+func (s *resyncSchedule) tick() time.Duration {
+	return slices.Min(s.intervals)
+}
+
+// due returns the due state of each group at now, nil means all groups are due.
+func (s *resyncSchedule) due(now time.Time) []bool {
+	due := make([]bool, len(s.intervals))
+	all := true
+	for i, interval := range s.intervals {
+		// tolerate ticker jitter.
+		if now.Sub(s.last[i]) >= interval-interval/10 {
+			due[i] = true
+			s.last[i] = now
+		} else {
+			all = false
+		}
+	}
+	if all {
+		return nil
+	}
+	return due
+}
+
+// group returns the group of task, first matched rule wins.
+func (s *resyncSchedule) group(t *model.Task) int {
+	for i, r := range s.rules {
+		if r.match(t) {
+			return i
+		}
+	}
+	return len(s.rules)
+}
+
+// slotOffset returns the offset of slot within interval.
+func slotOffset(slot, total int, interval time.Duration) time.Duration {
+	if total <= 0 || slot < 0 {
+		return 0
+	}
+	return time.Duration(int64(interval) * int64(slot%total) / int64(total))
+}
+
+func randDuration(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
+
+// resyncOffset returns the delay of the first resync of workerID.
+func (i *Infomer) resyncOffset(ctx context.Context, workerID string, interval time.Duration) time.Duration {
+	if i.opts.resyncSlotter != nil {
+		slot, total, err := i.opts.resyncSlotter.ResyncSlot(ctx, workerID)
+		if err == nil {
+			return slotOffset(slot, total, interval)
+		}
+		i.logger.Warn("[Infomer] get resync slot failed, fall back to splay: %v", err)
+	}
+	return randDuration(i.opts.resyncSplay)
+}
+
+// sleep waits d, false if ctx is done.
+func (i *Infomer) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-i.opts.clock.After(d):
+		return true
+	}
+}
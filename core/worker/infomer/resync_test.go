@@ -0,0 +1,77 @@
+package infomer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestResyncSchedule(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newResyncSchedule(30*time.Second, []ResyncRule{
+		{Type: "fast", Interval: 10 * time.Second},
+		{Labels: map[string]string{"tier": "cold"}, Interval: time.Minute},
+	}, start)
+
+	t.Run("ticker 使用最短周期", func(t *testing.T) {
+		if got := s.tick(); got != 10*time.Second {
+			t.Errorf("期望 10s, 得到 %v", got)
+		}
+	})
+
+	t.Run("第一个匹配的规则生效", func(t *testing.T) {
+		tests := []struct {
+			task *model.Task
+			want int
+		}{
+			{task: &model.Task{Type: "fast", Labels: map[string]string{"tier": "cold"}}, want: 0},
+			{task: &model.Task{Type: "slow", Labels: map[string]string{"tier": "cold"}}, want: 1},
+			{task: &model.Task{Type: "slow"}, want: 2},
+		}
+		for _, tt := range tests {
+			if got := s.group(tt.task); got != tt.want {
+				t.Errorf("task %+v 期望分组 %d, 得到 %d", tt.task, tt.want, got)
+			}
+		}
+	})
+
+	t.Run("按分组周期到期", func(t *testing.T) {
+		want := map[time.Duration][]bool{
+			10 * time.Second: {true, false, false},
+			20 * time.Second: {true, false, false},
+			30 * time.Second: {true, false, true},
+			40 * time.Second: {true, false, false},
+			60 * time.Second: nil, // 30s group is due since 30s, all due.
+		}
+		for _, d := range []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second, 40 * time.Second, 60 * time.Second} {
+			got := s.due(start.Add(d))
+			if len(got) != len(want[d]) {
+				t.Fatalf("%v 期望 %v, 得到 %v", d, want[d], got)
+			}
+			for i := range got {
+				if got[i] != want[d][i] {
+					t.Fatalf("%v 期望 %v, 得到 %v", d, want[d], got)
+				}
+			}
+		}
+	})
+}
+
+func TestSlotOffset(t *testing.T) {
+	tests := []struct {
+		slot, total int
+		want        time.Duration
+	}{
+		{0, 4, 0},
+		{1, 4, 15 * time.Second},
+		{3, 4, 45 * time.Second},
+		{5, 4, 15 * time.Second},
+		{1, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := slotOffset(tt.slot, tt.total, time.Minute); got != tt.want {
+			t.Errorf("slot %d/%d 期望 %v, 得到 %v", tt.slot, tt.total, tt.want, got)
+		}
+	}
+}
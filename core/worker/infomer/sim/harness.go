@@ -0,0 +1,138 @@
+// Package sim drives Infomer with in-memory want and real tasks, so that
+// reconcile logic can be tested deterministically:
+//
+//	h := sim.New()
+//	defer h.Stop()
+//	h.Want(&model.Task{TaskKey: "a", Status: model.TaskStatusWaitRunning, WantRunStatus: model.TaskStatusRunning})
+//	change, _ := h.NextChange(time.Second) // change.ChangeType == model.ChangeCreate
+//	h.Apply(change, model.TaskStatusRunning)
+package sim
+
+import (
+	"context"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/core/worker/infomer"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+// WorkerID is the worker that harness runs as.
+const WorkerID = "sim-worker"
+
+// ResyncInterval is the resync interval of infomer and indexer, it only
+// fires when Clock is stepped over it.
+const ResyncInterval = 15 * time.Second
+
+type Harness struct {
+	Clock    *clock.FakeClock
+	Recorder *Recorder
+	Loader   *Loader
+	Infomer  *infomer.Infomer
+
+	consumer infomer.ChangeConsumer
+	changes  chan model.Change
+	cancel   context.CancelFunc
+}
+
+// New starts an Infomer over fake recorder and loader.
+func New(opts ...infomer.Option) *Harness {
+	c := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	recorder := NewRecorder(c)
+	loader := NewLoader()
+	// debounce timer waits on fake clock, disable it unless opts enable it.
+	opts = append([]infomer.Option{infomer.WithTriggerDebounce(0)}, opts...)
+	opts = append(opts, infomer.WithClock(c))
+	i := infomer.New(infomer.NewIndexer(loader, ResyncInterval, infomer.WithClock(c)), recorder, log.Global(), opts...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &Harness{
+		Clock:    c,
+		Recorder: recorder,
+		Loader:   loader,
+		Infomer:  i,
+		consumer: i.ChangeConsumer(),
+		changes:  make(chan model.Change, 100),
+		cancel:   cancel,
+	}
+	go func() { _ = i.Run(ctx, WorkerID, ResyncInterval) }()
+	go func() {
+		for {
+			change, shutdown := h.consumer.WaitChange()
+			if shutdown {
+				close(h.changes)
+				return
+			}
+			h.changes <- change
+		}
+	}()
+	return h
+}
+
+// Want sets the want task, it is assigned to WorkerID if WorkerID is empty.
+func (h *Harness) Want(task *model.Task) {
+	t := copyTask(task)
+	if t.WorkerID == "" {
+		t.WorkerID = WorkerID
+	}
+	h.Recorder.SetWant(t)
+}
+
+// Real sets the real task as if an executor reported it.
+func (h *Harness) Real(task *model.Task) {
+	h.Loader.SetReal(task)
+}
+
+// Resync triggers a reconcile of all runnable tasks immediately,
+// stepping Clock by ResyncInterval also triggers resync.
+func (h *Harness) Resync() {
+	keys, _ := h.Recorder.ListRunnableTasks(context.Background(), WorkerID)
+	h.Recorder.watch <- keys
+}
+
+// NextChange waits for the next emitted change, returns false on timeout.
+func (h *Harness) NextChange(timeout time.Duration) (model.Change, bool) {
+	select {
+	case change, ok := <-h.changes:
+		return change, ok
+	case <-time.After(timeout):
+		return model.Change{}, false
+	}
+}
+
+// Apply simulates executor applying change, the real task turns to status.
+// note that retry of recording real status sleeps on Clock, so recorder
+// errors injected by tests block until Clock is stepped.
+// it returns after infomer has released the change, so that following
+// changes of the task will not be skipped as in-flight.
+func (h *Harness) Apply(change model.Change, status model.TaskStatus) {
+	real := change.Task.Clone()
+	real.Status = status
+	h.Real(real)
+	h.waitReleased(change.TaskKey)
+}
+
+// Jump drops change without applying it.
+func (h *Harness) Jump(change model.Change) {
+	h.consumer.JumpChange(change)
+}
+
+func (h *Harness) waitReleased(taskKey string) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		e, err := h.Infomer.Explain(context.Background(), WorkerID, taskKey)
+		if err == nil && !e.InFlightChange {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Stop stops the infomer.
+func (h *Harness) Stop() {
+	h.cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = h.Infomer.Shutdown(ctx)
+}
@@ -0,0 +1,60 @@
+package sim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestHarness(t *testing.T) {
+	t.Run("创建并暂停任务", func(t *testing.T) {
+		h := New()
+		defer h.Stop()
+
+		h.Want(&model.Task{TaskKey: "a", Type: "sim", Status: model.TaskStatusWaitRunning, WantRunStatus: model.TaskStatusRunning})
+		change, ok := h.NextChange(time.Second)
+		if !ok || change.ChangeType != model.ChangeCreate {
+			t.Fatalf("期望 create 变更, 得到 %+v", change)
+		}
+		h.Apply(change, model.TaskStatusRunning)
+
+		h.Want(&model.Task{TaskKey: "a", Type: "sim", Status: model.TaskStatusWaitPaused, WantRunStatus: model.TaskStatusPaused})
+		change, ok = h.NextChange(time.Second)
+		if !ok || change.ChangeType != model.ChangePause {
+			t.Fatalf("期望 pause 变更, 得到 %+v", change)
+		}
+	})
+
+	t.Run("spec 代数增加产生更新变更", func(t *testing.T) {
+		h := New()
+		defer h.Stop()
+
+		h.Want(&model.Task{TaskKey: "c", Type: "sim", Status: model.TaskStatusWaitRunning, WantRunStatus: model.TaskStatusRunning})
+		change, ok := h.NextChange(time.Second)
+		if !ok {
+			t.Fatal("期望 create 变更")
+		}
+		h.Apply(change, model.TaskStatusRunning)
+
+		h.Want(&model.Task{TaskKey: "c", Type: "sim", Status: model.TaskStatusRunning, WantRunStatus: model.TaskStatusRunning, Generation: 1})
+		change, ok = h.NextChange(time.Second)
+		if !ok || change.ChangeType != model.ChangeUpdate || change.Task.Generation != 1 {
+			t.Fatalf("期望 update 变更, 得到 %+v", change)
+		}
+	})
+
+	t.Run("处理中的任务不会重复产生变更", func(t *testing.T) {
+		h := New()
+		defer h.Stop()
+
+		h.Want(&model.Task{TaskKey: "b", Type: "sim", Status: model.TaskStatusWaitRunning, WantRunStatus: model.TaskStatusRunning})
+		if _, ok := h.NextChange(time.Second); !ok {
+			t.Fatal("期望 create 变更")
+		}
+		h.Resync()
+		if change, ok := h.NextChange(100 * time.Millisecond); ok {
+			t.Fatalf("不应该产生变更, 得到 %+v", change)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package sim
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// Loader is an in-memory loader of real tasks, it plays the role of executors.
+type Loader struct {
+	mu      sync.Mutex
+	tasks   map[string]*model.Task
+	results chan *model.Task
+}
+
+func NewLoader() *Loader {
+	return &Loader{
+		tasks:   make(map[string]*model.Task),
+		results: make(chan *model.Task, 100),
+	}
+}
+
+// SetReal stores real task and reports it as a change result.
+func (l *Loader) SetReal(task *model.Task) {
+	t := copyTask(task)
+	l.mu.Lock()
+	l.tasks[t.TaskKey] = t
+	l.mu.Unlock()
+
+	l.results <- copyTask(t)
+}
+
+func (l *Loader) List(context.Context) ([]*model.Task, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ret := make([]*model.Task, 0, len(l.tasks))
+	for _, t := range l.tasks {
+		ret = append(ret, copyTask(t))
+	}
+	return ret, nil
+}
+
+func (l *Loader) ChangeResult() <-chan *model.Task {
+	return l.results
+}
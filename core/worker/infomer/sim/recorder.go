@@ -0,0 +1,137 @@
+package sim
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+// Recorder is an in-memory recorder of want tasks.
+type Recorder struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	tasks   map[string]*model.Task
+	updates []*model.Task
+	watch   chan []string
+}
+
+func NewRecorder(c clock.Clock) *Recorder {
+	return &Recorder{
+		clock: c,
+		tasks: make(map[string]*model.Task),
+		watch: make(chan []string, 100),
+	}
+}
+
+// SetWant stores want task and notifies watchers of the task.
+func (r *Recorder) SetWant(task *model.Task) {
+	r.mu.Lock()
+	t := copyTask(task)
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = r.clock.Now()
+	}
+	t.UpdatedAt = r.clock.Now()
+	r.tasks[t.TaskKey] = t
+	r.mu.Unlock()
+
+	r.watch <- []string{t.TaskKey}
+}
+
+// Get returns a copy of stored task.
+func (r *Recorder) Get(taskKey string) (*model.Task, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tasks[taskKey]
+	if !ok {
+		return nil, false
+	}
+	return copyTask(t), true
+}
+
+// Updates returns all UpdateTask calls in order.
+func (r *Recorder) Updates() []*model.Task {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*model.Task(nil), r.updates...)
+}
+
+func (r *Recorder) UpdateTask(_ context.Context, task *model.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.update(task)
+	return nil
+}
+
+// BatchUpdateTasks records each task as an UpdateTask call.
+func (r *Recorder) BatchUpdateTasks(_ context.Context, tasks []*model.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, task := range tasks {
+		r.update(task)
+	}
+	return nil
+}
+
+func (r *Recorder) update(task *model.Task) {
+	r.updates = append(r.updates, copyTask(task))
+
+	t, ok := r.tasks[task.TaskKey]
+	if !ok {
+		return
+	}
+	if task.Status != "" {
+		t.Status = task.Status
+	}
+	if task.WantRunStatus != "" {
+		t.WantRunStatus = task.WantRunStatus
+	}
+	if task.Msg != "" {
+		t.Msg = task.Msg
+	}
+	if task.StartedAt != nil {
+		t.StartedAt = task.StartedAt
+	}
+	if task.FinishedAt != nil {
+		t.FinishedAt = task.FinishedAt
+	}
+	t.UpdatedAt = r.clock.Now()
+}
+
+func (r *Recorder) BatchGetTask(_ context.Context, taskKeys []string) ([]*model.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ret := make([]*model.Task, 0, len(taskKeys))
+	for _, key := range taskKeys {
+		if t, ok := r.tasks[key]; ok {
+			ret = append(ret, copyTask(t))
+		}
+	}
+	return ret, nil
+}
+
+func (r *Recorder) ListRunnableTasks(_ context.Context, workerID string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]string, 0, len(r.tasks))
+	for key, t := range r.tasks {
+		if t.WorkerID == workerID && !t.Status.IsFinalStatus() {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (r *Recorder) WatchRunnableTasks(context.Context, string) (<-chan []string, error) {
+	return r.watch, nil
+}
+
+// copyTask copies all fields of task, unlike Task.Clone which only
+// copies the fields of real task.
+func copyTask(t *model.Task) *model.Task {
+	c := *t
+	return &c
+}
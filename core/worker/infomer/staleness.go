@@ -0,0 +1,54 @@
+package infomer
+
+import (
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// markListed records a successful loader.List, the cache may miss changes
+// of real tasks since the last one.
+func (i *Indexer) markListed() {
+	i.listedAt.Store(i.clock.Now().UnixNano())
+}
+
+// CacheStaleness returns time since real tasks are listed successfully the last time.
+func (i *Indexer) CacheStaleness() time.Duration {
+	return i.clock.Since(time.Unix(0, i.listedAt.Load()))
+}
+
+// CacheStale reports whether loader.List kept failing for longer than the stale threshold.
+func (i *Indexer) CacheStale() bool {
+	return i.CacheStaleness() > i.staleAfter
+}
+
+// destructive changes are never emitted from a stale cache, since the
+// executor may have already changed and the change can't be undone.
+var destructiveChanges = map[model.ChangeType]bool{
+	model.ChangeStop:            true,
+	model.ChangeDelete:          true,
+	model.ChangeExceptionFinish: true,
+}
+
+// suppressDestructive drops destructive changes if cache of real tasks is stale.
+func (i *Infomer) suppressDestructive(changes []model.Change) []model.Change {
+	if !i.indexer.CacheStale() {
+		return changes
+	}
+	ret := changes[:0]
+	for _, c := range changes {
+		if !destructiveChanges[c.ChangeType] {
+			ret = append(ret, c)
+			continue
+		}
+		i.suppressed.Add(1, string(c.ChangeType))
+		i.logger.Warn("[Infomer] real tasks are not listed for %s, suppress change: %v", i.indexer.CacheStaleness(), c)
+	}
+	return ret
+}
+
+// CacheStaleness returns time since real tasks are listed successfully the last time,
+// stale is true beyond the stale threshold, destructive changes are suppressed meanwhile.
+func (i *Infomer) CacheStaleness() (staleness time.Duration, stale bool) {
+	return i.indexer.CacheStaleness(), i.indexer.CacheStale()
+}
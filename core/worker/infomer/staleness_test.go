@@ -0,0 +1,67 @@
+package infomer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+type failingLoader struct {
+	benchLoader
+	err error
+}
+
+func (l *failingLoader) List(ctx context.Context) ([]*model.Task, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	return l.benchLoader.List(ctx)
+}
+
+func TestStaleCache(t *testing.T) {
+	c := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	loader := &failingLoader{}
+	indexer := NewIndexer(loader, time.Minute, WithClock(c))
+	i := New(indexer, &benchRecorder{}, log.Global(), WithClock(c))
+	changes := []model.Change{
+		{TaskKey: "a", ChangeType: model.ChangeCreate},
+		{TaskKey: "b", ChangeType: model.ChangeStop},
+		{TaskKey: "c", ChangeType: model.ChangeDelete},
+	}
+
+	t.Run("缓存新鲜时不过滤", func(t *testing.T) {
+		loader.err = errors.New("docker daemon down")
+		c.Step(2 * time.Minute)
+		indexer.refreshCache(context.Background(), make(chan *model.Task, 1))
+		if _, stale := i.CacheStaleness(); stale {
+			t.Fatal("未超过阈值不应过期")
+		}
+		if got := i.suppressDestructive(append([]model.Change(nil), changes...)); len(got) != 3 {
+			t.Errorf("不应过滤变更: %v", got)
+		}
+	})
+
+	t.Run("缓存过期时抑制破坏性变更", func(t *testing.T) {
+		c.Step(2 * time.Minute)
+		indexer.refreshCache(context.Background(), make(chan *model.Task, 1))
+		if staleness, stale := i.CacheStaleness(); !stale || staleness != 4*time.Minute {
+			t.Fatalf("应过期: %s", staleness)
+		}
+		if got := i.suppressDestructive(append([]model.Change(nil), changes...)); len(got) != 1 || got[0].TaskKey != "a" {
+			t.Errorf("应只保留创建变更: %v", got)
+		}
+	})
+
+	t.Run("恢复后不再过期", func(t *testing.T) {
+		loader.err = nil
+		indexer.refreshCache(context.Background(), make(chan *model.Task, 1))
+		if _, stale := i.CacheStaleness(); stale {
+			t.Error("List 成功后不应过期")
+		}
+	})
+}
@@ -2,14 +2,24 @@ package infomer
 
 import (
 	"context"
+	"slices"
+	"sort"
 	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
 )
 
-const defaultResync = 15 * time.Second
+const (
+	defaultResync          = 15 * time.Second
+	defaultTriggerDebounce = 50 * time.Millisecond
+)
 
 type triggerInfo struct {
 	resync   bool
 	taskKeys []string
+	// for resync, only real tasks of due groups are compared, nil means all.
+	isDue func(t *model.Task) bool
 }
 
 // watch trigger event.
@@ -32,31 +42,118 @@ func (i *Infomer) makeTigger(ctx context.Context, workerID string, resync time.D
 	if err != nil {
 		return nil, err
 	}
-	go func() {
-		for keys := range ch {
-			tasksCh <- triggerInfo{resync: false, taskKeys: keys}
-		}
-	}()
+	go i.watch(ctx, workerID, ch, tasksCh)
 
 	// resync task.
 	go func() {
-		ticker := time.NewTicker(resync)
+		// spread resyncs of the fleet over the interval.
+		if !i.sleep(ctx, i.resyncOffset(ctx, workerID, resync)) {
+			return
+		}
+		schedule := newResyncSchedule(resync, i.opts.resyncRules, i.opts.clock.Now())
+		ticker := i.opts.clock.NewTicker(schedule.tick())
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-ticker.C:
+			case now := <-ticker.C():
+				due := schedule.due(now)
+				if due != nil && !slices.Contains(due, true) {
+					continue
+				}
+				if !i.sleep(ctx, randDuration(i.opts.resyncJitter)) {
+					return
+				}
 				keys, err := i.recorder.ListRunnableTasks(context.Background(), workerID)
 				if err != nil {
 					i.logger.Error("[Infomer] monitorChangeWant ListRunnableTasks failed: %v", err)
 					continue
 				}
-				tasksCh <- triggerInfo{resync: true, taskKeys: keys}
+				if due == nil {
+					tasksCh <- triggerInfo{resync: true, taskKeys: keys}
+					continue
+				}
+
+				info, err := i.dueTrigger(ctx, schedule, due, keys)
+				if err != nil {
+					i.logger.Error("[Infomer] resync load tasks failed: %v", err)
+					continue
+				}
+				tasksCh <- info
 			}
 		}
 	}()
 
 	return tasksCh, nil
 }
+
+// dueTrigger keeps runnable tasks of due groups only, want tasks have to
+// be loaded to know their type and labels.
+func (i *Infomer) dueTrigger(ctx context.Context, schedule *resyncSchedule, due []bool, keys []string) (triggerInfo, error) {
+	wants, err := taskrepo.ChunkedBatchGetTask(
+		ctx, i.recorder.BatchGetTask, keys,
+		i.opts.batchGetChunkSize, i.opts.batchGetParallelism,
+	)
+	if err != nil {
+		return triggerInfo{}, err
+	}
+
+	isDue := func(t *model.Task) bool { return due[schedule.group(t)] }
+	dueKeys := make([]string, 0, len(wants))
+	for _, want := range wants {
+		if isDue(want) {
+			dueKeys = append(dueKeys, want.TaskKey)
+		}
+	}
+	return triggerInfo{resync: true, taskKeys: dueKeys, isDue: isDue}, nil
+}
+
+// coalesceKeys merges task keys received within debounce window and
+// emits them as one trigger, keys are deduplicated and sorted.
+func (i *Infomer) coalesceKeys(ctx context.Context, in <-chan []string, out chan<- triggerInfo) {
+	window := i.opts.triggerDebounce
+	if window <= 0 {
+		for keys := range in {
+			out <- triggerInfo{resync: false, taskKeys: keys}
+		}
+		return
+	}
+
+	pending := make(map[string]struct{})
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		keys := make([]string, 0, len(pending))
+		for key := range pending {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		clear(pending)
+		out <- triggerInfo{resync: false, taskKeys: keys}
+	}
+
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case keys, ok := <-in:
+			if !ok {
+				flush()
+				return
+			}
+			for _, key := range keys {
+				pending[key] = struct{}{}
+			}
+			if timer == nil {
+				timer = i.opts.clock.After(window)
+			}
+		case <-timer:
+			timer = nil
+			flush()
+		}
+	}
+}
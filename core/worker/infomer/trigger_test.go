@@ -0,0 +1,58 @@
+package infomer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+func TestCoalesceKeys(t *testing.T) {
+	c := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	i := &Infomer{opts: newOptions(WithClock(c), WithTriggerDebounce(time.Second))}
+
+	in := make(chan []string)
+	out := make(chan triggerInfo, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go i.coalesceKeys(ctx, in, out)
+
+	in <- []string{"b", "a"}
+	in <- []string{"a"}
+	in <- []string{"c", "b"}
+	for !c.HasWaiters() {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case info := <-out:
+		t.Fatalf("窗口未结束不应该触发, 得到 %v", info)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	c.Step(time.Second)
+	select {
+	case info := <-out:
+		if want := []string{"a", "b", "c"}; !reflect.DeepEqual(info.taskKeys, want) {
+			t.Errorf("期望 %v, 得到 %v", want, info.taskKeys)
+		}
+		if info.resync {
+			t.Error("watch 触发不应该是 resync")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("窗口结束后应该触发")
+	}
+
+	// next burst starts a new window.
+	in <- []string{"a"}
+	close(in)
+	select {
+	case info := <-out:
+		if want := []string{"a"}; !reflect.DeepEqual(info.taskKeys, want) {
+			t.Errorf("期望 %v, 得到 %v", want, info.taskKeys)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("输入关闭时应该触发剩余的 key")
+	}
+}
@@ -0,0 +1,34 @@
+package infomer
+
+import (
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// usageRecorder observes resources used by finished tasks reported by
+// executors, so that resource requests of task types can be right-sized.
+type usageRecorder struct {
+	peakCPU    metrics.Histogram
+	avgCPU     metrics.Histogram
+	peakMemory metrics.Histogram
+}
+
+func newUsageRecorder(p metrics.Provider) *usageRecorder {
+	labels := []string{"biz_type", "type"}
+	return &usageRecorder{
+		peakCPU:    p.NewHistogram("minitaskx_task_peak_cpu_cores", "peak cpu cores used by finished tasks", labels...),
+		avgCPU:     p.NewHistogram("minitaskx_task_avg_cpu_cores", "average cpu cores used by finished tasks", labels...),
+		peakMemory: p.NewHistogram("minitaskx_task_peak_memory_gib", "peak memory in GiB used by finished tasks", labels...),
+	}
+}
+
+// observe records usage of t if it finished with usage sampled.
+func (r *usageRecorder) observe(t *model.Task) {
+	if t.Usage == nil || !t.Status.IsFinalStatus() {
+		return
+	}
+	labels := []string{t.BizType, t.Type}
+	r.peakCPU.Observe(t.Usage.PeakCPU, labels...)
+	r.avgCPU.Observe(t.Usage.AvgCPU, labels...)
+	r.peakMemory.Observe(t.Usage.PeakMemory, labels...)
+}
@@ -0,0 +1,110 @@
+package infomer
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+)
+
+const (
+	defaultWatchBackoff    = 100 * time.Millisecond
+	defaultWatchMaxBackoff = 30 * time.Second
+	// tasks updated this long before the watch is lost are repaired too,
+	// since clocks of worker and repo drift and events may be in flight.
+	watchGapSlack = 5 * time.Second
+)
+
+// watch forwards keys of changed tasks to out. once the watch is closed,
+// eg. the repo restarted, it is resubscribed with backoff, then tasks
+// changed in the gap are reconciled without waiting for the next resync.
+func (i *Infomer) watch(ctx context.Context, workerID string, ch <-chan []string, out chan<- triggerInfo) {
+	for {
+		i.coalesceKeys(ctx, ch, out)
+		if ctx.Err() != nil {
+			return
+		}
+
+		lostAt := i.opts.clock.Now()
+		i.logger.Warn("[Infomer] watch of runnable tasks is closed, resubscribe")
+		if ch = i.resubscribe(ctx, workerID); ch == nil {
+			return
+		}
+		i.resubscribes.Add(1)
+		i.logger.Info("[Infomer] watch of runnable tasks is resubscribed after %s, repair the gap", i.opts.clock.Since(lostAt))
+		if err := i.repairGap(ctx, workerID, lostAt, out); err != nil {
+			// the next resync repairs it.
+			i.logger.Error("[Infomer] repair watch gap since %s failed: %v", lostAt, err)
+		}
+	}
+}
+
+// resubscribe watches runnable tasks again until it succeeds, nil if ctx is done.
+func (i *Infomer) resubscribe(ctx context.Context, workerID string) <-chan []string {
+	backoff := i.opts.watchBackoff
+	for {
+		if !i.sleep(ctx, backoff) {
+			return nil
+		}
+		ch, err := i.recorder.WatchRunnableTasks(ctx, workerID)
+		if err == nil {
+			return ch
+		}
+		i.logger.Error("[Infomer] resubscribe watch of runnable tasks failed, retry after %s: %v", backoff, err)
+		backoff = min(backoff*2, i.opts.watchMaxBackoff)
+	}
+}
+
+// repairGap triggers tasks possibly changed since the watch was lost: want
+// tasks updated since then, and real tasks no longer runnable on the worker.
+func (i *Infomer) repairGap(ctx context.Context, workerID string, since time.Time, out chan<- triggerInfo) error {
+	keys, err := i.recorder.ListRunnableTasks(ctx, workerID)
+	if err != nil {
+		return err
+	}
+	// invalidations of the gap are missed.
+	if i.cache != nil {
+		i.cache.invalidate(keys...)
+	}
+	wants, err := taskrepo.ChunkedBatchGetTask(
+		ctx, i.recorder.BatchGetTask, keys,
+		i.opts.batchGetChunkSize, i.opts.batchGetParallelism,
+	)
+	if err != nil {
+		return err
+	}
+
+	changed := make(map[string]struct{})
+	runnable := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		runnable[key] = struct{}{}
+		// purged in the gap.
+		changed[key] = struct{}{}
+	}
+	since = since.Add(-watchGapSlack)
+	for _, want := range wants {
+		if !want.UpdatedAt.IsZero() && want.UpdatedAt.Before(since) {
+			delete(changed, want.TaskKey)
+		}
+	}
+	for _, key := range i.indexer.ListTaskKeys() {
+		if _, ok := runnable[key]; !ok {
+			changed[key] = struct{}{}
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	taskKeys := make([]string, 0, len(changed))
+	for key := range changed {
+		taskKeys = append(taskKeys, key)
+	}
+	sort.Strings(taskKeys)
+	select {
+	case out <- triggerInfo{resync: false, taskKeys: taskKeys}:
+	case <-ctx.Done():
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package infomer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+// watchRecorder fails the first failures watches, then hands out watches.
+type watchRecorder struct {
+	benchRecorder
+	runnable []string
+
+	mu       sync.Mutex
+	failures int
+	watches  []chan []string
+	attempts int
+}
+
+func (r *watchRecorder) ListRunnableTasks(context.Context, string) ([]string, error) {
+	return r.runnable, nil
+}
+
+func (r *watchRecorder) WatchRunnableTasks(context.Context, string) (<-chan []string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	if r.failures > 0 {
+		r.failures--
+		return nil, errors.New("repo unavailable")
+	}
+	ch := make(chan []string)
+	r.watches = append(r.watches, ch)
+	return ch, nil
+}
+
+func TestWatchResubscribe(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := clock.NewFakeClock(now)
+	recorder := &watchRecorder{
+		benchRecorder: benchRecorder{tasks: map[string]*model.Task{
+			"old": {TaskKey: "old", UpdatedAt: now.Add(-time.Hour)},
+			"new": {TaskKey: "new", UpdatedAt: now.Add(-time.Second)},
+		}},
+		runnable: []string{"old", "new"},
+		failures: 1,
+	}
+	loader := &benchLoader{tasks: []*model.Task{{TaskKey: "old"}, {TaskKey: "gone"}}}
+	indexer := NewIndexer(loader, time.Minute, WithClock(c))
+	if err := indexer.initCache(); err != nil {
+		t.Fatal(err)
+	}
+	i := New(indexer, recorder, log.Global(), WithClock(c), WithTriggerDebounce(0), WithWatchBackoff(time.Second, 10*time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	first := make(chan []string)
+	out := make(chan triggerInfo, 10)
+	go i.watch(ctx, "w1", first, out)
+	receive := func(t *testing.T) triggerInfo {
+		select {
+		case info := <-out:
+			return info
+		case <-time.After(time.Second):
+			t.Fatal("应该触发")
+		}
+		return triggerInfo{}
+	}
+
+	t.Run("转发 watch 的变更", func(t *testing.T) {
+		first <- []string{"old"}
+		if info := receive(t); !reflect.DeepEqual(info.taskKeys, []string{"old"}) || info.resync {
+			t.Errorf("期望 [old], 得到 %+v", info)
+		}
+	})
+
+	t.Run("关闭后退避重新订阅并修复间隙", func(t *testing.T) {
+		close(first)
+		attempts := func() int {
+			recorder.mu.Lock()
+			defer recorder.mu.Unlock()
+			return recorder.attempts
+		}
+		for attempts() < 2 {
+			c.Step(time.Second)
+			time.Sleep(time.Millisecond)
+		}
+		// tasks updated in the gap and real tasks no longer runnable.
+		if info := receive(t); !reflect.DeepEqual(info.taskKeys, []string{"gone", "new"}) || info.resync {
+			t.Errorf("期望 [gone new], 得到 %+v", info)
+		}
+		recorder.mu.Lock()
+		watches := recorder.watches
+		recorder.mu.Unlock()
+		if len(watches) != 1 {
+			t.Fatalf("期望订阅失败后重试成功, 得到 %d 个 watch", len(watches))
+		}
+
+		watches[0] <- []string{"new"}
+		if info := receive(t); !reflect.DeepEqual(info.taskKeys, []string{"new"}) {
+			t.Errorf("重新订阅后应转发变更, 得到 %+v", info)
+		}
+	})
+}
@@ -1,6 +1,13 @@
 package worker
 
-import "github.com/xyzbit/minitaskx/core/model"
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/core/worker/executor"
+)
 
 func (w *Worker) generateInstanceMetadata() (map[string]string, error) {
 	metadata := make(map[string]string)
@@ -25,15 +32,42 @@ func (w *Worker) generateInstanceMetadata() (map[string]string, error) {
 	for k, v := range stain {
 		metadata[k] = v
 	}
+	for k, v := range w.labels.metadata() {
+		metadata[k] = v
+	}
 
 	return metadata, nil
 }
 
+// executors returns the registry of executors the worker runs.
+func (w *Worker) executors() *executor.Registry {
+	if w.opts.executorRegistry != nil {
+		return w.opts.executorRegistry
+	}
+	return executor.DefaultRegistry()
+}
+
 // 获取节点描述
 func (w *Worker) generateWorkerDesc() map[string]string {
-	return map[string]string{
-		"worker_id": w.id,
+	desc := map[string]string{
+		"worker_id":            w.id,
+		model.ExecutorTypesKey: strings.Join(w.executors().RegisteredTypes(), ","),
+	}
+	if len(w.opts.namespaces) > 0 {
+		desc[model.NamespacesKey] = strings.Join(w.opts.namespaces, ",")
+	}
+	for taskType, version := range w.opts.executorVersions {
+		desc[model.ExecutorVersionKey(taskType)] = version
+	}
+	for taskType, schema := range w.executors().RegisteredSchemas() {
+		data, err := json.Marshal(schema)
+		if err != nil {
+			log.Error("[Worker] marshal payload schema of %s failed: %v", taskType, err)
+			continue
+		}
+		desc[model.PayloadSchemaKey(taskType)] = string(data)
 	}
+	return desc
 }
 
 func LoadWorkerDesc(metadata map[string]string) map[string]string {
@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/notify"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+const notifyTimeout = 10 * time.Second
+
+// finishNotifier returns a status observer that notify when task turns to one of statuses.
+func finishNotifier(n notify.Interface, statuses []model.TaskStatus) func(task *model.Task) {
+	return func(task *model.Task) {
+		if !slices.Contains(statuses, task.Status) {
+			return
+		}
+
+		level := notify.LevelInfo
+		if task.Status == model.TaskStatusFailed {
+			level = notify.LevelCritical
+		}
+		msg := notify.Message{
+			Level:   level,
+			Title:   fmt.Sprintf("task %s", task.Status),
+			Text:    fmt.Sprintf("task %s(%s) is %s", task.TaskKey, task.BizType, task.Status),
+			TaskKey: task.TaskKey,
+			BizID:   task.BizID,
+			BizType: task.BizType,
+			Status:  task.Status.String(),
+			Msg:     task.Msg,
+		}
+		// observer should not block reconcile.
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			defer cancel()
+			if err := n.Notify(ctx, msg); err != nil {
+				log.Error("[Worker] notify task %s %s failed: %v", task.TaskKey, task.Status, err)
+			}
+		}()
+	}
+}
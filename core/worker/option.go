@@ -3,16 +3,124 @@ package worker
 import (
 	"time"
 
+	"github.com/xyzbit/minitaskx/core/components/attempt"
+	"github.com/xyzbit/minitaskx/core/components/audit"
+	"github.com/xyzbit/minitaskx/core/components/chaos"
+	"github.com/xyzbit/minitaskx/core/components/crash"
+	"github.com/xyzbit/minitaskx/core/components/events"
 	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/notify"
+	"github.com/xyzbit/minitaskx/core/components/sink"
+	"github.com/xyzbit/minitaskx/core/components/tasklog"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/core/worker/executor"
+	"github.com/xyzbit/minitaskx/core/worker/infomer"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
 )
 
 type options struct {
 	reportResourceInterval time.Duration
 	// forced triggering of full task status comparison
 	resync time.Duration
+	// custom diff of want and real tasks, nil uses infomer.DefaultDiffer.
+	differ infomer.Differ
+	// resync intervals per task type or labels.
+	resyncRules []infomer.ResyncRule
+	// max changes handled in one pass.
+	changeBatchSize int
+	// window of coalescing watched task keys.
+	triggerDebounce time.Duration
+	// backoff of resubscribing closed watch, 0 uses defaults of infomer.
+	watchBackoff    time.Duration
+	watchMaxBackoff time.Duration
 
 	shutdownTimeout time.Duration
 	logger          log.Logger
+
+	// chunk size and parallelism of loading want tasks from repo.
+	batchGetChunkSize   int
+	batchGetParallelism int
+
+	// optional analytics sink of finished tasks.
+	taskSink sink.Interface
+
+	// notify when task turns to notifyStatuses.
+	notifier       notify.Interface
+	notifyStatuses []model.TaskStatus
+
+	// run attempts of tasks, optional.
+	attemptRepo attempt.Interface
+
+	// record privileged operations on worker.
+	auditor audit.Interface
+
+	// events of tasks, optional.
+	eventRecorder events.Interface
+
+	// time source of resync, cache recycle and retry.
+	clock clock.Clock
+
+	// fault injection for staging, nil disables it.
+	chaos *chaos.Config
+
+	// bound of each repo call, zero means only deadlines of ctx apply.
+	repoTimeouts taskrepo.Timeouts
+
+	// max status updates buffered in read-only mode.
+	readOnlyBufferSize int
+
+	// captured stdout/stderr of executors served by GetTaskLogs, optional.
+	logSink tasklog.Interface
+
+	// executor version per task type reported to scheduler.
+	executorVersions map[string]string
+	// namespaces the worker is dedicated to, empty means shared.
+	namespaces []string
+
+	// running task without heartbeat within it is flagged stale, 0 disables it.
+	heartbeatTimeout time.Duration
+
+	// rate limits of executor starts, nil/empty disables them.
+	startLimit        *StartLimit
+	startLimitPerType map[string]StartLimit
+
+	// evict low priority tasks under resource pressure, nil disables it.
+	eviction *EvictionPolicy
+
+	// spread resyncs of the fleet over the interval.
+	resyncSplay   time.Duration
+	resyncJitter  time.Duration
+	resyncSlotter infomer.ResyncSlotter
+
+	// cache want tasks read from repo, 0 disables it.
+	recorderCacheTTL time.Duration
+
+	// persist status changes in batches of at most the size, 0 disables it.
+	batchUpdateSize int
+
+	// limits of changes waiting for executors, nil means unbounded.
+	changeQueueBounds *infomer.ChangeQueueBounds
+
+	// real tasks are stale if listing them from executors kept failing longer than it.
+	staleCacheThreshold time.Duration
+
+	// tasks finished on one side are left to the system at most this long, 0 means forever.
+	autoFinishGrace time.Duration
+
+	// reports panics recovered by goroutines of worker, infomer and executors.
+	crashReporter crash.Reporter
+
+	// tasks not started within it are failed, 0 disables it.
+	startTimeout time.Duration
+
+	// executors run by the worker, nil means executor.DefaultRegistry.
+	executorRegistry *executor.Registry
+
+	// runtime labels overriding detected ones, eg. the zone of an on-premise host.
+	runtimeLabels map[string]string
+	// skip reading the zone from cloud metadata.
+	noZoneDetection bool
 }
 
 type Option func(o *options)
@@ -41,13 +149,302 @@ func WithTriggerResync(interval time.Duration) Option {
 	}
 }
 
+// WithAttemptRepo records each run of tasks as an attempt.
+func WithAttemptRepo(repo attempt.Interface) Option {
+	return func(o *options) {
+		o.attemptRepo = repo
+	}
+}
+
+// WithLogSink serves logs captured by executors through GetTaskLogs,
+// it should be the same sink passed to executors.
+func WithLogSink(sink tasklog.Interface) Option {
+	return func(o *options) {
+		o.logSink = sink
+	}
+}
+
+// WithExecutorVersion reports version of the executor of taskType,
+// scheduler routes canary tasks by it.
+func WithExecutorVersion(taskType, version string) Option {
+	return func(o *options) {
+		if o.executorVersions == nil {
+			o.executorVersions = make(map[string]string)
+		}
+		o.executorVersions[taskType] = version
+	}
+}
+
+// WithNamespaces dedicates the worker to tasks of namespaces, see
+// model.LabelNamespace, scheduler assigns it no task of other namespaces.
+func WithNamespaces(namespaces ...string) Option {
+	return func(o *options) {
+		o.namespaces = append(o.namespaces, namespaces...)
+	}
+}
+
+// WithHeartbeatTimeout flags running tasks whose executor has not reported
+// heartbeat within timeout, see Worker.StaleTasks.
+func WithHeartbeatTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.heartbeatTimeout = timeout
+	}
+}
+
+// WithClock replace the wall clock, mostly used by tests with clock.FakeClock.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithChaos injects faults into recorder, loader and change handling.
+// only for validating executors and recovery paths in staging.
+func WithChaos(cfg chaos.Config) Option {
+	return func(o *options) {
+		o.chaos = &cfg
+	}
+}
+
+// WithRepoTimeouts bounds each read and write of task repo,
+// so that a stalled database can not block reconciling forever.
+func WithRepoTimeouts(t taskrepo.Timeouts) Option {
+	return func(o *options) {
+		o.repoTimeouts = t
+	}
+}
+
+// WithReadOnlyBuffer limits status updates buffered while the worker is read-only,
+// updates beyond size fail and are retried by the next resync.
+func WithReadOnlyBuffer(size int) Option {
+	return func(o *options) {
+		o.readOnlyBufferSize = size
+	}
+}
+
+// WithAuditor record force operations of worker to auditor.
+func WithAuditor(auditor audit.Interface) Option {
+	return func(o *options) {
+		o.auditor = auditor
+	}
+}
+
+// WithEventRecorder emits Started, Finished and Evicted events of tasks to recorder.
+func WithEventRecorder(recorder events.Interface) Option {
+	return func(o *options) {
+		o.eventRecorder = recorder
+	}
+}
+
+// WithDiffer customizes how want/real task pairs translate into changes.
+func WithDiffer(d infomer.Differ) Option {
+	return func(o *options) {
+		o.differ = d
+	}
+}
+
+// WithResyncRules overrides the resync interval of tasks matching rules.
+func WithResyncRules(rules ...infomer.ResyncRule) Option {
+	return func(o *options) {
+		o.resyncRules = append(o.resyncRules, rules...)
+	}
+}
+
+// WithResyncJitter delays the first resync by a random duration within splay,
+// and each following resync by a random duration within jitter, so that
+// resyncs of workers sharing one repo do not spike together.
+func WithResyncJitter(splay, jitter time.Duration) Option {
+	return func(o *options) {
+		o.resyncSplay = splay
+		o.resyncJitter = jitter
+	}
+}
+
+// WithResyncSlotter staggers resyncs of workers by slots assigned by s, eg. the repo.
+func WithResyncSlotter(s infomer.ResyncSlotter) Option {
+	return func(o *options) {
+		o.resyncSlotter = s
+	}
+}
+
+// WithRecorderCache caches want tasks read from repo for at most ttl,
+// so that frequent reconciles do not reload unchanged tasks.
+// cached tasks are invalidated by repo watch events and updates of the worker.
+func WithRecorderCache(ttl time.Duration) Option {
+	return func(o *options) {
+		o.recorderCacheTTL = ttl
+	}
+}
+
+// WithChangeQueueBounds limits changes waiting for executors, changes over
+// bounds block diffing, or are dropped and found again by the next resync.
+func WithChangeQueueBounds(b infomer.ChangeQueueBounds) Option {
+	return func(o *options) {
+		o.changeQueueBounds = &b
+	}
+}
+
+// WithStaleCacheThreshold treats real tasks as stale once listing them from
+// executors kept failing longer than threshold, the worker stops and deletes
+// no executor and reports unhealthy meanwhile. default 3 resync intervals.
+func WithStaleCacheThreshold(threshold time.Duration) Option {
+	return func(o *options) {
+		o.staleCacheThreshold = threshold
+	}
+}
+
+// WithAutoFinishGrace reports the finish of an executor again if it is not
+// recorded within grace, and exits executors of tasks finished by others,
+// eg. force finished, after grace. tasks left to the system are recorded as
+// AutoFinished events if WithEventRecorder is set. default 0 leaves them forever.
+func WithAutoFinishGrace(grace time.Duration) Option {
+	return func(o *options) {
+		o.autoFinishGrace = grace
+	}
+}
+
+// WithCrashReporter reports panics recovered by goroutines of worker, infomer
+// and executors to r, eg. sentry.New. panics are always logged, changes
+// panicked in executors fail their tasks, and loops panicked are run again.
+func WithCrashReporter(r crash.Reporter) Option {
+	return func(o *options) {
+		o.crashReporter = r
+	}
+}
+
+// WithBatchUpdate persists up to size task status changes reported together
+// in one repo round-trip instead of one UpdateTask per task.
+func WithBatchUpdate(size int) Option {
+	return func(o *options) {
+		o.batchUpdateSize = size
+	}
+}
+
+// WithChangeBatch handles up to size changes of different tasks in one pass,
+// so that executors of a burst of tasks are started concurrently.
+func WithChangeBatch(size int) Option {
+	return func(o *options) {
+		o.changeBatchSize = size
+	}
+}
+
+// WithStartRateLimit limits how fast executors of all task types are started.
+func WithStartRateLimit(limit StartLimit) Option {
+	return func(o *options) {
+		o.startLimit = &limit
+	}
+}
+
+// WithTaskTypeStartRateLimit limits how fast executors of taskType are started,
+// in addition to the limit of WithStartRateLimit.
+func WithTaskTypeStartRateLimit(taskType string, limit StartLimit) Option {
+	return func(o *options) {
+		if o.startLimitPerType == nil {
+			o.startLimitPerType = make(map[string]StartLimit)
+		}
+		o.startLimitPerType[taskType] = limit
+	}
+}
+
+// WithEviction pauses or stops the lowest priority running tasks
+// while resource usage of worker exceeds thresholds of policy.
+func WithEviction(policy EvictionPolicy) Option {
+	return func(o *options) {
+		o.eviction = &policy
+	}
+}
+
+func WithTriggerDebounce(window time.Duration) Option {
+	return func(o *options) {
+		o.triggerDebounce = window
+	}
+}
+
+// WithWatchBackoff sets backoff of resubscribing the watch of runnable tasks
+// once it is closed, doubled from initial up to max.
+func WithWatchBackoff(initial, max time.Duration) Option {
+	return func(o *options) {
+		o.watchBackoff = initial
+		o.watchMaxBackoff = max
+	}
+}
+
+func WithBatchGetChunk(size, parallelism int) Option {
+	return func(o *options) {
+		o.batchGetChunkSize = size
+		o.batchGetParallelism = parallelism
+	}
+}
+
+// WithTaskSink streams finished task records to a OLAP sink, eg. ClickHouse.
+func WithTaskSink(s sink.Interface) Option {
+	return func(o *options) {
+		o.taskSink = s
+	}
+}
+
+// WithNotifier notify when task turns to one of statuses, default failed.
+// use notify.Router to config notifiers and templates per biz type.
+func WithNotifier(n notify.Interface, statuses ...model.TaskStatus) Option {
+	return func(o *options) {
+		o.notifier = n
+		if len(statuses) == 0 {
+			statuses = []model.TaskStatus{model.TaskStatusFailed}
+		}
+		o.notifyStatuses = statuses
+	}
+}
+
+// WithStartTimeout fails tasks whose executors do not start within timeout,
+// that is Run does not return or no status of the task is reported, so that
+// retry policies apply and later changes of the task are not blocked.
+// the executor is exited once the failure is observed. default 0 waits forever.
+func WithStartTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.startTimeout = timeout
+	}
+}
+
+// WithExecutorRegistry runs executors registered in r instead of those
+// registered by package level functions of executor, so that workers
+// embedded in one process run different task types, see Group.
+func WithExecutorRegistry(r *executor.Registry) Option {
+	return func(o *options) {
+		o.executorRegistry = r
+	}
+}
+
+// WithRuntimeLabel publishes runtime label key=value, overriding the
+// detected one, see model.RuntimeLabelPrefix.
+func WithRuntimeLabel(key, value string) Option {
+	return func(o *options) {
+		if o.runtimeLabels == nil {
+			o.runtimeLabels = make(map[string]string)
+		}
+		o.runtimeLabels[key] = value
+	}
+}
+
+// WithoutZoneDetection skips reading the zone from metadata services of
+// clouds, eg. on premise, the zone may be set by WithRuntimeLabel.
+func WithoutZoneDetection() Option {
+	return func(o *options) {
+		o.noZoneDetection = true
+	}
+}
+
 func newOptions(opts ...Option) *options {
 	// set default
 	o := options{
 		logger:                 log.Global(),
 		reportResourceInterval: 10 * time.Second,
 		resync:                 15 * time.Second,
+		triggerDebounce:        50 * time.Millisecond,
+		changeBatchSize:        1,
+		clock:                  clock.RealClock{},
 		shutdownTimeout:        180 * time.Second,
+		batchGetChunkSize:      taskrepo.DefaultBatchGetChunkSize,
+		batchGetParallelism:    taskrepo.DefaultBatchGetParallelism,
 	}
 	for _, opt := range opts {
 		opt(&o)
@@ -0,0 +1,145 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/core/worker/executor"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+// probeTick is the resolution of probe periods.
+const probeTick = time.Second
+
+type probeState struct {
+	// when the current executor was first seen running.
+	startedAt time.Time
+	// startup probe succeeded, or not declared.
+	started  bool
+	failures int
+	nextAt   time.Time
+	inflight bool
+}
+
+// prober runs startup and liveness probes of running tasks,
+// and fails or restarts tasks whose probes keep failing.
+type prober struct {
+	manager *executor.Manager
+	clock   clock.Clock
+	logger  log.Logger
+
+	mu     sync.Mutex
+	states map[string]*probeState
+}
+
+func newProber(manager *executor.Manager, c clock.Clock, logger log.Logger) *prober {
+	return &prober{
+		manager: manager,
+		clock:   c,
+		logger:  logger,
+		states:  make(map[string]*probeState),
+	}
+}
+
+func (p *prober) Run(ctx context.Context) {
+	ticker := p.clock.NewTicker(probeTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+
+		tasks, err := p.manager.List(ctx)
+		if err != nil {
+			p.logger.Error("[Worker] probe list tasks failed: %v", err)
+			continue
+		}
+		p.probeDue(ctx, tasks)
+	}
+}
+
+func (p *prober) probeDue(ctx context.Context, tasks []*model.Task) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock.Now()
+	alive := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		if task.Probes == nil || task.Status != model.TaskStatusRunning || p.manager.IsRestarting(task.TaskKey) {
+			continue
+		}
+		alive[task.TaskKey] = true
+
+		st, ok := p.states[task.TaskKey]
+		if !ok {
+			st = &probeState{startedAt: now, started: task.Probes.Startup == nil}
+			p.states[task.TaskKey] = st
+		}
+		spec := p.currentSpec(task, st)
+		if spec == nil || st.inflight || now.Before(st.startedAt.Add(spec.InitialDelay())) || now.Before(st.nextAt) {
+			continue
+		}
+
+		st.inflight = true
+		st.nextAt = now.Add(spec.Period())
+		go p.probe(ctx, task, spec)
+	}
+	// forget tasks no longer running, eg. finished or restarted.
+	for key := range p.states {
+		if !alive[key] {
+			delete(p.states, key)
+		}
+	}
+}
+
+func (p *prober) currentSpec(task *model.Task, st *probeState) *model.ProbeSpec {
+	if !st.started {
+		return task.Probes.Startup
+	}
+	return task.Probes.Liveness
+}
+
+func (p *prober) probe(ctx context.Context, task *model.Task, spec *model.ProbeSpec) {
+	err := p.manager.Probe(ctx, task, spec)
+
+	p.mu.Lock()
+	st, ok := p.states[task.TaskKey]
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+	st.inflight = false
+	if err == nil {
+		st.failures = 0
+		st.started = true
+		p.mu.Unlock()
+		return
+	}
+	st.failures++
+	failures := st.failures
+	if failures >= spec.Threshold() {
+		// the next executor starts with fresh state.
+		delete(p.states, task.TaskKey)
+	}
+	p.mu.Unlock()
+
+	p.logger.Warn("[Worker] task %s probe failed(%d/%d): %v", task.TaskKey, failures, spec.Threshold(), err)
+	if failures < spec.Threshold() {
+		return
+	}
+
+	if task.Probes.OnFailure == model.ProbeActionRestart {
+		err = p.manager.Restart(task)
+	} else {
+		err = p.manager.Fail(task)
+	}
+	if err != nil {
+		p.logger.Error("[Worker] task %s handle probe failure failed: %v", task.TaskKey, err)
+	}
+}
@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/core/worker/executor"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+type probeExecutor struct {
+	executor.Interface
+	task  *model.Task
+	exits atomic.Int32
+}
+
+func (e *probeExecutor) List(context.Context) ([]*model.Task, error) {
+	return []*model.Task{e.task}, nil
+}
+
+func (e *probeExecutor) Exit(string) error {
+	e.exits.Add(1)
+	return nil
+}
+
+func TestProberFailsTaskAfterThreshold(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	exe := &probeExecutor{task: &model.Task{
+		TaskKey: "t1",
+		Type:    "probe-test",
+		Status:  model.TaskStatusRunning,
+		Probes: &model.Probes{
+			Liveness:  &model.ProbeSpec{HTTPGet: srv.URL, PeriodSeconds: 1, FailureThreshold: 2},
+			OnFailure: model.ProbeActionFail,
+		},
+	}}
+	executor.RegisterExecutor("probe-test", exe)
+
+	c := clock.NewFakeClock(time.Now())
+	p := newProber(&executor.Manager{}, c, log.Global())
+	round := func() {
+		tasks, _ := p.manager.List(context.Background())
+		p.probeDue(context.Background(), tasks)
+		// wait for the probe goroutine.
+		for i := 0; i < 100; i++ {
+			p.mu.Lock()
+			st := p.states["t1"]
+			inflight := st != nil && st.inflight
+			p.mu.Unlock()
+			if !inflight {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		c.Step(time.Second)
+	}
+
+	round()
+	healthy.Store(false)
+	round()
+	if got := exe.exits.Load(); got != 0 {
+		t.Fatalf("exits = %d before threshold", got)
+	}
+	round()
+	// action runs after the probe state is released.
+	for i := 0; i < 100 && exe.exits.Load() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := exe.exits.Load(); got != 1 {
+		t.Fatalf("exits = %d, want 1 after threshold", got)
+	}
+}
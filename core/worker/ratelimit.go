@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// StartLimit is a token bucket of executor starts, Rate starts per second
+// on average and at most Burst at once.
+type StartLimit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l StartLimit) limiter() *rate.Limiter {
+	burst := l.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(l.Rate), burst)
+}
+
+// startLimiter throttles ChangeCreate, so that a backlog drain does not
+// start hundreds of executors in the same second.
+type startLimiter struct {
+	global *rate.Limiter
+	// task type <==> limiter.
+	perType map[string]*rate.Limiter
+}
+
+// newStartLimiter returns nil if no limit is configured.
+func newStartLimiter(global *StartLimit, perType map[string]StartLimit) *startLimiter {
+	if global == nil && len(perType) == 0 {
+		return nil
+	}
+	l := &startLimiter{perType: make(map[string]*rate.Limiter, len(perType))}
+	if global != nil {
+		l.global = global.limiter()
+	}
+	for taskType, limit := range perType {
+		l.perType[taskType] = limit.limiter()
+	}
+	return l
+}
+
+// Wait blocks until a task of taskType is allowed to start.
+func (l *startLimiter) Wait(ctx context.Context, taskType string) error {
+	if tl, ok := l.perType[taskType]; ok {
+		if err := tl.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if l.global != nil {
+		return l.global.Wait(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStartLimiter(t *testing.T) {
+	t.Run("未配置时不限制", func(t *testing.T) {
+		if newStartLimiter(nil, nil) != nil {
+			t.Fatal("期望 nil")
+		}
+	})
+
+	t.Run("按任务类型限制", func(t *testing.T) {
+		l := newStartLimiter(nil, map[string]StartLimit{"slow": {Rate: 0.001, Burst: 1}})
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		if err := l.Wait(ctx, "slow"); err != nil {
+			t.Fatalf("burst 内不应等待: %v", err)
+		}
+		if err := l.Wait(ctx, "slow"); err == nil {
+			t.Fatal("超出 burst 应被限制")
+		}
+		if err := l.Wait(ctx, "fast"); err != nil {
+			t.Fatalf("其他类型不应被限制: %v", err)
+		}
+	})
+
+	t.Run("全局限制", func(t *testing.T) {
+		l := newStartLimiter(&StartLimit{Rate: 0.001, Burst: 2}, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		for _, taskType := range []string{"a", "b"} {
+			if err := l.Wait(ctx, taskType); err != nil {
+				t.Fatalf("burst 内不应等待: %v", err)
+			}
+		}
+		if err := l.Wait(ctx, "c"); err == nil {
+			t.Fatal("超出全局 burst 应被限制")
+		}
+	})
+}
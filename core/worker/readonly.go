@@ -0,0 +1,183 @@
+package worker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+const defaultReadOnlyBufferSize = 10000
+
+var (
+	// ErrReadOnly is returned by writes other than status updates in read-only mode.
+	ErrReadOnly = errors.New("worker is read-only")
+	// ErrReadOnlyBufferFull is returned once buffered status updates reach the limit.
+	ErrReadOnlyBufferFull = errors.New("read-only buffer is full")
+)
+
+// readOnlyRepo buffers status updates of the worker in memory while read-only,
+// eg. during a planned database maintenance, and flushes them in order once
+// writes are enabled again. reads are passed through.
+type readOnlyRepo struct {
+	taskrepo.Interface
+
+	mu       sync.Mutex
+	readOnly bool
+	buffer   []*model.Task
+	limit    int
+	buffered metrics.Gauge
+}
+
+func newReadOnlyRepo(repo taskrepo.Interface, limit int, p metrics.Provider) *readOnlyRepo {
+	if limit <= 0 {
+		limit = defaultReadOnlyBufferSize
+	}
+	return &readOnlyRepo{
+		Interface: repo,
+		limit:     limit,
+		buffered:  p.NewGauge("minitaskx_worker_buffered_updates", "status updates buffered while worker is read-only"),
+	}
+}
+
+func (r *readOnlyRepo) UpdateTask(ctx context.Context, task *model.Task) error {
+	if buffered, err := r.hold(task); buffered || err != nil {
+		return err
+	}
+	return r.Interface.UpdateTask(ctx, task)
+}
+
+func (r *readOnlyRepo) BatchUpdateTasks(ctx context.Context, tasks []*model.Task) error {
+	if buffered, err := r.hold(tasks...); buffered || err != nil {
+		return err
+	}
+	return r.Interface.BatchUpdateTasks(ctx, tasks)
+}
+
+func (r *readOnlyRepo) UpdateTaskStatusCAS(ctx context.Context, taskKey string, from, to model.TaskStatus) (bool, error) {
+	if r.isReadOnly() {
+		return false, ErrReadOnly
+	}
+	return r.Interface.UpdateTaskStatusCAS(ctx, taskKey, from, to)
+}
+
+func (r *readOnlyRepo) UpdateTaskCAS(ctx context.Context, from model.TaskStatus, task *model.Task) (bool, error) {
+	if r.isReadOnly() {
+		return false, ErrReadOnly
+	}
+	return r.Interface.UpdateTaskCAS(ctx, from, task)
+}
+
+func (r *readOnlyRepo) BatchUpdateTasksCAS(ctx context.Context, from []model.TaskStatus, tasks []*model.Task) (bool, error) {
+	if r.isReadOnly() {
+		return false, ErrReadOnly
+	}
+	return r.Interface.BatchUpdateTasksCAS(ctx, from, tasks)
+}
+
+func (r *readOnlyRepo) UpdateTaskGenerationCAS(ctx context.Context, from int64, task *model.Task) (bool, error) {
+	if r.isReadOnly() {
+		return false, ErrReadOnly
+	}
+	return r.Interface.UpdateTaskGenerationCAS(ctx, from, task)
+}
+
+func (r *readOnlyRepo) isReadOnly() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.readOnly
+}
+
+// hold buffers tasks if read-only, buffered is false if tasks should be written.
+func (r *readOnlyRepo) hold(tasks ...*model.Task) (buffered bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.readOnly {
+		return false, nil
+	}
+	if len(r.buffer)+len(tasks) > r.limit {
+		return true, ErrReadOnlyBufferFull
+	}
+	for _, task := range tasks {
+		cp := *task
+		r.buffer = append(r.buffer, &cp)
+	}
+	r.buffered.Set(float64(len(r.buffer)))
+	return true, nil
+}
+
+func (r *readOnlyRepo) setReadOnly() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readOnly = true
+}
+
+// flush writes buffered updates in order and leaves read-only mode once
+// the buffer is empty, it stays read-only if any write fails.
+func (r *readOnlyRepo) flush(ctx context.Context) error {
+	for {
+		r.mu.Lock()
+		if len(r.buffer) == 0 {
+			r.readOnly = false
+			r.mu.Unlock()
+			return nil
+		}
+		n := min(len(r.buffer), taskrepo.DefaultBatchGetChunkSize)
+		batch := r.buffer[:n]
+		r.mu.Unlock()
+
+		if err := r.write(ctx, batch); err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		r.buffer = r.buffer[n:]
+		r.buffered.Set(float64(len(r.buffer)))
+		r.mu.Unlock()
+	}
+}
+
+func (r *readOnlyRepo) write(ctx context.Context, batch []*model.Task) error {
+	err := r.Interface.BatchUpdateTasks(ctx, batch)
+	if !errors.Is(err, taskrepo.ErrTaskNotFound) {
+		return errors.WithStack(err)
+	}
+	// the batch is rolled back by a purged task, skip it.
+	for _, task := range batch {
+		if err := r.Interface.UpdateTask(ctx, task); err != nil && !errors.Is(err, taskrepo.ErrTaskNotFound) {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func (r *readOnlyRepo) pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.buffer)
+}
+
+// SetReadOnly buffers status updates of the worker in memory instead of writing
+// them to repo, executors keep running and being monitored. disabling flushes
+// buffered updates in order, the worker stays read-only if flushing fails.
+func (w *Worker) SetReadOnly(ctx context.Context, readOnly bool) error {
+	if readOnly {
+		w.readOnly.setReadOnly()
+		w.opts.logger.Warn("[Worker] read-only enabled, status updates are buffered")
+		return nil
+	}
+	if err := w.readOnly.flush(ctx); err != nil {
+		return errors.Wrapf(err, "flush buffered updates, %d left", w.readOnly.pending())
+	}
+	w.opts.logger.Info("[Worker] read-only disabled, buffered updates are flushed")
+	return nil
+}
+
+// ReadOnly reports whether the worker is read-only and the number of buffered updates.
+func (w *Worker) ReadOnly() (readOnly bool, buffered int) {
+	return w.readOnly.isReadOnly(), w.readOnly.pending()
+}
@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type writeRecorder struct {
+	taskrepo.Interface
+	written []string
+	fail    bool
+}
+
+func (r *writeRecorder) UpdateTask(_ context.Context, task *model.Task) error {
+	return r.BatchUpdateTasks(context.Background(), []*model.Task{task})
+}
+
+func (r *writeRecorder) BatchUpdateTasks(_ context.Context, tasks []*model.Task) error {
+	if r.fail {
+		return errors.New("db maintenance")
+	}
+	for _, task := range tasks {
+		r.written = append(r.written, task.TaskKey+":"+string(task.Status))
+	}
+	return nil
+}
+
+func TestReadOnlyRepo(t *testing.T) {
+	ctx := context.Background()
+	inner := &writeRecorder{}
+	r := newReadOnlyRepo(inner, 2, metrics.Global())
+	r.setReadOnly()
+
+	t.Run("只读时缓存更新", func(t *testing.T) {
+		if err := r.UpdateTask(ctx, &model.Task{TaskKey: "a", Status: model.TaskStatusRunning}); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.UpdateTask(ctx, &model.Task{TaskKey: "a", Status: model.TaskStatusSuccess}); err != nil {
+			t.Fatal(err)
+		}
+		if len(inner.written) != 0 || r.pending() != 2 {
+			t.Errorf("期望缓存 2 个更新, 写入 %v", inner.written)
+		}
+	})
+
+	t.Run("超过上限返回错误", func(t *testing.T) {
+		err := r.UpdateTask(ctx, &model.Task{TaskKey: "b", Status: model.TaskStatusRunning})
+		if !errors.Is(err, ErrReadOnlyBufferFull) {
+			t.Errorf("期望 ErrReadOnlyBufferFull, 得到 %v", err)
+		}
+	})
+
+	t.Run("回写失败保持只读", func(t *testing.T) {
+		inner.fail = true
+		if err := r.flush(ctx); err == nil {
+			t.Fatal("期望回写失败")
+		}
+		if !r.isReadOnly() || r.pending() != 2 {
+			t.Errorf("期望保持只读并保留缓存")
+		}
+	})
+
+	t.Run("按顺序回写并退出只读", func(t *testing.T) {
+		inner.fail = false
+		if err := r.flush(ctx); err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"a:running", "a:success"}
+		if len(inner.written) != 2 || inner.written[0] != want[0] || inner.written[1] != want[1] {
+			t.Errorf("期望 %v, 得到 %v", want, inner.written)
+		}
+		if r.isReadOnly() {
+			t.Errorf("期望退出只读")
+		}
+	})
+}
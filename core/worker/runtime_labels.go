@@ -0,0 +1,191 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/mem"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// max time of probing cloud metadata for the zone, probes of other clouds
+// fail fast or time out on a host not of the cloud.
+const zoneProbeTimeout = 2 * time.Second
+
+// runtimeLabels are labels of the environment published by the worker, see
+// model.RuntimeLabelPrefix. the zone is detected in background and published
+// by the next resource report.
+type runtimeLabels struct {
+	mu     sync.RWMutex
+	labels map[string]string
+}
+
+// newRuntimeLabels detects labels of the host, labels configured override them.
+func newRuntimeLabels(configured map[string]string) *runtimeLabels {
+	labels := detectRuntimeLabels()
+	for k, v := range configured {
+		labels[k] = v
+	}
+	return &runtimeLabels{labels: labels}
+}
+
+func (l *runtimeLabels) get(key string) string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.labels[key]
+}
+
+func (l *runtimeLabels) set(key, value string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.labels[key] = value
+}
+
+// metadata returns labels as metadata of the worker instance.
+func (l *runtimeLabels) metadata() map[string]string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	ret := make(map[string]string, len(l.labels))
+	for k, v := range l.labels {
+		ret[model.RuntimeLabelPrefix+k] = v
+	}
+	return ret
+}
+
+// detectZone sets the zone read from cloud metadata, unless it's configured.
+func (l *runtimeLabels) detectZone(ctx context.Context, m cloudMetadata) {
+	if l.get(model.RuntimeLabelZone) != "" {
+		return
+	}
+	if zone := m.zone(ctx); zone != "" {
+		l.set(model.RuntimeLabelZone, zone)
+	}
+}
+
+func detectRuntimeLabels() map[string]string {
+	labels := map[string]string{
+		model.RuntimeLabelOS:   runtime.GOOS,
+		model.RuntimeLabelArch: runtime.GOARCH,
+		model.RuntimeLabelCPUs: strconv.Itoa(runtime.NumCPU()),
+		model.RuntimeLabelGPU:  "false",
+	}
+	if vm, err := mem.VirtualMemory(); err == nil {
+		labels[model.RuntimeLabelMemory] = strconv.FormatUint(vm.Total>>30, 10)
+	}
+	if gpus := countGPUs(); gpus > 0 {
+		labels[model.RuntimeLabelGPU] = "true"
+		labels[model.RuntimeLabelGPUs] = strconv.Itoa(gpus)
+	}
+	return labels
+}
+
+// countGPUs counts nvidia gpus by the driver, 0 if there is no driver.
+func countGPUs() int {
+	if entries, err := os.ReadDir("/proc/driver/nvidia/gpus"); err == nil && len(entries) > 0 {
+		return len(entries)
+	}
+	n := 0
+	for ; ; n++ {
+		if _, err := os.Stat("/dev/nvidia" + strconv.Itoa(n)); err != nil {
+			return n
+		}
+	}
+}
+
+// cloudMetadata reads the zone from metadata services of clouds, urls are
+// replaced by tests.
+type cloudMetadata struct {
+	client *http.Client
+	// base urls of aws, gcp and azure.
+	aws, gcp, azure string
+}
+
+func defaultCloudMetadata() cloudMetadata {
+	return cloudMetadata{
+		client: &http.Client{Timeout: zoneProbeTimeout},
+		aws:    "http://169.254.169.254",
+		gcp:    "http://metadata.google.internal",
+		azure:  "http://169.254.169.254",
+	}
+}
+
+// zone probes clouds concurrently and returns the first zone found, empty
+// if the host is on none of them.
+func (m cloudMetadata) zone(ctx context.Context) string {
+	ctx, cancel := context.WithTimeout(ctx, zoneProbeTimeout)
+	defer cancel()
+	probes := []func(context.Context) string{m.awsZone, m.gcpZone, m.azureZone}
+	zones := make(chan string, len(probes))
+	for _, probe := range probes {
+		go func() { zones <- probe(ctx) }()
+	}
+	for range probes {
+		if zone := <-zones; zone != "" {
+			return zone
+		}
+	}
+	return ""
+}
+
+// awsZone reads the zone by IMDSv2, eg. us-east-1a.
+func (m cloudMetadata) awsZone(ctx context.Context) string {
+	token := m.get(ctx, http.MethodPut, m.aws+"/latest/api/token", "X-aws-ec2-metadata-token-ttl-seconds", "60")
+	if token == "" {
+		return ""
+	}
+	return m.get(ctx, http.MethodGet, m.aws+"/latest/meta-data/placement/availability-zone", "X-aws-ec2-metadata-token", token)
+}
+
+// gcpZone reads the zone, eg. projects/123/zones/us-central1-a is us-central1-a.
+func (m cloudMetadata) gcpZone(ctx context.Context) string {
+	zone := m.get(ctx, http.MethodGet, m.gcp+"/computeMetadata/v1/instance/zone", "Metadata-Flavor", "Google")
+	if zone == "" {
+		return ""
+	}
+	return path.Base(zone)
+}
+
+// azureZone reads the location and zone, eg. eastus-1, the location if the
+// vm is not in a zone.
+func (m cloudMetadata) azureZone(ctx context.Context) string {
+	const query = "?api-version=2021-02-01&format=text"
+	location := m.get(ctx, http.MethodGet, m.azure+"/metadata/instance/compute/location"+query, "Metadata", "true")
+	if location == "" {
+		return ""
+	}
+	if zone := m.get(ctx, http.MethodGet, m.azure+"/metadata/instance/compute/zone"+query, "Metadata", "true"); zone != "" {
+		return location + "-" + zone
+	}
+	return location
+}
+
+// get returns the trimmed body of a successful request, empty otherwise.
+func (m cloudMetadata) get(ctx context.Context, method, url, header, value string) string {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set(header, value)
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(body))
+}
@@ -0,0 +1,85 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestRuntimeLabels(t *testing.T) {
+	t.Run("检测主机标签, 配置覆盖检测值", func(t *testing.T) {
+		l := newRuntimeLabels(map[string]string{model.RuntimeLabelZone: "idc-1", model.RuntimeLabelGPU: "true"})
+		md := l.metadata()
+		if md[model.RuntimeLabelPrefix+model.RuntimeLabelArch] != runtime.GOARCH || md[model.RuntimeLabelPrefix+model.RuntimeLabelOS] != runtime.GOOS {
+			t.Fatalf("期望检测到 os 和 arch, 得到 %v", md)
+		}
+		if md[model.RuntimeLabelPrefix+model.RuntimeLabelGPU] != "true" {
+			t.Fatalf("期望配置覆盖 gpu, 得到 %v", md)
+		}
+		// configured zone is never probed.
+		l.detectZone(context.Background(), cloudMetadata{client: http.DefaultClient, aws: "http://127.0.0.1:0"})
+		if got := l.get(model.RuntimeLabelZone); got != "idc-1" {
+			t.Fatalf("期望 zone idc-1, 得到 %q", got)
+		}
+	})
+
+	clouds := []struct {
+		name string
+		mux  func(mux *http.ServeMux)
+		want string
+	}{
+		{
+			name: "aws",
+			mux: func(mux *http.ServeMux) {
+				mux.HandleFunc("PUT /latest/api/token", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("token")) })
+				mux.HandleFunc("GET /latest/meta-data/placement/availability-zone", func(w http.ResponseWriter, r *http.Request) {
+					if r.Header.Get("X-aws-ec2-metadata-token") != "token" {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					w.Write([]byte("us-east-1a"))
+				})
+			},
+			want: "us-east-1a",
+		},
+		{
+			name: "gcp",
+			mux: func(mux *http.ServeMux) {
+				mux.HandleFunc("GET /computeMetadata/v1/instance/zone", func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte("projects/123/zones/us-central1-a\n"))
+				})
+			},
+			want: "us-central1-a",
+		},
+		{
+			name: "azure",
+			mux: func(mux *http.ServeMux) {
+				mux.HandleFunc("GET /metadata/instance/compute/location", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("eastus")) })
+				mux.HandleFunc("GET /metadata/instance/compute/zone", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("2")) })
+			},
+			want: "eastus-2",
+		},
+		{
+			name: "不在云上",
+			mux:  func(mux *http.ServeMux) {},
+		},
+	}
+	for _, tt := range clouds {
+		t.Run("探测 zone "+tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			tt.mux(mux)
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			l := newRuntimeLabels(nil)
+			l.detectZone(context.Background(), cloudMetadata{client: srv.Client(), aws: srv.URL, gcp: srv.URL, azure: srv.URL})
+			if got := l.get(model.RuntimeLabelZone); got != tt.want {
+				t.Fatalf("期望 zone %q, 得到 %q", tt.want, got)
+			}
+		})
+	}
+}
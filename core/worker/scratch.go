@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"context"
+	"maps"
+	"sync"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// scratchStore keeps scratch of tasks in the recorder, so that it's removed
+// with the task once purged. scratch of a task is written only by the
+// executor running it, writes of this worker are serialized to keep
+// read-modify-write of them consistent.
+type scratchStore struct {
+	repo taskrepo.Interface
+	mu   sync.Mutex
+}
+
+func newScratchStore(repo taskrepo.Interface) *scratchStore {
+	return &scratchStore{repo: repo}
+}
+
+func (s *scratchStore) Get(ctx context.Context, taskKey string) (map[string]string, error) {
+	task, err := s.repo.GetTask(ctx, taskKey)
+	if err != nil {
+		return nil, err
+	}
+	return maps.Clone(task.Scratch), nil
+}
+
+func (s *scratchStore) Put(ctx context.Context, taskKey string, kvs map[string]string) error {
+	return s.modify(ctx, taskKey, func(scratch map[string]string) {
+		maps.Copy(scratch, kvs)
+	})
+}
+
+func (s *scratchStore) Delete(ctx context.Context, taskKey string, keys ...string) error {
+	return s.modify(ctx, taskKey, func(scratch map[string]string) {
+		if len(keys) == 0 {
+			clear(scratch)
+		}
+		for _, k := range keys {
+			delete(scratch, k)
+		}
+	})
+}
+
+func (s *scratchStore) modify(ctx context.Context, taskKey string, fn func(scratch map[string]string)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, err := s.repo.GetTask(ctx, taskKey)
+	if err != nil {
+		return err
+	}
+	scratch := maps.Clone(task.Scratch)
+	if scratch == nil {
+		scratch = make(map[string]string)
+	}
+	fn(scratch)
+	if err := model.ValidateScratch(scratch); err != nil {
+		return err
+	}
+	// an empty but non-nil scratch clears the stored one.
+	return s.repo.UpdateTask(ctx, &model.Task{TaskKey: taskKey, Scratch: scratch})
+}
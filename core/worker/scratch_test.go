@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+type scratchRepo struct {
+	taskrepo.Interface
+	tasks map[string]*model.Task
+}
+
+func (r *scratchRepo) GetTask(_ context.Context, taskKey string) (*model.Task, error) {
+	task, ok := r.tasks[taskKey]
+	if !ok {
+		return nil, taskrepo.ErrTaskNotFound
+	}
+	return task.Clone(), nil
+}
+
+func (r *scratchRepo) UpdateTask(_ context.Context, task *model.Task) error {
+	if task.Scratch != nil {
+		r.tasks[task.TaskKey].Scratch = task.Scratch
+	}
+	return nil
+}
+
+func TestScratchStore(t *testing.T) {
+	ctx := context.Background()
+	repo := &scratchRepo{tasks: map[string]*model.Task{"t1": {TaskKey: "t1"}}}
+	s := newScratchStore(repo)
+
+	t.Run("写入后读取", func(t *testing.T) {
+		if err := s.Put(ctx, "t1", map[string]string{"offset": "10", "cursor": "a"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Put(ctx, "t1", map[string]string{"offset": "20"}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.Get(ctx, "t1")
+		if err != nil || got["offset"] != "20" || got["cursor"] != "a" {
+			t.Fatalf("期望合并写入, 得到 %v, %v", got, err)
+		}
+	})
+
+	t.Run("删除键", func(t *testing.T) {
+		if err := s.Delete(ctx, "t1", "cursor"); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := s.Get(ctx, "t1"); len(got) != 1 || got["offset"] != "20" {
+			t.Fatalf("期望只剩 offset, 得到 %v", got)
+		}
+		if err := s.Delete(ctx, "t1"); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := s.Get(ctx, "t1"); len(got) != 0 {
+			t.Fatalf("期望清空, 得到 %v", got)
+		}
+	})
+
+	t.Run("超过大小限制", func(t *testing.T) {
+		err := s.Put(ctx, "t1", map[string]string{"big": strings.Repeat("x", model.MaxScratchBytes)})
+		if !errors.Is(err, model.ErrScratchTooLarge) {
+			t.Fatalf("期望 ErrScratchTooLarge, 得到 %v", err)
+		}
+		if got, _ := s.Get(ctx, "t1"); len(got) != 0 {
+			t.Fatalf("超限写入不应生效, 得到 %v", got)
+		}
+	})
+
+	t.Run("任务不存在", func(t *testing.T) {
+		if err := s.Put(ctx, "t2", map[string]string{"k": "v"}); !errors.Is(err, taskrepo.ErrTaskNotFound) {
+			t.Fatalf("期望 ErrTaskNotFound, 得到 %v", err)
+		}
+	})
+}
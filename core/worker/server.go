@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/tasklog"
+)
+
+type HttpServer struct {
+	worker *Worker
+}
+
+func NewHttpServer(w *Worker) *HttpServer {
+	return &HttpServer{worker: w}
+}
+
+// RegisterRoutes register worker admin apis to r.
+// if authenticator is nil, apis are open to anyone can reach the port.
+func (s *HttpServer) RegisterRoutes(r gin.IRouter, authenticator auth.Authenticator) {
+	var middlewares []gin.HandlerFunc
+	if authenticator != nil {
+		middlewares = append(middlewares, auth.GinMiddleware(authenticator))
+	}
+
+	g := r.Group("/v1/tasks", middlewares...)
+	g.GET("/explain", auth.GinRequireRole(auth.RoleViewer), s.Explain)
+	g.GET("/logs", auth.GinRequireRole(auth.RoleViewer), s.GetTaskLogs)
+
+	admin := r.Group("/v1/admin", append(middlewares, auth.GinRequireRole(auth.RoleAdmin))...)
+	admin.POST("/force-release", s.ForceReleaseChange)
+	admin.GET("/read-only", s.GetReadOnly)
+	admin.POST("/read-only", s.SetReadOnly)
+
+	// probed by orchestrators, no need to authenticate.
+	r.GET("/healthz", s.Health)
+}
+
+// Health 查询 worker 健康状态, 不健康时返回 503
+func (s *HttpServer) Health(c *gin.Context) {
+	h := s.worker.Health()
+	status := http.StatusOK
+	if !h.Healthy {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, h)
+}
+
+// Explain 解释任务在当前 worker 上的调和决策
+func (s *HttpServer) Explain(c *gin.Context) {
+	taskKey := c.Query("task_key")
+	if taskKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_key is required"})
+		return
+	}
+
+	e, err := s.worker.Explain(c.Request.Context(), taskKey)
+	var forbidden *auth.ForbiddenError
+	if errors.As(err, &forbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
+
+// GetTaskLogs 获取任务输出日志, follow=true 时以 JSON lines 持续推送新日志
+func (s *HttpServer) GetTaskLogs(c *gin.Context) {
+	taskKey := c.Query("task_key")
+	if taskKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_key is required"})
+		return
+	}
+	tail, _ := strconv.Atoi(c.Query("tail"))
+	follow, _ := strconv.ParseBool(c.Query("follow"))
+
+	lines, err := s.worker.GetTaskLogs(c.Request.Context(), taskKey, tail, follow)
+	var forbidden *auth.ForbiddenError
+	if errors.As(err, &forbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, tasklog.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(c.Writer)
+	for line := range lines {
+		if err := enc.Encode(line); err != nil {
+			return
+		}
+		if len(lines) == 0 {
+			c.Writer.Flush()
+		}
+	}
+}
+
+// ForceReleaseChange 强制释放任务在变更队列中占用的 key
+func (s *HttpServer) ForceReleaseChange(c *gin.Context) {
+	var req struct {
+		TaskKey  string `json:"task_key"`
+		Reason   string `json:"reason"`
+		Operator string `json:"operator"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	operator := auth.OperatorOf(c, req.Operator)
+	if req.TaskKey == "" || operator == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task_key and operator are required"})
+		return
+	}
+
+	if err := s.worker.ForceReleaseChange(c.Request.Context(), req.TaskKey, req.Reason, operator); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已释放"})
+}
+
+// GetReadOnly 查询 worker 是否只读及缓存的状态更新数
+func (s *HttpServer) GetReadOnly(c *gin.Context) {
+	readOnly, buffered := s.worker.ReadOnly()
+	c.JSON(http.StatusOK, gin.H{"read_only": readOnly, "buffered": buffered})
+}
+
+// SetReadOnly 开关只读模式, 关闭时回写缓存的状态更新
+func (s *HttpServer) SetReadOnly(c *gin.Context) {
+	var req struct {
+		ReadOnly bool `json:"read_only"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.worker.SetReadOnly(c.Request.Context(), req.ReadOnly); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/tasklog"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo/memory"
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestTaskAccess(t *testing.T) {
+	store, err := tasklog.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := memory.New()
+	for key, bizType := range map[string]string{"a1": "a", "b1": "b"} {
+		if err := repo.CreateTask(context.Background(), &model.Task{TaskKey: key, BizType: bizType, WorkerID: "w1"}); err != nil {
+			t.Fatal(err)
+		}
+		stdout, _ := store.Writer(key, tasklog.StreamStdout)
+		fmt.Fprintln(stdout, "hello")
+		stdout.Close()
+	}
+	w := NewWorker("w1", "127.0.0.1", 8080, nil, repo, WithLogSink(store))
+
+	r := gin.New()
+	NewHttpServer(w).RegisterRoutes(r, auth.StaticTokens{
+		"a": {Name: "a", Role: auth.RoleViewer, BizTypes: []string{"a"}},
+	})
+	get := func(path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer a")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("只能查看有权限业务类型的日志", func(t *testing.T) {
+		if code := get("/v1/tasks/logs?task_key=a1"); code != http.StatusOK {
+			t.Errorf("期望 200, 得到 %d", code)
+		}
+		if code := get("/v1/tasks/logs?task_key=b1"); code != http.StatusForbidden {
+			t.Errorf("期望 403, 得到 %d", code)
+		}
+	})
+
+	t.Run("只能解释有权限业务类型的任务", func(t *testing.T) {
+		if code := get("/v1/tasks/explain?task_key=a1"); code != http.StatusOK {
+			t.Errorf("期望 200, 得到 %d", code)
+		}
+		if code := get("/v1/tasks/explain?task_key=b1"); code != http.StatusForbidden {
+			t.Errorf("期望 403, 得到 %d", code)
+		}
+	})
+
+	t.Run("已清理的任务仅限无限制的用户查看", func(t *testing.T) {
+		if err := repo.PurgeTask(context.Background(), "b1"); err != nil {
+			t.Fatal(err)
+		}
+		if code := get("/v1/tasks/logs?task_key=b1"); code != http.StatusForbidden {
+			t.Errorf("期望 403, 得到 %d", code)
+		}
+		if _, err := w.GetTaskLogs(context.Background(), "b1", 0, false); err != nil {
+			t.Errorf("未开启认证时期望可以查看, 得到 %v", err)
+		}
+	})
+}
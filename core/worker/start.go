@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/events"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/core/worker/infomer"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+// startWatcher times starts of tasks, a start times out if Run of the
+// executor does not return, or the executor reports no status of the task,
+// within timeout. otherwise the task is left in limbo and later changes of
+// it are blocked behind the create change.
+type startWatcher struct {
+	timeout time.Duration
+	clock   clock.Clock
+	// channels closed once the task is reported, by task key.
+	starting sync.Map
+}
+
+func newStartWatcher(timeout time.Duration, c clock.Clock) *startWatcher {
+	return &startWatcher{timeout: timeout, clock: c}
+}
+
+// Observe marks the task started once its executor reports any status,
+// a task failed quickly is started too.
+func (s *startWatcher) Observe(task *model.Task) {
+	if v, ok := s.starting.LoadAndDelete(task.TaskKey); ok {
+		close(v.(chan struct{}))
+	}
+}
+
+// run calls start, it returns once start returns or times out. waiting for
+// the first report of the task is left in background, timedOut is called
+// at most once if the start times out.
+func (s *startWatcher) run(taskKey string, start func() error, timedOut func(reason string)) error {
+	started := make(chan struct{})
+	s.starting.Store(taskKey, started)
+	timeout := s.clock.After(s.timeout)
+	done := make(chan error, 1)
+	go func() { done <- start() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			s.starting.Delete(taskKey)
+			return err
+		}
+	case <-timeout:
+		s.expire(taskKey, "run of executor did not return", timedOut)
+		return nil
+	}
+	go func() {
+		select {
+		case <-started:
+		case <-timeout:
+			s.expire(taskKey, "executor reported no status", timedOut)
+		}
+	}()
+	return nil
+}
+
+func (s *startWatcher) expire(taskKey, reason string, timedOut func(reason string)) {
+	// reported meanwhile.
+	if _, ok := s.starting.LoadAndDelete(taskKey); ok {
+		timedOut(reason)
+	}
+}
+
+// startTimedOut fails the task of change and releases the change, so that
+// retry policies apply and later changes of the task are not blocked.
+func (w *Worker) startTimedOut(consumer infomer.ChangeConsumer, change model.Change, reason string) {
+	err := fmt.Errorf("start timeout after %s, %s", w.opts.startTimeout, reason)
+	w.opts.logger.Warn("[Worker] task[%s] %v, fail it", change.TaskKey, err)
+	w.startTimeouts.Add(1, change.TaskType)
+	w.failTask(change, err)
+	if w.events != nil {
+		w.events.Emit(events.Event{
+			TaskKey: change.TaskKey,
+			Type:    events.TypeWarning,
+			Reason:  events.ReasonStartTimeout,
+			Message: err.Error(),
+		})
+	}
+	consumer.JumpChange(change)
+}
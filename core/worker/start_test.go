@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+func TestStartWatcher(t *testing.T) {
+	// steps the clock past timeout until timedOut is called, or gives up.
+	expire := func(c *clock.FakeClock, timeouts *atomic.Int32) {
+		for i := 0; i < 100 && timeouts.Load() == 0; i++ {
+			c.Step(time.Minute)
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	t.Run("上报状态后不超时", func(t *testing.T) {
+		c := clock.NewFakeClock(time.Now())
+		s := newStartWatcher(time.Minute, c)
+		var timeouts atomic.Int32
+		err := s.run("t1", func() error { return nil }, func(string) { timeouts.Add(1) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Observe(&model.Task{TaskKey: "t1", Status: model.TaskStatusRunning})
+		expire(c, &timeouts)
+		if timeouts.Load() != 0 {
+			t.Fatal("已上报状态不应超时")
+		}
+	})
+
+	t.Run("未上报状态超时", func(t *testing.T) {
+		c := clock.NewFakeClock(time.Now())
+		s := newStartWatcher(time.Minute, c)
+		var timeouts atomic.Int32
+		var reason atomic.Value
+		err := s.run("t1", func() error { return nil }, func(r string) {
+			reason.Store(r)
+			timeouts.Add(1)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expire(c, &timeouts)
+		if timeouts.Load() != 1 || reason.Load() != "executor reported no status" {
+			t.Fatalf("期望超时一次, 得到 %d 次, 原因 %v", timeouts.Load(), reason.Load())
+		}
+		// reported after timeout.
+		s.Observe(&model.Task{TaskKey: "t1", Status: model.TaskStatusRunning})
+	})
+
+	t.Run("Run 阻塞时超时返回", func(t *testing.T) {
+		c := clock.NewFakeClock(time.Now())
+		s := newStartWatcher(time.Minute, c)
+		var timeouts atomic.Int32
+		block := make(chan struct{})
+		defer close(block)
+		done := make(chan error, 1)
+		go func() {
+			done <- s.run("t1", func() error { <-block; return nil }, func(string) { timeouts.Add(1) })
+		}()
+		expire(c, &timeouts)
+		select {
+		case err := <-done:
+			if err != nil || timeouts.Load() != 1 {
+				t.Fatalf("期望超时返回, 得到 %v, 超时 %d 次", err, timeouts.Load())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Run 阻塞时应超时返回")
+		}
+	})
+
+	t.Run("Run 失败不超时", func(t *testing.T) {
+		c := clock.NewFakeClock(time.Now())
+		s := newStartWatcher(time.Minute, c)
+		var timeouts atomic.Int32
+		if err := s.run("t1", func() error { return errors.New("boom") }, func(string) { timeouts.Add(1) }); err == nil {
+			t.Fatal("期望返回 Run 的错误")
+		}
+		expire(c, &timeouts)
+		if timeouts.Load() != 0 {
+			t.Fatal("Run 失败不应超时")
+		}
+	})
+}
@@ -3,10 +3,18 @@ package worker
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/chaos"
+	"github.com/xyzbit/minitaskx/core/components/crash"
 	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/components/events"
 	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/metrics"
+	"github.com/xyzbit/minitaskx/core/components/sink"
+	"github.com/xyzbit/minitaskx/core/components/tasklog"
 	"github.com/xyzbit/minitaskx/core/components/taskrepo"
 	"github.com/xyzbit/minitaskx/core/model"
 	"github.com/xyzbit/minitaskx/core/worker/executor"
@@ -21,9 +29,24 @@ type Worker struct {
 	port int
 
 	discover discover.Interface
+	taskRepo taskrepo.Interface
 
 	infomer    *infomer.Infomer
 	exeManager *executor.Manager
+	exporter   *sink.Exporter
+	attempts   *attemptRecorder
+	events     *eventEmitter
+	chaos      *chaos.Injector
+	// nil if start rate is not limited.
+	startLimiter *startLimiter
+	// nil if starts never time out.
+	starts        *startWatcher
+	startTimeouts metrics.Counter
+	readOnly      *readOnlyRepo
+	labels        *runtimeLabels
+	// creates metrics of the worker labeled by worker_id, so that metrics of
+	// workers embedded in one process are told apart.
+	metrics metrics.Provider
 
 	opts *options
 }
@@ -43,14 +66,82 @@ func NewWorker(
 		discover: discover,
 		opts:     newOptions(opts...),
 	}
+	w.labels = newRuntimeLabels(w.opts.runtimeLabels)
+	w.metrics = metrics.Labeled(metrics.Global(), "worker_id", id)
 
-	manager := &executor.Manager{}
+	manager := executor.NewManager(w.opts.executorRegistry)
+	manager.SetCrashReporter(w.opts.crashReporter)
+	var loader chaos.Loader = manager
+	if w.opts.chaos != nil {
+		w.chaos = chaos.New(*w.opts.chaos)
+		taskRepo = w.chaos.WrapRepo(taskRepo)
+		loader = w.chaos.WrapLoader(loader)
+		w.opts.logger.Warn("[Worker] chaos enabled: %+v", *w.opts.chaos)
+	}
+	taskRepo = taskrepo.WithMetrics(taskrepo.WithTimeouts(taskrepo.WithFinalGuard(taskRepo), w.opts.repoTimeouts))
+	w.readOnly = newReadOnlyRepo(taskRepo, w.opts.readOnlyBufferSize, w.metrics)
+	taskRepo = w.readOnly
+	infomerOpts := []infomer.Option{
+		infomer.WithBatchGetChunk(w.opts.batchGetChunkSize, w.opts.batchGetParallelism),
+		infomer.WithTriggerDebounce(w.opts.triggerDebounce),
+		infomer.WithResyncRules(w.opts.resyncRules...),
+		infomer.WithClock(w.opts.clock),
+		infomer.WithResyncSplay(w.opts.resyncSplay),
+		infomer.WithResyncJitter(w.opts.resyncJitter),
+		infomer.WithRecorderCache(w.opts.recorderCacheTTL),
+		infomer.WithBatchUpdate(w.opts.batchUpdateSize),
+		infomer.WithAutoFinishGrace(w.opts.autoFinishGrace),
+		infomer.WithCrashReporter(w.opts.crashReporter),
+		infomer.WithMetricsProvider(w.metrics),
+	}
+	if w.opts.resyncSlotter != nil {
+		infomerOpts = append(infomerOpts, infomer.WithResyncSlotter(w.opts.resyncSlotter))
+	}
+	if w.opts.differ != nil {
+		infomerOpts = append(infomerOpts, infomer.WithDiffer(w.opts.differ))
+	}
+	if w.opts.watchBackoff > 0 && w.opts.watchMaxBackoff > 0 {
+		infomerOpts = append(infomerOpts, infomer.WithWatchBackoff(w.opts.watchBackoff, w.opts.watchMaxBackoff))
+	}
+	if w.opts.changeQueueBounds != nil {
+		infomerOpts = append(infomerOpts, infomer.WithChangeQueueBounds(*w.opts.changeQueueBounds))
+	}
+	if w.opts.taskSink != nil {
+		w.exporter = sink.NewExporter(w.opts.taskSink, 0, 0)
+		infomerOpts = append(infomerOpts, infomer.WithStatusObserver(w.exporter.Observe))
+	}
+	if w.opts.attemptRepo != nil {
+		w.attempts = newAttemptRecorder(w.opts.attemptRepo, func() string { return w.id }, w.opts.logger)
+		infomerOpts = append(infomerOpts, infomer.WithStatusObserver(w.attempts.Observe))
+	}
+	if w.opts.eventRecorder != nil {
+		w.events = newEventEmitter(w.opts.eventRecorder, func() string { return w.id }, w.opts.logger)
+		infomerOpts = append(infomerOpts, infomer.WithStatusObserver(w.events.Observe), infomer.WithEventEmitter(w.events.Emit))
+	}
+	if w.opts.startTimeout > 0 {
+		w.starts = newStartWatcher(w.opts.startTimeout, w.opts.clock)
+		w.startTimeouts = w.metrics.NewCounter("minitaskx_executor_start_timeouts_total", "tasks failed since executors did not start in time", "type")
+		infomerOpts = append(infomerOpts, infomer.WithStatusObserver(w.starts.Observe))
+	}
+	if w.opts.notifier != nil {
+		infomerOpts = append(infomerOpts, infomer.WithStatusObserver(finishNotifier(w.opts.notifier, w.opts.notifyStatuses)))
+	}
 	w.infomer = infomer.New(
-		infomer.NewIndexer(manager, w.opts.resync),
+		infomer.NewIndexer(
+			loader, w.opts.resync,
+			infomer.WithClock(w.opts.clock),
+			infomer.WithHeartbeatTimeout(w.opts.heartbeatTimeout),
+			infomer.WithStaleCacheThreshold(w.opts.staleCacheThreshold),
+			infomer.WithCrashReporter(w.opts.crashReporter),
+			infomer.WithMetricsProvider(w.metrics),
+		),
 		taskRepo,
 		w.opts.logger,
+		infomerOpts...,
 	)
 	w.exeManager = manager
+	w.taskRepo = taskRepo
+	w.startLimiter = newStartLimiter(w.opts.startLimit, w.opts.startLimitPerType)
 	return w
 }
 
@@ -65,9 +156,30 @@ func (w *Worker) Run(ctx context.Context) error {
 	// start run
 	w.opts.logger.Info("Worker[%s] 开始运行...", w.id)
 
-	go w.runResourceUsageReporter()
-	go w.runChangeSyncer()
+	// loops of worker are run again after they panic.
+	w.safeGo("worker.resource", w.runResourceUsageReporter)
+	if !w.opts.noZoneDetection {
+		w.safeGo("worker.zone", func() { w.labels.detectZone(ctx, defaultCloudMetadata()) })
+	}
+	w.exeManager.SetScratchStore(newScratchStore(w.taskRepo))
+	w.exeManager.RunLoaders(ctx)
+	w.exeManager.RunWarmPools(ctx)
+	prober := newProber(w.exeManager, w.opts.clock, w.opts.logger)
+	w.safeGo("worker.prober", func() { prober.Run(ctx) })
+	w.safeGo("worker.consumer", w.runChangeSyncer)
 	go w.runInfomer(ctx)
+	if w.exporter != nil {
+		w.safeGo("worker.exporter", func() { w.exporter.Run(ctx) })
+	}
+	if w.attempts != nil {
+		w.safeGo("worker.attempts", func() { w.attempts.Run(ctx) })
+	}
+	if w.events != nil {
+		w.safeGo("worker.events", func() { w.events.Run(ctx) })
+	}
+	if w.opts.eviction != nil {
+		w.safeGo("worker.evictor", func() { w.runEvictor(ctx) })
+	}
 
 	// wait ctx cancel
 	<-ctx.Done()
@@ -75,6 +187,69 @@ func (w *Worker) Run(ctx context.Context) error {
 	return w.gracefulShutdown()
 }
 
+// Explain explains the reconciliation decision of taskKey on this worker.
+func (w *Worker) Explain(ctx context.Context, taskKey string) (*infomer.Explanation, error) {
+	if err := w.checkBizType(ctx, taskKey); err != nil {
+		return nil, err
+	}
+	return w.infomer.Explain(ctx, w.id, taskKey)
+}
+
+// StaleTasks returns running tasks on this worker whose heartbeat is stale,
+// empty if heartbeat timeout is not set.
+func (w *Worker) StaleTasks() []*model.Task {
+	return w.infomer.StaleTasks()
+}
+
+// Health is the health of the worker.
+type Health struct {
+	Healthy bool `json:"healthy"`
+	// time since real tasks are listed from executors successfully.
+	CacheStaleness time.Duration `json:"cache_staleness"`
+	CacheStale     bool          `json:"cache_stale"`
+	ReadOnly       bool          `json:"read_only"`
+}
+
+// Health reports the worker unhealthy while cache of real tasks is stale,
+// since it can't observe its executors.
+func (w *Worker) Health() Health {
+	staleness, stale := w.infomer.CacheStaleness()
+	readOnly, _ := w.ReadOnly()
+	return Health{
+		Healthy:        !stale,
+		CacheStaleness: staleness,
+		CacheStale:     stale,
+		ReadOnly:       readOnly,
+	}
+}
+
+// GetTaskLogs returns the last tail lines of output of taskKey captured on this worker,
+// if follow, new lines are streamed until ctx is done.
+func (w *Worker) GetTaskLogs(ctx context.Context, taskKey string, tail int, follow bool) (<-chan tasklog.Line, error) {
+	if w.opts.logSink == nil {
+		return nil, fmt.Errorf("worker[%s] 未开启日志采集", w.id)
+	}
+	if err := w.checkBizType(ctx, taskKey); err != nil {
+		return nil, err
+	}
+	return w.opts.logSink.Read(ctx, taskKey, tail, follow)
+}
+
+// checkBizType returns error if the principal in ctx can not access biz type
+// of the task, tasks already purged are only accessible to unrestricted principals.
+func (w *Worker) checkBizType(ctx context.Context, taskKey string) error {
+	// deleted tasks are returned too, their logs are still readable.
+	tasks, err := w.taskRepo.BatchGetTask(ctx, []string{taskKey})
+	if err != nil {
+		return err
+	}
+	var bizType string
+	if len(tasks) > 0 {
+		bizType = tasks[0].BizType
+	}
+	return auth.CheckBizType(ctx, bizType)
+}
+
 func (w *Worker) init() (clear func() error, err error) {
 	// register instance
 	metadata, err := w.generateInstanceMetadata()
@@ -121,16 +296,100 @@ func (w *Worker) runChangeSyncer() {
 	consumer := w.infomer.ChangeConsumer()
 
 	for {
-		change, isShutdown := consumer.WaitChange()
+		changes, isShutdown := consumer.GetBatch(w.opts.changeBatchSize)
 		if isShutdown {
 			log.Info("[Worker] consumer shutdown.")
 			break
 		}
+		if w.chaos != nil {
+			w.chaos.Pause()
+		}
 
-		if err := w.exeManager.ChangeHandle(&change); err != nil {
-			log.Error("[Worker] change sync failed: %v", err)
-			consumer.JumpChange(change)
+		// changes in a batch belong to different tasks, handle them concurrently.
+		var wg sync.WaitGroup
+		for _, change := range changes {
+			wg.Add(1)
+			go func(change model.Change) {
+				defer wg.Done()
+				defer crash.Recover(w.opts.crashReporter, "worker.change", change.TaskKey, func(err error) {
+					w.failTask(change, err)
+					consumer.JumpChange(change)
+				})
+				if change.ChangeType == model.ChangeCreate && w.startLimiter != nil {
+					if err := w.startLimiter.Wait(context.Background(), change.TaskType); err != nil {
+						log.Error("[Worker] start rate limit wait failed: %v", err)
+					}
+				}
+				w.acknowledge(consumer, change, w.handleChange(consumer, change))
+			}(change)
 		}
+		wg.Wait()
+	}
+}
+
+// handleChange applies change to executors, starts of tasks are timed if
+// WithStartTimeout is set.
+func (w *Worker) handleChange(consumer infomer.ChangeConsumer, change model.Change) error {
+	if change.ChangeType != model.ChangeCreate || w.starts == nil {
+		return w.exeManager.ChangeHandle(&change)
+	}
+	return w.starts.run(change.TaskKey, func() (err error) {
+		// the start may outlive the change goroutine, panics are recovered here.
+		defer crash.Recover(w.opts.crashReporter, "worker.change", change.TaskKey, func(perr error) {
+			w.failTask(change, perr)
+			err = perr
+		})
+		return w.exeManager.ChangeHandle(&change)
+	}, func(reason string) {
+		w.startTimedOut(consumer, change, reason)
+	})
+}
+
+// acknowledge releases change not accepted by executors. transient failures
+// are retried once the task is reconciled again, while runs rejected fail
+// the task since retrying the same run can't help.
+func (w *Worker) acknowledge(consumer infomer.ChangeConsumer, change model.Change, err error) {
+	result := executor.ResultOf(err)
+	switch result.Ack {
+	case executor.AckAccepted:
+		return
+	case executor.AckAlreadyRunning:
+		log.Info("[Worker] task[%s] is already running, ignore %s", change.TaskKey, change.ChangeType)
+	case executor.AckRejected:
+		log.Error("[Worker] %s of task[%s] is rejected: %v", change.ChangeType, change.TaskKey, result.Err)
+		if change.ChangeType == model.ChangeCreate || change.ChangeType == model.ChangeUpdate {
+			w.failTask(change, result.Err)
+		}
+		if w.events != nil {
+			w.events.Emit(events.Event{
+				TaskKey: change.TaskKey,
+				Type:    events.TypeWarning,
+				Reason:  events.ReasonRejected,
+				Message: fmt.Sprintf("%s %v", change.ChangeType, result.Err),
+			})
+		}
+	default:
+		log.Error("[Worker] change sync failed: %v", result.Err)
+	}
+	consumer.JumpChange(change)
+}
+
+// delay before a loop of worker is run again after it panics.
+const panicRestartDelay = time.Second
+
+func (w *Worker) safeGo(component string, fn func()) {
+	crash.Go(w.opts.crashReporter, component, panicRestartDelay, fn)
+}
+
+// failTask fails the task whose change panicked or timed out in executor, the executor
+// may be left running and is exited once the failure is observed.
+func (w *Worker) failTask(change model.Change, err error) {
+	if uerr := w.taskRepo.UpdateTask(context.Background(), &model.Task{
+		TaskKey: change.TaskKey,
+		Status:  model.TaskStatusFailed,
+		Msg:     fmt.Sprintf("%s %v", change.ChangeType, err),
+	}); uerr != nil {
+		log.Error("[Worker] fail task %s: %v", change.TaskKey, uerr)
 	}
 }
 
@@ -149,6 +408,9 @@ func (w *Worker) gracefulShutdown() error {
 	if err != nil {
 		log.Error("[Worker] gracefulShutdown mark instance disable: %v", err)
 	}
+	if n := w.readOnly.pending(); n > 0 {
+		log.Warn("[Worker] gracefulShutdown drop %d status updates buffered in read-only mode", n)
+	}
 
 	// wait infomer shutdown.
 	stopCtx := context.Background()
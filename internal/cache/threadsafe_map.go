@@ -3,25 +3,34 @@ package cache
 import (
 	"sync"
 	"time"
+
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
 )
 
 const DefaultRecycleInterval = 1 * time.Minute
 
 type ThreadSafeMap[T any] struct {
+	clock   clock.Clock
 	lock    sync.RWMutex
 	setTime map[string]time.Time
 	items   map[string]T
 }
 
 func NewThreadSafeMap[T any](condition func(item T, afterSetDurtion time.Duration) bool) *ThreadSafeMap[T] {
+	return NewThreadSafeMapWithClock(condition, clock.RealClock{})
+}
+
+// NewThreadSafeMapWithClock is NewThreadSafeMap measuring set duration and recycle interval by c.
+func NewThreadSafeMapWithClock[T any](condition func(item T, afterSetDurtion time.Duration) bool, c clock.Clock) *ThreadSafeMap[T] {
 	tsm := &ThreadSafeMap[T]{
+		clock:   c,
 		items:   make(map[string]T),
 		setTime: make(map[string]time.Time),
 	}
 
 	go func() {
 		for {
-			time.Sleep(DefaultRecycleInterval)
+			c.Sleep(DefaultRecycleInterval)
 			for key, item := range tsm.listWithSetDurition() {
 				if condition(item.item, item.d) {
 					tsm.Delete(key)
@@ -37,7 +46,7 @@ func (c *ThreadSafeMap[T]) Set(key string, obj T) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	c.items[key] = obj
-	c.setTime[key] = time.Now()
+	c.setTime[key] = c.clock.Now()
 }
 
 func (c *ThreadSafeMap[T]) Delete(key string) {
@@ -67,7 +76,7 @@ func (c *ThreadSafeMap[T]) listWithSetDurition() map[string]itemWithDurition[T]
 		setTime := c.setTime[key]
 		m[key] = itemWithDurition[T]{
 			item: item,
-			d:    time.Now().Sub(setTime),
+			d:    c.clock.Since(setTime),
 		}
 	}
 	return m
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
+)
+
+func TestThreadSafeMapRecycle(t *testing.T) {
+	c := clock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	m := NewThreadSafeMapWithClock(func(item string, d time.Duration) bool {
+		return item == "done" && d >= 2*DefaultRecycleInterval
+	}, c)
+	m.Set("a", "done")
+	m.Set("b", "running")
+
+	step := func() {
+		// wait for recycle goroutine sleeping on clock.
+		for !c.HasWaiters() {
+			time.Sleep(time.Millisecond)
+		}
+		c.Step(DefaultRecycleInterval)
+	}
+
+	step()
+	time.Sleep(10 * time.Millisecond)
+	if _, ok := m.Get("a"); !ok {
+		t.Fatal("未到回收时间, 不应该被回收")
+	}
+
+	step()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := m.Get("a"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("到达回收时间, 应该被回收")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, ok := m.Get("b"); !ok {
+		t.Error("不满足回收条件, 不应该被回收")
+	}
+}
@@ -0,0 +1,38 @@
+package queue_test
+
+import (
+	"testing"
+
+	"github.com/xyzbit/minitaskx/internal/queue"
+)
+
+func TestGetBatch(t *testing.T) {
+	q := queue.NewTyped[string]()
+	q.Add("a")
+	q.Add("b")
+	q.Add("a")
+	q.Add("c")
+
+	items, shutdown := q.GetBatch(2)
+	if shutdown || len(items) != 2 || items[0] != "a" || items[1] != "b" {
+		t.Fatalf("期望 [a b], 得到 %v, shutdown: %v", items, shutdown)
+	}
+
+	// processing items can not be got again until done.
+	q.Add("a")
+	items, _ = q.GetBatch(10)
+	if len(items) != 1 || items[0] != "c" {
+		t.Fatalf("期望 [c], 得到 %v", items)
+	}
+	if !q.Exist("a") {
+		t.Error("处理中的 a 应该存在")
+	}
+	for _, item := range []string{"a", "b", "c"} {
+		q.Done(item)
+	}
+
+	q.ShutDown()
+	if items, shutdown = q.GetBatch(10); !shutdown || len(items) != 0 {
+		t.Errorf("关闭后期望 shutdown, 得到 %v, %v", items, shutdown)
+	}
+}
@@ -0,0 +1,54 @@
+package queue_test
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/xyzbit/minitaskx/internal/queue"
+)
+
+func BenchmarkTypedQueueManyKeys(b *testing.B) {
+	for _, n := range []int{1_000, 10_000, 100_000} {
+		keys := make([]string, n)
+		for i := range keys {
+			keys[i] = "task-" + strconv.Itoa(i)
+		}
+
+		b.Run(fmt.Sprintf("keys=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				q := queue.NewTyped[string]()
+				for _, key := range keys {
+					q.Add(key)
+				}
+				// duplicate adds are collapsed.
+				for _, key := range keys {
+					q.Add(key)
+				}
+				for range keys {
+					item, _ := q.Get()
+					q.Done(item)
+				}
+				q.ShutDown()
+			}
+		})
+	}
+}
+
+func BenchmarkTypedQueueParallel(b *testing.B) {
+	q := queue.NewTyped[int]()
+	defer q.ShutDown()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			q.Add(i % 10_000)
+			if item, shutdown := q.Get(); !shutdown {
+				q.Done(item)
+			}
+			i++
+		}
+	})
+}
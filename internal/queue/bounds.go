@@ -0,0 +1,127 @@
+package queue
+
+// OverflowPolicy decides what Add does once a bound of the queue is reached.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks Add until items are taken by Get.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest waiting item of the full bound.
+	OverflowDropOldest
+	// OverflowReject drops the item being added.
+	OverflowReject
+)
+
+// Bounds limits items waiting in the queue, items being processed are not counted.
+type Bounds[T comparable] struct {
+	// max items waiting, 0 means unbounded.
+	MaxLen int
+	// Group classifies items, MaxPerGroup limits waiting items per group,
+	// groups absent or not positive are unbounded.
+	Group       func(item T) string
+	MaxPerGroup map[string]int
+	Overflow    OverflowPolicy
+	// OnOverflow is called with the item dropped or rejected, lock of the
+	// queue is held so it must not call the queue.
+	OnOverflow func(item T)
+}
+
+type admission int
+
+const (
+	admitted admission = iota
+	waited
+	rejected
+)
+
+// admit makes room for item according to bounds, must be called with lock held.
+func (q *Typed[T]) admit(item T) admission {
+	b := q.bounds
+	if b == nil {
+		return admitted
+	}
+	for {
+		group, byGroup, full := q.full(item)
+		if !full {
+			return admitted
+		}
+		switch b.Overflow {
+		case OverflowDropOldest:
+			if q.dropOldest(group, byGroup) {
+				continue
+			}
+			fallthrough
+		case OverflowReject:
+			if b.OnOverflow != nil {
+				b.OnOverflow(item)
+			}
+			return rejected
+		default:
+			q.cond.Wait()
+			return waited
+		}
+	}
+}
+
+// full reports whether adding item exceeds a bound, byGroup is true if
+// only the bound of group is exceeded.
+func (q *Typed[T]) full(item T) (group string, byGroup, full bool) {
+	b := q.bounds
+	if b.MaxLen > 0 && q.queue.Len() >= b.MaxLen {
+		return "", false, true
+	}
+	if b.Group == nil {
+		return "", false, false
+	}
+	group = b.Group(item)
+	max, ok := b.MaxPerGroup[group]
+	return group, true, ok && max > 0 && q.groupLen[group] >= max
+}
+
+// dropOldest drops the first waiting item, of group if byGroup. the queue is
+// rotated through once so that order of other items is kept.
+func (q *Typed[T]) dropOldest(group string, byGroup bool) (dropped bool) {
+	for n := q.queue.Len(); n > 0; n-- {
+		item := q.queue.Pop()
+		if dropped || (byGroup && q.bounds.Group(item) != group) {
+			q.queue.Push(item)
+			continue
+		}
+		dropped = true
+		q.popped(item)
+		q.metrics.get(item)
+		q.metrics.done(item)
+		q.dirty.delete(item)
+		if q.bounds.OnOverflow != nil {
+			q.bounds.OnOverflow(item)
+		}
+	}
+	return dropped
+}
+
+func (q *Typed[T]) pushed(item T) {
+	if q.bounds != nil && q.bounds.Group != nil {
+		q.groupLen[q.bounds.Group(item)]++
+	}
+}
+
+// wake wakes all waiters if adders may be blocked by bounds, since a single
+// signal may wake an adder rather than a getter.
+func (q *Typed[T]) wake() {
+	if q.bounds != nil {
+		q.cond.Broadcast()
+		return
+	}
+	q.cond.Signal()
+}
+
+// popped updates group length and wakes adders blocked by bounds.
+func (q *Typed[T]) popped(item T) {
+	if q.bounds == nil {
+		return
+	}
+	if q.bounds.Group != nil {
+		q.groupLen[q.bounds.Group(item)]--
+	}
+	q.cond.Broadcast()
+}
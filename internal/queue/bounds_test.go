@@ -0,0 +1,80 @@
+package queue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/internal/queue"
+)
+
+type boundItem struct {
+	key   string
+	group string
+}
+
+func TestBounds(t *testing.T) {
+	group := func(i boundItem) string { return i.group }
+
+	t.Run("drop oldest of the full group", func(t *testing.T) {
+		var dropped []boundItem
+		q := queue.NewTypedWithConfig(queue.TypedQueueConfig[boundItem]{Bounds: &queue.Bounds[boundItem]{
+			Group:       group,
+			MaxPerGroup: map[string]int{"a": 2},
+			Overflow:    queue.OverflowDropOldest,
+			OnOverflow:  func(i boundItem) { dropped = append(dropped, i) },
+		}})
+		for _, i := range []boundItem{{"1", "a"}, {"2", "b"}, {"3", "a"}, {"4", "a"}} {
+			q.Add(i)
+		}
+		if len(dropped) != 1 || dropped[0].key != "1" || q.Exist(boundItem{"1", "a"}) {
+			t.Fatalf("expect item 1 dropped, got %v", dropped)
+		}
+		for _, want := range []string{"2", "3", "4"} {
+			if item, _ := q.Get(); item.key != want {
+				t.Errorf("expect %s, got %s", want, item.key)
+			}
+		}
+	})
+
+	t.Run("reject when full", func(t *testing.T) {
+		rejected := 0
+		q := queue.NewTypedWithConfig(queue.TypedQueueConfig[boundItem]{Bounds: &queue.Bounds[boundItem]{
+			MaxLen:     1,
+			Overflow:   queue.OverflowReject,
+			OnOverflow: func(boundItem) { rejected++ },
+		}})
+		q.Add(boundItem{"1", "a"})
+		if exist := q.Add(boundItem{"2", "a"}); exist || rejected != 1 || q.Len() != 1 {
+			t.Fatalf("expect item 2 rejected, rejected %d, len %d", rejected, q.Len())
+		}
+		// items being processed are not counted.
+		item, _ := q.Get()
+		q.Add(boundItem{"2", "a"})
+		if rejected != 1 || q.Len() != 1 {
+			t.Errorf("expect item 2 added, rejected %d", rejected)
+		}
+		q.Done(item)
+	})
+
+	t.Run("block until taken", func(t *testing.T) {
+		q := queue.NewTypedWithConfig(queue.TypedQueueConfig[boundItem]{Bounds: &queue.Bounds[boundItem]{MaxLen: 1}})
+		q.Add(boundItem{"1", "a"})
+		added := make(chan struct{})
+		go func() {
+			q.Add(boundItem{"2", "a"})
+			close(added)
+		}()
+		select {
+		case <-added:
+			t.Fatal("expect add blocked")
+		case <-time.After(50 * time.Millisecond):
+		}
+		q.Get()
+		select {
+		case <-added:
+		case <-time.After(time.Second):
+			t.Fatal("expect add unblocked")
+		}
+		q.ShutDown()
+	})
+}
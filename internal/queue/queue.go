@@ -17,6 +17,9 @@ type TypedInterface[T comparable] interface {
 	Add(item T) (exist bool)
 	Len() int
 	Get() (item T, shutdown bool)
+	// GetBatch blocks until at least one item is available, then returns up to max items
+	// without blocking. each item must be marked Done separately.
+	GetBatch(max int) (items []T, shutdown bool)
 	Done(item T)
 	ShutDown()
 	ShutDownWithDrain()
@@ -100,6 +103,9 @@ type TypedQueueConfig[T comparable] struct {
 
 	// Queue provides the underlying queue to use. It is optional and defaults to slice based FIFO queue.
 	Queue Queue[T]
+
+	// Bounds optionally limits items waiting in the queue.
+	Bounds *Bounds[T]
 }
 
 // NewTyped constructs a new work queue (see the package comment).
@@ -135,12 +141,14 @@ func newQueueWithConfig[T comparable](config TypedQueueConfig[T], updatePeriod t
 		config.Queue = DefaultQueue[T]()
 	}
 
-	return newQueue(
+	q := newQueue(
 		config.Clock,
 		config.Queue,
 		metricsFactory.newQueueMetrics(config.Name, config.Clock),
 		updatePeriod,
 	)
+	q.bounds = config.Bounds
+	return q
 }
 
 func newQueue[T comparable](c clock.WithTicker, queue Queue[T], metrics queueMetrics, updatePeriod time.Duration) *Typed[T] {
@@ -149,6 +157,7 @@ func newQueue[T comparable](c clock.WithTicker, queue Queue[T], metrics queueMet
 		queue:                      queue,
 		dirty:                      set[T]{},
 		processing:                 set[T]{},
+		groupLen:                   make(map[string]int),
 		cond:                       sync.NewCond(&sync.Mutex{}),
 		metrics:                    metrics,
 		unfinishedWorkUpdatePeriod: updatePeriod,
@@ -191,6 +200,10 @@ type Typed[t comparable] struct {
 
 	metrics queueMetrics
 
+	// limits of waiting items and number of waiting items per group.
+	bounds   *Bounds[t]
+	groupLen map[string]int
+
 	unfinishedWorkUpdatePeriod time.Duration
 	clock                      clock.WithTicker
 }
@@ -199,26 +212,37 @@ type Typed[t comparable] struct {
 func (q *Typed[T]) Add(item T) (exist bool) {
 	q.cond.L.Lock()
 	defer q.cond.L.Unlock()
-	if q.shuttingDown {
-		return false
-	}
-	if q.dirty.has(item) {
-		// the same item is added again before it is processed, call the Touch
-		// function if the queue cares about it (for e.g, reset its priority)
-		if !q.processing.has(item) {
-			q.queue.Touch(item)
+	for {
+		if q.shuttingDown {
+			return false
+		}
+		if q.dirty.has(item) {
+			// the same item is added again before it is processed, call the Touch
+			// function if the queue cares about it (for e.g, reset its priority)
+			if !q.processing.has(item) {
+				q.queue.Touch(item)
+			}
+			return true
 		}
-		return true
-	}
 
-	if q.processing.has(item) {
-		return true
+		if q.processing.has(item) {
+			return true
+		}
+		switch q.admit(item) {
+		case waited:
+			// state may be changed while waiting, check again.
+			continue
+		case rejected:
+			return false
+		}
+		break
 	}
 	q.metrics.add(item)
 	q.dirty.insert(item)
 
 	q.queue.Push(item)
-	q.cond.Signal()
+	q.pushed(item)
+	q.wake()
 	return false
 }
 
@@ -253,6 +277,7 @@ func (q *Typed[T]) Get() (item T, shutdown bool) {
 	}
 
 	item = q.queue.Pop()
+	q.popped(item)
 
 	q.metrics.get(item)
 
@@ -262,6 +287,36 @@ func (q *Typed[T]) Get() (item T, shutdown bool) {
 	return item, false
 }
 
+// GetBatch is like Get, but returns up to max items which are ready at that time.
+// items of the same key never appear twice in a batch, since they are collapsed on Add.
+func (q *Typed[T]) GetBatch(max int) (items []T, shutdown bool) {
+	if max <= 0 {
+		max = 1
+	}
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	for q.queue.Len() == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	if q.queue.Len() == 0 {
+		// We must be shutting down.
+		return nil, true
+	}
+
+	items = make([]T, 0, min(max, q.queue.Len()))
+	for len(items) < max && q.queue.Len() > 0 {
+		item := q.queue.Pop()
+		q.popped(item)
+
+		q.metrics.get(item)
+
+		q.processing.insert(item)
+		q.dirty.delete(item)
+		items = append(items, item)
+	}
+	return items, false
+}
+
 // Done marks item as done processing, and if it has been marked as dirty again
 // while it was being processed, it will be re-added to the queue for
 // re-processing.
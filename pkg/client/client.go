@@ -0,0 +1,115 @@
+// Package client is the http client of scheduler apis.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/pkg/util/tlsutil"
+)
+
+type Client struct {
+	addr string
+	opts *options
+}
+
+type options struct {
+	httpClient *http.Client
+	token      string
+	// polling interval of WaitForTerminal when watch is unavailable.
+	pollInterval time.Duration
+}
+
+type Option func(o *options)
+
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = c
+	}
+}
+
+// WithTLS sends requests by mutual TLS, addr must be https.
+func WithTLS(r *tlsutil.Reloader) Option {
+	return func(o *options) {
+		o.httpClient = r.HTTPClient(0)
+	}
+}
+
+// WithToken sets the bearer token of requests.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = interval
+	}
+}
+
+// New returns client of scheduler listening on addr, eg. http://127.0.0.1:8080.
+func New(addr string, opts ...Option) *Client {
+	o := options{
+		httpClient:   http.DefaultClient,
+		pollInterval: 2 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Client{addr: strings.TrimSuffix(addr, "/"), opts: &o}
+}
+
+// GetTask returns the task of taskKey.
+func (c *Client) GetTask(ctx context.Context, taskKey string) (*model.Task, error) {
+	resp, err := c.get(ctx, "/v1/tasks/get", url.Values{"task_key": {taskKey}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Data *model.Task `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode task: %w", err)
+	}
+	return body.Data, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.addr+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.opts.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.token)
+	}
+	resp, err := c.opts.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &StatusError{Code: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	return resp, nil
+}
+
+// StatusError is returned when scheduler responds with a non 200 status.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("scheduler responds %d: %s", e.Code, e.Body)
+}
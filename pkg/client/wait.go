@@ -0,0 +1,80 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+// WaitForTerminal blocks until taskKey reaches a final status (success, failed or stop)
+// and returns the task, whose Msg carries the result or error of the run.
+// it follows the watch api, and falls back to polling GetTask once watch is unavailable.
+// use ctx to bound the wait, eg. context.WithTimeout.
+func (c *Client) WaitForTerminal(ctx context.Context, taskKey string) (*model.Task, error) {
+	task, err := c.watchUntilTerminal(ctx, taskKey)
+	if task != nil || ctx.Err() != nil {
+		return task, ctx.Err()
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.Code != 404 && statusErr.Code < 500 {
+		// eg. unauthorized, polling would fail the same way.
+		return nil, err
+	}
+	return c.pollUntilTerminal(ctx, taskKey)
+}
+
+// watchUntilTerminal returns the terminal task, nil if watch ends before it.
+func (c *Client) watchUntilTerminal(ctx context.Context, taskKey string) (*model.Task, error) {
+	resp, err := c.get(ctx, "/v1/tasks/watch", url.Values{"task_key": {taskKey}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// server-sent events, only data lines are used.
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var event struct {
+			Task *model.Task `json:"task"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			continue
+		}
+		if event.Task != nil && event.Task.Status.IsFinalStatus() {
+			return event.Task, nil
+		}
+	}
+	return nil, scanner.Err()
+}
+
+func (c *Client) pollUntilTerminal(ctx context.Context, taskKey string) (*model.Task, error) {
+	ticker := time.NewTicker(c.opts.pollInterval)
+	defer ticker.Stop()
+	for {
+		task, err := c.GetTask(ctx, taskKey)
+		if err == nil && task != nil && task.Status.IsFinalStatus() {
+			return task, nil
+		}
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.Code < 500 {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
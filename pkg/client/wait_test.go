@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/model"
+)
+
+func TestWaitForTerminal(t *testing.T) {
+	t.Run("通过 watch 等待终态", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/tasks/watch" {
+				t.Errorf("unexpected request %s", r.URL.Path)
+				return
+			}
+			for _, status := range []model.TaskStatus{model.TaskStatusRunning, model.TaskStatusSuccess} {
+				b, _ := json.Marshal(map[string]any{"task": model.Task{TaskKey: "t1", Status: status, Msg: "done"}})
+				fmt.Fprintf(w, "event:task\ndata:%s\n\n", b)
+				w.(http.Flusher).Flush()
+			}
+			<-r.Context().Done()
+		}))
+		defer srv.Close()
+
+		task, err := New(srv.URL).WaitForTerminal(context.Background(), "t1")
+		if err != nil || task.Status != model.TaskStatusSuccess || task.Msg != "done" {
+			t.Fatalf("WaitForTerminal() = %+v, %v", task, err)
+		}
+	})
+
+	t.Run("watch 不可用时轮询", func(t *testing.T) {
+		var polls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v1/tasks/watch" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			status := model.TaskStatusRunning
+			if polls.Add(1) >= 3 {
+				status = model.TaskStatusFailed
+			}
+			json.NewEncoder(w).Encode(map[string]any{"data": model.Task{TaskKey: "t1", Status: status}})
+		}))
+		defer srv.Close()
+
+		c := New(srv.URL, WithPollInterval(10*time.Millisecond))
+		task, err := c.WaitForTerminal(context.Background(), "t1")
+		if err != nil || task.Status != model.TaskStatusFailed {
+			t.Fatalf("WaitForTerminal() = %+v, %v", task, err)
+		}
+	})
+
+	t.Run("超时返回", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer srv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if _, err := New(srv.URL).WaitForTerminal(ctx, "t1"); err != context.DeadlineExceeded {
+			t.Fatalf("WaitForTerminal() err = %v, want deadline exceeded", err)
+		}
+	})
+}
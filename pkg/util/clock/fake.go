@@ -0,0 +1,116 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock implements Clock, time only moves forward when Step or SetTime is called.
+type FakeClock struct {
+	lock    sync.RWMutex
+	time    time.Time
+	waiters []*fakeWaiter
+}
+
+var _ Clock = &FakeClock{}
+
+type fakeWaiter struct {
+	target   time.Time
+	period   time.Duration // non-zero for ticker.
+	ch       chan time.Time
+	stopped  bool
+	fireOnce bool
+}
+
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{time: t}
+}
+
+func (f *FakeClock) Now() time.Time {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.time
+}
+
+func (f *FakeClock) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+func (f *FakeClock) After(d time.Duration) <-chan time.Time {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	ch := make(chan time.Time, 1)
+	f.waiters = append(f.waiters, &fakeWaiter{target: f.time.Add(d), ch: ch, fireOnce: true})
+	return ch
+}
+
+func (f *FakeClock) NewTicker(d time.Duration) Ticker {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	w := &fakeWaiter{target: f.time.Add(d), period: d, ch: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, w)
+	return &fakeTicker{clock: f, waiter: w}
+}
+
+// Sleep blocks until the clock is stepped over d.
+func (f *FakeClock) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// Step moves the clock by d and fires all expired waiters.
+func (f *FakeClock) Step(d time.Duration) {
+	f.SetTime(f.Now().Add(d))
+}
+
+// SetTime sets the clock to t and fires all expired waiters.
+func (f *FakeClock) SetTime(t time.Time) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.time = t
+
+	remain := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.stopped {
+			continue
+		}
+		if w.target.After(t) {
+			remain = append(remain, w)
+			continue
+		}
+		// like time.Ticker, drop ticks for slow receivers.
+		select {
+		case w.ch <- t:
+		default:
+		}
+		if w.fireOnce {
+			continue
+		}
+		for !w.target.After(t) {
+			w.target = w.target.Add(w.period)
+		}
+		remain = append(remain, w)
+	}
+	f.waiters = remain
+}
+
+// HasWaiters returns true if After, Sleep or Ticker is waiting on the clock.
+func (f *FakeClock) HasWaiters() bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return len(f.waiters) > 0
+}
+
+type fakeTicker struct {
+	clock  *FakeClock
+	waiter *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.waiter.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+	t.waiter.stopped = true
+}
@@ -0,0 +1,53 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("After 到期后触发", func(t *testing.T) {
+		c := NewFakeClock(start)
+		ch := c.After(time.Second)
+		c.Step(500 * time.Millisecond)
+		select {
+		case <-ch:
+			t.Fatal("不应该提前触发")
+		default:
+		}
+		c.Step(500 * time.Millisecond)
+		select {
+		case got := <-ch:
+			if !got.Equal(start.Add(time.Second)) {
+				t.Errorf("期望 %v, 得到 %v", start.Add(time.Second), got)
+			}
+		default:
+			t.Fatal("应该触发")
+		}
+		if c.HasWaiters() {
+			t.Error("触发后不应该还有 waiter")
+		}
+	})
+
+	t.Run("Ticker 周期触发并可停止", func(t *testing.T) {
+		c := NewFakeClock(start)
+		ticker := c.NewTicker(time.Second)
+		for i := 0; i < 3; i++ {
+			c.Step(time.Second)
+			select {
+			case <-ticker.C():
+			default:
+				t.Fatalf("第 %d 次应该触发", i+1)
+			}
+		}
+		ticker.Stop()
+		c.Step(time.Second)
+		select {
+		case <-ticker.C():
+			t.Fatal("停止后不应该触发")
+		default:
+		}
+	})
+}
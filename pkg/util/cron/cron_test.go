@@ -0,0 +1,83 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, expr := range []string{"* * * * *", "*/5 1-3 1,15 * 1-5", "0 0 * * 7", "@daily", "5/10 * * * *"} {
+		if _, err := Parse(expr); err != nil {
+			t.Errorf("%q 解析失败: %v", expr, err)
+		}
+	}
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("%q 期望解析失败", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	utc := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"* * * * *", "2024-01-01 10:00", "2024-01-01 10:01"},
+		{"30 2 * * *", "2024-01-01 10:00", "2024-01-02 02:30"},
+		{"0 0 1 * *", "2024-01-15 00:00", "2024-02-01 00:00"},
+		// 2024-01-06 is saturday.
+		{"0 9 * * 1-5", "2024-01-05 10:00", "2024-01-08 09:00"},
+		// dom or dow when both are restricted, 2024-03-04 is monday.
+		{"0 0 15 * 1", "2024-03-01 00:00", "2024-03-04 00:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"@hourly", "2024-12-31 23:59", "2025-01-01 00:00"},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Next(utc(tt.from)); !got.Equal(utc(tt.want)) {
+			t.Errorf("%q from %s 期望 %s, 得到 %s", tt.expr, tt.from, tt.want, got)
+		}
+	}
+}
+
+func TestNextDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("缺少时区数据: %v", err)
+	}
+
+	t.Run("夏令时跳过的时间不触发", func(t *testing.T) {
+		// 2024-03-10 02:00-03:00 does not exist.
+		s, _ := Parse("30 2 * * *")
+		got := s.Next(time.Date(2024, 3, 9, 12, 0, 0, 0, loc))
+		want := time.Date(2024, 3, 11, 2, 30, 0, 0, loc)
+		if !got.Equal(want) {
+			t.Fatalf("期望 %s, 得到 %s", want, got)
+		}
+	})
+
+	t.Run("重复的时间只触发一次", func(t *testing.T) {
+		// 2024-11-03 01:00-02:00 happens twice.
+		s, _ := Parse("30 1 * * *")
+		first := s.Next(time.Date(2024, 11, 3, 0, 0, 0, 0, loc))
+		if first.Hour() != 1 || first.Minute() != 30 {
+			t.Fatalf("期望 01:30, 得到 %s", first)
+		}
+		second := s.Next(first)
+		if second.Day() != 4 {
+			t.Fatalf("期望下一次在 11-04, 得到 %s", second)
+		}
+	})
+}
@@ -0,0 +1,403 @@
+// Package graphql parses the subset of GraphQL queries needed to select
+// fields of read APIs: a single query operation with variables, aliases,
+// arguments and nested selections. fragments, directives and mutations
+// are not supported.
+package graphql
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// Field is a selected field of a query.
+type Field struct {
+	Alias  string
+	Name   string
+	Args   map[string]any
+	Fields []*Field
+}
+
+// Key returns the key of the field in the response.
+func (f *Field) Key() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// MaxDepth is the max nesting depth of selections, values and types of a query.
+const MaxDepth = 32
+
+// Parse returns top level fields of the query operation, variables
+// referenced by arguments are replaced by their values. variables is not
+// modified, default values are applied to a copy.
+func Parse(query string, variables map[string]any) ([]*Field, error) {
+	p := &parser{lex: lexer{src: query}, vars: maps.Clone(variables)}
+	p.next()
+	fields, err := p.document()
+	if err != nil {
+		return nil, fmt.Errorf("graphql: %v at offset %d", err, p.tok.pos)
+	}
+	return fields, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokName
+	tokPunct
+	tokString
+	tokNumber
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) next() (token, error) {
+	// skip ignored tokens: white space, commas and comments.
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '#' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ',' {
+			break
+		}
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("{}()[]:!$=", c) >= 0:
+		l.pos++
+		return token{kind: tokPunct, text: string(c), pos: start}, nil
+	case c == '"':
+		l.pos++
+		for l.pos < len(l.src) && l.src[l.pos] != '"' {
+			if l.src[l.pos] == '\\' {
+				l.pos++
+			}
+			l.pos++
+		}
+		if l.pos >= len(l.src) {
+			return token{}, fmt.Errorf("unterminated string")
+		}
+		l.pos++
+		s, err := strconv.Unquote(l.src[start:l.pos])
+		if err != nil {
+			return token{}, fmt.Errorf("invalid string %s", l.src[start:l.pos])
+		}
+		return token{kind: tokString, text: s, pos: start}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		l.pos++
+		for l.pos < len(l.src) && strings.IndexByte("0123456789.eE+-", l.src[l.pos]) >= 0 {
+			l.pos++
+		}
+		return token{kind: tokNumber, text: l.src[start:l.pos], pos: start}, nil
+	case c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z'):
+		for l.pos < len(l.src) {
+			c := l.src[l.pos]
+			if c != '_' && !(c|0x20 >= 'a' && c|0x20 <= 'z') && !(c >= '0' && c <= '9') {
+				break
+			}
+			l.pos++
+		}
+		return token{kind: tokName, text: l.src[start:l.pos], pos: start}, nil
+	}
+	return token{}, fmt.Errorf("unexpected character %q", c)
+}
+
+type parser struct {
+	lex   lexer
+	tok   token
+	err   error
+	vars  map[string]any
+	depth int
+}
+
+// enter increases the nesting depth, it fails beyond MaxDepth so that
+// deeply nested queries can not exhaust the stack. call leave when done.
+func (p *parser) enter() error {
+	if p.depth++; p.depth > MaxDepth {
+		return fmt.Errorf("query nested deeper than %d", MaxDepth)
+	}
+	return nil
+}
+
+func (p *parser) leave() { p.depth-- }
+
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lex.next()
+}
+
+func (p *parser) is(text string) bool {
+	return p.err == nil && p.tok.kind == tokPunct && p.tok.text == text
+}
+
+func (p *parser) expect(text string) error {
+	if p.err != nil {
+		return p.err
+	}
+	if !p.is(text) {
+		return fmt.Errorf("expected %q, got %q", text, p.tok.text)
+	}
+	p.next()
+	return p.err
+}
+
+func (p *parser) name() (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	if p.tok.kind != tokName {
+		return "", fmt.Errorf("expected name, got %q", p.tok.text)
+	}
+	name := p.tok.text
+	p.next()
+	return name, p.err
+}
+
+func (p *parser) document() ([]*Field, error) {
+	if p.tok.kind == tokName {
+		if p.tok.text != "query" {
+			return nil, fmt.Errorf("unsupported operation %q", p.tok.text)
+		}
+		p.next()
+		if p.tok.kind == tokName {
+			p.next()
+		}
+		if p.is("(") {
+			if err := p.variableDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	fields, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q after query", p.tok.text)
+	}
+	return fields, nil
+}
+
+// variableDefinitions applies default values of variables not provided, types are not checked.
+func (p *parser) variableDefinitions() error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	for !p.is(")") {
+		if err := p.expect("$"); err != nil {
+			return err
+		}
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		if err := p.typeRef(); err != nil {
+			return err
+		}
+		if p.is("=") {
+			p.next()
+			v, err := p.value()
+			if err != nil {
+				return err
+			}
+			if _, ok := p.vars[name]; !ok {
+				if p.vars == nil {
+					p.vars = make(map[string]any)
+				}
+				p.vars[name] = v
+			}
+		}
+	}
+	return p.expect(")")
+}
+
+func (p *parser) typeRef() error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+	if p.is("[") {
+		p.next()
+		if err := p.typeRef(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.is("!") {
+		p.next()
+	}
+	return p.err
+}
+
+func (p *parser) selectionSet() ([]*Field, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var fields []*Field
+	for !p.is("}") {
+		f, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty selection")
+	}
+	return fields, p.expect("}")
+}
+
+func (p *parser) field() (*Field, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	f := &Field{Name: name}
+	if p.is(":") {
+		p.next()
+		if f.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+		f.Alias = name
+	}
+	if p.is("(") {
+		if f.Args, err = p.arguments(); err != nil {
+			return nil, err
+		}
+	}
+	if p.is("{") {
+		if f.Fields, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (p *parser) arguments() (map[string]any, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	args := make(map[string]any)
+	for !p.is(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if args[name], err = p.value(); err != nil {
+			return nil, err
+		}
+	}
+	return args, p.expect(")")
+}
+
+func (p *parser) value() (any, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	tok := p.tok
+	switch {
+	case p.is("$"):
+		p.next()
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		return p.vars[name], nil
+	case p.is("["):
+		p.next()
+		var list []any
+		for !p.is("]") {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, p.expect("]")
+	case p.is("{"):
+		p.next()
+		obj := make(map[string]any)
+		for !p.is("}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if obj[name], err = p.value(); err != nil {
+				return nil, err
+			}
+		}
+		return obj, p.expect("}")
+	case tok.kind == tokString:
+		p.next()
+		return tok.text, p.err
+	case tok.kind == tokNumber:
+		p.next()
+		if n, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return n, p.err
+		}
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", tok.text)
+		}
+		return f, p.err
+	case tok.kind == tokName:
+		p.next()
+		switch tok.text {
+		case "true":
+			return true, p.err
+		case "false":
+			return false, p.err
+		case "null":
+			return nil, p.err
+		}
+		// enum value.
+		return tok.text, p.err
+	}
+	return nil, fmt.Errorf("unexpected %q", tok.text)
+}
@@ -0,0 +1,71 @@
+package graphql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("字段、别名、参数和嵌套选择", func(t *testing.T) {
+		fields, err := Parse(`{
+			failed: tasks(biz_type: "x", status: [failed, "stop"], limit: 10, labels: {env: "prod"}) {
+				task_key
+				attempts { worker_id }
+			}
+		}`, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := fields[0]
+		if f.Key() != "failed" || f.Name != "tasks" || len(f.Fields) != 2 || f.Fields[1].Fields[0].Name != "worker_id" {
+			t.Fatalf("解析结果错误: %+v", f)
+		}
+		want := map[string]any{
+			"biz_type": "x",
+			"status":   []any{"failed", "stop"},
+			"limit":    int64(10),
+			"labels":   map[string]any{"env": "prod"},
+		}
+		if !reflect.DeepEqual(f.Args, want) {
+			t.Errorf("期望参数 %v, 得到 %v", want, f.Args)
+		}
+	})
+
+	t.Run("变量及默认值", func(t *testing.T) {
+		vars := map[string]any{"key": "k1"}
+		fields, err := Parse(`query Q($key: String!, $n: Int = 5) { task(key: $key, n: $n) { status } }`, vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if args := fields[0].Args; args["key"] != "k1" || args["n"] != int64(5) {
+			t.Errorf("变量替换错误: %v", args)
+		}
+		if _, ok := vars["n"]; ok || len(vars) != 1 {
+			t.Errorf("默认值不应写入调用方的变量: %v", vars)
+		}
+	})
+
+	t.Run("语法错误", func(t *testing.T) {
+		for _, q := range []string{`{ tasks { } }`, `mutation { x }`, `{ task(key: "a" { status } }`, `{ a } b`} {
+			if _, err := Parse(q, nil); err == nil {
+				t.Errorf("期望 %s 解析失败", q)
+			}
+		}
+	})
+
+	t.Run("嵌套过深", func(t *testing.T) {
+		for _, q := range []string{
+			strings.Repeat("{ a ", MaxDepth+1) + strings.Repeat("}", MaxDepth+1),
+			`{ a(x: ` + strings.Repeat("[", MaxDepth+1) + strings.Repeat("]", MaxDepth+1) + `) }`,
+			`query($x: ` + strings.Repeat("[", MaxDepth+1) + "Int" + strings.Repeat("]", MaxDepth+1) + `) { a }`,
+		} {
+			if _, err := Parse(q, nil); err == nil {
+				t.Errorf("期望 %.40s... 解析失败", q)
+			}
+		}
+		if _, err := Parse(strings.Repeat("{ a ", MaxDepth)+strings.Repeat("}", MaxDepth), nil); err != nil {
+			t.Errorf("期望 %d 层嵌套解析成功: %v", MaxDepth, err)
+		}
+	})
+}
@@ -18,6 +18,7 @@ package retry
 import (
 	"time"
 
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
 	"github.com/xyzbit/minitaskx/pkg/util/wait"
 )
 
@@ -45,12 +46,22 @@ func Do(fn func() error) error {
 	return OnError(DefaultBackoff, func(error) bool { return true }, fn)
 }
 
+// DoWithClock is Do sleeping on c between retries.
+func DoWithClock(c clock.Clock, fn func() error) error {
+	return OnErrorWithClock(DefaultBackoff, c, func(error) bool { return true }, fn)
+}
+
 // OnError allows the caller to retry fn in case the error returned by fn is retriable
 // according to the provided function. backoff defines the maximum retries and the wait
 // interval between two retries.
 func OnError(backoff wait.Backoff, retriable func(error) bool, fn func() error) error {
+	return OnErrorWithClock(backoff, clock.RealClock{}, retriable, fn)
+}
+
+// OnErrorWithClock is OnError sleeping on c between retries.
+func OnErrorWithClock(backoff wait.Backoff, c clock.Clock, retriable func(error) bool, fn func() error) error {
 	var lastErr error
-	err := wait.ExponentialBackoff(backoff, func() (bool, error) {
+	err := wait.ExponentialBackoffWithClock(backoff, c, func() (bool, error) {
 		err := fn()
 		switch {
 		case err == nil:
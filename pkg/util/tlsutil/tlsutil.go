@@ -0,0 +1,232 @@
+// Package tlsutil builds mutual TLS configs whose certificates are reloaded from disk,
+// so rotated certificates take effect without restarting the process.
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/log"
+)
+
+const (
+	defaultReloadInterval = time.Minute
+	dialTimeout           = 10 * time.Second
+)
+
+type Files struct {
+	CertFile string
+	KeyFile  string
+	// CA used to verify the peer.
+	CAFile string
+}
+
+// FilesFromEnv returns files set by $MINITASKX_TLS_CERT, $MINITASKX_TLS_KEY
+// and $MINITASKX_TLS_CA, false if none of them is set.
+func FilesFromEnv() (Files, bool) {
+	files := Files{
+		CertFile: os.Getenv("MINITASKX_TLS_CERT"),
+		KeyFile:  os.Getenv("MINITASKX_TLS_KEY"),
+		CAFile:   os.Getenv("MINITASKX_TLS_CA"),
+	}
+	return files, files != Files{}
+}
+
+// Reloader holds the current certificate and CA pool, and reloads them when files change.
+type Reloader struct {
+	files Files
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime time.Time
+
+	stopCh chan struct{}
+	once   sync.Once
+}
+
+// NewReloader load files and check them every interval, interval <= 0 means one minute.
+func NewReloader(files Files, interval time.Duration) (*Reloader, error) {
+	r := &Reloader{files: files, stopCh: make(chan struct{})}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	go r.watch(interval)
+	return r, nil
+}
+
+func (r *Reloader) Stop() {
+	r.once.Do(func() { close(r.stopCh) })
+}
+
+// ServerConfig requires and verifies client certificate.
+func (r *Reloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := r.current()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+				ClientCAs:    pool,
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+			}, nil
+		},
+	}
+}
+
+// ClientConfig presents client certificate and verifies server by the reloadable CA.
+// the server certificate must be valid for serverName, if empty, for the name
+// set by the dialer, eg. host of the url by http.Transport. connections without
+// any server name are refused, HTTPClient also verifies ip addresses.
+func (r *Reloader) ClientConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			return cert, nil
+		},
+		// RootCAs can not be reloaded, so verification is done in VerifyConnection.
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("server certificate is missing")
+			}
+			name := serverName
+			if name == "" {
+				name = cs.ServerName
+			}
+			if name == "" {
+				return errors.New("server name is required to verify server certificate")
+			}
+			_, pool := r.current()
+			intermediates := x509.NewCertPool()
+			for _, c := range cs.PeerCertificates[1:] {
+				intermediates.AddCert(c)
+			}
+			_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         pool,
+				DNSName:       name,
+				Intermediates: intermediates,
+			})
+			return err
+		},
+	}
+}
+
+// HTTPClient returns a client of mutual TLS, servers are verified by the host
+// of dialed addresses, ip addresses included. timeout 0 means no timeout.
+func (r *Reloader) HTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				host, _, err := net.SplitHostPort(addr)
+				if err != nil {
+					return nil, err
+				}
+				d := &tls.Dialer{NetDialer: dialer, Config: r.ClientConfig(host)}
+				return d.DialContext(ctx, network, addr)
+			},
+			TLSHandshakeTimeout: dialTimeout,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.pool
+}
+
+func (r *Reloader) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.C:
+			changed, err := r.changed()
+			if err != nil {
+				log.Error("[TLS] stat certificate files failed: %v", err)
+				continue
+			}
+			if !changed {
+				continue
+			}
+			if err := r.reload(); err != nil {
+				log.Error("[TLS] reload certificate failed, keep the old one: %v", err)
+				continue
+			}
+			log.Info("[TLS] certificate reloaded")
+		}
+	}
+}
+
+func (r *Reloader) changed() (bool, error) {
+	latest, err := r.latestModTime()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return latest.After(r.modTime), nil
+}
+
+func (r *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, f := range []string{r.files.CertFile, r.files.KeyFile, r.files.CAFile} {
+		info, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *Reloader) reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.files.CertFile, r.files.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair: %w", err)
+	}
+	caPEM, err := os.ReadFile(r.files.CAFile)
+	if err != nil {
+		return fmt.Errorf("read ca: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return fmt.Errorf("no valid certificate in %s", r.files.CAFile)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.pool = pool
+	r.modTime = modTime
+	return nil
+}
@@ -0,0 +1,225 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// issue signs a certificate by parent, a self signed CA if parent is nil.
+func issue(t *testing.T, name string, parent *testCert, isCA bool, hosts ...string) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// write writes files of leaf, chain is appended to the certificate file.
+func write(t *testing.T, dir string, ca, leaf *testCert, chain ...*testCert) Files {
+	t.Helper()
+	files := Files{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+		CAFile:   filepath.Join(dir, "ca.pem"),
+	}
+	var certPEM []byte
+	for _, c := range append([]*testCert{leaf}, chain...) {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der})...)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(leaf.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, files.CertFile, certPEM)
+	writeFile(t, files.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	writeFile(t, files.CAFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.der}))
+	return files
+}
+
+func writeFile(t *testing.T, name string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newReloader(t *testing.T, files Files, interval time.Duration) *Reloader {
+	t.Helper()
+	r, err := NewReloader(files, interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(r.Stop)
+	return r
+}
+
+// serve starts a mutual TLS server, it responds with the common name of the client.
+func serve(t *testing.T, r *Reloader) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.TLS = r.ServerConfig()
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestReloader(t *testing.T) {
+	ca := issue(t, "ca", nil, true)
+	server := newReloader(t, write(t, t.TempDir(), ca, issue(t, "server", ca, false, "127.0.0.1", "localhost")), 0)
+	srv := serve(t, server)
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	t.Run("双向认证按拨号地址校验服务端", func(t *testing.T) {
+		client := newReloader(t, write(t, t.TempDir(), ca, issue(t, "client", ca, false)), 0)
+		for _, host := range []string{"127.0.0.1", "localhost"} {
+			resp, err := client.HTTPClient(time.Second).Get("https://" + net.JoinHostPort(host, port))
+			if err != nil {
+				t.Fatalf("访问 %s 失败: %v", host, err)
+			}
+			resp.Body.Close()
+		}
+	})
+
+	t.Run("证书链经中间证书校验", func(t *testing.T) {
+		intermediate := issue(t, "intermediate", ca, true)
+		client := newReloader(t, write(t, t.TempDir(), ca, issue(t, "client", intermediate, false), intermediate), 0)
+		resp, err := client.HTTPClient(time.Second).Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		// without the intermediate the chain is broken.
+		broken := newReloader(t, write(t, t.TempDir(), ca, issue(t, "client", intermediate, false)), 0)
+		if _, err := broken.HTTPClient(time.Second).Get(srv.URL); err == nil {
+			t.Fatal("期望缺少中间证书时握手失败")
+		}
+	})
+
+	t.Run("拒绝其他 CA 签发的证书", func(t *testing.T) {
+		other := issue(t, "other", nil, true)
+		client := newReloader(t, write(t, t.TempDir(), other, issue(t, "client", other, false)), 0)
+		if _, err := client.HTTPClient(time.Second).Get(srv.URL); err == nil {
+			t.Fatal("期望校验服务端证书失败")
+		}
+	})
+
+	t.Run("主机名不匹配", func(t *testing.T) {
+		client := newReloader(t, write(t, t.TempDir(), ca, issue(t, "client", ca, false)), 0)
+		conn, err := tls.Dial("tcp", srv.Listener.Addr().String(), client.ClientConfig("scheduler.example.com"))
+		if err == nil {
+			conn.Close()
+			t.Fatal("期望主机名不匹配时握手失败")
+		}
+	})
+
+	t.Run("缺少服务端名称时拒绝连接", func(t *testing.T) {
+		client := newReloader(t, write(t, t.TempDir(), ca, issue(t, "client", ca, false)), 0)
+		// no server name is sent when dialing an ip address.
+		conn, err := tls.Dial("tcp", srv.Listener.Addr().String(), client.ClientConfig(""))
+		if err == nil {
+			conn.Close()
+			t.Fatal("期望没有服务端名称时握手失败")
+		}
+	})
+
+	t.Run("证书轮换后重新加载", func(t *testing.T) {
+		dir := t.TempDir()
+		files := write(t, dir, ca, issue(t, "client-v1", ca, false))
+		client := newReloader(t, files, 10*time.Millisecond)
+		hc := client.HTTPClient(time.Second)
+		commonName := func() string {
+			t.Helper()
+			resp, err := hc.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			var b [64]byte
+			n, _ := resp.Body.Read(b[:])
+			hc.CloseIdleConnections()
+			return string(b[:n])
+		}
+		if got := commonName(); got != "client-v1" {
+			t.Fatalf("期望 client-v1, 得到 %s", got)
+		}
+
+		write(t, dir, ca, issue(t, "client-v2", ca, false))
+		// mod time of some file systems is in seconds.
+		future := time.Now().Add(time.Second)
+		os.Chtimes(files.CertFile, future, future)
+		deadline := time.Now().Add(2 * time.Second)
+		for commonName() != "client-v2" {
+			if time.Now().After(deadline) {
+				t.Fatal("期望轮换后使用新证书")
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	})
+
+	t.Run("加载失败时保留旧证书", func(t *testing.T) {
+		dir := t.TempDir()
+		files := write(t, dir, ca, issue(t, "client", ca, false))
+		client := newReloader(t, files, 10*time.Millisecond)
+		old, _ := client.current()
+
+		writeFile(t, files.KeyFile, []byte("broken"))
+		future := time.Now().Add(time.Second)
+		os.Chtimes(files.KeyFile, future, future)
+		time.Sleep(50 * time.Millisecond)
+		if cert, _ := client.current(); cert != old {
+			t.Fatal("期望保留旧证书")
+		}
+	})
+}
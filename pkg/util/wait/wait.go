@@ -4,6 +4,8 @@ import (
 	"errors"
 	"math/rand/v2"
 	"time"
+
+	"github.com/xyzbit/minitaskx/pkg/util/clock"
 )
 
 var ErrWaitTimeout = errors.New("timed out waiting for the condition")
@@ -94,6 +96,11 @@ func Jitter(duration time.Duration, maxFactor float64) time.Duration {
 type ConditionFunc func() (done bool, err error)
 
 func ExponentialBackoff(backoff Backoff, condition ConditionFunc) error {
+	return ExponentialBackoffWithClock(backoff, clock.RealClock{}, condition)
+}
+
+// ExponentialBackoffWithClock is ExponentialBackoff sleeping on c.
+func ExponentialBackoffWithClock(backoff Backoff, c clock.Clock, condition ConditionFunc) error {
 	for backoff.Steps > 0 {
 		if ok, err := condition(); err != nil || ok {
 			return err
@@ -101,7 +108,7 @@ func ExponentialBackoff(backoff Backoff, condition ConditionFunc) error {
 		if backoff.Steps == 1 {
 			break
 		}
-		time.Sleep(backoff.Step())
+		c.Sleep(backoff.Step())
 	}
 	return ErrWaitTimeout
 }
@@ -0,0 +1,152 @@
+// Package minitaskx wires components into ready to run deployments.
+package minitaskx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/xyzbit/minitaskx/core/components/auth"
+	"github.com/xyzbit/minitaskx/core/components/discover"
+	"github.com/xyzbit/minitaskx/core/components/election"
+	"github.com/xyzbit/minitaskx/core/components/log"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo/memory"
+	"github.com/xyzbit/minitaskx/core/components/taskrepo/sqlite"
+	"github.com/xyzbit/minitaskx/core/scheduler"
+	"github.com/xyzbit/minitaskx/core/worker"
+	"github.com/xyzbit/minitaskx/pkg/util/tlsutil"
+)
+
+// max time the http server waits for requests in flight once ctx is done.
+const httpShutdownTimeout = 5 * time.Second
+
+// how often stats of the pool of the sql database are published.
+const poolStatsInterval = 15 * time.Second
+
+// Config of RunStandalone.
+type Config struct {
+	// database/sql driver and DSN of the SQLite repo, the driver must be
+	// registered by importing it, eg. _ "modernc.org/sqlite". DSN default
+	// an in-memory database. if both Driver and Repo are empty, tasks are
+	// kept in memory of the process.
+	Driver string
+	DSN    string
+	// Repo is used instead of SQLite if set, eg. a MySQL repo of a small deployment.
+	Repo taskrepo.Interface
+
+	// Addr serves apis of both scheduler and worker, eg. ":8080",
+	// empty disables them.
+	Addr string
+	// if nil, apis are open to anyone can reach Addr.
+	Authenticator auth.Authenticator
+	// if set, Addr is served by mutual TLS, clients must present
+	// certificates signed by the CA.
+	TLS *tlsutil.Reloader
+
+	// id of the worker and the scheduler, default "standalone".
+	ID               string
+	SchedulerOptions []scheduler.Option
+	WorkerOptions    []worker.Option
+}
+
+func (c Config) withDefaults() Config {
+	if c.DSN == "" {
+		c.DSN = ":memory:"
+	}
+	if c.ID == "" {
+		c.ID = "standalone"
+	}
+	return c
+}
+
+// RunStandalone runs a scheduler and a worker in one process until ctx is
+// done, instances and leadership are kept in memory, so that tests, demos
+// and small deployments need no distributed infrastructure. executors are
+// registered as usual before it is called.
+func RunStandalone(ctx context.Context, cfg Config) error {
+	cfg = cfg.withDefaults()
+	repo := cfg.Repo
+	switch {
+	case repo != nil:
+	case cfg.Driver == "":
+		repo = memory.New()
+	default:
+		db, err := sql.Open(cfg.Driver, cfg.DSN)
+		if err != nil {
+			return fmt.Errorf("open %s database: %v", cfg.Driver, err)
+		}
+		defer db.Close()
+		sqliteRepo, err := sqlite.New(db, sqlite.WithPoolStats(cfg.Driver, poolStatsInterval))
+		if err != nil {
+			return err
+		}
+		defer sqliteRepo.Close()
+		repo = sqliteRepo
+	}
+
+	ip, port, err := splitAddr(cfg.Addr)
+	if err != nil {
+		return err
+	}
+	registry := discover.NewLocal()
+	s, err := scheduler.NewScheduler(election.NewLocal(cfg.ID), registry, repo, cfg.SchedulerOptions...)
+	if err != nil {
+		return err
+	}
+	w := worker.NewWorker(cfg.ID, ip, port, registry, repo, cfg.WorkerOptions...)
+	// controllers of the scheduler run until the process exits.
+	if err := s.Run(); err != nil {
+		return err
+	}
+
+	if cfg.Addr != "" {
+		r := gin.New()
+		r.Use(gin.Recovery())
+		s.HttpServer().RegisterRoutes(r, cfg.Authenticator)
+		worker.NewHttpServer(w).RegisterRoutes(r, cfg.Authenticator)
+		srv := &http.Server{Addr: cfg.Addr, Handler: r}
+		serve := srv.ListenAndServe
+		if cfg.TLS != nil {
+			srv.TLSConfig = cfg.TLS.ServerConfig()
+			serve = func() error { return srv.ListenAndServeTLS("", "") }
+		}
+		go func() {
+			if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error("[Standalone] serve http on %s failed: %v", cfg.Addr, err)
+			}
+		}()
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+			defer cancel()
+			srv.Shutdown(ctx)
+		}()
+	}
+	return w.Run(ctx)
+}
+
+// splitAddr returns the ip and port the worker registers with.
+func splitAddr(addr string) (string, int, error) {
+	if addr == "" {
+		return "127.0.0.1", 0, nil
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid addr %q: %v", addr, err)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port of addr %q: %v", addr, err)
+	}
+	return host, p, nil
+}
@@ -0,0 +1,68 @@
+package minitaskx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/minitaskx/core/components/taskrepo/memory"
+	"github.com/xyzbit/minitaskx/core/model"
+	"github.com/xyzbit/minitaskx/core/worker"
+	"github.com/xyzbit/minitaskx/core/worker/executor"
+	"github.com/xyzbit/minitaskx/core/worker/executor/testexec"
+)
+
+func TestRunStandalone(t *testing.T) {
+	t.Run("未注册的驱动返回错误", func(t *testing.T) {
+		if err := RunStandalone(context.Background(), Config{Driver: "no-such-driver"}); err == nil {
+			t.Fatal("期望返回错误")
+		}
+	})
+
+	t.Run("运行任务直到完成", func(t *testing.T) {
+		registry := executor.NewRegistry()
+		testexec.RegisterTo(registry)
+		repo := memory.New(memory.WithWatchInterval(10 * time.Millisecond))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- RunStandalone(ctx, Config{
+				Repo:          repo,
+				WorkerOptions: []worker.Option{worker.WithExecutorRegistry(registry)},
+			})
+		}()
+		defer func() {
+			cancel()
+			<-done
+		}()
+
+		task := &model.Task{TaskKey: "noop", Type: testexec.TypeNoop, Status: model.TaskStatusWaitScheduling}
+		if err := repo.CreateTask(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			got, err := repo.GetTask(ctx, task.TaskKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status == model.TaskStatusSuccess {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("期望任务执行成功, 状态 %s", got.Status)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	})
+
+	t.Run("解析监听地址", func(t *testing.T) {
+		if ip, port, err := splitAddr(":8080"); err != nil || ip != "127.0.0.1" || port != 8080 {
+			t.Fatalf("期望 127.0.0.1:8080, 得到 %s:%d, %v", ip, port, err)
+		}
+		if _, _, err := splitAddr("localhost"); err == nil {
+			t.Fatal("缺少端口应返回错误")
+		}
+	})
+}